@@ -0,0 +1,84 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/jetstack/version-checker/pkg/admission"
+	"github.com/jetstack/version-checker/pkg/policy"
+)
+
+// AdmissionOptions is a struct to hold options for the admission webhook.
+type AdmissionOptions struct {
+	ServingAddress string
+	TLSCertFile    string
+	TLSKeyFile     string
+	Mode           string
+	AllowedTags    string
+	MaxTagAge      time.Duration
+}
+
+func (a *AdmissionOptions) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&a.ServingAddress,
+		"admission-serving-address", "",
+		"Address to serve the admission webhooks of pods on over TLS, "+
+			"validating at the /validate path, and resolving tags to digests at "+
+			"the /mutate path. Disabled if empty.")
+
+	fs.StringVar(&a.TLSCertFile,
+		"admission-tls-cert-file", "",
+		"File of the TLS certificate the admission webhook is served with.")
+
+	fs.StringVar(&a.TLSKeyFile,
+		"admission-tls-key-file", "",
+		"File of the TLS private key the admission webhook is served with.")
+
+	fs.StringVar(&a.Mode,
+		"admission-mode", string(admission.ModeDeny),
+		"What the admission webhook does with pods violating the image policy "+
+			"(deny, warn). Warned pods are admitted, with the violations logged "+
+			"and recorded as an audit annotation.")
+
+	fs.StringVar(&a.AllowedTags,
+		"admission-allowed-tags", "",
+		"Regex the tags of admitted containers must match, such as "+
+			`^v\d+\.\d+\.\d+$.`)
+
+	fs.DurationVar(&a.MaxTagAge,
+		"admission-max-tag-age", 0,
+		"Maximum age of the tags of admitted containers, since they were "+
+			"pushed, such as 2160h. Disabled if 0.")
+}
+
+// options returns the options of the admission webhook.
+func (a *AdmissionOptions) options(defaultTestAll bool, excludeTagRegexes []string) (admission.Options, error) {
+	mode := admission.Mode(a.Mode)
+	if mode != admission.ModeDeny && mode != admission.ModeWarn {
+		return admission.Options{}, fmt.Errorf("unsupported --admission-mode %q, must be %s or %s",
+			a.Mode, admission.ModeDeny, admission.ModeWarn)
+	}
+
+	if len(a.TLSCertFile) == 0 || len(a.TLSKeyFile) == 0 {
+		return admission.Options{}, fmt.Errorf("--admission-tls-cert-file and --admission-tls-key-file are required")
+	}
+
+	opts := admission.Options{
+		Mode:              mode,
+		Policy:            policy.Policy{MaxAge: a.MaxTagAge},
+		DefaultTestAll:    defaultTestAll,
+		ExcludeTagRegexes: excludeTagRegexes,
+	}
+
+	if len(a.AllowedTags) > 0 {
+		allowedTags, err := regexp.Compile(a.AllowedTags)
+		if err != nil {
+			return admission.Options{}, fmt.Errorf("invalid --admission-allowed-tags %q: %s", a.AllowedTags, err)
+		}
+		opts.Policy.AllowedTags = allowedTags
+	}
+
+	return opts, nil
+}
@@ -4,17 +4,28 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" // Load all auth plugins
 
+	"github.com/jetstack/version-checker/pkg/admission"
+	"github.com/jetstack/version-checker/pkg/cache"
 	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/client/gitlab"
+	"github.com/jetstack/version-checker/pkg/client/oci"
 	"github.com/jetstack/version-checker/pkg/controller"
 	"github.com/jetstack/version-checker/pkg/metrics"
+	"github.com/jetstack/version-checker/pkg/notify"
+	"github.com/jetstack/version-checker/pkg/query"
+	vcversion "github.com/jetstack/version-checker/pkg/version"
 )
 
 const (
@@ -22,22 +33,68 @@ const (
 
 	helpOutput = "Kubernetes utility for exposing used image versions compared to the latest version, as metrics."
 
-	envPrefix         = "VERSION_CHECKER"
-	envGCRAccessToken = "GCR_TOKEN"
-	envDockerUsername = "DOCKER_USERNAME"
-	envDockerPassword = "DOCKER_PASSWORD"
-	envDockerJWT      = "DOCKER_TOKEN"
-	envQuayToken      = "QUAY_TOKEN"
+	envPrefix                 = "VERSION_CHECKER"
+	envGCRAccessToken         = "GCR_TOKEN"
+	envGCRTokenFile           = "GCR_TOKEN_FILE"
+	envArtifactRegistryToken  = "ARTIFACT_REGISTRY_TOKEN"
+	envArtifactRegistryKey    = "ARTIFACT_REGISTRY_SERVICE_ACCOUNT_KEY_FILE"
+	envGHCRUsername           = "GHCR_USERNAME"
+	envGHCRToken              = "GHCR_TOKEN"
+	envGitHubToken            = "GITHUB_TOKEN"
+	envACRUsername            = "ACR_USERNAME"
+	envACRPassword            = "ACR_PASSWORD"
+	envArtifactoryAPIKey      = "ARTIFACTORY_API_KEY"
+	envArtifactoryAccessToken = "ARTIFACTORY_ACCESS_TOKEN"
+	envGitLabUsername         = "GITLAB_USERNAME"
+	envGitLabToken            = "GITLAB_TOKEN"
+	envHarborUsername         = "HARBOR_USERNAME"
+	envHarborPassword         = "HARBOR_PASSWORD"
+	envDOCRToken              = "DOCR_TOKEN"
+	envECRAccessKeyID         = "ECR_ACCESS_KEY_ID"
+	envECRSecretAccessKey     = "ECR_SECRET_ACCESS_KEY"
+	envECRSessionToken        = "ECR_SESSION_TOKEN"
+	envDockerUsername         = "DOCKER_USERNAME"
+	envDockerPassword         = "DOCKER_PASSWORD"
+	envDockerJWT              = "DOCKER_TOKEN"
+	envNexusUsername          = "NEXUS_USERNAME"
+	envNexusPassword          = "NEXUS_PASSWORD"
+	envNexusToken             = "NEXUS_TOKEN"
+	envQuayToken              = "QUAY_TOKEN"
+	envQuayTokenFile          = "QUAY_TOKEN_FILE"
+	envRedisPassword          = "REDIS_PASSWORD"
 )
 
 // Options is a struct to hold options for the version-checker
 type Options struct {
-	MetricsServingAddress string
-	DefaultTestAll        bool
-	CacheTimeout          time.Duration
-	LogLevel              string
+	MetricsServingAddress        string
+	QueryServingAddress          string
+	DefaultTestAll               bool
+	UsePullSecrets               bool
+	RegistryCredentials          bool
+	RegistryCredentialsNamespace string
+	Policies                     bool
+	ArgoRollouts                 bool
+	Knative                      bool
+	Workloads                    []string
+	CacheTimeout                 time.Duration
+	RegistryCacheTimeouts        map[string]string
+	LogLevel                     string
+	SnapshotDir                  string
+	CacheBackend                 string
+	Workers                      int
+	ExcludeTagRegexes            []string
+	EnrichmentConcurrency        int
+	TagPolicyConfigMap           string
+	IncludeNamespaces            []string
+	ExcludeNamespaces            []string
+	PodSelector                  string
 
-	Client client.Options
+	Redis      cache.RedisOptions
+	Client     client.Options
+	Notify     NotifyOptions
+	Admission  AdmissionOptions
+	GitOps     GitOpsOptions
+	AutoUpdate AutoUpdateOptions
 }
 
 func NewCommand(ctx context.Context) *cobra.Command {
@@ -51,16 +108,11 @@ func NewCommand(ctx context.Context) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.checkEnv()
 
-			logLevel, err := logrus.ParseLevel(opts.LogLevel)
+			log, err := opts.newLogger()
 			if err != nil {
-				return fmt.Errorf("failed to parse --log-level %q: %s",
-					opts.LogLevel, err)
+				return err
 			}
 
-			nlog := logrus.New()
-			nlog.SetLevel(logLevel)
-			log := logrus.NewEntry(nlog)
-
 			restConfig, err := kubeConfigFlags.ToRESTConfig()
 			if err != nil {
 				return fmt.Errorf("failed to build kubernetes rest config: %s", err)
@@ -76,6 +128,18 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				return fmt.Errorf("failed to start metrics server: %s", err)
 			}
 
+			opts.Client.ObserveRequestDuration = metrics.ObserveRegistryRequestDuration
+			opts.Client.Docker.ObserveRateLimit = metrics.ObserveDockerHubRateLimit
+			opts.Client.ObserveNegativeCache = metrics.ObserveNegativeCache
+			if len(opts.SnapshotDir) > 0 {
+				store, err := client.NewFileSnapshotStore(opts.SnapshotDir)
+				if err != nil {
+					return err
+				}
+				opts.Client.SnapshotStore = store
+			}
+
+			opts.Client.Log = log
 			client, err := client.New(ctx, opts.Client)
 			if err != nil {
 				return fmt.Errorf("failed to setup image registry clients: %s", err)
@@ -87,8 +151,150 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				}
 			}()
 
+			if len(opts.QueryServingAddress) > 0 {
+				queryServer := query.New(log, metrics)
+				if err := queryServer.Run(opts.QueryServingAddress); err != nil {
+					return fmt.Errorf("failed to start query server: %s", err)
+				}
+
+				defer func() {
+					if err := queryServer.Shutdown(); err != nil {
+						log.Error(err)
+					}
+				}()
+			}
+
+			var dynamicClient, policyClient, workloadClient dynamic.Interface
+			watchWorkloads := opts.ArgoRollouts || opts.Knative || len(opts.Workloads) > 0
+			if opts.RegistryCredentials || opts.Policies || watchWorkloads {
+				kubeDynamicClient, err := dynamic.NewForConfig(restConfig)
+				if err != nil {
+					return fmt.Errorf("failed to build kubernetes dynamic client: %s", err)
+				}
+
+				if opts.RegistryCredentials {
+					dynamicClient = kubeDynamicClient
+				}
+				if opts.Policies {
+					policyClient = kubeDynamicClient
+				}
+				if watchWorkloads {
+					workloadClient = kubeDynamicClient
+				}
+			}
+
+			var tagCache cache.Cache
+			switch opts.CacheBackend {
+			case "memory":
+				tagCache = cache.NewMemory()
+			case "redis":
+				tagCache, err = cache.NewRedis(opts.Redis)
+				if err != nil {
+					return fmt.Errorf("failed to setup redis cache: %s", err)
+				}
+			default:
+				return fmt.Errorf("unsupported --cache-backend %q, must be memory or redis", opts.CacheBackend)
+			}
+
+			for _, excludeRegex := range opts.ExcludeTagRegexes {
+				if _, err := regexp.Compile(excludeRegex); err != nil {
+					return fmt.Errorf("invalid --exclude-tag-regex %q: %s", excludeRegex, err)
+				}
+			}
+
+			podSelector, err := labels.Parse(opts.PodSelector)
+			if err != nil {
+				return fmt.Errorf("invalid --pod-selector %q: %s", opts.PodSelector, err)
+			}
+
+			registryCacheTimeouts := make(map[string]time.Duration)
+			for host, value := range opts.RegistryCacheTimeouts {
+				timeout, err := time.ParseDuration(value)
+				if err != nil || timeout <= 0 {
+					return fmt.Errorf("invalid --registry-cache-timeout of %q, must be a positive duration: %q", host, value)
+				}
+				registryCacheTimeouts[host] = timeout
+			}
+
+			if len(opts.Admission.ServingAddress) > 0 {
+				admissionOpts, err := opts.Admission.options(opts.DefaultTestAll, opts.ExcludeTagRegexes)
+				if err != nil {
+					return err
+				}
+
+				// Lookups share the tag cache of image checks.
+				tags := vcversion.New(log, client, opts.CacheTimeout, tagCache)
+				admissionServer := admission.New(log, tags, client, admissionOpts)
+				if err := admissionServer.Run(opts.Admission.ServingAddress,
+					opts.Admission.TLSCertFile, opts.Admission.TLSKeyFile); err != nil {
+					return fmt.Errorf("failed to start admission webhook server: %s", err)
+				}
+
+				defer func() {
+					if err := admissionServer.Shutdown(); err != nil {
+						log.Error(err)
+					}
+				}()
+			}
+
+			senders, err := opts.Notify.senders()
+			if err != nil {
+				return fmt.Errorf("failed to setup notifiers: %s", err)
+			}
+			if len(senders) > 0 {
+				dispatcher := notify.NewDispatcher(log, senders, opts.Notify.Dispatcher)
+				go dispatcher.Run(ctx, metrics)
+			}
+
+			if len(opts.Notify.Alertmanager.URL) > 0 {
+				alertmanager, err := notify.NewAlertmanager(log, opts.Notify.Alertmanager)
+				if err != nil {
+					return fmt.Errorf("failed to setup alertmanager notifier: %s", err)
+				}
+				go alertmanager.Run(ctx, metrics)
+			}
+
+			if len(opts.Notify.Email.SMTPAddr) > 0 {
+				email, err := opts.Notify.email(ctx, log, kubeClient)
+				if err != nil {
+					return fmt.Errorf("failed to setup email notifier: %s", err)
+				}
+				go email.Run(ctx, metrics)
+			}
+
+			gitOpsUpdater, err := opts.GitOps.updater(log, kubeClient)
+			if err != nil {
+				return fmt.Errorf("failed to setup gitops: %s", err)
+			}
+			if gitOpsUpdater != nil {
+				go gitOpsUpdater.Run(ctx, metrics)
+			}
+
+			if opts.AutoUpdate.Enabled {
+				updater, shutdown := opts.AutoUpdate.updater(log, kubeClient)
+				defer shutdown()
+				go updater.Run(ctx, metrics)
+			}
+
 			c := controller.New(opts.CacheTimeout, metrics,
-				client, kubeClient, log, opts.DefaultTestAll)
+				client, kubeClient, log, opts.DefaultTestAll, controller.Options{
+					UsePullSecrets:               opts.UsePullSecrets,
+					DynamicClient:                dynamicClient,
+					RegistryCredentialsNamespace: opts.RegistryCredentialsNamespace,
+					TagCache:                     tagCache,
+					RegistryCacheTimeouts:        registryCacheTimeouts,
+					Workers:                      opts.Workers,
+					ExcludeTagRegexes:            opts.ExcludeTagRegexes,
+					TagPolicyConfigMap:           opts.TagPolicyConfigMap,
+					PolicyClient:                 policyClient,
+					IncludeNamespaces:            opts.IncludeNamespaces,
+					ExcludeNamespaces:            opts.ExcludeNamespaces,
+					PodSelector:                  podSelector,
+					WorkloadClient:               workloadClient,
+					WatchRollouts:                opts.ArgoRollouts,
+					WatchKnative:                 opts.Knative,
+					WorkloadSpecs:                opts.Workloads,
+				})
 			return c.Run(ctx)
 		},
 	}
@@ -96,70 +302,595 @@ func NewCommand(ctx context.Context) *cobra.Command {
 	kubeConfigFlags.AddFlags(cmd.PersistentFlags())
 	opts.addFlags(cmd)
 
+	cmd.AddCommand(newScanCommand(ctx, opts))
+	cmd.AddCommand(newCheckCommand(ctx, opts))
+
 	return cmd
 }
 
+// newLogger returns a logger of the --log-level.
+func (o *Options) newLogger() (*logrus.Entry, error) {
+	logLevel, err := logrus.ParseLevel(o.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse --log-level %q: %s",
+			o.LogLevel, err)
+	}
+
+	nlog := logrus.New()
+	nlog.SetLevel(logLevel)
+	return logrus.NewEntry(nlog), nil
+}
+
 func (o *Options) addFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVarP(&o.MetricsServingAddress,
 		"metrics-serving-address", "m", "0.0.0.0:8080",
-		"Address to serve metrics on at the /metrics path.")
+		"Address to serve metrics on at the /metrics path, and the results of "+
+			"image checks as JSON at the /api/v1/images path.")
+
+	cmd.PersistentFlags().StringVar(&o.QueryServingAddress,
+		"query-serving-address", "",
+		"Address to serve the gRPC image status query service on, streaming "+
+			"changes to the results of image checks. Disabled if empty.")
 
 	cmd.PersistentFlags().BoolVarP(&o.DefaultTestAll,
 		"test-all-containers", "a", false,
 		`If enable, all containers will be tested, unless they have the annotation `+
 			`"enable.version-checker/${my-container}=false".`)
 
-	cmd.PersistentFlags().DurationVarP(&o.CacheTimeout,
+	cmd.PersistentFlags().BoolVar(&o.UsePullSecrets,
+		"use-pull-secrets", false,
+		"If enabled, images are looked up using the registry credentials of their "+
+			"pod's imagePullSecrets, in place of configured credentials, for the "+
+			"docker, harbor, nexus, ecr public and distribution API registries. "+
+			"Requires permission to get secrets.")
+
+	cmd.PersistentFlags().BoolVar(&o.ArgoRollouts,
+		"watch-argo-rollouts", false,
+		"If enabled, the pod templates of Argo Rollouts are checked, so that the "+
+			"images of paused and aborted revisions are reported whether or not "+
+			"their pods are running. Annotations of a Rollout take precedence over "+
+			"those of its pod template.")
+
+	cmd.PersistentFlags().BoolVar(&o.Knative,
+		"watch-knative", false,
+		"If enabled, the pod templates of Knative Services, and the specs of "+
+			"Knative Revisions receiving traffic, are checked, so that images of "+
+			"services scaled to zero are still reported.")
+
+	cmd.PersistentFlags().StringArrayVar(&o.Workloads,
+		"workload", nil,
+		"Additional resources whose images are checked, of the form "+
+			"group/version/Kind=jsonpath, e.g. monitoring.coreos.com/v1/Prometheus={.spec.image}. "+
+			"The JSONPath may find more than one image. May be given more than once.")
+
+	cmd.PersistentFlags().StringSliceVar(&o.IncludeNamespaces,
+		"include-namespaces", nil,
+		"Namespaces whose pods are checked, e.g. prod,payments. Pods of every "+
+			"namespace are checked if empty.")
+
+	cmd.PersistentFlags().StringSliceVar(&o.ExcludeNamespaces,
+		"exclude-namespaces", nil,
+		"Namespaces whose pods are never checked, e.g. kube-system.")
+
+	cmd.PersistentFlags().StringVar(&o.PodSelector,
+		"pod-selector", "",
+		"Label selector of pods to check, e.g. tier=production,!canary. Pods "+
+			"which no longer match are removed from metrics.")
+
+	cmd.PersistentFlags().BoolVar(&o.RegistryCredentials,
+		"watch-registry-credentials", false,
+		"If enabled, RegistryCredential resources are watched, and the credentials "+
+			"of the secrets they reference used for lookups against their registry "+
+			"host. Pull secrets of a pod take precedence. Requires the "+
+			"RegistryCredential CRD to be installed.")
+
+	cmd.PersistentFlags().StringVar(&o.RegistryCredentialsNamespace,
+		"registry-credentials-namespace", "version-checker",
+		"The only namespace of secrets which RegistryCredential resources may "+
+			"reference. Resources referencing secrets in other namespaces are skipped.")
+
+	cmd.PersistentFlags().BoolVar(&o.Policies,
+		"watch-policies", false,
+		"If enabled, VersionCheckPolicy resources are watched, and their options "+
+			"applied to the containers of the namespaces and images they select. "+
+			"Annotations of pods take precedence. Requires the VersionCheckPolicy "+
+			"CRD to be installed.")
+
+	cmd.PersistentFlags().IntVar(&o.Workers,
+		"workers", 5,
+		"Number of pods processed concurrently. Lookups against registries with "+
+			"a --registry-rate-limit wait for the limit, or are requeued.")
+
+	cmd.PersistentFlags().StringVar(&o.TagPolicyConfigMap,
+		"tag-policy-configmap", "",
+		"The namespace/name of a ConfigMap holding the allowed and denied tags "+
+			"of repositories, watched for changes. Only allowed tags are "+
+			"considered when searching for the latest image of a repository.")
+
+	cmd.PersistentFlags().StringToStringVar(&o.RegistryCacheTimeouts,
+		"registry-cache-timeout", nil,
+		"Map of registry host to the image cache timeout of its images, in place "+
+			"of --image-cache-timeout, e.g. registry.corp=5m,docker.io=2h. The "+
+			`"cache-timeout.version-checker.io/${my-container}" annotation takes `+
+			"precedence.")
+
+	cmd.PersistentFlags().StringVar(&o.CacheBackend,
+		"cache-backend", "memory",
+		"Backend of the image tags cache (memory, redis). Tags cached in redis "+
+			"are shared between replicas and survive restarts, expiring after "+
+			"--image-cache-timeout.")
+
+	cmd.PersistentFlags().StringVar(&o.Redis.Address,
+		"redis-address", "",
+		"Address (host:port) of the redis server of the redis cache backend.")
+	cmd.PersistentFlags().StringVar(&o.Redis.Username,
+		"redis-username", "",
+		"Username to authenticate with the redis server, using redis ACLs.")
+	cmd.PersistentFlags().StringVar(&o.Redis.Password,
+		"redis-password", "",
+		fmt.Sprintf(
+			"Password to authenticate with the redis server (%s_%s).",
+			envPrefix, envRedisPassword,
+		))
+	cmd.PersistentFlags().IntVar(&o.Redis.Database,
+		"redis-database", 0,
+		"Redis database of the redis cache backend.")
+	cmd.PersistentFlags().StringVar(&o.Redis.KeyPrefix,
+		"redis-key-prefix", "version-checker:",
+		"Prefix of all keys stored in redis.")
+	cmd.PersistentFlags().BoolVar(&o.Redis.TLS,
+		"redis-tls", false,
+		"Connect to the redis server over TLS.")
+
+	cmd.PersistentFlags().StringVar(&o.SnapshotDir,
+		"snapshot-dir", "",
+		"Directory to persist state between restarts, such as the tags seen "+
+			"pointing at each image digest. State is not kept if unset.")
+
+	o.Notify.addFlags(cmd.PersistentFlags())
+	o.Admission.addFlags(cmd.PersistentFlags())
+	o.GitOps.addFlags(cmd.PersistentFlags())
+	o.AutoUpdate.addFlags(cmd.PersistentFlags())
+	o.addLookupFlags(cmd.PersistentFlags())
+}
+
+// addScanFlags adds the flags of batch scans of images, shared by the commands
+// checking images without the controller.
+func (o *Options) addScanFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&o.EnrichmentConcurrency,
+		"enrichment-concurrency", 0,
+		"Maximum number of concurrent per tag requests, such as manifest "+
+			"fetches for tag timestamps, across all images checked. Set to 0 "+
+			"for no limit.")
+}
+
+// addLookupFlags adds the flags of image lookups against registries, shared by
+// the controller and the commands checking images without it.
+func (o *Options) addLookupFlags(fs *pflag.FlagSet) {
+	fs.DurationVarP(&o.CacheTimeout,
 		"image-cache-timeout", "c", time.Minute*30,
 		"The time for an image in the cache to be considered fresh. Images will be "+
 			"checked at this interval.")
 
-	cmd.PersistentFlags().StringVarP(&o.LogLevel,
+	fs.StringToStringVar(&o.Client.RateLimits,
+		"registry-rate-limit", nil,
+		"Map of registry host to the number of image lookups per second allowed "+
+			"against it, e.g. docker.io=0.5. Pods whose lookups would wait "+
+			"longer than 5s for the limit are requeued.")
+
+	fs.StringArrayVar(&o.ExcludeTagRegexes,
+		"exclude-tag-regex", nil,
+		"Regex of tags to exclude from the latest image search of every image, "+
+			"such as ^nightly- or -debug$. May be given more than once. "+
+			`Applied alongside the "exclude-regex.version-checker.io/${my-container}" `+
+			"annotation.")
+
+	fs.DurationVar(&o.Client.NegativeCacheTimeout,
+		"image-not-found-cache-timeout", time.Minute*5,
+		"The time to remember that an image repository was not found, skipping "+
+			"requests to the registry for that image. Set to 0 to disable.")
+
+	fs.StringVarP(&o.LogLevel,
 		"log-level", "v", "info",
 		"Log level (debug, info, warn, error, fatal, panic).")
 
-	cmd.PersistentFlags().StringVar(&o.Client.GCR.Token,
+	fs.BoolVar(&o.Client.VerifyDigests,
+		"verify-digests", false,
+		"Verify the content of fetched image manifests matches their digest.")
+
+	fs.StringSliceVar(&o.Client.DigestAlgorithms,
+		"digest-algorithms", oci.DefaultDigestAlgorithms,
+		"Digest algorithms allowed when verifying digests. Digests using any "+
+			"other algorithm will fail verification.")
+
+	fs.BoolVar(&o.Client.AllowDuplicatePlatforms,
+		"allow-duplicate-platforms", false,
+		"Use the first manifest of a platform listed more than once in a "+
+			"manifest list, rather than failing the lookup as ambiguous.")
+
+	fs.StringToStringVar(&o.Client.CABundles,
+		"registry-ca-bundle", nil,
+		"Map of registry host to a CA bundle file the registry's certificate is "+
+			"verified against, e.g. registry.example.com=/etc/ssl/example-ca.pem. "+
+			"Hosts may include a port.")
+
+	fs.StringToStringVar(&o.Client.ClientCertificates,
+		"registry-client-cert", nil,
+		"Map of registry host to a client certificate file presented to the "+
+			"registry for mutual TLS, e.g. registry.example.com=/etc/tls/tls.crt. "+
+			"Requires a --registry-client-key for the same host.")
+
+	fs.StringToStringVar(&o.Client.ClientKeys,
+		"registry-client-key", nil,
+		"Map of registry host to the key file of its --registry-client-cert, "+
+			"e.g. registry.example.com=/etc/tls/tls.key.")
+
+	fs.StringSliceVar(&o.Client.InsecureSkipVerifyHosts,
+		"registry-insecure-skip-verify", nil,
+		"Registry hosts whose TLS certificates are not verified. Hosts may "+
+			"include a port. Prefer --registry-ca-bundle where possible.")
+
+	fs.StringToStringVar(&o.Client.TLSServerNames,
+		"registry-tls-server-name", nil,
+		"Map of registry host to the server name the registry's certificate is "+
+			"verified against, when connecting to registries by IP or through an "+
+			"SNI routing proxy, e.g. 10.0.0.5:5000=registry.corp.")
+
+	fs.StringToStringVar(&o.Client.Proxies,
+		"registry-proxy", nil,
+		"Map of registry host to the URL of the proxy its requests are made "+
+			"through, or 'direct' to bypass the proxy, e.g. "+
+			"registry.corp=direct. Other hosts use the HTTP_PROXY, HTTPS_PROXY "+
+			"and NO_PROXY environment variables.")
+
+	fs.IntVar(&o.Client.RetryMaxAttempts,
+		"registry-retry-max-attempts", 3,
+		"Total number of attempts of registry requests failing with a network "+
+			"error, 429 or 5xx response. Set to 1 to disable retries.")
+
+	fs.DurationVar(&o.Client.RetryBackoff,
+		"registry-retry-backoff", time.Millisecond*200,
+		"Base time waited before retrying a registry request, doubled for "+
+			"every following attempt with jitter. Retry-After headers of 429 "+
+			"and 503 responses take precedence.")
+
+	fs.IntVar(&o.Client.CircuitBreakerThreshold,
+		"registry-circuit-breaker-threshold", 5,
+		"Number of consecutive failed lookups of a registry host after which "+
+			"lookups against it are stopped, serving the last tags seen of each "+
+			"image. Set to 0 to disable the circuit breaker.")
+
+	fs.DurationVar(&o.Client.CircuitBreakerCooldown,
+		"registry-circuit-breaker-cooldown", time.Minute,
+		"Time after the circuit breaker of a registry host opens before a probe "+
+			"lookup is let through, closing the circuit if it succeeds.")
+
+	fs.BoolVar(&o.Client.LazyAuth,
+		"registry-lazy-auth", false,
+		"Request manifests before authenticating, only requesting a token when "+
+			"challenged by the registry. Saves a round trip for registries "+
+			"allowing anonymous pulls. Docker Hub is always authenticated first.")
+
+	fs.DurationVar(&o.Client.CoalesceWindow,
+		"registry-coalesce-window", 0,
+		"Window in which lookups of the same image share one request to the "+
+			"registry, smoothing bursts of lookups. Set to 0 to disable.")
+
+	fs.StringToStringVar(&o.Client.Mirrors,
+		"registry-mirror", nil,
+		"Map of image prefix to the registry mirror its tags are looked up "+
+			"against, e.g. docker.io=mirror.corp/docker. Prefixes are a registry "+
+			"host, optionally followed by a repository path, and the longest "+
+			"matching prefix is used. Metrics still report the original image.")
+
+	fs.StringVar(&o.Client.DockerConfigFile,
+		"docker-config-file", "",
+		"Path of a docker config.json whose credentials and credential helpers "+
+			"(credHelpers and credsStore) are used to authenticate to registry "+
+			"hosts without credentials from image pull secrets. Helpers must be "+
+			"installed as docker-credential-<name> on the PATH.")
+
+	fs.StringSliceVar(&o.Client.RedactPatterns,
+		"redact-pattern", nil,
+		"Regular expressions of credentials to redact from error messages, in "+
+			"addition to URL credentials, authorization headers and tokens.")
+
+	fs.StringVar(&o.Client.GCR.Token,
 		"gcr-token", "",
 		fmt.Sprintf(
 			"Access token for read access to private GCR registries (%s_%s).",
 			envPrefix, envGCRAccessToken,
 		))
 
-	cmd.PersistentFlags().StringVar(&o.Client.Quay.Token,
+	fs.StringVar(&o.Client.GCR.TokenFile,
+		"gcr-token-file", "",
+		fmt.Sprintf(
+			"Path to a file containing the GCR access token, which is re-read as "+
+				"it is refreshed externally. Takes precedence over --gcr-token (%s_%s).",
+			envPrefix, envGCRTokenFile,
+		))
+	fs.BoolVar(&o.Client.GCR.UseApplicationDefaultCredentials,
+		"gcr-use-application-default-credentials", false,
+		"Authenticate with GCR using the application default credentials, such as "+
+			"GKE Workload Identity, if no GCR token is set.")
+
+	fs.StringVar(&o.Client.ArtifactRegistry.Token,
+		"artifact-registry-token", "",
+		fmt.Sprintf(
+			"Access token for read access to private Artifact Registry "+
+				"repositories (%s_%s).",
+			envPrefix, envArtifactRegistryToken,
+		))
+	fs.StringVar(&o.Client.ArtifactRegistry.ServiceAccountKeyFile,
+		"artifact-registry-service-account-key-file", "",
+		fmt.Sprintf(
+			"Path to a service account JSON key, exchanged for access tokens to "+
+				"Artifact Registry (%s_%s).",
+			envPrefix, envArtifactRegistryKey,
+		))
+	fs.BoolVar(&o.Client.ArtifactRegistry.UseMetadataServer,
+		"artifact-registry-use-metadata-server", false,
+		"Request access tokens to Artifact Registry from the GCE metadata server, "+
+			"if no other Artifact Registry credentials are set.")
+	fs.BoolVar(&o.Client.ArtifactRegistry.UseApplicationDefaultCredentials,
+		"artifact-registry-use-application-default-credentials", false,
+		"Authenticate with Artifact Registry using the application default "+
+			"credentials, such as GKE Workload Identity, if no other Artifact "+
+			"Registry credentials are set.")
+
+	fs.StringVar(&o.Client.GHCR.Username,
+		"ghcr-username", "",
+		fmt.Sprintf(
+			"Username to authenticate with the GitHub Container Registry (%s_%s).",
+			envPrefix, envGHCRUsername,
+		))
+	fs.StringVar(&o.Client.GHCR.Token,
+		"ghcr-token", "",
+		fmt.Sprintf(
+			"Personal access token with read:packages scope, or GITHUB_TOKEN, to "+
+				"authenticate with the GitHub Container Registry (%s_%s, or %s).",
+			envPrefix, envGHCRToken, envGitHubToken,
+		))
+
+	fs.StringVar(&o.Client.Quay.Token,
 		"quay-token", "",
 		fmt.Sprintf(
 			"Access token for read access to private Quay registries (%s_%s).",
 			envPrefix, envQuayToken,
 		))
+	fs.StringVar(&o.Client.Quay.TokenFile,
+		"quay-token-file", "",
+		fmt.Sprintf(
+			"Path to a file containing the Quay access token, which is re-read as "+
+				"it is refreshed externally. Takes precedence over --quay-token (%s_%s).",
+			envPrefix, envQuayTokenFile,
+		))
 
-	cmd.PersistentFlags().StringVar(&o.Client.Docker.Username,
+	fs.StringVar(&o.Client.Docker.Username,
 		"docker-username", "",
 		fmt.Sprintf(
 			"Username is authenticate with docker registry (%s_%s).",
 			envPrefix, envDockerUsername,
 		))
-	cmd.PersistentFlags().StringVar(&o.Client.Docker.Password,
+	fs.StringVar(&o.Client.Docker.Password,
 		"docker-password", "",
 		fmt.Sprintf(
 			"Password is authenticate with docker registry (%s_%s).",
 			envPrefix, envDockerPassword,
 		))
-	cmd.PersistentFlags().StringVar(&o.Client.Docker.JWT,
+	fs.StringVar(&o.Client.Docker.JWT,
 		"docker-token", "",
 		fmt.Sprintf(
 			"Token is authenticate with docker registry. Cannot be used with "+
 				"username/password (%s_%s).",
 			envPrefix, envDockerJWT,
 		))
-	cmd.PersistentFlags().StringVar(&o.Client.Docker.LoginURL,
+	fs.StringVar(&o.Client.Docker.LoginURL,
 		"docker-login-url", "https://hub.docker.com/v2/users/login/",
 		"URL to login into docker using username/password.")
+	fs.IntVar(&o.Client.Docker.RateLimitThreshold,
+		"docker-rate-limit-threshold", 10,
+		"Remaining Docker Hub rate limit budget below which Docker Hub requests "+
+			"are delayed by --docker-throttle-delay, slowing lookups until the "+
+			"budget recovers. Set to 0 to disable throttling.")
+	fs.DurationVar(&o.Client.Docker.ThrottleDelay,
+		"docker-throttle-delay", time.Second*5,
+		"Delay of Docker Hub requests while the remaining rate limit budget is "+
+			"below --docker-rate-limit-threshold.")
+	fs.IntVar(&o.Client.Docker.MaxPages,
+		"docker-max-pages", 0,
+		"Maximum number of Docker Hub tag pages to request per image lookup. "+
+			"Tags are requested most recently updated first. Set to 0 for no limit.")
+	fs.IntVar(&o.Client.Docker.MaxTags,
+		"docker-max-tags", 0,
+		"Maximum number of Docker Hub tags to collect per image lookup, "+
+			"keeping the most recently updated. Set to 0 for no limit.")
+
+	fs.StringSliceVar(&o.Client.GitLab.Hosts,
+		"gitlab-hosts", []string{gitlab.DefaultHost},
+		"Hosts of GitLab container registries, including self-managed "+
+			"instances. Images with these prefixes will be checked against GitLab.")
+	fs.StringToStringVar(&o.Client.GitLab.APIURLs,
+		"gitlab-api-urls", nil,
+		"URLs of the GitLab instance of each GitLab registry host, e.g. "+
+			"registry.gitlab.corp=https://gitlab.corp, whose registry API is used "+
+			"to list tags with their creation timestamps. Images of other hosts, "+
+			"or not readable by the token such as with deploy tokens, are listed "+
+			"with the distribution API. registry.gitlab.com uses https://gitlab.com.")
+	fs.StringVar(&o.Client.GitLab.Username,
+		"gitlab-username", "",
+		fmt.Sprintf(
+			"Username to authenticate with GitLab. Defaults to gitlab-ci-token "+
+				"when a token is set, for CI job tokens (%s_%s).",
+			envPrefix, envGitLabUsername,
+		))
+	fs.StringVar(&o.Client.GitLab.Token,
+		"gitlab-token", "",
+		fmt.Sprintf(
+			"Deploy token, access token or CI job token to authenticate with "+
+				"GitLab (%s_%s).",
+			envPrefix, envGitLabToken,
+		))
+
+	fs.StringVar(&o.Client.DOCR.Token,
+		"docr-token", "",
+		fmt.Sprintf(
+			"DigitalOcean API token with read access to DigitalOcean Container "+
+				"Registries (%s_%s).",
+			envPrefix, envDOCRToken,
+		))
+
+	fs.StringVar(&o.Client.ECR.AccessKeyID,
+		"ecr-access-key-id", "",
+		fmt.Sprintf(
+			"Access key ID to authenticate with private ECR registries. If unset, "+
+				"the default AWS credential chain is used, including web identity "+
+				"tokens of IAM Roles for Service Accounts (%s_%s).",
+			envPrefix, envECRAccessKeyID,
+		))
+	fs.StringVar(&o.Client.ECR.SecretAccessKey,
+		"ecr-secret-access-key", "",
+		fmt.Sprintf(
+			"Secret access key to authenticate with private ECR registries (%s_%s).",
+			envPrefix, envECRSecretAccessKey,
+		))
+	fs.StringVar(&o.Client.ECR.SessionToken,
+		"ecr-session-token", "",
+		fmt.Sprintf(
+			"Session token of temporary credentials to authenticate with private "+
+				"ECR registries (%s_%s).",
+			envPrefix, envECRSessionToken,
+		))
+	fs.StringToStringVar(&o.Client.ECR.AssumeRoleARNs,
+		"ecr-assume-role-arn", nil,
+		"Map of registry account ID to the ARN of a role to assume for lookups "+
+			"against ECR registries of that account, e.g. "+
+			"123456789012=arn:aws:iam::123456789012:role/version-checker.")
+
+	fs.StringVar(&o.Client.ACR.Username,
+		"acr-username", "",
+		fmt.Sprintf(
+			"Username of the admin user, a token or a service principal client ID "+
+				"to authenticate with Azure Container Registries (%s_%s).",
+			envPrefix, envACRUsername,
+		))
+	fs.StringVar(&o.Client.ACR.Password,
+		"acr-password", "",
+		fmt.Sprintf(
+			"Password or service principal client secret to authenticate with "+
+				"Azure Container Registries (%s_%s).",
+			envPrefix, envACRPassword,
+		))
+	fs.BoolVar(&o.Client.ACR.UseWorkloadIdentity,
+		"acr-use-workload-identity", false,
+		"Authenticate with Azure Container Registries using Azure Workload "+
+			"Identity, configured by the environment of the workload identity webhook.")
+	fs.BoolVar(&o.Client.ACR.UseManagedIdentity,
+		"acr-use-managed-identity", false,
+		"Authenticate with Azure Container Registries using the managed identity "+
+			"of the node, from the instance metadata service.")
+	fs.StringVar(&o.Client.ACR.ManagedIdentityClientID,
+		"acr-managed-identity-client-id", "",
+		"Client ID of a user assigned managed identity to use with "+
+			"--acr-use-managed-identity. Defaults to the system assigned identity.")
+
+	fs.StringVar(&o.Client.Harbor.Host,
+		"harbor-host", "",
+		"Host of the Harbor instance (harbor.corp). Images with this prefix will "+
+			"be checked against the Harbor API.")
+	fs.StringVar(&o.Client.Harbor.Username,
+		"harbor-username", "",
+		fmt.Sprintf(
+			"Username of a user or robot account to authenticate with Harbor (%s_%s).",
+			envPrefix, envHarborUsername,
+		))
+	fs.StringVar(&o.Client.Harbor.Password,
+		"harbor-password", "",
+		fmt.Sprintf(
+			"Password to authenticate with Harbor (%s_%s).",
+			envPrefix, envHarborPassword,
+		))
+
+	fs.StringVar(&o.Client.Artifactory.Host,
+		"artifactory-host", "",
+		"Host of the Artifactory instance (artifactory.corp), serving Docker "+
+			"repositories by repository path. Images with this prefix will be "+
+			"checked against Artifactory.")
+	fs.StringVar(&o.Client.Artifactory.APIKey,
+		"artifactory-api-key", "",
+		fmt.Sprintf(
+			"API key to authenticate with Artifactory (%s_%s).",
+			envPrefix, envArtifactoryAPIKey,
+		))
+	fs.StringVar(&o.Client.Artifactory.AccessToken,
+		"artifactory-access-token", "",
+		fmt.Sprintf(
+			"Access token to authenticate with Artifactory. Cannot be used with "+
+				"an API key (%s_%s).",
+			envPrefix, envArtifactoryAccessToken,
+		))
+
+	fs.StringVar(&o.Client.Nexus.Host,
+		"nexus-host", "",
+		"Host and port of the Nexus Docker connector (nexus.corp:8082). Images "+
+			"with this prefix will be checked against Nexus.")
+	fs.StringVar(&o.Client.Nexus.APIHost,
+		"nexus-api-host", "",
+		"Host and port of the Nexus REST API (nexus.corp:8081). If set, tags "+
+			"are listed from the Nexus components of images, timestamped by "+
+			"their lastModified time, without fetching the manifest of each tag.")
+	fs.StringVar(&o.Client.Nexus.Repository,
+		"nexus-repository", "",
+		"Name of the Nexus repository served by the Nexus Docker connector, "+
+			"used to look up components of images not using path-based routing.")
+	fs.StringVar(&o.Client.Nexus.Username,
+		"nexus-username", "",
+		fmt.Sprintf(
+			"Username to authenticate with Nexus (%s_%s).",
+			envPrefix, envNexusUsername,
+		))
+	fs.StringVar(&o.Client.Nexus.Password,
+		"nexus-password", "",
+		fmt.Sprintf(
+			"Password to authenticate with Nexus (%s_%s).",
+			envPrefix, envNexusPassword,
+		))
+	fs.StringVar(&o.Client.Nexus.Token,
+		"nexus-token", "",
+		fmt.Sprintf(
+			"Bearer token to authenticate with Nexus. Cannot be used with "+
+				"username/password (%s_%s).",
+			envPrefix, envNexusToken,
+		))
 }
 
 func (o *Options) checkEnv() {
+	o.Notify.checkEnv()
+	o.GitOps.checkEnv()
+
 	if len(o.Client.GCR.Token) == 0 {
 		o.Client.GCR.Token = os.Getenv(envPrefix + "_" + envGCRAccessToken)
 	}
+	if len(o.Client.GCR.TokenFile) == 0 {
+		o.Client.GCR.TokenFile = os.Getenv(envPrefix + "_" + envGCRTokenFile)
+	}
+
+	if len(o.Client.ArtifactRegistry.Token) == 0 {
+		o.Client.ArtifactRegistry.Token = os.Getenv(envPrefix + "_" + envArtifactRegistryToken)
+	}
+	if len(o.Client.ArtifactRegistry.ServiceAccountKeyFile) == 0 {
+		o.Client.ArtifactRegistry.ServiceAccountKeyFile = os.Getenv(envPrefix + "_" + envArtifactRegistryKey)
+	}
+
+	if len(o.Client.GHCR.Username) == 0 {
+		o.Client.GHCR.Username = os.Getenv(envPrefix + "_" + envGHCRUsername)
+	}
+	if len(o.Client.GHCR.Token) == 0 {
+		o.Client.GHCR.Token = os.Getenv(envPrefix + "_" + envGHCRToken)
+	}
+	if len(o.Client.GHCR.Token) == 0 {
+		o.Client.GHCR.Token = os.Getenv(envGitHubToken)
+	}
 
 	if len(o.Client.Docker.Username) == 0 {
 		o.Client.Docker.Username = os.Getenv(envPrefix + "_" + envDockerUsername)
@@ -171,7 +902,66 @@ func (o *Options) checkEnv() {
 		o.Client.Docker.JWT = os.Getenv(envPrefix + "_" + envDockerJWT)
 	}
 
+	if len(o.Client.GitLab.Username) == 0 {
+		o.Client.GitLab.Username = os.Getenv(envPrefix + "_" + envGitLabUsername)
+	}
+	if len(o.Client.GitLab.Token) == 0 {
+		o.Client.GitLab.Token = os.Getenv(envPrefix + "_" + envGitLabToken)
+	}
+
+	if len(o.Client.DOCR.Token) == 0 {
+		o.Client.DOCR.Token = os.Getenv(envPrefix + "_" + envDOCRToken)
+	}
+
+	if len(o.Client.ECR.AccessKeyID) == 0 {
+		o.Client.ECR.AccessKeyID = os.Getenv(envPrefix + "_" + envECRAccessKeyID)
+	}
+	if len(o.Client.ECR.SecretAccessKey) == 0 {
+		o.Client.ECR.SecretAccessKey = os.Getenv(envPrefix + "_" + envECRSecretAccessKey)
+	}
+	if len(o.Client.ECR.SessionToken) == 0 {
+		o.Client.ECR.SessionToken = os.Getenv(envPrefix + "_" + envECRSessionToken)
+	}
+
+	if len(o.Client.ACR.Username) == 0 {
+		o.Client.ACR.Username = os.Getenv(envPrefix + "_" + envACRUsername)
+	}
+	if len(o.Client.ACR.Password) == 0 {
+		o.Client.ACR.Password = os.Getenv(envPrefix + "_" + envACRPassword)
+	}
+
+	if len(o.Client.Harbor.Username) == 0 {
+		o.Client.Harbor.Username = os.Getenv(envPrefix + "_" + envHarborUsername)
+	}
+	if len(o.Client.Harbor.Password) == 0 {
+		o.Client.Harbor.Password = os.Getenv(envPrefix + "_" + envHarborPassword)
+	}
+
+	if len(o.Client.Artifactory.APIKey) == 0 {
+		o.Client.Artifactory.APIKey = os.Getenv(envPrefix + "_" + envArtifactoryAPIKey)
+	}
+	if len(o.Client.Artifactory.AccessToken) == 0 {
+		o.Client.Artifactory.AccessToken = os.Getenv(envPrefix + "_" + envArtifactoryAccessToken)
+	}
+
+	if len(o.Client.Nexus.Username) == 0 {
+		o.Client.Nexus.Username = os.Getenv(envPrefix + "_" + envNexusUsername)
+	}
+	if len(o.Client.Nexus.Password) == 0 {
+		o.Client.Nexus.Password = os.Getenv(envPrefix + "_" + envNexusPassword)
+	}
+	if len(o.Client.Nexus.Token) == 0 {
+		o.Client.Nexus.Token = os.Getenv(envPrefix + "_" + envNexusToken)
+	}
+
 	if len(o.Client.Quay.Token) == 0 {
 		o.Client.Quay.Token = os.Getenv(envPrefix + "_" + envQuayToken)
 	}
+	if len(o.Client.Quay.TokenFile) == 0 {
+		o.Client.Quay.TokenFile = os.Getenv(envPrefix + "_" + envQuayTokenFile)
+	}
+
+	if len(o.Redis.Password) == 0 {
+		o.Redis.Password = os.Getenv(envPrefix + "_" + envRedisPassword)
+	}
 }
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/autoupdate"
+)
+
+// AutoUpdateOptions is a struct to hold options for automatically updating
+// the images of workloads once outdated.
+type AutoUpdateOptions struct {
+	Enabled bool
+	DryRun  bool
+}
+
+func (a *AutoUpdateOptions) addFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&a.Enabled,
+		"auto-update", false,
+		fmt.Sprintf(
+			"If enabled, the Deployment or StatefulSet owning a pod is updated to "+
+				"the latest version of the image of a container once outdated, if "+
+				"the container has the %s/<container> annotation set to true. "+
+				"Intended for development clusters.",
+			api.EnableAutoUpdateAnnotationKey,
+		))
+
+	fs.BoolVar(&a.DryRun,
+		"auto-update-dry-run", false,
+		"If enabled, automatic updates are only recorded as events of the "+
+			"workloads, without updating them.")
+}
+
+// updater returns the updater of workload images, recording events with a
+// broadcaster which is shut down by the returned func.
+func (a *AutoUpdateOptions) updater(log *logrus.Entry, kubeClient kubernetes.Interface) (*autoupdate.Updater, func()) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: kubeClient.CoreV1().Events(""),
+	})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "version-checker"})
+
+	return autoupdate.New(log, kubeClient, recorder, autoupdate.Options{DryRun: a.DryRun}), broadcaster.Shutdown
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/scan"
+)
+
+const (
+	// checkContainerName is the container name the annotations of the check
+	// command's flags are set for.
+	checkContainerName = "image"
+)
+
+// checkAnnotationFlags are the flags of the check command equivalent to
+// container annotations, named by the annotation key without its domain.
+var checkAnnotationFlags = []struct {
+	key    string
+	isBool bool
+	usage  string
+}{
+	{api.UseSHAAnnotationKey, true, "Compare the image digest against the digest of the latest image."},
+	{api.UseMetaDataAnnotationKey, true, "Consider tags with metadata after the patch version, such as v1.0.1-gke.3."},
+	{api.MatchRegexAnnotationKey, false, "Only consider tags matching the regex."},
+	{api.ExcludeRegexAnnotationKey, false, "Never consider tags matching the regex, such as ^nightly-."},
+	{api.ChannelAnnotationKey, false, "Only consider tags of the release channel, being stable or a pre-release identifier such as rc."},
+	{api.PinMajorAnnotationKey, false, "Only consider tags of the major version."},
+	{api.PinMinorAnnotationKey, false, "Only consider tags of the minor version, requires --pin-major."},
+	{api.PinPatchAnnotationKey, false, "Only consider tags of the patch version, requires --pin-major and --pin-minor."},
+	{api.SemverConstraintAnnotationKey, false, `Only consider tags satisfying the semver range, such as ">=1.4 <2.0".`},
+	{api.TagOrderingAnnotationKey, false, "How tags are ordered (semver, debian, calver, numeric, lexical)."},
+	{api.BuildIDRegexAnnotationKey, false, "Regex whose first capture group is the build ID of tags, for the numeric and lexical tag orderings."},
+	{api.MatchOSAnnotationKey, false, "Only consider tags built for the OS, such as linux."},
+	{api.MatchArchitectureAnnotationKey, false, "Only consider tags built for the architecture, such as arm64."},
+}
+
+// CheckOptions is a struct to hold options for the check command.
+type CheckOptions struct {
+	Output         string
+	FailOnOutdated bool
+}
+
+func newCheckCommand(ctx context.Context, opts *Options) *cobra.Command {
+	checkOpts := new(CheckOptions)
+
+	cmd := &cobra.Command{
+		Use:   "check IMAGE[:TAG]",
+		Short: "Check an image against its registry.",
+		Long: "Find the latest version of an image, using flags equivalent to " +
+			"the version checker annotations of containers, and print it " +
+			"against the current version of the image, without a cluster. " +
+			"Images without a tag, or using the latest tag, only have their " +
+			"latest version found.",
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.checkEnv()
+
+			log, err := opts.newLogger()
+			if err != nil {
+				return err
+			}
+
+			if checkOpts.Output != scan.OutputTable && checkOpts.Output != scan.OutputJSON {
+				return fmt.Errorf("unsupported --output %q, must be %s or %s",
+					checkOpts.Output, scan.OutputTable, scan.OutputJSON)
+			}
+
+			annotations := make(map[string]string)
+			for _, flag := range checkAnnotationFlags {
+				name := strings.TrimSuffix(flag.key, api.AnnotationDomain)
+				if cmd.Flags().Changed(name) {
+					annotations[flag.key+"/"+checkContainerName] = cmd.Flags().Lookup(name).Value.String()
+				}
+			}
+
+			opts.Client.Log = log
+			client, err := client.New(ctx, opts.Client)
+			if err != nil {
+				return fmt.Errorf("failed to setup image registry clients: %s", err)
+			}
+
+			scanner := scan.New(log, client, opts.CacheTimeout, opts.ExcludeTagRegexes,
+				opts.EnrichmentConcurrency)
+			result := scanner.Check(ctx, scan.Container{
+				ContainerName: checkContainerName,
+				Image:         args[0],
+				Annotations:   annotations,
+			})
+
+			if err := scan.WriteCheckReport(os.Stdout, checkOpts.Output, result); err != nil {
+				return err
+			}
+
+			if len(result.Error) > 0 {
+				return fmt.Errorf("failed to check %q: %s", result.Image, result.Error)
+			}
+			if !result.Unpinned() && !result.IsLatest && checkOpts.FailOnOutdated {
+				return fmt.Errorf("%q is outdated, latest is %q", result.Image, result.LatestVersion)
+			}
+
+			return nil
+		},
+	}
+
+	for _, flag := range checkAnnotationFlags {
+		name := strings.TrimSuffix(flag.key, api.AnnotationDomain)
+		if flag.isBool {
+			cmd.Flags().Bool(name, false, flag.usage)
+		} else {
+			cmd.Flags().String(name, "", flag.usage)
+		}
+	}
+
+	cmd.Flags().StringVarP(&checkOpts.Output,
+		"output", "o", scan.OutputTable,
+		"Format of the result (table, json).")
+
+	cmd.Flags().BoolVar(&checkOpts.FailOnOutdated,
+		"fail-on-outdated", true,
+		"Exit with an error if the image is outdated.")
+
+	opts.addScanFlags(cmd.Flags())
+
+	return cmd
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/jetstack/version-checker/pkg/gitops"
+)
+
+const (
+	envGitOpsGitHubToken = "GITOPS_GITHUB_TOKEN"
+	envGitOpsGitLabToken = "GITOPS_GITLAB_TOKEN"
+)
+
+// GitOpsOptions is a struct to hold options for opening pull requests bumping
+// outdated images in the Git repositories they are tracked from.
+type GitOpsOptions struct {
+	GitHubToken string
+	GitHubURL   string
+
+	GitLabToken string
+	GitLabURL   string
+
+	AllowedRepos []string
+}
+
+func (g *GitOpsOptions) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&g.GitHubToken,
+		"gitops-github-token", "",
+		fmt.Sprintf(
+			"Token pull requests are opened on GitHub with, for images tracked "+
+				"from a GitHub repository by annotation (%s_%s).",
+			envPrefix, envGitOpsGitHubToken,
+		))
+
+	fs.StringVar(&g.GitHubURL,
+		"gitops-github-url", gitops.DefaultGitHubURL,
+		"API URL of GitHub, such as https://github.example.com/api/v3 for "+
+			"GitHub Enterprise.")
+
+	fs.StringVar(&g.GitLabToken,
+		"gitops-gitlab-token", "",
+		fmt.Sprintf(
+			"Token merge requests are opened on GitLab with, for images tracked "+
+				"from a GitLab project by annotation (%s_%s).",
+			envPrefix, envGitOpsGitLabToken,
+		))
+
+	fs.StringVar(&g.GitLabURL,
+		"gitops-gitlab-url", gitops.DefaultGitLabURL,
+		"URL of GitLab, such as https://gitlab.example.com for self-managed "+
+			"instances.")
+
+	fs.StringArrayVar(&g.AllowedRepos,
+		"gitops-allowed-repo", nil,
+		"Repository pull requests may be opened against, of the form "+
+			"[<namespace>=]<host>/<repo>, such as prod=github.com/my-org/*. The "+
+			"repository may use * to match any path element, and is allowed for "+
+			"pods of any namespace if no namespace is given. Required to open pull "+
+			"requests, as repositories are set by pod annotation. Can be used "+
+			"multiple times.")
+}
+
+func (g *GitOpsOptions) checkEnv() {
+	if len(g.GitHubToken) == 0 {
+		g.GitHubToken = os.Getenv(envPrefix + "_" + envGitOpsGitHubToken)
+	}
+	if len(g.GitLabToken) == 0 {
+		g.GitLabToken = os.Getenv(envPrefix + "_" + envGitOpsGitLabToken)
+	}
+}
+
+// updater returns the updater opening pull requests with the Git providers
+// with a configured token, or nil if none are configured.
+func (g *GitOpsOptions) updater(log *logrus.Entry, kubeClient kubernetes.Interface) (*gitops.Updater, error) {
+	providers := g.providers()
+	if len(providers) == 0 {
+		return nil, nil
+	}
+
+	if len(g.AllowedRepos) == 0 {
+		return nil, errors.New("--gitops-allowed-repo must be set with a gitops token")
+	}
+
+	allowedRepos, err := gitops.ParseAllowedRepos(g.AllowedRepos)
+	if err != nil {
+		return nil, err
+	}
+
+	return gitops.New(log, kubeClient, providers, allowedRepos), nil
+}
+
+// providers returns the Git providers with a configured token.
+func (g *GitOpsOptions) providers() []gitops.Provider {
+	var providers []gitops.Provider
+	if len(g.GitHubToken) > 0 {
+		providers = append(providers, gitops.NewGitHub(g.GitHubURL, g.GitHubToken, nil))
+	}
+	if len(g.GitLabToken) > 0 {
+		providers = append(providers, gitops.NewGitLab(g.GitLabURL, g.GitLabToken, nil))
+	}
+	return providers
+}
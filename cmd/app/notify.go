@@ -0,0 +1,309 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/jetstack/version-checker/pkg/notify"
+)
+
+const (
+	envNotifyWebhookSecret   = "NOTIFY_WEBHOOK_SECRET"
+	envNotifySlackToken      = "NOTIFY_SLACK_TOKEN"
+	envNotifySlackWebhookURL = "NOTIFY_SLACK_WEBHOOK_URL"
+	envNotifyTeamsWebhookURL = "NOTIFY_TEAMS_WEBHOOK_URL"
+	envNotifyChatWebhookURL  = "NOTIFY_CHAT_WEBHOOK_URL"
+	envNotifyEmailPassword   = "NOTIFY_EMAIL_PASSWORD"
+)
+
+// NotifyOptions is a struct to hold options for notifications of images
+// becoming outdated.
+type NotifyOptions struct {
+	WebhookURLs   []string
+	WebhookSecret string
+
+	SlackToken             string
+	SlackWebhookURL        string
+	SlackChannel           string
+	SlackNamespaceChannels map[string]string
+
+	TeamsWebhookURL           string
+	TeamsNamespaceWebhookURLs map[string]string
+
+	ChatWebhookURL string
+	ChatTextField  string
+
+	Alertmanager notify.AlertmanagerOptions
+
+	Email                notify.EmailOptions
+	EmailGroupAnnotation string
+
+	Template string
+
+	Dispatcher notify.DispatcherOptions
+}
+
+func (n *NotifyOptions) addFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&n.WebhookURLs,
+		"notify-webhook-url", nil,
+		"URL of a webhook a JSON payload is POSTed to whenever the image of a "+
+			"container transitions from the latest version to outdated. May be "+
+			"given more than once.")
+
+	fs.StringVar(&n.WebhookSecret,
+		"notify-webhook-secret", "",
+		fmt.Sprintf(
+			"Secret webhook payloads are signed with using HMAC SHA256, in the "+
+				"%s header (%s_%s).",
+			notify.SignatureHeader, envPrefix, envNotifyWebhookSecret,
+		))
+
+	fs.StringVar(&n.SlackToken,
+		"notify-slack-token", "",
+		fmt.Sprintf(
+			"Slack bot token messages are posted to channels with, using "+
+				"chat.postMessage (%s_%s).",
+			envPrefix, envNotifySlackToken,
+		))
+
+	fs.StringVar(&n.SlackWebhookURL,
+		"notify-slack-webhook-url", "",
+		fmt.Sprintf(
+			"Slack incoming webhook URL messages are posted to, if no "+
+				"--notify-slack-token is set (%s_%s).",
+			envPrefix, envNotifySlackWebhookURL,
+		))
+
+	fs.StringVar(&n.SlackChannel,
+		"notify-slack-channel", "",
+		"Slack channel messages are posted to, unless the namespace has a "+
+			"channel set with --notify-slack-namespace-channel.")
+
+	fs.StringToStringVar(&n.SlackNamespaceChannels,
+		"notify-slack-namespace-channel", nil,
+		"Slack channel of a namespace, such as payments=#payments-alerts, so "+
+			"teams are notified of their own images. May be given more than once.")
+
+	fs.StringVar(&n.TeamsWebhookURL,
+		"notify-teams-webhook-url", "",
+		fmt.Sprintf(
+			"Microsoft Teams incoming webhook URL connector cards are posted "+
+				"to, unless the namespace has a webhook set with "+
+				"--notify-teams-namespace-webhook-url (%s_%s).",
+			envPrefix, envNotifyTeamsWebhookURL,
+		))
+
+	fs.StringToStringVar(&n.TeamsNamespaceWebhookURLs,
+		"notify-teams-namespace-webhook-url", nil,
+		"Microsoft Teams incoming webhook URL of a namespace, such as "+
+			"payments=https://..., so teams are notified of their own images. "+
+			"May be given more than once.")
+
+	fs.StringVar(&n.ChatWebhookURL,
+		"notify-chat-webhook-url", "",
+		fmt.Sprintf(
+			"Incoming webhook URL of a chat platform accepting a JSON object "+
+				"of the message text, such as Mattermost, Rocket.Chat, Google "+
+				"Chat or Discord (%s_%s).",
+			envPrefix, envNotifyChatWebhookURL,
+		))
+
+	fs.StringVar(&n.ChatTextField,
+		"notify-chat-text-field", "text",
+		"Field of the JSON object posted to --notify-chat-webhook-url holding "+
+			"the message text, such as content for Discord.")
+
+	fs.StringVar(&n.Alertmanager.URL,
+		"notify-alertmanager-url", "",
+		"Base URL of an Alertmanager, such as http://alertmanager:9093, alerts "+
+			"of images exceeding --notify-alertmanager-versions-behind or "+
+			"--notify-alertmanager-outdated-for are pushed to, labelled the same "+
+			"as the is_latest_version metric.")
+
+	fs.IntVar(&n.Alertmanager.VersionsBehind,
+		"notify-alertmanager-versions-behind", 0,
+		"Number of minor versions, or patch versions of the same minor, the "+
+			"current version of an image is behind the latest before its alert "+
+			"fires. A major version behind always fires. Set to 0 to disable.")
+
+	fs.DurationVar(&n.Alertmanager.OutdatedFor,
+		"notify-alertmanager-outdated-for", 0,
+		"Time an image has been outdated before its alert fires, such as "+
+			"168h. Set to 0 to disable. If both thresholds are disabled, every "+
+			"outdated image fires.")
+
+	fs.DurationVar(&n.Alertmanager.ResendInterval,
+		"notify-alertmanager-resend-interval", time.Minute,
+		"Interval firing alerts are pushed to Alertmanager again at. Alerts "+
+			"not pushed again within 3 intervals are resolved by Alertmanager.")
+
+	fs.StringToStringVar(&n.Alertmanager.Labels,
+		"notify-alertmanager-label", nil,
+		"Label added to every alert, such as cluster=prod-eu. May be given "+
+			"more than once.")
+
+	fs.StringVar(&n.Email.SMTPAddr,
+		"notify-email-smtp-addr", "",
+		"host:port of the SMTP server a digest email of the outdated images is "+
+			"sent through every --notify-email-interval.")
+
+	fs.StringVar(&n.Email.Username,
+		"notify-email-username", "",
+		"Username of the SMTP server, authenticating with PLAIN auth if set.")
+
+	fs.StringVar(&n.Email.Password,
+		"notify-email-password", "",
+		fmt.Sprintf(
+			"Password of the SMTP server (%s_%s).",
+			envPrefix, envNotifyEmailPassword,
+		))
+
+	fs.StringVar(&n.Email.From,
+		"notify-email-from", "",
+		"Sender address of digest emails.")
+
+	fs.StringArrayVar(&n.Email.To,
+		"notify-email-to", nil,
+		"Recipient address of digest emails. May be given more than once.")
+
+	fs.DurationVar(&n.Email.Interval,
+		"notify-email-interval", time.Hour*24,
+		"Interval digest emails are sent at, such as 24h for daily or 168h for "+
+			"weekly digests.")
+
+	fs.StringVar(&n.EmailGroupAnnotation,
+		"notify-email-group-annotation", "",
+		"Annotation of namespaces whose value the images of digest emails are "+
+			"grouped by, such as the owning team. Namespaces without it are "+
+			"grouped by their name. Defaults to grouping by namespace.")
+
+	fs.StringVar(&n.Template,
+		"notify-template", "",
+		"Go template of chat messages, shared by every chat notifier, whose data has the fields Namespace, "+
+			"Pod, Container, Image, CurrentVersion, LatestVersion and Severity. "+
+			"Defaults to a single line summary.")
+
+	fs.DurationVar(&n.Dispatcher.DedupWindow,
+		"notify-dedup-window", time.Hour*24,
+		"Time in which the same image, current and latest version of a "+
+			"namespace is notified at most once, such as for the pods of one "+
+			"Deployment.")
+
+	fs.Float64Var(&n.Dispatcher.RateLimit,
+		"notify-rate-limit", 1,
+		"Number of notifications sent per second. Notifications waiting on "+
+			"the limit are queued, and dropped once the queue is full. Set to 0 "+
+			"to disable.")
+
+	fs.IntVar(&n.Dispatcher.RateBurst,
+		"notify-rate-burst", 5,
+		"Number of notifications which may be sent at once, above "+
+			"--notify-rate-limit.")
+}
+
+func (n *NotifyOptions) checkEnv() {
+	if len(n.WebhookSecret) == 0 {
+		n.WebhookSecret = os.Getenv(envPrefix + "_" + envNotifyWebhookSecret)
+	}
+	if len(n.SlackToken) == 0 {
+		n.SlackToken = os.Getenv(envPrefix + "_" + envNotifySlackToken)
+	}
+	if len(n.SlackWebhookURL) == 0 {
+		n.SlackWebhookURL = os.Getenv(envPrefix + "_" + envNotifySlackWebhookURL)
+	}
+	if len(n.TeamsWebhookURL) == 0 {
+		n.TeamsWebhookURL = os.Getenv(envPrefix + "_" + envNotifyTeamsWebhookURL)
+	}
+	if len(n.ChatWebhookURL) == 0 {
+		n.ChatWebhookURL = os.Getenv(envPrefix + "_" + envNotifyChatWebhookURL)
+	}
+	if len(n.Email.Password) == 0 {
+		n.Email.Password = os.Getenv(envPrefix + "_" + envNotifyEmailPassword)
+	}
+}
+
+// senders returns the senders of the configured notifiers.
+func (n *NotifyOptions) senders() ([]notify.Sender, error) {
+	var senders []notify.Sender
+	for _, url := range n.WebhookURLs {
+		senders = append(senders, notify.New(notify.Options{
+			URL:    url,
+			Secret: n.WebhookSecret,
+		}))
+	}
+
+	tmpl, err := notify.NewTemplate(n.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(n.SlackToken) > 0 || len(n.SlackWebhookURL) > 0 {
+		slack, err := notify.NewSlack(notify.SlackOptions{
+			Token:             n.SlackToken,
+			WebhookURL:        n.SlackWebhookURL,
+			Channel:           n.SlackChannel,
+			NamespaceChannels: n.SlackNamespaceChannels,
+			Template:          tmpl,
+		})
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, slack)
+	}
+
+	if len(n.TeamsWebhookURL) > 0 || len(n.TeamsNamespaceWebhookURLs) > 0 {
+		teams, err := notify.NewTeams(notify.TeamsOptions{
+			WebhookURL:           n.TeamsWebhookURL,
+			NamespaceWebhookURLs: n.TeamsNamespaceWebhookURLs,
+			Template:             tmpl,
+		})
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, teams)
+	}
+
+	if len(n.ChatWebhookURL) > 0 {
+		chat, err := notify.NewChat(notify.ChatOptions{
+			WebhookURL: n.ChatWebhookURL,
+			TextField:  n.ChatTextField,
+			Template:   tmpl,
+		})
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, chat)
+	}
+
+	return senders, nil
+}
+
+// email returns the email digest notifier, grouping images by the
+// --notify-email-group-annotation of their namespace, if set.
+func (n *NotifyOptions) email(ctx context.Context, log *logrus.Entry, kubeClient kubernetes.Interface) (*notify.Email, error) {
+	opts := n.Email
+	if len(n.EmailGroupAnnotation) > 0 {
+		opts.GroupBy = func(namespace string) string {
+			ns, err := kubeClient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+			if err != nil {
+				log.Errorf("failed to get namespace %q to group digest email: %s", namespace, err)
+				return namespace
+			}
+
+			if group, ok := ns.Annotations[n.EmailGroupAnnotation]; ok && len(group) > 0 {
+				return group
+			}
+
+			return namespace
+		}
+	}
+
+	return notify.NewEmail(log, opts)
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/scan"
+)
+
+// PluginOptions is a struct to hold options for the kubectl plugin.
+type PluginOptions struct {
+	AllNamespaces bool
+	Selector      string
+	Output        string
+	OutdatedOnly  bool
+}
+
+// NewPluginCommand returns the command of the kubectl-version_checker kubectl
+// plugin, which checks the images of running pods directly, without the
+// controller.
+func NewPluginCommand(ctx context.Context) *cobra.Command {
+	opts := new(Options)
+	pluginOpts := new(PluginOptions)
+	kubeConfigFlags := genericclioptions.NewConfigFlags(true)
+
+	cmd := &cobra.Command{
+		Use:   "kubectl version-checker",
+		Short: "Show the images of running pods against their latest versions.",
+		Long: "Check the images of the pods of a namespace against their " +
+			"registries, and print a table per namespace of their current and " +
+			"latest versions. Version checker annotations of pods are applied.",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.checkEnv()
+
+			log, err := opts.newLogger()
+			if err != nil {
+				return err
+			}
+
+			if pluginOpts.Output != scan.OutputTable && pluginOpts.Output != scan.OutputJSON {
+				return fmt.Errorf("unsupported --output %q, must be %s or %s",
+					pluginOpts.Output, scan.OutputTable, scan.OutputJSON)
+			}
+
+			restConfig, err := kubeConfigFlags.ToRESTConfig()
+			if err != nil {
+				return fmt.Errorf("failed to build kubernetes rest config: %s", err)
+			}
+
+			kubeClient, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return fmt.Errorf("failed to build kubernetes client: %s", err)
+			}
+
+			namespace, _, err := kubeConfigFlags.ToRawKubeConfigLoader().Namespace()
+			if err != nil {
+				return fmt.Errorf("failed to get namespace: %s", err)
+			}
+			if pluginOpts.AllNamespaces {
+				namespace = metav1.NamespaceAll
+			}
+
+			pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: pluginOpts.Selector,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list pods: %s", err)
+			}
+
+			var containers []scan.Container
+			for i := range pods.Items {
+				containers = append(containers, scan.PodContainers(&pods.Items[i])...)
+			}
+
+			opts.Client.Log = log
+			client, err := client.New(ctx, opts.Client)
+			if err != nil {
+				return fmt.Errorf("failed to setup image registry clients: %s", err)
+			}
+
+			scanner := scan.New(log, client, opts.CacheTimeout, opts.ExcludeTagRegexes,
+				opts.EnrichmentConcurrency)
+			results := scanner.Scan(ctx, containers)
+
+			if pluginOpts.OutdatedOnly {
+				var outdated []scan.Result
+				for _, result := range results {
+					if len(result.Error) == 0 && !result.IsLatest {
+						outdated = append(outdated, result)
+					}
+				}
+				results = outdated
+			}
+
+			return scan.WriteNamespaceReport(os.Stdout, pluginOpts.Output, results)
+		},
+	}
+
+	kubeConfigFlags.AddFlags(cmd.Flags())
+	opts.addLookupFlags(cmd.Flags())
+	opts.addScanFlags(cmd.Flags())
+
+	cmd.Flags().BoolVarP(&pluginOpts.AllNamespaces,
+		"all-namespaces", "A", false,
+		"Check the pods of every namespace.")
+
+	cmd.Flags().StringVarP(&pluginOpts.Selector,
+		"selector", "l", "",
+		"Label selector of the pods to check, e.g. app=web.")
+
+	cmd.Flags().StringVarP(&pluginOpts.Output,
+		"output", "o", scan.OutputTable,
+		"Format of the report (table, json).")
+
+	cmd.Flags().BoolVar(&pluginOpts.OutdatedOnly,
+		"outdated-only", false,
+		"Only show images which are outdated.")
+
+	return cmd
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/scan"
+)
+
+// ScanOptions is a struct to hold options for the scan command.
+type ScanOptions struct {
+	Path           string
+	Output         string
+	FailOnOutdated bool
+}
+
+func newScanCommand(ctx context.Context, opts *Options) *cobra.Command {
+	scanOpts := new(ScanOptions)
+
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Check the images of Kubernetes manifests on disk against their registries.",
+		Long: "Check the images of the Kubernetes manifests at a path, such as " +
+			"the output of helm template, against their registries and print a " +
+			"report. Version checker annotations of the resources and their pod " +
+			"templates are applied. Fails if any image is outdated or can't be " +
+			"checked, so can gate changes before they reach a cluster.",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.checkEnv()
+
+			log, err := opts.newLogger()
+			if err != nil {
+				return err
+			}
+
+			// Reject an unsupported output before making any lookups.
+			if scanOpts.Output != scan.OutputTable && scanOpts.Output != scan.OutputJSON {
+				return fmt.Errorf("unsupported --output %q, must be %s or %s",
+					scanOpts.Output, scan.OutputTable, scan.OutputJSON)
+			}
+
+			containers, err := scan.ReadManifests(scanOpts.Path)
+			if err != nil {
+				return fmt.Errorf("failed to read manifests: %s", err)
+			}
+
+			opts.Client.Log = log
+			client, err := client.New(ctx, opts.Client)
+			if err != nil {
+				return fmt.Errorf("failed to setup image registry clients: %s", err)
+			}
+
+			scanner := scan.New(log, client, opts.CacheTimeout, opts.ExcludeTagRegexes,
+				opts.EnrichmentConcurrency)
+			results := scanner.Scan(ctx, containers)
+
+			if err := scan.WriteReport(os.Stdout, scanOpts.Output, results); err != nil {
+				return err
+			}
+
+			var outdated, failed int
+			for _, result := range results {
+				switch {
+				case len(result.Error) > 0:
+					failed++
+				case !result.IsLatest:
+					outdated++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("failed to check %d of %d images", failed, len(results))
+			}
+			if outdated > 0 && scanOpts.FailOnOutdated {
+				return fmt.Errorf("%d of %d images are outdated", outdated, len(results))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&scanOpts.Path,
+		"path", "p", ".",
+		`Manifest file, or directory walked for .yaml, .yml and .json files. `+
+			`"-" reads manifests from stdin.`)
+
+	cmd.Flags().StringVarP(&scanOpts.Output,
+		"output", "o", scan.OutputTable,
+		"Format of the report (table, json).")
+
+	cmd.Flags().BoolVar(&scanOpts.FailOnOutdated,
+		"fail-on-outdated", true,
+		"Exit with an error if any image is outdated.")
+
+	opts.addScanFlags(cmd.Flags())
+
+	return cmd
+}
@@ -0,0 +1,283 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/controller"
+	"github.com/jetstack/version-checker/pkg/policy"
+)
+
+const (
+	// ViolationsAuditAnnotation is the audit annotation holding the
+	// violations of pods admitted in warn mode.
+	ViolationsAuditAnnotation = "violations"
+
+	// reviewTimeout is the time the tags of a pod's images are looked up for,
+	// within the API server's webhook timeout.
+	reviewTimeout = time.Second * 8
+)
+
+// Mode is what the webhook does with pods violating the policy.
+type Mode string
+
+const (
+	// ModeDeny rejects pods violating the policy.
+	ModeDeny Mode = "deny"
+
+	// ModeWarn admits pods violating the policy, logging and recording the
+	// violations as an audit annotation.
+	ModeWarn Mode = "warn"
+)
+
+// Options used to configure the admission webhook.
+type Options struct {
+	Mode Mode
+
+	// Policy is enforced on the tag of every checked container, such as a
+	// max age floor of tags, or a regex of allowed tags.
+	Policy policy.Policy
+
+	// DefaultTestAll checks every container, unless disabled by its enable
+	// annotation, rather than only enabled containers.
+	DefaultTestAll bool
+
+	// ExcludeTagRegexes are applied to every container, as with image checks.
+	ExcludeTagRegexes []string
+}
+
+// Server serves the admission webhooks of pods. The validating webhook
+// rejects or warns on containers whose tag violates the policy, or doesn't
+// match the release channel or regex of the container's annotations. The
+// mutating webhook resolves the tags of images to digests.
+type Server struct {
+	*http.Server
+
+	log       *logrus.Entry
+	evaluator *policy.Evaluator
+	digests   Digester
+	opts      Options
+}
+
+func New(log *logrus.Entry, tags policy.TagLister, digests Digester, opts Options) *Server {
+	return &Server{
+		log:       log.WithField("module", "admission"),
+		evaluator: policy.New(tags),
+		digests:   digests,
+		opts:      opts,
+	}
+}
+
+// Run will serve the validating admission webhook over TLS at the /validate
+// path, and the mutating admission webhook at the /mutate path.
+func (s *Server) Run(servingAddress, certFile, keyFile string) error {
+	router := http.NewServeMux()
+	router.HandleFunc("/validate", s.serveValidate)
+	router.HandleFunc("/mutate", s.serveMutate)
+
+	ln, err := net.Listen("tcp", servingAddress)
+	if err != nil {
+		return err
+	}
+
+	s.Server = &http.Server{
+		Addr:           ln.Addr().String(),
+		ReadTimeout:    8 * time.Second,
+		WriteTimeout:   reviewTimeout + 2*time.Second,
+		MaxHeaderBytes: 1 << 15, // 1 MiB
+		Handler:        router,
+	}
+
+	go func() {
+		s.log.Infof("serving admission webhook on %s", ln.Addr())
+
+		if err := s.ServeTLS(ln, certFile, keyFile); err != nil && err != http.ErrServerClosed {
+			s.log.Errorf("failed to serve admission webhook: %s", err)
+		}
+	}()
+
+	return nil
+}
+
+// serveValidate responds to the AdmissionReview of a pod, denying it if it
+// violates the policy.
+func (s *Server) serveValidate(w http.ResponseWriter, r *http.Request) {
+	s.serveReview(w, r, s.validate)
+}
+
+// serveReview responds to an AdmissionReview with the response of the review
+// func.
+func (s *Server) serveReview(w http.ResponseWriter, r *http.Request,
+	review func(context.Context, *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var admissionReview admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&admissionReview); err != nil || admissionReview.Request == nil {
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), reviewTimeout)
+	defer cancel()
+
+	admissionReview.Response = review(ctx, admissionReview.Request)
+	admissionReview.Response.UID = admissionReview.Request.UID
+	admissionReview.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(admissionReview); err != nil {
+		s.log.Errorf("failed to write admission review response: %s", err)
+	}
+}
+
+// validate returns the response to the admission request of a pod, denying
+// it in deny mode if any container violates the policy.
+func (s *Server) validate(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	var pod corev1.Pod
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		return &admissionv1.AdmissionResponse{
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("failed to decode pod: %s", err),
+				Code:    http.StatusBadRequest,
+			},
+		}
+	}
+
+	violations := s.violations(ctx, &pod)
+	if len(violations) == 0 {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	message := strings.Join(violations, "; ")
+	name := pod.Name
+	if len(name) == 0 {
+		name = pod.GenerateName
+	}
+
+	if s.opts.Mode == ModeWarn {
+		s.log.Warnf("admitting pod %s/%s violating image policy: %s", req.Namespace, name, message)
+		return &admissionv1.AdmissionResponse{
+			Allowed:          true,
+			AuditAnnotations: map[string]string{ViolationsAuditAnnotation: message},
+		}
+	}
+
+	s.log.Infof("denying pod %s/%s violating image policy: %s", req.Namespace, name, message)
+	return &admissionv1.AdmissionResponse{
+		Result: &metav1.Status{
+			Message: message,
+			Reason:  metav1.StatusReasonForbidden,
+			Code:    http.StatusForbidden,
+		},
+	}
+}
+
+// violations returns the ways the tags of the pod's checked containers
+// violate the policy, or the channel and regex of their annotations. Images
+// which can't be looked up are logged and admitted, so that registry outages
+// don't block pods.
+func (s *Server) violations(ctx context.Context, pod *corev1.Pod) []string {
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+
+	var violations []string
+	for _, container := range containers {
+		if !s.checked(pod, container.Name) {
+			continue
+		}
+
+		imageURL, tag := splitImage(container.Image)
+		// Digests and the latest tag have no version to enforce.
+		if len(tag) == 0 || tag == "latest" {
+			continue
+		}
+
+		opts, err := controller.BuildOptions(container.Name, pod.Annotations, s.opts.ExcludeTagRegexes)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("container %q: %s", container.Name, err))
+			continue
+		}
+
+		if (opts.RegexMatcher != nil || opts.Channel != nil) && !opts.Matches(tag) {
+			violations = append(violations, fmt.Sprintf("container %q: tag %q does not match the channel or regex of its annotations",
+				container.Name, tag))
+		}
+
+		if s.opts.Policy.AllowedTags == nil && s.opts.Policy.MaxAge <= 0 {
+			continue
+		}
+
+		result, err := s.evaluator.Evaluate(ctx, imageURL, tag, &s.opts.Policy)
+		if err != nil {
+			s.log.Errorf("failed to evaluate image policy of %q, admitting: %s", container.Image, err)
+			continue
+		}
+
+		for _, violation := range result.Violations {
+			if len(result.UpgradeTargets) > 0 {
+				violation = fmt.Sprintf("%s, newest compliant tag is %q", violation, result.UpgradeTargets[0].Tag)
+			}
+			violations = append(violations, fmt.Sprintf("container %q: %s", container.Name, violation))
+		}
+	}
+
+	return violations
+}
+
+// checked returns true if the container of the pod is checked, being enabled
+// by its annotation, or not disabled if every container is checked.
+func (s *Server) checked(pod *corev1.Pod, containerName string) bool {
+	enable, ok := pod.Annotations[api.EnableAnnotationKey+"/"+containerName]
+	if s.opts.DefaultTestAll {
+		return !ok || enable != "false"
+	}
+	return enable == "true"
+}
+
+// Shutdown will stop the admission webhook server.
+func (s *Server) Shutdown() error {
+	// If the admission webhook server is not started then exit early
+	if s.Server == nil {
+		return nil
+	}
+
+	s.log.Info("shutting down admission webhook server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := s.Server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("admission webhook server shutdown failed: %s", err)
+	}
+
+	s.log.Info("admission webhook server gracefully stopped")
+
+	return nil
+}
+
+// splitImage returns the image URL and tag of the image, ignoring any digest.
+// Images only by digest have no tag.
+func splitImage(image string) (string, string) {
+	if i := strings.Index(image, "@"); i > -1 {
+		image = image[:i]
+	}
+
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+
+	return image, ""
+}
@@ -0,0 +1,161 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/policy"
+)
+
+// fakeTags returns the tags of each image URL.
+type fakeTags map[string][]api.ImageTag
+
+func (f fakeTags) Tags(_ context.Context, imageURL string) ([]api.ImageTag, error) {
+	tags, ok := f[imageURL]
+	if !ok {
+		return nil, errors.New("registry unavailable")
+	}
+	return tags, nil
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Now()
+	tags := fakeTags{
+		"nginx": {
+			{Tag: "1.17.0", Timestamp: now.Add(-time.Hour * 24 * 400)},
+			{Tag: "1.21.0", Timestamp: now.Add(-time.Hour * 24 * 10)},
+			{Tag: "1.22.0-rc.1", Timestamp: now.Add(-time.Hour * 24)},
+		},
+	}
+
+	tests := map[string]struct {
+		mode        Mode
+		annotations map[string]string
+		image       string
+		expAllowed  bool
+		expMessage  string
+	}{
+		"recent tag should be allowed": {
+			mode:       ModeDeny,
+			image:      "nginx:1.21.0",
+			expAllowed: true,
+		},
+		"tag older than the max age should be denied": {
+			mode:       ModeDeny,
+			image:      "nginx:1.17.0",
+			expMessage: `container "nginx": tag "1.17.0" is older than max age 8760h0m0s, newest compliant tag is "1.21.0"`,
+		},
+		"tag older than the max age should be allowed in warn mode": {
+			mode:       ModeWarn,
+			image:      "nginx:1.17.0",
+			expAllowed: true,
+		},
+		"tag not of the annotated channel should be denied": {
+			mode:        ModeDeny,
+			annotations: map[string]string{api.ChannelAnnotationKey + "/nginx": "stable"},
+			image:       "nginx:1.22.0-rc.1",
+			expMessage:  `container "nginx": tag "1.22.0-rc.1" does not match the channel or regex of its annotations`,
+		},
+		"disabled container should be allowed": {
+			mode:        ModeDeny,
+			annotations: map[string]string{api.EnableAnnotationKey + "/nginx": "false"},
+			image:       "nginx:1.17.0",
+			expAllowed:  true,
+		},
+		"digest should be allowed": {
+			mode:       ModeDeny,
+			image:      "nginx@sha256:abc",
+			expAllowed: true,
+		},
+		"tag resolved to a digest should be enforced by its tag": {
+			mode:       ModeDeny,
+			image:      "nginx:1.17.0@sha256:abc",
+			expMessage: `container "nginx": tag "1.17.0" is older than max age 8760h0m0s, newest compliant tag is "1.21.0"`,
+		},
+		"image failing lookup should be allowed": {
+			mode:       ModeDeny,
+			image:      "private/app:1.0.0",
+			expAllowed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := New(logrus.NewEntry(logrus.New()), tags, nil, Options{
+				Mode:           test.mode,
+				DefaultTestAll: true,
+				Policy: policy.Policy{
+					AllowedTags: regexp.MustCompile(`^\d+\.\d+\.\d+`),
+					MaxAge:      time.Hour * 24 * 365,
+				},
+			})
+
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod", Annotations: test.annotations},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "nginx", Image: test.image}},
+				},
+			}
+			raw, err := json.Marshal(pod)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := json.Marshal(admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+				Request: &admissionv1.AdmissionRequest{
+					UID:       types.UID("review-1"),
+					Namespace: "prod",
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.serveValidate(rec, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("unexpected status code, exp=%d got=%d", http.StatusOK, rec.Code)
+			}
+
+			var review admissionv1.AdmissionReview
+			if err := json.Unmarshal(rec.Body.Bytes(), &review); err != nil {
+				t.Fatal(err)
+			}
+			resp := review.Response
+			if resp == nil || resp.UID != "review-1" {
+				t.Fatalf("expected response of the review, got=%+v", resp)
+			}
+
+			if resp.Allowed != test.expAllowed {
+				t.Errorf("unexpected allowed, exp=%t got=%t", test.expAllowed, resp.Allowed)
+			}
+			if test.expAllowed {
+				if test.mode == ModeWarn && !strings.Contains(resp.AuditAnnotations[ViolationsAuditAnnotation], "older than max age") {
+					t.Errorf("expected violations audit annotation, got=%v", resp.AuditAnnotations)
+				}
+				return
+			}
+			if resp.Result == nil || resp.Result.Message != test.expMessage {
+				t.Errorf("unexpected message, exp=%q got=%+v", test.expMessage, resp.Result)
+			}
+		})
+	}
+}
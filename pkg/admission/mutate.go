@@ -0,0 +1,147 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// Digester resolves the current digest of a tag. Satisfied by client.Client.
+type Digester interface {
+	TagDigest(ctx context.Context, imageURL, tag string) (string, error)
+}
+
+// patchOperation is a JSON patch operation of a pod.
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// serveMutate responds to the AdmissionReview of a pod with a patch resolving
+// the tags of its images to digests.
+func (s *Server) serveMutate(w http.ResponseWriter, r *http.Request) {
+	s.serveReview(w, r, s.mutate)
+}
+
+// mutate returns the response to the admission request of a pod, patching the
+// image of every checked container from tag to tag@digest, and annotating the
+// pod with the original images. Tags which can't be resolved are logged and
+// left as is, so that registry outages don't block pods.
+func (s *Server) mutate(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	var pod corev1.Pod
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		return &admissionv1.AdmissionResponse{
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("failed to decode pod: %s", err),
+				Code:    http.StatusBadRequest,
+			},
+		}
+	}
+
+	var (
+		patch []patchOperation
+		// originals are the annotations of the original images of patched
+		// containers.
+		originals [][2]string
+	)
+
+	resolve := func(path string, containers []corev1.Container) {
+		for i, container := range containers {
+			if !s.checked(&pod, container.Name) {
+				continue
+			}
+
+			image, err := s.resolveDigest(ctx, container.Image)
+			if err != nil {
+				s.log.Errorf("failed to resolve digest of %q, admitting unchanged: %s", container.Image, err)
+				continue
+			}
+			if image == container.Image {
+				continue
+			}
+
+			patch = append(patch, patchOperation{
+				Op:    "replace",
+				Path:  fmt.Sprintf("%s/%d/image", path, i),
+				Value: image,
+			})
+			originals = append(originals, [2]string{
+				api.OriginalImageAnnotationKey + "/" + container.Name, container.Image,
+			})
+		}
+	}
+	resolve("/spec/initContainers", pod.Spec.InitContainers)
+	resolve("/spec/containers", pod.Spec.Containers)
+
+	if len(patch) == 0 {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	if pod.Annotations == nil {
+		annotations := make(map[string]string)
+		for _, original := range originals {
+			annotations[original[0]] = original[1]
+		}
+		patch = append(patch, patchOperation{Op: "add", Path: "/metadata/annotations", Value: annotations})
+	} else {
+		for _, original := range originals {
+			patch = append(patch, patchOperation{
+				Op:    "add",
+				Path:  "/metadata/annotations/" + escapePatchPath(original[0]),
+				Value: original[1],
+			})
+		}
+	}
+
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return &admissionv1.AdmissionResponse{
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("failed to marshal patch: %s", err),
+				Code:    http.StatusInternalServerError,
+			},
+		}
+	}
+
+	patchType := admissionv1.PatchTypeJSONPatch
+	return &admissionv1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     body,
+		PatchType: &patchType,
+	}
+}
+
+// resolveDigest returns the image with its tag resolved to the current
+// digest, as tag@digest. Images without a tag are resolved as the latest tag,
+// and images already by digest are returned as is.
+func (s *Server) resolveDigest(ctx context.Context, image string) (string, error) {
+	if strings.Contains(image, "@") {
+		return image, nil
+	}
+
+	imageURL, tag := splitImage(image)
+	if len(tag) == 0 {
+		tag = "latest"
+	}
+
+	digest, err := s.digests.TagDigest(ctx, imageURL, tag)
+	if err != nil {
+		return "", err
+	}
+
+	return image + "@" + digest, nil
+}
+
+// escapePatchPath escapes the key as a JSON pointer path segment.
+func escapePatchPath(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
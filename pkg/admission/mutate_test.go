@@ -0,0 +1,121 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// fakeDigests returns the digest of each image URL and tag.
+type fakeDigests map[string]string
+
+func (f fakeDigests) TagDigest(_ context.Context, imageURL, tag string) (string, error) {
+	digest, ok := f[imageURL+":"+tag]
+	if !ok {
+		return "", errors.New("registry unavailable")
+	}
+	return digest, nil
+}
+
+func TestMutate(t *testing.T) {
+	digests := fakeDigests{
+		"nginx:1.21.0":      "sha256:a",
+		"busybox:latest":    "sha256:b",
+		"flyway/flyway:7.0": "sha256:c",
+	}
+
+	tests := map[string]struct {
+		annotations    map[string]string
+		initContainers []corev1.Container
+		containers     []corev1.Container
+		expPatch       []patchOperation
+	}{
+		"tags should be resolved to digests and annotated": {
+			initContainers: []corev1.Container{{Name: "migrate", Image: "flyway/flyway:7.0"}},
+			containers: []corev1.Container{
+				{Name: "nginx", Image: "nginx:1.21.0"},
+				{Name: "debug", Image: "busybox"},
+			},
+			expPatch: []patchOperation{
+				{Op: "replace", Path: "/spec/initContainers/0/image", Value: "flyway/flyway:7.0@sha256:c"},
+				{Op: "replace", Path: "/spec/containers/0/image", Value: "nginx:1.21.0@sha256:a"},
+				{Op: "replace", Path: "/spec/containers/1/image", Value: "busybox@sha256:b"},
+				{Op: "add", Path: "/metadata/annotations", Value: map[string]interface{}{
+					api.OriginalImageAnnotationKey + "/migrate": "flyway/flyway:7.0",
+					api.OriginalImageAnnotationKey + "/nginx":   "nginx:1.21.0",
+					api.OriginalImageAnnotationKey + "/debug":   "busybox",
+				}},
+			},
+		},
+		"existing annotations should be added to": {
+			annotations: map[string]string{"team": "web"},
+			containers:  []corev1.Container{{Name: "nginx", Image: "nginx:1.21.0"}},
+			expPatch: []patchOperation{
+				{Op: "replace", Path: "/spec/containers/0/image", Value: "nginx:1.21.0@sha256:a"},
+				{Op: "add", Path: "/metadata/annotations/original-image.version-checker.io~1nginx", Value: "nginx:1.21.0"},
+			},
+		},
+		"digests, disabled containers and failed lookups should be unchanged": {
+			annotations: map[string]string{api.EnableAnnotationKey + "/debug": "false"},
+			containers: []corev1.Container{
+				{Name: "nginx", Image: "nginx@sha256:a"},
+				{Name: "debug", Image: "busybox"},
+				{Name: "app", Image: "private/app:1.0.0"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := New(logrus.NewEntry(logrus.New()), nil, digests, Options{DefaultTestAll: true})
+
+			raw, err := json.Marshal(corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod", Annotations: test.annotations},
+				Spec: corev1.PodSpec{
+					InitContainers: test.initContainers,
+					Containers:     test.containers,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp := s.mutate(context.TODO(), &admissionv1.AdmissionRequest{
+				Namespace: "prod",
+				Object:    runtime.RawExtension{Raw: raw},
+			})
+			if !resp.Allowed {
+				t.Fatalf("expected pod to be allowed, got=%+v", resp.Result)
+			}
+
+			if test.expPatch == nil {
+				if resp.Patch != nil {
+					t.Errorf("expected no patch, got=%s", resp.Patch)
+				}
+				return
+			}
+
+			if resp.PatchType == nil || *resp.PatchType != admissionv1.PatchTypeJSONPatch {
+				t.Errorf("expected JSON patch type, got=%v", resp.PatchType)
+			}
+
+			var patch []patchOperation
+			if err := json.Unmarshal(resp.Patch, &patch); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(patch, test.expPatch) {
+				t.Errorf("unexpected patch, exp=%+v got=%+v", test.expPatch, patch)
+			}
+		})
+	}
+}
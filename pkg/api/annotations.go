@@ -0,0 +1,37 @@
+package api
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	// Annotations written back to pods describing the result of a check.
+	IsLatestAnnotationKey      = "version-checker/is-latest"
+	LatestVersionAnnotationKey = "version-checker/latest-version"
+	LastCheckedAnnotationKey   = "version-checker/last-checked"
+)
+
+// UpgradeAnnotation returns the annotations describing whether the current
+// tag is the latest tag, to be patched onto a pod. The current tag is the
+// latest if it has the same tag name, or the same digest. If latest is nil,
+// is-latest is "unknown" and no latest version is set. last-checked is the
+// current time in RFC 3339 format.
+func UpgradeAnnotation(current ImageTag, latest *ImageTag) map[string]string {
+	annotations := map[string]string{
+		LastCheckedAnnotationKey: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if latest == nil {
+		annotations[IsLatestAnnotationKey] = "unknown"
+		return annotations
+	}
+
+	isLatest := current.Tag == latest.Tag ||
+		(len(current.SHA) > 0 && current.SHA == latest.SHA)
+
+	annotations[IsLatestAnnotationKey] = strconv.FormatBool(isLatest)
+	annotations[LatestVersionAnnotationKey] = latest.Tag
+
+	return annotations
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestUpgradeAnnotation(t *testing.T) {
+	tests := map[string]struct {
+		current     ImageTag
+		latest      *ImageTag
+		expIsLatest string
+		expLatest   string
+	}{
+		"current tag should be latest": {
+			current:     ImageTag{Tag: "v0.2.0"},
+			latest:      &ImageTag{Tag: "v0.2.0", SHA: "sha256:a"},
+			expIsLatest: "true",
+			expLatest:   "v0.2.0",
+		},
+		"current digest should be latest": {
+			current:     ImageTag{SHA: "sha256:a"},
+			latest:      &ImageTag{Tag: "v0.2.0", SHA: "sha256:a"},
+			expIsLatest: "true",
+			expLatest:   "v0.2.0",
+		},
+		"outdated tag should not be latest": {
+			current:     ImageTag{Tag: "v0.1.0", SHA: "sha256:b"},
+			latest:      &ImageTag{Tag: "v0.2.0", SHA: "sha256:a"},
+			expIsLatest: "false",
+			expLatest:   "v0.2.0",
+		},
+		"unknown latest should be unknown": {
+			current:     ImageTag{Tag: "v0.1.0"},
+			expIsLatest: "unknown",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			annotations := UpgradeAnnotation(test.current, test.latest)
+
+			if errs := validation.ValidateAnnotations(annotations, field.NewPath("annotations")); len(errs) > 0 {
+				t.Errorf("invalid annotations: %v", errs)
+			}
+
+			if v := annotations[IsLatestAnnotationKey]; v != test.expIsLatest {
+				t.Errorf("unexpected %s, exp=%q got=%q", IsLatestAnnotationKey, test.expIsLatest, v)
+			}
+			if v := annotations[LatestVersionAnnotationKey]; v != test.expLatest {
+				t.Errorf("unexpected %s, exp=%q got=%q", LatestVersionAnnotationKey, test.expLatest, v)
+			}
+			if _, err := time.Parse(time.RFC3339, annotations[LastCheckedAnnotationKey]); err != nil {
+				t.Errorf("unexpected %s: %s", LastCheckedAnnotationKey, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// ReleaseCadence returns the median and 90th percentile of the time between
+// consecutive releases, ordered by timestamp. Tags which are not versions
+// are ignored, and aliased tags of the same version count as one release at
+// the earliest timestamp. Returns zero if there are fewer than two releases.
+func ReleaseCadence(tags []ImageTag) (median, p90 time.Duration) {
+	return releaseCadence(tags, false)
+}
+
+// StableReleaseCadence is the same as ReleaseCadence, but ignores releases
+// with metadata, such as pre-releases.
+func StableReleaseCadence(tags []ImageTag) (median, p90 time.Duration) {
+	return releaseCadence(tags, true)
+}
+
+func releaseCadence(tags []ImageTag, ignorePreReleases bool) (time.Duration, time.Duration) {
+	releases := make(map[string]time.Time)
+	for _, tag := range tags {
+		v := semver.Parse(tag.Tag)
+		if !v.IsVersion() || (ignorePreReleases && v.HasMetaData()) {
+			continue
+		}
+
+		version := fmt.Sprintf("%d.%d.%d%s", v.Major(), v.Minor(), v.Patch(), v.MetaData())
+		if timestamp, ok := releases[version]; !ok || tag.Timestamp.Before(timestamp) {
+			releases[version] = tag.Timestamp
+		}
+	}
+
+	if len(releases) < 2 {
+		return 0, 0
+	}
+
+	var timestamps []time.Time
+	for _, timestamp := range releases {
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i].Before(timestamps[j])
+	})
+
+	var gaps []time.Duration
+	for i := 1; i < len(timestamps); i++ {
+		gaps = append(gaps, timestamps[i].Sub(timestamps[i-1]))
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		return gaps[i] < gaps[j]
+	})
+
+	return percentile(gaps, 50), percentile(gaps, 90)
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
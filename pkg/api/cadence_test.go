@@ -0,0 +1,85 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReleaseCadence(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := time.Hour * 24
+	at := func(tag string, days int) ImageTag {
+		return ImageTag{Tag: tag, Timestamp: start.Add(day * time.Duration(days))}
+	}
+
+	tests := map[string]struct {
+		tags              []ImageTag
+		stable            bool
+		expMedian, expP90 time.Duration
+	}{
+		"no releases should return zero": {},
+		"single release should return zero": {
+			tags: []ImageTag{at("v1.0.0", 0)},
+		},
+		"regular releases should return interval": {
+			tags: []ImageTag{
+				at("v1.0.0", 0), at("v1.1.0", 7), at("v1.2.0", 14), at("v1.3.0", 21),
+			},
+			expMedian: day * 7,
+			expP90:    day * 7,
+		},
+		"irregular releases should return median and p90": {
+			tags: []ImageTag{
+				at("v1.0.0", 0), at("v1.0.1", 1), at("v1.0.2", 3), at("v1.1.0", 6),
+				at("v1.1.1", 10), at("v1.2.0", 40),
+			},
+			// gaps: 1 2 3 4 30
+			expMedian: day * 3,
+			expP90:    day * 30,
+		},
+		"unordered tags should be sorted by timestamp": {
+			tags: []ImageTag{
+				at("v1.2.0", 20), at("v1.0.0", 0), at("v1.1.0", 10),
+			},
+			expMedian: day * 10,
+			expP90:    day * 10,
+		},
+		"aliases and non versions should be ignored": {
+			tags: []ImageTag{
+				at("v1.0.0", 0), at("1.0.0", 1), at("latest", 2), at("v1.1.0", 5), at("1.1.0", 5),
+			},
+			expMedian: day * 5,
+			expP90:    day * 5,
+		},
+		"pre-releases should be included": {
+			tags: []ImageTag{
+				at("v1.0.0", 0), at("v1.1.0-rc.0", 2), at("v1.1.0", 4),
+			},
+			expMedian: day * 2,
+			expP90:    day * 2,
+		},
+		"pre-releases should be ignored for stable cadence": {
+			tags: []ImageTag{
+				at("v1.0.0", 0), at("v1.1.0-rc.0", 2), at("v1.1.0", 4),
+			},
+			stable:    true,
+			expMedian: day * 4,
+			expP90:    day * 4,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cadence := ReleaseCadence
+			if test.stable {
+				cadence = StableReleaseCadence
+			}
+
+			median, p90 := cadence(test.tags)
+			if median != test.expMedian || p90 != test.expP90 {
+				t.Errorf("unexpected cadence, exp=%s/%s got=%s/%s",
+					test.expMedian, test.expP90, median, p90)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"math"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// HealthWeights are the relative weights of each input to a health score.
+// Weights do not need to sum to 100; each penalty is scaled by its weight
+// over the sum of all weights.
+type HealthWeights struct {
+	Age             int
+	VersionsBehind  int
+	Unsigned        int
+	Vulnerabilities int
+}
+
+// HealthOptions are the inputs to a health score which are not held on the
+// image tags, and how they are weighted.
+type HealthOptions struct {
+	// Weights defaults to DefaultHealthWeights if all zero.
+	Weights HealthWeights
+
+	// MaxAge, MaxVersionsBehind and MaxVulnerabilities are the values at which
+	// the full penalty of their input applies. Each defaults to its
+	// DefaultHealth value if zero.
+	MaxAge             time.Duration
+	MaxVersionsBehind  int
+	MaxVulnerabilities int
+
+	// Signed is whether the current tag is signed.
+	Signed bool
+
+	// Vulnerabilities is the number of known vulnerabilities of the current
+	// tag.
+	Vulnerabilities int
+}
+
+var (
+	DefaultHealthWeights = HealthWeights{
+		Age:             30,
+		VersionsBehind:  30,
+		Unsigned:        20,
+		Vulnerabilities: 20,
+	}
+
+	DefaultHealthMaxAge             = time.Hour * 24 * 365
+	DefaultHealthMaxVersionsBehind  = 5
+	DefaultHealthMaxVulnerabilities = 10
+)
+
+// HealthScore returns a score from 0 to 100 of the current tag compared to
+// the latest tag, where 100 is healthiest. Each input is a penalty between 0
+// and 1, and the score is
+//
+//	100 - 100 * sum(weight * penalty) / sum(weight)
+//
+// The penalties are:
+//   - age: time the latest tag was released after the current tag, over MaxAge
+//   - versions behind: minor versions behind the latest, or patch versions if
+//     the same minor, over MaxVersionsBehind. A major version behind is the
+//     full penalty
+//   - unsigned: 1 if the current tag is not signed
+//   - vulnerabilities: number of vulnerabilities, over MaxVulnerabilities
+//
+// Penalties above 1 are capped at 1.
+func HealthScore(tag, latest ImageTag, opts HealthOptions) int {
+	weights := opts.Weights
+	if weights == (HealthWeights{}) {
+		weights = DefaultHealthWeights
+	}
+
+	maxAge := opts.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultHealthMaxAge
+	}
+	maxVersionsBehind := opts.MaxVersionsBehind
+	if maxVersionsBehind <= 0 {
+		maxVersionsBehind = DefaultHealthMaxVersionsBehind
+	}
+	maxVulnerabilities := opts.MaxVulnerabilities
+	if maxVulnerabilities <= 0 {
+		maxVulnerabilities = DefaultHealthMaxVulnerabilities
+	}
+
+	var unsigned float64
+	if !opts.Signed {
+		unsigned = 1
+	}
+
+	penalty := float64(weights.Age)*ratio(float64(latest.Timestamp.Sub(tag.Timestamp)), float64(maxAge)) +
+		float64(weights.VersionsBehind)*ratio(VersionsBehind(tag.Tag, latest.Tag), float64(maxVersionsBehind)) +
+		float64(weights.Unsigned)*unsigned +
+		float64(weights.Vulnerabilities)*ratio(float64(opts.Vulnerabilities), float64(maxVulnerabilities))
+
+	total := weights.Age + weights.VersionsBehind + weights.Unsigned + weights.Vulnerabilities
+	if total <= 0 {
+		return 100
+	}
+
+	return int(math.Round(100 - 100*penalty/float64(total)))
+}
+
+// VersionsBehind returns the number of minor versions the current tag is
+// behind the latest, or patch versions if they share a minor version. Returns
+// +Inf if a major version behind.
+func VersionsBehind(current, latest string) float64 {
+	currentV, latestV := semver.Parse(current), semver.Parse(latest)
+	switch {
+	case !currentV.IsVersion() || !latestV.IsVersion():
+		return 0
+	case currentV.Major() != latestV.Major():
+		if currentV.Major() < latestV.Major() {
+			return math.Inf(1)
+		}
+		return 0
+	case currentV.Minor() != latestV.Minor():
+		return float64(latestV.Minor() - currentV.Minor())
+	default:
+		return float64(latestV.Patch() - currentV.Patch())
+	}
+}
+
+// ratio returns value over max, clamped between 0 and 1.
+func ratio(value, max float64) float64 {
+	return math.Max(0, math.Min(1, value/max))
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthScore(t *testing.T) {
+	released := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	latest := ImageTag{Tag: "v1.5.2", Timestamp: released.Add(time.Hour * 24 * 365)}
+	current := ImageTag{Tag: "v1.5.2", Timestamp: latest.Timestamp}
+
+	tests := map[string]struct {
+		tag      ImageTag
+		opts     HealthOptions
+		expScore int
+	}{
+		"current signed tag without vulnerabilities should be healthy": {
+			tag:      current,
+			opts:     HealthOptions{Signed: true},
+			expScore: 100,
+		},
+		"unsigned tag should lose the unsigned weight": {
+			tag:      current,
+			expScore: 80,
+		},
+		"vulnerabilities should lose score up to the max": {
+			tag:      current,
+			opts:     HealthOptions{Signed: true, Vulnerabilities: 5},
+			expScore: 90,
+		},
+		"more vulnerabilities than the max should lose the full weight": {
+			tag:      current,
+			opts:     HealthOptions{Signed: true, Vulnerabilities: 50},
+			expScore: 80,
+		},
+		"patch versions behind should lose score": {
+			tag:      ImageTag{Tag: "v1.5.0", Timestamp: latest.Timestamp},
+			opts:     HealthOptions{Signed: true},
+			expScore: 88,
+		},
+		"minor versions behind should lose score": {
+			tag:      ImageTag{Tag: "v1.4.0", Timestamp: latest.Timestamp},
+			opts:     HealthOptions{Signed: true},
+			expScore: 94,
+		},
+		"major version behind should lose the full weight": {
+			tag:      ImageTag{Tag: "v0.9.0", Timestamp: latest.Timestamp},
+			opts:     HealthOptions{Signed: true},
+			expScore: 70,
+		},
+		"age should lose score up to the max": {
+			tag:      ImageTag{Tag: "v1.5.2", Timestamp: latest.Timestamp.Add(-time.Hour * 24 * 73)},
+			opts:     HealthOptions{Signed: true},
+			expScore: 94,
+		},
+		"everything worst should score zero": {
+			tag:      ImageTag{Tag: "v0.1.0", Timestamp: released},
+			opts:     HealthOptions{Vulnerabilities: 10},
+			expScore: 0,
+		},
+		"custom weights should be used": {
+			tag: current,
+			opts: HealthOptions{
+				Weights: HealthWeights{Age: 1, Unsigned: 1},
+			},
+			expScore: 50,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if score := HealthScore(test.tag, latest, test.opts); score != test.expScore {
+				t.Errorf("unexpected health score, exp=%d got=%d", test.expScore, score)
+			}
+		})
+	}
+}
+
+func TestHealthScoreWorsens(t *testing.T) {
+	latest := ImageTag{Tag: "v1.10.0", Timestamp: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)}
+
+	last := 101
+	for _, tag := range []string{"v1.10.0", "v1.9.0", "v1.8.0", "v1.7.0", "v0.9.0"} {
+		score := HealthScore(ImageTag{Tag: tag, Timestamp: latest.Timestamp}, latest, HealthOptions{Signed: true})
+		if score >= last {
+			t.Errorf("expected score to worsen for %q, last=%d got=%d", tag, last, score)
+		}
+		last = score
+	}
+}
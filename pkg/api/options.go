@@ -0,0 +1,92 @@
+package api
+
+import (
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// Matches returns true if the tag satisfies the restrictions of the options.
+// If a regex is set, only the regex is used. Otherwise tags must match any
+// pinned versions and semver constraint, and only have metadata if
+// UseMetaData is set. If a channel is set, tags must be of the channel,
+// regardless of UseMetaData. UseSHA is not considered.
+func (o *Options) Matches(tag string) bool {
+	if o.RegexMatcher != nil {
+		return o.RegexMatcher.MatchString(tag)
+	}
+
+	v := semver.Parse(tag)
+
+	if o.Channel != nil {
+		if *o.Channel == ChannelStable {
+			if v.HasMetaData() {
+				return false
+			}
+		} else if v.PreRelease() != *o.Channel {
+			return false
+		}
+	} else if !o.UseMetaData && v.HasMetaData() {
+		// If we have declared we wont use metadata but version has it, continue.
+		return false
+	}
+
+	if o.PinMajor != nil && *o.PinMajor != v.Major() {
+		return false
+	}
+	if o.PinMinor != nil && *o.PinMinor != v.Minor() {
+		return false
+	}
+	if o.PinPatch != nil && *o.PinPatch != v.Patch() {
+		return false
+	}
+
+	if o.ConstraintMatcher != nil && !o.ConstraintMatcher.Check(v) {
+		return false
+	}
+
+	return true
+}
+
+// MatchesPlatform returns true if the image of the tag is built for the
+// platform of the options. Tags which don't describe a platform, such as
+// manifest lists, always match, as do fields unset on either side.
+func (o *Options) MatchesPlatform(tag *ImageTag) bool {
+	if o.Platform == nil {
+		return true
+	}
+
+	return platformFieldMatches(o.Platform.OS, tag.OS) &&
+		platformFieldMatches(o.Platform.Architecture, tag.Architecture) &&
+		platformFieldMatches(o.Platform.Variant, tag.Variant)
+}
+
+func platformFieldMatches(want, got string) bool {
+	return len(want) == 0 || len(got) == 0 || want == got
+}
+
+// Excludes returns true if the tag is excluded from the search by the
+// exclude regex of the options.
+func (o *Options) Excludes(tag string) bool {
+	return o.ExcludeMatcher != nil && o.ExcludeMatcher.MatchString(tag)
+}
+
+// TagAllowed returns true if the tag is in the allowed tags of the options,
+// or none are set, and is not in the denied tags.
+func (o *Options) TagAllowed(tag string) bool {
+	for _, deny := range o.DenyTags {
+		if deny == tag {
+			return false
+		}
+	}
+
+	if len(o.AllowTags) == 0 {
+		return true
+	}
+
+	for _, allow := range o.AllowTags {
+		if allow == tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"sort"
+	"time"
+)
+
+// RebuildEvent describes a tag whose digest changed between two snapshots of
+// an image's tags, without the tag name changing.
+type RebuildEvent struct {
+	Tag          string `json:"tag"`
+	OS           string `json:"os,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+
+	OldSHA       string    `json:"oldSHA"`
+	NewSHA       string    `json:"newSHA"`
+	OldTimestamp time.Time `json:"oldTimestamp"`
+	NewTimestamp time.Time `json:"newTimestamp"`
+}
+
+// DetectSilentRebuild compares the digests of each tag across an old and new
+// snapshot of an image's tags, returning an event for each tag that was
+// rebuilt with a new digest under the same name. Tags are compared per OS and
+// architecture, and tags without a digest in either snapshot, or only present
+// in one snapshot, are ignored. Events are sorted by tag.
+func DetectSilentRebuild(old, new []ImageTag) []RebuildEvent {
+	type key struct {
+		tag, os, arch string
+	}
+
+	previous := make(map[key]ImageTag)
+	for _, tag := range old {
+		if len(tag.SHA) > 0 {
+			previous[key{tag.Tag, tag.OS, tag.Architecture}] = tag
+		}
+	}
+
+	var events []RebuildEvent
+	for _, tag := range new {
+		oldTag, ok := previous[key{tag.Tag, tag.OS, tag.Architecture}]
+		if !ok || len(tag.SHA) == 0 || tag.SHA == oldTag.SHA {
+			continue
+		}
+
+		events = append(events, RebuildEvent{
+			Tag:          tag.Tag,
+			OS:           tag.OS,
+			Architecture: tag.Architecture,
+			OldSHA:       oldTag.SHA,
+			NewSHA:       tag.SHA,
+			OldTimestamp: oldTag.Timestamp,
+			NewTimestamp: tag.Timestamp,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Tag < events[j].Tag
+	})
+
+	return events
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectSilentRebuild(t *testing.T) {
+	before := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	after := before.Add(time.Hour * 24)
+
+	tests := map[string]struct {
+		old, new  []ImageTag
+		expEvents []RebuildEvent
+	}{
+		"unchanged tags should not be rebuilt": {
+			old: []ImageTag{{Tag: "v1.0.0", SHA: "sha256:a", Timestamp: before}},
+			new: []ImageTag{{Tag: "v1.0.0", SHA: "sha256:a", Timestamp: before}},
+		},
+		"new and removed tags should not be rebuilt": {
+			old: []ImageTag{{Tag: "v1.0.0", SHA: "sha256:a"}},
+			new: []ImageTag{{Tag: "v1.1.0", SHA: "sha256:b"}},
+		},
+		"tags without digests should be ignored": {
+			old: []ImageTag{{Tag: "v1.0.0"}},
+			new: []ImageTag{{Tag: "v1.0.0", SHA: "sha256:a"}},
+		},
+		"changed digests should be rebuilt": {
+			old: []ImageTag{
+				{Tag: "v1.0.0", SHA: "sha256:a", Timestamp: before},
+				{Tag: "latest", SHA: "sha256:b", Timestamp: before},
+				{Tag: "v0.9.0", SHA: "sha256:c", Timestamp: before},
+			},
+			new: []ImageTag{
+				{Tag: "v1.0.0", SHA: "sha256:d", Timestamp: after},
+				{Tag: "latest", SHA: "sha256:e", Timestamp: after},
+				{Tag: "v0.9.0", SHA: "sha256:c", Timestamp: before},
+			},
+			expEvents: []RebuildEvent{
+				{Tag: "latest", OldSHA: "sha256:b", NewSHA: "sha256:e", OldTimestamp: before, NewTimestamp: after},
+				{Tag: "v1.0.0", OldSHA: "sha256:a", NewSHA: "sha256:d", OldTimestamp: before, NewTimestamp: after},
+			},
+		},
+		"tags should be compared per platform": {
+			old: []ImageTag{
+				{Tag: "v1.0.0", SHA: "sha256:amd64", OS: "linux", Architecture: "amd64"},
+				{Tag: "v1.0.0", SHA: "sha256:arm64", OS: "linux", Architecture: "arm64"},
+			},
+			new: []ImageTag{
+				{Tag: "v1.0.0", SHA: "sha256:amd64", OS: "linux", Architecture: "amd64"},
+				{Tag: "v1.0.0", SHA: "sha256:arm64-rebuilt", OS: "linux", Architecture: "arm64"},
+			},
+			expEvents: []RebuildEvent{
+				{Tag: "v1.0.0", OS: "linux", Architecture: "arm64", OldSHA: "sha256:arm64", NewSHA: "sha256:arm64-rebuilt"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			events := DetectSilentRebuild(test.old, test.new)
+			if !reflect.DeepEqual(events, test.expEvents) {
+				t.Errorf("unexpected rebuild events, exp=%+v got=%+v", test.expEvents, events)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"strings"
+)
+
+const (
+	// DefaultRegistry is the registry used when an image reference doesn't
+	// contain a registry host.
+	DefaultRegistry = "docker.io"
+
+	// officialRepoPrefix is the namespace of official images on Docker Hub.
+	officialRepoPrefix = "library/"
+)
+
+// dockerHubAliases are registry hosts which are equivalent to Docker Hub.
+var dockerHubAliases = map[string]bool{
+	"docker.io":               true,
+	"index.docker.io":         true,
+	"registry-1.docker.io":    true,
+	"registry.hub.docker.com": true,
+}
+
+// ImageRef is a parsed container image reference.
+type ImageRef struct {
+	// Registry is the normalized registry host, including port if present.
+	// e.g. docker.io, quay.io, localhost:5000
+	Registry string
+
+	// Repository is the repository path within the registry.
+	// e.g. library/nginx, jetstack/version-checker
+	Repository string
+
+	Tag    string
+	Digest string
+}
+
+// ParseImageRef will parse the given image reference into its parts. Images
+// without a registry host are considered to be on Docker Hub, and official
+// Docker Hub images are given the library namespace.
+func ParseImageRef(ref string) ImageRef {
+	var imageRef ImageRef
+
+	if i := strings.Index(ref, "@"); i > -1 {
+		imageRef.Digest = ref[i+1:]
+		ref = ref[:i]
+	}
+
+	// A colon after the last slash is a tag, otherwise it is a port.
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		imageRef.Tag = ref[i+1:]
+		ref = ref[:i]
+	}
+
+	imageRef.Registry = DefaultRegistry
+	if i := strings.Index(ref, "/"); i > -1 && isRegistryHost(ref[:i]) {
+		imageRef.Registry = ref[:i]
+		ref = ref[i+1:]
+	}
+
+	if dockerHubAliases[imageRef.Registry] {
+		imageRef.Registry = DefaultRegistry
+		if !strings.Contains(ref, "/") {
+			ref = officialRepoPrefix + ref
+		}
+	}
+
+	imageRef.Repository = ref
+
+	return imageRef
+}
+
+// isRegistryHost returns true if the first component of an image reference
+// is a registry host, rather than a repository namespace.
+func isRegistryHost(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
+
+// PullReference returns the fully qualified reference to pull the given tag
+// of the registry and repository. The reference is pinned by digest if the tag
+// has one, otherwise by tag name. An empty registry is Docker Hub.
+// e.g. docker.io/library/nginx@sha256:..., quay.io/jetstack/app:v0.1.0
+func PullReference(registry, repo string, tag ImageTag) string {
+	if len(registry) == 0 {
+		registry = DefaultRegistry
+	}
+
+	ref := ParseImageRef(registry + "/" + repo)
+	name := ref.Registry + "/" + ref.Repository
+
+	if len(tag.SHA) > 0 {
+		return name + "@" + tag.SHA
+	}
+
+	return name + ":" + tag.Tag
+}
+
+// RegistryOf returns the normalized registry host of the image reference,
+// which is docker.io for references without a registry host.
+func RegistryOf(ref string) string {
+	return ParseImageRef(ref).Registry
+}
+
+// TagImmutability reports whether the tag of the image reference is
+// immutable on its registry, such as repositories with tag immutability
+// enabled. known is false if the registry's immutability is not known.
+type TagImmutability func(ref ImageRef) (immutable, known bool)
+
+// IsMutableReference returns true if the image reference may resolve to
+// different content over time. References pinned by digest, including those
+// with both a tag and digest, are immutable. Tag references, including those
+// with no tag which resolve to latest, are mutable unless a given
+// TagImmutability reports the tag is immutable.
+func IsMutableReference(ref string, immutability ...TagImmutability) bool {
+	imageRef := ParseImageRef(ref)
+	if len(imageRef.Digest) > 0 {
+		return false
+	}
+
+	if len(imageRef.Tag) == 0 {
+		imageRef.Tag = "latest"
+	}
+
+	for _, fn := range immutability {
+		if immutable, known := fn(imageRef); known {
+			return !immutable
+		}
+	}
+
+	return true
+}
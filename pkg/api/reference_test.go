@@ -0,0 +1,147 @@
+package api
+
+import "testing"
+
+func TestPullReference(t *testing.T) {
+	tests := map[string]struct {
+		registry, repo string
+		tag            ImageTag
+		expRef         string
+	}{
+		"docker hub official image should be given the library namespace": {
+			registry: "docker.io",
+			repo:     "nginx",
+			tag:      ImageTag{Tag: "1.19.0"},
+			expRef:   "docker.io/library/nginx:1.19.0",
+		},
+		"empty registry should be docker hub": {
+			repo:   "nginx",
+			tag:    ImageTag{Tag: "1.19.0"},
+			expRef: "docker.io/library/nginx:1.19.0",
+		},
+		"docker hub alias should be normalized": {
+			registry: "registry.hub.docker.com",
+			repo:     "jetstack/version-checker",
+			tag:      ImageTag{Tag: "v0.2.0"},
+			expRef:   "docker.io/jetstack/version-checker:v0.2.0",
+		},
+		"user repository should be kept": {
+			registry: "quay.io",
+			repo:     "jetstack/cert-manager-controller",
+			tag:      ImageTag{Tag: "v0.16.0"},
+			expRef:   "quay.io/jetstack/cert-manager-controller:v0.16.0",
+		},
+		"single segment repository on other registries should not be given the library namespace": {
+			registry: "localhost:5000",
+			repo:     "app",
+			tag:      ImageTag{Tag: "latest"},
+			expRef:   "localhost:5000/app:latest",
+		},
+		"tags with a digest should be pinned by digest": {
+			registry: "docker.io",
+			repo:     "nginx",
+			tag:      ImageTag{Tag: "1.19.0", SHA: "sha256:abc"},
+			expRef:   "docker.io/library/nginx@sha256:abc",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if ref := PullReference(test.registry, test.repo, test.tag); ref != test.expRef {
+				t.Errorf("unexpected pull reference, exp=%q got=%q", test.expRef, ref)
+			}
+		})
+	}
+}
+
+func TestRegistryOf(t *testing.T) {
+	tests := map[string]struct {
+		ref         string
+		expRegistry string
+	}{
+		"docker hub official shorthand": {
+			ref:         "nginx:1.19.0",
+			expRegistry: "docker.io",
+		},
+		"docker hub user shorthand": {
+			ref:         "jetstack/version-checker:v0.2.0",
+			expRegistry: "docker.io",
+		},
+		"docker hub alias should be normalized": {
+			ref:         "index.docker.io/library/nginx",
+			expRegistry: "docker.io",
+		},
+		"fully qualified host": {
+			ref:         "quay.io/jetstack/cert-manager-controller@sha256:abc",
+			expRegistry: "quay.io",
+		},
+		"localhost with port": {
+			ref:         "localhost:5000/app:latest",
+			expRegistry: "localhost:5000",
+		},
+		"localhost without port": {
+			ref:         "localhost/app",
+			expRegistry: "localhost",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if registry := RegistryOf(test.ref); registry != test.expRegistry {
+				t.Errorf("unexpected registry, exp=%q got=%q", test.expRegistry, registry)
+			}
+		})
+	}
+}
+
+func TestIsMutableReference(t *testing.T) {
+	immutableRepos := func(ref ImageRef) (bool, bool) {
+		if ref.Registry != "123456789012.dkr.ecr.eu-west-1.amazonaws.com" {
+			return false, false
+		}
+		return ref.Repository == "jetstack/immutable", true
+	}
+
+	tests := map[string]struct {
+		ref          string
+		immutability []TagImmutability
+		expMutable   bool
+	}{
+		"tag reference should be mutable": {
+			ref:        "quay.io/jetstack/app:v0.1.0",
+			expMutable: true,
+		},
+		"reference without tag should be mutable": {
+			ref:        "nginx",
+			expMutable: true,
+		},
+		"digest reference should be immutable": {
+			ref: "quay.io/jetstack/app@sha256:abc",
+		},
+		"tag and digest reference should be immutable": {
+			ref: "quay.io/jetstack/app:v0.1.0@sha256:abc",
+		},
+		"tag in immutable repository should be immutable": {
+			ref:          "123456789012.dkr.ecr.eu-west-1.amazonaws.com/jetstack/immutable:v0.1.0",
+			immutability: []TagImmutability{immutableRepos},
+		},
+		"tag in mutable repository should be mutable": {
+			ref:          "123456789012.dkr.ecr.eu-west-1.amazonaws.com/jetstack/app:v0.1.0",
+			immutability: []TagImmutability{immutableRepos},
+			expMutable:   true,
+		},
+		"tag with unknown immutability should be mutable": {
+			ref:          "quay.io/jetstack/immutable:v0.1.0",
+			immutability: []TagImmutability{immutableRepos},
+			expMutable:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if mutable := IsMutableReference(test.ref, test.immutability...); mutable != test.expMutable {
+				t.Errorf("unexpected mutable, exp=%t got=%t", test.expMutable, mutable)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RegistryCredentialResource is the cluster scoped RegistryCredential custom
+// resource, declaring the credentials of registry hosts.
+var RegistryCredentialResource = schema.GroupVersionResource{
+	Group:    "version-checker.io",
+	Version:  "v1alpha1",
+	Resource: "registrycredentials",
+}
+
+// RegistryCredential references a Secret holding credentials of a registry
+// host, used for all image lookups against the host.
+type RegistryCredential struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RegistryCredentialSpec `json:"spec"`
+}
+
+type RegistryCredentialSpec struct {
+	// Host is the registry host, including any port. Required for
+	// kubernetes.io/basic-auth secrets. For docker config secrets, only the
+	// credentials of Host are used, or of every host in the config if empty.
+	Host string `json:"host,omitempty"`
+
+	// SecretRef is the Secret holding the credentials, either a
+	// kubernetes.io/basic-auth, kubernetes.io/dockerconfigjson or
+	// kubernetes.io/dockercfg secret.
+	SecretRef SecretReference `json:"secretRef"`
+}
+
+type SecretReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
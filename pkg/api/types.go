@@ -3,23 +3,107 @@ package api
 import (
 	"regexp"
 	"time"
+
+	"github.com/jetstack/version-checker/pkg/version/semver"
 )
 
 const (
+	// AnnotationDomain is the domain of all annotation keys, which are
+	// suffixed with "/" and the name of the container they apply to.
+	AnnotationDomain = ".version-checker.io"
+
 	EnableAnnotationKey = "enable.version-checker.io"
 
 	UseSHAAnnotationKey     = "use-sha.version-checker.io"
 	MatchRegexAnnotationKey = "match-regex.version-checker.io"
 
+	// ExcludeRegexAnnotationKey excludes tags matching the regex from the
+	// latest image search, such as nightly or debug builds.
+	ExcludeRegexAnnotationKey = "exclude-regex.version-checker.io"
+
 	// MetaData is defined as a tag containing anything after the patch digit.
 	// e.g. v1.0.1-gke.3 v1.0.1-alpha.0, v1.2.3.4
 	UseMetaDataAnnotationKey = "use-metadata.version-checker.io"
 
+	// ChannelAnnotationKey restricts the latest image search to tags of a
+	// release channel, being a pre-release identifier such as rc, or stable.
+	ChannelAnnotationKey = "channel.version-checker.io"
+
 	PinMajorAnnotationKey = "pin-major.version-checker.io"
 	PinMinorAnnotationKey = "pin-minor.version-checker.io"
 	PinPatchAnnotationKey = "pin-patch.version-checker.io"
 
-	// TODO: set OS + arch options
+	// SemverConstraintAnnotationKey restricts the latest image search to tags
+	// satisfying a semver range expression, such as ">=1.4 <2.0" or "^1.4".
+	SemverConstraintAnnotationKey = "semver-constraint.version-checker.io"
+
+	// TagOrderingAnnotationKey sets how tags are ordered, one of TagOrdering.
+	TagOrderingAnnotationKey = "tag-ordering.version-checker.io"
+
+	// BuildIDRegexAnnotationKey sets the regex whose first capture group is
+	// the build ID of tags, for the numeric and lexical tag orderings.
+	BuildIDRegexAnnotationKey = "build-id-regex.version-checker.io"
+
+	// CacheTimeoutAnnotationKey overrides the time the latest image of the
+	// container is cached for, as a duration such as 5m.
+	CacheTimeoutAnnotationKey = "cache-timeout.version-checker.io"
+
+	// MatchOSAnnotationKey and MatchArchitectureAnnotationKey restrict the
+	// latest image search to tags built for the OS and architecture, such as
+	// linux and arm64.
+	MatchOSAnnotationKey           = "match-os.version-checker.io"
+	MatchArchitectureAnnotationKey = "match-architecture.version-checker.io"
+
+	// OriginalImageAnnotationKey is the image of the container before its tag
+	// was resolved to a digest at admission, so that the tag is still
+	// checked.
+	OriginalImageAnnotationKey = "original-image.version-checker.io"
+
+	// GitOpsRepoAnnotationKey, GitOpsPathAnnotationKey and
+	// GitOpsKeyAnnotationKey are the Git repository, such as
+	// github.com/org/repo, the path of the YAML file, and the dot separated
+	// key of the value in the file, the image of the container is tracked
+	// from. Pull requests bumping the value are opened when the image is
+	// outdated.
+	GitOpsRepoAnnotationKey = "gitops-repo.version-checker.io"
+	GitOpsPathAnnotationKey = "gitops-path.version-checker.io"
+	GitOpsKeyAnnotationKey  = "gitops-key.version-checker.io"
+
+	// GitOpsBranchAnnotationKey is the branch pull requests are opened
+	// against. Defaults to main.
+	GitOpsBranchAnnotationKey = "gitops-branch.version-checker.io"
+
+	// EnableAutoUpdateAnnotationKey enables patching the Deployment or
+	// StatefulSet owning the pod to the latest version of the image of the
+	// container, once outdated.
+	EnableAutoUpdateAnnotationKey = "enable-auto-update.version-checker.io"
+)
+
+// ChannelStable is the release channel of tags without metadata.
+const ChannelStable = "stable"
+
+// TagOrdering is how tags are ordered to find the latest.
+type TagOrdering string
+
+const (
+	// TagOrderingSemver orders tags by semver. This is the default.
+	TagOrderingSemver TagOrdering = "semver"
+
+	// TagOrderingDebian orders Debian style versions, such as 1:1.2.3-1, by
+	// epoch, then upstream version, then revision.
+	TagOrderingDebian TagOrdering = "debian"
+
+	// TagOrderingCalVer orders calendar versions, such as 2024.06.2 or
+	// 20240611, by year, then each following part numerically.
+	TagOrderingCalVer TagOrdering = "calver"
+
+	// TagOrderingNumeric orders tags by a build ID captured from each tag,
+	// such as 1041 of build-1041, compared numerically.
+	TagOrderingNumeric TagOrdering = "numeric"
+
+	// TagOrderingLexical orders tags by a build ID captured from each tag,
+	// or the whole tag, compared lexically.
+	TagOrderingLexical TagOrdering = "lexical"
 )
 
 // Options is used to describe what restrictions should be used for determining
@@ -30,15 +114,63 @@ type Options struct {
 
 	MatchRegex *string `json:"match-regex,omitempty"`
 
+	// ExcludeRegex excludes tags from the search before they are compared,
+	// regardless of the other options.
+	ExcludeRegex *string `json:"exclude-regex,omitempty"`
+
+	// AllowTags restricts tags to those listed, if any, and DenyTags excludes
+	// those listed, as curated by the tag policy of the image's repository.
+	AllowTags []string `json:"allow-tags,omitempty"`
+	DenyTags  []string `json:"deny-tags,omitempty"`
+
 	// UseMetaData defines whether tags with '-alpha', '-debian.0' etc. is
 	// permissible.
 	UseMetaData bool `json:"use-metadata,omitempty"`
 
+	// Channel restricts tags to those of the pre-release identifier, such as
+	// rc, or to those without metadata if ChannelStable.
+	Channel *string `json:"channel,omitempty"`
+
 	PinMajor *int64 `json:"pin-major,omitempty"`
 	PinMinor *int64 `json:"pin-minor,omitempty"`
 	PinPatch *int64 `json:"pin-patch,omitempty"`
 
-	RegexMatcher *regexp.Regexp
+	// SemverConstraint is a semver range expression tags must satisfy.
+	SemverConstraint *string `json:"semver-constraint,omitempty"`
+
+	// TagOrdering is how tags are ordered. Defaults to semver if unset.
+	TagOrdering TagOrdering `json:"tag-ordering,omitempty"`
+
+	// BuildIDRegex captures the build ID of tags in its first capture group,
+	// for the numeric and lexical tag orderings.
+	BuildIDRegex *string `json:"build-id-regex,omitempty"`
+
+	// Platform restricts tags to images built for the platform, such as that
+	// of the node running the container, when set.
+	Platform *Platform `json:"platform,omitempty"`
+
+	// RegexMatcher is the compiled MatchRegex. MatchRegex must always be set
+	// alongside so that the options are fully represented when serialized.
+	RegexMatcher *regexp.Regexp `json:"-"`
+
+	// BuildIDMatcher is the compiled BuildIDRegex, which must always be set
+	// alongside.
+	BuildIDMatcher *regexp.Regexp `json:"-"`
+
+	// ExcludeMatcher is the compiled ExcludeRegex, which must always be set
+	// alongside.
+	ExcludeMatcher *regexp.Regexp `json:"-"`
+
+	// ConstraintMatcher is the parsed SemverConstraint, which must always be
+	// set alongside.
+	ConstraintMatcher *semver.Constraint `json:"-"`
+}
+
+// Platform is the OS, architecture and variant an image is built for.
+type Platform struct {
+	OS           string `json:"os,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+	Variant      string `json:"variant,omitempty"`
 }
 
 // ImageTag describes a container image tag.
@@ -48,4 +180,20 @@ type ImageTag struct {
 	Timestamp    time.Time `json:"timestamp"`
 	Architecture string    `json:"architecture,omitempty"`
 	OS           string    `json:"os,omitempty"`
+	Variant      string    `json:"variant,omitempty"`
+
+	// IndexSHA is the digest of the manifest list of multi-arch tags, if the
+	// registry reports SHA as the digest of the tag's platform manifest.
+	IndexSHA string `json:"indexSHA,omitempty"`
+
+	// Labels are registry labels attached to this tag's image, if supported
+	// by the registry.
+	Labels []string `json:"labels,omitempty"`
+
+	// PullCount is the number of times this tag has been pulled, if known.
+	PullCount int64 `json:"pullCount,omitempty"`
+
+	// IsCurrentInMinor is true if this tag is the highest patch release of
+	// its major.minor version. Set by MarkCurrentInMinor.
+	IsCurrentInMinor bool `json:"isCurrentInMinor,omitempty"`
 }
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// VersionCheckPolicyResource is the cluster scoped VersionCheckPolicy custom
+// resource, declaring the options of containers in place of annotations.
+var VersionCheckPolicyResource = schema.GroupVersionResource{
+	Group:    "version-checker.io",
+	Version:  "v1alpha1",
+	Resource: "versioncheckpolicies",
+}
+
+// VersionCheckPolicy sets the options of containers whose namespace and image
+// are selected by the policy. Annotations of the pod take precedence over
+// policies of its namespace, which take precedence over policies of every
+// namespace.
+type VersionCheckPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VersionCheckPolicySpec `json:"spec"`
+}
+
+type VersionCheckPolicySpec struct {
+	// Namespaces are the namespaces the policy applies to, or every namespace
+	// if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Images are glob patterns of the image URLs, without tags, the policy
+	// applies to, such as docker.io/library/*. Applies to every image if
+	// empty.
+	Images []string `json:"images,omitempty"`
+
+	UseSHA            *bool   `json:"useSHA,omitempty"`
+	UseMetaData       *bool   `json:"useMetaData,omitempty"`
+	MatchRegex        *string `json:"matchRegex,omitempty"`
+	ExcludeRegex      *string `json:"excludeRegex,omitempty"`
+	Channel           *string `json:"channel,omitempty"`
+	PinMajor          *int64  `json:"pinMajor,omitempty"`
+	PinMinor          *int64  `json:"pinMinor,omitempty"`
+	PinPatch          *int64  `json:"pinPatch,omitempty"`
+	SemverConstraint  *string `json:"semverConstraint,omitempty"`
+	TagOrdering       *string `json:"tagOrdering,omitempty"`
+	BuildIDRegex      *string `json:"buildIDRegex,omitempty"`
+	MatchOS           *string `json:"matchOS,omitempty"`
+	MatchArchitecture *string `json:"matchArchitecture,omitempty"`
+
+	// CacheTimeout is the time the latest image of containers is cached for,
+	// as a duration such as 5m.
+	CacheTimeout *string `json:"cacheTimeout,omitempty"`
+}
+
+// Annotations returns the options of the policy as the annotations of the
+// container, such that they are built the same as those of pods.
+func (s *VersionCheckPolicySpec) Annotations(containerName string) map[string]string {
+	annotations := make(map[string]string)
+	set := func(key string, value *string) {
+		if value != nil {
+			annotations[key+"/"+containerName] = *value
+		}
+	}
+	setBool := func(key string, value *bool) {
+		if value != nil {
+			annotations[key+"/"+containerName] = strconv.FormatBool(*value)
+		}
+	}
+	setInt := func(key string, value *int64) {
+		if value != nil {
+			annotations[key+"/"+containerName] = strconv.FormatInt(*value, 10)
+		}
+	}
+
+	setBool(UseSHAAnnotationKey, s.UseSHA)
+	setBool(UseMetaDataAnnotationKey, s.UseMetaData)
+	set(MatchRegexAnnotationKey, s.MatchRegex)
+	set(ExcludeRegexAnnotationKey, s.ExcludeRegex)
+	set(ChannelAnnotationKey, s.Channel)
+	setInt(PinMajorAnnotationKey, s.PinMajor)
+	setInt(PinMinorAnnotationKey, s.PinMinor)
+	setInt(PinPatchAnnotationKey, s.PinPatch)
+	set(SemverConstraintAnnotationKey, s.SemverConstraint)
+	set(TagOrderingAnnotationKey, s.TagOrdering)
+	set(BuildIDRegexAnnotationKey, s.BuildIDRegex)
+	set(MatchOSAnnotationKey, s.MatchOS)
+	set(MatchArchitectureAnnotationKey, s.MatchArchitecture)
+	set(CacheTimeoutAnnotationKey, s.CacheTimeout)
+
+	return annotations
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// MarkCurrentInMinor will set IsCurrentInMinor on each tag which is the
+// highest patch release of its major.minor version. Tags with metadata, such
+// as pre-releases, are never current in their minor.
+func MarkCurrentInMinor(tags []ImageTag) {
+	latest := make(map[[2]int64]*semver.SemVer)
+	for _, tag := range tags {
+		v := semver.Parse(tag.Tag)
+		if v.HasMetaData() {
+			continue
+		}
+
+		minor := [2]int64{v.Major(), v.Minor()}
+		if current, ok := latest[minor]; !ok || current.LessThan(v) {
+			latest[minor] = v
+		}
+	}
+
+	for i := range tags {
+		v := semver.Parse(tags[i].Tag)
+		current, ok := latest[[2]int64{v.Major(), v.Minor()}]
+
+		tags[i].IsCurrentInMinor = ok && !v.HasMetaData() &&
+			!v.LessThan(current) && !current.LessThan(v)
+	}
+}
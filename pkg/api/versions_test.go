@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestMarkCurrentInMinor(t *testing.T) {
+	tests := map[string]struct {
+		tags    []string
+		current map[string]bool
+	}{
+		"highest patch of each minor should be current": {
+			tags: []string{"v1.0.0", "v1.0.1", "v1.0.2", "v1.1.0", "v1.1.1", "v2.0.0"},
+			current: map[string]bool{
+				"v1.0.2": true,
+				"v1.1.1": true,
+				"v2.0.0": true,
+			},
+		},
+		"pre-release of newer patch should not supersede": {
+			tags: []string{"v1.2.0", "v1.2.1", "v1.2.2-rc.0"},
+			current: map[string]bool{
+				"v1.2.1": true,
+			},
+		},
+		"aliased highest patch should all be current": {
+			tags: []string{"v1.2.0", "v1.2.3", "1.2.3"},
+			current: map[string]bool{
+				"v1.2.3": true,
+				"1.2.3":  true,
+			},
+		},
+		"non semver tags should not be current": {
+			tags:    []string{"latest", "main"},
+			current: map[string]bool{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var tags []ImageTag
+			for _, tag := range test.tags {
+				tags = append(tags, ImageTag{Tag: tag})
+			}
+
+			MarkCurrentInMinor(tags)
+
+			for _, tag := range tags {
+				if tag.IsCurrentInMinor != test.current[tag.Tag] {
+					t.Errorf("unexpected current in minor for %q, exp=%t got=%t",
+						tag.Tag, test.current[tag.Tag], tag.IsCurrentInMinor)
+				}
+			}
+		})
+	}
+}
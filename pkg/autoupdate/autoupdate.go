@@ -0,0 +1,245 @@
+package autoupdate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/controller"
+	"github.com/jetstack/version-checker/pkg/metrics"
+	"github.com/jetstack/version-checker/pkg/version"
+)
+
+const (
+	// ReasonImageUpdated is the reason of events of workloads whose image was
+	// updated.
+	ReasonImageUpdated = "ImageUpdated"
+
+	// ReasonImageUpdateDryRun is the reason of events of workloads whose
+	// image would have been updated, if not a dry run.
+	ReasonImageUpdateDryRun = "ImageUpdateDryRun"
+)
+
+// workload is a Deployment or StatefulSet.
+type workload interface {
+	runtime.Object
+	metav1.Object
+}
+
+// Options used to configure automatic updates of workload images.
+type Options struct {
+	// DryRun records the updates which would be made as events, without
+	// updating workloads.
+	DryRun bool
+}
+
+// Updater updates the image of the Deployment or StatefulSet owning a pod to
+// the latest version, once the image of a container with the enable auto
+// update annotation is outdated. The latest version is found with the
+// options of the container, so respects its semver constraint and pins.
+type Updater struct {
+	log        *logrus.Entry
+	kubeClient kubernetes.Interface
+	recorder   record.EventRecorder
+	opts       Options
+
+	// dryRun is the latest version each container of a workload was last
+	// recorded as updated to in dry run, so that the pods of a workload
+	// record a single event. Only accessed by the update worker.
+	dryRun map[string]string
+}
+
+func New(log *logrus.Entry, kubeClient kubernetes.Interface, recorder record.EventRecorder, opts Options) *Updater {
+	return &Updater{
+		log:        log.WithField("module", "autoupdate"),
+		kubeClient: kubeClient,
+		recorder:   recorder,
+		opts:       opts,
+		dryRun:     make(map[string]string),
+	}
+}
+
+// Run will watch the results of image checks, updating the workloads of
+// outdated images until the context is done.
+func (u *Updater) Run(ctx context.Context, watcher metrics.Watcher) {
+	metrics.WatchOutdated(ctx, u.log, watcher, u.update)
+}
+
+// update will update the image of the workload owning the pod of the image,
+// if the enable auto update annotation of its container is set.
+func (u *Updater) update(ctx context.Context, image metrics.Image) error {
+	pod, err := u.kubeClient.CoreV1().Pods(image.Namespace).Get(ctx, image.Pod, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %s", err)
+	}
+
+	if pod.Annotations[api.EnableAutoUpdateAnnotationKey+"/"+image.Container] != "true" {
+		return nil
+	}
+
+	// Only update to a strictly newer version, by the tag ordering the latest
+	// version was selected with, so that images are never downgraded.
+	opts, err := controller.BuildOptions(image.Container, pod.Annotations, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build version options: %s", err)
+	}
+	if c, ok := version.CompareTags(opts, image.CurrentVersion, image.LatestVersion); !ok || c >= 0 {
+		u.log.Debugf("latest version %q of %s/%s %s is not newer than %q, not updating",
+			image.LatestVersion, image.Namespace, image.Pod, image.Container, image.CurrentVersion)
+		return nil
+	}
+
+	kind, name, err := u.owner(ctx, pod)
+	if err != nil {
+		return err
+	}
+
+	apps := u.kubeClient.AppsV1()
+
+	switch kind {
+	case "Deployment":
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			deploy, err := apps.Deployments(image.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			return u.updatePodSpec(deploy, kind, &deploy.Spec.Template.Spec, image, func() error {
+				_, err := apps.Deployments(image.Namespace).Update(ctx, deploy, metav1.UpdateOptions{})
+				return err
+			})
+		})
+
+	case "StatefulSet":
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			sts, err := apps.StatefulSets(image.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			return u.updatePodSpec(sts, kind, &sts.Spec.Template.Spec, image, func() error {
+				_, err := apps.StatefulSets(image.Namespace).Update(ctx, sts, metav1.UpdateOptions{})
+				return err
+			})
+		})
+
+	default:
+		u.log.Debugf("pod %s/%s is not owned by a Deployment or StatefulSet, not updating",
+			pod.Namespace, pod.Name)
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to update %s %s/%s: %s", kind, image.Namespace, name, err)
+	}
+
+	return nil
+}
+
+// owner returns the kind and name of the Deployment or StatefulSet
+// controlling the pod, or empty if neither.
+func (u *Updater) owner(ctx context.Context, pod *corev1.Pod) (string, string, error) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return "", "", nil
+	}
+
+	switch ref.Kind {
+	case "StatefulSet":
+		return ref.Kind, ref.Name, nil
+
+	case "ReplicaSet":
+		rs, err := u.kubeClient.AppsV1().ReplicaSets(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return "", "", nil
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get replicaset: %s", err)
+		}
+
+		if ref := metav1.GetControllerOf(rs); ref != nil && ref.Kind == "Deployment" {
+			return ref.Kind, ref.Name, nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// updatePodSpec will update the image of the container of the pod spec of the
+// workload from the current to the latest version, calling update to persist
+// the workload and recording an event of the update. Containers whose image
+// is not of the current version, such as already updated or pinned by
+// digest, are left as is. In dry run, only the event is recorded.
+func (u *Updater) updatePodSpec(obj workload, kind string, spec *corev1.PodSpec,
+	image metrics.Image, update func() error) error {
+	var container *corev1.Container
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			if containers[i].Name == image.Container {
+				container = &containers[i]
+			}
+		}
+	}
+	if container == nil {
+		return nil
+	}
+
+	from := container.Image
+	to, ok := updateTag(from, image.CurrentVersion, image.LatestVersion)
+	if !ok {
+		u.log.Debugf("image %q of %s %s/%s is not of version %q, not updating",
+			from, kind, obj.GetNamespace(), obj.GetName(), image.CurrentVersion)
+		return nil
+	}
+
+	if u.opts.DryRun {
+		key := strings.Join([]string{kind, obj.GetNamespace(), obj.GetName(), container.Name}, "/")
+		if u.dryRun[key] == image.LatestVersion {
+			return nil
+		}
+		u.dryRun[key] = image.LatestVersion
+
+		u.log.Infof("dry run: would update image of %s %s/%s container %s from %s to %s",
+			kind, obj.GetNamespace(), obj.GetName(), container.Name, from, to)
+		u.recorder.Eventf(obj, corev1.EventTypeNormal, ReasonImageUpdateDryRun,
+			"Dry run: would update image of container %s from %s to %s", container.Name, from, to)
+
+		return nil
+	}
+
+	container.Image = to
+	if err := update(); err != nil {
+		return err
+	}
+
+	u.log.Infof("updated image of %s %s/%s container %s from %s to %s",
+		kind, obj.GetNamespace(), obj.GetName(), container.Name, from, to)
+	u.recorder.Eventf(obj, corev1.EventTypeNormal, ReasonImageUpdated,
+		"Updated image of container %s from %s to %s", container.Name, from, to)
+
+	return nil
+}
+
+// updateTag returns the image with its tag updated from the current to the
+// latest version, or false if the image is not tagged with the current
+// version.
+func updateTag(image, currentVersion, latestVersion string) (string, bool) {
+	if strings.Contains(image, "@") || !strings.HasSuffix(image, ":"+currentVersion) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(image, currentVersion) + latestVersion, true
+}
@@ -0,0 +1,226 @@
+package autoupdate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+func TestUpdate(t *testing.T) {
+	image := metrics.Image{
+		Namespace:      "dev",
+		Pod:            "web-5d4f-abcde",
+		Container:      "nginx",
+		Image:          "docker.io/library/nginx",
+		CurrentVersion: "1.19.6",
+		LatestVersion:  "1.21.0",
+	}
+
+	isController := true
+	enabled := map[string]string{"enable-auto-update.version-checker.io/nginx": "true"}
+
+	pod := func(annotations map[string]string, ownerKind, ownerName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "dev",
+				Name:        "web-5d4f-abcde",
+				Annotations: annotations,
+				OwnerReferences: []metav1.OwnerReference{{
+					Kind:       ownerKind,
+					Name:       ownerName,
+					Controller: &isController,
+				}},
+			},
+		}
+	}
+	podSpec := func(image string) corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "sidecar", Image: "envoyproxy/envoy:v1.16.0"},
+					{Name: "nginx", Image: image},
+				},
+			},
+		}
+	}
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "dev",
+			Name:      "web-5d4f",
+			OwnerReferences: []metav1.OwnerReference{{
+				Kind:       "Deployment",
+				Name:       "web",
+				Controller: &isController,
+			}},
+		},
+	}
+	deployment := func(image string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "dev", Name: "web"},
+			Spec:       appsv1.DeploymentSpec{Template: podSpec(image)},
+		}
+	}
+
+	tests := map[string]struct {
+		objects   []runtime.Object
+		image     *metrics.Image
+		dryRun    bool
+		expImage  string
+		expEvents []string
+	}{
+		"deployment of pod with annotation should be updated": {
+			objects:   []runtime.Object{pod(enabled, "ReplicaSet", "web-5d4f"), replicaSet, deployment("nginx:1.19.6")},
+			expImage:  "nginx:1.21.0",
+			expEvents: []string{"Normal ImageUpdated Updated image of container nginx from nginx:1.19.6 to nginx:1.21.0"},
+		},
+		"deployment of pod without annotation should not be updated": {
+			objects:  []runtime.Object{pod(nil, "ReplicaSet", "web-5d4f"), replicaSet, deployment("nginx:1.19.6")},
+			expImage: "nginx:1.19.6",
+		},
+		"dry run should only record an event once": {
+			objects:   []runtime.Object{pod(enabled, "ReplicaSet", "web-5d4f"), replicaSet, deployment("nginx:1.19.6")},
+			dryRun:    true,
+			expImage:  "nginx:1.19.6",
+			expEvents: []string{"Normal ImageUpdateDryRun Dry run: would update image of container nginx from nginx:1.19.6 to nginx:1.21.0"},
+		},
+		"deployment of another version should not be updated": {
+			objects:  []runtime.Object{pod(enabled, "ReplicaSet", "web-5d4f"), replicaSet, deployment("nginx:1.20.0")},
+			expImage: "nginx:1.20.0",
+		},
+		"statefulset of pod with annotation should be updated": {
+			objects: []runtime.Object{
+				pod(enabled, "StatefulSet", "web"),
+				&appsv1.StatefulSet{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "dev", Name: "web"},
+					Spec:       appsv1.StatefulSetSpec{Template: podSpec("nginx:1.19.6")},
+				},
+			},
+			expImage:  "nginx:1.21.0",
+			expEvents: []string{"Normal ImageUpdated Updated image of container nginx from nginx:1.19.6 to nginx:1.21.0"},
+		},
+		"older latest version should not downgrade": {
+			objects: []runtime.Object{pod(enabled, "ReplicaSet", "web-5d4f"), replicaSet, deployment("nginx:1.21.0")},
+			image: &metrics.Image{
+				Namespace:      "dev",
+				Pod:            "web-5d4f-abcde",
+				Container:      "nginx",
+				Image:          "docker.io/library/nginx",
+				CurrentVersion: "1.21.0",
+				LatestVersion:  "1.9.0",
+			},
+			expImage: "nginx:1.21.0",
+		},
+		"older latest version by tag ordering should not downgrade": {
+			objects: []runtime.Object{
+				pod(map[string]string{
+					"enable-auto-update.version-checker.io/nginx": "true",
+					"tag-ordering.version-checker.io/nginx":       "debian",
+				}, "ReplicaSet", "web-5d4f"),
+				replicaSet, deployment("nginx:1:1.0-1"),
+			},
+			image: &metrics.Image{
+				Namespace:      "dev",
+				Pod:            "web-5d4f-abcde",
+				Container:      "nginx",
+				Image:          "docker.io/library/nginx",
+				CurrentVersion: "1:1.0-1",
+				LatestVersion:  "2.0-1",
+			},
+			expImage: "nginx:1:1.0-1",
+		},
+		"pod of a daemonset should be ignored": {
+			objects: []runtime.Object{pod(enabled, "DaemonSet", "web")},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(test.objects...)
+			recorder := record.NewFakeRecorder(10)
+
+			u := New(logrus.NewEntry(logrus.New()), kubeClient, recorder, Options{DryRun: test.dryRun})
+
+			image := image
+			if test.image != nil {
+				image = *test.image
+			}
+
+			// Updating again, such as for another pod of the workload, should
+			// not record another event.
+			for i := 0; i < 2; i++ {
+				if err := u.update(context.TODO(), image); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var template *corev1.PodTemplateSpec
+			if deploy, err := kubeClient.AppsV1().Deployments("dev").Get(context.TODO(), "web", metav1.GetOptions{}); err == nil {
+				template = &deploy.Spec.Template
+			}
+			if sts, err := kubeClient.AppsV1().StatefulSets("dev").Get(context.TODO(), "web", metav1.GetOptions{}); err == nil {
+				template = &sts.Spec.Template
+			}
+
+			if template != nil {
+				if got := template.Spec.Containers[1].Image; got != test.expImage {
+					t.Errorf("unexpected image, exp=%q got=%q", test.expImage, got)
+				}
+				if got := template.Spec.Containers[0].Image; got != "envoyproxy/envoy:v1.16.0" {
+					t.Errorf("unexpected update of other container, got=%q", got)
+				}
+			}
+
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if len(events) != len(test.expEvents) {
+				t.Fatalf("unexpected events, exp=%q got=%q", test.expEvents, events)
+			}
+			for i := range events {
+				if events[i] != test.expEvents[i] {
+					t.Errorf("unexpected event, exp=%q got=%q", test.expEvents[i], events[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateTag(t *testing.T) {
+	tests := map[string]struct {
+		image string
+		exp   string
+		expOK bool
+	}{
+		"tag of the current version should be updated": {
+			image: "localhost:5000/nginx:1.19.6",
+			exp:   "localhost:5000/nginx:1.21.0",
+			expOK: true,
+		},
+		"tag of another version should not be updated": {
+			image: "nginx:1.19.60",
+		},
+		"image pinned by digest should not be updated": {
+			image: "nginx:1.19.6@sha256:abc",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := updateTag(test.image, "1.19.6", "1.21.0")
+			if ok != test.expOK || got != test.exp {
+				t.Errorf("unexpected update, exp=%q,%t got=%q,%t", test.exp, test.expOK, got, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// memorySweepInterval is the minimum time between sweeps of expired
+	// items from a Memory cache.
+	memorySweepInterval = time.Minute
+)
+
+// Cache stores values under keys until their TTL expires. It may be shared
+// between replicas, so values should not depend on local state.
+type Cache interface {
+	// Get returns the value of the key, and false if there is no value or it
+	// has expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores the value under the key, replacing any existing value, until
+	// the TTL expires.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// Memory is a Cache held in memory, which is lost on restart.
+type Memory struct {
+	mu        sync.Mutex
+	items     map[string]memoryItem
+	lastSweep time.Time
+}
+
+type memoryItem struct {
+	value   []byte
+	expires time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		items:     make(map[string]memoryItem),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !item.expires.After(time.Now()) {
+		delete(m.items, key)
+		return nil, false, nil
+	}
+
+	return item.value, true, nil
+}
+
+// Set will store the value, sweeping expired items if it has not done so for
+// memorySweepInterval.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.lastSweep) >= memorySweepInterval {
+		for key, item := range m.items {
+			if !item.expires.After(now) {
+				delete(m.items, key)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	m.items[key] = memoryItem{value: value, expires: now.Add(ttl)}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	ctx := context.TODO()
+
+	if _, ok, err := m.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("unexpected hit of missing key, got=%t %v", ok, err)
+	}
+
+	if err := m.Set(ctx, "fresh", []byte("a"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set(ctx, "expired", []byte("b"), -time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, ok, err := m.Get(ctx, "fresh"); !ok || err != nil || string(value) != "a" {
+		t.Errorf("unexpected value of fresh key, exp=%q got=%q (%t %v)", "a", value, ok, err)
+	}
+	if _, ok, err := m.Get(ctx, "expired"); ok || err != nil {
+		t.Errorf("unexpected hit of expired key, got=%t %v", ok, err)
+	}
+
+	// Expired items are swept on set.
+	m.Set(ctx, "expired", []byte("b"), -time.Second)
+	m.lastSweep = time.Now().Add(-memorySweepInterval)
+	m.Set(ctx, "other", []byte("c"), time.Hour)
+	if _, ok := m.items["expired"]; ok {
+		t.Error("expected expired item to be swept")
+	}
+}
@@ -0,0 +1,242 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	// redisMaxIdleConns is the number of idle connections kept open to the
+	// Redis server.
+	redisMaxIdleConns = 5
+)
+
+// RedisOptions configure the connection to a Redis server.
+type RedisOptions struct {
+	// Address is the host:port of the Redis server.
+	Address string
+
+	// Username and Password authenticate with the server, if set. Username
+	// requires Redis 6 ACLs.
+	Username string
+	Password string
+
+	// Database is the logical database selected on connection.
+	Database int
+
+	// KeyPrefix is prepended to all keys, so that a server may be shared.
+	KeyPrefix string
+
+	// TLS will connect to the server over TLS.
+	TLS bool
+
+	// Timeout of dialling and each command. Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// Redis is a Cache stored in a Redis server, so that it is shared between
+// replicas and survives restarts.
+type Redis struct {
+	opts RedisOptions
+
+	// idle holds connections ready for reuse.
+	idle chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply of the Redis server.
+type redisError string
+
+func (r redisError) Error() string {
+	return "redis: " + string(r)
+}
+
+func NewRedis(opts RedisOptions) (*Redis, error) {
+	if len(opts.Address) == 0 {
+		return nil, errors.New("redis address must be set")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second * 5
+	}
+
+	return &Redis{
+		opts: opts,
+		idle: make(chan *redisConn, redisMaxIdleConns),
+	}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", r.opts.KeyPrefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if reply == nil {
+		return nil, false, nil
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected redis reply to GET: %v", reply)
+	}
+
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ms := ttl.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+
+	_, err := r.do(ctx, "SET", r.opts.KeyPrefix+key, string(value), "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+// do will run the command on an idle or new connection, returning the reply.
+// Connections are only reused if the command completed.
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(ctx, r.opts.Timeout, args...)
+	if _, ok := err.(redisError); err != nil && !ok {
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case r.idle <- conn:
+	default:
+		conn.Close()
+	}
+
+	return reply, err
+}
+
+// conn returns an idle connection, or dials a new one, authenticating and
+// selecting the database.
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: r.opts.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", r.opts.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %s", err)
+	}
+
+	if r.opts.TLS {
+		host, _, err := net.SplitHostPort(r.opts.Address)
+		if err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("invalid redis address %q: %s", r.opts.Address, err)
+		}
+
+		tlsConn := tls.Client(netConn, &tls.Config{ServerName: host})
+		tlsConn.SetDeadline(time.Now().Add(r.opts.Timeout))
+		if err := tlsConn.Handshake(); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("failed to connect to redis: %s", err)
+		}
+		netConn = tlsConn
+	}
+
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if len(r.opts.Password) > 0 {
+		args := []string{"AUTH", r.opts.Password}
+		if len(r.opts.Username) > 0 {
+			args = []string{"AUTH", r.opts.Username, r.opts.Password}
+		}
+		if _, err := conn.do(ctx, r.opts.Timeout, args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to authenticate with redis: %s", err)
+		}
+	}
+
+	if r.opts.Database > 0 {
+		if _, err := conn.do(ctx, r.opts.Timeout, "SELECT", strconv.Itoa(r.opts.Database)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select redis database: %s", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// do will write the command as an array of bulk strings, and read the reply.
+func (c *redisConn) do(ctx context.Context, timeout time.Duration, args ...string) (interface{}, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	cmd := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		cmd = append(cmd, "$"+strconv.Itoa(len(arg))+"\r\n"+arg+"\r\n"...)
+	}
+	if _, err := c.Write(cmd); err != nil {
+		return nil, fmt.Errorf("failed to write redis command: %s", err)
+	}
+
+	return c.readReply()
+}
+
+// readReply reads a reply of the RESP protocol. Nil bulk strings are returned
+// as nil, and bulk strings as bytes. Arrays are not used by any command sent.
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redis reply: %s", err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed redis reply: %q", line)
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis bulk string length: %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		value := make([]byte, n+2)
+		if _, err := io.ReadFull(c.reader, value); err != nil {
+			return nil, fmt.Errorf("failed to read redis reply: %s", err)
+		}
+
+		return value[:n], nil
+	default:
+		return nil, fmt.Errorf("unsupported redis reply: %q", line)
+	}
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a Redis server supporting the commands used by the cache.
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu       sync.Mutex
+	items    map[string]string
+	ttls     map[string]string
+	commands []string
+	conns    int
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeRedis{
+		ln:       ln,
+		password: password,
+		items:    make(map[string]string),
+		ttls:     make(map[string]string),
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns++
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := len(f.password) == 0
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		f.commands = append(f.commands, args[0])
+		var reply string
+		switch {
+		case args[0] == "AUTH":
+			if args[len(args)-1] != f.password {
+				reply = "-WRONGPASS invalid password\r\n"
+			} else {
+				authed = true
+				reply = "+OK\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "SELECT":
+			reply = "+OK\r\n"
+		case args[0] == "GET":
+			if value, ok := f.items[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case args[0] == "SET":
+			f.items[args[1]] = args[2]
+			f.ttls[args[1]] = strings.Join(args[3:], " ")
+			reply = "+OK\r\n"
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	defer server.ln.Close()
+
+	r, err := NewRedis(RedisOptions{
+		Address:   server.ln.Addr().String(),
+		Password:  "secret",
+		Database:  2,
+		KeyPrefix: "vc:",
+		Timeout:   time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+
+	if _, ok, err := r.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("unexpected hit of missing key, got=%t %v", ok, err)
+	}
+
+	value := "multi\r\nline value"
+	if err := r.Set(ctx, "tags:nginx", []byte(value), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := r.Get(ctx, "tags:nginx")
+	if !ok || err != nil || string(got) != value {
+		t.Errorf("unexpected value, exp=%q got=%q (%t %v)", value, got, ok, err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if ttl := server.ttls["vc:tags:nginx"]; ttl != "PX 60000" {
+		t.Errorf("unexpected ttl of prefixed key, exp=%q got=%q", "PX 60000", ttl)
+	}
+	if exp := "AUTH,SELECT,GET,SET,GET"; strings.Join(server.commands, ",") != exp {
+		t.Errorf("unexpected commands, exp=%s got=%s", exp, strings.Join(server.commands, ","))
+	}
+	if server.conns != 1 {
+		t.Errorf("expected connection to be reused, exp=1 got=%d", server.conns)
+	}
+}
+
+func TestRedisAuthError(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	defer server.ln.Close()
+
+	r, err := NewRedis(RedisOptions{Address: server.ln.Addr().String(), Password: "wrong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := r.Get(context.TODO(), "key"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("expected authentication error, got=%v", err)
+	}
+}
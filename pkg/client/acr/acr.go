@@ -0,0 +1,202 @@
+package acr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	tagsURL = "https://%s/acr/v1/%s/_tags?n=100"
+)
+
+var (
+	// hostRegex matches ACR registry hosts of the public and sovereign clouds.
+	// e.g. myregistry.azurecr.io
+	hostRegex = regexp.MustCompile(`^[a-z0-9]+\.azurecr\.(io|cn|us)$`)
+
+	// linkNextRegex matches the URL of the next page of a Link header.
+	linkNextRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+type Options struct {
+	// Username and Password of the admin user, a repository scoped token, or
+	// a service principal client ID and secret.
+	Username string
+	Password string
+
+	// UseWorkloadIdentity will exchange the federated token of Azure Workload
+	// Identity for ACR tokens, configured by the environment set by the
+	// workload identity webhook.
+	UseWorkloadIdentity bool
+
+	// UseManagedIdentity will exchange an AAD token of the managed identity
+	// for ACR tokens, requested from the instance metadata service.
+	UseManagedIdentity bool
+
+	// ManagedIdentityClientID selects a user assigned managed identity. If
+	// empty, the system assigned identity is used.
+	ManagedIdentityClientID string
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+}
+
+type Client struct {
+	*http.Client
+	Options
+
+	tokens *tokenCache
+}
+
+type TagsResponse struct {
+	Tags []Tag `json:"tags"`
+}
+
+type Tag struct {
+	Name           string    `json:"name"`
+	Digest         string    `json:"digest"`
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+}
+
+func New(opts Options) (*Client, error) {
+	if opts.UseWorkloadIdentity && opts.UseManagedIdentity {
+		return nil, errors.New("cannot use both workload identity and managed identity")
+	}
+	if (opts.UseWorkloadIdentity || opts.UseManagedIdentity) && (len(opts.Username) > 0 || len(opts.Password) > 0) {
+		return nil, errors.New("cannot specify username/password as well as an identity")
+	}
+
+	return &Client{
+		Options: opts,
+		Client: &http.Client{
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
+		},
+		tokens: &tokenCache{
+			refresh: make(map[string]*token),
+			access:  make(map[string]*token),
+		},
+	}, nil
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	return hostRegex.MatchString(api.ParseImageRef(imageURL).Registry)
+}
+
+// Tags will list the tags of the image's repository with the ACR API. Tags
+// are timestamped by when they were last updated.
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	ref := api.ParseImageRef(imageURL)
+
+	var tags []api.ImageTag
+	url := fmt.Sprintf(tagsURL, ref.Registry, ref.Repository)
+	for len(url) > 0 {
+		var response TagsResponse
+		header, err := c.doRequest(ctx, ref.Registry, ref.Repository, url, &response)
+		if err != nil {
+			return nil, err
+		}
+		util.CountPage(ctx)
+
+		for _, tag := range response.Tags {
+			tags = append(tags, api.ImageTag{
+				Tag:       tag.Name,
+				SHA:       tag.Digest,
+				Timestamp: tag.LastUpdateTime,
+			})
+		}
+
+		url = ""
+		if match := linkNextRegex.FindStringSubmatch(header.Get("Link")); len(match) == 2 {
+			url = match[1]
+			// Link is relative to the registry host.
+			if strings.HasPrefix(url, "/") {
+				url = "https://" + ref.Registry + url
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+func (c *Client) useAAD() bool {
+	return c.UseWorkloadIdentity || c.UseManagedIdentity
+}
+
+// doRequest will request the URL with an access token of the repository. If
+// the registry rejects the token, such as when it was revoked or expired
+// early, new tokens are requested and the request retried once.
+func (c *Client) doRequest(ctx context.Context, host, repo, url string, obj interface{}) (http.Header, error) {
+	resp, body, err := c.authorizedRequest(ctx, host, repo, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.tokens.reset(host)
+
+		resp, body, err = c.authorizedRequest(ctx, host, repo, url)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := util.NotFound(resp, url, body); err != nil {
+		return nil, err
+	}
+
+	if err := util.ErrorEnvelope(body); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from %q: %d %s",
+			url, resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, obj); err != nil {
+		return nil, fmt.Errorf("unexpected response from %q: %s", url, body)
+	}
+
+	return resp.Header, nil
+}
+
+// authorizedRequest will get the URL with an access token of the repository.
+func (c *Client) authorizedRequest(ctx context.Context, host, repo, url string) (*http.Response, []byte, error) {
+	token, err := c.accessToken(ctx, host, repo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to authenticate with %q: %s", host, err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req = req.WithContext(ctx)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get acr image: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, body, nil
+}
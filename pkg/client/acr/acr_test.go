@@ -0,0 +1,201 @@
+package acr
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testJWT returns an unsigned JWT with the id and expiry.
+func testJWT(id string, expiry time.Time) string {
+	payload := fmt.Sprintf(`{"jti":%q,"exp":%d}`, id, expiry.Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".sig"
+}
+
+// testRegistry serves AAD and an ACR registry, accepting only the latest
+// access token it issued.
+type testRegistry struct {
+	mu        sync.Mutex
+	exchanges int
+	access    string
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	req.ParseForm()
+
+	switch req.URL.Path {
+	case "/tenant/oauth2/v2.0/token":
+		if req.PostForm.Get("client_assertion") != "federated-token" ||
+			req.PostForm.Get("client_id") != "client-id" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"access_token":"aad-token","expires_in":3600}`))
+
+	case "/oauth2/exchange":
+		if req.PostForm.Get("access_token") != "aad-token" ||
+			req.PostForm.Get("service") != "myregistry.azurecr.io" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.exchanges++
+		fmt.Fprintf(w, `{"refresh_token":%q}`, testJWT(fmt.Sprintf("refresh-%d", r.exchanges), time.Now().Add(3*time.Hour)))
+
+	case "/oauth2/token":
+		if !strings.HasPrefix(req.PostForm.Get("refresh_token"), "ey") ||
+			req.PostForm.Get("scope") != "repository:jetstack/app:pull" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.access = testJWT(fmt.Sprintf("access-%d", r.exchanges), time.Now().Add(time.Hour))
+		fmt.Fprintf(w, `{"access_token":%q}`, r.access)
+
+	case "/acr/v1/jetstack/app/_tags":
+		if req.Header.Get("Authorization") != "Bearer "+r.access {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`))
+			return
+		}
+
+		if req.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</acr/v1/jetstack/app/_tags?last=v0.1.0&n=100>; rel="next"`)
+			w.Write([]byte(`{"tags":[{"name":"v0.1.0","digest":"sha256:a","lastUpdateTime":"2020-06-01T10:00:00Z"}]}`))
+			return
+		}
+		w.Write([]byte(`{"tags":[{"name":"v0.2.0","digest":"sha256:b","lastUpdateTime":"2020-07-01T10:00:00Z"}]}`))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestTagsWorkloadIdentity(t *testing.T) {
+	registry := new(testRegistry)
+	server := httptest.NewTLSServer(registry)
+	defer server.Close()
+
+	// The test server can't have an ACR host, so redirect requests to it.
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, strings.TrimPrefix(server.URL, "https://"))
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = true
+
+	dir, err := ioutil.TempDir("", "version-checker-acr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("federated-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, v := range map[string]string{
+		"AZURE_FEDERATED_TOKEN_FILE": tokenFile,
+		"AZURE_CLIENT_ID":            "client-id",
+		"AZURE_TENANT_ID":            "tenant",
+		"AZURE_AUTHORITY_HOST":       "https://login.microsoftonline.com/",
+	} {
+		defer os.Setenv(k, os.Getenv(k))
+		os.Setenv(k, v)
+	}
+
+	client, err := New(Options{UseWorkloadIdentity: true, Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imageURL := "myregistry.azurecr.io/jetstack/app"
+	if !client.IsClient(imageURL) {
+		t.Fatalf("expected client to match %q", imageURL)
+	}
+
+	for i := 0; i < 2; i++ {
+		tags, err := client.Tags(context.TODO(), imageURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tags) != 2 || tags[0].Tag != "v0.1.0" || tags[1].Tag != "v0.2.0" ||
+			tags[1].SHA != "sha256:b" || !tags[1].Timestamp.Equal(time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected tags, got=%+v", tags)
+		}
+	}
+
+	if registry.exchanges != 1 {
+		t.Errorf("expected refresh token to be cached, exp=1 got=%d", registry.exchanges)
+	}
+
+	// Revoking the access token mid-run should request new tokens.
+	registry.mu.Lock()
+	registry.access = "revoked"
+	registry.mu.Unlock()
+
+	if _, err := client.Tags(context.TODO(), imageURL); err != nil {
+		t.Fatal(err)
+	}
+
+	if registry.exchanges != 2 {
+		t.Errorf("expected new refresh token after revocation, exp=2 got=%d", registry.exchanges)
+	}
+}
+
+func TestJWTToken(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tok, err := jwtToken(testJWT("id", expiry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tok.expiry.Equal(expiry) || !tok.valid() {
+		t.Errorf("unexpected token expiry, exp=%s got=%s", expiry, tok.expiry)
+	}
+
+	// Tokens expiring within the leeway should be refreshed.
+	tok, err = jwtToken(testJWT("id", time.Now().Add(30*time.Second)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.valid() {
+		t.Error("expected token expiring within leeway to be invalid")
+	}
+
+	if _, err := jwtToken("not-a-jwt"); err == nil {
+		t.Error("expected error for non JWT token")
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	client, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for imageURL, exp := range map[string]bool{
+		"myregistry.azurecr.io/jetstack/app": true,
+		"myregistry.azurecr.cn/jetstack/app": true,
+		"azurecr.io/jetstack/app":            false,
+		"myregistry.azurecr.io.evil/app":     false,
+		"quay.io/jetstack/app":               false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package acr
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// tokenExpiryLeeway is how long before expiry a token is refreshed.
+	tokenExpiryLeeway = time.Minute
+
+	defaultAuthorityHost = "https://login.microsoftonline.com/"
+
+	// managementResource is the resource AAD tokens are requested for, which
+	// ACR accepts in exchange for refresh tokens.
+	managementResource = "https://management.azure.com/"
+
+	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+var (
+	// imdsTokenURL is the instance metadata service endpoint returning AAD
+	// tokens of managed identities.
+	imdsTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// token is a token and the time it expires.
+type token struct {
+	value  string
+	expiry time.Time
+}
+
+func (t *token) valid() bool {
+	return t != nil && time.Now().Add(tokenExpiryLeeway).Before(t.expiry)
+}
+
+type aadTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	// ExpiresIn is a number of seconds, encoded as a string by the instance
+	// metadata service and as a number by AAD.
+	ExpiresIn json.Number `json:"expires_in"`
+}
+
+// tokenCache holds the AAD token, and the ACR refresh token of each host,
+// and the ACR access token of each repository.
+type tokenCache struct {
+	mu      sync.Mutex
+	aad     *token
+	refresh map[string]*token
+	access  map[string]*token
+}
+
+// reset will forget the tokens of the host, so that new tokens are requested,
+// such as when a token was revoked before it expired.
+func (t *tokenCache) reset(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.refresh, host)
+	for key := range t.access {
+		if strings.HasPrefix(key, host+"/") {
+			delete(t.access, key)
+		}
+	}
+}
+
+// accessToken will return an ACR access token to pull the repository,
+// requesting a new one if the cached token is about to expire. Credentials of
+// the context, such as from image pull secrets, take precedence over the
+// configured username, password and identity.
+func (c *Client) accessToken(ctx context.Context, host, repo string) (string, error) {
+	c.tokens.mu.Lock()
+	defer c.tokens.mu.Unlock()
+
+	username, password := c.Username, c.Password
+	cred, hasCred := util.CredentialsFor(ctx, host)
+
+	key := host + "/" + repo
+	if hasCred {
+		username, password = cred.Username, cred.Password
+		key += "@" + cred.ID()
+	}
+
+	if access := c.tokens.access[key]; access.valid() {
+		return access.value, nil
+	}
+
+	form := url.Values{
+		"service": {host},
+		"scope":   {fmt.Sprintf("repository:%s:pull", repo)},
+	}
+	tokenURL := fmt.Sprintf("https://%s/oauth2/token", host)
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if c.useAAD() && !hasCred {
+		refresh, err := c.refreshToken(ctx, host)
+		if err != nil {
+			return "", err
+		}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", refresh)
+
+		if err := c.postForm(ctx, tokenURL, form, &response); err != nil {
+			return "", fmt.Errorf("failed to get access token: %s", err)
+		}
+	} else {
+		// Anonymous unless a username and password are set.
+		req, err := http.NewRequest(http.MethodGet, tokenURL+"?"+form.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		if len(username) > 0 || len(password) > 0 {
+			req.SetBasicAuth(username, password)
+		}
+
+		body, err := c.send(ctx, req)
+		if err != nil {
+			return "", fmt.Errorf("failed to get access token: %s", err)
+		}
+		if err := json.Unmarshal(body, &response); err != nil {
+			return "", fmt.Errorf("unexpected access token response from %q", tokenURL)
+		}
+	}
+
+	access, err := jwtToken(response.AccessToken)
+	if err != nil {
+		return "", fmt.Errorf("unexpected access token: %s", err)
+	}
+	c.tokens.access[key] = access
+
+	return access.value, nil
+}
+
+// refreshToken will return an ACR refresh token of the host, exchanged for
+// the AAD token of the workload or managed identity. Must be called with the
+// token cache lock held.
+func (c *Client) refreshToken(ctx context.Context, host string) (string, error) {
+	if refresh := c.tokens.refresh[host]; refresh.valid() {
+		return refresh.value, nil
+	}
+
+	aad, err := c.aadToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get AAD token: %s", err)
+	}
+
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {host},
+		"access_token": {aad},
+	}
+	if tenant := os.Getenv("AZURE_TENANT_ID"); len(tenant) > 0 {
+		form.Set("tenant", tenant)
+	}
+
+	var response struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.postForm(ctx, fmt.Sprintf("https://%s/oauth2/exchange", host), form, &response); err != nil {
+		return "", fmt.Errorf("failed to exchange AAD token for refresh token: %s", err)
+	}
+
+	refresh, err := jwtToken(response.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("unexpected refresh token: %s", err)
+	}
+	c.tokens.refresh[host] = refresh
+
+	return refresh.value, nil
+}
+
+// aadToken will return an AAD token of the workload identity, or managed
+// identity. Must be called with the token cache lock held.
+func (c *Client) aadToken(ctx context.Context) (string, error) {
+	if c.tokens.aad.valid() {
+		return c.tokens.aad.value, nil
+	}
+
+	var (
+		req *http.Request
+		err error
+	)
+	if c.UseWorkloadIdentity {
+		req, err = workloadIdentityRequest()
+	} else {
+		req, err = c.managedIdentityRequest()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	body, err := c.send(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	response := new(aadTokenResponse)
+	if err := json.Unmarshal(body, response); err != nil || len(response.AccessToken) == 0 {
+		return "", errors.New("unexpected AAD token response")
+	}
+
+	expiresIn, err := response.ExpiresIn.Int64()
+	if err != nil {
+		return "", fmt.Errorf("unexpected AAD token expiry %q", response.ExpiresIn)
+	}
+
+	c.tokens.aad = &token{
+		value:  response.AccessToken,
+		expiry: time.Now().Add(time.Duration(expiresIn) * time.Second),
+	}
+
+	return response.AccessToken, nil
+}
+
+// workloadIdentityRequest returns a request exchanging the federated token of
+// Azure Workload Identity for an AAD token, configured by the environment
+// set by the workload identity webhook.
+func workloadIdentityRequest() (*http.Request, error) {
+	tokenFile, clientID, tenantID := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_TENANT_ID")
+	if len(tokenFile) == 0 || len(clientID) == 0 || len(tenantID) == 0 {
+		return nil, errors.New("workload identity requires AZURE_FEDERATED_TOKEN_FILE, AZURE_CLIENT_ID and AZURE_TENANT_ID to be set")
+	}
+
+	// The federated token is rotated by the kubelet, so is read every time.
+	assertion, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read federated token: %s", err)
+	}
+
+	authority := os.Getenv("AZURE_AUTHORITY_HOST")
+	if len(authority) == 0 {
+		authority = defaultAuthorityHost
+	}
+
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {clientID},
+		"client_assertion_type": {clientAssertionType},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+		"scope":                 {managementResource + ".default"},
+	}
+
+	req, err := http.NewRequest(http.MethodPost,
+		strings.TrimSuffix(authority, "/")+"/"+tenantID+"/oauth2/v2.0/token",
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
+// managedIdentityRequest returns a request for an AAD token of the managed
+// identity from the instance metadata service.
+func (c *Client) managedIdentityRequest() (*http.Request, error) {
+	query := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {managementResource},
+	}
+	if len(c.ManagedIdentityClientID) > 0 {
+		query.Set("client_id", c.ManagedIdentityClientID)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, imdsTokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	return req, nil
+}
+
+func (c *Client) postForm(ctx context.Context, url string, form url.Values, obj interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, obj); err != nil {
+		return fmt.Errorf("unexpected response from %q", url)
+	}
+
+	return nil
+}
+
+// send will perform the request, returning the response body if the status
+// code is 200.
+func (c *Client) send(ctx context.Context, req *http.Request) ([]byte, error) {
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %q: %s", req.URL.Host+req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from %q: %d %s",
+			req.URL.Host+req.URL.Path, resp.StatusCode, body)
+	}
+
+	return body, nil
+}
+
+// jwtToken returns the token with the expiry of its exp claim. The signature
+// is not verified, as the token is only passed back to the registry.
+func jwtToken(value string) (*token, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JWT payload: %s", err)
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return nil, errors.New("no exp claim in JWT")
+	}
+
+	return &token{value: value, expiry: time.Unix(claims.Exp, 0)}, nil
+}
@@ -0,0 +1,218 @@
+package artifactory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	aqlURL = "https://%s/artifactory/api/search/aql"
+
+	// aqlQuery finds the manifests of every tag of an image. Docker
+	// repositories store each tag as a folder of the image path, holding a
+	// manifest.json, or list.manifest.json for multi platform images.
+	aqlQuery = `items.find({"repo":%q,"path":{"$match":%q},"name":{"$in":["manifest.json","list.manifest.json"]}})` +
+		`.include("path","name","created","sha256")`
+
+	// aqlStatsQuery finds the download statistics of the manifests of every
+	// tag of an image. Each pull of a tag downloads its manifest.
+	aqlStatsQuery = `items.find({"repo":%q,"path":{"$match":%q},"name":{"$in":["manifest.json","list.manifest.json"]}})` +
+		`.include("path","name","stat.downloads")`
+
+	apiKeyHeader = "X-JFrog-Art-Api"
+)
+
+type Options struct {
+	// Host is the host of the Artifactory instance, serving Docker
+	// repositories by the repository path method.
+	// e.g. artifactory.corp/docker-local/image
+	Host string
+
+	// APIKey or AccessToken to authenticate with.
+	APIKey      string
+	AccessToken string
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+}
+
+type Client struct {
+	*http.Client
+	Options
+}
+
+type AQLResponse struct {
+	Results []AQLResult `json:"results"`
+}
+
+type AQLResult struct {
+	Path    string    `json:"path"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	SHA256  string    `json:"sha256"`
+
+	// Stats is set when included by the query.
+	Stats []AQLStat `json:"stats"`
+}
+
+type AQLStat struct {
+	Downloads int64 `json:"downloads"`
+}
+
+func New(opts Options) (*Client, error) {
+	if len(opts.APIKey) > 0 && len(opts.AccessToken) > 0 {
+		return nil, errors.New("cannot specify API key as well as access token")
+	}
+
+	return &Client{
+		Options: opts,
+		Client: &http.Client{
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
+		},
+	}, nil
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	return len(c.Host) > 0 && strings.HasPrefix(imageURL, c.Host+"/")
+}
+
+// Tags will query the manifests of the image with AQL, returning a tag per
+// manifest, with the manifest's creation time and digest.
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	repo, image, err := c.splitImage(imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AQLResponse
+	if err := c.doRequest(ctx, fmt.Sprintf(aqlQuery, repo, image+"/*"), &response); err != nil {
+		return nil, err
+	}
+	util.CountPage(ctx)
+
+	var tags []api.ImageTag
+	for _, result := range response.Results {
+		tag, ok := tagOf(image, result)
+		if !ok {
+			continue
+		}
+
+		tags = append(tags, api.ImageTag{
+			Tag:       tag,
+			SHA:       "sha256:" + result.SHA256,
+			Timestamp: result.Created,
+		})
+	}
+
+	return tags, nil
+}
+
+// PullCounts will query the download statistics of the manifests of the image
+// with AQL, returning the number of downloads of each tag's manifest.
+func (c *Client) PullCounts(ctx context.Context, imageURL string) (map[string]int64, error) {
+	repo, image, err := c.splitImage(imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var response AQLResponse
+	if err := c.doRequest(ctx, fmt.Sprintf(aqlStatsQuery, repo, image+"/*"), &response); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for _, result := range response.Results {
+		tag, ok := tagOf(image, result)
+		if !ok {
+			continue
+		}
+
+		for _, stat := range result.Stats {
+			counts[tag] += stat.Downloads
+		}
+	}
+
+	return counts, nil
+}
+
+// splitImage will split the image URL into its Docker repository and image
+// path.
+func (c *Client) splitImage(imageURL string) (string, string, error) {
+	split := strings.SplitN(strings.TrimPrefix(imageURL, c.Host+"/"), "/", 2)
+	if len(split) != 2 || len(split[0]) == 0 || len(split[1]) == 0 {
+		return "", "", fmt.Errorf("image %q is not of the form %s/<repository>/<image>", imageURL, c.Host)
+	}
+
+	return split[0], split[1], nil
+}
+
+// tagOf returns the tag of the manifest of the image, or false if the
+// manifest is of a nested image.
+func tagOf(image string, result AQLResult) (string, bool) {
+	tag := strings.TrimPrefix(result.Path, image+"/")
+	if tag == result.Path || strings.Contains(tag, "/") {
+		return "", false
+	}
+
+	return tag, true
+}
+
+func (c *Client) doRequest(ctx context.Context, query string, obj interface{}) error {
+	url := fmt.Sprintf(aqlURL, c.Host)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(query))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/plain")
+	cred, hasCred := util.CredentialsFor(ctx, c.Host)
+	switch {
+	case hasCred:
+		req.SetBasicAuth(cred.Username, cred.Password)
+	case len(c.AccessToken) > 0:
+		req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	case len(c.APIKey) > 0:
+		req.Header.Set(apiKeyHeader, c.APIKey)
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get artifactory image: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := util.NotFound(resp, url, body); err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code from %q: %d %s",
+			url, resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, obj); err != nil {
+		return fmt.Errorf("unexpected response from %q: %s", url, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package artifactory
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+func TestTags(t *testing.T) {
+	tests := map[string]struct {
+		opts   Options
+		cred   *util.Credentials
+		header string
+		value  string
+	}{
+		"api key": {
+			opts:   Options{APIKey: "api-key"},
+			header: apiKeyHeader,
+			value:  "api-key",
+		},
+		"access token": {
+			opts:   Options{AccessToken: "access-token"},
+			header: "Authorization",
+			value:  "Bearer access-token",
+		},
+		"context credentials should take precedence": {
+			opts:   Options{AccessToken: "access-token"},
+			cred:   &util.Credentials{Username: "user", Password: "pass"},
+			header: "Authorization",
+			value:  "Basic dXNlcjpwYXNz",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.Method != http.MethodPost || req.URL.Path != "/artifactory/api/search/aql" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if req.Header.Get(test.header) != test.value {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				body, _ := ioutil.ReadAll(req.Body)
+				if !strings.Contains(string(body), `"repo":"docker-local"`) ||
+					!strings.Contains(string(body), `"$match":"jetstack/app/*"`) {
+					t.Errorf("unexpected query, got=%s", body)
+				}
+
+				w.Write([]byte(`{"results":[
+					{"path":"jetstack/app/v0.1.0","name":"manifest.json","created":"2020-06-01T10:00:00.000Z","sha256":"aaa"},
+					{"path":"jetstack/app/v0.2.0","name":"list.manifest.json","created":"2020-07-01T10:00:00.000+02:00","sha256":"bbb"},
+					{"path":"jetstack/app/nested/v0.3.0","name":"manifest.json","created":"2020-08-01T10:00:00.000Z","sha256":"ccc"}
+				]}`))
+			}))
+			defer server.Close()
+
+			opts := test.opts
+			opts.Host = strings.TrimPrefix(server.URL, "https://")
+			opts.Transport = server.Client().Transport
+			client, err := New(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			imageURL := client.Host + "/docker-local/jetstack/app"
+			if !client.IsClient(imageURL) {
+				t.Fatalf("expected client to match %q", imageURL)
+			}
+
+			ctx := context.TODO()
+			if test.cred != nil {
+				ctx = util.WithHostCredentials(ctx, client.Host, *test.cred)
+			}
+
+			tags, err := client.Tags(ctx, imageURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tags) != 2 {
+				t.Fatalf("unexpected number of tags, exp=2 got=%d: %+v", len(tags), tags)
+			}
+
+			exp := []struct {
+				tag, sha  string
+				timestamp time.Time
+			}{
+				{"v0.1.0", "sha256:aaa", time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)},
+				{"v0.2.0", "sha256:bbb", time.Date(2020, 7, 1, 8, 0, 0, 0, time.UTC)},
+			}
+			for i, e := range exp {
+				if tags[i].Tag != e.tag || tags[i].SHA != e.sha || !tags[i].Timestamp.Equal(e.timestamp) {
+					t.Errorf("unexpected tag, exp=%+v got=%+v", e, tags[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPullCounts(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		if !strings.Contains(string(body), `"stat.downloads"`) {
+			t.Errorf("unexpected query, got=%s", body)
+		}
+
+		w.Write([]byte(`{"results":[
+			{"path":"jetstack/app/v0.1.0","name":"manifest.json","stats":[{"downloads":42}]},
+			{"path":"jetstack/app/v0.2.0","name":"list.manifest.json","stats":[{"downloads":7}]},
+			{"path":"jetstack/app/v0.3.0","name":"manifest.json","stats":[]},
+			{"path":"jetstack/app/nested/v0.3.0","name":"manifest.json","stats":[{"downloads":100}]}
+		]}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Options{
+		Host:      strings.TrimPrefix(server.URL, "https://"),
+		Transport: server.Client().Transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := client.PullCounts(context.TODO(), client.Host+"/docker-local/jetstack/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]int64{"v0.1.0": 42, "v0.2.0": 7}
+	if !reflect.DeepEqual(counts, exp) {
+		t.Errorf("unexpected pull counts, exp=%v got=%v", exp, counts)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Options{APIKey: "key", AccessToken: "token"}); err == nil {
+		t.Error("expected error setting both API key and access token, got=nil")
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	client, err := New(Options{Host: "artifactory.corp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for imageURL, exp := range map[string]bool{
+		"artifactory.corp/docker-local/app":  true,
+		"artifactory.corp.io/docker-local/a": false,
+		"quay.io/jetstack/app":               false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+}
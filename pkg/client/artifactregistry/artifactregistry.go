@@ -0,0 +1,193 @@
+package artifactregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/gcpauth"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// hostRegex matches the regional and multi-regional Docker hosts of
+	// Artifact Registry, e.g. europe-west2-docker.pkg.dev, us-docker.pkg.dev
+	hostRegex = `^[a-z0-9-]+-docker\.pkg\.dev$`
+)
+
+var (
+	regHost = regexp.MustCompile(hostRegex)
+)
+
+type Options struct {
+	// Token is an OAuth access token. Takes precedence over all other
+	// credentials.
+	Token string
+
+	// ServiceAccountKeyFile is a path to a service account JSON key, which is
+	// exchanged for access tokens.
+	ServiceAccountKeyFile string
+
+	// UseMetadataServer will request access tokens of the default service
+	// account from the GCE metadata server, if no other credentials are set.
+	UseMetadataServer bool
+
+	// UseApplicationDefaultCredentials will use the application default
+	// credentials, such as GKE Workload Identity, if no other credentials are
+	// set.
+	UseApplicationDefaultCredentials bool
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+}
+
+type Client struct {
+	*http.Client
+	Options
+
+	// tokens returns exchanged access tokens. If nil, Token is used.
+	tokens *gcpauth.TokenSource
+}
+
+// Response is the tags list response, extended with the manifests of each
+// digest in the same form as GCR.
+type Response struct {
+	Manifest map[string]ManifestItem `json:"manifest"`
+}
+
+type ManifestItem struct {
+	Tag         []string `json:"tag"`
+	TimeCreated string   `json:"timeCreatedMs"`
+}
+
+func New(opts Options) (*Client, error) {
+	client := &Client{
+		Options: opts,
+		Client: &http.Client{
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
+		},
+	}
+
+	switch {
+	case len(opts.Token) > 0:
+	case len(opts.ServiceAccountKeyFile) > 0:
+		tokens, err := gcpauth.NewKeyTokenSource(client.Client, opts.ServiceAccountKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		client.tokens = tokens
+	case opts.UseMetadataServer:
+		client.tokens = gcpauth.NewMetadataTokenSource(client.Client)
+	case opts.UseApplicationDefaultCredentials:
+		tokens, err := gcpauth.NewDefaultTokenSource(client.Client)
+		if err != nil {
+			return nil, err
+		}
+		client.tokens = tokens
+	}
+
+	return client, nil
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	split := strings.SplitN(imageURL, "/", 2)
+	return len(split) == 2 && regHost.MatchString(split[0])
+}
+
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	split := strings.SplitN(imageURL, "/", 2)
+	if len(split) != 2 {
+		return nil, fmt.Errorf("invalid artifact registry image %q", imageURL)
+	}
+	url := fmt.Sprintf("https://%s/v2/%s/tags/list", split[0], split[1])
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if cred, ok := util.CredentialsFor(ctx, split[0]); ok {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	} else {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(token) > 0 {
+			req.SetBasicAuth("oauth2accesstoken", token)
+		}
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifact registry image: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := util.NotFound(resp, url, body); err != nil {
+		return nil, err
+	}
+
+	if err := util.ErrorEnvelope(body); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from %q: %d %s",
+			url, resp.StatusCode, body)
+	}
+	util.CountPage(ctx)
+
+	var response Response
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unexpected response from %q: %s", url, body)
+	}
+
+	var tags []api.ImageTag
+	for sha, manifestItem := range response.Manifest {
+		miliTimestamp, err := strconv.ParseInt(manifestItem.TimeCreated, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert timestamp string: %s", err)
+		}
+
+		timestamp := time.Unix(0, miliTimestamp*int64(time.Millisecond))
+
+		// If no tag, add without and continue early.
+		if len(manifestItem.Tag) == 0 {
+			tags = append(tags, api.ImageTag{SHA: sha, Timestamp: timestamp})
+			continue
+		}
+
+		for _, tag := range manifestItem.Tag {
+			tags = append(tags, api.ImageTag{Tag: tag, SHA: sha, Timestamp: timestamp})
+		}
+	}
+
+	return tags, nil
+}
+
+// token returns the access token to authenticate with, exchanging one if
+// configured. Returns an empty token for anonymous access.
+func (c *Client) token(ctx context.Context) (string, error) {
+	if c.tokens != nil {
+		return c.tokens.Token(ctx)
+	}
+
+	return c.Token, nil
+}
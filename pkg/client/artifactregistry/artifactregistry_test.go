@@ -0,0 +1,90 @@
+package artifactregistry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTags(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, pass, ok := req.BasicAuth(); !ok || user != "oauth2accesstoken" || pass != "access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`))
+			return
+		}
+
+		if req.URL.Path != "/v2/my-project/my-repo/app/tags/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte(`{"manifest":{
+			"sha256:a":{"tag":["v0.1.0"],"timeCreatedMs":"1590962400000"},
+			"sha256:b":{"tag":["v0.2.0","latest"],"timeCreatedMs":"1593554400000"}
+		}}`))
+	}))
+	defer server.Close()
+
+	// The test server can't have an Artifact Registry host, so redirect
+	// requests to it.
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, strings.TrimPrefix(server.URL, "https://"))
+	}
+	transport.TLSClientConfig.InsecureSkipVerify = true
+
+	client, err := New(Options{Token: "access-token", Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := client.Tags(context.TODO(), "europe-west2-docker.pkg.dev/my-project/my-repo/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	exp := []struct {
+		tag, sha  string
+		timestamp time.Time
+	}{
+		{"latest", "sha256:b", time.Unix(1593554400, 0)},
+		{"v0.1.0", "sha256:a", time.Unix(1590962400, 0)},
+		{"v0.2.0", "sha256:b", time.Unix(1593554400, 0)},
+	}
+	if len(tags) != len(exp) {
+		t.Fatalf("unexpected number of tags, exp=%d got=%d: %+v", len(exp), len(tags), tags)
+	}
+	for i, e := range exp {
+		if tags[i].Tag != e.tag || tags[i].SHA != e.sha || !tags[i].Timestamp.Equal(e.timestamp) {
+			t.Errorf("unexpected tag, exp=%+v got=%+v", e, tags[i])
+		}
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	client, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for imageURL, exp := range map[string]bool{
+		"europe-west2-docker.pkg.dev/project/repo/app": true,
+		"us-docker.pkg.dev/project/repo/app":           true,
+		"asia-northeast1-docker.pkg.dev/project/repo":  true,
+		"europe-west2-npm.pkg.dev/project/repo/app":    false,
+		"docker.pkg.dev/project/repo/app":              false,
+		"gcr.io/project/app":                           false,
+		"europe-west2-docker.pkg.dev":                  false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+}
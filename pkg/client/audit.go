@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// AuditEvent is a structured record of a registry request, or of a lookup
+// served from cache without a request.
+type AuditEvent struct {
+	Timestamp time.Time
+	Host      string
+	Repo      string
+	Method    string
+	// Status is the response status code. Zero if the request failed or the
+	// lookup was served from cache.
+	Status   int
+	Duration time.Duration
+	// Retries is the number of times the same request was previously made
+	// during the lookup.
+	Retries int
+	Cached  bool
+}
+
+// AuditSink receives an AuditEvent for every registry request, for keeping a
+// persistent audit trail.
+type AuditSink interface {
+	Record(AuditEvent)
+}
+
+// nopAuditSink is the default AuditSink, discarding all events.
+type nopAuditSink struct{}
+
+func (nopAuditSink) Record(AuditEvent) {}
+
+type auditLookupKey struct{}
+
+// auditLookup holds the repository of a tag lookup, and the number of times
+// each request has been made during it.
+type auditLookup struct {
+	repo string
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func withAuditLookup(ctx context.Context, repo string) context.Context {
+	return context.WithValue(ctx, auditLookupKey{}, &auditLookup{
+		repo:     repo,
+		attempts: make(map[string]int),
+	})
+}
+
+// auditTransport is a http.RoundTripper which records an AuditEvent for every
+// request.
+type auditTransport struct {
+	next http.RoundTripper
+	sink AuditSink
+}
+
+func (a *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	event := AuditEvent{
+		Timestamp: time.Now(),
+		Host:      req.URL.Host,
+		Repo:      req.URL.Path,
+		Method:    req.Method,
+	}
+
+	if lookup, ok := req.Context().Value(auditLookupKey{}).(*auditLookup); ok {
+		key := req.Method + " " + req.URL.String()
+		lookup.mu.Lock()
+		event.Repo = lookup.repo
+		event.Retries = lookup.attempts[key]
+		lookup.attempts[key]++
+		lookup.mu.Unlock()
+	}
+
+	resp, err := a.next.RoundTrip(req)
+	event.Duration = time.Since(event.Timestamp)
+	if err == nil {
+		event.Status = resp.StatusCode
+	}
+
+	a.sink.Record(event)
+
+	return resp, err
+}
+
+// recordCached will record an AuditEvent for a lookup of the image URL served
+// from cache.
+func (c *Client) recordCached(imageURL string, start time.Time) {
+	if c.audit == nil {
+		return
+	}
+
+	ref := api.ParseImageRef(imageURL)
+	c.audit.Record(AuditEvent{
+		Timestamp: start,
+		Host:      ref.Registry,
+		Repo:      ref.Repository,
+		Method:    http.MethodGet,
+		Duration:  time.Since(start),
+		Cached:    true,
+	})
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/client/nexus"
+)
+
+// auditRecorder is an AuditSink collecting all recorded events.
+type auditRecorder struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (a *auditRecorder) Record(event AuditEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+}
+
+func TestTagsAudit(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/jetstack/app/tags/list", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"name":"jetstack/app","tags":["v0.1.0"]}`))
+	})
+	mux.HandleFunc("/v2/jetstack/app/manifests/v0.1.0", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:v0.1.0")
+		w.Write([]byte(`{"schemaVersion":2,"config":{"digest":"sha256:config"}}`))
+	})
+	mux.HandleFunc("/v2/jetstack/app/blobs/sha256:config", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"created":"2020-06-01T10:00:00Z","os":"linux","architecture":"amd64"}`))
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	recorder := new(auditRecorder)
+	nexusClient, err := nexus.New(nexus.Options{
+		Host:      strings.TrimPrefix(server.URL, "https://"),
+		Transport: &auditTransport{next: server.Client().Transport, sink: recorder},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	client := &Client{
+		fallback:  nexusClient,
+		coalescer: newCoalescer(time.Minute, clock),
+		audit:     recorder,
+	}
+
+	imageURL := nexusClient.Host + "/jetstack/app"
+	if _, err := client.Tags(context.TODO(), imageURL); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorder.events) != 3 {
+		t.Fatalf("expected an event per request of uncached scan, exp=3 got=%d: %+v",
+			len(recorder.events), recorder.events)
+	}
+	for _, event := range recorder.events {
+		if event.Host != nexusClient.Host || event.Repo != "jetstack/app" ||
+			event.Method != http.MethodGet || event.Status != http.StatusOK ||
+			event.Retries != 0 || event.Cached || event.Timestamp.IsZero() {
+			t.Errorf("unexpected event of uncached scan, got=%+v", event)
+		}
+	}
+
+	if _, err := client.Tags(context.TODO(), imageURL); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorder.events) != 4 {
+		t.Fatalf("expected one event of cached scan, exp=4 got=%d: %+v",
+			len(recorder.events), recorder.events)
+	}
+	event := recorder.events[3]
+	if event.Host != nexusClient.Host || event.Repo != "jetstack/app" ||
+		event.Status != 0 || !event.Cached {
+		t.Errorf("unexpected event of cached scan, got=%+v", event)
+	}
+}
+
+func TestAuditTransportRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	recorder := new(auditRecorder)
+	client := &http.Client{
+		Transport: &auditTransport{next: http.DefaultTransport, sink: recorder},
+	}
+
+	ctx := withAuditLookup(context.TODO(), "jetstack/app")
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v2/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	for i, event := range recorder.events {
+		if event.Retries != i || event.Status != http.StatusServiceUnavailable {
+			t.Errorf("unexpected event of request %d, exp_retries=%d got=%+v", i, i, event)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+const (
+	// Annotations set on image manifests describing the image that the image
+	// was built from.
+	baseImageNameAnnotation   = "org.opencontainers.image.base.name"
+	baseImageDigestAnnotation = "org.opencontainers.image.base.digest"
+)
+
+// BaseImage will return the name and digest of the base image of the given
+// image URL and tag, as recorded by the OCI base image annotations. Returns
+// empty strings if the annotations are not present.
+func (c *Client) BaseImage(ctx context.Context, imageURL, tag string) (name, digest string, err error) {
+	ref := api.ParseImageRef(c.mirrors.rewrite(imageURL))
+
+	manifest, err := c.oci.Manifest(ctx, ref.Registry, ref.Repository, tag)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get manifest for %q: %s", imageURL+":"+tag, err)
+	}
+
+	if name, digest := baseImageAnnotations(manifest.Annotations); len(name) > 0 {
+		return name, digest, nil
+	}
+
+	if !manifest.IsIndex() {
+		return "", "", nil
+	}
+
+	manifest, err = c.oci.ImageManifest(ctx, ref.Registry, ref.Repository, tag)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get image manifest for %q: %s", imageURL+":"+tag, err)
+	}
+
+	name, digest = baseImageAnnotations(manifest.Annotations)
+	return name, digest, nil
+}
+
+func baseImageAnnotations(annotations map[string]string) (string, string) {
+	return annotations[baseImageNameAnnotation], annotations[baseImageDigestAnnotation]
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBaseImage(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	registry.addImage("jetstack/app", "no-annotations", "sha256:layer")
+
+	registry.addImage("jetstack/app", "image-annotations", "sha256:layer").Annotations = map[string]string{
+		baseImageNameAnnotation:   "docker.io/library/alpine:3.12",
+		baseImageDigestAnnotation: "sha256:alpine",
+	}
+
+	registry.addIndex("jetstack/app", "index-annotations", map[string]string{
+		"linux/amd64": "sha256:amd64",
+	}).Annotations = map[string]string{
+		baseImageNameAnnotation:   "docker.io/library/debian:buster",
+		baseImageDigestAnnotation: "sha256:debian",
+	}
+
+	registry.addIndex("jetstack/app", "platform-annotations", map[string]string{
+		"linux/amd64": "sha256:amd64",
+	})
+	registry.addImage("jetstack/app", "sha256:amd64", "sha256:layer").Annotations = map[string]string{
+		baseImageNameAnnotation:   "gcr.io/distroless/static:nonroot",
+		baseImageDigestAnnotation: "sha256:distroless",
+	}
+
+	tests := map[string]struct {
+		tag                string
+		expName, expDigest string
+	}{
+		"image without annotations should return empty": {
+			tag: "no-annotations",
+		},
+		"image with annotations should return base image": {
+			tag:       "image-annotations",
+			expName:   "docker.io/library/alpine:3.12",
+			expDigest: "sha256:alpine",
+		},
+		"index with annotations should return base image": {
+			tag:       "index-annotations",
+			expName:   "docker.io/library/debian:buster",
+			expDigest: "sha256:debian",
+		},
+		"index with annotations on platform image should return base image": {
+			tag:       "platform-annotations",
+			expName:   "gcr.io/distroless/static:nonroot",
+			expDigest: "sha256:distroless",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			name, digest, err := registry.client().BaseImage(context.TODO(),
+				registry.host()+"/jetstack/app", test.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if name != test.expName || digest != test.expDigest {
+				t.Errorf("unexpected base image, exp=%s@%s got=%s@%s",
+					test.expName, test.expDigest, name, digest)
+			}
+		})
+	}
+}
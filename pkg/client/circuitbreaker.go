@@ -0,0 +1,165 @@
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// lastTagsTimeout is how long the last tags seen of an image are served
+	// while the circuit of its host is open, and kept for.
+	lastTagsTimeout = time.Hour * 24
+
+	// lastTagsPruneInterval is how often last tags older than the timeout
+	// are removed.
+	lastTagsPruneInterval = time.Minute
+)
+
+// circuitBreaker stops lookups against registry hosts which have failed a
+// number of consecutive times, serving the last tags seen of each image
+// instead. After a cooldown, one probe lookup is let through; the circuit
+// closes if it succeeds and opens again if it fails.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	clock     util.Clock
+
+	mu    sync.Mutex
+	hosts map[string]*circuitState
+	// last holds the last tags seen of each image URL, served while the
+	// circuit of its host is open. Tags not seen again within the last tags
+	// timeout are pruned, so that images no longer looked up are dropped.
+	last       map[string]lastTags
+	lastPruned time.Time
+}
+
+type lastTags struct {
+	timestamp time.Time
+	tags      []api.ImageTag
+}
+
+type circuitState struct {
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, clock util.Clock) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		clock:     clock,
+		hosts:     make(map[string]*circuitState),
+		last:      make(map[string]lastTags),
+	}
+}
+
+// allow returns true if a lookup may be made against the host, and whether
+// the lookup is the probe of a half open circuit, which must be ended with
+// endProbe. While the circuit is open, the last tags seen of the image URL
+// are returned instead, or ErrCircuitOpen if there are none.
+func (b *circuitBreaker) allow(host, imageURL string) (bool, bool, []api.ImageTag, error) {
+	if b == nil {
+		return true, false, nil, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.hosts[host]
+	if !ok || state.failures < b.threshold {
+		return true, false, nil, nil
+	}
+
+	now := b.clock.Now()
+
+	// Half open, letting one probe through.
+	if !state.probing && !now.Before(state.openedAt.Add(b.cooldown)) {
+		state.probing = true
+		return true, true, nil, nil
+	}
+
+	if last, ok := b.last[imageURL]; ok && now.Before(last.timestamp.Add(lastTagsTimeout)) {
+		return false, false, last.tags, nil
+	}
+
+	return false, false, nil, ErrCircuitOpen
+}
+
+// endProbe will end the probe of the host, so that another lookup may probe
+// the host if the probe ended without recording a result, such as by failing
+// to resolve credentials, or by sharing the result of another lookup.
+func (b *circuitBreaker) endProbe(host string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if state, ok := b.hosts[host]; ok {
+		state.probing = false
+	}
+}
+
+// record will record the result of a lookup of the image URL against the
+// host. Not found errors are a healthy response of the registry, so are not
+// counted as failures.
+func (b *circuitBreaker) record(host, imageURL string, tags []api.ImageTag, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || errors.Is(err, util.ErrRepositoryNotFound) {
+		delete(b.hosts, host)
+		if err == nil {
+			now := b.clock.Now()
+			b.last[imageURL] = lastTags{timestamp: now, tags: tags}
+			b.pruneLast(now)
+		}
+		return
+	}
+
+	state, ok := b.hosts[host]
+	// Cancelled and rate limited lookups say nothing of the health of the
+	// registry.
+	if isContextError(err) || errors.Is(err, ErrRateLimited) {
+		if ok {
+			state.probing = false
+		}
+		return
+	}
+
+	if !ok {
+		state = new(circuitState)
+		b.hosts[host] = state
+	}
+
+	state.failures++
+	if state.probing || state.failures == b.threshold {
+		state.openedAt = b.clock.Now()
+		state.probing = false
+	}
+}
+
+// pruneLast will remove the last tags older than the timeout, at most once
+// every prune interval. The lock must be held.
+func (b *circuitBreaker) pruneLast(now time.Time) {
+	if now.Before(b.lastPruned.Add(lastTagsPruneInterval)) {
+		return
+	}
+	b.lastPruned = now
+
+	for imageURL, last := range b.last {
+		if !now.Before(last.timestamp.Add(lastTagsTimeout)) {
+			delete(b.last, imageURL)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+func TestTagsCircuitBreaker(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	upstream := &fakeClient{tags: []api.ImageTag{{Tag: "v1.0.0"}}}
+	client := &Client{
+		fallback: upstream,
+		breaker:  newCircuitBreaker(2, time.Minute, clock),
+	}
+
+	lookup := func(imageURL string) ([]api.ImageTag, error) {
+		return client.Tags(context.TODO(), imageURL)
+	}
+
+	if _, err := lookup("jetstack/version-checker"); err != nil {
+		t.Fatal(err)
+	}
+
+	upstream.err = errors.New("bad gateway")
+	for i := 0; i < 2; i++ {
+		if _, err := lookup("jetstack/version-checker"); err == nil {
+			t.Fatal("expected error from failing registry")
+		}
+	}
+	if upstream.calls != 3 {
+		t.Fatalf("unexpected upstream calls, exp=3 got=%d", upstream.calls)
+	}
+
+	tags, err := lookup("jetstack/version-checker")
+	if err != nil || len(tags) != 1 || tags[0].Tag != "v1.0.0" {
+		t.Errorf("expected last tags while open, got=%+v %v", tags, err)
+	}
+	if _, err := lookup("jetstack/another"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("unexpected error of image without tags while open, exp=%v got=%v", ErrCircuitOpen, err)
+	}
+	if _, err := lookup("quay.io/jetstack/cert-manager-controller"); err == nil {
+		t.Error("expected other hosts to still be looked up")
+	}
+	if upstream.calls != 4 {
+		t.Fatalf("expected no upstream calls of the open host, exp=4 got=%d", upstream.calls)
+	}
+
+	// A failing probe opens the circuit again.
+	clock.advance(time.Minute)
+	if _, err := lookup("jetstack/version-checker"); err == nil {
+		t.Fatal("expected error from failing probe")
+	}
+	if _, err := lookup("jetstack/another"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected circuit to open after failed probe, exp=%v got=%v", ErrCircuitOpen, err)
+	}
+	if upstream.calls != 5 {
+		t.Fatalf("unexpected upstream calls, exp=5 got=%d", upstream.calls)
+	}
+
+	// A successful probe closes the circuit.
+	clock.advance(time.Minute)
+	upstream.err = nil
+	for _, imageURL := range []string{"jetstack/version-checker", "jetstack/another"} {
+		if _, err := lookup(imageURL); err != nil {
+			t.Errorf("expected circuit to close after successful probe, got=%v", err)
+		}
+	}
+	if upstream.calls != 7 {
+		t.Errorf("unexpected upstream calls, exp=7 got=%d", upstream.calls)
+	}
+}
+
+func TestCircuitBreakerNotFound(t *testing.T) {
+	b := newCircuitBreaker(1, time.Minute, &fakeClock{})
+	b.record("docker.io", "jetstack/missing", nil, util.ErrRepositoryNotFound)
+
+	if ok, _, _, err := b.allow("docker.io", "jetstack/missing"); !ok || err != nil {
+		t.Errorf("expected not found to not open the circuit, got=%t %v", ok, err)
+	}
+}
+
+func TestCircuitBreakerProbeWithoutResult(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	upstream := &fakeClient{err: errors.New("bad gateway")}
+	client := &Client{
+		fallback: upstream,
+		breaker:  newCircuitBreaker(1, time.Minute, clock),
+	}
+
+	if _, err := client.Tags(context.TODO(), "jetstack/version-checker"); err == nil {
+		t.Fatal("expected error from failing registry")
+	}
+
+	execCredentialHelperOrig := execCredentialHelper
+	defer func() { execCredentialHelper = execCredentialHelperOrig }()
+	execCredentialHelper = func(_ context.Context, _, _ string) ([]byte, error) {
+		return nil, errors.New("exit status 1: helper crashed")
+	}
+
+	// A probe failing to resolve credentials records no result of the
+	// registry, so must let the next lookup probe.
+	clock.advance(time.Minute)
+	client.dockerConfig = &dockerConfigCredentials{credsStore: "broken", helperCache: make(map[string]credentialHelperItem)}
+	if _, err := client.Tags(context.TODO(), "jetstack/version-checker"); err == nil {
+		t.Fatal("expected error from failing credential helper")
+	}
+
+	client.dockerConfig = nil
+	upstream.err = nil
+	if _, err := client.Tags(context.TODO(), "jetstack/version-checker"); err != nil {
+		t.Errorf("expected another probe to be let through, got=%v", err)
+	}
+	if upstream.calls != 2 {
+		t.Errorf("unexpected upstream calls, exp=2 got=%d", upstream.calls)
+	}
+}
+
+func TestCircuitBreakerLastTagsTimeout(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := newCircuitBreaker(1, time.Minute, clock)
+
+	b.record("docker.io", "jetstack/version-checker", []api.ImageTag{{Tag: "v1.0.0"}}, nil)
+	b.record("docker.io", "jetstack/version-checker", nil, errors.New("bad gateway"))
+
+	if ok, _, tags, err := b.allow("docker.io", "jetstack/version-checker"); ok || err != nil || len(tags) != 1 {
+		t.Errorf("expected last tags while open, got=%t %+v %v", ok, tags, err)
+	}
+
+	// The first lookup after the cooldown probes the host.
+	clock.advance(lastTagsTimeout)
+	if ok, probe, _, _ := b.allow("docker.io", "jetstack/version-checker"); !ok || !probe {
+		t.Fatalf("expected probe after the cooldown, got=%t %t", ok, probe)
+	}
+	if _, _, _, err := b.allow("docker.io", "jetstack/version-checker"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected last tags older than the timeout not to be served, exp=%v got=%v", ErrCircuitOpen, err)
+	}
+
+	b.record("quay.io", "quay.io/jetstack/app", []api.ImageTag{{Tag: "v1.0.0"}}, nil)
+	if _, ok := b.last["jetstack/version-checker"]; ok || len(b.last) != 1 {
+		t.Errorf("expected last tags older than the timeout to be pruned, got=%+v", b.last)
+	}
+}
@@ -2,12 +2,30 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/acr"
+	"github.com/jetstack/version-checker/pkg/client/artifactory"
+	"github.com/jetstack/version-checker/pkg/client/artifactregistry"
 	"github.com/jetstack/version-checker/pkg/client/docker"
+	"github.com/jetstack/version-checker/pkg/client/docr"
+	"github.com/jetstack/version-checker/pkg/client/ecr"
+	"github.com/jetstack/version-checker/pkg/client/ecrpublic"
 	"github.com/jetstack/version-checker/pkg/client/gcr"
+	"github.com/jetstack/version-checker/pkg/client/ghcr"
+	"github.com/jetstack/version-checker/pkg/client/gitlab"
+	"github.com/jetstack/version-checker/pkg/client/harbor"
+	"github.com/jetstack/version-checker/pkg/client/nexus"
+	"github.com/jetstack/version-checker/pkg/client/oci"
 	"github.com/jetstack/version-checker/pkg/client/quay"
+	"github.com/jetstack/version-checker/pkg/client/selfhosted"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 type ImageClient interface {
@@ -23,47 +41,410 @@ type ImageClient interface {
 // Client is a container image registry client to list tags of given image
 // URLs.
 type Client struct {
-	quay   *quay.Client
-	docker *docker.Client
-	gcr    *gcr.Client
+	// clients are tested in order against an image URL to find the
+	// appropriate registry client.
+	clients []ImageClient
+
+	// fallback is used when no client matches the image URL.
+	fallback ImageClient
+
+	// oci is used for requests against the registry distribution API, such
+	// as fetching manifests.
+	oci *oci.Client
+
+	// latencies tracks the request durations of all registry clients.
+	latencies *latencyTracker
+
+	// tracer is used to record spans of registry lookups, if set.
+	tracer trace.Tracer
+
+	// notFound caches image URLs whose repository was not found, if enabled.
+	notFound *negativeCache
+
+	// redactor removes credentials from returned errors.
+	redactor *util.Redactor
+
+	// coalescer shares lookups of the same image made within a window, if
+	// enabled.
+	coalescer *coalescer
+
+	// audit receives an event for every lookup served from cache. Events of
+	// registry requests are recorded by the transport.
+	audit AuditSink
+
+	// digests records every tag seen pointing at each digest across scans,
+	// if a snapshot store is set.
+	digests *digestIndex
+
+	// log logs errors which don't fail lookups.
+	log *logrus.Entry
+
+	// dockerConfig resolves registry credentials from a docker config file,
+	// if set.
+	dockerConfig *dockerConfigCredentials
+
+	// mirrors rewrites image URLs to the registry mirror they are looked up
+	// against, if set.
+	mirrors *mirrors
+
+	// breaker stops lookups against failing registry hosts, if enabled.
+	breaker *circuitBreaker
+
+	// rateLimits limits the rate of lookups of registry hosts, if set.
+	rateLimits *hostRateLimits
 }
 
 // Options used to configure client authentication.
 type Options struct {
-	Docker docker.Options
-	GCR    gcr.Options
-	Quay   quay.Options
+	ACR              acr.Options
+	Artifactory      artifactory.Options
+	ArtifactRegistry artifactregistry.Options
+	Docker           docker.Options
+	DOCR             docr.Options
+	ECR              ecr.Options
+	ECRPublic        ecrpublic.Options
+	GCR              gcr.Options
+	GHCR             ghcr.Options
+	GitLab           gitlab.Options
+	Harbor           harbor.Options
+	Nexus            nexus.Options
+	Quay             quay.Options
+
+	// ObserveRequestDuration, if set, is called with the duration of every
+	// request made to a registry host.
+	ObserveRequestDuration func(host string, duration time.Duration)
+
+	// Tracer, if set, is used to record a span for every tag lookup.
+	Tracer trace.Tracer
+
+	// VerifyDigests will verify fetched manifests against their digest,
+	// using one of DigestAlgorithms.
+	VerifyDigests    bool
+	DigestAlgorithms []string
+
+	// AllowDuplicatePlatforms will use the first manifest of a platform
+	// listed more than once in a manifest list, rather than failing the
+	// lookup as ambiguous.
+	AllowDuplicatePlatforms bool
+
+	// NegativeCacheTimeout is the time to remember that an image repository
+	// was not found, failing lookups without contacting the registry. Zero
+	// disables the negative cache.
+	NegativeCacheTimeout time.Duration
+
+	// CABundles is a map of registry host to the path of a CA bundle file that
+	// the host's certificate is verified against. Hosts may include a port.
+	// Hosts not in the map are verified against the system roots.
+	CABundles map[string]string
+
+	// ClientCertificates and ClientKeys are maps of registry host to the
+	// paths of the client certificate and key presented to the host, for
+	// mutual TLS. Hosts may include a port.
+	ClientCertificates map[string]string
+	ClientKeys         map[string]string
+
+	// InsecureSkipVerifyHosts are registry hosts whose certificates are not
+	// verified. Hosts may include a port.
+	InsecureSkipVerifyHosts []string
+
+	// TLSServerNames is a map of registry host to the server name the host's
+	// certificate is verified against, for connecting to registries by IP or
+	// through an SNI routing proxy. Hosts may include a port.
+	TLSServerNames map[string]string
+
+	// Proxies is a map of registry host to the URL of the proxy its requests
+	// are made through, or "direct" to bypass any proxy. Hosts may include a
+	// port. Requests to other hosts use the HTTP_PROXY, HTTPS_PROXY and
+	// NO_PROXY environment variables.
+	Proxies map[string]string
+
+	// ObserveNegativeCache, if set, is called with every image URL added to
+	// the negative cache, and again once it is removed.
+	ObserveNegativeCache func(imageURL string, cached bool)
+
+	// RetryMaxAttempts is the total number of attempts of registry requests
+	// failing with a network error or a transient status code. Retries back
+	// off exponentially from RetryBackoff with jitter, honouring Retry-After
+	// headers. Values less than two disable retries.
+	RetryMaxAttempts int
+	RetryBackoff     time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive failed lookups of
+	// a registry host after which lookups against it are stopped, serving the
+	// last tags seen of each image. After CircuitBreakerCooldown, one probe
+	// lookup is let through. Zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	// RateLimits is a map of registry host to the number of lookups per
+	// second allowed against it, e.g. docker.io=0.5. Lookups wait for the
+	// limit, failing with ErrRateLimited if the wait would be too long.
+	RateLimits map[string]string
+
+	// LazyAuth will request content from distribution API registries before
+	// authenticating, only requesting a token when challenged.
+	LazyAuth bool
+
+	// CoalesceWindow is the window in which lookups of the same image share
+	// one upstream call. Zero disables coalescing.
+	CoalesceWindow time.Duration
+
+	// AuditSink, if set, receives an event for every registry request and
+	// every lookup served from cache.
+	AuditSink AuditSink
+
+	// SnapshotStore persists state between scans, such as the tags seen
+	// pointing at each digest. The state is not kept if nil.
+	SnapshotStore SnapshotStore
+
+	// Log is used to log errors which don't fail lookups, such as failing to
+	// persist state. Defaults to the standard logger.
+	Log *logrus.Entry
+
+	// DockerConfigFile is the path of a docker config.json, whose credentials
+	// and credential helpers are used for registry hosts without credentials
+	// from image pull secrets. Not used if empty.
+	DockerConfigFile string
+
+	// Mirrors is a map of image prefix to the mirror it is looked up against,
+	// e.g. docker.io=mirror.corp/docker. Prefixes are a registry host,
+	// optionally followed by a repository path, and the longest matching
+	// prefix is used. Lookups are still reported under the original image.
+	Mirrors map[string]string
+
+	// RedactPatterns are regular expressions of credentials to redact from
+	// errors, in addition to URL userinfo and util.DefaultRedactPatterns.
+	RedactPatterns []string
 }
 
 func New(ctx context.Context, opts Options) (*Client, error) {
+	redactor, err := util.NewRedactor(opts.RedactPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := newProxyFunc(opts.Proxies)
+	if err != nil {
+		return nil, err
+	}
+
+	baseTransport, err := newTLSTransport(tlsOptions{
+		caBundles:     opts.CABundles,
+		clientCerts:   opts.ClientCertificates,
+		clientKeys:    opts.ClientKeys,
+		insecureHosts: opts.InsecureSkipVerifyHosts,
+		serverNames:   opts.TLSServerNames,
+		proxy:         proxy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.AuditSink == nil {
+		opts.AuditSink = nopAuditSink{}
+	}
+
+	if opts.Log == nil {
+		opts.Log = logrus.NewEntry(logrus.StandardLogger())
+	}
+
+	// Tracing is beneath retries, so that every attempt records its events.
+	latencies := newLatencyTracker(baseTransport, opts.ObserveRequestDuration)
+	transport := newRetryTransport(
+		&tracingTransport{
+			next: &auditTransport{next: latencies, sink: opts.AuditSink},
+		},
+		opts.RetryMaxAttempts, opts.RetryBackoff,
+	)
+	opts.ACR.Transport = transport
+	opts.Artifactory.Transport = transport
+	opts.ArtifactRegistry.Transport = transport
+	opts.Docker.Transport = transport
+	opts.DOCR.Transport = transport
+	opts.ECR.Transport = transport
+	opts.ECRPublic.Transport = transport
+	opts.GCR.Transport = transport
+	opts.Harbor.Transport = transport
+	opts.Nexus.Transport = transport
+	opts.Quay.Transport = transport
+
 	dockerClient, err := docker.New(ctx, opts.Docker)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create docker client: %s", err)
 	}
 
+	nexusClient, err := nexus.New(opts.Nexus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nexus client: %s", err)
+	}
+
+	artifactoryClient, err := artifactory.New(opts.Artifactory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create artifactory client: %s", err)
+	}
+
+	acrClient, err := acr.New(opts.ACR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create acr client: %s", err)
+	}
+
+	gcrClient, err := gcr.New(opts.GCR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcr client: %s", err)
+	}
+
+	artifactRegistryClient, err := artifactregistry.New(opts.ArtifactRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create artifact registry client: %s", err)
+	}
+
+	ociOpts := oci.Options{
+		Transport:        transport,
+		VerifyDigests:    opts.VerifyDigests,
+		DigestAlgorithms: opts.DigestAlgorithms,
+		Redactor:         redactor,
+		LazyAuth:         opts.LazyAuth,
+
+		AllowDuplicatePlatforms: opts.AllowDuplicatePlatforms,
+	}
+	opts.GHCR.OCI = ociOpts
+	opts.GitLab.OCI = ociOpts
+
+	var dockerConfig *dockerConfigCredentials
+	if len(opts.DockerConfigFile) > 0 {
+		dockerConfig, err = newDockerConfigCredentials(opts.DockerConfigFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mirrors, err := newMirrors(opts.Mirrors)
+	if err != nil {
+		return nil, err
+	}
+
+	var notFound *negativeCache
+	if opts.NegativeCacheTimeout > 0 {
+		notFound = newNegativeCache(opts.NegativeCacheTimeout, opts.ObserveNegativeCache)
+		go notFound.garbageCollect(ctx)
+	}
+
+	rateLimits, err := newHostRateLimits(opts.RateLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	var breaker *circuitBreaker
+	if opts.CircuitBreakerThreshold > 0 {
+		breaker = newCircuitBreaker(opts.CircuitBreakerThreshold, opts.CircuitBreakerCooldown, util.RealClock{})
+	}
+
+	var coalescer *coalescer
+	if opts.CoalesceWindow > 0 {
+		coalescer = newCoalescer(opts.CoalesceWindow, util.RealClock{})
+	}
+
 	return &Client{
-		quay:   quay.New(opts.Quay),
-		docker: dockerClient,
-		gcr:    gcr.New(opts.GCR),
+		clients: []ImageClient{
+			quay.New(opts.Quay),
+			gcrClient,
+			artifactRegistryClient,
+			ghcr.New(opts.GHCR),
+			ecr.New(opts.ECR),
+			acrClient,
+			ecrpublic.New(opts.ECRPublic),
+			docr.New(opts.DOCR),
+			gitlab.New(opts.GitLab),
+			harbor.New(opts.Harbor),
+			nexusClient,
+			artifactoryClient,
+			dockerClient,
+			// Any other registry host is assumed to implement the
+			// distribution API.
+			selfhosted.New(selfhosted.Options{OCI: ociOpts}),
+		},
+		// Fall back to docker if we can't determine the registry
+		fallback:  dockerClient,
+		oci:       oci.New(ociOpts),
+		latencies: latencies,
+		tracer:    opts.Tracer,
+		notFound:  notFound,
+		redactor:  redactor,
+		coalescer: coalescer,
+		audit:     opts.AuditSink,
+		digests:   newDigestIndex(opts.SnapshotStore),
+		log:       opts.Log.WithField("module", "client"),
+
+		dockerConfig: dockerConfig,
+		mirrors:      mirrors,
+		breaker:      breaker,
+		rateLimits:   rateLimits,
 	}, nil
 }
 
 func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
-	return c.fromImageURL(imageURL).Tags(ctx, imageURL)
+	start := time.Now()
+
+	// Results of lookups with credentials of the context, such as from image
+	// pull secrets, are only shared with lookups with the same credentials.
+	credsKey := util.CredentialsKey(ctx)
+	if err := c.notFound.get(imageURL, credsKey); err != nil {
+		c.recordCached(imageURL, start)
+		return nil, err
+	}
+
+	lookupURL := c.mirrors.rewrite(imageURL)
+	host := api.ParseImageRef(lookupURL).Registry
+	allowed, probe, tags, err := c.breaker.allow(host, imageURL+credsKey)
+	if !allowed {
+		c.recordCached(imageURL, start)
+		return tags, err
+	}
+	if probe {
+		defer c.breaker.endProbe(host)
+	}
+
+	ctx, err = c.dockerConfig.withCredentials(ctx, lookupURL)
+	if err != nil {
+		return nil, c.redactor.Error(err)
+	}
+
+	ctx = withAuditLookup(ctx, api.ParseImageRef(imageURL).Repository)
+	tags, shared, err := c.coalescer.do(ctx, imageURL+credsKey, func() ([]api.ImageTag, error) {
+		if err := c.rateLimits.wait(ctx, host); err != nil {
+			return nil, err
+		}
+		return c.tracedTags(ctx, c.fromImageURL(lookupURL), lookupURL)
+	})
+	if shared {
+		c.recordCached(imageURL, start)
+	} else {
+		c.breaker.record(host, imageURL+credsKey, tags, err)
+	}
+	err = c.redactor.Error(err)
+	if errors.Is(err, util.ErrRepositoryNotFound) {
+		c.notFound.add(imageURL, credsKey, err)
+	}
+
+	if err == nil && !shared {
+		// The digest index is history of the tags, so failing to update it
+		// doesn't fail the lookup.
+		if err := c.digests.update(imageURL, tags); err != nil {
+			c.log.Errorf("failed to update digest index of %q: %s", imageURL, err)
+		}
+	}
+
+	return tags, err
 }
 
-// ClientFromImage will return the appropriate registry client for a given
+// fromImageURL will return the appropriate registry client for a given
 // image URL.
 func (c *Client) fromImageURL(imageURL string) ImageClient {
-	switch {
-	case c.quay.IsClient(imageURL):
-		return c.quay
-	case c.gcr.IsClient(imageURL):
-		return c.gcr
-	case c.docker.IsClient(imageURL):
-		return c.docker
-	default:
-		// Fall back to docker if we can't determine the registry
-		return c.docker
+	for _, client := range c.clients {
+		if client.IsClient(imageURL) {
+			return client
+		}
 	}
+
+	return c.fallback
 }
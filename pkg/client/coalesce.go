@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+// coalescer shares the result of a tag lookup with lookups of the same image
+// URL starting within a window of it, so that bursts of lookups arriving
+// milliseconds apart make one upstream call.
+type coalescer struct {
+	window time.Duration
+	clock  util.Clock
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	start time.Time
+	done  chan struct{}
+	tags  []api.ImageTag
+	err   error
+}
+
+func newCoalescer(window time.Duration, clock util.Clock) *coalescer {
+	return &coalescer{
+		window: window,
+		clock:  clock,
+		calls:  make(map[string]*coalescedCall),
+	}
+}
+
+// do will call fn, unless a call for the same image URL started within the
+// window, in which case its result is waited for and returned instead.
+// Returns true if the result was shared from another call. Waiting returns
+// early if the context is done, and calls ending with their own context done
+// are not shared, so are made again by their waiters.
+func (c *coalescer) do(ctx context.Context, imageURL string, fn func() ([]api.ImageTag, error)) ([]api.ImageTag, bool, error) {
+	if c == nil {
+		tags, err := fn()
+		return tags, false, err
+	}
+
+	for {
+		c.mu.Lock()
+		call, ok := c.calls[imageURL]
+		if !ok || c.clock.Now().Sub(call.start) > c.window {
+			break
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-call.done:
+			if !isContextError(call.err) {
+				return call.tags, true, call.err
+			}
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+
+	// The lock is held from the loop.
+	now := c.clock.Now()
+
+	// Remove calls whose window has passed.
+	for url, call := range c.calls {
+		if now.Sub(call.start) > c.window {
+			delete(c.calls, url)
+		}
+	}
+
+	call := &coalescedCall{start: now, done: make(chan struct{})}
+	c.calls[imageURL] = call
+	c.mu.Unlock()
+
+	call.tags, call.err = fn()
+
+	if isContextError(call.err) {
+		c.mu.Lock()
+		if c.calls[imageURL] == call {
+			delete(c.calls, imageURL)
+		}
+		c.mu.Unlock()
+	}
+	close(call.done)
+
+	return call.tags, false, call.err
+}
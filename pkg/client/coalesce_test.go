@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// fakeClock is a util.Clock which is advanced manually.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func TestTagsCoalescing(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	upstream := &fakeClient{tags: []api.ImageTag{{Tag: "v1.0.0"}}}
+	client := &Client{
+		fallback:  upstream,
+		coalescer: newCoalescer(100*time.Millisecond, clock),
+	}
+
+	for i := 0; i < 5; i++ {
+		tags, err := client.Tags(context.TODO(), "jetstack/version-checker")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tags) != 1 || tags[0].Tag != "v1.0.0" {
+			t.Errorf("unexpected tags from coalesced lookup, got=%+v", tags)
+		}
+		clock.advance(20 * time.Millisecond)
+	}
+
+	if upstream.calls != 1 {
+		t.Errorf("expected staggered lookups within window to share one upstream call, exp=1 got=%d", upstream.calls)
+	}
+
+	if _, err := client.Tags(context.TODO(), "jetstack/another"); err != nil {
+		t.Fatal(err)
+	}
+	if upstream.calls != 2 {
+		t.Errorf("expected lookup of another image to make an upstream call, exp=2 got=%d", upstream.calls)
+	}
+
+	clock.advance(100 * time.Millisecond)
+	if _, err := client.Tags(context.TODO(), "jetstack/version-checker"); err != nil {
+		t.Fatal(err)
+	}
+	if upstream.calls != 3 {
+		t.Errorf("expected lookup after window to make an upstream call, exp=3 got=%d", upstream.calls)
+	}
+}
+
+func TestTagsCoalescingInFlight(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	release := make(chan struct{})
+	var calls int
+	var wg sync.WaitGroup
+
+	coalescer := newCoalescer(100*time.Millisecond, clock)
+	started := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		coalescer.do(context.TODO(), "jetstack/version-checker", func() ([]api.ImageTag, error) {
+			calls++
+			close(started)
+			<-release
+			return nil, nil
+		})
+	}()
+	<-started
+
+	clock.advance(50 * time.Millisecond)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		coalescer.do(context.TODO(), "jetstack/version-checker", func() ([]api.ImageTag, error) {
+			calls++
+			return nil, nil
+		})
+	}()
+
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected lookup during in flight call to wait for it, exp=1 got=%d", calls)
+	}
+}
+
+func TestCoalescerContext(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	coalescer := newCoalescer(100*time.Millisecond, clock)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		coalescer.do(context.TODO(), "jetstack/version-checker", func() ([]api.ImageTag, error) {
+			close(started)
+			<-release
+			return nil, context.Canceled
+		})
+	}()
+	<-started
+
+	// Waiters return once their own context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, shared, err := coalescer.do(ctx, "jetstack/version-checker", func() ([]api.ImageTag, error) {
+		t.Error("unexpected call while another is in flight")
+		return nil, nil
+	}); !shared || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected waiter to return with its context error, got=%t %v", shared, err)
+	}
+
+	// Waiters make the call again if the shared call ended by its own
+	// context being done.
+	var calls int
+	waited := make(chan struct{})
+	go func() {
+		defer close(waited)
+		tags, shared, err := coalescer.do(context.TODO(), "jetstack/version-checker", func() ([]api.ImageTag, error) {
+			calls++
+			return []api.ImageTag{{Tag: "v1.0.0"}}, nil
+		})
+		if shared || err != nil || len(tags) != 1 {
+			t.Errorf("expected waiter to make its own call, got=%+v %t %v", tags, shared, err)
+		}
+	}()
+
+	close(release)
+	<-done
+	<-waited
+
+	if calls != 1 {
+		t.Errorf("unexpected calls of the waiter, exp=1 got=%d", calls)
+	}
+}
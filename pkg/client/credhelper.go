@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// credentialHelperCacheTimeout is how long credentials returned by a
+	// credential helper are used before the helper is run again.
+	credentialHelperCacheTimeout = time.Minute * 5
+
+	// dockerHubServerURL is the server URL credential helpers store Docker
+	// Hub credentials under.
+	dockerHubServerURL = "https://index.docker.io/v1/"
+
+	// credentialsNotFoundMessage is the error credential helpers report for
+	// server URLs they hold no credentials of.
+	credentialsNotFoundMessage = "credentials not found"
+)
+
+var (
+	// execCredentialHelper runs the docker credential helper to get the
+	// credentials of the server URL, returning its output.
+	execCredentialHelper = func(ctx context.Context, helper, serverURL string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+		cmd.Stdin = strings.NewReader(serverURL)
+
+		// Helpers write errors, such as credentials not being found, to stdout.
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+		}
+
+		return out, nil
+	}
+)
+
+// credentialHelperResponse is the output of a credential helper get.
+type credentialHelperResponse struct {
+	Username string `json:"Username"`
+	Secret   string `json:"Secret"`
+}
+
+type credentialHelperItem struct {
+	timestamp time.Time
+	cred      util.Credentials
+	// ok is false if the credential store holds no credentials of the host.
+	ok bool
+}
+
+// dockerConfigCredentials resolves the credentials of registry hosts from a
+// docker config file, running the credHelpers and credsStore credential
+// helpers it configures.
+type dockerConfigCredentials struct {
+	auths       map[string]util.Credentials
+	credHelpers map[string]string
+	credsStore  string
+
+	// mu guards helperCache, and is not held while helpers run.
+	mu sync.Mutex
+	// helperCache holds the credentials returned by helpers per host.
+	helperCache map[string]credentialHelperItem
+}
+
+// newDockerConfigCredentials will read the docker config file at the path.
+func newDockerConfigCredentials(path string) (*dockerConfigCredentials, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker config: %s", err)
+	}
+
+	var config util.DockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config %q: %s", path, err)
+	}
+
+	d := &dockerConfigCredentials{
+		auths:       make(map[string]util.Credentials),
+		credHelpers: make(map[string]string),
+		credsStore:  config.CredsStore,
+		helperCache: make(map[string]credentialHelperItem),
+	}
+
+	for server, entry := range config.Auths {
+		cred, err := entry.Credentials()
+		if err != nil {
+			return nil, fmt.Errorf("invalid credentials of %q in docker config %q: %s", server, path, err)
+		}
+		// Entries of hosts in a credential store have no credentials.
+		if len(cred.Username) > 0 || len(cred.Password) > 0 {
+			d.auths[util.RegistryHost(server)] = cred
+		}
+	}
+
+	for server, helper := range config.CredHelpers {
+		d.credHelpers[util.RegistryHost(server)] = helper
+	}
+
+	return d, nil
+}
+
+// withCredentials returns a context holding the credentials of the image
+// URL's registry host, unless the context already holds credentials for the
+// host, such as from image pull secrets.
+func (d *dockerConfigCredentials) withCredentials(ctx context.Context, imageURL string) (context.Context, error) {
+	if d == nil {
+		return ctx, nil
+	}
+
+	host := api.ParseImageRef(imageURL).Registry
+	if _, ok := util.CredentialsFor(ctx, host); ok {
+		return ctx, nil
+	}
+
+	cred, ok, err := d.credentials(ctx, host)
+	if err != nil || !ok {
+		return ctx, err
+	}
+
+	return util.WithHostCredentials(ctx, host, cred), nil
+}
+
+// credentials will return the credentials of the host. A credHelpers entry
+// of the host takes precedence, then credentials in auths, then the
+// credsStore. Hosts the credsStore holds no credentials of have none, so are
+// looked up anonymously.
+func (d *dockerConfigCredentials) credentials(ctx context.Context, host string) (util.Credentials, bool, error) {
+	helper, ok := d.credHelpers[host]
+	isStore := !ok
+	if !ok {
+		if cred, ok := d.auths[host]; ok {
+			return cred, true, nil
+		}
+
+		helper = d.credsStore
+	}
+	if len(helper) == 0 {
+		return util.Credentials{}, false, nil
+	}
+
+	d.mu.Lock()
+	item, ok := d.helperCache[host]
+	d.mu.Unlock()
+
+	if ok && item.timestamp.Add(credentialHelperCacheTimeout).After(time.Now()) {
+		return item.cred, item.ok, nil
+	}
+
+	serverURL := host
+	if host == api.DefaultRegistry {
+		serverURL = dockerHubServerURL
+	}
+
+	// Helpers are run without the lock held, so that a slow helper doesn't
+	// block lookups of other hosts.
+	out, err := execCredentialHelper(ctx, helper, serverURL)
+	if err != nil {
+		if isStore && strings.Contains(err.Error(), credentialsNotFoundMessage) {
+			d.cache(host, credentialHelperItem{timestamp: time.Now()})
+			return util.Credentials{}, false, nil
+		}
+
+		return util.Credentials{}, false, fmt.Errorf("failed to get credentials of %q from credential helper %q: %s",
+			host, helper, err)
+	}
+
+	var response credentialHelperResponse
+	if err := json.Unmarshal(out, &response); err != nil {
+		return util.Credentials{}, false, fmt.Errorf("unexpected output of credential helper %q", helper)
+	}
+
+	cred := util.Credentials{Username: response.Username, Password: response.Secret}
+	d.cache(host, credentialHelperItem{timestamp: time.Now(), cred: cred, ok: true})
+
+	return cred, true, nil
+}
+
+// cache will store the result of the helper of the host.
+func (d *dockerConfigCredentials) cache(host string, item credentialHelperItem) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.helperCache[host] = item
+}
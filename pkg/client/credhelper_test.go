@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+func TestDockerConfigCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-docker-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{
+	"auths": {
+		"https://index.docker.io/v1/": {},
+		"registry.corp": {"auth": "dXNlcjpwYXNz"},
+		"gcr.io": {"auth": "aWdub3JlZDppZ25vcmVk"}
+	},
+	"credHelpers": {"gcr.io": "gcloud"},
+	"credsStore": "desktop"
+}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	execCredentialHelperOrig := execCredentialHelper
+	defer func() { execCredentialHelper = execCredentialHelperOrig }()
+	execCredentialHelper = func(_ context.Context, helper, serverURL string) ([]byte, error) {
+		calls = append(calls, helper+" "+serverURL)
+		switch serverURL {
+		case "gcr.io":
+			return []byte(`{"ServerURL":"gcr.io","Username":"_dcgcloud_token","Secret":"token"}`), nil
+		case dockerHubServerURL:
+			return []byte(`{"ServerURL":"https://index.docker.io/v1/","Username":"hub","Secret":"secret"}`), nil
+		case "registry.broken":
+			return nil, errors.New("exit status 1: error getting credentials")
+		default:
+			return nil, errors.New("exit status 1: credentials not found in native keychain")
+		}
+	}
+
+	d, err := newDockerConfigCredentials(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		imageURL  string
+		expCred   util.Credentials
+		expNoCred bool
+		expErr    bool
+	}{
+		"auths entry should be used": {
+			imageURL: "registry.corp/team/app",
+			expCred:  util.Credentials{Username: "user", Password: "pass"},
+		},
+		"cred helper should take precedence over auths": {
+			imageURL: "gcr.io/project/app",
+			expCred:  util.Credentials{Username: "_dcgcloud_token", Password: "token"},
+		},
+		"docker hub should use the creds store with the index server URL": {
+			imageURL: "library/nginx",
+			expCred:  util.Credentials{Username: "hub", Password: "secret"},
+		},
+		"host not in the creds store should be anonymous": {
+			imageURL:  "quay.io/jetstack/cert-manager-controller",
+			expNoCred: true,
+		},
+		"failing helper should error": {
+			imageURL: "registry.broken/jetstack/app",
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, err := d.withCredentials(context.TODO(), test.imageURL)
+			if test.expErr != (err != nil) {
+				t.Fatalf("exp error=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			cred, ok := util.CredentialsFor(ctx, api.ParseImageRef(test.imageURL).Registry)
+			if test.expNoCred {
+				if ok {
+					t.Errorf("expected no credentials, got=%+v", cred)
+				}
+				return
+			}
+			if !ok || cred != test.expCred {
+				t.Errorf("unexpected credentials, exp=%+v got=%+v (%t)", test.expCred, cred, ok)
+			}
+		})
+	}
+
+	calls = nil
+	if _, err := d.withCredentials(context.TODO(), "gcr.io/project/app"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.withCredentials(context.TODO(), "quay.io/jetstack/cert-manager-controller"); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected helper credentials to be cached, got calls=%v", calls)
+	}
+
+	podCred := util.Credentials{Username: "pod", Password: "secret"}
+	ctx := util.WithHostCredentials(context.TODO(), "gcr.io", podCred)
+	ctx, err = d.withCredentials(ctx, "gcr.io/project/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred, _ := util.CredentialsFor(ctx, "gcr.io"); cred != podCred {
+		t.Errorf("expected context credentials to take precedence, exp=%+v got=%+v", podCred, cred)
+	}
+}
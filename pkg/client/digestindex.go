@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+const (
+	// digestIndexKeyPrefix prefixes the snapshot key of an image URL's
+	// digest index.
+	digestIndexKeyPrefix = "digest-index/"
+)
+
+// digestIndex is a reverse index of digest to every tag seen pointing at it,
+// per image URL. Tags are never removed, so history is kept after a tag has
+// been moved or deleted from the registry.
+type digestIndex struct {
+	store SnapshotStore
+
+	// mu guards loading, updating and saving indexes.
+	mu sync.Mutex
+}
+
+// newDigestIndex returns a digest index saved to the store, or nil if the
+// store is nil.
+func newDigestIndex(store SnapshotStore) *digestIndex {
+	if store == nil {
+		return nil
+	}
+
+	return &digestIndex{store: store}
+}
+
+// update will add the tags to the index of the image URL, saving it only if
+// new tags were seen.
+func (d *digestIndex) update(imageURL string, tags []api.ImageTag) error {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	index, err := d.load(imageURL)
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	for _, tag := range tags {
+		if len(tag.SHA) == 0 || len(tag.Tag) == 0 {
+			continue
+		}
+
+		if !containsString(index[tag.SHA], tag.Tag) {
+			index[tag.SHA] = append(index[tag.SHA], tag.Tag)
+			sort.Strings(index[tag.SHA])
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to encode digest index of %q: %s", imageURL, err)
+	}
+
+	return d.store.Save(digestIndexKeyPrefix+imageURL, data)
+}
+
+// load returns the index of the image URL, or an empty index if none has
+// been saved.
+func (d *digestIndex) load(imageURL string) (map[string][]string, error) {
+	index := make(map[string][]string)
+
+	data, err := d.store.Load(digestIndexKeyPrefix + imageURL)
+	if err != nil || data == nil {
+		return index, err
+	}
+
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode digest index of %q: %s", imageURL, err)
+	}
+
+	return index, nil
+}
+
+// TagsEverForDigest will return every tag of the image URL that has been
+// seen pointing at the digest across scans, sorted. Tags are recorded each
+// time the image's tags are listed, including tags which have since been
+// moved to another digest or deleted.
+func (c *Client) TagsEverForDigest(ctx context.Context, imageURL, digest string) ([]string, error) {
+	if c.digests == nil {
+		return nil, nil
+	}
+
+	c.digests.mu.Lock()
+	defer c.digests.mu.Unlock()
+
+	index, err := c.digests.load(imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return index[digest], nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// failingSnapshotStore fails to save every key.
+type failingSnapshotStore struct{}
+
+func (failingSnapshotStore) Load(_ string) ([]byte, error) {
+	return nil, nil
+}
+
+func (failingSnapshotStore) Save(_ string, _ []byte) error {
+	return errors.New("disk full")
+}
+
+func TestTagsEverForDigest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewFileSnapshotStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upstream := &fakeClient{
+		tags: []api.ImageTag{
+			{Tag: "v1.0.0", SHA: "sha256:a"},
+			{Tag: "v1.0", SHA: "sha256:a"},
+			{Tag: "latest", SHA: "sha256:a"},
+		},
+	}
+	client := &Client{
+		fallback: upstream,
+		digests:  &digestIndex{store: store},
+	}
+
+	imageURL := "jetstack/version-checker"
+	if _, err := client.Tags(context.TODO(), imageURL); err != nil {
+		t.Fatal(err)
+	}
+
+	// latest and the v1.0 alias are moved to the new release, the v1.0.0 tag
+	// is removed, but digest sha256:a persists under v1.0.0-1.
+	upstream.tags = []api.ImageTag{
+		{Tag: "v1.0.0-1", SHA: "sha256:a"},
+		{Tag: "v1.0.1", SHA: "sha256:b"},
+		{Tag: "v1.0", SHA: "sha256:b"},
+		{Tag: "latest", SHA: "sha256:b"},
+	}
+	if _, err := client.Tags(context.TODO(), imageURL); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new client against the same store should see the same history.
+	restarted := &Client{digests: &digestIndex{store: store}}
+
+	tests := map[string]struct {
+		client  *Client
+		digest  string
+		expTags []string
+	}{
+		"digest with removed and moved tags": {
+			client:  client,
+			digest:  "sha256:a",
+			expTags: []string{"latest", "v1.0", "v1.0.0", "v1.0.0-1"},
+		},
+		"digest of second scan": {
+			client:  client,
+			digest:  "sha256:b",
+			expTags: []string{"latest", "v1.0", "v1.0.1"},
+		},
+		"unknown digest": {
+			client:  client,
+			digest:  "sha256:c",
+			expTags: nil,
+		},
+		"history persisted across restarts": {
+			client:  restarted,
+			digest:  "sha256:a",
+			expTags: []string{"latest", "v1.0", "v1.0.0", "v1.0.0-1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tags, err := test.client.TagsEverForDigest(context.TODO(), imageURL, test.digest)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tags, test.expTags) {
+				t.Errorf("unexpected tags, exp=%v got=%v", test.expTags, tags)
+			}
+		})
+	}
+}
+
+func TestTagsDigestIndexFailure(t *testing.T) {
+	client := &Client{
+		fallback: &fakeClient{tags: []api.ImageTag{{Tag: "v1.0.0", SHA: "sha256:a"}}},
+		digests:  newDigestIndex(failingSnapshotStore{}),
+		log:      logrus.NewEntry(logrus.New()),
+	}
+
+	tags, err := client.Tags(context.TODO(), "jetstack/version-checker")
+	if err != nil || len(tags) != 1 {
+		t.Errorf("expected tags despite failing to update the digest index, got=%+v %v", tags, err)
+	}
+}
+
+func TestNewDigestIndex(t *testing.T) {
+	if d := newDigestIndex(nil); d != nil {
+		t.Errorf("expected no digest index without a snapshot store, got=%+v", d)
+	}
+}
+
+func TestMemorySnapshotStore(t *testing.T) {
+	store := NewMemorySnapshotStore()
+
+	data, err := store.Load("missing")
+	if err != nil || data != nil {
+		t.Errorf("expected missing key to return nil, got=%q err=%v", data, err)
+	}
+
+	if err := store.Save("key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := store.Load("key"); err != nil || string(data) != "value" {
+		t.Errorf("unexpected loaded data, exp=value got=%q err=%v", data, err)
+	}
+}
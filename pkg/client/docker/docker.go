@@ -8,15 +8,20 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 const (
-	repoURL        = "https://registry.hub.docker.com/v2/repositories/%s/tags"
-	imagePrefix    = "docker.io/"
-	imagePrefixHub = "registry.hub.docker.com/"
+	// Tags are ordered most recently updated first, so that bounded lookups
+	// keep the most recent tags.
+	repoURL          = "https://registry.hub.docker.com/v2/repositories/%s/tags?page_size=100&ordering=last_updated"
+	imagePrefix      = "docker.io/"
+	imagePrefixHub   = "registry.hub.docker.com/"
+	imagePrefixIndex = "index.docker.io/"
 )
 
 type Options struct {
@@ -24,11 +29,37 @@ type Options struct {
 	Username string
 	Password string
 	JWT      string
+
+	// RateLimitThreshold is the remaining Docker Hub rate limit budget below
+	// which requests are delayed by ThrottleDelay, slowing lookups until the
+	// budget recovers. Zero disables throttling.
+	RateLimitThreshold int
+	ThrottleDelay      time.Duration
+
+	// ObserveRateLimit, if set, is called with the rate limit and remaining
+	// budget reported by every Docker Hub response.
+	ObserveRateLimit func(limit, remaining int)
+
+	// MaxPages and MaxTags bound the number of tag pages requested, and tag
+	// names collected, per lookup. As tags are ordered most recently updated first,
+	// the most recent tags are kept. Zero is unlimited.
+	MaxPages int
+	MaxTags  int
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
 }
 
 type Client struct {
 	*http.Client
 	Options
+
+	jwtMu sync.Mutex
+	// jwts holds the JWT obtained with each context credentials, by their ID.
+	jwts map[string]string
+
+	rateLimit *rateLimit
 }
 
 type AuthResponse struct {
@@ -42,6 +73,7 @@ type TagResponse struct {
 
 type Result struct {
 	Name      string  `json:"name"`
+	Digest    string  `json:"digest"`
 	Timestamp string  `json:"last_updated"`
 	Images    []Image `json:"images"`
 }
@@ -50,11 +82,13 @@ type Image struct {
 	Digest       string `json:"digest"`
 	OS           string `json:"os"`
 	Architecture string `json:"Architecture"`
+	Variant      string `json:"variant"`
 }
 
 func New(ctx context.Context, opts Options) (*Client, error) {
 	client := &http.Client{
-		Timeout: time.Second * 5,
+		Timeout:   time.Second * 5,
+		Transport: opts.Transport,
 	}
 
 	// Setup Auth if username and password used.
@@ -73,42 +107,67 @@ func New(ctx context.Context, opts Options) (*Client, error) {
 	return &Client{
 		Options: opts,
 		Client:  client,
+		jwts:    make(map[string]string),
+		rateLimit: &rateLimit{
+			threshold: opts.RateLimitThreshold,
+			delay:     opts.ThrottleDelay,
+			observe:   opts.ObserveRateLimit,
+			sleep:     sleepContext,
+		},
 	}, nil
 }
 
 func (c *Client) IsClient(imageURL string) bool {
 	return strings.HasPrefix(imageURL, imagePrefix) ||
-		strings.HasPrefix(imageURL, imagePrefixHub)
+		strings.HasPrefix(imageURL, imagePrefixHub) ||
+		strings.HasPrefix(imageURL, imagePrefixIndex)
 }
 
 func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
-	if strings.HasPrefix(imageURL, imagePrefix) {
-		imageURL = strings.TrimPrefix(imageURL, imagePrefix)
+	pages, err := c.TagPages(ctx, imageURL, c.MaxPages)
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.HasPrefix(imageURL, imagePrefixHub) {
-		imageURL = strings.TrimPrefix(imageURL, imagePrefixHub)
+	var tags []api.ImageTag
+	for _, page := range pages {
+		tags = append(tags, page...)
 	}
 
-	if len(strings.Split(imageURL, "/")) == 1 {
-		imageURL = fmt.Sprintf("library/%s", imageURL)
-	}
+	return tags, nil
+}
 
-	url := fmt.Sprintf(repoURL, imageURL)
+// TagPages will return the tags of the image URL, as the pages returned by
+// Docker Hub, up to maxPages, most recently updated first. Zero maxPages
+// returns all pages. Pages stop once MaxTags tags are collected, if set, where
+// each tag may hold an image for many platforms.
+func (c *Client) TagPages(ctx context.Context, imageURL string, maxPages int) ([][]api.ImageTag, error) {
+	url := fmt.Sprintf(repoURL, repoPath(imageURL))
 
-	var tags []api.ImageTag
-	for url != "" {
+	var (
+		pages [][]api.ImageTag
+		total int
+	)
+	for url != "" && (maxPages <= 0 || len(pages) < maxPages) && (c.MaxTags <= 0 || total < c.MaxTags) {
 		response, err := c.doRequest(ctx, url)
 		if err != nil {
 			return nil, err
 		}
+		util.CountPage(ctx)
 
+		var tags []api.ImageTag
 		for _, result := range response.Results {
 			// No images in this result, so continue early
 			if len(result.Images) == 0 {
 				continue
 			}
 
+			// Enough tags have been collected, so stop early
+			if c.MaxTags > 0 && total >= c.MaxTags {
+				break
+			}
+			total++
+
 			timestamp, err := time.Parse(time.RFC3339Nano, result.Timestamp)
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse image timestamp: %s", err)
@@ -120,20 +179,29 @@ func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, err
 					continue
 				}
 
-				tags = append(tags, api.ImageTag{
+				tag := api.ImageTag{
 					Tag:          result.Name,
 					SHA:          image.Digest,
 					Timestamp:    timestamp,
 					OS:           image.OS,
 					Architecture: image.Architecture,
-				})
+					Variant:      image.Variant,
+				}
+
+				// The digest of multi-arch tags is that of their manifest list.
+				if result.Digest != image.Digest {
+					tag.IndexSHA = result.Digest
+				}
+
+				tags = append(tags, tag)
 			}
 		}
+		pages = append(pages, tags)
 
 		url = response.Next
 	}
 
-	return tags, nil
+	return pages, nil
 }
 
 func (c *Client) doRequest(ctx context.Context, url string) (*TagResponse, error) {
@@ -144,20 +212,38 @@ func (c *Client) doRequest(ctx context.Context, url string) (*TagResponse, error
 
 	req.URL.Scheme = "https"
 	req = req.WithContext(ctx)
-	if len(c.JWT) > 0 {
-		req.Header.Add("Authorization", "JWT "+c.JWT)
+
+	jwt, err := c.jwt(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(jwt) > 0 {
+		req.Header.Add("Authorization", "JWT "+jwt)
+	}
+
+	if err := c.rateLimit.wait(ctx); err != nil {
+		return nil, err
 	}
 
 	resp, err := c.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get docker image: %s", err)
 	}
+	c.rateLimit.update(resp.Header)
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := util.NotFound(resp, url, body); err != nil {
+		return nil, err
+	}
+
+	if err := util.ErrorEnvelope(body); err != nil {
+		return nil, err
+	}
+
 	response := new(TagResponse)
 	if err := json.Unmarshal(body, response); err != nil {
 		return nil, fmt.Errorf("unexpected image tags response: %s", body)
@@ -166,6 +252,46 @@ func (c *Client) doRequest(ctx context.Context, url string) (*TagResponse, error
 	return response, nil
 }
 
+// repoPath returns the Docker Hub repository path of the image URL. Only
+// images referenced on Docker Hub without a namespace are official images,
+// and are given the library namespace. Images with a registry host that is not
+// Docker Hub, which we have fallen back to, are preserved.
+func repoPath(imageURL string) string {
+	ref := api.ParseImageRef(imageURL)
+	if ref.Registry != api.DefaultRegistry {
+		return imageURL
+	}
+
+	return ref.Repository
+}
+
+// jwt will return the JWT to authenticate with, logging in with the
+// credentials of the context the first time they are used. Otherwise, the
+// configured JWT is returned.
+func (c *Client) jwt(ctx context.Context) (string, error) {
+	cred, ok := util.CredentialsFor(ctx, strings.TrimSuffix(imagePrefix, "/"))
+	if !ok {
+		return c.JWT, nil
+	}
+
+	c.jwtMu.Lock()
+	defer c.jwtMu.Unlock()
+
+	if jwt, ok := c.jwts[cred.ID()]; ok {
+		return jwt, nil
+	}
+
+	opts := c.Options
+	opts.Username, opts.Password = cred.Username, cred.Password
+	jwt, err := basicAuthSetup(c.Client, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to login with context credentials: %s", err)
+	}
+	c.jwts[cred.ID()] = jwt
+
+	return jwt, nil
+}
+
 func basicAuthSetup(client *http.Client, opts Options) (string, error) {
 	upReader := strings.NewReader(
 		fmt.Sprintf(`{"username": "%s", "password": "%s"}`,
@@ -0,0 +1,227 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepoPath(t *testing.T) {
+	tests := map[string]struct {
+		imageURL string
+		expPath  string
+	}{
+		"official image should be in library": {
+			"nginx", "library/nginx",
+		},
+		"official image with docker.io prefix should be in library": {
+			"docker.io/nginx", "library/nginx",
+		},
+		"official image with hub prefix should be in library": {
+			"registry.hub.docker.com/nginx", "library/nginx",
+		},
+		"official image with index prefix should be in library": {
+			"index.docker.io/nginx", "library/nginx",
+		},
+		"official image already in library should not be prefixed again": {
+			"docker.io/library/nginx", "library/nginx",
+		},
+		"user image should keep namespace": {
+			"docker.io/jetstack/version-checker", "jetstack/version-checker",
+		},
+		"user image without prefix should keep namespace": {
+			"jetstack/version-checker", "jetstack/version-checker",
+		},
+		"single segment image on another registry should not be official": {
+			"localhost:5000/app", "localhost:5000/app",
+		},
+		"single segment image on another registry host should not be official": {
+			"registry.corp/app", "registry.corp/app",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if path := repoPath(test.imageURL); path != test.expPath {
+				t.Errorf("unexpected repository path, exp=%s got=%s", test.expPath, path)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := map[string]struct {
+		header       http.Header
+		expObserved  []int
+		expThrottled bool
+	}{
+		"no headers should not throttle": {
+			header: http.Header{},
+		},
+		"registry headers should be parsed": {
+			header: http.Header{
+				"Ratelimit-Limit":     []string{"100;w=21600"},
+				"Ratelimit-Remaining": []string{"76;w=21600"},
+			},
+			expObserved: []int{100, 76},
+		},
+		"hub api headers below the threshold should throttle": {
+			header: http.Header{
+				"X-Ratelimit-Limit":     []string{"180"},
+				"X-Ratelimit-Remaining": []string{"4"},
+			},
+			expObserved:  []int{180, 4},
+			expThrottled: true,
+		},
+		"invalid headers should be ignored": {
+			header: http.Header{
+				"Ratelimit-Limit":     []string{"100"},
+				"Ratelimit-Remaining": []string{"none"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var observed []int
+			var throttled bool
+			r := &rateLimit{
+				threshold: 10,
+				delay:     time.Second,
+				observe: func(limit, remaining int) {
+					observed = []int{limit, remaining}
+				},
+				sleep: func(_ context.Context, d time.Duration) error {
+					throttled = d == time.Second
+					return nil
+				},
+			}
+
+			r.update(test.header)
+			if err := r.wait(context.TODO()); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(observed) != len(test.expObserved) ||
+				(len(observed) == 2 && (observed[0] != test.expObserved[0] || observed[1] != test.expObserved[1])) {
+				t.Errorf("unexpected observed rate limit, exp=%v got=%v", test.expObserved, observed)
+			}
+			if throttled != test.expThrottled {
+				t.Errorf("unexpected throttle, exp=%t got=%t", test.expThrottled, throttled)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTagsBounded(t *testing.T) {
+	tests := map[string]struct {
+		maxPages, maxTags int
+		expTags           []string
+		expRequests       int
+	}{
+		"no limits should walk every page": {
+			expTags:     []string{"p0t0", "p0t1", "p0t2", "p1t0", "p1t1", "p1t2", "p2t0", "p2t1", "p2t2"},
+			expRequests: 3,
+		},
+		"max pages should stop after the pages": {
+			maxPages:    2,
+			expTags:     []string{"p0t0", "p0t1", "p0t2", "p1t0", "p1t1", "p1t2"},
+			expRequests: 2,
+		},
+		"max tags should stop once the tags are collected": {
+			maxTags:     4,
+			expTags:     []string{"p0t0", "p0t1", "p0t2", "p1t0"},
+			expRequests: 2,
+		},
+		"lower max pages should win over max tags": {
+			maxPages:    1,
+			maxTags:     4,
+			expTags:     []string{"p0t0", "p0t1", "p0t2"},
+			expRequests: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests int
+			transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if requests == 0 && req.URL.Query().Get("ordering") != "last_updated" {
+					t.Errorf("unexpected ordering, exp=last_updated got=%s", req.URL.Query().Get("ordering"))
+				}
+
+				page := requests
+				requests++
+
+				response := TagResponse{}
+				if page < 2 {
+					response.Next = fmt.Sprintf("https://registry.hub.docker.com/v2/repositories/library/nginx/tags?page=%d", page+1)
+				}
+				for i := 0; i < 3; i++ {
+					response.Results = append(response.Results, Result{
+						Name:      fmt.Sprintf("p%dt%d", page, i),
+						Digest:    "sha256:index",
+						Timestamp: "2020-01-01T00:00:00Z",
+						Images: []Image{
+							{Digest: "sha256:a", OS: "linux", Architecture: "amd64"},
+							{Digest: "sha256:b", OS: "linux", Architecture: "arm64"},
+						},
+					})
+				}
+
+				body, err := json.Marshal(response)
+				if err != nil {
+					return nil, err
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{},
+					Body:       ioutil.NopCloser(strings.NewReader(string(body))),
+				}, nil
+			})
+
+			c := &Client{
+				Options:   Options{MaxPages: test.maxPages, MaxTags: test.maxTags},
+				Client:    &http.Client{Transport: transport},
+				jwts:      make(map[string]string),
+				rateLimit: &rateLimit{sleep: sleepContext},
+			}
+
+			tags, err := c.Tags(context.TODO(), "nginx")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, tag := range tags {
+				if tag.IndexSHA != "sha256:index" {
+					t.Errorf("unexpected index digest of %q, exp=sha256:index got=%s", tag.Tag, tag.IndexSHA)
+				}
+
+				// Every platform of a tag should be kept
+				if tag.Architecture == "arm64" {
+					names = append(names, tag.Tag)
+				}
+			}
+			if len(tags) != len(names)*2 {
+				t.Errorf("unexpected platforms, exp=%d got=%d", len(names)*2, len(tags))
+			}
+			if strings.Join(names, ",") != strings.Join(test.expTags, ",") {
+				t.Errorf("unexpected tags, exp=%v got=%v", test.expTags, names)
+			}
+			if requests != test.expRequests {
+				t.Errorf("unexpected requests, exp=%d got=%d", test.expRequests, requests)
+			}
+		})
+	}
+}
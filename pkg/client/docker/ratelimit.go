@@ -0,0 +1,98 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimit tracks the pull rate limit of Docker Hub, as last reported in
+// response headers, and throttles requests while the remaining budget is
+// below a threshold.
+type rateLimit struct {
+	// threshold is the remaining budget below which requests are throttled.
+	// Zero disables throttling.
+	threshold int
+	delay     time.Duration
+
+	// observe is called with every reported limit and remaining budget, if
+	// set.
+	observe func(limit, remaining int)
+
+	// sleep waits for the duration, returning early with an error if the
+	// context is done.
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu        sync.Mutex
+	remaining int
+	known     bool
+}
+
+// update records the rate limit reported by the response headers, if any.
+func (r *rateLimit) update(header http.Header) {
+	limit, okLimit := rateLimitHeader(header, "RateLimit-Limit")
+	remaining, ok := rateLimitHeader(header, "RateLimit-Remaining")
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	r.remaining, r.known = remaining, true
+	r.mu.Unlock()
+
+	if r.observe != nil && okLimit {
+		r.observe(limit, remaining)
+	}
+}
+
+// wait will wait for the throttle delay before a request if the last known
+// remaining budget is below the threshold.
+func (r *rateLimit) wait(ctx context.Context) error {
+	r.mu.Lock()
+	throttle := r.threshold > 0 && r.known && r.remaining < r.threshold
+	r.mu.Unlock()
+
+	if !throttle {
+		return nil
+	}
+
+	return r.sleep(ctx, r.delay)
+}
+
+// rateLimitHeader returns the value of the rate limit header, reported by the
+// registry as e.g. "100;w=21600", or by the Hub API with an X- prefix.
+func rateLimitHeader(header http.Header, name string) (int, bool) {
+	value := header.Get(name)
+	if len(value) == 0 {
+		value = header.Get("X-" + name)
+	}
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if i := strings.Index(value, ";"); i > -1 {
+		value = value[:i]
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	return n, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,172 @@
+package docr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	imagePrefix = "registry.digitalocean.com/"
+
+	// DefaultAPIHost is the host of the DigitalOcean API.
+	DefaultAPIHost = "api.digitalocean.com"
+
+	tagsURL = "https://%s/v2/registry/%s/repositoriesV2/%s/tags?per_page=100"
+)
+
+type Options struct {
+	// Token is a DigitalOcean API token with read access to the registry.
+	Token string
+
+	// APIHost is the host of the DigitalOcean API. Defaults to
+	// api.digitalocean.com.
+	APIHost string
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+}
+
+type Client struct {
+	*http.Client
+	Options
+}
+
+type Response struct {
+	Tags  []Tag `json:"tags"`
+	Links struct {
+		Pages struct {
+			Next string `json:"next"`
+		} `json:"pages"`
+	} `json:"links"`
+}
+
+type Tag struct {
+	Tag            string    `json:"tag"`
+	ManifestDigest string    `json:"manifest_digest"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type ErrorResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func New(opts Options) *Client {
+	if len(opts.APIHost) == 0 {
+		opts.APIHost = DefaultAPIHost
+	}
+
+	return &Client{
+		Options: opts,
+		Client: &http.Client{
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
+		},
+	}
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	return strings.HasPrefix(imageURL, imagePrefix)
+}
+
+// Tags will list the tags of the image's repository with the DigitalOcean
+// API. Tags are timestamped by when they were last updated.
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	token := c.token(ctx)
+	if len(token) == 0 {
+		return nil, errors.New("a DigitalOcean API token is required to list registry tags")
+	}
+
+	split := strings.SplitN(strings.TrimPrefix(imageURL, imagePrefix), "/", 2)
+	if len(split) != 2 || len(split[0]) == 0 || len(split[1]) == 0 {
+		return nil, fmt.Errorf("image %q is not of the form %s<registry>/<repository>", imageURL, imagePrefix)
+	}
+
+	// Repository names containing slashes must be encoded.
+	url := fmt.Sprintf(tagsURL, c.APIHost, url.PathEscape(split[0]), url.PathEscape(split[1]))
+
+	var tags []api.ImageTag
+	for len(url) > 0 {
+		var response Response
+		if err := c.doRequest(ctx, token, url, &response); err != nil {
+			return nil, err
+		}
+		util.CountPage(ctx)
+
+		for _, tag := range response.Tags {
+			tags = append(tags, api.ImageTag{
+				Tag:       tag.Tag,
+				SHA:       tag.ManifestDigest,
+				Timestamp: tag.UpdatedAt,
+			})
+		}
+
+		url = response.Links.Pages.Next
+	}
+
+	return tags, nil
+}
+
+// token returns the API token of the credentials of the context, such as from
+// image pull secrets, or else the configured token. Registry credentials of
+// doctl hold the API token as their password.
+func (c *Client) token(ctx context.Context) string {
+	if cred, ok := util.CredentialsFor(ctx, strings.TrimSuffix(imagePrefix, "/")); ok {
+		return cred.Password
+	}
+
+	return c.Token
+}
+
+func (c *Client) doRequest(ctx context.Context, token, url string, obj interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req = req.WithContext(ctx)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get docr image: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := util.NotFound(resp, url, body); err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResponse ErrorResponse
+		if err := json.Unmarshal(body, &errResponse); err == nil && len(errResponse.Message) > 0 {
+			return fmt.Errorf("unexpected status code from %q: %d %s: %s",
+				url, resp.StatusCode, errResponse.ID, errResponse.Message)
+		}
+
+		return fmt.Errorf("unexpected status code from %q: %d %s",
+			url, resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, obj); err != nil {
+		return fmt.Errorf("unexpected response from %q: %s", url, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package docr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+func TestTags(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer do-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"id":"Unauthorized","message":"Unable to authenticate you"}`))
+			return
+		}
+
+		if req.URL.EscapedPath() != "/v2/registry/jetstack/repositoriesV2/team%2Fapp/tags" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id":"not_found","message":"repository not found"}`))
+			return
+		}
+
+		if req.URL.Query().Get("page") == "" {
+			w.Write([]byte(`{"tags":[
+				{"tag":"v0.1.0","manifest_digest":"sha256:a","updated_at":"2020-06-01T10:00:00Z"}
+			],"links":{"pages":{"next":"` + server.URL + `/v2/registry/jetstack/repositoriesV2/team%2Fapp/tags?page=2&per_page=100"}}}`))
+			return
+		}
+
+		w.Write([]byte(`{"tags":[
+			{"tag":"v0.2.0","manifest_digest":"sha256:b","updated_at":"2020-07-01T10:00:00Z"}
+		],"links":{}}`))
+	}))
+	defer server.Close()
+
+	client := New(Options{
+		Token:     "do-token",
+		APIHost:   strings.TrimPrefix(server.URL, "https://"),
+		Transport: server.Client().Transport,
+	})
+
+	tags, err := client.Tags(context.TODO(), "registry.digitalocean.com/jetstack/team/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 2 {
+		t.Fatalf("unexpected number of tags, exp=2 got=%d: %+v", len(tags), tags)
+	}
+
+	exp := []struct {
+		tag, sha  string
+		timestamp time.Time
+	}{
+		{"v0.1.0", "sha256:a", time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)},
+		{"v0.2.0", "sha256:b", time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for i, e := range exp {
+		if tags[i].Tag != e.tag || tags[i].SHA != e.sha || !tags[i].Timestamp.Equal(e.timestamp) {
+			t.Errorf("unexpected tag, exp=%+v got=%+v", e, tags[i])
+		}
+	}
+
+	if _, err := client.Tags(context.TODO(), "registry.digitalocean.com/jetstack/missing"); !errors.Is(err, util.ErrRepositoryNotFound) {
+		t.Errorf("expected repository not found error, got=%v", err)
+	}
+
+	client.Token = "wrong-token"
+	_, err = client.Tags(context.TODO(), "registry.digitalocean.com/jetstack/team/app")
+	if err == nil || !strings.Contains(err.Error(), "Unable to authenticate you") {
+		t.Errorf("expected authentication error, got=%v", err)
+	}
+
+	client.Token = ""
+	if _, err := client.Tags(context.TODO(), "registry.digitalocean.com/jetstack/team/app"); err == nil {
+		t.Error("expected error without token, got=nil")
+	}
+
+	// Registry credentials of doctl hold the API token as their password.
+	ctx := util.WithHostCredentials(context.TODO(), "registry.digitalocean.com",
+		util.Credentials{Username: "do-token", Password: "do-token"})
+	if _, err := client.Tags(ctx, "registry.digitalocean.com/jetstack/team/app"); err != nil {
+		t.Errorf("expected context credentials to be used, got=%v", err)
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	client := New(Options{})
+
+	for imageURL, exp := range map[string]bool{
+		"registry.digitalocean.com/jetstack/app":      true,
+		"registry.digitalocean.com/jetstack/team/app": true,
+		"registry.digitalocean.co/jetstack/app":       false,
+		"docker.io/jetstack/app":                      false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package ecr
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// credentialsExpiryLeeway is how long before expiry credentials are
+	// refreshed.
+	credentialsExpiryLeeway = time.Minute
+
+	defaultRoleSessionName = "version-checker"
+	stsVersion             = "2011-06-15"
+)
+
+var (
+	// stsEndpoint is the regional STS endpoint, formatted with the region and
+	// domain of the registry.
+	stsEndpoint = "https://sts.%s.%s/"
+
+	// instanceMetadataURL is the EC2 instance metadata service.
+	instanceMetadataURL = "http://169.254.169.254"
+
+	// containerMetadataURL is the ECS container credentials endpoint, which
+	// AWS_CONTAINER_CREDENTIALS_RELATIVE_URI is relative to.
+	containerMetadataURL = "http://169.254.170.2"
+)
+
+// awsCredentials are AWS access keys, temporary if they have an expiration.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// metadataCredentials are credentials returned by the container and instance
+// metadata endpoints.
+type metadataCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// stsResponse is the response of the STS AssumeRole and
+// AssumeRoleWithWebIdentity actions, whose result elements are named after
+// the action.
+type stsResponse struct {
+	Result struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"Credentials"`
+	} `xml:",any"`
+}
+
+// credentialsCache caches credentials returned by fetch until shortly before
+// they expire. Credentials without an expiration are cached indefinitely.
+type credentialsCache struct {
+	fetch func(ctx context.Context) (*awsCredentials, error)
+
+	mu    sync.Mutex
+	creds *awsCredentials
+}
+
+// get returns valid credentials, fetching new ones if the cached
+// credentials are about to expire.
+func (c *credentialsCache) get(ctx context.Context) (*awsCredentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.creds != nil && (c.creds.Expiration.IsZero() ||
+		time.Now().Add(credentialsExpiryLeeway).Before(c.creds.Expiration)) {
+		return c.creds, nil
+	}
+
+	creds, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.creds = creds
+
+	return creds, nil
+}
+
+// defaultCredentials will return credentials from the first source of the
+// default credential chain which is configured: environment variables, a
+// web identity token (IRSA), the container credentials endpoint, then the
+// instance metadata service.
+func (c *Client) defaultCredentials(ctx context.Context, region, domain string) (*awsCredentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); len(id) > 0 && len(secret) > 0 {
+		return &awsCredentials{
+			AccessKeyID:     id,
+			SecretAccessKey: secret,
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	if tokenFile, roleARN := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN"); len(tokenFile) > 0 && len(roleARN) > 0 {
+		creds, err := c.webIdentityCredentials(ctx, region, domain, tokenFile, roleARN)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get web identity credentials: %s", err)
+		}
+		return creds, nil
+	}
+
+	fullURI := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relativeURI := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); len(relativeURI) > 0 {
+		fullURI = containerMetadataURL + relativeURI
+	}
+	if len(fullURI) > 0 {
+		creds, err := c.containerCredentials(ctx, fullURI)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get container credentials: %s", err)
+		}
+		return creds, nil
+	}
+
+	creds, err := c.instanceCredentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("no AWS credentials found, and failed to get instance credentials: %s", err)
+	}
+
+	return creds, nil
+}
+
+// webIdentityCredentials will exchange the web identity token file for
+// temporary credentials of the role.
+func (c *Client) webIdentityCredentials(ctx context.Context, region, domain, tokenFile, roleARN string) (*awsCredentials, error) {
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read web identity token: %s", err)
+	}
+
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if len(sessionName) == 0 {
+		sessionName = defaultRoleSessionName
+	}
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {stsVersion},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+
+	// AssumeRoleWithWebIdentity is authenticated by the token, so isn't signed.
+	return c.sts(ctx, region, domain, form, nil)
+}
+
+// assumeRole will assume the role using the base credentials.
+func (c *Client) assumeRole(ctx context.Context, region, domain, roleARN string, base *awsCredentials) (*awsCredentials, error) {
+	form := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {stsVersion},
+		"RoleArn":         {roleARN},
+		"RoleSessionName": {defaultRoleSessionName},
+	}
+
+	creds, err := c.sts(ctx, region, domain, form, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assume role %q: %s", roleARN, err)
+	}
+
+	return creds, nil
+}
+
+// sts will perform the STS action of the form, signing the request if creds
+// is not nil.
+func (c *Client) sts(ctx context.Context, region, domain string, form url.Values, creds *awsCredentials) (*awsCredentials, error) {
+	body := []byte(form.Encode())
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(stsEndpoint, region, domain), strings.NewReader(string(body)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if creds != nil {
+		signRequest(req, body, creds, region, "sts", time.Now())
+	}
+
+	respBody, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := new(stsResponse)
+	if err := xml.Unmarshal(respBody, response); err != nil ||
+		len(response.Result.Credentials.AccessKeyID) == 0 {
+		return nil, fmt.Errorf("unexpected STS response: %s", respBody)
+	}
+
+	return &awsCredentials{
+		AccessKeyID:     response.Result.Credentials.AccessKeyID,
+		SecretAccessKey: response.Result.Credentials.SecretAccessKey,
+		SessionToken:    response.Result.Credentials.SessionToken,
+		Expiration:      response.Result.Credentials.Expiration,
+	}, nil
+}
+
+// containerCredentials will request credentials from the container
+// credentials endpoint, as used by ECS and EKS Pod Identity.
+func (c *Client) containerCredentials(ctx context.Context, uri string) (*awsCredentials, error) {
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); len(tokenFile) > 0 {
+		data, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read container authorization token: %s", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if len(token) > 0 {
+		req.Header.Set("Authorization", token)
+	}
+
+	return c.metadataCredentials(ctx, req)
+}
+
+// instanceCredentials will request credentials of the instance profile role
+// from the instance metadata service, using IMDSv2.
+func (c *Client) instanceCredentials(ctx context.Context) (*awsCredentials, error) {
+	req, err := http.NewRequest(http.MethodPut, instanceMetadataURL+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+
+	token, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rolesURL := instanceMetadataURL + "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequest(http.MethodGet, rolesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+
+	roles, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	role := strings.TrimSpace(strings.SplitN(string(roles), "\n", 2)[0])
+	if len(role) == 0 {
+		return nil, errors.New("no instance profile role")
+	}
+
+	req, err = http.NewRequest(http.MethodGet, rolesURL+role, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+
+	return c.metadataCredentials(ctx, req)
+}
+
+func (c *Client) metadataCredentials(ctx context.Context, req *http.Request) (*awsCredentials, error) {
+	body, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := new(metadataCredentials)
+	if err := json.Unmarshal(body, response); err != nil || len(response.AccessKeyID) == 0 {
+		return nil, fmt.Errorf("unexpected credentials response from %q", req.URL)
+	}
+
+	return &awsCredentials{
+		AccessKeyID:     response.AccessKeyID,
+		SecretAccessKey: response.SecretAccessKey,
+		SessionToken:    response.Token,
+		Expiration:      response.Expiration,
+	}, nil
+}
+
+// send will perform the request, returning the response body if the status
+// code is 200.
+func (c *Client) send(ctx context.Context, req *http.Request) ([]byte, error) {
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %q: %s", req.URL.Host+req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from %q: %d %s",
+			req.URL.Host+req.URL.Path, resp.StatusCode, body)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,242 @@
+package ecr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	describeImagesTarget = "AmazonEC2ContainerRegistry_V20150921.DescribeImages"
+
+	repositoryNotFoundException = "RepositoryNotFoundException"
+)
+
+var (
+	// hostRegex matches private ECR registry hosts, capturing the account ID,
+	// region and domain. e.g. 123456789012.dkr.ecr.eu-west-1.amazonaws.com
+	hostRegex = regexp.MustCompile(`^([0-9]{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.(amazonaws\.com(?:\.cn)?)$`)
+
+	// ecrEndpoint is the regional ECR API endpoint, formatted with the region
+	// and domain of the registry.
+	ecrEndpoint = "https://api.ecr.%s.%s/"
+)
+
+type Options struct {
+	// AccessKeyID, SecretAccessKey and SessionToken are static credentials.
+	// If empty, the default credential chain is used.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// AssumeRoleARNs is a map of registry account ID to the ARN of a role to
+	// assume for lookups against registries of that account.
+	AssumeRoleARNs map[string]string
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+}
+
+type Client struct {
+	*http.Client
+	Options
+
+	mu sync.Mutex
+	// credentials holds the cached credentials of each region, and of each
+	// assumed role per region.
+	credentials map[string]*credentialsCache
+}
+
+type DescribeImagesResponse struct {
+	ImageDetails []ImageDetail `json:"imageDetails"`
+	NextToken    string        `json:"nextToken"`
+}
+
+type ImageDetail struct {
+	ImageDigest   string   `json:"imageDigest"`
+	ImageTags     []string `json:"imageTags"`
+	ImagePushedAt float64  `json:"imagePushedAt"`
+}
+
+type ErrorResponse struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func New(opts Options) *Client {
+	return &Client{
+		Options: opts,
+		Client: &http.Client{
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
+		},
+		credentials: make(map[string]*credentialsCache),
+	}
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	return hostRegex.MatchString(api.ParseImageRef(imageURL).Registry)
+}
+
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	ref := api.ParseImageRef(imageURL)
+	match := hostRegex.FindStringSubmatch(ref.Registry)
+	if len(match) != 4 {
+		return nil, fmt.Errorf("failed to parse ECR registry host %q", ref.Registry)
+	}
+	account, region, domain := match[1], match[2], match[3]
+
+	creds, err := c.credentialsFor(account, region, domain).get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS credentials: %s", err)
+	}
+
+	var (
+		tags      []api.ImageTag
+		nextToken string
+	)
+	for {
+		request := map[string]interface{}{
+			"registryId":     account,
+			"repositoryName": ref.Repository,
+			"maxResults":     1000,
+			"filter":         map[string]string{"tagStatus": "TAGGED"},
+		}
+		if len(nextToken) > 0 {
+			request["nextToken"] = nextToken
+		}
+
+		response := new(DescribeImagesResponse)
+		if err := c.doRequest(ctx, region, domain, creds, request, response); err != nil {
+			return nil, err
+		}
+		util.CountPage(ctx)
+
+		for _, image := range response.ImageDetails {
+			sec, frac := math.Modf(image.ImagePushedAt)
+			timestamp := time.Unix(int64(sec), int64(frac*1e9)).UTC()
+
+			for _, tag := range image.ImageTags {
+				tags = append(tags, api.ImageTag{
+					Tag:       tag,
+					SHA:       image.ImageDigest,
+					Timestamp: timestamp,
+				})
+			}
+		}
+
+		nextToken = response.NextToken
+		if len(nextToken) == 0 {
+			return tags, nil
+		}
+	}
+}
+
+// credentialsFor returns the credentials cache for lookups against the
+// registry of the account. If a role is set for the account, the role is
+// assumed with the base credentials.
+func (c *Client) credentialsFor(account, region, domain string) *credentialsCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	base, ok := c.credentials[region]
+	if !ok {
+		base = &credentialsCache{
+			fetch: func(ctx context.Context) (*awsCredentials, error) {
+				if len(c.AccessKeyID) > 0 {
+					return &awsCredentials{
+						AccessKeyID:     c.AccessKeyID,
+						SecretAccessKey: c.SecretAccessKey,
+						SessionToken:    c.SessionToken,
+					}, nil
+				}
+				return c.defaultCredentials(ctx, region, domain)
+			},
+		}
+		c.credentials[region] = base
+	}
+
+	roleARN, ok := c.AssumeRoleARNs[account]
+	if !ok {
+		return base
+	}
+
+	key := region + "/" + roleARN
+	role, ok := c.credentials[key]
+	if !ok {
+		role = &credentialsCache{
+			fetch: func(ctx context.Context) (*awsCredentials, error) {
+				baseCreds, err := base.get(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return c.assumeRole(ctx, region, domain, roleARN, baseCreds)
+			},
+		}
+		c.credentials[key] = role
+	}
+
+	return role
+}
+
+func (c *Client) doRequest(ctx context.Context, region, domain string, creds *awsCredentials,
+	request, obj interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf(ecrEndpoint, region, domain)
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", describeImagesTarget)
+	signRequest(req, body, creds, region, "ecr", time.Now())
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get ecr images: %s", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		errResponse := new(ErrorResponse)
+		if err := json.Unmarshal(respBody, errResponse); err == nil && len(errResponse.Type) > 0 {
+			// The type may be prefixed with the service namespace.
+			if strings.HasSuffix(errResponse.Type, repositoryNotFoundException) {
+				return fmt.Errorf("%w: %s", util.ErrRepositoryNotFound, errResponse.Message)
+			}
+			return fmt.Errorf("ecr returned error %s: %s", errResponse.Type, errResponse.Message)
+		}
+
+		return fmt.Errorf("unexpected status code from %q: %d %s",
+			url, resp.StatusCode, respBody)
+	}
+
+	if err := json.Unmarshal(respBody, obj); err != nil {
+		return fmt.Errorf("unexpected response from %q: %s", url, respBody)
+	}
+
+	return nil
+}
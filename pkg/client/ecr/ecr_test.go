@@ -0,0 +1,181 @@
+package ecr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	testImageURL = "123456789012.dkr.ecr.eu-west-1.amazonaws.com/jetstack/app"
+	testRoleARN  = "arn:aws:iam::123456789012:role/version-checker"
+)
+
+// newTestAWS returns a server serving STS and ECR, which only allows
+// DescribeImages signed with the credentials of the assumed role.
+func newTestAWS(t *testing.T) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		if req.URL.Path == "/sts/" {
+			form, _ := url.ParseQuery(string(body))
+			switch form.Get("Action") {
+			case "AssumeRoleWithWebIdentity":
+				if form.Get("WebIdentityToken") != "web-identity-token" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				writeSTSCredentials(w, form.Get("Action"), "web-identity")
+			case "AssumeRole":
+				if form.Get("RoleArn") != testRoleARN ||
+					!strings.Contains(req.Header.Get("Authorization"), "Credential=web-identity/") {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				writeSTSCredentials(w, form.Get("Action"), "assumed-role")
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			return
+		}
+
+		if req.Header.Get("X-Amz-Target") != describeImagesTarget ||
+			!strings.Contains(req.Header.Get("Authorization"), "Credential=assumed-role/") ||
+			req.Header.Get("X-Amz-Security-Token") != "assumed-role-session" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var request struct {
+			RegistryID     string `json:"registryId"`
+			RepositoryName string `json:"repositoryName"`
+			NextToken      string `json:"nextToken"`
+		}
+		json.Unmarshal(body, &request)
+
+		switch {
+		case request.RegistryID != "123456789012" || request.RepositoryName != "jetstack/app":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"RepositoryNotFoundException","message":"repository does not exist"}`))
+		case request.NextToken == "":
+			w.Write([]byte(`{"imageDetails":[
+				{"imageDigest":"sha256:a","imageTags":["v0.1.0","v0.1"],"imagePushedAt":1590969600.5}
+			],"nextToken":"next"}`))
+		default:
+			w.Write([]byte(`{"imageDetails":[
+				{"imageDigest":"sha256:b","imageTags":["v0.2.0"],"imagePushedAt":1593561600}
+			]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	ecrEndpoint, stsEndpoint = server.URL+"/ecr/%s/%s", server.URL+"/sts/%.0s%.0s"
+	t.Cleanup(func() {
+		ecrEndpoint, stsEndpoint = "https://api.ecr.%s.%s/", "https://sts.%s.%s/"
+	})
+
+	return server
+}
+
+func writeSTSCredentials(w http.ResponseWriter, action, keyID string) {
+	w.Write([]byte(`<` + action + `Response><` + action + `Result><Credentials>` +
+		`<AccessKeyId>` + keyID + `</AccessKeyId>` +
+		`<SecretAccessKey>secret</SecretAccessKey>` +
+		`<SessionToken>` + keyID + `-session</SessionToken>` +
+		`<Expiration>` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `</Expiration>` +
+		`</Credentials></` + action + `Result></` + action + `Response>`))
+}
+
+func setenv(t *testing.T, env map[string]string) {
+	for k, v := range env {
+		prev, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+
+		k := k
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(k, prev)
+			} else {
+				os.Unsetenv(k)
+			}
+		})
+	}
+}
+
+func TestTagsWebIdentityAssumeRole(t *testing.T) {
+	server := newTestAWS(t)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("web-identity-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	setenv(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":           "",
+		"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile,
+		"AWS_ROLE_ARN":                "arn:aws:iam::000000000000:role/irsa",
+	})
+
+	client := New(Options{
+		AssumeRoleARNs: map[string]string{"123456789012": testRoleARN},
+		Transport:      server.Client().Transport,
+	})
+
+	if !client.IsClient(testImageURL) {
+		t.Fatalf("expected client to match %q", testImageURL)
+	}
+
+	tags, err := client.Tags(context.TODO(), testImageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []struct {
+		tag, sha  string
+		timestamp time.Time
+	}{
+		{"v0.1.0", "sha256:a", time.Date(2020, 6, 1, 0, 0, 0, 5e8, time.UTC)},
+		{"v0.1", "sha256:a", time.Date(2020, 6, 1, 0, 0, 0, 5e8, time.UTC)},
+		{"v0.2.0", "sha256:b", time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	if len(tags) != len(exp) {
+		t.Fatalf("unexpected number of tags, exp=%d got=%d: %+v", len(exp), len(tags), tags)
+	}
+	for i, e := range exp {
+		if tags[i].Tag != e.tag || tags[i].SHA != e.sha || !tags[i].Timestamp.Equal(e.timestamp) {
+			t.Errorf("unexpected tag, exp=%+v got=%+v", e, tags[i])
+		}
+	}
+
+	_, err = client.Tags(context.TODO(), "123456789012.dkr.ecr.eu-west-1.amazonaws.com/jetstack/missing")
+	if !errors.Is(err, util.ErrRepositoryNotFound) {
+		t.Errorf("unexpected error, exp=%v got=%v", util.ErrRepositoryNotFound, err)
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	client := New(Options{})
+
+	for imageURL, exp := range map[string]bool{
+		"123456789012.dkr.ecr.eu-west-1.amazonaws.com/jetstack/app":      true,
+		"123456789012.dkr.ecr-fips.us-east-1.amazonaws.com/jetstack/app": true,
+		"123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn/jetstack/app":  true,
+		"12345.dkr.ecr.eu-west-1.amazonaws.com/jetstack/app":             false,
+		"public.ecr.aws/jetstack/app":                                    false,
+		"quay.io/jetstack/app":                                           false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package ecrpublic
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/oci"
+)
+
+const (
+	// DefaultHost is the host of the Amazon ECR Public registry.
+	DefaultHost = "public.ecr.aws"
+)
+
+type Options struct {
+	// Host is the registry host. Defaults to public.ecr.aws.
+	Host string
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+}
+
+// Client lists tags of ECR Public gallery images with the distribution API,
+// authenticating anonymously with the public token endpoint the registry
+// challenges with.
+type Client struct {
+	Options
+
+	oci *oci.Client
+}
+
+func New(opts Options) *Client {
+	if len(opts.Host) == 0 {
+		opts.Host = DefaultHost
+	}
+
+	return &Client{
+		Options: opts,
+		oci:     oci.New(oci.Options{Transport: opts.Transport, LazyAuth: true}),
+	}
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	return strings.HasPrefix(imageURL, c.Host+"/")
+}
+
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	return c.oci.ImageTags(ctx, c.Host, strings.TrimPrefix(imageURL, c.Host+"/"))
+}
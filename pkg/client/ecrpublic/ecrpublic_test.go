@@ -0,0 +1,101 @@
+package ecrpublic
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTags(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token/" {
+			if scope := req.URL.Query().Get("scope"); scope != "aws:amazonlinux:pull" {
+				t.Errorf("unexpected token scope, got=%s", scope)
+			}
+			w.Write([]byte(`{"token":"public-token"}`))
+			return
+		}
+
+		if req.Header.Get("Authorization") != "Bearer public-token" {
+			w.Header().Set("WWW-Authenticate",
+				`Bearer realm="`+server.URL+`/token/",service="public.ecr.aws",scope="aws:amazonlinux:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch req.URL.Path {
+		case "/v2/amazonlinux/amazonlinux/tags/list":
+			w.Write([]byte(`{"name":"amazonlinux/amazonlinux","tags":["2","2023"]}`))
+		case "/v2/amazonlinux/amazonlinux/manifests/2":
+			w.Header().Set("Docker-Content-Digest", "sha256:two")
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"digest":"sha256:config-two"}}`))
+		case "/v2/amazonlinux/amazonlinux/manifests/2023":
+			w.Header().Set("Docker-Content-Digest", "sha256:2023")
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+				{"digest":"sha256:2023-arm64","platform":{"os":"linux","architecture":"arm64"}},
+				{"digest":"sha256:2023-amd64","platform":{"os":"linux","architecture":"amd64"}}
+			]}`))
+		case "/v2/amazonlinux/amazonlinux/manifests/sha256:2023-amd64":
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"digest":"sha256:config-2023"}}`))
+		case "/v2/amazonlinux/amazonlinux/blobs/sha256:config-two":
+			w.Write([]byte(`{"created":"2020-06-01T10:00:00Z","os":"linux","architecture":"amd64"}`))
+		case "/v2/amazonlinux/amazonlinux/blobs/sha256:config-2023":
+			w.Write([]byte(`{"created":"2023-03-01T10:00:00Z","os":"linux","architecture":"amd64"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Options{
+		Host:      strings.TrimPrefix(server.URL, "https://"),
+		Transport: server.Client().Transport,
+	})
+
+	imageURL := client.Host + "/amazonlinux/amazonlinux"
+	if !client.IsClient(imageURL) {
+		t.Fatalf("expected client to match %q", imageURL)
+	}
+
+	tags, err := client.Tags(context.TODO(), imageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 2 {
+		t.Fatalf("unexpected number of tags, exp=2 got=%d: %+v", len(tags), tags)
+	}
+
+	exp := []struct {
+		tag, sha, arch string
+		timestamp      time.Time
+	}{
+		{"2", "sha256:two", "amd64", time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)},
+		{"2023", "sha256:2023", "", time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for i, e := range exp {
+		if tags[i].Tag != e.tag || tags[i].SHA != e.sha ||
+			tags[i].Architecture != e.arch || !tags[i].Timestamp.Equal(e.timestamp) {
+			t.Errorf("unexpected tag, exp=%+v got=%+v", e, tags[i])
+		}
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	client := New(Options{})
+
+	for imageURL, exp := range map[string]bool{
+		"public.ecr.aws/amazonlinux/amazonlinux":                    true,
+		"public.ecr.aws/eks-distro/kubernetes/pause":                true,
+		"123456789012.dkr.ecr.eu-west-1.amazonaws.com/jetstack/app": false,
+		"public.ecr.aws.corp/jetstack/app":                          false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+}
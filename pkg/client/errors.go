@@ -0,0 +1,18 @@
+package client
+
+import "errors"
+
+var (
+	// ErrUnsupported is returned when the registry serving an image does not
+	// expose the data required for the operation.
+	ErrUnsupported = errors.New("operation not supported for image registry")
+
+	// ErrCircuitOpen is returned when the registry serving an image has
+	// failed too many consecutive lookups, and no previous tags of the image
+	// are known.
+	ErrCircuitOpen = errors.New("registry circuit breaker open after consecutive failures")
+
+	// ErrRateLimited is returned when a lookup would wait too long for the
+	// rate limit of its registry host.
+	ErrRateLimited = errors.New("registry lookup rate limit exceeded")
+)
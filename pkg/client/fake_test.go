@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// fakeClient is an ImageClient which returns a static set of tags, or error.
+type fakeClient struct {
+	tags  []api.ImageTag
+	err   error
+	calls int
+}
+
+func (f *fakeClient) IsClient(string) bool {
+	return true
+}
+
+func (f *fakeClient) Tags(context.Context, string) ([]api.ImageTag, error) {
+	f.calls++
+	return f.tags, f.err
+}
+
+func newFakeClient(tags []api.ImageTag) *Client {
+	return &Client{fallback: &fakeClient{tags: tags}}
+}
@@ -0,0 +1,299 @@
+package gcpauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+
+	// scope is the OAuth scope requested for access tokens.
+	scope = "https://www.googleapis.com/auth/cloud-platform"
+
+	jwtBearerGrantType    = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	refreshTokenGrantType = "refresh_token"
+
+	// tokenExpiryLeeway is how long before expiry an access token is
+	// refreshed.
+	tokenExpiryLeeway = time.Minute
+
+	// credentialsEnv is the environment variable holding the path of the
+	// application default credentials file.
+	credentialsEnv = "GOOGLE_APPLICATION_CREDENTIALS"
+)
+
+var (
+	// metadataTokenURL is the metadata server endpoint returning an access
+	// token of the instance's default service account, or of the Kubernetes
+	// service account's bound service account with GKE Workload Identity.
+	metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// wellKnownCredentialsFile returns the path of the application default
+	// credentials written by gcloud auth application-default login.
+	wellKnownCredentialsFile = func() string {
+		return filepath.Join(os.Getenv("HOME"), ".config", "gcloud", "application_default_credentials.json")
+	}
+)
+
+// credentialsFile is a service account key or authorized user credentials
+// file.
+type credentialsFile struct {
+	Type string `json:"type"`
+
+	// service_account
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+
+	// authorized_user
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// TokenSource returns OAuth access tokens, exchanged from a service account
+// key or user refresh token, or requested from the metadata server, and
+// cached until shortly before they expire.
+type TokenSource struct {
+	client *http.Client
+
+	// creds is used to exchange a signed JWT or refresh token for an access
+	// token. If nil, the metadata server is used.
+	creds      *credentialsFile
+	privateKey *rsa.PrivateKey
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewKeyTokenSource returns a TokenSource exchanging the service account key
+// file at the path for access tokens.
+func NewKeyTokenSource(client *http.Client, path string) (*TokenSource, error) {
+	creds, err := readCredentialsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if creds.Type != "service_account" {
+		return nil, fmt.Errorf("unsupported credentials type in %q: %q", path, creds.Type)
+	}
+
+	return newFileTokenSource(client, path, creds)
+}
+
+// NewMetadataTokenSource returns a TokenSource requesting access tokens from
+// the metadata server.
+func NewMetadataTokenSource(client *http.Client) *TokenSource {
+	return &TokenSource{client: client}
+}
+
+// NewDefaultTokenSource returns a TokenSource using the application default
+// credentials: the credentials file at GOOGLE_APPLICATION_CREDENTIALS, then
+// the credentials file written by gcloud, then the metadata server.
+func NewDefaultTokenSource(client *http.Client) (*TokenSource, error) {
+	path := os.Getenv(credentialsEnv)
+	if len(path) == 0 {
+		path = wellKnownCredentialsFile()
+		if _, err := os.Stat(path); err != nil {
+			return NewMetadataTokenSource(client), nil
+		}
+	}
+
+	creds, err := readCredentialsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFileTokenSource(client, path, creds)
+}
+
+func readCredentialsFile(path string) (*credentialsFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %s", err)
+	}
+
+	creds := new(credentialsFile)
+	if err := json.Unmarshal(data, creds); err != nil {
+		return nil, fmt.Errorf("failed to decode credentials file %q: %s", path, err)
+	}
+	if len(creds.TokenURI) == 0 {
+		creds.TokenURI = defaultTokenURI
+	}
+
+	return creds, nil
+}
+
+func newFileTokenSource(client *http.Client, path string, creds *credentialsFile) (*TokenSource, error) {
+	switch creds.Type {
+	case "service_account":
+	case "authorized_user":
+		if len(creds.RefreshToken) == 0 {
+			return nil, fmt.Errorf("no refresh token in credentials file %q", path)
+		}
+		return &TokenSource{client: client, creds: creds}, nil
+	default:
+		return nil, fmt.Errorf("unsupported credentials type in %q: %q", path, creds.Type)
+	}
+
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM private key in service account key %q", path)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key of %q: %s", path, err)
+	}
+
+	privateKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("service account key %q is not an RSA key", path)
+	}
+
+	return &TokenSource{
+		client:     client,
+		creds:      creds,
+		privateKey: privateKey,
+	}, nil
+}
+
+// Token returns a valid access token, requesting a new one if the cached
+// token is about to expire.
+func (t *TokenSource) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.token) > 0 && time.Now().Add(tokenExpiryLeeway).Before(t.expiry) {
+		return t.token, nil
+	}
+
+	var (
+		req *http.Request
+		err error
+	)
+	switch {
+	case t.privateKey != nil:
+		req, err = t.exchangeRequest()
+	case t.creds != nil:
+		req, err = t.refreshRequest()
+	default:
+		req, err = http.NewRequest(http.MethodGet, metadataTokenURL, nil)
+		if req != nil {
+			req.Header.Set("Metadata-Flavor", "Google")
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := t.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to request access token: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code requesting access token from %q: %d %s",
+			req.URL, resp.StatusCode, body)
+	}
+
+	response := new(tokenResponse)
+	if err := json.Unmarshal(body, response); err != nil || len(response.AccessToken) == 0 {
+		return "", errors.New("unexpected access token response")
+	}
+
+	t.token = response.AccessToken
+	t.expiry = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+
+	return t.token, nil
+}
+
+// exchangeRequest returns a request exchanging a JWT signed by the service
+// account key for an access token.
+func (t *TokenSource) exchangeRequest() (*http.Request, error) {
+	now := time.Now()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return nil, err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   t.creds.ClientEmail,
+		"scope": scope,
+		"aud":   t.creds.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token request: %s", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", jwtBearerGrantType)
+	form.Set("assertion", unsigned+"."+base64.RawURLEncoding.EncodeToString(signature))
+
+	return formRequest(t.creds.TokenURI, form)
+}
+
+// refreshRequest returns a request exchanging the refresh token of
+// authorized user credentials for an access token.
+func (t *TokenSource) refreshRequest() (*http.Request, error) {
+	form := url.Values{}
+	form.Set("grant_type", refreshTokenGrantType)
+	form.Set("client_id", t.creds.ClientID)
+	form.Set("client_secret", t.creds.ClientSecret)
+	form.Set("refresh_token", t.creds.RefreshToken)
+
+	return formRequest(t.creds.TokenURI, form)
+}
+
+func formRequest(tokenURI string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
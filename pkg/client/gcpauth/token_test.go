@@ -0,0 +1,256 @@
+package gcpauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyTokenSource(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+
+		if err := req.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if grant := req.PostForm.Get("grant_type"); grant != jwtBearerGrantType {
+			t.Errorf("unexpected grant type, exp=%s got=%s", jwtBearerGrantType, grant)
+		}
+
+		parts := strings.Split(req.PostForm.Get("assertion"), ".")
+		if len(parts) != 3 {
+			t.Fatalf("unexpected assertion, got=%q", req.PostForm.Get("assertion"))
+		}
+
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, hash[:], signature); err != nil {
+			t.Errorf("failed to verify assertion signature: %s", err)
+		}
+
+		claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		var claims map[string]interface{}
+		if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+			t.Fatal(err)
+		}
+		if claims["iss"] != "checker@project.iam.gserviceaccount.com" || claims["scope"] != scope {
+			t.Errorf("unexpected assertion claims, got=%v", claims)
+		}
+
+		w.Write([]byte(`{"access_token":"exchanged-token","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := json.Marshal(credentialsFile{
+		Type:        "service_account",
+		ClientEmail: "checker@project.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		TokenURI:    server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "version-checker-gcpauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "key.json")
+	if err := ioutil.WriteFile(path, key, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := NewKeyTokenSource(http.DefaultClient, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		token, err := tokens.Token(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "exchanged-token" {
+			t.Errorf("unexpected token, exp=exchanged-token got=%s", token)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected valid token to be cached, exp=1 got=%d", requests)
+	}
+}
+
+func TestMetadataTokenSource(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+
+		if req.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		// Tokens expiring within the leeway should be refreshed.
+		w.Write([]byte(`{"access_token":"metadata-token","expires_in":30}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { metadataTokenURL = url }(metadataTokenURL)
+	metadataTokenURL = server.URL
+
+	tokens := NewMetadataTokenSource(http.DefaultClient)
+
+	for i := 0; i < 2; i++ {
+		token, err := tokens.Token(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "metadata-token" {
+			t.Errorf("unexpected token, exp=metadata-token got=%s", token)
+		}
+	}
+
+	if requests != 2 {
+		t.Errorf("expected token expiring within leeway to be refreshed, exp=2 got=%d", requests)
+	}
+}
+
+func TestNewKeyTokenSourceInvalidKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-gcpauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := map[string]string{
+		"not json":          `not json`,
+		"not a service key": `{"type":"authorized_user"}`,
+		"no private key":    `{"type":"service_account","private_key":"invalid"}`,
+	}
+
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, "key.json")
+			if err := ioutil.WriteFile(path, []byte(key), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := NewKeyTokenSource(http.DefaultClient, path); err == nil {
+				t.Error("expected error for invalid key, got=nil")
+			}
+		})
+	}
+}
+
+func TestDefaultTokenSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/metadata" {
+			w.Write([]byte(`{"access_token":"metadata-token","expires_in":3600}`))
+			return
+		}
+
+		if err := req.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if req.PostForm.Get("grant_type") != refreshTokenGrantType ||
+			req.PostForm.Get("refresh_token") != "refresh-token" ||
+			req.PostForm.Get("client_id") != "client-id" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Write([]byte(`{"access_token":"user-token","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	defer func(url string) { metadataTokenURL = url }(metadataTokenURL)
+	metadataTokenURL = server.URL + "/metadata"
+
+	dir, err := ioutil.TempDir("", "version-checker-gcpauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	userCreds := filepath.Join(dir, "user.json")
+	if err := ioutil.WriteFile(userCreds, []byte(`{"type":"authorized_user",`+
+		`"client_id":"client-id","client_secret":"secret","refresh_token":"refresh-token",`+
+		`"token_uri":"`+server.URL+`/token"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(f func() string) { wellKnownCredentialsFile = f }(wellKnownCredentialsFile)
+
+	tests := map[string]struct {
+		env, wellKnown string
+		expToken       string
+	}{
+		"GOOGLE_APPLICATION_CREDENTIALS should be used first": {
+			env:       userCreds,
+			wellKnown: filepath.Join(dir, "missing.json"),
+			expToken:  "user-token",
+		},
+		"gcloud credentials should be used if no env": {
+			wellKnown: userCreds,
+			expToken:  "user-token",
+		},
+		"metadata server should be used if no credentials file": {
+			wellKnown: filepath.Join(dir, "missing.json"),
+			expToken:  "metadata-token",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer os.Setenv(credentialsEnv, os.Getenv(credentialsEnv))
+			os.Setenv(credentialsEnv, test.env)
+
+			wellKnown := test.wellKnown
+			wellKnownCredentialsFile = func() string { return wellKnown }
+
+			tokens, err := NewDefaultTokenSource(http.DefaultClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := tokens.Token(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != test.expToken {
+				t.Errorf("unexpected token, exp=%s got=%s", test.expToken, token)
+			}
+		})
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/gcpauth"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 const (
@@ -30,11 +32,30 @@ var (
 
 type Options struct {
 	Token string
+
+	// TokenFile is a path to a file containing the token, which is refreshed
+	// externally. Takes precedence over Token.
+	TokenFile string
+
+	// UseApplicationDefaultCredentials will use the application default
+	// credentials, such as GKE Workload Identity, if no token is set. Access
+	// tokens are refreshed before they expire.
+	UseApplicationDefaultCredentials bool
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
 }
 
 type Client struct {
 	*http.Client
 	Options
+
+	tokenFile *util.TokenFile
+
+	// tokens returns access tokens of the application default credentials,
+	// if used.
+	tokens *gcpauth.TokenSource
 }
 
 type Response struct {
@@ -46,13 +67,28 @@ type ManifestItem struct {
 	TimeCreated string   `json:"timeCreatedMs"`
 }
 
-func New(opts Options) *Client {
-	return &Client{
+func New(opts Options) (*Client, error) {
+	client := &Client{
 		Options: opts,
 		Client: &http.Client{
-			Timeout: time.Second * 5,
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
 		},
 	}
+
+	switch {
+	case len(opts.TokenFile) > 0:
+		client.tokenFile = util.NewTokenFile(opts.TokenFile)
+	case len(opts.Token) > 0:
+	case opts.UseApplicationDefaultCredentials:
+		tokens, err := gcpauth.NewDefaultTokenSource(client.Client)
+		if err != nil {
+			return nil, err
+		}
+		client.tokens = tokens
+	}
+
+	return client, nil
 }
 
 func (c *Client) IsClient(imageURL string) bool {
@@ -73,8 +109,17 @@ func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, err
 		return nil, err
 	}
 
-	if len(c.Token) > 0 {
-		req.SetBasicAuth("oauth2accesstoken", c.Token)
+	host := strings.SplitN(imageURL, "/", 2)[0]
+	if cred, ok := util.CredentialsFor(ctx, host); ok {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	} else {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(token) > 0 {
+			req.SetBasicAuth("oauth2accesstoken", token)
+		}
 	}
 
 	req.URL.Scheme = "https"
@@ -90,6 +135,15 @@ func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, err
 		return nil, err
 	}
 
+	if err := util.NotFound(resp, url, body); err != nil {
+		return nil, err
+	}
+
+	if err := util.ErrorEnvelope(body); err != nil {
+		return nil, err
+	}
+	util.CountPage(ctx)
+
 	var response Response
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, err
@@ -117,3 +171,16 @@ func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, err
 
 	return tags, nil
 }
+
+// token returns the token to authenticate with, read from the token file or
+// application default credentials if set.
+func (c *Client) token(ctx context.Context) (string, error) {
+	if c.tokenFile != nil {
+		return c.tokenFile.Token(ctx)
+	}
+	if c.tokens != nil {
+		return c.tokens.Token(ctx)
+	}
+
+	return c.Token, nil
+}
@@ -0,0 +1,69 @@
+package ghcr
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/oci"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// DefaultHost is the host of the GitHub Container Registry.
+	DefaultHost = "ghcr.io"
+
+	// defaultUsername is used with a token if no username is set. GitHub
+	// ignores the username of token authentication.
+	defaultUsername = "version-checker"
+)
+
+type Options struct {
+	// Host is the registry host. Defaults to ghcr.io.
+	Host string
+
+	// Username and Token of a personal access token, or a GITHUB_TOKEN, with
+	// read:packages scope. Public images are pulled anonymously if unset.
+	Username string
+	Token    string
+
+	OCI oci.Options
+}
+
+// Client lists tags of GitHub Container Registry images with the
+// distribution API, exchanging the credentials of the context, or else the
+// configured token, for a registry token.
+type Client struct {
+	Options
+
+	oci *oci.Client
+}
+
+func New(opts Options) *Client {
+	if len(opts.Host) == 0 {
+		opts.Host = DefaultHost
+	}
+	if len(opts.Token) > 0 && len(opts.Username) == 0 {
+		opts.Username = defaultUsername
+	}
+
+	return &Client{
+		Options: opts,
+		oci:     oci.New(opts.OCI),
+	}
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	return strings.HasPrefix(imageURL, c.Host+"/")
+}
+
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	if _, ok := util.CredentialsFor(ctx, c.Host); !ok && len(c.Token) > 0 {
+		ctx = util.WithHostCredentials(ctx, c.Host, util.Credentials{
+			Username: c.Username,
+			Password: c.Token,
+		})
+	}
+
+	return c.oci.ImageTags(ctx, c.Host, strings.TrimPrefix(imageURL, c.Host+"/"))
+}
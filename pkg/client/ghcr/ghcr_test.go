@@ -0,0 +1,181 @@
+package ghcr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/client/oci"
+)
+
+func newTestGHCR(t *testing.T, username, token string) (*httptest.Server, *Client) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		if scope := req.URL.Query().Get("scope"); scope != "repository:jetstack/app:pull" {
+			t.Errorf("unexpected token scope, got=%s", scope)
+		}
+
+		if len(token) == 0 {
+			w.Write([]byte(`{"token":"anonymous"}`))
+			return
+		}
+
+		if user, pass, ok := req.BasicAuth(); !ok || user != username || pass != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`))
+			return
+		}
+		w.Write([]byte(`{"token":"registry-token"}`))
+	})
+
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("WWW-Authenticate",
+			fmt.Sprintf(`Bearer realm="https://%s/token",service="ghcr.io"`, req.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	authed := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			exp := "Bearer anonymous"
+			if len(token) > 0 {
+				exp = "Bearer registry-token"
+			}
+			if req.Header.Get("Authorization") != exp {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`))
+				return
+			}
+			handler(w, req)
+		}
+	}
+
+	mux.HandleFunc("/v2/jetstack/app/tags/list", authed(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/jetstack/app/tags/list?last=v0.1.0&n=1000>; rel="next"`)
+			w.Write([]byte(`{"name":"jetstack/app","tags":["v0.1.0"]}`))
+			return
+		}
+
+		w.Write([]byte(`{"name":"jetstack/app","tags":["v0.2.0"]}`))
+	}))
+
+	mux.HandleFunc("/v2/jetstack/app/manifests/v0.1.0", authed(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:v0.1.0")
+		w.Write([]byte(`{"schemaVersion":2,"config":{"digest":"sha256:config-v0.1.0"}}`))
+	}))
+	mux.HandleFunc("/v2/jetstack/app/manifests/v0.2.0", authed(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:v0.2.0")
+		w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+			{"digest":"sha256:v0.2.0-arm64","platform":{"os":"linux","architecture":"arm64"}},
+			{"digest":"sha256:v0.2.0-amd64","platform":{"os":"linux","architecture":"amd64"}}
+		]}`))
+	}))
+	mux.HandleFunc("/v2/jetstack/app/manifests/sha256:v0.2.0-amd64", authed(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:v0.2.0-amd64")
+		w.Write([]byte(`{"schemaVersion":2,"config":{"digest":"sha256:config-v0.2.0"}}`))
+	}))
+
+	mux.HandleFunc("/v2/jetstack/app/blobs/sha256:config-v0.1.0", authed(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"created":"2020-06-01T10:00:00Z","os":"linux","architecture":"amd64"}`))
+	}))
+	mux.HandleFunc("/v2/jetstack/app/blobs/sha256:config-v0.2.0", authed(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"created":"2020-07-01T10:00:00Z","os":"linux","architecture":"amd64"}`))
+	}))
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	return server, New(Options{
+		Host:     strings.TrimPrefix(server.URL, "https://"),
+		Username: username,
+		Token:    token,
+		OCI:      oci.Options{Transport: server.Client().Transport},
+	})
+}
+
+func TestTags(t *testing.T) {
+	tests := map[string]struct {
+		username, token string
+	}{
+		"anonymous": {},
+		"personal access token": {
+			username: "joshvanl",
+			token:    "ghp_token",
+		},
+		"github token without username": {
+			username: defaultUsername,
+			token:    "ghs_token",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, client := newTestGHCR(t, test.username, test.token)
+			if test.username == defaultUsername {
+				client = New(Options{
+					Host:  client.Host,
+					Token: test.token,
+					OCI:   client.OCI,
+				})
+			}
+
+			imageURL := client.Host + "/jetstack/app"
+			if !client.IsClient(imageURL) {
+				t.Fatalf("expected client to match %q", imageURL)
+			}
+
+			tags, err := client.Tags(context.TODO(), imageURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tags) != 2 {
+				t.Fatalf("unexpected number of tags, exp=2 got=%d: %+v", len(tags), tags)
+			}
+
+			exp := []struct {
+				tag, sha, arch string
+				timestamp      time.Time
+			}{
+				{"v0.1.0", "sha256:v0.1.0", "amd64", time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)},
+				{"v0.2.0", "sha256:v0.2.0", "", time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)},
+			}
+			for i, e := range exp {
+				if tags[i].Tag != e.tag || tags[i].SHA != e.sha ||
+					tags[i].Architecture != e.arch || !tags[i].Timestamp.Equal(e.timestamp) {
+					t.Errorf("unexpected tag, exp=%+v got=%+v", e, tags[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTagsInvalidToken(t *testing.T) {
+	_, client := newTestGHCR(t, "joshvanl", "ghp_token")
+	client.Token = "ghp_wrong"
+
+	if _, err := client.Tags(context.TODO(), client.Host+"/jetstack/app"); err == nil {
+		t.Error("expected error with invalid token, got=nil")
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	client := New(Options{})
+
+	for imageURL, exp := range map[string]bool{
+		"ghcr.io/jetstack/app":      true,
+		"ghcr.io/jetstack/team/app": true,
+		"ghcr.io.corp/jetstack/app": false,
+		"docker.io/jetstack/app":    false,
+		"gcr.io/jetstack/app":       false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+}
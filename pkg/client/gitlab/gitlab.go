@@ -0,0 +1,328 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/oci"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// DefaultHost is the host of the GitLab.com container registry.
+	DefaultHost = "registry.gitlab.com"
+
+	// DefaultAPIURL is the URL of the GitLab instance of DefaultHost.
+	DefaultAPIURL = "https://gitlab.com"
+
+	// jobTokenUsername is the username GitLab expects for CI job tokens. Used
+	// if a token is set without a username.
+	jobTokenUsername = "gitlab-ci-token"
+
+	repositoriesURL = "%s/api/v4/projects/%s/registry/repositories?per_page=100"
+	tagsURL         = "%s/api/v4/projects/%s/registry/repositories/%d/tags?per_page=100"
+	tagURL          = "%s/api/v4/projects/%s/registry/repositories/%d/tags/%s"
+)
+
+var (
+	// errAPIUnavailable is returned when the registry API doesn't serve the
+	// repository to the configured token, such as deploy tokens, which only
+	// authenticate with the registry.
+	errAPIUnavailable = errors.New("registry API unavailable")
+
+	// linkNextRegex matches the URL of the next page of a Link header.
+	linkNextRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+type Options struct {
+	// Hosts are the hostnames of GitLab container registries, including
+	// self-managed instances. Defaults to registry.gitlab.com.
+	Hosts []string
+
+	// APIURLs are the URLs of the GitLab instance of each registry host,
+	// whose registry API lists tags with their creation timestamps. Images of
+	// other hosts, or whose project the registry API doesn't serve, are
+	// listed with the distribution API. registry.gitlab.com defaults to
+	// https://gitlab.com.
+	APIURLs map[string]string
+
+	// Username and Token authenticate with the registry's GitLab instance.
+	// The token may be a deploy token, a personal or project access token,
+	// or a CI job token, in which case the username defaults to
+	// gitlab-ci-token. Public images are pulled anonymously if unset.
+	Username string
+	Token    string
+
+	OCI oci.Options
+}
+
+// Client lists tags of GitLab container registry images with the registry
+// API of the GitLab instance, or else the distribution API.
+type Client struct {
+	*http.Client
+	Options
+
+	oci *oci.Client
+}
+
+// Repository is a container repository of a project.
+type Repository struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"`
+}
+
+// Tag is a tag of a container repository. Only the details of a single tag
+// hold its digest and creation timestamp.
+type Tag struct {
+	Name      string    `json:"name"`
+	Digest    string    `json:"digest"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func New(opts Options) *Client {
+	if len(opts.Hosts) == 0 {
+		opts.Hosts = []string{DefaultHost}
+	}
+	if len(opts.Token) > 0 && len(opts.Username) == 0 {
+		opts.Username = jobTokenUsername
+	}
+
+	apiURLs := map[string]string{DefaultHost: DefaultAPIURL}
+	for host, apiURL := range opts.APIURLs {
+		apiURLs[host] = strings.TrimSuffix(apiURL, "/")
+	}
+	opts.APIURLs = apiURLs
+
+	return &Client{
+		Options: opts,
+		Client: &http.Client{
+			Timeout:   time.Second * 5,
+			Transport: opts.OCI.Transport,
+		},
+		oci: oci.New(opts.OCI),
+	}
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	return len(c.host(imageURL)) > 0
+}
+
+// Tags will list the tags of the image with the registry API if its GitLab
+// instance is known, falling back to the distribution API if the registry API
+// doesn't serve the repository. Credentials of the context, such as from pull
+// secrets, are registry credentials, so are only used with the distribution
+// API.
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	host := c.host(imageURL)
+	repo := strings.TrimPrefix(imageURL, host+"/")
+
+	if _, ok := util.CredentialsFor(ctx, host); !ok {
+		if apiURL, ok := c.APIURLs[host]; ok {
+			tags, err := c.apiTags(ctx, apiURL, repo)
+			if !errors.Is(err, errAPIUnavailable) {
+				return tags, err
+			}
+		}
+
+		if len(c.Token) > 0 {
+			ctx = util.WithHostCredentials(ctx, host, util.Credentials{
+				Username: c.Username,
+				Password: c.Token,
+			})
+		}
+	}
+
+	return c.oci.ImageTags(ctx, host, repo)
+}
+
+// host returns the configured registry host of the image URL, or an empty
+// string if it is not hosted on a GitLab registry.
+func (c *Client) host(imageURL string) string {
+	for _, host := range c.Hosts {
+		if strings.HasPrefix(imageURL, host+"/") {
+			return host
+		}
+	}
+
+	return ""
+}
+
+// apiTags will list the tags of the repository with the registry API,
+// requesting the details of each tag for its digest and creation timestamp.
+// Tags deleted since being listed are skipped.
+func (c *Client) apiTags(ctx context.Context, apiURL, repo string) ([]api.ImageTag, error) {
+	project, repository, err := c.repository(ctx, apiURL, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	err = c.list(ctx, fmt.Sprintf(tagsURL, apiURL, url.PathEscape(project), repository.ID), func(body []byte) error {
+		var page []Tag
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("unexpected tags response: %s", body)
+		}
+		for _, tag := range page {
+			names = append(names, tag.Name)
+		}
+		util.CountPage(ctx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []api.ImageTag
+	for _, name := range names {
+		tag, err := c.apiTag(ctx, fmt.Sprintf(tagURL, apiURL, url.PathEscape(project), repository.ID,
+			url.PathEscape(name)))
+		if errors.Is(err, util.ErrRepositoryNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image tag %q: %s", name, err)
+		}
+
+		tags = append(tags, api.ImageTag{
+			Tag:       tag.Name,
+			SHA:       tag.Digest,
+			Timestamp: tag.CreatedAt,
+		})
+	}
+
+	return tags, nil
+}
+
+func (c *Client) apiTag(ctx context.Context, url string) (*Tag, error) {
+	release, err := util.AcquireEnrichment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	body, _, err := c.doRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := new(Tag)
+	if err := json.Unmarshal(body, tag); err != nil {
+		return nil, fmt.Errorf("unexpected tag response: %s", body)
+	}
+
+	return tag, nil
+}
+
+// repository will find the project and container repository of the
+// repository path. Images may be at the root of their project's registry, or
+// nested below it, so the project is searched for from the longest path
+// down. Returns errAPIUnavailable if no project holds the repository.
+func (c *Client) repository(ctx context.Context, apiURL, repo string) (string, *Repository, error) {
+	parts := strings.Split(repo, "/")
+
+	// Projects are always within a group or user namespace.
+	for i := len(parts); i >= 2; i-- {
+		project := strings.Join(parts[:i], "/")
+
+		var repository *Repository
+		err := c.list(ctx, fmt.Sprintf(repositoriesURL, apiURL, url.PathEscape(project)), func(body []byte) error {
+			var page []Repository
+			if err := json.Unmarshal(body, &page); err != nil {
+				return fmt.Errorf("unexpected repositories response: %s", body)
+			}
+			for j := range page {
+				if page[j].Path == repo {
+					repository = &page[j]
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, util.ErrRepositoryNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		if repository != nil {
+			return project, repository, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("%w: no project found of %q", errAPIUnavailable, repo)
+}
+
+// list will request each page of a paginated list, following the Link header
+// of each page.
+func (c *Client) list(ctx context.Context, url string, page func([]byte) error) error {
+	for len(url) > 0 {
+		body, header, err := c.doRequest(ctx, url)
+		if err != nil {
+			return err
+		}
+
+		if err := page(body); err != nil {
+			return err
+		}
+
+		url = ""
+		if match := linkNextRegex.FindStringSubmatch(header.Get("Link")); len(match) == 2 {
+			url = match[1]
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) doRequest(ctx context.Context, url string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if len(c.Token) > 0 {
+		if c.Username == jobTokenUsername {
+			req.Header.Set("JOB-TOKEN", c.Token)
+		} else {
+			req.Header.Set("PRIVATE-TOKEN", c.Token)
+		}
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get gitlab image: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, resp.Header, nil
+
+	case http.StatusNotFound:
+		return nil, nil, fmt.Errorf("%w: %q", util.ErrRepositoryNotFound, url)
+
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, nil, fmt.Errorf("%w: %q: %d %s", errAPIUnavailable, url, resp.StatusCode, body)
+
+	default:
+		return nil, nil, fmt.Errorf("unexpected status code from %q: %d %s",
+			url, resp.StatusCode, body)
+	}
+}
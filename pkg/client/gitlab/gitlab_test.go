@@ -0,0 +1,226 @@
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/client/oci"
+)
+
+func newTestGitLab(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/jwt/auth", func(w http.ResponseWriter, req *http.Request) {
+		if scope := req.URL.Query().Get("scope"); scope != "repository:group/project/app:pull" {
+			t.Errorf("unexpected token scope, got=%s", scope)
+		}
+
+		if user, pass, ok := req.BasicAuth(); !ok || user != "gitlab+deploy-token-1" || pass != "deploy-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"HTTP Basic: Access denied"}]}`))
+			return
+		}
+		w.Write([]byte(`{"token":"registry-token"}`))
+	})
+
+	authed := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "Bearer registry-token" {
+				w.Header().Set("WWW-Authenticate",
+					`Bearer realm="`+server.URL+`/jwt/auth",service="container_registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`))
+				return
+			}
+			handler(w, req)
+		}
+	}
+
+	mux.HandleFunc("/v2/", authed(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+
+	mux.HandleFunc("/v2/group/project/app/tags/list", authed(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/group/project/app/tags/list?last=v0.1.0&n=1000>; rel="next"`)
+			w.Write([]byte(`{"name":"group/project/app","tags":["v0.1.0"]}`))
+			return
+		}
+
+		w.Write([]byte(`{"name":"group/project/app","tags":["v0.2.0"]}`))
+	}))
+
+	for _, tag := range []string{"v0.1.0", "v0.2.0"} {
+		tag := tag
+		mux.HandleFunc("/v2/group/project/app/manifests/"+tag, authed(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Docker-Content-Digest", "sha256:"+tag)
+			w.Write([]byte(`{"schemaVersion":2,"config":{"digest":"sha256:config-` + tag + `"}}`))
+		}))
+	}
+
+	mux.HandleFunc("/v2/group/project/app/blobs/sha256:config-v0.1.0", authed(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"created":"2020-06-01T10:00:00Z","os":"linux","architecture":"amd64"}`))
+	}))
+	mux.HandleFunc("/v2/group/project/app/blobs/sha256:config-v0.2.0", authed(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"created":"2020-07-01T10:00:00Z","os":"linux","architecture":"arm64"}`))
+	}))
+
+	apiAuthed := func(handler http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("PRIVATE-TOKEN") != "personal-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"message":"401 Unauthorized"}`))
+				return
+			}
+			handler(w, req)
+		}
+	}
+
+	mux.HandleFunc("/api/v4/projects/group/project/registry/repositories", apiAuthed(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `<`+server.URL+`/api/v4/projects/group%2Fproject/registry/repositories?page=2&per_page=100>; rel="next"`)
+			w.Write([]byte(`[{"id":6,"path":"group/project/other"}]`))
+			return
+		}
+
+		w.Write([]byte(`[{"id":7,"path":"group/project/app"}]`))
+	}))
+	mux.HandleFunc("/api/v4/projects/group/project/registry/repositories/7/tags", apiAuthed(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[{"name":"v0.1.0"},{"name":"v0.2.0"},{"name":"deleted"}]`))
+	}))
+	for _, tag := range []string{"v0.1.0", "v0.2.0"} {
+		tag := tag
+		mux.HandleFunc("/api/v4/projects/group/project/registry/repositories/7/tags/"+tag, apiAuthed(func(w http.ResponseWriter, req *http.Request) {
+			month := map[string]string{"v0.1.0": "08", "v0.2.0": "09"}[tag]
+			w.Write([]byte(`{"name":"` + tag + `","digest":"sha256:` + tag + `","created_at":"2021-` + month + `-01T10:00:00.000+00:00"}`))
+		}))
+	}
+
+	server = httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTags(t *testing.T) {
+	server := newTestGitLab(t)
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	tests := map[string]struct {
+		username, token string
+		expArchs        []string
+		expTimestamps   []time.Time
+	}{
+		"access token should list tags with the registry API": {
+			username: "project_bot",
+			token:    "personal-token",
+			expArchs: []string{"", ""},
+			expTimestamps: []time.Time{
+				time.Date(2021, 8, 1, 10, 0, 0, 0, time.UTC),
+				time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		"deploy token should list tags with the distribution API": {
+			username: "gitlab+deploy-token-1",
+			token:    "deploy-token",
+			expArchs: []string{"amd64", "arm64"},
+			expTimestamps: []time.Time{
+				time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC),
+				time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := New(Options{
+				Hosts:    []string{"gitlab.corp", host},
+				APIURLs:  map[string]string{host: server.URL + "/"},
+				Username: test.username,
+				Token:    test.token,
+				OCI:      oci.Options{Transport: server.Client().Transport},
+			})
+
+			imageURL := host + "/group/project/app"
+			if !client.IsClient(imageURL) {
+				t.Fatalf("expected client to match %q", imageURL)
+			}
+
+			tags, err := client.Tags(context.TODO(), imageURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tags) != 2 {
+				t.Fatalf("unexpected number of tags, exp=2 got=%d: %+v", len(tags), tags)
+			}
+
+			for i, expTag := range []string{"v0.1.0", "v0.2.0"} {
+				if tags[i].Tag != expTag || tags[i].SHA != "sha256:"+expTag ||
+					tags[i].Architecture != test.expArchs[i] || !tags[i].Timestamp.Equal(test.expTimestamps[i]) {
+					t.Errorf("unexpected tag %q, exp=%s,%s got=%+v", expTag, test.expArchs[i], test.expTimestamps[i], tags[i])
+				}
+			}
+		})
+	}
+
+	client := New(Options{
+		Hosts:    []string{host},
+		Username: "gitlab+deploy-token-1",
+		Token:    "wrong-token",
+		OCI:      oci.Options{Transport: server.Client().Transport},
+	})
+	if _, err := client.Tags(context.TODO(), host+"/group/project/app"); err == nil {
+		t.Error("expected error with invalid token, got=nil")
+	}
+}
+
+func TestNew(t *testing.T) {
+	client := New(Options{Token: "job-token"})
+	if client.Username != jobTokenUsername {
+		t.Errorf("expected username to default for job tokens, exp=%s got=%s",
+			jobTokenUsername, client.Username)
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	tests := map[string]struct {
+		hosts []string
+		exp   map[string]bool
+	}{
+		"default host": {
+			hosts: nil,
+			exp: map[string]bool{
+				"registry.gitlab.com/group/project":     true,
+				"registry.gitlab.com/group/project/app": true,
+				"registry.gitlab.com.corp/group/app":    false,
+				"gitlab.corp/group/project":             false,
+			},
+		},
+		"self-managed hosts": {
+			hosts: []string{"gitlab.corp:5050", "registry.gitlab.corp"},
+			exp: map[string]bool{
+				"gitlab.corp:5050/group/project":     true,
+				"registry.gitlab.corp/group/project": true,
+				"gitlab.corp/group/project":          false,
+				"registry.gitlab.com/group/project":  false,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := New(Options{Hosts: test.hosts})
+			for imageURL, exp := range test.exp {
+				if got := client.IsClient(imageURL); got != exp {
+					t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package harbor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	artifactsURL = "https://%s/api/v2.0/projects/%s/repositories/%s/artifacts?page_size=100&with_tag=true&with_label=true"
+)
+
+var (
+	// linkNextRegex matches the URL of the next page of a Link header.
+	linkNextRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+type Options struct {
+	// Host is the host of the Harbor instance. e.g. harbor.corp
+	Host string
+
+	// Username and Password of a user or robot account with pull access.
+	Username string
+	Password string
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+}
+
+type Client struct {
+	*http.Client
+	Options
+}
+
+type Artifact struct {
+	Digest     string     `json:"digest"`
+	PushTime   time.Time  `json:"push_time"`
+	Tags       []Tag      `json:"tags"`
+	Labels     []Label    `json:"labels"`
+	ExtraAttrs ExtraAttrs `json:"extra_attrs"`
+}
+
+type Tag struct {
+	Name     string    `json:"name"`
+	PushTime time.Time `json:"push_time"`
+}
+
+type Label struct {
+	Name string `json:"name"`
+}
+
+type ExtraAttrs struct {
+	Created      time.Time `json:"created"`
+	OS           string    `json:"os"`
+	Architecture string    `json:"architecture"`
+	Variant      string    `json:"variant"`
+}
+
+func New(opts Options) *Client {
+	return &Client{
+		Options: opts,
+		Client: &http.Client{
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
+		},
+	}
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	return len(c.Host) > 0 && strings.HasPrefix(imageURL, c.Host+"/")
+}
+
+// Tags will list the artifacts of the image's repository, returning a tag for
+// each of their tags. Tags are timestamped by the artifact's creation time,
+// falling back to when the tag was pushed.
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	project, repo, err := splitImage(strings.TrimPrefix(imageURL, c.Host+"/"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Repository names containing slashes must be encoded twice.
+	url := fmt.Sprintf(artifactsURL, c.Host, url.PathEscape(project),
+		url.PathEscape(url.PathEscape(repo)))
+
+	var tags []api.ImageTag
+	for len(url) > 0 {
+		var artifacts []Artifact
+		header, err := c.doRequest(ctx, url, &artifacts)
+		if err != nil {
+			return nil, err
+		}
+		util.CountPage(ctx)
+
+		for _, artifact := range artifacts {
+			var labels []string
+			for _, label := range artifact.Labels {
+				labels = append(labels, label.Name)
+			}
+
+			for _, tag := range artifact.Tags {
+				timestamp := artifact.ExtraAttrs.Created
+				if timestamp.IsZero() {
+					timestamp = tag.PushTime
+				}
+
+				tags = append(tags, api.ImageTag{
+					Tag:          tag.Name,
+					SHA:          artifact.Digest,
+					Timestamp:    timestamp,
+					OS:           artifact.ExtraAttrs.OS,
+					Architecture: artifact.ExtraAttrs.Architecture,
+					Variant:      artifact.ExtraAttrs.Variant,
+					Labels:       labels,
+				})
+			}
+		}
+
+		url = ""
+		if match := linkNextRegex.FindStringSubmatch(header.Get("Link")); len(match) == 2 {
+			url = match[1]
+			// Link is relative to the Harbor host.
+			if strings.HasPrefix(url, "/") {
+				url = "https://" + c.Host + url
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+// splitImage will split the image path into its Harbor project and
+// repository.
+func splitImage(image string) (string, string, error) {
+	split := strings.SplitN(image, "/", 2)
+	if len(split) != 2 || len(split[0]) == 0 || len(split[1]) == 0 {
+		return "", "", fmt.Errorf("image %q is not of the form <project>/<repository>", image)
+	}
+
+	return split[0], split[1], nil
+}
+
+func (c *Client) doRequest(ctx context.Context, url string, obj interface{}) (http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if cred, ok := util.CredentialsFor(ctx, c.Host); ok {
+		req.SetBasicAuth(cred.Username, cred.Password)
+	} else if len(c.Username) > 0 || len(c.Password) > 0 {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get harbor image: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := util.NotFound(resp, url, body); err != nil {
+		return nil, err
+	}
+
+	if err := util.ErrorEnvelope(body); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from %q: %d %s",
+			url, resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, obj); err != nil {
+		return nil, fmt.Errorf("unexpected response from %q: %s", url, body)
+	}
+
+	return resp.Header, nil
+}
@@ -0,0 +1,106 @@
+package harbor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTags(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, pass, ok := req.BasicAuth(); !ok || user != "robot$ci" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"unauthorized"}]}`))
+			return
+		}
+
+		if req.URL.EscapedPath() != "/api/v2.0/projects/library/repositories/team%252Fapp/artifacts" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"code":"NOT_FOUND","message":"repository not found"}]}`))
+			return
+		}
+
+		if req.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `</api/v2.0/projects/library/repositories/team%252Fapp/artifacts?page=2&page_size=100>; rel="next"`)
+			w.Write([]byte(`[{
+				"digest": "sha256:a",
+				"push_time": "2020-06-02T10:00:00Z",
+				"tags": [{"name": "v0.1.0", "push_time": "2020-06-02T10:00:00Z"}, {"name": "v0.1", "push_time": "2020-06-03T10:00:00Z"}],
+				"labels": [{"name": "approved"}],
+				"extra_attrs": {"created": "2020-06-01T10:00:00Z", "os": "linux", "architecture": "amd64"}
+			}]`))
+			return
+		}
+
+		w.Write([]byte(`[{
+			"digest": "sha256:b",
+			"push_time": "2020-07-02T10:00:00Z",
+			"tags": [{"name": "v0.2.0", "push_time": "2020-07-02T10:00:00Z"}]
+		}]`))
+	}))
+	defer server.Close()
+
+	client := New(Options{
+		Host:      strings.TrimPrefix(server.URL, "https://"),
+		Username:  "robot$ci",
+		Password:  "pass",
+		Transport: server.Client().Transport,
+	})
+
+	imageURL := client.Host + "/library/team/app"
+	if !client.IsClient(imageURL) {
+		t.Fatalf("expected client to match %q", imageURL)
+	}
+
+	tags, err := client.Tags(context.TODO(), imageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 3 {
+		t.Fatalf("unexpected number of tags, exp=3 got=%d: %+v", len(tags), tags)
+	}
+
+	exp := []struct {
+		tag, sha, arch string
+		timestamp      time.Time
+		labels         []string
+	}{
+		{"v0.1.0", "sha256:a", "amd64", time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC), []string{"approved"}},
+		{"v0.1", "sha256:a", "amd64", time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC), []string{"approved"}},
+		{"v0.2.0", "sha256:b", "", time.Date(2020, 7, 2, 10, 0, 0, 0, time.UTC), nil},
+	}
+	for i, e := range exp {
+		if tags[i].Tag != e.tag || tags[i].SHA != e.sha || tags[i].Architecture != e.arch ||
+			!tags[i].Timestamp.Equal(e.timestamp) || !reflect.DeepEqual(tags[i].Labels, e.labels) {
+			t.Errorf("unexpected tag, exp=%+v got=%+v", e, tags[i])
+		}
+	}
+
+	if _, err := client.Tags(context.TODO(), client.Host+"/app"); err == nil {
+		t.Error("expected error for image without project, got=nil")
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	client := New(Options{Host: "harbor.corp"})
+
+	for imageURL, exp := range map[string]bool{
+		"harbor.corp/library/app":  true,
+		"harbor.corp/library/a/b":  true,
+		"harbor.corp.io/library/a": false,
+		"quay.io/jetstack/app":     false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+
+	if New(Options{}).IsClient("harbor.corp/library/app") {
+		t.Error("expected client without host to not match")
+	}
+}
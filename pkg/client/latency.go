@@ -0,0 +1,110 @@
+package client
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyWindowSize is the number of most recent request durations kept
+	// per host to calculate latency percentiles.
+	latencyWindowSize = 1000
+)
+
+// latencyTracker is a http.RoundTripper which records a rolling window of
+// request durations per registry host.
+type latencyTracker struct {
+	next http.RoundTripper
+
+	// observe is called with every request duration, if set.
+	observe func(host string, duration time.Duration)
+
+	mu sync.Mutex
+	// windows holds a ring buffer of durations per host.
+	windows map[string]*latencyWindow
+}
+
+type latencyWindow struct {
+	durations []time.Duration
+	next      int
+}
+
+func newLatencyTracker(next http.RoundTripper, observe func(string, time.Duration)) *latencyTracker {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &latencyTracker{
+		next:    next,
+		observe: observe,
+		windows: make(map[string]*latencyWindow),
+	}
+}
+
+func (l *latencyTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := l.next.RoundTrip(req)
+	l.record(req.URL.Host, time.Since(start))
+	return resp, err
+}
+
+// record will add the duration to the window of the host, evicting the
+// oldest duration if the window is full.
+func (l *latencyTracker) record(host string, duration time.Duration) {
+	l.mu.Lock()
+	window, ok := l.windows[host]
+	if !ok {
+		window = new(latencyWindow)
+		l.windows[host] = window
+	}
+
+	if len(window.durations) < latencyWindowSize {
+		window.durations = append(window.durations, duration)
+	} else {
+		window.durations[window.next] = duration
+	}
+	window.next = (window.next + 1) % latencyWindowSize
+	l.mu.Unlock()
+
+	if l.observe != nil {
+		l.observe(host, duration)
+	}
+}
+
+// percentiles returns the p50, p95 and p99 of the recorded durations for the
+// host. Returns zero if no requests have been made to the host.
+func (l *latencyTracker) percentiles(host string) (p50, p95, p99 time.Duration) {
+	l.mu.Lock()
+	window, ok := l.windows[host]
+	if !ok {
+		l.mu.Unlock()
+		return 0, 0, 0
+	}
+	durations := make([]time.Duration, len(window.durations))
+	copy(durations, window.durations)
+	l.mu.Unlock()
+
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+
+	return percentile(durations, 50), percentile(durations, 95), percentile(durations, 99)
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// Latencies returns the rolling p50, p95 and p99 durations of requests made to
+// the given registry host.
+func (c *Client) Latencies(host string) (p50, p95, p99 time.Duration) {
+	return c.latencies.percentiles(host)
+}
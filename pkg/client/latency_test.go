@@ -0,0 +1,61 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyPercentiles(t *testing.T) {
+	tests := map[string]struct {
+		durations              []time.Duration
+		expP50, expP95, expP99 time.Duration
+	}{
+		"no requests should return zero": {},
+		"single request should be every percentile": {
+			durations: []time.Duration{time.Second},
+			expP50:    time.Second,
+			expP95:    time.Second,
+			expP99:    time.Second,
+		},
+		"1 to 100ms should return percentile ms": {
+			durations: millisRange(1, 100),
+			expP50:    50 * time.Millisecond,
+			expP95:    95 * time.Millisecond,
+			expP99:    99 * time.Millisecond,
+		},
+		"window should only hold most recent durations": {
+			durations: append(
+				millisRange(10000, 10000+latencyWindowSize),
+				millisRange(1, 100)...,
+			),
+			expP50: 10500 * time.Millisecond,
+			expP95: 10950 * time.Millisecond,
+			expP99: 10990 * time.Millisecond,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := newLatencyTracker(nil, nil)
+			for _, d := range test.durations {
+				tracker.record("quay.io", d)
+			}
+			// Other hosts should not affect the result
+			tracker.record("gcr.io", time.Hour)
+
+			p50, p95, p99 := tracker.percentiles("quay.io")
+			if p50 != test.expP50 || p95 != test.expP95 || p99 != test.expP99 {
+				t.Errorf("unexpected percentiles, exp=%s/%s/%s got=%s/%s/%s",
+					test.expP50, test.expP95, test.expP99, p50, p95, p99)
+			}
+		})
+	}
+}
+
+func millisRange(from, to int) []time.Duration {
+	var durations []time.Duration
+	for i := from; i <= to; i++ {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	return durations
+}
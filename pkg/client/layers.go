@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// FetchLayers will return the layer digests of the given image URL and tag,
+// in order.
+func (c *Client) FetchLayers(ctx context.Context, imageURL, tag string) ([]string, error) {
+	ref := api.ParseImageRef(c.mirrors.rewrite(imageURL))
+
+	layers, err := c.oci.Layers(ctx, ref.Registry, ref.Repository, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layers for %q: %s", imageURL+":"+tag, err)
+	}
+
+	return layers, nil
+}
+
+// LayerDelta will return the layer digests which are present in the latest tag
+// but not the current (added), and those present in the current tag but not
+// the latest (removed). Layers shared by both tags, such as a common base
+// image, are omitted.
+func (c *Client) LayerDelta(ctx context.Context, imageURL, currentTag, latestTag string) (added, removed []string, err error) {
+	currentLayers, err := c.FetchLayers(ctx, imageURL, currentTag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	latestLayers, err := c.FetchLayers(ctx, imageURL, latestTag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	added = layersDifference(latestLayers, currentLayers)
+	removed = layersDifference(currentLayers, latestLayers)
+
+	return added, removed, nil
+}
+
+// layersDifference returns the unique layers in a which are not present in b,
+// in the order they appear in a.
+func layersDifference(a, b []string) []string {
+	seen := make(map[string]bool)
+	for _, layer := range b {
+		seen[layer] = true
+	}
+
+	var diff []string
+	for _, layer := range a {
+		if seen[layer] {
+			continue
+		}
+
+		seen[layer] = true
+		diff = append(diff, layer)
+	}
+
+	return diff
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestLayerDelta(t *testing.T) {
+	tests := map[string]struct {
+		currentLayers, latestLayers []string
+		expAdded, expRemoved        []string
+	}{
+		"identical layers should have no delta": {
+			currentLayers: []string{"sha256:base", "sha256:app"},
+			latestLayers:  []string{"sha256:base", "sha256:app"},
+			expAdded:      nil,
+			expRemoved:    nil,
+		},
+		"shared base should only return differing layers": {
+			currentLayers: []string{"sha256:base", "sha256:deps", "sha256:app-1"},
+			latestLayers:  []string{"sha256:base", "sha256:deps", "sha256:app-2"},
+			expAdded:      []string{"sha256:app-2"},
+			expRemoved:    []string{"sha256:app-1"},
+		},
+		"disjoint layers should return all layers": {
+			currentLayers: []string{"sha256:a", "sha256:b"},
+			latestLayers:  []string{"sha256:c", "sha256:d"},
+			expAdded:      []string{"sha256:c", "sha256:d"},
+			expRemoved:    []string{"sha256:a", "sha256:b"},
+		},
+		"duplicate layers should be returned once": {
+			currentLayers: []string{"sha256:base"},
+			latestLayers:  []string{"sha256:base", "sha256:empty", "sha256:empty"},
+			expAdded:      []string{"sha256:empty"},
+			expRemoved:    nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := newTestRegistry(t)
+			registry.addImage("jetstack/app", "v0.1.0", test.currentLayers...)
+			registry.addImage("jetstack/app", "v0.2.0", test.latestLayers...)
+
+			added, removed, err := registry.client().LayerDelta(context.TODO(),
+				registry.host()+"/jetstack/app", "v0.1.0", "v0.2.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(added, test.expAdded) {
+				t.Errorf("unexpected added layers, exp=%v got=%v", test.expAdded, added)
+			}
+			if !reflect.DeepEqual(removed, test.expRemoved) {
+				t.Errorf("unexpected removed layers, exp=%v got=%v", test.expRemoved, removed)
+			}
+		})
+	}
+}
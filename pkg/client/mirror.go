@@ -0,0 +1,87 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// mirrors rewrites image URLs to the registry mirror serving them, so that
+// lookups are made against the mirror.
+type mirrors struct {
+	// rules are ordered longest prefix first, so the most specific rule of an
+	// image wins.
+	rules []mirrorRule
+}
+
+type mirrorRule struct {
+	prefix, replacement string
+}
+
+// newMirrors returns the mirrors of the map of image prefix to replacement,
+// e.g. docker.io=mirror.corp/docker. Prefixes are a registry host, optionally
+// followed by a repository path. Returns nil if the map is empty.
+func newMirrors(rules map[string]string) (*mirrors, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	m := new(mirrors)
+	for prefix, replacement := range rules {
+		prefix = strings.Trim(prefix, "/")
+		replacement = strings.Trim(replacement, "/")
+		if len(prefix) == 0 || len(replacement) == 0 {
+			return nil, fmt.Errorf("invalid registry mirror %q=%q: prefix and replacement must not be empty",
+				prefix, replacement)
+		}
+
+		// Normalise the prefix the same way as image URLs, so that Docker Hub
+		// aliases and prefixes without a registry host match. Two path
+		// components are appended so that no official image prefix is added.
+		ref := api.ParseImageRef(prefix + "/_/_")
+		prefix = strings.TrimSuffix(ref.Registry+"/"+strings.TrimSuffix(ref.Repository, "_/_"), "/")
+
+		m.rules = append(m.rules, mirrorRule{prefix: prefix, replacement: replacement})
+	}
+
+	sort.Slice(m.rules, func(i, j int) bool {
+		if len(m.rules[i].prefix) != len(m.rules[j].prefix) {
+			return len(m.rules[i].prefix) > len(m.rules[j].prefix)
+		}
+		return m.rules[i].prefix < m.rules[j].prefix
+	})
+
+	return m, nil
+}
+
+// rewrite returns the image URL on the mirror of the longest matching prefix,
+// or the image URL unchanged if no prefix matches. Tags and digests of the
+// image URL are kept.
+func (m *mirrors) rewrite(imageURL string) string {
+	if m == nil {
+		return imageURL
+	}
+
+	ref := api.ParseImageRef(imageURL)
+	name := ref.Registry + "/" + ref.Repository
+
+	for _, rule := range m.rules {
+		if name != rule.prefix && !strings.HasPrefix(name, rule.prefix+"/") {
+			continue
+		}
+
+		rewritten := rule.replacement + strings.TrimPrefix(name, rule.prefix)
+		if len(ref.Tag) > 0 {
+			rewritten += ":" + ref.Tag
+		}
+		if len(ref.Digest) > 0 {
+			rewritten += "@" + ref.Digest
+		}
+
+		return rewritten
+	}
+
+	return imageURL
+}
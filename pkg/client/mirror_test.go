@@ -0,0 +1,64 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestMirrorsRewrite(t *testing.T) {
+	m, err := newMirrors(map[string]string{
+		"docker.io":             "mirror.corp/docker",
+		"docker.io/library":     "mirror.corp/official/",
+		"quay.io/jetstack":      "mirror.corp:5000/jetstack",
+		"registry.corp/team/ap": "mirror.corp/never",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		imageURL string
+		exp      string
+	}{
+		"docker hub image should use the docker hub mirror": {
+			imageURL: "jetstack/version-checker",
+			exp:      "mirror.corp/docker/jetstack/version-checker",
+		},
+		"official image should use the more specific mirror": {
+			imageURL: "nginx",
+			exp:      "mirror.corp/official/nginx",
+		},
+		"docker hub alias should match": {
+			imageURL: "index.docker.io/library/nginx",
+			exp:      "mirror.corp/official/nginx",
+		},
+		"tag and digest should be kept": {
+			imageURL: "quay.io/jetstack/cert-manager-controller:v1.0.0@sha256:abc",
+			exp:      "mirror.corp:5000/jetstack/cert-manager-controller:v1.0.0@sha256:abc",
+		},
+		"prefix should only match whole path components": {
+			imageURL: "registry.corp/team/app",
+			exp:      "registry.corp/team/app",
+		},
+		"unmatched image should be unchanged": {
+			imageURL: "gcr.io/project/app",
+			exp:      "gcr.io/project/app",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := m.rewrite(test.imageURL); got != test.exp {
+				t.Errorf("unexpected rewrite, exp=%q got=%q", test.exp, got)
+			}
+		})
+	}
+
+	if _, err := newMirrors(map[string]string{"docker.io": ""}); err == nil {
+		t.Error("expected error for empty replacement")
+	}
+
+	var nilMirrors *mirrors
+	if got := nilMirrors.rewrite("nginx"); got != "nginx" {
+		t.Errorf("unexpected rewrite of nil mirrors, exp=%q got=%q", "nginx", got)
+	}
+}
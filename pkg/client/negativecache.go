@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// negativeCache remembers image URLs whose repository was not found, so that
+// subsequent lookups fail fast without a request to the registry. Results are
+// keyed by the image URL and the credentials of the lookup, so that a lookup
+// without access to a private repository doesn't fail lookups with access.
+type negativeCache struct {
+	timeout time.Duration
+
+	// observe is called with every image URL added to, or removed from, the
+	// cache, if set.
+	observe func(imageURL string, cached bool)
+
+	mu sync.Mutex
+	// items holds the not found error, and when it was observed, per image URL
+	// and credentials key.
+	items map[string]negativeCacheItem
+}
+
+type negativeCacheItem struct {
+	imageURL  string
+	timestamp time.Time
+	err       error
+}
+
+func newNegativeCache(timeout time.Duration, observe func(string, bool)) *negativeCache {
+	if observe == nil {
+		observe = func(string, bool) {}
+	}
+
+	return &negativeCache{
+		timeout: timeout,
+		observe: observe,
+		items:   make(map[string]negativeCacheItem),
+	}
+}
+
+// get will return the cached not found error of the image URL looked up with
+// the credentials key, if it is still fresh. Returns nil otherwise.
+func (n *negativeCache) get(imageURL, credsKey string) error {
+	if n == nil {
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := imageURL + credsKey
+	item, ok := n.items[key]
+	if !ok {
+		return nil
+	}
+
+	if item.timestamp.Add(n.timeout).Before(time.Now()) {
+		n.delete(key)
+		return nil
+	}
+
+	return item.err
+}
+
+func (n *negativeCache) add(imageURL, credsKey string, err error) {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.items[imageURL+credsKey] = negativeCacheItem{imageURL, time.Now(), err}
+	n.observe(imageURL, true)
+}
+
+// garbageCollect will remove expired items every timeout until the context is
+// done, so that images no longer looked up, such as of deleted pods, are
+// observed as removed.
+func (n *negativeCache) garbageCollect(ctx context.Context) {
+	ticker := time.NewTicker(n.timeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.sweep()
+		}
+	}
+}
+
+// sweep will remove every expired item.
+func (n *negativeCache) sweep() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	for key, item := range n.items {
+		if item.timestamp.Add(n.timeout).Before(now) {
+			n.delete(key)
+		}
+	}
+}
+
+// purge will remove the not found results of the image URL of every
+// credentials key.
+func (n *negativeCache) purge(imageURL string) {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for key, item := range n.items {
+		if item.imageURL == imageURL {
+			n.delete(key)
+		}
+	}
+}
+
+// delete will remove the item of the key, observing its image URL as removed
+// once no other item of the image URL remains. Must be called with mu held.
+func (n *negativeCache) delete(key string) {
+	imageURL := n.items[key].imageURL
+	delete(n.items, key)
+
+	for _, item := range n.items {
+		if item.imageURL == imageURL {
+			return
+		}
+	}
+	n.observe(imageURL, false)
+}
+
+// Purge will remove any cached not found result of the image URL, so that the
+// next lookup is made against the registry.
+func (c *Client) Purge(imageURL string) {
+	c.notFound.purge(imageURL)
+}
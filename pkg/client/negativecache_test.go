@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/client/nexus"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+func TestTagsNegativeCache(t *testing.T) {
+	var requests int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"code":"NAME_UNKNOWN","message":"repository name not known to registry"}]}`))
+	}))
+	defer server.Close()
+
+	nexusClient, err := nexus.New(nexus.Options{
+		Host:      strings.TrimPrefix(server.URL, "https://"),
+		Transport: server.Client().Transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{
+		fallback: nexusClient,
+		notFound: newNegativeCache(time.Minute, nil),
+	}
+
+	imageURL := nexusClient.Host + "/jetstack/mistyped"
+	for i := 0; i < 3; i++ {
+		if _, err := client.Tags(context.TODO(), imageURL); !errors.Is(err, util.ErrRepositoryNotFound) {
+			t.Fatalf("expected repository not found error, got=%v", err)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected scans within the negative cache timeout to not hit the registry, exp=1 got=%d", requests)
+	}
+
+	client.Purge(imageURL)
+	if _, err := client.Tags(context.TODO(), imageURL); !errors.Is(err, util.ErrRepositoryNotFound) {
+		t.Fatalf("expected repository not found error, got=%v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("expected scan after purge to hit the registry, exp=2 got=%d", requests)
+	}
+}
+
+func TestTagsNegativeCacheCredentials(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, _, ok := req.BasicAuth(); !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"code":"NAME_UNKNOWN","message":"repository name not known to registry"}]}`))
+			return
+		}
+		w.Write([]byte(`{"name":"jetstack/private","tags":[]}`))
+	}))
+	defer server.Close()
+
+	nexusClient, err := nexus.New(nexus.Options{
+		Host:      strings.TrimPrefix(server.URL, "https://"),
+		Transport: server.Client().Transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{
+		fallback: nexusClient,
+		notFound: newNegativeCache(time.Minute, nil),
+	}
+
+	imageURL := nexusClient.Host + "/jetstack/private"
+	if _, err := client.Tags(context.TODO(), imageURL); !errors.Is(err, util.ErrRepositoryNotFound) {
+		t.Fatalf("expected repository not found error without credentials, got=%v", err)
+	}
+
+	// The not found result of the anonymous lookup should not fail lookups
+	// with the credentials of a pull secret.
+	ctx := util.WithCredentials(context.TODO(), map[string]util.Credentials{
+		nexusClient.Host: {Username: "robot", Password: "secret"},
+	})
+	if _, err := client.Tags(ctx, imageURL); err != nil {
+		t.Errorf("expected lookup with credentials to hit the registry, got=%v", err)
+	}
+}
+
+func TestNegativeCacheTimeout(t *testing.T) {
+	observed := make(map[string]bool)
+	cache := newNegativeCache(time.Minute, func(imageURL string, cached bool) {
+		observed[imageURL] = cached
+	})
+	cache.add("image", "", util.ErrRepositoryNotFound)
+
+	if err := cache.get("image", ""); err == nil {
+		t.Error("expected fresh not found result to be cached")
+	}
+	if !observed["image"] {
+		t.Error("expected added image to be observed as cached")
+	}
+
+	cache.items["image"] = negativeCacheItem{"image", time.Now().Add(-time.Hour), util.ErrRepositoryNotFound}
+	if err := cache.get("image", ""); err != nil {
+		t.Errorf("expected stale not found result to be expired, got=%v", err)
+	}
+	if cached, ok := observed["image"]; !ok || cached {
+		t.Error("expected expired image to be observed as removed")
+	}
+
+	cache.add("purged", "", util.ErrRepositoryNotFound)
+	cache.add("purged", "@creds", util.ErrRepositoryNotFound)
+	cache.purge("purged")
+	if cached, ok := observed["purged"]; !ok || cached {
+		t.Error("expected purged image to be observed as removed")
+	}
+}
+
+func TestNegativeCacheGarbageCollect(t *testing.T) {
+	var mu sync.Mutex
+	observed := make(map[string]bool)
+	cache := newNegativeCache(time.Millisecond*10, func(imageURL string, cached bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed[imageURL] = cached
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.garbageCollect(ctx)
+
+	cache.add("image", "", util.ErrRepositoryNotFound)
+	cache.add("image", "@creds", util.ErrRepositoryNotFound)
+
+	// Expired images should be observed as removed without another lookup.
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		mu.Lock()
+		cached, ok := observed["image"]
+		mu.Unlock()
+		if ok && !cached {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected expired image to be observed as removed")
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if len(cache.items) != 0 {
+		t.Errorf("expected expired items to be removed, got=%v", cache.items)
+	}
+}
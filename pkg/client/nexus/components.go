@@ -0,0 +1,74 @@
+package nexus
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+// ComponentsResponse is a page of the Nexus REST API search endpoint.
+type ComponentsResponse struct {
+	Items []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Assets  []struct {
+			Path         string    `json:"path"`
+			LastModified time.Time `json:"lastModified"`
+			Checksum     struct {
+				SHA256 string `json:"sha256"`
+			} `json:"checksum"`
+		} `json:"assets"`
+	} `json:"items"`
+	ContinuationToken string `json:"continuationToken"`
+}
+
+// components will return a tag for each component version of the image in
+// the Nexus repository, using the Nexus REST API. Tags are timestamped by the
+// latest lastModified time of their assets, with the digest of their
+// manifest asset, so that no manifests or configs are fetched.
+func (c *Client) components(ctx context.Context, repository, image string) ([]api.ImageTag, error) {
+	query := url.Values{
+		"repository": {repository},
+		"format":     {"docker"},
+		"name":       {image},
+	}
+
+	var tags []api.ImageTag
+	for {
+		var response ComponentsResponse
+		url := fmt.Sprintf("https://%s/service/rest/v1/search?%s", c.APIHost, query.Encode())
+		if _, err := c.doRequest(ctx, url, "", &response); err != nil {
+			return nil, err
+		}
+		util.CountPage(ctx)
+
+		for _, item := range response.Items {
+			// Search matches names containing the image name.
+			if item.Name != image {
+				continue
+			}
+
+			tag := api.ImageTag{Tag: item.Version}
+			for _, asset := range item.Assets {
+				if asset.LastModified.After(tag.Timestamp) {
+					tag.Timestamp = asset.LastModified
+				}
+				if strings.Contains(asset.Path, "/manifests/") && len(asset.Checksum.SHA256) > 0 {
+					tag.SHA = "sha256:" + asset.Checksum.SHA256
+				}
+			}
+
+			tags = append(tags, tag)
+		}
+
+		if len(response.ContinuationToken) == 0 {
+			return tags, nil
+		}
+		query.Set("continuationToken", response.ContinuationToken)
+	}
+}
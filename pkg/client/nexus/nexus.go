@@ -0,0 +1,286 @@
+package nexus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// repositoryPathPrefix is the path Nexus serves path-based routed
+	// repositories from. e.g. nexus.corp:8082/repository/docker-hosted/image
+	repositoryPathPrefix = "repository/"
+
+	manifestAccept = "application/vnd.docker.distribution.manifest.v2+json, " +
+		"application/vnd.oci.image.manifest.v1+json"
+)
+
+var (
+	// linkNextRegex matches the URL of the next page of a Link header.
+	linkNextRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+type Options struct {
+	// Host is the host and port of the Nexus Docker connector.
+	// e.g. nexus.corp:8082
+	Host string
+
+	// APIHost is the host and port of the Nexus REST API, used to list tags
+	// from the components of the image, timestamped by their lastModified
+	// time. If empty, tags are listed with the distribution API and
+	// timestamped by their image configs. e.g. nexus.corp:8081
+	APIHost string
+
+	// Repository is the name of the Nexus repository served by Host. Images
+	// served by path-based routing use the repository in their path.
+	Repository string
+
+	Username string
+	Password string
+	Token    string
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+}
+
+type Client struct {
+	*http.Client
+	Options
+}
+
+type TagResponse struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+type ManifestResponse struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+}
+
+type ConfigResponse struct {
+	Created      time.Time `json:"created"`
+	OS           string    `json:"os"`
+	Architecture string    `json:"architecture"`
+}
+
+func New(opts Options) (*Client, error) {
+	if len(opts.Token) > 0 && (len(opts.Username) > 0 || len(opts.Password) > 0) {
+		return nil, errors.New("cannot specify token as well as username/password")
+	}
+
+	return &Client{
+		Options: opts,
+		Client: &http.Client{
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
+		},
+	}, nil
+}
+
+func (c *Client) IsClient(imageURL string) bool {
+	return len(c.Host) > 0 && strings.HasPrefix(imageURL, c.Host+"/")
+}
+
+// Tags will list the components of the image with the Nexus REST API if its
+// host and the image's repository are known, otherwise the tags of the image
+// with the distribution API, fetching the manifest and config of each.
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	baseURL, repository, image := c.repoURL(imageURL)
+
+	if len(c.APIHost) > 0 && len(repository) > 0 {
+		tags, err := c.components(ctx, repository, image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nexus components: %s", err)
+		}
+
+		return tags, nil
+	}
+
+	pages, err := c.tagNamePages(ctx, baseURL, image, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []api.ImageTag
+	for _, page := range pages {
+		for _, tagName := range page {
+			tag, err := c.imageTag(ctx, baseURL, image, tagName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get image tag %q: %s", tagName, err)
+			}
+
+			tags = append(tags, *tag)
+		}
+	}
+
+	return tags, nil
+}
+
+// TagPages will return the tags of the image URL, as the pages returned by
+// the registry, up to maxPages. Zero maxPages returns all pages. Tags only
+// have their name set, as their manifests are not fetched.
+func (c *Client) TagPages(ctx context.Context, imageURL string, maxPages int) ([][]api.ImageTag, error) {
+	baseURL, _, image := c.repoURL(imageURL)
+
+	namePages, err := c.tagNamePages(ctx, baseURL, image, maxPages)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages [][]api.ImageTag
+	for _, names := range namePages {
+		var tags []api.ImageTag
+		for _, name := range names {
+			tags = append(tags, api.ImageTag{Tag: name})
+		}
+		pages = append(pages, tags)
+	}
+
+	return pages, nil
+}
+
+// tagNamePages will list the tag names of the image, following the Link
+// header of each page, up to maxPages.
+func (c *Client) tagNamePages(ctx context.Context, baseURL, image string, maxPages int) ([][]string, error) {
+	var pages [][]string
+	url := fmt.Sprintf("%s/%s/tags/list", baseURL, image)
+	for len(url) > 0 && (maxPages <= 0 || len(pages) < maxPages) {
+		var response TagResponse
+		header, err := c.doRequest(ctx, url, "", &response)
+		if err != nil {
+			return nil, err
+		}
+		util.CountPage(ctx)
+
+		pages = append(pages, response.Tags)
+
+		url = ""
+		if match := linkNextRegex.FindStringSubmatch(header.Get("Link")); len(match) == 2 {
+			url = match[1]
+			// Link may be relative to the registry host.
+			if strings.HasPrefix(url, "/") {
+				url = "https://" + c.Host + url
+			}
+		}
+	}
+
+	return pages, nil
+}
+
+// imageTag will fetch the manifest and config of the given tag to populate
+// its digest and timestamp.
+func (c *Client) imageTag(ctx context.Context, baseURL, image, tagName string) (*api.ImageTag, error) {
+	release, err := util.AcquireEnrichment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var manifest ManifestResponse
+	header, err := c.doRequest(ctx,
+		fmt.Sprintf("%s/%s/manifests/%s", baseURL, image, tagName), manifestAccept, &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &api.ImageTag{
+		Tag: tagName,
+		SHA: header.Get("Docker-Content-Digest"),
+	}
+
+	if len(manifest.Config.Digest) == 0 {
+		return tag, nil
+	}
+
+	var config ConfigResponse
+	if _, err := c.doRequest(ctx,
+		fmt.Sprintf("%s/%s/blobs/%s", baseURL, image, manifest.Config.Digest), "", &config); err != nil {
+		return nil, err
+	}
+
+	tag.Timestamp = config.Created
+	tag.OS = config.OS
+	tag.Architecture = config.Architecture
+
+	return tag, nil
+}
+
+// repoURL returns the distribution API base URL, Nexus repository and image
+// name of the image URL, handling repositories served by path-based routing.
+func (c *Client) repoURL(imageURL string) (string, string, string) {
+	path := strings.TrimPrefix(imageURL, c.Host+"/")
+
+	if strings.HasPrefix(path, repositoryPathPrefix) {
+		split := strings.SplitN(strings.TrimPrefix(path, repositoryPathPrefix), "/", 2)
+		if len(split) == 2 {
+			return fmt.Sprintf("https://%s/%s%s/v2", c.Host, repositoryPathPrefix, split[0]), split[0], split[1]
+		}
+	}
+
+	return fmt.Sprintf("https://%s/v2", c.Host), c.Repository, path
+}
+
+func (c *Client) doRequest(ctx context.Context, url, accept string, obj interface{}) (http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accept) > 0 {
+		req.Header.Set("Accept", accept)
+	}
+
+	cred, hasCred := util.CredentialsFor(ctx, c.Host)
+	switch {
+	case hasCred:
+		req.SetBasicAuth(cred.Username, cred.Password)
+	case len(c.Token) > 0:
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case len(c.Username) > 0 || len(c.Password) > 0:
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nexus image: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := util.NotFound(resp, url, body); err != nil {
+		return nil, err
+	}
+
+	if err := util.ErrorEnvelope(body); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from %q: %d %s",
+			url, resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, obj); err != nil {
+		return nil, fmt.Errorf("unexpected response from %q: %s", url, body)
+	}
+
+	return resp.Header, nil
+}
@@ -0,0 +1,206 @@
+package nexus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestNexus(t *testing.T, prefix string) (*httptest.Server, *Client) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(prefix+"/v2/jetstack/app/tags/list", func(w http.ResponseWriter, req *http.Request) {
+		if user, pass, ok := req.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":[{"code":"UNAUTHORIZED","message":"access to the requested resource is not authorized"}]}`))
+			return
+		}
+
+		if req.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `<`+prefix+`/v2/jetstack/app/tags/list?last=v0.1.0&n=1>; rel="next"`)
+			w.Write([]byte(`{"name":"jetstack/app","tags":["v0.1.0"]}`))
+			return
+		}
+
+		w.Write([]byte(`{"name":"jetstack/app","tags":["v0.2.0"]}`))
+	})
+
+	for _, tag := range []string{"v0.1.0", "v0.2.0"} {
+		tag := tag
+		mux.HandleFunc(prefix+"/v2/jetstack/app/manifests/"+tag, func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Docker-Content-Digest", "sha256:"+tag)
+			w.Write([]byte(`{"schemaVersion":2,"config":{"digest":"sha256:config-` + tag + `"}}`))
+		})
+	}
+
+	mux.HandleFunc(prefix+"/v2/jetstack/app/blobs/sha256:config-v0.1.0", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"created":"2020-06-01T10:00:00Z","os":"linux","architecture":"amd64"}`))
+	})
+	mux.HandleFunc(prefix+"/v2/jetstack/app/blobs/sha256:config-v0.2.0", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"created":"2020-07-01T10:00:00Z","os":"linux","architecture":"arm64"}`))
+	})
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := New(Options{
+		Host:      strings.TrimPrefix(server.URL, "https://"),
+		Username:  "user",
+		Password:  "pass",
+		Transport: server.Client().Transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, client
+}
+
+func TestTags(t *testing.T) {
+	tests := map[string]struct {
+		prefix, imagePath string
+	}{
+		"port based repository": {
+			prefix:    "",
+			imagePath: "jetstack/app",
+		},
+		"path based repository": {
+			prefix:    "/repository/docker-hosted",
+			imagePath: "repository/docker-hosted/jetstack/app",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, client := newTestNexus(t, test.prefix)
+
+			imageURL := client.Host + "/" + test.imagePath
+			if !client.IsClient(imageURL) {
+				t.Fatalf("expected client to match %q", imageURL)
+			}
+
+			tags, err := client.Tags(context.TODO(), imageURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tags) != 2 {
+				t.Fatalf("unexpected number of tags, exp=2 got=%d: %+v", len(tags), tags)
+			}
+
+			exp := []struct {
+				tag, sha, arch string
+				timestamp      time.Time
+			}{
+				{"v0.1.0", "sha256:v0.1.0", "amd64", time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)},
+				{"v0.2.0", "sha256:v0.2.0", "arm64", time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)},
+			}
+			for i, e := range exp {
+				if tags[i].Tag != e.tag || tags[i].SHA != e.sha ||
+					tags[i].Architecture != e.arch || !tags[i].Timestamp.Equal(e.timestamp) {
+					t.Errorf("unexpected tag, exp=%+v got=%+v", e, tags[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIsClient(t *testing.T) {
+	client, err := New(Options{Host: "nexus.corp:8082"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for imageURL, exp := range map[string]bool{
+		"nexus.corp:8082/jetstack/app":                          true,
+		"nexus.corp:8082/repository/docker-hosted/jetstack/app": true,
+		"nexus.corp:8083/jetstack/app":                          false,
+		"nexus.corp/jetstack/app":                               false,
+		"quay.io/jetstack/app":                                  false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+}
+
+func TestTagsComponents(t *testing.T) {
+	tests := map[string]struct {
+		prefix, imagePath, repository string
+	}{
+		"port based repository": {
+			prefix:     "",
+			imagePath:  "jetstack/app",
+			repository: "docker-hosted",
+		},
+		"path based repository": {
+			prefix:    "/repository/docker-hosted",
+			imagePath: "repository/docker-hosted/jetstack/app",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, client := newTestNexus(t, test.prefix)
+			client.APIHost = client.Host
+			client.Repository = test.repository
+
+			server.Config.Handler.(*http.ServeMux).HandleFunc("/service/rest/v1/search", func(w http.ResponseWriter, req *http.Request) {
+				query := req.URL.Query()
+				if query.Get("repository") != "docker-hosted" || query.Get("format") != "docker" ||
+					query.Get("name") != "jetstack/app" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				if query.Get("continuationToken") == "" {
+					w.Write([]byte(`{"items":[
+						{"name":"jetstack/app","version":"v0.1.0","assets":[
+							{"path":"v2/jetstack/app/manifests/v0.1.0","lastModified":"2021-01-01T10:00:00.000+00:00","checksum":{"sha256":"aaa"}}
+						]},
+						{"name":"jetstack/app-other","version":"v0.2.0","assets":[
+							{"path":"v2/jetstack/app-other/manifests/v0.2.0","lastModified":"2023-01-01T10:00:00.000+00:00","checksum":{"sha256":"ccc"}}
+						]}
+					],"continuationToken":"next"}`))
+					return
+				}
+
+				// v0.3.0 has no manifest served by the test registry, so is
+				// only listed if tags are listed from components alone.
+				w.Write([]byte(`{"items":[
+					{"name":"jetstack/app","version":"v0.2.0","assets":[
+						{"path":"v2/jetstack/app/manifests/v0.2.0","lastModified":"2021-02-01T10:00:00.000+00:00","checksum":{"sha256":"bbb"}}
+					]},
+					{"name":"jetstack/app","version":"v0.3.0","assets":[
+						{"path":"v2/jetstack/app/manifests/v0.3.0","lastModified":"2021-03-01T10:00:00.000+00:00","checksum":{"sha256":"ddd"}}
+					]}
+				],"continuationToken":null}`))
+			})
+
+			tags, err := client.Tags(context.TODO(), client.Host+"/"+test.imagePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exp := map[string]struct {
+				sha       string
+				timestamp time.Time
+			}{
+				"v0.1.0": {"sha256:aaa", time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)},
+				"v0.2.0": {"sha256:bbb", time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)},
+				"v0.3.0": {"sha256:ddd", time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)},
+			}
+			if len(tags) != len(exp) {
+				t.Fatalf("unexpected number of tags, exp=%d got=%d: %+v", len(exp), len(tags), tags)
+			}
+			for _, tag := range tags {
+				if e := exp[tag.Tag]; tag.SHA != e.sha || !tag.Timestamp.Equal(e.timestamp) {
+					t.Errorf("unexpected tag %q, exp=%s,%s got=%s,%s", tag.Tag, e.sha, e.timestamp, tag.SHA, tag.Timestamp)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Blob will return the content of the blob with the given digest. The content
+// is verified against the digest if VerifyDigests is enabled.
+func (c *Client) Blob(ctx context.Context, host, repo, digest string) ([]byte, error) {
+	_, body, err := c.doRequest(ctx, http.MethodGet, host, repo, "blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.VerifyDigests {
+		if err := VerifyDigest(digest, body, c.DigestAlgorithms); err != nil {
+			return nil, fmt.Errorf("%s/%s@%s: %w", host, repo, digest, err)
+		}
+	}
+
+	return body, nil
+}
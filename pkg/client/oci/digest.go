@@ -0,0 +1,67 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedDigestAlgorithm is returned when verifying content against
+	// a digest using an unknown, or not allowed, algorithm.
+	ErrUnsupportedDigestAlgorithm = errors.New("unsupported digest algorithm")
+
+	// ErrDigestMismatch is returned when content doesn't match its digest.
+	ErrDigestMismatch = errors.New("content does not match digest")
+
+	// DefaultDigestAlgorithms are the digest algorithms allowed for
+	// verification if none are configured.
+	DefaultDigestAlgorithms = []string{"sha256", "sha512"}
+)
+
+// digestAlgorithms are the known digest algorithms, keyed by the algorithm
+// prefix of a digest.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// VerifyDigest will verify that the content matches the digest, in the form
+// <algorithm>:<hex>. The algorithm must be one of the allowed algorithms, or
+// DefaultDigestAlgorithms if none are given.
+func VerifyDigest(digest string, content []byte, allowed []string) error {
+	split := strings.SplitN(digest, ":", 2)
+	if len(split) != 2 {
+		return fmt.Errorf("invalid digest: %q", digest)
+	}
+	algorithm, encoded := split[0], split[1]
+
+	if len(allowed) == 0 {
+		allowed = DefaultDigestAlgorithms
+	}
+
+	var isAllowed bool
+	for _, a := range allowed {
+		if a == algorithm {
+			isAllowed = true
+			break
+		}
+	}
+
+	newHash, ok := digestAlgorithms[algorithm]
+	if !ok || !isAllowed {
+		return fmt.Errorf("%w: %q", ErrUnsupportedDigestAlgorithm, algorithm)
+	}
+
+	h := newHash()
+	h.Write(content)
+	if hex.EncodeToString(h.Sum(nil)) != strings.ToLower(encoded) {
+		return fmt.Errorf("%w: %s", ErrDigestMismatch, digest)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const (
+	testContent = `{"schemaVersion":2}`
+
+	// Digests of testContent.
+	testContentSHA256 = "sha256:bafebd36189ad3688b7b3915ea55d461e0bfcfbdde11e54b0a123999fb6be50f"
+	testContentSHA512 = "sha512:63f87a5b21b700711f6dd1cabacfdea21e33fb2fb220d00be07d7fcd1de3f085" +
+		"e5b7ee51c25be6b9a5f054d904f36da93e0fff53fdd5fb223acd8075bc5ff465"
+)
+
+func TestVerifyDigest(t *testing.T) {
+	tests := map[string]struct {
+		digest  string
+		allowed []string
+		expErr  error
+	}{
+		"matching sha256 should verify": {
+			digest: testContentSHA256,
+		},
+		"matching sha512 should verify": {
+			digest: testContentSHA512,
+		},
+		"mismatching sha256 should error": {
+			digest: "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			expErr: ErrDigestMismatch,
+		},
+		"unknown algorithm should error": {
+			digest: "md5:c1ce2fb2a1d7e8184b7e60b7ab4da8b1",
+			expErr: ErrUnsupportedDigestAlgorithm,
+		},
+		"known algorithm not in allowlist should error": {
+			digest:  testContentSHA512,
+			allowed: []string{"sha256"},
+			expErr:  ErrUnsupportedDigestAlgorithm,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := VerifyDigest(test.digest, []byte(testContent), test.allowed)
+			if test.expErr == nil && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if !errors.Is(err, test.expErr) {
+				t.Errorf("unexpected error, exp=%v got=%v", test.expErr, err)
+			}
+		})
+	}
+}
+
+func TestManifestVerifyDigests(t *testing.T) {
+	body := testContent
+	client, host := newTestServer(t, Options{VerifyDigests: true}, map[string]string{
+		"jetstack/app/manifests/" + testContentSHA256:                                                    body,
+		"jetstack/app/manifests/" + testContentSHA512:                                                    body,
+		"jetstack/app/manifests/sha256:0000000000000000000000000000000000000000000000000000000000000000": body,
+	})
+
+	for _, digest := range []string{testContentSHA256, testContentSHA512} {
+		if _, err := client.Manifest(context.TODO(), host, "jetstack/app", digest); err != nil {
+			t.Errorf("unexpected error for %s: %s", digest, err)
+		}
+	}
+
+	_, err := client.Manifest(context.TODO(), host, "jetstack/app",
+		"sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("expected digest mismatch, got=%v", err)
+	}
+}
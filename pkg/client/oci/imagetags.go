@@ -0,0 +1,136 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+// ImageConfig holds the fields of an image config used to describe a tag.
+type ImageConfig struct {
+	Created      time.Time `json:"created"`
+	OS           string    `json:"os"`
+	Architecture string    `json:"architecture"`
+	Variant      string    `json:"variant"`
+}
+
+// Digest will return the digest of the given reference, using a HEAD request
+// so that the manifest isn't downloaded.
+func (c *Client) Digest(ctx context.Context, host, repo, reference string) (string, error) {
+	header := http.Header{}
+	header.Set("Accept", strings.Join([]string{
+		MediaTypeOCIIndex,
+		MediaTypeDockerManifestList,
+		MediaTypeOCIManifest,
+		MediaTypeDockerManifest,
+	}, ", "))
+
+	resp, _, err := c.doRequest(ctx, http.MethodHead, host, repo, "manifests/"+reference, header)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// ImageTags will return every tag of the repository, with their digest, and
+// the created timestamp of their image config. Manifest lists are described
+// by the config of the default platform, or else their first manifest,
+// without an OS or architecture. Tags whose manifest is not found, such as
+// deleted since the tags were listed, are skipped.
+func (c *Client) ImageTags(ctx context.Context, host, repo string) ([]api.ImageTag, error) {
+	names, err := c.Tags(ctx, host, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []api.ImageTag
+	for _, name := range names {
+		tag, err := c.imageTag(ctx, host, repo, name)
+		// Tags deleted since being listed are skipped.
+		if errors.Is(err, util.ErrManifestNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image tag %q: %s", name, err)
+		}
+
+		tags = append(tags, *tag)
+	}
+
+	return tags, nil
+}
+
+func (c *Client) imageTag(ctx context.Context, host, repo, name string) (*api.ImageTag, error) {
+	release, err := util.AcquireEnrichment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	manifest, err := c.Manifest(ctx, host, repo, name)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &api.ImageTag{Tag: name, SHA: manifest.Digest}
+
+	multiPlatform := manifest.IsIndex()
+	if multiPlatform {
+		manifest, err = c.platformManifest(ctx, host, repo, manifest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest == nil || len(manifest.Config.Digest) == 0 {
+		return tag, nil
+	}
+
+	blob, err := c.Blob(ctx, host, repo, manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ImageConfig
+	if err := json.Unmarshal(blob, &config); err != nil {
+		return nil, fmt.Errorf("unexpected image config: %s", blob)
+	}
+
+	tag.Timestamp = config.Created
+	if !multiPlatform {
+		tag.OS = config.OS
+		tag.Architecture = config.Architecture
+		tag.Variant = config.Variant
+	}
+
+	return tag, nil
+}
+
+// platformManifest will return the image manifest of the default platform in
+// the manifest list, or the first manifest if the default platform is not
+// listed. Returns nil if the list is empty.
+func (c *Client) platformManifest(ctx context.Context, host, repo string, index *Manifest) (*Manifest, error) {
+	if len(index.Manifests) == 0 {
+		return nil, nil
+	}
+
+	digest := index.Manifests[0].Digest
+	for _, desc := range index.Manifests {
+		if desc.Platform != nil &&
+			desc.Platform.OS == DefaultOS &&
+			desc.Platform.Architecture == DefaultArchitecture {
+			digest = desc.Digest
+			break
+		}
+	}
+
+	return c.Manifest(ctx, host, repo, digest)
+}
@@ -0,0 +1,150 @@
+package oci
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRegistry returns a registry host challenging for a bearer token,
+// serving an image manifest v0.1.0, a manifest list v0.2.0, and a catalog.
+func newTestRegistry(t *testing.T) (*Client, string) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			switch scope := req.URL.Query().Get("scope"); scope {
+			case "repository:jetstack/app:pull", "registry:catalog:*":
+				w.Write([]byte(`{"token":"` + scope + `"}`))
+			default:
+				w.WriteHeader(http.StatusForbidden)
+			}
+			return
+		}
+
+		exp := "Bearer repository:jetstack/app:pull"
+		if req.URL.Path == "/v2/_catalog" {
+			exp = "Bearer registry:catalog:*"
+		}
+		if req.Header.Get("Authorization") != exp {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch req.URL.Path {
+		case "/v2/_catalog":
+			w.Write([]byte(`{"repositories":["jetstack/app","jetstack/other"]}`))
+		case "/v2/jetstack/app/tags/list":
+			w.Write([]byte(`{"name":"jetstack/app","tags":["v0.1.0","v0.2.0"]}`))
+		case "/v2/jetstack/app/manifests/v0.1.0":
+			w.Header().Set("Docker-Content-Digest", "sha256:v0.1.0")
+			if req.Method == http.MethodHead {
+				return
+			}
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"digest":"sha256:config-v0.1.0"}}`))
+		case "/v2/jetstack/app/manifests/v0.2.0":
+			w.Header().Set("Docker-Content-Digest", "sha256:v0.2.0")
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+				{"digest":"sha256:v0.2.0-arm64","platform":{"os":"linux","architecture":"arm64"}}
+			]}`))
+		case "/v2/jetstack/app/manifests/sha256:v0.2.0-arm64":
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"digest":"sha256:config-v0.2.0"}}`))
+		case "/v2/jetstack/app/blobs/sha256:config-v0.1.0":
+			w.Write([]byte(`{"created":"2020-06-01T10:00:00Z","os":"linux","architecture":"amd64"}`))
+		case "/v2/jetstack/app/blobs/sha256:config-v0.2.0":
+			w.Write([]byte(`{"created":"2020-07-01T10:00:00Z","os":"linux","architecture":"arm64"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return New(Options{Transport: server.Client().Transport, LazyAuth: true}),
+		strings.TrimPrefix(server.URL, "https://")
+}
+
+func TestImageTags(t *testing.T) {
+	client, host := newTestRegistry(t)
+
+	tags, err := client.ImageTags(context.TODO(), host, "jetstack/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 2 {
+		t.Fatalf("unexpected number of tags, exp=2 got=%d: %+v", len(tags), tags)
+	}
+
+	exp := []struct {
+		tag, sha, arch string
+		timestamp      time.Time
+	}{
+		{"v0.1.0", "sha256:v0.1.0", "amd64", time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)},
+		// Manifest lists without the default platform use the first manifest.
+		{"v0.2.0", "sha256:v0.2.0", "", time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for i, e := range exp {
+		if tags[i].Tag != e.tag || tags[i].SHA != e.sha ||
+			tags[i].Architecture != e.arch || !tags[i].Timestamp.Equal(e.timestamp) {
+			t.Errorf("unexpected tag, exp=%+v got=%+v", e, tags[i])
+		}
+	}
+}
+
+func TestImageTagsDeletedTag(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v2/jetstack/app/tags/list":
+			w.Write([]byte(`{"name":"jetstack/app","tags":["v0.1.0","v0.2.0"]}`))
+		case "/v2/jetstack/app/manifests/v0.1.0":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`))
+		case "/v2/jetstack/app/manifests/v0.2.0":
+			w.Header().Set("Docker-Content-Digest", "sha256:v0.2.0")
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Options{Transport: server.Client().Transport, LazyAuth: true})
+	tags, err := client.ImageTags(context.TODO(), strings.TrimPrefix(server.URL, "https://"), "jetstack/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 1 || tags[0].Tag != "v0.2.0" || tags[0].SHA != "sha256:v0.2.0" {
+		t.Errorf("unexpected tags, exp=[v0.2.0] got=%+v", tags)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	client, host := newTestRegistry(t)
+
+	digest, err := client.Digest(context.TODO(), host, "jetstack/app", "v0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if digest != "sha256:v0.1.0" {
+		t.Errorf("unexpected digest, exp=sha256:v0.1.0 got=%s", digest)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	client, host := newTestRegistry(t)
+
+	repos, err := client.Catalog(context.TODO(), host)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := []string{"jetstack/app", "jetstack/other"}; !reflect.DeepEqual(repos, exp) {
+		t.Errorf("unexpected repositories, exp=%v got=%v", exp, repos)
+	}
+}
@@ -0,0 +1,208 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+
+	// DefaultOS and DefaultArchitecture is the platform resolved from manifest
+	// lists when none is given.
+	DefaultOS           = "linux"
+	DefaultArchitecture = "amd64"
+)
+
+var (
+	// ErrAmbiguousPlatform is returned when a manifest list contains more
+	// than one manifest for the same platform.
+	ErrAmbiguousPlatform = errors.New("manifest list contains duplicate platform")
+)
+
+// Descriptor describes content stored in the registry.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Platform describes the platform an image manifest in a manifest list is
+// built for.
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String returns the platform in the form os/arch[/variant].
+func (p *Platform) String() string {
+	if len(p.Variant) > 0 {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+
+	return p.OS + "/" + p.Architecture
+}
+
+// Manifest is either an image manifest or a manifest list (index). Manifest
+// lists will have Manifests populated, image manifests will have Config and
+// Layers populated.
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+
+	// Digest is the digest of the manifest, as returned by the registry or
+	// requested by.
+	Digest string `json:"-"`
+}
+
+// IsIndex returns true if this manifest is a manifest list, rather than an
+// image manifest.
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeDockerManifestList ||
+		m.MediaType == MediaTypeOCIIndex ||
+		(len(m.MediaType) == 0 && len(m.Manifests) > 0)
+}
+
+// Manifest will return the manifest of the given reference, either a tag or
+// digest.
+func (c *Client) Manifest(ctx context.Context, host, repo, reference string) (*Manifest, error) {
+	header := http.Header{}
+	header.Set("Accept", strings.Join([]string{
+		MediaTypeOCIIndex,
+		MediaTypeDockerManifestList,
+		MediaTypeOCIManifest,
+		MediaTypeDockerManifest,
+	}, ", "))
+
+	resp, body, err := c.doRequest(ctx, http.MethodGet, host, repo, "manifests/"+reference, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.VerifyDigests {
+		digest := resp.Header.Get("Docker-Content-Digest")
+		if strings.Contains(reference, ":") {
+			digest = reference
+		}
+
+		if len(digest) > 0 {
+			if err := VerifyDigest(digest, body, c.DigestAlgorithms); err != nil {
+				return nil, fmt.Errorf("%s/%s:%s: %w", host, repo, reference, err)
+			}
+		}
+	}
+
+	manifest := new(Manifest)
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return nil, fmt.Errorf("unexpected manifest response: %s", body)
+	}
+
+	if len(manifest.MediaType) == 0 {
+		manifest.MediaType = resp.Header.Get("Content-Type")
+	}
+
+	manifest.Digest = resp.Header.Get("Docker-Content-Digest")
+	if strings.Contains(reference, ":") {
+		manifest.Digest = reference
+	}
+
+	if manifest.IsIndex() {
+		if err := c.validatePlatforms(manifest); err != nil {
+			return nil, fmt.Errorf("%s/%s:%s: %w", host, repo, reference, err)
+		}
+	}
+
+	return manifest, nil
+}
+
+// validatePlatforms will ensure that each platform is only listed once in the
+// manifest list. If duplicates are allowed, only the first manifest of each
+// platform is kept.
+func (c *Client) validatePlatforms(manifest *Manifest) error {
+	seen := make(map[string]bool)
+
+	var manifests []Descriptor
+	for _, desc := range manifest.Manifests {
+		if desc.Platform == nil {
+			manifests = append(manifests, desc)
+			continue
+		}
+
+		// Attestation manifests are listed with an unknown platform, one for
+		// each image.
+		if desc.Platform.OS == "unknown" && desc.Platform.Architecture == "unknown" {
+			manifests = append(manifests, desc)
+			continue
+		}
+
+		platform := desc.Platform.String()
+		if seen[platform] {
+			if !c.AllowDuplicatePlatforms {
+				return fmt.Errorf("%w: %s", ErrAmbiguousPlatform, platform)
+			}
+
+			continue
+		}
+
+		seen[platform] = true
+		manifests = append(manifests, desc)
+	}
+
+	manifest.Manifests = manifests
+
+	return nil
+}
+
+// ImageManifest will return the image manifest of the given reference. If
+// the reference is a manifest list, the image manifest of the default
+// platform is resolved.
+func (c *Client) ImageManifest(ctx context.Context, host, repo, reference string) (*Manifest, error) {
+	manifest, err := c.Manifest(ctx, host, repo, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if !manifest.IsIndex() {
+		return manifest, nil
+	}
+
+	for _, desc := range manifest.Manifests {
+		if desc.Platform != nil &&
+			desc.Platform.OS == DefaultOS &&
+			desc.Platform.Architecture == DefaultArchitecture {
+			return c.Manifest(ctx, host, repo, desc.Digest)
+		}
+	}
+
+	return nil, fmt.Errorf("no manifest found for platform %s/%s in %s/%s:%s",
+		DefaultOS, DefaultArchitecture, host, repo, reference)
+}
+
+// Layers will return the layer digests of the given reference, in order.
+func (c *Client) Layers(ctx context.Context, host, repo, reference string) ([]string, error) {
+	manifest, err := c.ImageManifest(ctx, host, repo, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []string
+	for _, layer := range manifest.Layers {
+		layers = append(layers, layer.Digest)
+	}
+
+	return layers, nil
+}
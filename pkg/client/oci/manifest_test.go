@@ -0,0 +1,119 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const duplicatePlatformIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"digest": "sha256:amd64-a", "platform": {"os": "linux", "architecture": "amd64"}},
+    {"digest": "sha256:arm64", "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+    {"digest": "sha256:amd64-b", "platform": {"os": "linux", "architecture": "amd64"}},
+    {"digest": "sha256:att-a", "platform": {"os": "unknown", "architecture": "unknown"}},
+    {"digest": "sha256:att-b", "platform": {"os": "unknown", "architecture": "unknown"}}
+  ]
+}`
+
+const validIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"digest": "sha256:amd64", "platform": {"os": "linux", "architecture": "amd64"}},
+    {"digest": "sha256:arm-v6", "platform": {"os": "linux", "architecture": "arm", "variant": "v6"}},
+    {"digest": "sha256:arm-v7", "platform": {"os": "linux", "architecture": "arm", "variant": "v7"}},
+    {"digest": "sha256:att-a", "platform": {"os": "unknown", "architecture": "unknown"}},
+    {"digest": "sha256:att-b", "platform": {"os": "unknown", "architecture": "unknown"}}
+  ]
+}`
+
+// newTestServer returns a Client and registry host, serving the given
+// manifest bodies keyed by "<repo>/manifests/<reference>".
+func newTestServer(t *testing.T, opts Options, manifests map[string]string) (*Client, string) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, ok := manifests[strings.TrimPrefix(req.URL.Path, "/v2/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	opts.Transport = server.Client().Transport
+	return New(opts), strings.TrimPrefix(server.URL, "https://")
+}
+
+func TestManifestDuplicatePlatforms(t *testing.T) {
+	tests := map[string]struct {
+		index           string
+		allowDuplicates bool
+		expErr          error
+		expDigests      []string
+	}{
+		"valid index should return all manifests": {
+			index:      validIndex,
+			expDigests: []string{"sha256:amd64", "sha256:arm-v6", "sha256:arm-v7", "sha256:att-a", "sha256:att-b"},
+		},
+		"duplicate platform should error": {
+			index:  duplicatePlatformIndex,
+			expErr: ErrAmbiguousPlatform,
+		},
+		"duplicate platform when allowed should keep the first": {
+			index:           duplicatePlatformIndex,
+			allowDuplicates: true,
+			expDigests:      []string{"sha256:amd64-a", "sha256:arm64", "sha256:att-a", "sha256:att-b"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client, host := newTestServer(t,
+				Options{AllowDuplicatePlatforms: test.allowDuplicates},
+				map[string]string{"jetstack/app/manifests/v0.1.0": test.index},
+			)
+
+			manifest, err := client.Manifest(context.TODO(), host, "jetstack/app", "v0.1.0")
+			if !errors.Is(err, test.expErr) {
+				t.Fatalf("unexpected error, exp=%v got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			var digests []string
+			for _, desc := range manifest.Manifests {
+				digests = append(digests, desc.Digest)
+			}
+			if strings.Join(digests, ",") != strings.Join(test.expDigests, ",") {
+				t.Errorf("unexpected manifests, exp=%v got=%v", test.expDigests, digests)
+			}
+		})
+	}
+}
+
+func TestManifestErrorEnvelope(t *testing.T) {
+	client, host := newTestServer(t, Options{}, map[string]string{
+		"jetstack/app/manifests/v0.1.0": `{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`,
+	})
+
+	_, err := client.Manifest(context.TODO(), host, "jetstack/app", "v0.1.0")
+
+	var registryErr *util.ErrRegistryError
+	if !errors.As(err, &registryErr) {
+		t.Fatalf("expected registry error from 200 error envelope, got=%v", err)
+	}
+
+	if codes := registryErr.Codes(); len(codes) != 1 || codes[0] != "MANIFEST_UNKNOWN" {
+		t.Errorf("unexpected error codes, exp=[MANIFEST_UNKNOWN] got=%v", codes)
+	}
+}
@@ -0,0 +1,302 @@
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// dockerHubHost is the host serving the Docker Hub distribution API.
+	dockerHubHost = "registry-1.docker.io"
+)
+
+var (
+	// challengeParamRegex matches the key="value" parameters of a
+	// WWW-Authenticate header.
+	challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// Options used to configure the distribution API client.
+type Options struct {
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+
+	// AllowDuplicatePlatforms will use the first manifest of a platform listed
+	// more than once in a manifest list, rather than returning
+	// ErrAmbiguousPlatform.
+	AllowDuplicatePlatforms bool
+
+	// VerifyDigests will verify the content of manifests against their
+	// digest, either the digest they were requested by, or the digest
+	// returned by the registry.
+	VerifyDigests bool
+
+	// DigestAlgorithms are the digest algorithms allowed when verifying
+	// digests. Defaults to DefaultDigestAlgorithms.
+	DigestAlgorithms []string
+
+	// Redactor removes credentials from returned errors. If nil, the default
+	// patterns are used.
+	Redactor *util.Redactor
+
+	// LazyAuth will request content without authenticating first, requesting
+	// a token only when challenged. This saves a round trip for registries
+	// allowing anonymous pulls. Otherwise, each host is first pinged for its
+	// challenge, and a token requested before the first request of each
+	// repository. Docker Hub, which always challenges, is authenticated
+	// eagerly regardless.
+	LazyAuth bool
+}
+
+// Client is a client for registries implementing the OCI Distribution API. It
+// handles the bearer token challenge flow, anonymously unless the request
+// context holds credentials for the registry host.
+type Client struct {
+	*http.Client
+	Options
+
+	tokenMu sync.Mutex
+	// tokens holds a bearer token per host and repository.
+	tokens map[string]string
+	// challenges holds the authentication challenge of each host, used for
+	// eager authentication. Empty if the host doesn't require authentication.
+	challenges map[string]string
+}
+
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+func New(opts Options) *Client {
+	return &Client{
+		Options: opts,
+		Client: &http.Client{
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
+		},
+		tokens:     make(map[string]string),
+		challenges: make(map[string]string),
+	}
+}
+
+// doRequest will perform a request against the distribution API of the
+// given host and repository. An empty repository requests the path from the
+// registry root, such as the catalog. If the registry challenges for a bearer
+// token, one will be requested and the request retried. Credentials are
+// redacted from returned errors.
+func (c *Client) doRequest(ctx context.Context, method, host, repo, path string, header http.Header) (*http.Response, []byte, error) {
+	resp, body, err := c.request(ctx, method, host, repo, path, header)
+	return resp, body, c.Redactor.Error(err)
+}
+
+func (c *Client) request(ctx context.Context, method, host, repo, path string, header http.Header) (*http.Response, []byte, error) {
+	var cred *util.Credentials
+	if found, ok := util.CredentialsFor(ctx, host); ok {
+		cred = &found
+	}
+
+	// Docker Hub always challenges, so is authenticated eagerly even with
+	// lazy auth, rather than wasting a request on every repository.
+	eager := !c.LazyAuth || host == api.DefaultRegistry
+
+	if host == api.DefaultRegistry {
+		host = dockerHubHost
+	}
+
+	url := fmt.Sprintf("https://%s/v2/%s/%s", host, repo, path)
+	if len(repo) == 0 {
+		url = fmt.Sprintf("https://%s/v2/%s", host, path)
+	}
+	tokenIndex := host + "/" + repo
+	if cred != nil {
+		tokenIndex += "@" + cred.ID()
+	}
+
+	token := c.token(tokenIndex)
+	if len(token) == 0 && eager {
+		var err error
+		token, err = c.eagerToken(ctx, host, repo, cred)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to authenticate with %q: %s", host, err)
+		}
+
+		c.tokenMu.Lock()
+		c.tokens[tokenIndex] = token
+		c.tokenMu.Unlock()
+	}
+
+	resp, body, err := c.send(ctx, method, url, header, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := c.requestToken(ctx, resp.Header.Get("WWW-Authenticate"), repo, cred)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to authenticate with %q: %s", host, err)
+		}
+
+		c.tokenMu.Lock()
+		c.tokens[tokenIndex] = token
+		c.tokenMu.Unlock()
+
+		resp, body, err = c.send(ctx, method, url, header, token)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := util.NotFound(resp, url, body); err != nil {
+		return nil, nil, err
+	}
+
+	if err := util.ErrorEnvelope(body); err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status code from %q: %d %s",
+			url, resp.StatusCode, body)
+	}
+
+	return resp, body, nil
+}
+
+// eagerToken will request a token for the repository before it is
+// requested, using the challenge of the host. The challenge is discovered by
+// pinging the host the first time it is used. Returns an empty token if the
+// host doesn't require authentication.
+func (c *Client) eagerToken(ctx context.Context, host, repo string, cred *util.Credentials) (string, error) {
+	c.tokenMu.Lock()
+	challenge, ok := c.challenges[host]
+	c.tokenMu.Unlock()
+
+	if !ok {
+		resp, _, err := c.send(ctx, http.MethodGet, fmt.Sprintf("https://%s/v2/", host), nil, "")
+		if err != nil {
+			return "", err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized {
+			challenge = resp.Header.Get("WWW-Authenticate")
+		}
+
+		c.tokenMu.Lock()
+		c.challenges[host] = challenge
+		c.tokenMu.Unlock()
+	}
+
+	if len(challenge) == 0 {
+		return "", nil
+	}
+
+	return c.requestToken(ctx, challenge, repo, cred)
+}
+
+func (c *Client) send(ctx context.Context, method, url string, header http.Header, token string) (*http.Response, []byte, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get %q: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp, body, nil
+}
+
+// requestToken will request a pull token from the realm given in the
+// WWW-Authenticate challenge, anonymously if cred is nil.
+func (c *Client) requestToken(ctx context.Context, challenge, repo string, cred *util.Credentials) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", fmt.Errorf("unsupported authentication challenge: %q", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, match := range challengeParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+
+	realm, ok := params["realm"]
+	if !ok {
+		return "", fmt.Errorf("no realm in authentication challenge: %q", challenge)
+	}
+
+	query := url.Values{}
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+	scope, ok := params["scope"]
+	switch {
+	case ok:
+	case len(repo) == 0:
+		scope = "registry:catalog:*"
+	default:
+		scope = fmt.Sprintf("repository:%s:pull", repo)
+	}
+	query.Set("scope", scope)
+
+	var header http.Header
+	if cred != nil {
+		header = http.Header{"Authorization": {"Basic " +
+			base64.StdEncoding.EncodeToString([]byte(cred.Username+":"+cred.Password))}}
+	}
+
+	resp, body, err := c.send(ctx, http.MethodGet, realm+"?"+query.Encode(), header, "")
+	if err != nil {
+		return "", err
+	}
+
+	if cred != nil && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code from token realm %q: %d %s",
+			realm, resp.StatusCode, body)
+	}
+
+	response := new(tokenResponse)
+	if err := json.Unmarshal(body, response); err != nil {
+		return "", fmt.Errorf("unexpected token response: %s", body)
+	}
+
+	if len(response.Token) > 0 {
+		return response.Token, nil
+	}
+
+	return response.AccessToken, nil
+}
+
+func (c *Client) token(tokenIndex string) string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.tokens[tokenIndex]
+}
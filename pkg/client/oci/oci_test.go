@@ -0,0 +1,175 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+func TestRequestRedactsErrors(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/token":
+			w.Write([]byte(`{"token":"hunter2"}`))
+		case req.Header.Get("Authorization") == "":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			// Misbehaving registry echoing the request credentials.
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, "internal error handling request with Authorization: %s", req.Header.Get("Authorization"))
+		}
+	}))
+	defer server.Close()
+
+	client := New(Options{Transport: server.Client().Transport})
+	_, err := client.Manifest(context.TODO(), strings.TrimPrefix(server.URL, "https://"), "jetstack/app", "v0.1.0")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("expected credential to be redacted, got=%q", err)
+	}
+	if !strings.Contains(err.Error(), "Bearer REDACTED") {
+		t.Errorf("expected redacted authorization in error, got=%q", err)
+	}
+}
+
+func TestLazyAuth(t *testing.T) {
+	newRegistry := func(t *testing.T, challenge bool) (*httptest.Server, *int) {
+		var requests int
+		var server *httptest.Server
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			requests++
+			switch {
+			case req.URL.Path == "/token":
+				w.Write([]byte(`{"token":"abc"}`))
+			case challenge && req.Header.Get("Authorization") != "Bearer abc":
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+			case req.URL.Path == "/v2/":
+				w.Write([]byte(`{}`))
+			default:
+				w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`))
+			}
+		}))
+		t.Cleanup(server.Close)
+		return server, &requests
+	}
+
+	tests := map[string]struct {
+		dockerHub   bool
+		challenge   bool
+		lazy        bool
+		expRequests int
+	}{
+		// ping, manifest, manifest
+		"eager auth against anonymous registry should ping once": {
+			expRequests: 3,
+		},
+		// manifest, manifest
+		"lazy auth against anonymous registry should not ping": {
+			lazy:        true,
+			expRequests: 2,
+		},
+		// ping, token, manifest, token, manifest
+		"eager auth against challenging registry should authenticate before each repository": {
+			challenge:   true,
+			expRequests: 5,
+		},
+		// manifest, token, manifest, manifest, token, manifest
+		"lazy auth against challenging registry should authenticate when challenged": {
+			challenge:   true,
+			lazy:        true,
+			expRequests: 6,
+		},
+		// ping, token, manifest, token, manifest
+		"lazy auth against docker hub should authenticate before each repository": {
+			dockerHub:   true,
+			challenge:   true,
+			lazy:        true,
+			expRequests: 5,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, requests := newRegistry(t, test.challenge)
+			host := strings.TrimPrefix(server.URL, "https://")
+
+			transport := server.Client().Transport
+			if test.dockerHub {
+				// Send requests to Docker Hub to the test registry.
+				next, serverHost := transport, host
+				transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					req.URL.Host = serverHost
+					return next.RoundTrip(req)
+				})
+				host = "docker.io"
+			}
+
+			client := New(Options{
+				Transport: transport,
+				LazyAuth:  test.lazy,
+			})
+
+			for _, repo := range []string{"jetstack/a", "jetstack/b"} {
+				if _, err := client.Manifest(context.TODO(), host, repo, "v0.1.0"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if *requests != test.expRequests {
+				t.Errorf("unexpected number of requests, exp=%d got=%d", test.expRequests, *requests)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestContextCredentials(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/token":
+			// Anonymous tokens can't pull.
+			if user, pass, ok := req.BasicAuth(); ok && user == "user" && pass == "pass" {
+				w.Write([]byte(`{"token":"user-token"}`))
+			} else {
+				w.Write([]byte(`{"token":"anonymous-token"}`))
+			}
+		case req.Header.Get("Authorization") != "Bearer user-token":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`))
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	client := New(Options{Transport: server.Client().Transport})
+
+	if _, err := client.Manifest(context.TODO(), host, "jetstack/app", "v0.1.0"); err == nil {
+		t.Fatal("expected anonymous request to fail")
+	}
+
+	ctx := util.WithCredentials(context.TODO(), map[string]util.Credentials{
+		host: {Username: "user", Password: "pass"},
+	})
+	if _, err := client.Manifest(ctx, host, "jetstack/app", "v0.1.0"); err != nil {
+		t.Errorf("unexpected error with context credentials: %s", err)
+	}
+}
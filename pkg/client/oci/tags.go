@@ -0,0 +1,97 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+var (
+	// linkNextRegex matches the URL of the next page of a Link header.
+	linkNextRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+type tagsResponse struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+type catalogResponse struct {
+	Repositories []string `json:"repositories"`
+}
+
+// Tags will return the tag names of the repository, following the Link
+// header of each page.
+func (c *Client) Tags(ctx context.Context, host, repo string) ([]string, error) {
+	var tags []string
+	err := c.list(ctx, host, repo, "tags/list?n=1000", func(body []byte) error {
+		var response tagsResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return fmt.Errorf("unexpected tags response: %s", body)
+		}
+		tags = append(tags, response.Tags...)
+		util.CountPage(ctx)
+		return nil
+	})
+
+	return tags, err
+}
+
+// Catalog will return the repositories of the registry, following the Link
+// header of each page. Registries may restrict the catalog to authenticated
+// users, or not serve it at all.
+func (c *Client) Catalog(ctx context.Context, host string) ([]string, error) {
+	var repos []string
+	err := c.list(ctx, host, "", "_catalog?n=1000", func(body []byte) error {
+		var response catalogResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return fmt.Errorf("unexpected catalog response: %s", body)
+		}
+		repos = append(repos, response.Repositories...)
+		return nil
+	})
+
+	return repos, err
+}
+
+// list will request each page of a paginated list, starting at path, calling
+// page with the body of each.
+func (c *Client) list(ctx context.Context, host, repo, path string, page func([]byte) error) error {
+	prefix := "/v2/"
+	if len(repo) > 0 {
+		prefix += repo + "/"
+	}
+
+	for len(path) > 0 {
+		resp, body, err := c.doRequest(ctx, http.MethodGet, host, repo, path, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := page(body); err != nil {
+			return err
+		}
+
+		path = ""
+		if match := linkNextRegex.FindStringSubmatch(resp.Header.Get("Link")); len(match) == 2 {
+			next, err := url.Parse(match[1])
+			if err != nil {
+				return fmt.Errorf("invalid Link header %q: %s", resp.Header.Get("Link"), err)
+			}
+
+			// The next page is requested relative to the repository.
+			path = strings.TrimPrefix(next.Path, prefix)
+			if len(next.RawQuery) > 0 {
+				path += "?" + next.RawQuery
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package oci
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/v2/":
+			w.Write([]byte(`{}`))
+		case req.URL.Path != "/v2/jetstack/app/tags/list":
+			w.WriteHeader(http.StatusNotFound)
+		case req.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/jetstack/app/tags/list?last=v0.2.0&n=2>; rel="next"`)
+			w.Write([]byte(`{"name":"jetstack/app","tags":["v0.1.0","v0.2.0"]}`))
+		default:
+			w.Write([]byte(`{"name":"jetstack/app","tags":["v0.3.0"]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := New(Options{Transport: server.Client().Transport})
+	tags, err := client.Tags(context.TODO(), strings.TrimPrefix(server.URL, "https://"), "jetstack/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := []string{"v0.1.0", "v0.2.0", "v0.3.0"}; !reflect.DeepEqual(tags, exp) {
+		t.Errorf("unexpected tags, exp=%v got=%v", exp, tags)
+	}
+}
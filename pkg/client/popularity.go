@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// PopularityClient is an ImageClient which is able to return the number of
+// times each tag has been pulled. Satisfied by the artifactory client.
+type PopularityClient interface {
+	ImageClient
+
+	// PullCounts returns the number of pulls of each tag of the image URL,
+	// keyed by tag.
+	PullCounts(ctx context.Context, imageURL string) (map[string]int64, error)
+}
+
+// TagsByPopularity will return the tags of the given image URL, with
+// PullCount set, ordered by the most pulled first. Tags with the same number
+// of pulls are ordered by the highest version first. Returns ErrUnsupported if
+// the registry doesn't expose pull counts.
+func (c *Client) TagsByPopularity(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	client, ok := c.fromImageURL(imageURL).(PopularityClient)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	tags, err := c.tracedTags(ctx, client, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := client.PullCounts(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tags {
+		tags[i].PullCount = counts[tags[i].Tag]
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		if tags[i].PullCount != tags[j].PullCount {
+			return tags[i].PullCount > tags[j].PullCount
+		}
+
+		return semver.Parse(tags[j].Tag).LessThan(semver.Parse(tags[i].Tag))
+	})
+
+	return tags, nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/client/artifactory"
+)
+
+// fakePopularityClient is a fakeClient which also returns pull counts.
+type fakePopularityClient struct {
+	fakeClient
+	counts map[string]int64
+}
+
+func (f *fakePopularityClient) PullCounts(context.Context, string) (map[string]int64, error) {
+	return f.counts, nil
+}
+
+func TestTagsByPopularity(t *testing.T) {
+	client := &Client{
+		fallback: &fakePopularityClient{
+			fakeClient: fakeClient{
+				tags: tagsFromNames("v1.0.0", "v1.1.0", "v1.2.0", "v1.3.0", "latest"),
+			},
+			counts: map[string]int64{
+				"v1.0.0": 50,
+				"v1.1.0": 1200,
+				"v1.2.0": 50,
+				"latest": 9000,
+			},
+		},
+	}
+
+	tags, err := client.TagsByPopularity(context.TODO(), "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{"latest", "v1.1.0", "v1.2.0", "v1.0.0", "v1.3.0"}
+	if got := tagNames(tags); !equalNames(exp, got) {
+		t.Errorf("unexpected tag order, exp=%v got=%v", exp, got)
+	}
+
+	if tags[0].PullCount != 9000 || tags[4].PullCount != 0 {
+		t.Errorf("unexpected pull counts, got=%+v", tags)
+	}
+}
+
+func TestTagsByPopularityUnsupported(t *testing.T) {
+	_, err := newFakeClient(tagsFromNames("v1.0.0")).TagsByPopularity(context.TODO(), "image")
+	if err != ErrUnsupported {
+		t.Errorf("unexpected error, exp=%v got=%v", ErrUnsupported, err)
+	}
+}
+
+func TestPopularityClients(t *testing.T) {
+	var client ImageClient = new(artifactory.Client)
+	if _, ok := client.(PopularityClient); !ok {
+		t.Error("expected artifactory client to return pull counts")
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// proxyDirect is the proxy of registry hosts which bypass any proxy.
+const proxyDirect = "direct"
+
+// newProxyFunc returns a proxy function using the proxy URL given for each
+// registry host, or connecting directly if the proxy is "direct". Hosts may
+// include a port to only match that port. Requests to any other host use the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. Returns nil if
+// no proxies are given.
+func newProxyFunc(proxies map[string]string) (func(*http.Request) (*url.URL, error), error) {
+	if len(proxies) == 0 {
+		return nil, nil
+	}
+
+	hosts := make(map[string]*url.URL)
+	for host, proxy := range proxies {
+		if proxy == proxyDirect {
+			hosts[host] = nil
+			continue
+		}
+
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || len(proxyURL.Host) == 0 {
+			return nil, fmt.Errorf("invalid proxy URL for %q, must be a URL or %q", host, proxyDirect)
+		}
+
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme for %q: %q", host, proxyURL.Scheme)
+		}
+
+		hosts[host] = proxyURL
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if proxyURL, ok := hosts[req.URL.Host]; ok {
+			return proxyURL, nil
+		}
+		if proxyURL, ok := hosts[req.URL.Hostname()]; ok {
+			return proxyURL, nil
+		}
+
+		return http.ProxyFromEnvironment(req)
+	}, nil
+}
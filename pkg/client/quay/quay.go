@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
 const (
@@ -19,11 +20,21 @@ const (
 
 type Options struct {
 	Token string
+
+	// TokenFile is a path to a file containing the token, which is refreshed
+	// externally. Takes precedence over Token.
+	TokenFile string
+
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
 }
 
 type Client struct {
 	*http.Client
 	Options
+
+	tokenFile *util.TokenFile
 }
 
 type Response struct {
@@ -37,10 +48,17 @@ type Tag struct {
 }
 
 func New(opts Options) *Client {
+	var tokenFile *util.TokenFile
+	if len(opts.TokenFile) > 0 {
+		tokenFile = util.NewTokenFile(opts.TokenFile)
+	}
+
 	return &Client{
-		Options: opts,
+		Options:   opts,
+		tokenFile: tokenFile,
 		Client: &http.Client{
-			Timeout: time.Second * 5,
+			Timeout:   time.Second * 5,
+			Transport: opts.Transport,
 		},
 	}
 }
@@ -61,8 +79,12 @@ func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, err
 		return nil, err
 	}
 
-	if len(c.Token) > 0 {
-		req.Header.Add("Authorization", "Bearer "+c.Token)
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(token) > 0 {
+		req.Header.Add("Authorization", "Bearer "+token)
 	}
 
 	req.URL.Scheme = "https"
@@ -78,6 +100,15 @@ func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, err
 		return nil, err
 	}
 
+	if err := util.NotFound(resp, url, body); err != nil {
+		return nil, err
+	}
+
+	if err := util.ErrorEnvelope(body); err != nil {
+		return nil, err
+	}
+	util.CountPage(ctx)
+
 	var response Response
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, err
@@ -99,3 +130,13 @@ func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, err
 
 	return tags, nil
 }
+
+// token returns the token to authenticate with, read from the token file if
+// set.
+func (c *Client) token(ctx context.Context) (string, error) {
+	if c.tokenFile != nil {
+		return c.tokenFile.Token(ctx)
+	}
+
+	return c.Token, nil
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// rateLimitMaxWait is the longest a lookup waits for the rate limit of
+	// its registry host. Lookups which would wait longer fail with
+	// ErrRateLimited, so that their work is requeued rather than holding a
+	// worker.
+	rateLimitMaxWait = time.Second * 5
+)
+
+// hostRateLimits holds a token bucket rate limit of lookups per registry
+// host.
+type hostRateLimits struct {
+	limiters map[string]*rate.Limiter
+}
+
+// newHostRateLimits returns the rate limits of the map of registry host to
+// lookups per second, with the burst of each being rounded up. Returns nil if
+// no limits are given.
+func newHostRateLimits(limits map[string]string) (*hostRateLimits, error) {
+	if len(limits) == 0 {
+		return nil, nil
+	}
+
+	h := &hostRateLimits{limiters: make(map[string]*rate.Limiter)}
+	for host, value := range limits {
+		rps, err := strconv.ParseFloat(value, 64)
+		if err != nil || rps <= 0 || math.IsInf(rps, 0) {
+			return nil, fmt.Errorf("invalid rate limit of %q, must be a positive number of lookups per second: %q",
+				host, value)
+		}
+
+		h.limiters[host] = rate.NewLimiter(rate.Limit(rps), int(math.Ceil(rps)))
+	}
+
+	return h, nil
+}
+
+// wait will wait for the rate limit of the host to allow a lookup. Returns
+// ErrRateLimited if the wait would be longer than rateLimitMaxWait.
+func (h *hostRateLimits) wait(ctx context.Context, host string) error {
+	if h == nil {
+		return nil
+	}
+
+	limiter, ok := h.limiters[host]
+	if !ok {
+		return nil
+	}
+
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	if delay > rateLimitMaxWait {
+		reservation.Cancel()
+		return fmt.Errorf("%w: %s", ErrRateLimited, host)
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHostRateLimits(t *testing.T) {
+	limits, err := newHostRateLimits(map[string]string{
+		"docker.io": "0.1",
+		"quay.io":   "1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+
+	if err := limits.wait(ctx, "docker.io"); err != nil {
+		t.Errorf("expected first lookup to be allowed, got=%v", err)
+	}
+	if err := limits.wait(ctx, "docker.io"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected lookup waiting too long to be rate limited, exp=%v got=%v", ErrRateLimited, err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := limits.wait(ctx, "gcr.io"); err != nil {
+			t.Errorf("expected host without a limit to be allowed, got=%v", err)
+		}
+	}
+
+	if err := limits.wait(ctx, "quay.io"); err != nil {
+		t.Errorf("expected first lookup to be allowed, got=%v", err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := limits.wait(cancelled, "quay.io"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected waiting lookup to return when the context is done, exp=%v got=%v", context.Canceled, err)
+	}
+
+	for _, invalid := range []string{"0", "-1", "fast"} {
+		if _, err := newHostRateLimits(map[string]string{"docker.io": invalid}); err == nil {
+			t.Errorf("expected error for rate limit %q", invalid)
+		}
+	}
+
+	var nilLimits *hostRateLimits
+	if err := nilLimits.wait(ctx, "docker.io"); err != nil {
+		t.Errorf("expected nil limits to allow lookups, got=%v", err)
+	}
+}
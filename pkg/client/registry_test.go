@@ -0,0 +1,133 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/client/oci"
+)
+
+// testRegistry is a stub distribution API serving static manifests.
+type testRegistry struct {
+	*httptest.Server
+
+	// manifests are keyed by "<repo>/manifests/<reference>".
+	manifests map[string]*oci.Manifest
+
+	// blobs are keyed by "<repo>/blobs/<digest>".
+	blobs map[string][]byte
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	r := &testRegistry{
+		manifests: make(map[string]*oci.Manifest),
+		blobs:     make(map[string][]byte),
+	}
+
+	r.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if blob, ok := r.blobs[strings.TrimPrefix(req.URL.Path, "/v2/")]; ok {
+			w.Write(blob)
+			return
+		}
+
+		manifest, ok := r.manifests[strings.TrimPrefix(req.URL.Path, "/v2/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		body, err := json.Marshal(manifest)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		sum := sha256.Sum256(body)
+
+		w.Header().Set("Content-Type", manifest.MediaType)
+		w.Header().Set("Docker-Content-Digest", "sha256:"+hex.EncodeToString(sum[:]))
+		w.Write(body)
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+// host returns the host of the registry, to be used as the registry of image
+// URLs.
+func (r *testRegistry) host() string {
+	return strings.TrimPrefix(r.URL, "https://")
+}
+
+// client returns a Client which uses the stub registry for distribution API
+// requests.
+func (r *testRegistry) client() *Client {
+	latencies := newLatencyTracker(r.Server.Client().Transport, nil)
+	return &Client{
+		fallback:  new(fakeClient),
+		oci:       oci.New(oci.Options{Transport: latencies}),
+		latencies: latencies,
+	}
+}
+
+// addImage will add an image manifest with the given layers.
+func (r *testRegistry) addImage(repo, reference string, layers ...string) *oci.Manifest {
+	manifest := &oci.Manifest{
+		SchemaVersion: 2,
+		MediaType:     oci.MediaTypeOCIManifest,
+	}
+	for _, layer := range layers {
+		manifest.Layers = append(manifest.Layers, oci.Descriptor{Digest: layer})
+	}
+
+	r.manifests[repo+"/manifests/"+reference] = manifest
+	return manifest
+}
+
+// addIndex will add a manifest list referencing the given image digests for
+// each platform.
+func (r *testRegistry) addIndex(repo, reference string, platforms map[string]string) *oci.Manifest {
+	manifest := &oci.Manifest{
+		SchemaVersion: 2,
+		MediaType:     oci.MediaTypeOCIIndex,
+	}
+	for platform, digest := range platforms {
+		split := strings.Split(platform, "/")
+		desc := oci.Descriptor{
+			MediaType: oci.MediaTypeOCIManifest,
+			Digest:    digest,
+			Platform:  &oci.Platform{OS: split[0], Architecture: split[1]},
+		}
+		if len(split) > 2 {
+			desc.Platform.Variant = split[2]
+		}
+		manifest.Manifests = append(manifest.Manifests, desc)
+	}
+
+	r.manifests[repo+"/manifests/"+reference] = manifest
+	return manifest
+}
+
+// addBlob will add a blob with the given content, returning its digest.
+func (r *testRegistry) addBlob(repo string, content []byte) string {
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	r.blobs[repo+"/blobs/"+digest] = content
+	return digest
+}
+
+// manifestDigest returns the digest of the manifest, as served by the
+// registry.
+func manifestDigest(t *testing.T, manifest *oci.Manifest) string {
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
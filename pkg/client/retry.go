@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// retryMaxBackoff is the longest time waited between attempts, including
+	// waiting for a Retry-After header. Responses asking to retry after longer
+	// than this are returned without retrying.
+	retryMaxBackoff = time.Second * 2
+)
+
+var (
+	// retrySleep waits for the duration, returning early with an error if the
+	// context is done.
+	retrySleep = func(ctx context.Context, d time.Duration) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	}
+)
+
+// retryTransport is a http.RoundTripper which retries requests failing with a
+// network error or a transient status code, with exponential backoff and
+// jitter. Retry-After headers of 429 and 503 responses are honoured.
+type retryTransport struct {
+	next http.RoundTripper
+
+	// maxAttempts is the total number of attempts of a request.
+	maxAttempts int
+
+	// backoff is the base wait before the second attempt, doubled for each
+	// following attempt.
+	backoff time.Duration
+}
+
+// newRetryTransport returns next if maxAttempts is less than two, otherwise a
+// transport retrying requests up to a total of maxAttempts.
+func newRetryTransport(next http.RoundTripper, maxAttempts int, backoff time.Duration) http.RoundTripper {
+	if maxAttempts < 2 {
+		return next
+	}
+
+	return &retryTransport{
+		next:        next,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+func (r *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests with a body can only be retried if it can be read again.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return r.next.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := r.next.RoundTrip(req)
+		if attempt == r.maxAttempts || !retryable(resp, err) {
+			return resp, err
+		}
+
+		wait, ok := r.wait(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+
+		attrs := []attribute.KeyValue{
+			attribute.String("http.host", req.URL.Host),
+			attribute.Int("http.attempt", attempt+1),
+			attribute.String("http.retry_wait", wait.String()),
+		}
+		if resp != nil {
+			attrs = append(attrs, attribute.Int("http.status_code", resp.StatusCode))
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		trace.SpanFromContext(req.Context()).AddEvent(retryEventName, trace.WithAttributes(attrs...))
+
+		if err := retrySleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// wait returns the time to wait before the attempt following the given
+// attempt. Returns false if the response asks to retry after longer than
+// retryMaxBackoff.
+func (r *retryTransport) wait(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if after, ok := retryAfter(resp); ok {
+			return after, after <= retryMaxBackoff
+		}
+	}
+
+	backoff := r.backoff << uint(attempt-1)
+	if backoff <= 0 || backoff > retryMaxBackoff {
+		backoff = retryMaxBackoff
+	}
+
+	// Full jitter, so that clients backing off together don't retry together.
+	return time.Duration(rand.Int63n(int64(backoff)) + 1), true
+}
+
+// retryable returns true if the request failed with a network error, or a
+// status code likely to succeed on retry.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !isContextError(err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter returns the wait of the Retry-After header of 429 and 503
+// responses, given in either seconds or as a HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	header := resp.Header.Get("Retry-After")
+	if len(header) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var waits []time.Duration
+	retrySleepOrig := retrySleep
+	defer func() { retrySleep = retrySleepOrig }()
+	retrySleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	tests := map[string]struct {
+		statuses    []int
+		retryAfter  string
+		method      string
+		expStatus   int
+		expAttempts int32
+		expWaits    []time.Duration
+	}{
+		"success should not be retried": {
+			statuses:    []int{http.StatusOK},
+			expStatus:   http.StatusOK,
+			expAttempts: 1,
+		},
+		"not found should not be retried": {
+			statuses:    []int{http.StatusNotFound, http.StatusOK},
+			expStatus:   http.StatusNotFound,
+			expAttempts: 1,
+		},
+		"transient errors should be retried until success": {
+			statuses:    []int{http.StatusBadGateway, http.StatusInternalServerError, http.StatusOK},
+			expStatus:   http.StatusOK,
+			expAttempts: 3,
+		},
+		"attempts should be limited": {
+			statuses:    []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			expStatus:   http.StatusServiceUnavailable,
+			expAttempts: 3,
+		},
+		"retry after should be honoured": {
+			statuses:    []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:  "1",
+			expStatus:   http.StatusOK,
+			expAttempts: 2,
+			expWaits:    []time.Duration{time.Second},
+		},
+		"long retry after should not be retried": {
+			statuses:    []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:  "60",
+			expStatus:   http.StatusTooManyRequests,
+			expAttempts: 1,
+		},
+		"requests with a body should be retried": {
+			statuses:    []int{http.StatusBadGateway, http.StatusOK},
+			method:      http.MethodPost,
+			expStatus:   http.StatusOK,
+			expAttempts: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			waits = nil
+
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := atomic.AddInt32(&attempts, 1)
+				if r.Method == http.MethodPost {
+					body := make([]byte, 4)
+					if n, _ := r.Body.Read(body); string(body[:n]) != "body" {
+						t.Errorf("unexpected body of attempt %d: %q", attempt, body[:n])
+					}
+				}
+				if len(test.retryAfter) > 0 {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+				w.WriteHeader(test.statuses[attempt-1])
+			}))
+			defer server.Close()
+
+			method := test.method
+			if len(method) == 0 {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, server.URL, nil)
+			if method == http.MethodPost {
+				req, err = http.NewRequest(method, server.URL, strings.NewReader("body"))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			transport := newRetryTransport(http.DefaultTransport, 3, time.Millisecond*100)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != test.expStatus {
+				t.Errorf("unexpected status, exp=%d got=%d", test.expStatus, resp.StatusCode)
+			}
+			if attempts != test.expAttempts {
+				t.Errorf("unexpected attempts, exp=%d got=%d", test.expAttempts, attempts)
+			}
+			if test.expWaits != nil && !durationsEqual(waits, test.expWaits) {
+				t.Errorf("unexpected waits, exp=%v got=%v", test.expWaits, waits)
+			}
+			for i, wait := range waits {
+				if test.expWaits == nil && (wait <= 0 || wait > time.Millisecond*100<<uint(i)) {
+					t.Errorf("unexpected backoff of attempt %d: %s", i+1, wait)
+				}
+			}
+		})
+	}
+}
+
+func durationsEqual(a, b []time.Duration) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
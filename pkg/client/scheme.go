@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+const (
+	// Defaults of SchemeOptions.
+	defaultSchemeSampleSize = 100
+	defaultSchemePages      = 5
+)
+
+var (
+	// calVerRegex matches calendar versions, starting with a full year.
+	// e.g. 2020.06.01, 2020-06-01, v2020.6
+	calVerRegex = regexp.MustCompile(`^v?[12][0-9]{3}[.\-_](?:0?[1-9]|1[0-2])(?:[.\-_][0-9]+)*$`)
+
+	// commitRegex matches tags of git commit hashes.
+	commitRegex = regexp.MustCompile(`^(?:sha-)?[0-9a-f]{7,40}$`)
+
+	// floatingTags are tag names which are moved between releases.
+	floatingTags = map[string]bool{
+		"latest": true, "stable": true, "edge": true, "main": true,
+		"master": true, "nightly": true, "dev": true, "develop": true,
+		"canary": true, "beta": true, "alpha": true, "next": true,
+	}
+)
+
+// TagScheme is the tagging scheme used by an image repository.
+type TagScheme int
+
+const (
+	SchemeUnknown TagScheme = iota
+	SchemeSemver
+	SchemeCalVer
+	SchemeCommit
+	SchemeFloating
+)
+
+func (s TagScheme) String() string {
+	switch s {
+	case SchemeSemver:
+		return "Semver"
+	case SchemeCalVer:
+		return "CalVer"
+	case SchemeCommit:
+		return "Commit"
+	case SchemeFloating:
+		return "Floating"
+	default:
+		return "Unknown"
+	}
+}
+
+// PagedClient is an ImageClient which is able to list pages of tags, without
+// the cost of listing every tag.
+type PagedClient interface {
+	ImageClient
+
+	// TagPages returns the tags of the image URL as pages, up to maxPages.
+	// Tags may only have their name set.
+	TagPages(ctx context.Context, imageURL string, maxPages int) ([][]api.ImageTag, error)
+}
+
+// SchemeOptions configure the sampling of tags used to detect a tagging
+// scheme.
+type SchemeOptions struct {
+	// SampleSize is the number of tags sampled, spread evenly across the
+	// listed tags. Defaults to 100.
+	SampleSize int
+
+	// Pages is the number of pages listed from registries which paginate
+	// tags. Defaults to 5.
+	Pages int
+}
+
+// DetectTaggingScheme will classify the tagging scheme of the image URL by
+// the majority scheme of a sample of its tags. For registries which paginate
+// tags, the sample is spread across the first pages, so that repositories
+// with a first page dominated by floating tags are classified by their
+// release tags.
+func (c *Client) DetectTaggingScheme(ctx context.Context, imageURL string, opts SchemeOptions) (TagScheme, error) {
+	if opts.SampleSize <= 0 {
+		opts.SampleSize = defaultSchemeSampleSize
+	}
+	if opts.Pages <= 0 {
+		opts.Pages = defaultSchemePages
+	}
+
+	var tags []api.ImageTag
+	if client, ok := c.fromImageURL(imageURL).(PagedClient); ok {
+		pages, err := client.TagPages(ctx, imageURL, opts.Pages)
+		if err != nil {
+			return SchemeUnknown, c.redactor.Error(err)
+		}
+
+		for _, page := range pages {
+			tags = append(tags, page...)
+		}
+	} else {
+		var err error
+		tags, err = c.Tags(ctx, imageURL)
+		if err != nil {
+			return SchemeUnknown, err
+		}
+	}
+
+	counts := make(map[TagScheme]int)
+	for _, tag := range sampleTags(tags, opts.SampleSize) {
+		counts[classifyTag(tag.Tag)]++
+	}
+
+	scheme, max := SchemeUnknown, 0
+	for _, s := range []TagScheme{SchemeSemver, SchemeCalVer, SchemeCommit, SchemeFloating, SchemeUnknown} {
+		if counts[s] > max {
+			scheme, max = s, counts[s]
+		}
+	}
+
+	return scheme, nil
+}
+
+// sampleTags returns up to size tags, evenly spread across the given tags.
+// Cosign artifacts are not sampled.
+func sampleTags(tags []api.ImageTag, size int) []api.ImageTag {
+	var candidates []api.ImageTag
+	for _, tag := range tags {
+		if !IsCosignArtifact(tag.Tag) {
+			candidates = append(candidates, tag)
+		}
+	}
+
+	if len(candidates) <= size {
+		return candidates
+	}
+
+	sample := make([]api.ImageTag, size)
+	for i := range sample {
+		sample[i] = candidates[i*len(candidates)/size]
+	}
+
+	return sample
+}
+
+// classifyTag returns the tagging scheme of a single tag.
+func classifyTag(tag string) TagScheme {
+	switch {
+	case floatingTags[strings.ToLower(tag)]:
+		return SchemeFloating
+	case calVerRegex.MatchString(tag):
+		return SchemeCalVer
+	case commitRegex.MatchString(tag) && strings.ContainsAny(tag, "abcdef"):
+		return SchemeCommit
+	case semver.Parse(tag).IsVersion():
+		return SchemeSemver
+	default:
+		return SchemeUnknown
+	}
+}
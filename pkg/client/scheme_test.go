@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// fakePagedClient is a PagedClient which returns static pages of tags.
+type fakePagedClient struct {
+	fakeClient
+	pages [][]api.ImageTag
+}
+
+func (f *fakePagedClient) TagPages(_ context.Context, _ string, maxPages int) ([][]api.ImageTag, error) {
+	if maxPages > len(f.pages) {
+		maxPages = len(f.pages)
+	}
+	return f.pages[:maxPages], nil
+}
+
+func TestDetectTaggingScheme(t *testing.T) {
+	var semverPages [][]api.ImageTag
+	for page := 0; page < 2; page++ {
+		var names []string
+		for i := 0; i < 10; i++ {
+			names = append(names, fmt.Sprintf("v1.%d.%d", page, i))
+		}
+		semverPages = append(semverPages, tagsFromNames(names...))
+	}
+
+	floatingPage := tagsFromNames("latest", "stable", "edge", "main", "nightly", "dev", "canary", "next")
+
+	tests := map[string]struct {
+		client    ImageClient
+		opts      SchemeOptions
+		expScheme TagScheme
+	}{
+		"first page of floating tags only should be floating": {
+			client:    &fakePagedClient{pages: append([][]api.ImageTag{floatingPage}, semverPages...)},
+			opts:      SchemeOptions{Pages: 1},
+			expScheme: SchemeFloating,
+		},
+		"sampling across pages should classify by the release tags": {
+			client:    &fakePagedClient{pages: append([][]api.ImageTag{floatingPage}, semverPages...)},
+			expScheme: SchemeSemver,
+		},
+		"small samples should be spread across pages": {
+			client:    &fakePagedClient{pages: append([][]api.ImageTag{floatingPage}, semverPages...)},
+			opts:      SchemeOptions{SampleSize: 5},
+			expScheme: SchemeSemver,
+		},
+		"clients without pages should sample all tags": {
+			client: &fakeClient{tags: tagsFromNames(
+				"2020.06.01", "2020.07.01", "2020-08-01", "latest", "sha256-abc.sig",
+			)},
+			expScheme: SchemeCalVer,
+		},
+		"commit tags should be commit": {
+			client:    &fakeClient{tags: tagsFromNames("3f2a1bc", "sha-9d8e7f6a", "a1b2c3d4e5f6", "latest")},
+			expScheme: SchemeCommit,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &Client{fallback: test.client}
+
+			scheme, err := client.DetectTaggingScheme(context.TODO(), "image", test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if scheme != test.expScheme {
+				t.Errorf("unexpected tagging scheme, exp=%s got=%s", test.expScheme, scheme)
+			}
+		})
+	}
+}
+
+func TestClassifyTag(t *testing.T) {
+	tests := map[string]TagScheme{
+		"v1.2.3":        SchemeSemver,
+		"10.1.0":        SchemeSemver,
+		"1.2.3-alpine":  SchemeSemver,
+		"2020.06.01":    SchemeCalVer,
+		"v2021.1":       SchemeCalVer,
+		"latest":        SchemeFloating,
+		"STABLE":        SchemeFloating,
+		"3f2a1bc":       SchemeCommit,
+		"1234567":       SchemeSemver,
+		"feature-login": SchemeUnknown,
+	}
+
+	for tag, expScheme := range tests {
+		if scheme := classifyTag(tag); scheme != expScheme {
+			t.Errorf("unexpected scheme for %q, exp=%s got=%s", tag, expScheme, scheme)
+		}
+	}
+}
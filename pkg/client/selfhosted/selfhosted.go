@@ -0,0 +1,41 @@
+package selfhosted
+
+import (
+	"context"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/oci"
+)
+
+// Options used to configure the distribution API client of self hosted
+// registries.
+type Options struct {
+	OCI oci.Options
+}
+
+// Client is a thin wrapper over the OCI Distribution API client, used for
+// any registry host without a dedicated client, such as Distribution or Zot.
+type Client struct {
+	oci *oci.Client
+}
+
+func New(opts Options) *Client {
+	return &Client{oci: oci.New(opts.OCI)}
+}
+
+// IsClient returns true if the image URL has a registry host, other than
+// Docker Hub.
+func (c *Client) IsClient(imageURL string) bool {
+	return api.ParseImageRef(imageURL).Registry != api.DefaultRegistry
+}
+
+func (c *Client) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	ref := api.ParseImageRef(imageURL)
+	return c.oci.ImageTags(ctx, ref.Registry, ref.Repository)
+}
+
+// Repositories will return the repositories of the registry host from its
+// catalog.
+func (c *Client) Repositories(ctx context.Context, host string) ([]string, error) {
+	return c.oci.Catalog(ctx, host)
+}
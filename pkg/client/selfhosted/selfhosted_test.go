@@ -0,0 +1,24 @@
+package selfhosted
+
+import (
+	"testing"
+)
+
+func TestIsClient(t *testing.T) {
+	client := New(Options{})
+
+	for imageURL, exp := range map[string]bool{
+		"registry.corp/jetstack/app":      true,
+		"registry.corp:5000/jetstack/app": true,
+		"localhost/jetstack/app":          true,
+		"zot.corp/app":                    true,
+		"docker.io/jetstack/app":          false,
+		"index.docker.io/library/nginx":   false,
+		"jetstack/app":                    false,
+		"nginx":                           false,
+	} {
+		if got := client.IsClient(imageURL); got != exp {
+			t.Errorf("unexpected IsClient for %q, exp=%t got=%t", imageURL, exp, got)
+		}
+	}
+}
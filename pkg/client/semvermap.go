@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// TagsBySemver will return the semver tags of the image URL keyed by their
+// normalized version, for fast lookup of whether a version is available.
+// Versions are normalized to major.minor.patch, with missing parts as zero,
+// followed by any pre-release suffix. The v prefix and build metadata, after
+// a '+', are dropped. e.g. v1.2 -> 1.2.0, v1.2.3-rc.0+abc -> 1.2.3-rc.0
+//
+// When tags normalize to the same version, the tag already in normalized
+// form wins, otherwise the last tag listed wins. Non-semver tags are omitted.
+func (c *Client) TagsBySemver(ctx context.Context, imageURL string) (map[string]api.ImageTag, error) {
+	tags, err := c.Tags(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]api.ImageTag)
+	for _, tag := range tags {
+		if IsCosignArtifact(tag.Tag) {
+			continue
+		}
+
+		key, ok := NormalizeSemver(tag.Tag)
+		if !ok {
+			continue
+		}
+
+		if existing, ok := versions[key]; ok && existing.Tag == key && tag.Tag != key {
+			continue
+		}
+
+		versions[key] = tag
+	}
+
+	return versions, nil
+}
+
+// NormalizeSemver returns the normalized version of the tag used as the key
+// of TagsBySemver. Returns false if the tag is not a semver version.
+func NormalizeSemver(tag string) (string, bool) {
+	v := semver.Parse(tag)
+	if !v.IsVersion() {
+		return "", false
+	}
+
+	preRelease := v.MetaData()
+	if i := strings.Index(preRelease, "+"); i > -1 {
+		preRelease = preRelease[:i]
+	}
+
+	return fmt.Sprintf("%d.%d.%d%s", v.Major(), v.Minor(), v.Patch(), preRelease), true
+}
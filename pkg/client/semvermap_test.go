@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTagsBySemver(t *testing.T) {
+	tests := map[string]struct {
+		tags    []string
+		expTags map[string]string
+	}{
+		"non-semver tags should be omitted": {
+			tags:    []string{"latest", "main", "v1.0.0", "sha256-abc.sig"},
+			expTags: map[string]string{"1.0.0": "v1.0.0"},
+		},
+		"versions should be normalized": {
+			tags: []string{"v1.2", "1.3.0+build.5", "v1.4.0-rc.0+abc"},
+			expTags: map[string]string{
+				"1.2.0":      "v1.2",
+				"1.3.0":      "1.3.0+build.5",
+				"1.4.0-rc.0": "v1.4.0-rc.0+abc",
+			},
+		},
+		"unprefixed tag should win when listed last": {
+			tags:    []string{"v1.2.3", "1.2.3"},
+			expTags: map[string]string{"1.2.3": "1.2.3"},
+		},
+		"unprefixed tag should win when listed first": {
+			tags:    []string{"1.2.3", "v1.2.3"},
+			expTags: map[string]string{"1.2.3": "1.2.3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			versions, err := newFakeClient(tagsFromNames(test.tags...)).TagsBySemver(context.TODO(), "image")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string)
+			for key, tag := range versions {
+				got[key] = tag.Tag
+			}
+
+			if !reflect.DeepEqual(got, test.expTags) {
+				t.Errorf("unexpected tags by semver, exp=%v got=%v", test.expTags, got)
+			}
+		})
+	}
+}
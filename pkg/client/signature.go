@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+const (
+	// signatureTagSuffix is the suffix of tags cosign uses to store the
+	// signature of an image digest in the same repository.
+	// e.g. sha256-<hex>.sig
+	signatureTagSuffix = ".sig"
+)
+
+// cosignArtifactSuffixes are the tag suffixes used by cosign to attach
+// artifacts to a digest. These are not considered as image versions.
+var cosignArtifactSuffixes = []string{signatureTagSuffix, ".att", ".sbom"}
+
+// LatestIsSigned will return whether the newest tag of the given image URL has
+// an attached signature, along with that tag. Returns ErrUnsupported if the
+// registry doesn't expose digests, which means signatures can't be resolved.
+func (c *Client) LatestIsSigned(ctx context.Context, imageURL string) (bool, *api.ImageTag, error) {
+	tags, err := c.Tags(ctx, imageURL)
+	if err != nil {
+		return false, nil, err
+	}
+
+	latest := newestTag(tags)
+	if latest == nil {
+		return false, nil, fmt.Errorf("no tags found for given image URL: %q", imageURL)
+	}
+
+	if len(latest.SHA) == 0 {
+		return false, latest, ErrUnsupported
+	}
+
+	return SignedDigests(tags)[SignatureDigest(*latest)], latest, nil
+}
+
+// SignatureDigest returns the digest signatures of the tag are attached to.
+// cosign signs the manifest list of multi-arch images, so this is the tag's
+// index digest if the registry reports one, or else its digest.
+func SignatureDigest(tag api.ImageTag) string {
+	if len(tag.IndexSHA) > 0 {
+		return tag.IndexSHA
+	}
+
+	return tag.SHA
+}
+
+// newestTag will return the tag with the latest timestamp, ignoring cosign
+// artifacts.
+func newestTag(tags []api.ImageTag) *api.ImageTag {
+	var newest *api.ImageTag
+	for i := range tags {
+		if IsCosignArtifact(tags[i].Tag) {
+			continue
+		}
+
+		if newest == nil || tags[i].Timestamp.After(newest.Timestamp) {
+			newest = &tags[i]
+		}
+	}
+
+	return newest
+}
+
+// SignedDigests returns the set of digests which have a signature tag present
+// in the given tags.
+func SignedDigests(tags []api.ImageTag) map[string]bool {
+	signed := make(map[string]bool)
+	for _, tag := range tags {
+		if !IsCosignArtifact(tag.Tag) || !strings.HasSuffix(tag.Tag, signatureTagSuffix) {
+			continue
+		}
+
+		digest := strings.TrimSuffix(tag.Tag, signatureTagSuffix)
+		signed[strings.Replace(digest, "-", ":", 1)] = true
+	}
+
+	return signed
+}
+
+// IsCosignArtifact returns true if the tag is one used by cosign to attach
+// artifacts to a digest.
+func IsCosignArtifact(tag string) bool {
+	if !strings.HasPrefix(tag, "sha256-") && !strings.HasPrefix(tag, "sha512-") {
+		return false
+	}
+
+	for _, suffix := range cosignArtifactSuffixes {
+		if strings.HasSuffix(tag, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestLatestIsSigned(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		tags      []api.ImageTag
+		expSigned bool
+		expTag    string
+		expErr    error
+	}{
+		"latest tag with signature should be signed": {
+			tags: []api.ImageTag{
+				{Tag: "v0.1.0", SHA: "sha256:aaa", Timestamp: now.Add(-time.Hour)},
+				{Tag: "v0.2.0", SHA: "sha256:bbb", Timestamp: now},
+				{Tag: "sha256-bbb.sig", SHA: "sha256:ccc", Timestamp: now.Add(time.Minute)},
+			},
+			expSigned: true,
+			expTag:    "v0.2.0",
+		},
+		"latest tag without signature should not be signed": {
+			tags: []api.ImageTag{
+				{Tag: "v0.1.0", SHA: "sha256:aaa", Timestamp: now.Add(-time.Hour)},
+				{Tag: "sha256-aaa.sig", SHA: "sha256:ccc", Timestamp: now.Add(-time.Minute)},
+				{Tag: "v0.2.0", SHA: "sha256:bbb", Timestamp: now},
+			},
+			expSigned: false,
+			expTag:    "v0.2.0",
+		},
+		"multi-arch latest tag with signature of its index should be signed": {
+			tags: []api.ImageTag{
+				{Tag: "v0.1.0", SHA: "sha256:aaa", Timestamp: now.Add(-time.Hour)},
+				{Tag: "v0.2.0", SHA: "sha256:bbb", IndexSHA: "sha256:ddd", Architecture: "amd64", Timestamp: now},
+				{Tag: "v0.2.0", SHA: "sha256:eee", IndexSHA: "sha256:ddd", Architecture: "arm64", Timestamp: now},
+				{Tag: "sha256-ddd.sig", SHA: "sha256:ccc", Timestamp: now.Add(time.Minute)},
+			},
+			expSigned: true,
+			expTag:    "v0.2.0",
+		},
+		"attestation should not count as signature": {
+			tags: []api.ImageTag{
+				{Tag: "v0.2.0", SHA: "sha256:bbb", Timestamp: now},
+				{Tag: "sha256-bbb.att", SHA: "sha256:ccc", Timestamp: now.Add(time.Minute)},
+			},
+			expSigned: false,
+			expTag:    "v0.2.0",
+		},
+		"latest tag without digest should be unsupported": {
+			tags: []api.ImageTag{
+				{Tag: "v0.2.0", Timestamp: now},
+			},
+			expSigned: false,
+			expTag:    "v0.2.0",
+			expErr:    ErrUnsupported,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			signed, tag, err := newFakeClient(test.tags).LatestIsSigned(context.TODO(), "image")
+			if err != test.expErr {
+				t.Fatalf("unexpected error, exp=%v got=%v", test.expErr, err)
+			}
+
+			if signed != test.expSigned {
+				t.Errorf("unexpected signed, exp=%t got=%t", test.expSigned, signed)
+			}
+
+			if tag == nil || tag.Tag != test.expTag {
+				t.Errorf("unexpected latest tag, exp=%s got=%v", test.expTag, tag)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+const (
+	// cosignSignatureAnnotation is the annotation of a signature layer holding
+	// the base64 encoded signature of the layer's payload.
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+// simpleSigningPayload is the payload signed by cosign, identifying the
+// signed image manifest digest.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// TagsSignedBy will return the tags of the image URL which have a cosign
+// signature that verifies against the public key at keyRef, a PEM encoded
+// public key file. Returns ErrUnsupported if the registry doesn't expose
+// digests, or the signatures can't be fetched.
+func (c *Client) TagsSignedBy(ctx context.Context, imageURL, keyRef string) ([]api.ImageTag, error) {
+	key, err := loadPublicKey(keyRef)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := c.Tags(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	hasDigests := false
+	for _, tag := range tags {
+		if len(tag.SHA) > 0 {
+			hasDigests = true
+			break
+		}
+	}
+	if !hasDigests {
+		return nil, ErrUnsupported
+	}
+
+	ref := api.ParseImageRef(c.mirrors.rewrite(imageURL))
+
+	var signed []api.ImageTag
+	verified := make(map[string]bool)
+	for digest := range SignedDigests(tags) {
+		ok, err := c.verifySignature(ctx, ref, digest, key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupported, err)
+		}
+		verified[digest] = ok
+	}
+
+	for _, tag := range tags {
+		if !IsCosignArtifact(tag.Tag) && verified[SignatureDigest(tag)] {
+			signed = append(signed, tag)
+		}
+	}
+
+	return signed, nil
+}
+
+// verifySignature returns true if any signature of the digest verifies
+// against the public key, and signs that digest.
+func (c *Client) verifySignature(ctx context.Context, ref api.ImageRef, digest string, key crypto.PublicKey) (bool, error) {
+	sigTag := strings.Replace(digest, ":", "-", 1) + signatureTagSuffix
+
+	manifest, err := c.oci.Manifest(ctx, ref.Registry, ref.Repository, sigTag)
+	if err != nil {
+		return false, fmt.Errorf("failed to get signature %q: %s", sigTag, err)
+	}
+
+	for _, layer := range manifest.Layers {
+		sig, err := base64.StdEncoding.DecodeString(layer.Annotations[cosignSignatureAnnotation])
+		if err != nil || len(sig) == 0 {
+			continue
+		}
+
+		payload, err := c.oci.Blob(ctx, ref.Registry, ref.Repository, layer.Digest)
+		if err != nil {
+			return false, fmt.Errorf("failed to get signature payload %q: %s", layer.Digest, err)
+		}
+
+		var simpleSigning simpleSigningPayload
+		if err := json.Unmarshal(payload, &simpleSigning); err != nil ||
+			simpleSigning.Critical.Image.DockerManifestDigest != digest {
+			continue
+		}
+
+		if verifyPayload(key, payload, sig) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// verifyPayload returns true if the signature of the payload verifies
+// against the public key.
+func verifyPayload(key crypto.PublicKey, payload, sig []byte) bool {
+	sum := sha256.Sum256(payload)
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, sum[:], sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, payload, sig)
+	default:
+		return false
+	}
+}
+
+// loadPublicKey will read the PEM encoded public key at the given path.
+func loadPublicKey(keyRef string) (crypto.PublicKey, error) {
+	data, err := ioutil.ReadFile(keyRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key %q: %s", keyRef, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found in public key: " + keyRef)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %q: %s", keyRef, err)
+	}
+
+	return key, nil
+}
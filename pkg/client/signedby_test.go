@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/oci"
+)
+
+// addSignature will add a cosign signature of the digest, signed by the key.
+func (r *testRegistry) addSignature(t *testing.T, repo, digest string, key *ecdsa.PrivateKey) {
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"` + repo + `"},` +
+		`"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"}}`)
+
+	sum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := r.addImage(repo, strings.Replace(digest, ":", "-", 1)+".sig")
+	manifest.Layers = []oci.Descriptor{{
+		MediaType:   "application/vnd.dev.cosign.simplesigning.v1+json",
+		Digest:      r.addBlob(repo, payload),
+		Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	}}
+}
+
+// writePublicKey will write the PEM encoded public key of the key to dir,
+// returning its path.
+func writePublicKey(t *testing.T, dir string, key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "cosign.pub")
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestTagsSignedBy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-cosign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	trustedKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyRef := writePublicKey(t, dir, trustedKey)
+
+	registry := newTestRegistry(t)
+	registry.addSignature(t, "jetstack/app", "sha256:aaa", trustedKey)
+	registry.addSignature(t, "jetstack/app", "sha256:bbb", otherKey)
+
+	client := registry.client()
+	client.fallback = &fakeClient{tags: []api.ImageTag{
+		{Tag: "v0.1.0", SHA: "sha256:aaa"},
+		{Tag: "v0.2.0", SHA: "sha256:bbb"},
+		{Tag: "v0.3.0", SHA: "sha256:ccc"},
+		{Tag: "sha256-aaa.sig", SHA: "sha256:sig-aaa"},
+		{Tag: "sha256-bbb.sig", SHA: "sha256:sig-bbb"},
+	}}
+
+	tags, err := client.TagsSignedBy(context.TODO(), registry.host()+"/jetstack/app", keyRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{"v0.1.0"}
+	if got := tagNames(tags); !equalNames(exp, got) {
+		t.Errorf("unexpected signed tags, exp=%v got=%v", exp, got)
+	}
+}
+
+func TestTagsSignedByUnsupported(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-cosign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyRef := writePublicKey(t, dir, key)
+
+	tests := map[string]struct {
+		tags []api.ImageTag
+	}{
+		"tags without digests should be unsupported": {
+			tags: tagsFromNames("v0.1.0", "v0.2.0"),
+		},
+		"unreachable signatures should be unsupported": {
+			tags: []api.ImageTag{
+				{Tag: "v0.1.0", SHA: "sha256:aaa"},
+				{Tag: "sha256-aaa.sig", SHA: "sha256:sig-aaa"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := newTestRegistry(t)
+			client := registry.client()
+			client.fallback = &fakeClient{tags: test.tags}
+
+			_, err := client.TagsSignedBy(context.TODO(), registry.host()+"/jetstack/app", keyRef)
+			if !errors.Is(err, ErrUnsupported) {
+				t.Errorf("expected unsupported error, got=%v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SnapshotStore persists state between scans, such as tag history.
+type SnapshotStore interface {
+	// Load returns the data saved under the key, or nil if there is none.
+	Load(key string) ([]byte, error)
+
+	// Save will store the data under the key, replacing any existing data.
+	Save(key string, data []byte) error
+}
+
+// MemorySnapshotStore is a SnapshotStore held in memory, which is lost on
+// restart.
+type MemorySnapshotStore struct {
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{items: make(map[string][]byte)}
+}
+
+func (m *MemorySnapshotStore) Load(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.items[key], nil
+}
+
+func (m *MemorySnapshotStore) Save(key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[key] = data
+	return nil
+}
+
+// FileSnapshotStore is a SnapshotStore writing a file per key to a directory.
+type FileSnapshotStore struct {
+	dir string
+}
+
+func NewFileSnapshotStore(dir string) (*FileSnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory %q: %s", dir, err)
+	}
+
+	return &FileSnapshotStore{dir: dir}, nil
+}
+
+func (f *FileSnapshotStore) Load(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(f.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot %q: %s", key, err)
+	}
+
+	return data, nil
+}
+
+// Save will write the data to a temporary file, before renaming it over the
+// key's file so that partial writes are never loaded.
+func (f *FileSnapshotStore) Save(key string, data []byte) error {
+	tmp, err := ioutil.TempFile(f.dir, ".snapshot-")
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot %q: %s", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save snapshot %q: %s", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save snapshot %q: %s", key, err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path(key)); err != nil {
+		return fmt.Errorf("failed to save snapshot %q: %s", key, err)
+	}
+
+	return nil
+}
+
+// path returns the file path of the key, escaped so that keys containing
+// slashes, such as image URLs, are a single file.
+func (f *FileSnapshotStore) path(key string) string {
+	return filepath.Join(f.dir, url.PathEscape(key))
+}
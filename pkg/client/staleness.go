@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// Staleness is a classification of how far behind the newest release a tag
+// is.
+type Staleness int
+
+const (
+	// Unknown is returned when the tag can't be compared to the newest tag,
+	// such as a non-semver tag with a different digest to the newest tag.
+	Unknown Staleness = iota
+	Current
+	PatchBehind
+	MinorBehind
+	MajorBehind
+)
+
+func (s Staleness) String() string {
+	switch s {
+	case Current:
+		return "Current"
+	case PatchBehind:
+		return "PatchBehind"
+	case MinorBehind:
+		return "MinorBehind"
+	case MajorBehind:
+		return "MajorBehind"
+	default:
+		return "Unknown"
+	}
+}
+
+// Staleness will classify how far behind the newest release of the image URL
+// the current tag is, along with the newest tag. Semver tags are compared to
+// the highest version, ignoring tags with metadata unless the current tag has
+// metadata. Other tags are compared by digest to the tag with the latest
+// timestamp, and are either Current or Unknown.
+func (c *Client) Staleness(ctx context.Context, imageURL, currentTag string) (Staleness, *api.ImageTag, error) {
+	tags, err := c.Tags(ctx, imageURL)
+	if err != nil {
+		return Unknown, nil, err
+	}
+
+	currentV := semver.Parse(currentTag)
+	if !currentV.IsVersion() {
+		return stalenessByTimestamp(tags, currentTag, imageURL)
+	}
+
+	var (
+		newest  *api.ImageTag
+		newestV *semver.SemVer
+	)
+	for i := range tags {
+		v := semver.Parse(tags[i].Tag)
+		if !v.IsVersion() || IsCosignArtifact(tags[i].Tag) {
+			continue
+		}
+		if v.HasMetaData() && !currentV.HasMetaData() {
+			continue
+		}
+
+		if newest == nil || versionLess(newestV, v) {
+			newest, newestV = &tags[i], v
+		}
+	}
+
+	if newest == nil {
+		return Unknown, nil, fmt.Errorf("no version tags found for given image URL: %q", imageURL)
+	}
+
+	switch {
+	case !versionLess(currentV, newestV):
+		return Current, newest, nil
+	case currentV.Major() < newestV.Major():
+		return MajorBehind, newest, nil
+	case currentV.Minor() < newestV.Minor():
+		return MinorBehind, newest, nil
+	default:
+		return PatchBehind, newest, nil
+	}
+}
+
+// stalenessByTimestamp classifies a non-semver tag as Current if it has the
+// same digest as the tag with the latest timestamp.
+func stalenessByTimestamp(tags []api.ImageTag, currentTag, imageURL string) (Staleness, *api.ImageTag, error) {
+	newest := newestTag(tags)
+	if newest == nil {
+		return Unknown, nil, fmt.Errorf("no tags found for given image URL: %q", imageURL)
+	}
+
+	for _, tag := range tags {
+		if tag.Tag == currentTag && len(tag.SHA) > 0 && tag.SHA == newest.SHA {
+			return Current, newest, nil
+		}
+	}
+
+	return Unknown, newest, nil
+}
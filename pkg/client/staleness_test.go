@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestStaleness(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	semverTags := tagsFromNames("v1.2.3", "v1.2.4", "v1.3.0", "v2.0.0", "v2.0.1", "v2.1.0-rc.0", "sha256-abc.sig")
+	rollingTags := []api.ImageTag{
+		{Tag: "latest", SHA: "sha256:b", Timestamp: now},
+		{Tag: "main", SHA: "sha256:b", Timestamp: now},
+		{Tag: "old", SHA: "sha256:a", Timestamp: now.Add(-time.Hour)},
+	}
+
+	tests := map[string]struct {
+		tags         []api.ImageTag
+		currentTag   string
+		expStaleness Staleness
+		expNewest    string
+	}{
+		"newest version should be current": {
+			tags:         semverTags,
+			currentTag:   "v2.0.1",
+			expStaleness: Current,
+			expNewest:    "v2.0.1",
+		},
+		"patch behind should be patch behind": {
+			tags:         semverTags,
+			currentTag:   "v2.0.0",
+			expStaleness: PatchBehind,
+			expNewest:    "v2.0.1",
+		},
+		"minor behind should be minor behind": {
+			tags:         tagsFromNames("v1.2.3", "v1.2.4", "v1.3.0"),
+			currentTag:   "v1.2.4",
+			expStaleness: MinorBehind,
+			expNewest:    "v1.3.0",
+		},
+		"major behind should be major behind": {
+			tags:         semverTags,
+			currentTag:   "v1.3.0",
+			expStaleness: MajorBehind,
+			expNewest:    "v2.0.1",
+		},
+		"pre-release should be compared to pre-releases": {
+			tags:         semverTags,
+			currentTag:   "v2.0.1-rc.0",
+			expStaleness: MinorBehind,
+			expNewest:    "v2.1.0-rc.0",
+		},
+		"non-semver tag with newest digest should be current": {
+			tags:         rollingTags,
+			currentTag:   "main",
+			expStaleness: Current,
+			expNewest:    "latest",
+		},
+		"non-semver tag with old digest should be unknown": {
+			tags:         rollingTags,
+			currentTag:   "old",
+			expStaleness: Unknown,
+			expNewest:    "latest",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			staleness, newest, err := newFakeClient(test.tags).Staleness(context.TODO(), "image", test.currentTag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if staleness != test.expStaleness {
+				t.Errorf("unexpected staleness, exp=%s got=%s", test.expStaleness, staleness)
+			}
+			if newest == nil || newest.Tag != test.expNewest {
+				t.Errorf("unexpected newest tag, exp=%s got=%+v", test.expNewest, newest)
+			}
+		})
+	}
+}
+
+func TestStalenessNoVersions(t *testing.T) {
+	staleness, _, err := newFakeClient(tagsFromNames("latest")).Staleness(context.TODO(), "image", "v1.0.0")
+	if err == nil {
+		t.Error("expected error when no version tags exist")
+	}
+	if staleness != Unknown {
+		t.Errorf("unexpected staleness, exp=%s got=%s", Unknown, staleness)
+	}
+}
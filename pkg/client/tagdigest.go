@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// TagDigest will return the current digest of the given image URL and tag,
+// using a manifest HEAD request so that neither the tag list nor the manifest
+// are downloaded.
+func (c *Client) TagDigest(ctx context.Context, imageURL, tag string) (string, error) {
+	lookupURL := c.mirrors.rewrite(imageURL)
+	ref := api.ParseImageRef(lookupURL)
+
+	ctx, err := c.dockerConfig.withCredentials(ctx, lookupURL)
+	if err != nil {
+		return "", c.redactor.Error(err)
+	}
+
+	if err := c.rateLimits.wait(ctx, ref.Registry); err != nil {
+		return "", err
+	}
+
+	digest, err := c.oci.Digest(ctx, ref.Registry, ref.Repository, tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to get digest for %q: %s", imageURL+":"+tag, err)
+	}
+	if len(digest) == 0 {
+		return "", fmt.Errorf("no digest returned for %q", imageURL+":"+tag)
+	}
+
+	return digest, nil
+}
+
+// PlatformDigest will resolve the given digest of the image URL to the digest
+// of the image manifest built for the platform. Digests of image manifests
+// are returned as is. Fields of the platform which are unset match any
+// manifest.
+func (c *Client) PlatformDigest(ctx context.Context, imageURL, digest string, platform *api.Platform) (string, error) {
+	lookupURL := c.mirrors.rewrite(imageURL)
+	ref := api.ParseImageRef(lookupURL)
+
+	ctx, err := c.dockerConfig.withCredentials(ctx, lookupURL)
+	if err != nil {
+		return "", c.redactor.Error(err)
+	}
+
+	if err := c.rateLimits.wait(ctx, ref.Registry); err != nil {
+		return "", err
+	}
+
+	manifest, err := c.oci.Manifest(ctx, ref.Registry, ref.Repository, digest)
+	if err != nil {
+		return "", fmt.Errorf("failed to get manifest for %q: %s", imageURL+"@"+digest, err)
+	}
+
+	if !manifest.IsIndex() {
+		return digest, nil
+	}
+
+	opts := &api.Options{Platform: platform}
+	for _, desc := range manifest.Manifests {
+		if desc.Platform == nil {
+			continue
+		}
+
+		if opts.MatchesPlatform(&api.ImageTag{
+			OS:           desc.Platform.OS,
+			Architecture: desc.Platform.Architecture,
+			Variant:      desc.Platform.Variant,
+		}) {
+			return desc.Digest, nil
+		}
+	}
+
+	return "", fmt.Errorf("no manifest found for platform %s/%s in %q",
+		platform.OS, platform.Architecture, imageURL+"@"+digest)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestTagDigest(t *testing.T) {
+	registry := newTestRegistry(t)
+	image := registry.addImage("jetstack/app", "latest", "sha256:base", "sha256:app")
+	index := registry.addIndex("jetstack/app", "multi-arch", map[string]string{
+		"linux/amd64": "sha256:amd64",
+		"linux/arm64": "sha256:arm64",
+	})
+
+	tests := map[string]struct {
+		tag       string
+		expDigest string
+		expErr    bool
+	}{
+		"image tag should return the manifest digest": {
+			tag:       "latest",
+			expDigest: manifestDigest(t, image),
+		},
+		"manifest list tag should return the list digest": {
+			tag:       "multi-arch",
+			expDigest: manifestDigest(t, index),
+		},
+		"missing tag should error": {
+			tag:    "missing",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			digest, err := registry.client().TagDigest(context.TODO(),
+				registry.host()+"/jetstack/app", test.tag)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			if digest != test.expDigest {
+				t.Errorf("unexpected digest, exp=%s got=%s", test.expDigest, digest)
+			}
+		})
+	}
+}
+
+func TestPlatformDigest(t *testing.T) {
+	registry := newTestRegistry(t)
+	image := registry.addImage("jetstack/app", "single", "sha256:app")
+	index := registry.addIndex("jetstack/app", "multi-arch", map[string]string{
+		"linux/amd64":    "sha256:amd64",
+		"linux/arm64/v8": "sha256:arm64",
+	})
+
+	imageDigest, indexDigest := manifestDigest(t, image), manifestDigest(t, index)
+	registry.manifests["jetstack/app/manifests/"+imageDigest] = image
+	registry.manifests["jetstack/app/manifests/"+indexDigest] = index
+
+	tests := map[string]struct {
+		digest    string
+		platform  *api.Platform
+		expDigest string
+		expErr    bool
+	}{
+		"image manifest digest should be returned as is": {
+			digest:    imageDigest,
+			platform:  &api.Platform{OS: "linux", Architecture: "arm64"},
+			expDigest: imageDigest,
+		},
+		"manifest list should resolve the architecture": {
+			digest:    indexDigest,
+			platform:  &api.Platform{OS: "linux", Architecture: "amd64"},
+			expDigest: "sha256:amd64",
+		},
+		"manifest list should resolve the architecture with any variant": {
+			digest:    indexDigest,
+			platform:  &api.Platform{OS: "linux", Architecture: "arm64"},
+			expDigest: "sha256:arm64",
+		},
+		"manifest list without the platform should error": {
+			digest:   indexDigest,
+			platform: &api.Platform{OS: "linux", Architecture: "s390x"},
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			digest, err := registry.client().PlatformDigest(context.TODO(),
+				registry.host()+"/jetstack/app", test.digest, test.platform)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			if digest != test.expDigest {
+				t.Errorf("unexpected digest, exp=%s got=%s", test.expDigest, digest)
+			}
+		})
+	}
+}
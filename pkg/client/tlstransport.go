@@ -0,0 +1,138 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// tlsOptions configure the TLS connections to registry hosts. Hosts of each
+// option may include a port to only match that port.
+type tlsOptions struct {
+	// caBundles is a map of host to the path of a CA bundle file the host's
+	// certificate is verified against, in place of the system roots.
+	caBundles map[string]string
+
+	// clientCerts and clientKeys are maps of host to the paths of the client
+	// certificate and key presented to the host, for mutual TLS.
+	clientCerts map[string]string
+	clientKeys  map[string]string
+
+	// insecureHosts are hosts whose certificates are not verified.
+	insecureHosts []string
+
+	// serverNames is a map of host to the name the host's certificate is
+	// verified against, in place of the host name.
+	serverNames map[string]string
+
+	// proxy, if set, is the proxy function of all transports in place of
+	// the proxy environment variables.
+	proxy func(*http.Request) (*url.URL, error)
+}
+
+// tlsTransport is a http.RoundTripper which uses the TLS configuration of
+// each configured registry host. Requests to any other host use the default
+// transport.
+type tlsTransport struct {
+	next http.RoundTripper
+
+	// hosts holds a transport per host, with only that host's TLS
+	// configuration.
+	hosts map[string]http.RoundTripper
+}
+
+// newTLSTransport returns a transport using the TLS configuration of each
+// host. If no hosts are configured and no proxy is set, http.DefaultTransport
+// is returned.
+func newTLSTransport(opts tlsOptions) (http.RoundTripper, error) {
+	configs := make(map[string]*tls.Config)
+	config := func(host string) *tls.Config {
+		if _, ok := configs[host]; !ok {
+			configs[host] = new(tls.Config)
+		}
+		return configs[host]
+	}
+
+	for host, serverName := range opts.serverNames {
+		if len(serverName) == 0 || strings.ContainsAny(serverName, " :/") {
+			return nil, fmt.Errorf("invalid TLS server name for %q, must be a host name: %q", host, serverName)
+		}
+
+		config(host).ServerName = serverName
+	}
+
+	for host, path := range opts.caBundles {
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle for %q: %s", host, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle for %q: %s", host, path)
+		}
+
+		config(host).RootCAs = pool
+	}
+
+	for host, certPath := range opts.clientCerts {
+		keyPath, ok := opts.clientKeys[host]
+		if !ok {
+			return nil, fmt.Errorf("no client key given for client certificate of %q", host)
+		}
+
+		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate for %q: %s", host, err)
+		}
+
+		config(host).Certificates = []tls.Certificate{cert}
+	}
+	for host := range opts.clientKeys {
+		if _, ok := opts.clientCerts[host]; !ok {
+			return nil, fmt.Errorf("no client certificate given for client key of %q", host)
+		}
+	}
+
+	for _, host := range opts.insecureHosts {
+		config(host).InsecureSkipVerify = true
+	}
+
+	if len(configs) == 0 && opts.proxy == nil {
+		return http.DefaultTransport, nil
+	}
+
+	newTransport := func(config *tls.Config) *http.Transport {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config
+		if opts.proxy != nil {
+			transport.Proxy = opts.proxy
+		}
+		return transport
+	}
+
+	hosts := make(map[string]http.RoundTripper)
+	for host, config := range configs {
+		hosts[host] = newTransport(config)
+	}
+
+	return &tlsTransport{
+		next:  newTransport(nil),
+		hosts: hosts,
+	}, nil
+}
+
+func (c *tlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport, ok := c.hosts[req.URL.Host]; ok {
+		return transport.RoundTrip(req)
+	}
+	if transport, ok := c.hosts[req.URL.Hostname()]; ok {
+		return transport.RoundTrip(req)
+	}
+
+	return c.next.RoundTrip(req)
+}
@@ -0,0 +1,372 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCATLSServer starts a TLS server with a certificate for name signed by a
+// new CA, writing the CA to a PEM file in dir. If ipSAN, the certificate is
+// also valid for 127.0.0.1.
+func newCATLSServer(t *testing.T, dir, name string, ipSAN bool) (*httptest.Server, string) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name + "-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{name},
+	}
+	if ipSAN {
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(name))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+	server.StartTLS()
+
+	path := filepath.Join(dir, name+".pem")
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return server, path
+}
+
+func TestCATransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	serverA, caA := newCATLSServer(t, dir, "registry-a", true)
+	defer serverA.Close()
+	serverB, caB := newCATLSServer(t, dir, "registry-b", true)
+	defer serverB.Close()
+
+	hostA := strings.TrimPrefix(serverA.URL, "https://")
+	hostB := strings.TrimPrefix(serverB.URL, "https://")
+
+	tests := map[string]struct {
+		caBundles  map[string]string
+		expA, expB bool
+	}{
+		"each host should verify against its own CA": {
+			caBundles: map[string]string{hostA: caA, hostB: caB},
+			expA:      true,
+			expB:      true,
+		},
+		"hosts with the wrong CA should fail verification": {
+			caBundles: map[string]string{hostA: caB, hostB: caA},
+		},
+		"hosts without a CA should use the system roots": {
+			caBundles: map[string]string{hostA: caA},
+			expA:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			transport, err := newTLSTransport(tlsOptions{caBundles: test.caBundles})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: transport}
+
+			for _, c := range []struct {
+				url string
+				exp bool
+			}{{serverA.URL, test.expA}, {serverB.URL, test.expB}} {
+				resp, err := client.Get(c.url)
+				if err == nil {
+					resp.Body.Close()
+				}
+				if (err == nil) != c.exp {
+					t.Errorf("unexpected verification of %q, exp=%t got=%v", c.url, c.exp, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCATransportInvalidBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bad.pem")
+	if err := ioutil.WriteFile(path, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newTLSTransport(tlsOptions{caBundles: map[string]string{"registry.example.com": path}}); err == nil {
+		t.Error("expected error for CA bundle without certificates")
+	}
+}
+
+func TestTLSTransportServerName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server, ca := newCATLSServer(t, dir, "registry.internal", false)
+	defer server.Close()
+	otherServer, otherCA := newCATLSServer(t, dir, "registry-b", true)
+	defer otherServer.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	otherHost := strings.TrimPrefix(otherServer.URL, "https://")
+	caBundles := map[string]string{host: ca, otherHost: otherCA}
+
+	tests := map[string]struct {
+		serverNames map[string]string
+		expErr      bool
+	}{
+		"connecting by IP without an override should fail verification": {
+			expErr: true,
+		},
+		"overriding the server name should pass verification": {
+			serverNames: map[string]string{host: "registry.internal"},
+		},
+		"overriding with the wrong server name should fail verification": {
+			serverNames: map[string]string{host: "registry.example.com"},
+			expErr:      true,
+		},
+		"overriding the server name of other hosts should fail verification": {
+			serverNames: map[string]string{otherHost: "registry.internal"},
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			transport, err := newTLSTransport(tlsOptions{caBundles: caBundles, serverNames: test.serverNames})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: transport}
+
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != test.expErr {
+				t.Errorf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			// Hosts without an override should keep verifying against their
+			// own name.
+			if _, ok := test.serverNames[otherHost]; ok {
+				return
+			}
+			resp, err = client.Get(otherServer.URL)
+			if err != nil {
+				t.Errorf("unexpected error of host without an override: %s", err)
+				return
+			}
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestTLSTransportInvalidServerName(t *testing.T) {
+	for _, serverName := range []string{"", " ", "https://registry.internal", "registry.internal:443"} {
+		if _, err := newTLSTransport(tlsOptions{serverNames: map[string]string{"10.0.0.5": serverName}}); err == nil {
+			t.Errorf("expected error for invalid server name %q", serverName)
+		}
+	}
+}
+
+func TestTLSTransportInsecureHosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server, _ := newCATLSServer(t, dir, "registry-a", true)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	tests := map[string]struct {
+		insecureHosts []string
+		expErr        bool
+	}{
+		"host should fail verification by default": {
+			expErr: true,
+		},
+		"insecure host should skip verification": {
+			insecureHosts: []string{host},
+		},
+		"other insecure hosts should not skip verification": {
+			insecureHosts: []string{"registry.example.com"},
+			expErr:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			transport, err := newTLSTransport(tlsOptions{insecureHosts: test.insecureHosts})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != test.expErr {
+				t.Errorf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+		})
+	}
+}
+
+func TestTLSTransportClientCertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "version-checker"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	tests := map[string]struct {
+		clientCerts, clientKeys map[string]string
+		expErr                  bool
+	}{
+		"no client certificate should fail the handshake": {
+			expErr: true,
+		},
+		"client certificate of the host should be presented": {
+			clientCerts: map[string]string{host: certPath},
+			clientKeys:  map[string]string{host: keyPath},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			transport, err := newTLSTransport(tlsOptions{
+				clientCerts:   test.clientCerts,
+				clientKeys:    test.clientKeys,
+				insecureHosts: []string{host},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != "version-checker" {
+				t.Errorf("unexpected client certificate, exp=%q got=%q", "version-checker", body)
+			}
+		})
+	}
+
+	if _, err := newTLSTransport(tlsOptions{clientCerts: map[string]string{host: certPath}}); err == nil {
+		t.Error("expected error for client certificate without a key")
+	}
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// Span and event names recorded when tracing is enabled.
+	tagsSpanName       = "Tags"
+	rateLimitEventName = "rate_limited"
+	retryEventName     = "retry"
+)
+
+// tracingTransport is a http.RoundTripper which records events on the span of
+// the request context, if any.
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		trace.SpanFromContext(req.Context()).AddEvent(rateLimitEventName,
+			trace.WithAttributes(
+				attribute.String("http.host", req.URL.Host),
+				attribute.String("http.retry_after", resp.Header.Get("Retry-After")),
+			),
+		)
+	}
+
+	return resp, nil
+}
+
+// tracedTags will list the tags of the image URL with the given client,
+// recording a span if tracing is enabled.
+func (c *Client) tracedTags(ctx context.Context, client ImageClient, imageURL string) ([]api.ImageTag, error) {
+	if c.tracer == nil {
+		return client.Tags(ctx, imageURL)
+	}
+
+	ref := api.ParseImageRef(imageURL)
+	ctx, span := c.tracer.Start(ctx, tagsSpanName, trace.WithAttributes(
+		attribute.String("registry.host", ref.Registry),
+		attribute.String("registry.repository", ref.Repository),
+	))
+	defer span.End()
+
+	ctx, pages := util.WithPageCounter(ctx)
+	tags, err := client.Tags(ctx, imageURL)
+
+	span.SetAttributes(
+		attribute.Int("registry.page_count", pages()),
+		attribute.Int("registry.tag_count", len(tags)),
+	)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return tags, err
+}
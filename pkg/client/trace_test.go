@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+// pagedClient is an ImageClient which returns one tag per page.
+type pagedClient struct {
+	pages int
+}
+
+func (p *pagedClient) IsClient(string) bool {
+	return true
+}
+
+func (p *pagedClient) Tags(ctx context.Context, _ string) ([]api.ImageTag, error) {
+	var tags []api.ImageTag
+	for i := 0; i < p.pages; i++ {
+		util.CountPage(ctx)
+		tags = append(tags, api.ImageTag{Tag: "v0.0.1"}, api.ImageTag{Tag: "v0.0.2"})
+	}
+	return tags, nil
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTagsTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client := &Client{
+		fallback: &pagedClient{pages: 3},
+		tracer:   provider.Tracer("test"),
+	}
+
+	for _, imageURL := range []string{"quay.io/jetstack/version-checker", "nginx"} {
+		if _, err := client.Tags(context.TODO(), imageURL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected a span per scan, exp=2 got=%d", len(spans))
+	}
+
+	expAttrs := []map[attribute.Key]attribute.Value{
+		{
+			"registry.host":       attribute.StringValue("quay.io"),
+			"registry.repository": attribute.StringValue("jetstack/version-checker"),
+			"registry.page_count": attribute.IntValue(3),
+			"registry.tag_count":  attribute.IntValue(6),
+		},
+		{
+			"registry.host":       attribute.StringValue("docker.io"),
+			"registry.repository": attribute.StringValue("library/nginx"),
+			"registry.page_count": attribute.IntValue(3),
+			"registry.tag_count":  attribute.IntValue(6),
+		},
+	}
+
+	for i, span := range spans {
+		if span.Name() != tagsSpanName {
+			t.Errorf("unexpected span name, exp=%s got=%s", tagsSpanName, span.Name())
+		}
+
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+
+		for k, v := range expAttrs[i] {
+			if attrs[k] != v {
+				t.Errorf("unexpected span attribute %q, exp=%v got=%v", k, v.Emit(), attrs[k].Emit())
+			}
+		}
+	}
+}
+
+func TestTracingTransportRateLimitEvent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	transport := &tracingTransport{
+		next: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+			resp.Header.Set("Retry-After", "10")
+			return resp, nil
+		}),
+	}
+
+	ctx, span := provider.Tracer("test").Start(context.TODO(), "test")
+	req, err := http.NewRequest(http.MethodGet, "https://registry-1.docker.io/v2/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transport.RoundTrip(req.WithContext(ctx)); err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	events := recorder.Ended()[0].Events()
+	if len(events) != 1 || events[0].Name != rateLimitEventName {
+		t.Errorf("expected rate limit event, got=%+v", events)
+	}
+}
+
+func TestRetryTransportEvents(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	retrySleepOrig := retrySleep
+	defer func() { retrySleep = retrySleepOrig }()
+	retrySleep = func(context.Context, time.Duration) error { return nil }
+
+	var attempts int
+	transport := newRetryTransport(&tracingTransport{
+		next: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts < 3 {
+				resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}, Body: http.NoBody}
+				resp.Header.Set("Retry-After", "1")
+				return resp, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+	}, 3, time.Millisecond)
+
+	ctx, span := provider.Tracer("test").Start(context.TODO(), "test")
+	req, err := http.NewRequest(http.MethodGet, "https://registry-1.docker.io/v2/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transport.RoundTrip(req.WithContext(ctx)); err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	var names []string
+	for _, event := range recorder.Ended()[0].Events() {
+		names = append(names, event.Name)
+	}
+	exp := []string{rateLimitEventName, retryEventName, rateLimitEventName, retryEventName}
+	if !reflect.DeepEqual(names, exp) {
+		t.Errorf("expected events of every attempt, exp=%v got=%v", exp, names)
+	}
+}
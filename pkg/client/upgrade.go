@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// NoUpgradeReason describes why no upgrade was recommended.
+type NoUpgradeReason int
+
+const (
+	// NoReason is returned alongside a recommended upgrade.
+	NoReason NoUpgradeReason = iota
+
+	// AlreadyLatest is returned when no version is newer than the current
+	// tag.
+	AlreadyLatest
+
+	// FilteredByPolicy is returned when newer versions exist, but none
+	// satisfy the options, or are available for the required architecture.
+	FilteredByPolicy
+
+	// NoComparableTags is returned when the current tag, or all of the
+	// image's tags, are not semver versions.
+	NoComparableTags
+)
+
+func (n NoUpgradeReason) String() string {
+	switch n {
+	case AlreadyLatest:
+		return "AlreadyLatest"
+	case FilteredByPolicy:
+		return "FilteredByPolicy"
+	case NoComparableTags:
+		return "NoComparableTags"
+	default:
+		return "NoReason"
+	}
+}
+
+// UpgradeOptions restrict the upgrades recommended by NextUpgrade.
+type UpgradeOptions struct {
+	// Options restrict the versions which may be recommended. If nil,
+	// pre-releases are not considered.
+	Options *api.Options
+
+	// RequireArch, if set, skips versions which are not available for this
+	// architecture, such as multi-arch tags which are mid-publish.
+	RequireArch string
+}
+
+// NextUpgrade will return the highest version of the image URL which is newer
+// than the current tag, and satisfies the options. If no upgrade is
+// recommended, nil is returned with the reason why.
+func (c *Client) NextUpgrade(ctx context.Context, imageURL, currentTag string, opts UpgradeOptions) (*api.ImageTag, NoUpgradeReason, error) {
+	if opts.Options == nil {
+		opts.Options = new(api.Options)
+	}
+
+	tags, err := c.Tags(ctx, imageURL)
+	if err != nil {
+		return nil, NoReason, err
+	}
+
+	currentV := semver.Parse(currentTag)
+	if !currentV.IsVersion() {
+		return nil, NoComparableTags, nil
+	}
+
+	var (
+		candidates []api.ImageTag
+		comparable bool
+		newer      bool
+	)
+	for _, tag := range tags {
+		v := semver.Parse(tag.Tag)
+		if !v.IsVersion() || IsCosignArtifact(tag.Tag) {
+			continue
+		}
+		comparable = true
+
+		if !versionLess(currentV, v) {
+			continue
+		}
+		newer = true
+
+		if opts.Options.Matches(tag.Tag) {
+			candidates = append(candidates, tag)
+		}
+	}
+
+	// Highest version first, then newest timestamp.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		vi, vj := semver.Parse(candidates[i].Tag), semver.Parse(candidates[j].Tag)
+		if versionLess(vj, vi) {
+			return true
+		}
+		if versionLess(vi, vj) {
+			return false
+		}
+		return candidates[i].Timestamp.After(candidates[j].Timestamp)
+	})
+
+	checked := make(map[string]bool)
+	for i := range candidates {
+		if len(opts.RequireArch) == 0 {
+			return &candidates[i], NoReason, nil
+		}
+
+		if checked[candidates[i].Tag] {
+			continue
+		}
+		checked[candidates[i].Tag] = true
+
+		ok, err := c.hasArch(ctx, imageURL, tags, candidates[i].Tag, opts.RequireArch)
+		if err != nil {
+			return nil, NoReason, err
+		}
+		if ok {
+			return &candidates[i], NoReason, nil
+		}
+	}
+
+	switch {
+	case !comparable:
+		return nil, NoComparableTags, nil
+	case newer:
+		return nil, FilteredByPolicy, nil
+	default:
+		return nil, AlreadyLatest, nil
+	}
+}
+
+// hasArch returns true if the tag is available for the architecture. The
+// architectures listed by the registry are used if present, otherwise the
+// manifest of the tag is fetched.
+func (c *Client) hasArch(ctx context.Context, imageURL string, tags []api.ImageTag, tag, arch string) (bool, error) {
+	listed := false
+	for _, t := range tags {
+		if t.Tag != tag || len(t.Architecture) == 0 {
+			continue
+		}
+
+		listed = true
+		if t.Architecture == arch {
+			return true, nil
+		}
+	}
+	if listed {
+		return false, nil
+	}
+
+	ref := api.ParseImageRef(c.mirrors.rewrite(imageURL))
+	manifest, err := c.oci.Manifest(ctx, ref.Registry, ref.Repository, tag)
+	if err != nil {
+		return false, fmt.Errorf("failed to get manifest for %q: %s", imageURL+":"+tag, err)
+	}
+
+	if manifest.IsIndex() {
+		for _, desc := range manifest.Manifests {
+			if desc.Platform != nil && desc.Platform.Architecture == arch {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	blob, err := c.oci.Blob(ctx, ref.Registry, ref.Repository, manifest.Config.Digest)
+	if err != nil {
+		return false, fmt.Errorf("failed to get image config for %q: %s", imageURL+":"+tag, err)
+	}
+
+	var config struct {
+		Architecture string `json:"architecture"`
+	}
+	if err := json.Unmarshal(blob, &config); err != nil {
+		return false, fmt.Errorf("unexpected image config for %q: %s", imageURL+":"+tag, err)
+	}
+
+	return config.Architecture == arch, nil
+}
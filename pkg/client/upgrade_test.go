@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestNextUpgrade(t *testing.T) {
+	pinMajor := int64(1)
+	tags := tagsFromNames("v1.0.0", "v1.1.0", "v1.2.0", "v2.0.0-rc.0", "latest", "sha256-abc.sig")
+
+	tests := map[string]struct {
+		tags       []api.ImageTag
+		currentTag string
+		opts       UpgradeOptions
+		expTag     string
+		expReason  NoUpgradeReason
+	}{
+		"newer version should be recommended": {
+			tags:       tags,
+			currentTag: "v1.0.0",
+			expTag:     "v1.2.0",
+		},
+		"options should restrict the recommended version": {
+			tags:       append(tagsFromNames("v2.1.0"), tags...),
+			currentTag: "v1.0.0",
+			opts:       UpgradeOptions{Options: &api.Options{PinMajor: &pinMajor}},
+			expTag:     "v1.2.0",
+		},
+		"latest version should be already latest": {
+			tags:       tagsFromNames("v1.0.0", "v1.2.0", "latest"),
+			currentTag: "v1.2.0",
+			expReason:  AlreadyLatest,
+		},
+		"newer pre-release without metadata allowed should be filtered by policy": {
+			tags:       tags,
+			currentTag: "v1.2.0",
+			expReason:  FilteredByPolicy,
+		},
+		"newer pre-release with metadata allowed should be recommended": {
+			tags:       tags,
+			currentTag: "v1.2.0",
+			opts:       UpgradeOptions{Options: &api.Options{UseMetaData: true}},
+			expTag:     "v2.0.0-rc.0",
+		},
+		"newer versions not matching regex should be filtered by policy": {
+			tags:       tags,
+			currentTag: "v1.0.0",
+			opts:       UpgradeOptions{Options: &api.Options{RegexMatcher: regexp.MustCompile(`^v3`)}},
+			expReason:  FilteredByPolicy,
+		},
+		"non-semver current tag should have no comparable tags": {
+			tags:       tags,
+			currentTag: "latest",
+			expReason:  NoComparableTags,
+		},
+		"image without semver tags should have no comparable tags": {
+			tags:       tagsFromNames("latest", "main"),
+			currentTag: "v1.0.0",
+			expReason:  NoComparableTags,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tag, reason, err := newFakeClient(test.tags).NextUpgrade(context.TODO(), "image", test.currentTag, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			if tag != nil {
+				got = tag.Tag
+			}
+			if got != test.expTag {
+				t.Errorf("unexpected upgrade, exp=%q got=%q", test.expTag, got)
+			}
+			if reason != test.expReason {
+				t.Errorf("unexpected reason, exp=%s got=%s", test.expReason, reason)
+			}
+		})
+	}
+}
+
+func TestNextUpgradeRequireArch(t *testing.T) {
+	registry := newTestRegistry(t)
+	registry.addIndex("jetstack/app", "v1.2.0", map[string]string{"linux/amd64": "sha256:a"})
+	registry.addIndex("jetstack/app", "v1.1.0", map[string]string{"linux/amd64": "sha256:b", "linux/arm64": "sha256:c"})
+	registry.addImage("jetstack/app", "v1.0.1").Config.Digest = registry.addBlob("jetstack/app",
+		[]byte(`{"architecture":"arm64","os":"linux"}`))
+
+	tests := map[string]struct {
+		tags        []api.ImageTag
+		requireArch string
+		expTag      string
+		expReason   NoUpgradeReason
+	}{
+		"without a required arch the newest version should be recommended": {
+			tags:   tagsFromNames("v1.0.0", "v1.1.0", "v1.2.0"),
+			expTag: "v1.2.0",
+		},
+		"newest version lacking the arch should fall back to an older complete version": {
+			tags:        tagsFromNames("v1.0.0", "v1.1.0", "v1.2.0"),
+			requireArch: "arm64",
+			expTag:      "v1.1.0",
+		},
+		"single arch image config should be checked": {
+			tags:        tagsFromNames("v1.0.0", "v1.0.1"),
+			requireArch: "arm64",
+			expTag:      "v1.0.1",
+		},
+		"architectures listed by the registry should be used": {
+			tags: []api.ImageTag{
+				{Tag: "v1.0.0", Architecture: "arm64"},
+				{Tag: "v1.3.0", Architecture: "amd64"},
+				{Tag: "v1.3.0", Architecture: "s390x"},
+			},
+			requireArch: "arm64",
+			expReason:   FilteredByPolicy,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := registry.client()
+			client.fallback = &fakeClient{tags: test.tags}
+
+			tag, reason, err := client.NextUpgrade(context.TODO(), registry.host()+"/jetstack/app", "v1.0.0",
+				UpgradeOptions{RequireArch: test.requireArch})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			if tag != nil {
+				got = tag.Tag
+			}
+			if got != test.expTag {
+				t.Errorf("unexpected upgrade, exp=%q got=%q", test.expTag, got)
+			}
+			if reason != test.expReason {
+				t.Errorf("unexpected reason, exp=%s got=%s", test.expReason, reason)
+			}
+		})
+	}
+}
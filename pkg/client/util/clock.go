@@ -0,0 +1,16 @@
+package util
+
+import "time"
+
+// Clock returns the current time. It is injected so that time dependent
+// behaviour can be tested.
+type Clock interface {
+	Now() time.Time
+}
+
+// RealClock is a Clock returning the system time.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// Credentials are a username and password to authenticate with a registry
+// host.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+type credentialsKey struct{}
+
+// WithCredentials returns a context holding credentials of registry hosts,
+// which registry clients use in place of their configured credentials for
+// lookups made with the context.
+func WithCredentials(ctx context.Context, creds map[string]Credentials) context.Context {
+	if len(creds) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, credentialsKey{}, creds)
+}
+
+// WithHostCredentials returns a context holding the credentials of the host,
+// in addition to the credentials already held by the context.
+func WithHostCredentials(ctx context.Context, host string, cred Credentials) context.Context {
+	creds := map[string]Credentials{host: cred}
+	if existing, ok := ctx.Value(credentialsKey{}).(map[string]Credentials); ok {
+		for h, c := range existing {
+			if h != host {
+				creds[h] = c
+			}
+		}
+	}
+	return context.WithValue(ctx, credentialsKey{}, creds)
+}
+
+// CredentialsFor will return the credentials of the registry host held by
+// the context, if any.
+func CredentialsFor(ctx context.Context, host string) (Credentials, bool) {
+	creds, ok := ctx.Value(credentialsKey{}).(map[string]Credentials)
+	if !ok {
+		return Credentials{}, false
+	}
+
+	cred, ok := creds[host]
+	return cred, ok
+}
+
+// ID returns an identifier of the credentials which doesn't hold the
+// password, to key state such as tokens obtained with them.
+func (c Credentials) ID() string {
+	sum := sha256.Sum256([]byte(c.Username + ":" + c.Password))
+	return hex.EncodeToString(sum[:8])
+}
+
+// CredentialsKey returns the suffix of the cache keys of lookups made with the
+// context, being "@" and an ID of every host's credentials held by the
+// context, or empty if it holds none. Results of lookups with credentials,
+// such as from the image pull secrets of a pod, are then never served to
+// lookups with different, or no, credentials.
+func CredentialsKey(ctx context.Context) string {
+	creds, ok := ctx.Value(credentialsKey{}).(map[string]Credentials)
+	if !ok || len(creds) == 0 {
+		return ""
+	}
+
+	ids := make([]string, 0, len(creds))
+	for host, cred := range creds {
+		ids = append(ids, host+"="+cred.ID())
+	}
+	sort.Strings(ids)
+
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	return "@" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// dockerHubHosts are the registry hosts of Docker Hub used in docker
+// configs, given as docker.io to registry clients.
+var dockerHubHosts = map[string]bool{
+	"docker.io":               true,
+	"index.docker.io":         true,
+	"registry-1.docker.io":    true,
+	"registry.hub.docker.com": true,
+}
+
+// DockerConfig is a docker config.json, or the content of a
+// kubernetes.io/dockerconfigjson secret.
+type DockerConfig struct {
+	Auths map[string]DockerConfigEntry `json:"auths"`
+
+	// CredHelpers is a map of registry host to the credential helper
+	// providing its credentials.
+	CredHelpers map[string]string `json:"credHelpers"`
+
+	// CredsStore is the credential helper of hosts without a CredHelpers
+	// entry.
+	CredsStore string `json:"credsStore"`
+}
+
+// DockerConfigEntry is the credentials of a registry host in a docker config.
+type DockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// Credentials returns the credentials of the entry, decoding auth if set.
+func (e DockerConfigEntry) Credentials() (Credentials, error) {
+	if len(e.Auth) == 0 {
+		return Credentials{Username: e.Username, Password: e.Password}, nil
+	}
+
+	auth, err := base64.StdEncoding.DecodeString(e.Auth)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to decode auth: %s", err)
+	}
+
+	split := strings.SplitN(string(auth), ":", 2)
+	if len(split) != 2 {
+		return Credentials{}, fmt.Errorf("malformed auth, expected username:password")
+	}
+
+	return Credentials{Username: split[0], Password: split[1]}, nil
+}
+
+// RegistryHost returns the registry host of a docker config server, which
+// may be a URL such as https://index.docker.io/v1/. Docker Hub hosts are
+// returned as docker.io.
+func RegistryHost(server string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	host = strings.SplitN(host, "/", 2)[0]
+
+	if dockerHubHosts[host] {
+		return "docker.io"
+	}
+
+	return host
+}
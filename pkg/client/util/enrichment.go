@@ -0,0 +1,36 @@
+package util
+
+import (
+	"context"
+)
+
+type enrichmentLimitKey struct{}
+
+// WithEnrichmentLimit returns a context which limits the number of concurrent
+// enrichment requests, such as per tag manifest fetches, made by registry
+// clients using it to n. The limit is shared by all contexts derived from the
+// returned context, so that a batch of lookups shares one bounded pool.
+func WithEnrichmentLimit(ctx context.Context, n int) context.Context {
+	if n <= 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, enrichmentLimitKey{}, make(chan struct{}, n))
+}
+
+// AcquireEnrichment will block until an enrichment request may be made,
+// returning a func to release it once done. Returns immediately if the
+// context has no enrichment limit, or an error if the context is done first.
+func AcquireEnrichment(ctx context.Context) (func(), error) {
+	sem, ok := ctx.Value(enrichmentLimitKey{}).(chan struct{})
+	if !ok {
+		return func() {}, nil
+	}
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
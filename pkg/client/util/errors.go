@@ -0,0 +1,105 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrRepositoryNotFound is returned when the registry reports that the image
+// repository does not exist.
+var ErrRepositoryNotFound = errors.New("repository not found")
+
+// ErrManifestNotFound is returned when the registry reports that a manifest
+// or blob of an existing repository does not exist, such as of a tag deleted
+// since the tags were listed.
+var ErrManifestNotFound = errors.New("manifest not found")
+
+// ErrRegistryError is returned when a registry responds with an error
+// envelope, which may be sent even with a 200 status code by misconfigured
+// gateways.
+type ErrRegistryError struct {
+	Errors []RegistryErrorDetail `json:"errors"`
+}
+
+// RegistryErrorDetail is a single error of an error envelope, as defined by
+// the distribution API.
+type RegistryErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ErrRegistryError) Error() string {
+	var errs []string
+	for _, detail := range e.Errors {
+		errs = append(errs, fmt.Sprintf("%s: %s", detail.Code, detail.Message))
+	}
+
+	return "registry returned errors: " + strings.Join(errs, ", ")
+}
+
+// Is returns true for ErrRepositoryNotFound if the envelope contains the
+// NAME_UNKNOWN error code, and for ErrManifestNotFound if it contains the
+// MANIFEST_UNKNOWN or BLOB_UNKNOWN error code.
+func (e *ErrRegistryError) Is(target error) bool {
+	for _, detail := range e.Errors {
+		switch {
+		case target == ErrRepositoryNotFound && detail.Code == "NAME_UNKNOWN":
+			return true
+		case target == ErrManifestNotFound && (detail.Code == "MANIFEST_UNKNOWN" || detail.Code == "BLOB_UNKNOWN"):
+			return true
+		}
+	}
+
+	return false
+}
+
+// Codes returns the error codes of the envelope.
+func (e *ErrRegistryError) Codes() []string {
+	var codes []string
+	for _, detail := range e.Errors {
+		codes = append(codes, detail.Code)
+	}
+	return codes
+}
+
+// ErrorEnvelope will return an ErrRegistryError if the given response body is
+// an error envelope with a top level errors array. Returns nil otherwise.
+func ErrorEnvelope(body []byte) error {
+	// Avoid decoding bodies which can't be an envelope.
+	if !strings.HasPrefix(strings.TrimSpace(string(body)), "{") {
+		return nil
+	}
+
+	envelope := new(ErrRegistryError)
+	if err := json.Unmarshal(body, envelope); err != nil || len(envelope.Errors) == 0 {
+		return nil
+	}
+
+	return envelope
+}
+
+// NotFound will return an error if the response has a not found status code.
+// The error is ErrRepositoryNotFound only if the repository is not found,
+// being a NAME_UNKNOWN error envelope, or not found without an envelope
+// other than of a manifest or blob, such as of the tags of the repository.
+// Not found manifests and blobs are ErrManifestNotFound, so that the tags of
+// a repository are not cached as not found for a single deleted tag. Returns
+// nil otherwise.
+func NotFound(resp *http.Response, url string, body []byte) error {
+	if resp.StatusCode != http.StatusNotFound {
+		return nil
+	}
+
+	if err := ErrorEnvelope(body); err != nil {
+		return fmt.Errorf("%w: %q", err, url)
+	}
+
+	if strings.Contains(url, "/manifests/") || strings.Contains(url, "/blobs/") {
+		return fmt.Errorf("%w: %q", ErrManifestNotFound, url)
+	}
+
+	return fmt.Errorf("%w: %q", ErrRepositoryNotFound, url)
+}
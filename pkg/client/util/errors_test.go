@@ -0,0 +1,125 @@
+package util
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		expCodes []string
+	}{
+		"tags response should not be an envelope": {
+			body: `{"name": "jetstack/app", "tags": ["v0.1.0"]}`,
+		},
+		"empty errors should not be an envelope": {
+			body: `{"errors": []}`,
+		},
+		"non JSON body should not be an envelope": {
+			body: `<html>bad gateway</html>`,
+		},
+		"errors array should be an envelope": {
+			body:     `{"errors": [{"code": "UNAUTHORIZED", "message": "authentication required"}, {"code": "DENIED"}]}`,
+			expCodes: []string{"UNAUTHORIZED", "DENIED"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ErrorEnvelope([]byte(test.body))
+			if test.expCodes == nil {
+				if err != nil {
+					t.Errorf("expected no error, got=%v", err)
+				}
+				return
+			}
+
+			var registryErr *ErrRegistryError
+			if !errors.As(err, &registryErr) {
+				t.Fatalf("expected ErrRegistryError, got=%v", err)
+			}
+
+			if !reflect.DeepEqual(registryErr.Codes(), test.expCodes) {
+				t.Errorf("unexpected codes, exp=%v got=%v", test.expCodes, registryErr.Codes())
+			}
+		})
+	}
+}
+
+func TestRepositoryNotFound(t *testing.T) {
+	err := ErrorEnvelope([]byte(`{"errors": [{"code": "NAME_UNKNOWN", "message": "repository name not known to registry"}]}`))
+	if !errors.Is(err, ErrRepositoryNotFound) {
+		t.Errorf("expected NAME_UNKNOWN envelope to be repository not found, got=%v", err)
+	}
+
+	err = ErrorEnvelope([]byte(`{"errors": [{"code": "UNAUTHORIZED"}]}`))
+	if errors.Is(err, ErrRepositoryNotFound) {
+		t.Errorf("expected UNAUTHORIZED envelope not to be repository not found, got=%v", err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	tests := map[string]struct {
+		statusCode  int
+		url         string
+		body        string
+		expRepo     bool
+		expManifest bool
+	}{
+		"ok response should not be not found": {
+			statusCode: http.StatusOK,
+			url:        "https://registry.example.com/v2/app/tags/list",
+		},
+		"not found tags should be repository not found": {
+			statusCode: http.StatusNotFound,
+			url:        "https://registry.example.com/v2/app/tags/list",
+			expRepo:    true,
+		},
+		"not found repository endpoint should be repository not found": {
+			statusCode: http.StatusNotFound,
+			url:        "https://quay.io/api/v1/repository/jetstack/app",
+			expRepo:    true,
+		},
+		"NAME_UNKNOWN manifest should be repository not found": {
+			statusCode: http.StatusNotFound,
+			url:        "https://registry.example.com/v2/app/manifests/v0.1.0",
+			body:       `{"errors": [{"code": "NAME_UNKNOWN"}]}`,
+			expRepo:    true,
+		},
+		"MANIFEST_UNKNOWN should be manifest not found": {
+			statusCode:  http.StatusNotFound,
+			url:         "https://registry.example.com/v2/app/manifests/v0.1.0",
+			body:        `{"errors": [{"code": "MANIFEST_UNKNOWN"}]}`,
+			expManifest: true,
+		},
+		"BLOB_UNKNOWN should be manifest not found": {
+			statusCode:  http.StatusNotFound,
+			url:         "https://registry.example.com/v2/app/blobs/sha256:a",
+			body:        `{"errors": [{"code": "BLOB_UNKNOWN"}]}`,
+			expManifest: true,
+		},
+		"not found manifest without envelope should be manifest not found": {
+			statusCode:  http.StatusNotFound,
+			url:         "https://registry.example.com/v2/app/manifests/v0.1.0",
+			expManifest: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := NotFound(&http.Response{StatusCode: test.statusCode}, test.url, []byte(test.body))
+			if test.statusCode == http.StatusOK && err != nil {
+				t.Fatalf("expected no error, got=%v", err)
+			}
+			if got := errors.Is(err, ErrRepositoryNotFound); got != test.expRepo {
+				t.Errorf("unexpected repository not found, exp=%t got=%t (%v)", test.expRepo, got, err)
+			}
+			if got := errors.Is(err, ErrManifestNotFound); got != test.expManifest {
+				t.Errorf("unexpected manifest not found, exp=%t got=%t (%v)", test.expManifest, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type pageCounterKey struct{}
+
+// WithPageCounter returns a context which counts the pages of tags requested
+// by registry clients, and a func to read the current count.
+func WithPageCounter(ctx context.Context) (context.Context, func() int) {
+	var pages int32
+	return context.WithValue(ctx, pageCounterKey{}, &pages), func() int {
+		return int(atomic.LoadInt32(&pages))
+	}
+}
+
+// CountPage will record that a page of tags has been requested, if the
+// context is counting pages.
+func CountPage(ctx context.Context) {
+	if pages, ok := ctx.Value(pageCounterKey{}).(*int32); ok {
+		atomic.AddInt32(pages, 1)
+	}
+}
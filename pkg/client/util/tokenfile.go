@@ -0,0 +1,102 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTokenFileCacheTimeout is how long a token read from file is used
+	// before the file is read again.
+	defaultTokenFileCacheTimeout = time.Second * 10
+
+	// defaultTokenFileRetries and defaultTokenFileRetryInterval control how
+	// long to wait for a token file that is absent, such as during rotation.
+	defaultTokenFileRetries       = 5
+	defaultTokenFileRetryInterval = time.Millisecond * 100
+)
+
+// TokenFile reads a bearer token from a file which is refreshed externally,
+// for example by a sidecar. The token is cached for a short period to avoid
+// reading the file on every request.
+type TokenFile struct {
+	path string
+
+	cacheTimeout  time.Duration
+	retries       int
+	retryInterval time.Duration
+
+	mu     sync.Mutex
+	token  string
+	readAt time.Time
+}
+
+func NewTokenFile(path string) *TokenFile {
+	return &TokenFile{
+		path:          path,
+		cacheTimeout:  defaultTokenFileCacheTimeout,
+		retries:       defaultTokenFileRetries,
+		retryInterval: defaultTokenFileRetryInterval,
+	}
+}
+
+// Token returns the current token from the file. If the file is absent, it
+// is retried briefly to allow for rotation to complete. If the file is still
+// absent, the last read token is returned if there is one, and used until the
+// cache timeout before the file is read again.
+func (t *TokenFile) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	if len(t.token) > 0 && time.Since(t.readAt) < t.cacheTimeout {
+		defer t.mu.Unlock()
+		return t.token, nil
+	}
+	t.mu.Unlock()
+
+	data, err := t.read(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		if len(t.token) > 0 {
+			t.readAt = time.Now()
+			return t.token, nil
+		}
+
+		return "", fmt.Errorf("failed to read token file: %s", err)
+	}
+
+	token := strings.TrimSpace(string(data))
+	if len(token) == 0 {
+		return "", fmt.Errorf("token file is empty: %q", t.path)
+	}
+
+	t.token = token
+	t.readAt = time.Now()
+
+	return t.token, nil
+}
+
+// read will read the file, retrying while it is absent until the retries are
+// exhausted or the context is done.
+func (t *TokenFile) read(ctx context.Context) ([]byte, error) {
+	for i := 0; ; i++ {
+		data, err := ioutil.ReadFile(t.path)
+		if !os.IsNotExist(err) || i >= t.retries {
+			return data, err
+		}
+
+		timer := time.NewTimer(t.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenFileRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version-checker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	// Write tokens atomically, as rotating tools do.
+	writeToken := func(token string) {
+		if err := ioutil.WriteFile(path+".tmp", []byte(token+"\n"), 0600); err != nil {
+			t.Error(err)
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			t.Error(err)
+		}
+	}
+
+	tokenFile := NewTokenFile(path)
+	tokenFile.cacheTimeout = time.Millisecond * 50
+	tokenFile.retryInterval = time.Millisecond * 20
+
+	expToken := func(exp string) {
+		t.Helper()
+		token, err := tokenFile.Token(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != exp {
+			t.Errorf("unexpected token, exp=%s got=%s", exp, token)
+		}
+	}
+
+	writeToken("token-1")
+	expToken("token-1")
+
+	// Rotated token should not be read until the cache has expired.
+	writeToken("token-2")
+	expToken("token-1")
+	time.Sleep(tokenFile.cacheTimeout)
+	expToken("token-2")
+
+	// Token file is absent mid rotation, but appears before retries are
+	// exhausted.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(tokenFile.cacheTimeout)
+	go func() {
+		time.Sleep(tokenFile.retryInterval * 2)
+		writeToken("token-3")
+	}()
+	expToken("token-3")
+
+	// Token file is absent for longer than the retries, so the last token is
+	// used.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(tokenFile.cacheTimeout)
+	expToken("token-3")
+
+	// The last token is used until the cache has expired, without retrying.
+	start := time.Now()
+	expToken("token-3")
+	if d := time.Since(start); d >= tokenFile.retryInterval {
+		t.Errorf("expected cached token without retrying, took %s", d)
+	}
+}
+
+func TestTokenFileMissing(t *testing.T) {
+	tokenFile := NewTokenFile(filepath.Join(os.TempDir(), "version-checker-does-not-exist"))
+	tokenFile.retryInterval = time.Millisecond
+
+	if _, err := tokenFile.Token(context.TODO()); err == nil {
+		t.Error("expected error reading missing token file, got none")
+	}
+}
+
+func TestTokenFileContext(t *testing.T) {
+	tokenFile := NewTokenFile(filepath.Join(os.TempDir(), "version-checker-does-not-exist"))
+	tokenFile.retryInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		cancel()
+	}()
+
+	if _, err := tokenFile.Token(ctx); err == nil {
+		t.Error("expected error reading missing token file with cancelled context, got none")
+	}
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// LatestPerMinor will return the highest patch release of each major.minor
+// version of the image URL, in ascending version order. Tags with metadata,
+// such as pre-releases, are ignored.
+func (c *Client) LatestPerMinor(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	tags, err := c.Tags(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return latestPerMinor(tags), nil
+}
+
+// UpgradePath will return the ordered releases to step through when upgrading
+// from one tag to another. This is the highest patch release of each minor
+// version between the two tags, followed by the target tag itself.
+func (c *Client) UpgradePath(ctx context.Context, imageURL, fromTag, toTag string) ([]api.ImageTag, error) {
+	tags, err := c.Tags(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	fromV, toV := semver.Parse(fromTag), semver.Parse(toTag)
+	if !fromV.LessThan(toV) {
+		return nil, fmt.Errorf("%q is not a newer version than %q", toTag, fromTag)
+	}
+
+	var to *api.ImageTag
+	for i := range tags {
+		if tags[i].Tag == toTag {
+			to = &tags[i]
+			break
+		}
+	}
+	if to == nil {
+		return nil, fmt.Errorf("tag %q not found for image URL: %q", toTag, imageURL)
+	}
+
+	var path []api.ImageTag
+	for _, tag := range latestPerMinor(tags) {
+		v := semver.Parse(tag.Tag)
+		if !isMinorBetween(v, fromV, toV) {
+			continue
+		}
+
+		path = append(path, tag)
+	}
+
+	return append(path, *to), nil
+}
+
+// FirstVersionAtLeast will return the lowest semver tag of the image URL
+// which is equal to, or greater than, minVersion. This is the minimal upgrade
+// to a release containing a fix. Tags which are not semver versions are
+// ignored, as are tags with metadata unless minVersion itself has metadata.
+// Returns nil if no such tag exists yet.
+func (c *Client) FirstVersionAtLeast(ctx context.Context, imageURL, minVersion string) (*api.ImageTag, error) {
+	tags, err := c.Tags(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	minV := semver.Parse(minVersion)
+	if !minV.IsVersion() {
+		return nil, fmt.Errorf("%q is not a semver version", minVersion)
+	}
+
+	var (
+		first  *api.ImageTag
+		firstV *semver.SemVer
+	)
+	for i := range tags {
+		v := semver.Parse(tags[i].Tag)
+		if !v.IsVersion() || IsCosignArtifact(tags[i].Tag) {
+			continue
+		}
+		if v.HasMetaData() && !minV.HasMetaData() {
+			continue
+		}
+		if versionLess(v, minV) {
+			continue
+		}
+
+		if first == nil || versionLess(v, firstV) {
+			first, firstV = &tags[i], v
+		}
+	}
+
+	return first, nil
+}
+
+// latestPerMinor returns the highest patch tag of each major.minor, in
+// ascending version order.
+func latestPerMinor(tags []api.ImageTag) []api.ImageTag {
+	latest := make(map[[2]int64]*api.ImageTag)
+	for i := range tags {
+		v := semver.Parse(tags[i].Tag)
+		if v.HasMetaData() || IsCosignArtifact(tags[i].Tag) {
+			continue
+		}
+
+		minor := [2]int64{v.Major(), v.Minor()}
+		if current, ok := latest[minor]; !ok || semver.Parse(current.Tag).LessThan(v) {
+			latest[minor] = &tags[i]
+		}
+	}
+
+	var result []api.ImageTag
+	for _, tag := range latest {
+		result = append(result, *tag)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return semver.Parse(result[i].Tag).LessThan(semver.Parse(result[j].Tag))
+	})
+
+	return result
+}
+
+// isMinorBetween returns true if the major.minor of v is strictly between
+// the major.minor of from and to.
+func isMinorBetween(v, from, to *semver.SemVer) bool {
+	return compareMinor(from, v) < 0 && compareMinor(v, to) < 0
+}
+
+// versionLess returns true if a is a lower version than b. Unlike
+// SemVer.LessThan, a stable release of a lower version is always less than a
+// pre-release of a higher version.
+func versionLess(a, b *semver.SemVer) bool {
+	if c := compareMinor(a, b); c != 0 {
+		return c < 0
+	}
+	if a.Patch() != b.Patch() {
+		return a.Patch() < b.Patch()
+	}
+	return a.LessThan(b)
+}
+
+// compareMinor compares the major.minor of two versions, returning -1, 0 or 1.
+func compareMinor(a, b *semver.SemVer) int {
+	switch {
+	case a.Major() != b.Major():
+		if a.Major() < b.Major() {
+			return -1
+		}
+		return 1
+	case a.Minor() != b.Minor():
+		if a.Minor() < b.Minor() {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func tagsFromNames(names ...string) []api.ImageTag {
+	var tags []api.ImageTag
+	for _, name := range names {
+		tags = append(tags, api.ImageTag{Tag: name})
+	}
+	return tags
+}
+
+func tagNames(tags []api.ImageTag) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Tag)
+	}
+	return names
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLatestPerMinor(t *testing.T) {
+	client := newFakeClient(tagsFromNames(
+		"v1.1.0", "v1.0.1", "v1.0.0", "v1.2.0-rc.0", "v1.1.3", "v2.0.0", "latest",
+	))
+
+	tags, err := client.LatestPerMinor(context.TODO(), "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{"v1.0.1", "v1.1.3", "v2.0.0"}
+	if got := tagNames(tags); !equalNames(exp, got) {
+		t.Errorf("unexpected latest per minor, exp=%v got=%v", exp, got)
+	}
+}
+
+func TestUpgradePath(t *testing.T) {
+	tags := tagsFromNames(
+		"v1.2.0", "v1.2.3", "v1.3.0", "v1.3.4", "v1.4.0", "v1.4.1", "v1.4.2-rc.0",
+		"v1.5.0", "v1.5.1", "v2.0.0", "v2.1.0", "v2.1.1",
+	)
+
+	tests := map[string]struct {
+		from, to string
+		expPath  []string
+		expErr   bool
+	}{
+		"multi minor jump should step through latest patch of each minor": {
+			from:    "v1.2.3",
+			to:      "v1.5.1",
+			expPath: []string{"v1.3.4", "v1.4.1", "v1.5.1"},
+		},
+		"multi major jump should step through minors of each major": {
+			from:    "v1.4.0",
+			to:      "v2.1.0",
+			expPath: []string{"v1.5.1", "v2.0.0", "v2.1.0"},
+		},
+		"adjacent minors should go directly to target": {
+			from:    "v1.2.3",
+			to:      "v1.3.0",
+			expPath: []string{"v1.3.0"},
+		},
+		"patch upgrade should go directly to target": {
+			from:    "v1.2.0",
+			to:      "v1.2.3",
+			expPath: []string{"v1.2.3"},
+		},
+		"older target should error": {
+			from:   "v1.5.0",
+			to:     "v1.2.0",
+			expErr: true,
+		},
+		"missing target should error": {
+			from:   "v1.2.0",
+			to:     "v1.9.0",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, err := newFakeClient(tags).UpgradePath(context.TODO(), "image", test.from, test.to)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			if got := tagNames(path); !equalNames(test.expPath, got) {
+				t.Errorf("unexpected upgrade path, exp=%v got=%v", test.expPath, got)
+			}
+		})
+	}
+}
+
+func TestFirstVersionAtLeast(t *testing.T) {
+	tags := tagsFromNames(
+		"v1.2.3", "v1.2.7", "v1.2.6", "v1.2.5-rc.0", "v1.3.0", "latest", "sha256-abc.sig",
+	)
+
+	tests := map[string]struct {
+		minVersion string
+		expTag     string
+		expErr     bool
+	}{
+		"should return the lowest version above the minimum": {
+			minVersion: "v1.2.5",
+			expTag:     "v1.2.6",
+		},
+		"should return the minimum version if it exists": {
+			minVersion: "v1.2.7",
+			expTag:     "v1.2.7",
+		},
+		"should return nil if no fix exists yet": {
+			minVersion: "v1.3.1",
+		},
+		"should include pre-releases if the minimum is a pre-release": {
+			minVersion: "v1.2.4-rc.0",
+			expTag:     "v1.2.5-rc.0",
+		},
+		"should error if the minimum is not a version": {
+			minVersion: "latest",
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tag, err := newFakeClient(tags).FirstVersionAtLeast(context.TODO(), "image", test.minVersion)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			var got string
+			if tag != nil {
+				got = tag.Tag
+			}
+			if got != test.expTag {
+				t.Errorf("unexpected tag, exp=%q got=%q", test.expTag, got)
+			}
+		})
+	}
+}
@@ -8,20 +8,22 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
 	"github.com/jetstack/version-checker/pkg/version"
 )
 
 // imageCacheItem is a single node item for the cache of a lastest image search.
 type imageCacheItem struct {
 	timestamp   time.Time
+	timeout     time.Duration
 	latestImage *api.ImageTag
 }
 
 // getLatestImage will get the latestImage image given an image URL and
-// options. If not found in the cache, or is too old, then will do a fresh
-// lookup and commit to the cache.
+// options. If not found in the cache, or is older than the cache timeout, then
+// will do a fresh lookup and commit to the cache.
 func (c *Controller) getLatestImage(ctx context.Context, log *logrus.Entry,
-	imageURL string, opts *api.Options) (*api.ImageTag, error) {
+	imageURL string, opts *api.Options, cacheTimeout time.Duration) (*api.ImageTag, error) {
 
 	log = c.log.WithField("cache", "getter")
 
@@ -29,23 +31,37 @@ func (c *Controller) getLatestImage(ctx context.Context, log *logrus.Entry,
 	if err != nil {
 		return nil, err
 	}
+	// Searches with the credentials of different pods are never shared.
+	hashIndex += util.CredentialsKey(ctx)
 
 	c.cacheMu.RLock()
 	cacheItem, ok := c.imageCache[hashIndex]
 	c.cacheMu.RUnlock()
 
 	// Test if exists in the cache or is too old
-	if !ok || cacheItem.timestamp.Add(c.cacheTimeout).Before(time.Now()) {
-		latestImage, err := c.versionGetter.LatestTagFromImage(ctx, opts, imageURL)
+	if !ok || cacheItem.timestamp.Add(cacheTimeout).Before(time.Now()) {
+		// Identical searches in flight share one lookup.
+		latestImage, shared, err := c.lookups.do(ctx, hashIndex, func() (*api.ImageTag, error) {
+			latestImage, err := c.versionGetter.LatestTagFromImage(
+				version.WithCacheTimeout(ctx, cacheTimeout), opts, imageURL)
+			if err != nil {
+				return nil, err
+			}
+
+			// Commit to the cache
+			log.Debugf("committing search: %q", hashIndex)
+			c.cacheMu.Lock()
+			c.imageCache[hashIndex] = imageCacheItem{time.Now(), cacheTimeout, latestImage}
+			c.cacheMu.Unlock()
+
+			return latestImage, nil
+		})
 		if err != nil {
 			return nil, fmt.Errorf("%q: %s", imageURL, err)
 		}
-
-		// Commit to the cache
-		log.Debugf("committing search: %q", hashIndex)
-		c.cacheMu.Lock()
-		c.imageCache[hashIndex] = imageCacheItem{time.Now(), latestImage}
-		c.cacheMu.Unlock()
+		if shared {
+			log.Debugf("shared in flight search: %q", hashIndex)
+		}
 
 		return latestImage, nil
 	}
@@ -55,6 +71,68 @@ func (c *Controller) getLatestImage(ctx context.Context, log *logrus.Entry,
 	return cacheItem.latestImage, nil
 }
 
+// getTagDigest will get the current digest of the given image URL and tag,
+// without listing its tags. If not found in the cache, or is older than the
+// cache timeout, then will do a fresh lookup and commit to the cache.
+func (c *Controller) getTagDigest(ctx context.Context, imageURL, tag string,
+	cacheTimeout time.Duration) (string, error) {
+	return c.getDigest(ctx, "digest:"+imageURL+":"+tag+util.CredentialsKey(ctx), cacheTimeout, func() (string, error) {
+		return c.digests.TagDigest(ctx, imageURL, tag)
+	})
+}
+
+// getPlatformDigest will get the digest of the image manifest for the
+// platform, of the given image URL and digest. If not found in the cache, or
+// is older than the cache timeout, then will do a fresh lookup and commit to
+// the cache.
+func (c *Controller) getPlatformDigest(ctx context.Context, imageURL, digest string,
+	platform *api.Platform, cacheTimeout time.Duration) (string, error) {
+	hashIndex := fmt.Sprintf("platform:%s@%s:%s/%s/%s%s", imageURL, digest,
+		platform.OS, platform.Architecture, platform.Variant, util.CredentialsKey(ctx))
+
+	return c.getDigest(ctx, hashIndex, cacheTimeout, func() (string, error) {
+		return c.digests.PlatformDigest(ctx, imageURL, digest, platform)
+	})
+}
+
+// getDigest will get the digest cached at the hash index, or else look it up
+// and commit it to the cache.
+func (c *Controller) getDigest(ctx context.Context, hashIndex string,
+	cacheTimeout time.Duration, lookup func() (string, error)) (string, error) {
+
+	log := c.log.WithField("cache", "getter")
+
+	c.cacheMu.RLock()
+	cacheItem, ok := c.imageCache[hashIndex]
+	c.cacheMu.RUnlock()
+
+	if ok && !cacheItem.timestamp.Add(cacheTimeout).Before(time.Now()) {
+		log.Debugf("found digest: %q", hashIndex)
+		return cacheItem.latestImage.SHA, nil
+	}
+
+	latestImage, _, err := c.lookups.do(ctx, hashIndex, func() (*api.ImageTag, error) {
+		digest, err := lookup()
+		if err != nil {
+			return nil, err
+		}
+
+		latestImage := &api.ImageTag{SHA: digest}
+
+		log.Debugf("committing digest: %q", hashIndex)
+		c.cacheMu.Lock()
+		c.imageCache[hashIndex] = imageCacheItem{time.Now(), cacheTimeout, latestImage}
+		c.cacheMu.Unlock()
+
+		return latestImage, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return latestImage.SHA, nil
+}
+
 func (c *Controller) garbageCollect(refreshRate time.Duration) {
 	log := c.log.WithField("cache", "garbage_collector")
 	log.Infof("starting search cache garbage collector")
@@ -68,7 +146,7 @@ func (c *Controller) garbageCollect(refreshRate time.Duration) {
 		for hashIndex, cacheItem := range c.imageCache {
 
 			// Check is cache item is fresh
-			if cacheItem.timestamp.Add(c.cacheTimeout).Before(now) {
+			if cacheItem.timestamp.Add(cacheItem.timeout).Before(now) {
 
 				log.Debugf("removing stale search from cache: %q",
 					hashIndex)
@@ -78,6 +156,4 @@ func (c *Controller) garbageCollect(refreshRate time.Duration) {
 		}
 		c.cacheMu.Unlock()
 	}
-
-	return
 }
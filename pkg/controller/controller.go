@@ -9,22 +9,41 @@ import (
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
+	"github.com/jetstack/version-checker/pkg/api"
+	vcache "github.com/jetstack/version-checker/pkg/cache"
 	"github.com/jetstack/version-checker/pkg/client"
 	"github.com/jetstack/version-checker/pkg/metrics"
 	"github.com/jetstack/version-checker/pkg/version"
 )
 
 const (
-	numWorkers = 5
+	// defaultWorkers is the number of workers processing pods if not set.
+	defaultWorkers = 5
+
+	// registryCredentialsKey is the single key of the queue of registry
+	// credential rebuilds.
+	registryCredentialsKey = "registry-credentials"
 )
 
+// digestResolver resolves the current digest of an image tag, and the digest
+// of the image of a platform in a manifest list. Satisfied by client.Client.
+type digestResolver interface {
+	TagDigest(ctx context.Context, imageURL, tag string) (string, error)
+	PlatformDigest(ctx context.Context, imageURL, digest string, platform *api.Platform) (string, error)
+}
+
 // controller is the main controller that check and exposes metrics on
 // versions.
 type Controller struct {
@@ -32,16 +51,123 @@ type Controller struct {
 
 	kubeClient kubernetes.Interface
 	podLister  corev1listers.PodLister
+	nodeLister corev1listers.NodeLister
 	workqueue  workqueue.RateLimitingInterface
 
+	// namespaceLister lists namespaces, whose annotations are the default
+	// annotations of the pods in them.
+	namespaceLister corev1listers.NamespaceLister
+
+	// workers is the number of pods processed concurrently.
+	workers int
+
 	versionGetter *version.VersionGetter
 	metrics       *metrics.Metrics
 
+	// digests resolves the current digest of a tag, so that images using the
+	// latest tag can be compared without listing every tag, and the digest
+	// of the platform of multi-arch images, if set.
+	digests digestResolver
+
 	cacheMu      sync.RWMutex
 	cacheTimeout time.Duration
 	imageCache   map[string]imageCacheItem
 
+	// lookups shares in flight latest image searches between identical
+	// searches.
+	lookups *lookupGroup
+
+	// registryCacheTimeouts are the cache timeouts of images of each registry
+	// host, in place of cacheTimeout.
+	registryCacheTimeouts map[string]time.Duration
+
+	// excludeTagRegexes exclude tags of every image from the latest image
+	// search, alongside the exclude regex annotation of containers.
+	excludeTagRegexes []string
+
+	// tagPolicyConfigMap is the namespace/name of the ConfigMap holding the
+	// allowed and denied tags of repositories, if set.
+	tagPolicyConfigMap string
+	tagPolicy          *tagPolicy
+
 	defaultTestAll bool
+
+	// pullSecrets resolves registry credentials from the image pull secrets
+	// of pods, if enabled.
+	pullSecrets *pullSecretCache
+
+	// dynamicClient is used to watch RegistryCredential resources, if set.
+	// Only secrets in registryCredentialsNamespace may be referenced.
+	dynamicClient                dynamic.Interface
+	registryCredentialsNamespace string
+	registryCredentials          *registryCredentials
+
+	// policyClient is used to watch VersionCheckPolicy resources, if set.
+	policyClient dynamic.Interface
+	policies     *versionCheckPolicies
+
+	// scope restricts the pods checked by namespace and labels.
+	scope *podScope
+
+	// workloadClient is used to watch the resources of workloads, whose pod
+	// templates are checked alongside pods.
+	workloadClient  dynamic.Interface
+	workloads       map[schema.GroupVersionResource]workload
+	workloadListers map[schema.GroupVersionResource]cache.GenericLister
+
+	// workloadSpecs are additional workloads, of the form
+	// group/version/Kind=jsonpath, whose images are found by the JSONPath.
+	workloadSpecs []string
+}
+
+// Options configure the optional features of the controller. The zero value
+// checks every pod with the default number of workers.
+type Options struct {
+	// UsePullSecrets resolves registry credentials from the image pull
+	// secrets of pods.
+	UsePullSecrets bool
+
+	// DynamicClient, if set, is used to watch RegistryCredential resources,
+	// which may only reference secrets in RegistryCredentialsNamespace.
+	DynamicClient                dynamic.Interface
+	RegistryCredentialsNamespace string
+
+	// TagCache caches the tags of images. If nil, tags are cached in memory.
+	TagCache vcache.Cache
+
+	// RegistryCacheTimeouts are the cache timeouts of images of each registry
+	// host, in place of the cache timeout.
+	RegistryCacheTimeouts map[string]time.Duration
+
+	// Workers is the number of pods processed concurrently. Defaults to 5.
+	Workers int
+
+	// ExcludeTagRegexes exclude tags of every image from the latest image
+	// search.
+	ExcludeTagRegexes []string
+
+	// TagPolicyConfigMap is the namespace/name of the ConfigMap holding the
+	// allowed and denied tags of repositories, if set.
+	TagPolicyConfigMap string
+
+	// PolicyClient, if set, is used to watch VersionCheckPolicy resources.
+	PolicyClient dynamic.Interface
+
+	// IncludeNamespaces, ExcludeNamespaces and PodSelector restrict the pods
+	// checked.
+	IncludeNamespaces []string
+	ExcludeNamespaces []string
+	PodSelector       labels.Selector
+
+	// WorkloadClient, if set, is used to watch the resources of workloads,
+	// whose pod templates are checked alongside pods. WatchRollouts and
+	// WatchKnative watch Argo Rollouts and Knative Services, and
+	// WorkloadSpecs are additional workloads, of the form
+	// group/version/Kind=jsonpath.
+	WorkloadClient dynamic.Interface
+	WatchRollouts  bool
+	WatchKnative   bool
+	WorkloadSpecs  []string
 }
 
 func New(
@@ -51,16 +177,52 @@ func New(
 	kubeClient kubernetes.Interface,
 	log *logrus.Entry,
 	defaultTestAll bool,
+	opts Options,
 ) *Controller {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
 	c := &Controller{
 		log:            log.WithField("module", "controller"),
 		kubeClient:     kubeClient,
 		workqueue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
-		versionGetter:  version.New(log, imageClient, cacheTimeout),
+		versionGetter:  version.New(log, imageClient, cacheTimeout, opts.TagCache),
 		metrics:        metrics,
 		cacheTimeout:   cacheTimeout,
 		imageCache:     make(map[string]imageCacheItem),
+		lookups:        newLookupGroup(),
+		workers:        workers,
 		defaultTestAll: defaultTestAll,
+		dynamicClient:  opts.DynamicClient,
+		policyClient:   opts.PolicyClient,
+		scope:          newPodScope(opts.IncludeNamespaces, opts.ExcludeNamespaces, opts.PodSelector),
+		workloadClient: opts.WorkloadClient,
+		workloads:      make(map[schema.GroupVersionResource]workload),
+		workloadSpecs:  opts.WorkloadSpecs,
+
+		registryCacheTimeouts:        opts.RegistryCacheTimeouts,
+		registryCredentialsNamespace: opts.RegistryCredentialsNamespace,
+		excludeTagRegexes:            opts.ExcludeTagRegexes,
+		tagPolicyConfigMap:           opts.TagPolicyConfigMap,
+	}
+
+	if imageClient != nil {
+		c.digests = imageClient
+	}
+
+	if opts.WatchRollouts {
+		c.workloads[rolloutWorkload.resource] = rolloutWorkload
+	}
+
+	if opts.WatchKnative {
+		c.workloads[knativeServiceWorkload.resource] = knativeServiceWorkload
+		c.workloads[knativeRevisionWorkload.resource] = knativeRevisionWorkload
+	}
+
+	if opts.UsePullSecrets {
+		c.pullSecrets = newPullSecretCache(kubeClient, cacheTimeout)
 	}
 
 	return c
@@ -71,23 +233,79 @@ func (c *Controller) Run(ctx context.Context) error {
 	defer c.workqueue.ShutDown()
 
 	sharedInformerFactory := informers.NewSharedInformerFactoryWithOptions(c.kubeClient, time.Second*30)
-	c.podLister = sharedInformerFactory.Core().V1().Pods().Lister()
-	podInformer := sharedInformerFactory.Core().V1().Pods().Informer()
+
+	// Pods are listed by the pod selector, so that pods which no longer match
+	// are deleted from the informer, and their metrics removed. Only the pods
+	// of a single included namespace are listed.
+	podInformerOpts := []informers.SharedInformerOption{
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = c.scope.podSelector.String()
+		}),
+	}
+	if len(c.scope.includeNamespaces) == 1 {
+		for ns := range c.scope.includeNamespaces {
+			podInformerOpts = append(podInformerOpts, informers.WithNamespace(ns))
+		}
+	}
+	podInformerFactory := informers.NewSharedInformerFactoryWithOptions(c.kubeClient, time.Second*30,
+		podInformerOpts...)
+	c.podLister = podInformerFactory.Core().V1().Pods().Lister()
+	podInformer := podInformerFactory.Core().V1().Pods().Informer()
+	c.nodeLister = sharedInformerFactory.Core().V1().Nodes().Lister()
+	nodeInformer := sharedInformerFactory.Core().V1().Nodes().Informer()
+	c.namespaceLister = sharedInformerFactory.Core().V1().Namespaces().Lister()
+	namespaceInformer := sharedInformerFactory.Core().V1().Namespaces().Informer()
+	enqueue := func(obj interface{}) {
+		if c.scope.contains(obj) {
+			c.workqueue.Add(obj)
+		}
+	}
 	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.workqueue.Add(obj) },
-		UpdateFunc: func(_, obj interface{}) { c.workqueue.Add(obj) },
-		DeleteFunc: func(obj interface{}) { c.workqueue.Add(obj) },
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+		DeleteFunc: enqueue,
 	})
 
 	c.log.Info("starting control loop")
 	sharedInformerFactory.Start(ctx.Done())
-	if !cache.WaitForCacheSync(ctx.Done(), podInformer.HasSynced) {
+	podInformerFactory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), podInformer.HasSynced, nodeInformer.HasSynced,
+		namespaceInformer.HasSynced) {
 		return fmt.Errorf("error waiting for informer caches to sync")
 	}
 
+	if c.dynamicClient != nil {
+		if err := c.watchRegistryCredentials(ctx); err != nil {
+			return err
+		}
+	}
+
+	if c.workloadClient != nil {
+		if err := c.resolveWorkloads(); err != nil {
+			return err
+		}
+
+		if len(c.workloads) > 0 {
+			if err := c.watchWorkloads(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.policyClient != nil {
+		if err := c.watchPolicies(ctx); err != nil {
+			return err
+		}
+	}
+
+	if len(c.tagPolicyConfigMap) > 0 {
+		if err := c.watchTagPolicy(ctx); err != nil {
+			return err
+		}
+	}
+
 	c.log.Info("starting workers")
-	// Launch two workers to process Foo resources
-	for i := 0; i < numWorkers; i++ {
+	for i := 0; i < c.workers; i++ {
 		go wait.Until(func() { c.runWorker(ctx) }, time.Second, ctx.Done())
 	}
 
@@ -98,6 +316,67 @@ func (c *Controller) Run(ctx context.Context) error {
 	return nil
 }
 
+// watchRegistryCredentials will watch RegistryCredential resources, keeping
+// the registry credentials used for all lookups up to date.
+func (c *Controller) watchRegistryCredentials(ctx context.Context) error {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(c.dynamicClient, time.Second*30)
+	informer := factory.ForResource(api.RegistryCredentialResource)
+
+	c.registryCredentials = &registryCredentials{
+		log:        c.log.WithField("module", "registry_credentials"),
+		kubeClient: c.kubeClient,
+		lister:     informer.Lister(),
+		namespace:  c.registryCredentialsNamespace,
+	}
+
+	// Every event, including the resync of every resource which re-reads
+	// secrets to pick up rotations, queues the same key, so that a burst of
+	// events results in a single rebuild.
+	queue := workqueue.New()
+	go func() {
+		<-ctx.Done()
+		queue.ShutDown()
+	}()
+
+	enqueue := func(interface{}) { queue.Add(registryCredentialsKey) }
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+		DeleteFunc: enqueue,
+	})
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		return fmt.Errorf("error waiting for registry credential informer cache to sync")
+	}
+	c.registryCredentials.rebuild(ctx)
+
+	go func() {
+		for {
+			key, shutdown := queue.Get()
+			if shutdown {
+				return
+			}
+			c.registryCredentials.rebuild(ctx)
+			queue.Done(key)
+		}
+	}()
+
+	return nil
+}
+
+// removePodMetrics will remove the metrics of every container of the pod.
+func (c *Controller) removePodMetrics(pod *corev1.Pod) {
+	for _, container := range podContainers(pod) {
+		imageURL, currentTag := urlAndTagFromImage(container.Image)
+
+		c.log.Debugf("removing deleted container from metrics: %s/%s/%s: %s:%s",
+			pod.Namespace, pod.Name, container.Name, imageURL, currentTag)
+		c.metrics.RemoveImage(pod.Namespace, pod.Name, container.Name, container.containerType,
+			imageURL, currentTag)
+	}
+}
+
 // runWorker is a long-running function that will continually call the
 // processNextWorkItem function in order to read and process a message on the
 // workqueue.
@@ -115,36 +394,47 @@ func (c *Controller) runWorker(ctx context.Context) {
 }
 
 // processNextWorkItem will read a single work item off the workqueue and
-// attempt to process it, by calling the syncHandler.
+// attempt to process it, by calling the syncHandler. Items are either pods, or
+// the keys of workload resources whose pod templates are checked.
 func (c *Controller) processNextWorkItem(ctx context.Context, obj interface{}) error {
 	defer c.workqueue.Done(obj)
 
-	pod, ok := obj.(*corev1.Pod)
-	if !ok {
-		c.log.Errorf("non-pod type passed to sync: %+v", obj)
-		c.workqueue.Forget(obj)
-		return nil
-	}
+	var pod *corev1.Pod
+	switch item := obj.(type) {
+	case *corev1.Pod:
+		if _, err := c.podLister.Pods(item.Namespace).Get(item.Name); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
 
-	if _, err := c.podLister.Pods(pod.Namespace).Get(pod.Name); err != nil {
-		if !apierrors.IsNotFound(err) {
-			return err
+			// If the pod has been deleted, remove from metrics
+			c.removePodMetrics(item)
+			return nil
 		}
+		pod = item
 
-		// If the pod has been deleted, remove from metrics
-		for _, container := range pod.Spec.Containers {
-			imageURL, currentTag := urlAndTagFromImage(container.Image)
+	case workloadKey:
+		workloadPod, err := c.workloadPod(item)
+		if err != nil {
+			c.workqueue.Forget(obj)
+			return fmt.Errorf("failed to get pod template of %s: %s", item, err)
+		}
 
-			c.log.Debugf("removing deleted container from metrics: %s/%s/%s: %s:%s",
-				pod.Namespace, pod.Name, container.Name, imageURL, currentTag)
-			c.metrics.RemoveImage(pod.Namespace, pod.Name, container.Name, imageURL, currentTag)
+		// Metrics of deleted workloads are removed by their informer.
+		if workloadPod == nil {
+			c.workqueue.Forget(obj)
+			return nil
 		}
+		pod = workloadPod
 
+	default:
+		c.log.Errorf("non-pod type passed to sync: %+v", obj)
+		c.workqueue.Forget(obj)
 		return nil
 	}
 
-	if err := c.sync(ctx, pod); err != nil {
-		c.workqueue.AddAfter(pod, time.Second*20)
+	if err := c.sync(ctx, obj, pod); err != nil {
+		c.workqueue.AddAfter(obj, time.Second*20)
 		return fmt.Errorf("error syncing '%s/%s': %s, requeuing",
 			pod.Name, pod.Namespace, err)
 	}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// versionCheckPolicies holds the VersionCheckPolicy resources, rebuilt
+// whenever a resource changes.
+type versionCheckPolicies struct {
+	log    *logrus.Entry
+	lister cache.GenericLister
+
+	mu       sync.RWMutex
+	policies []*api.VersionCheckPolicy
+}
+
+// rebuild will decode all VersionCheckPolicy resources, ordered by name. A
+// resource failing to decode is logged and skipped.
+func (v *versionCheckPolicies) rebuild() {
+	objs, err := v.lister.List(labels.Everything())
+	if err != nil {
+		v.log.Errorf("failed to list version check policies: %s", err)
+		return
+	}
+
+	var policies []*api.VersionCheckPolicy
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		policy := new(api.VersionCheckPolicy)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, policy); err != nil {
+			v.log.Errorf("failed to decode version check policy %q: %s", u.GetName(), err)
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+
+	v.mu.Lock()
+	v.policies = policies
+	v.mu.Unlock()
+}
+
+// annotations returns the annotations set by the policies selecting the
+// container's namespace and image. Policies of the namespace take precedence
+// over those of every namespace. When more than one policy of the same
+// precedence sets an option, the first by name is used.
+func (v *versionCheckPolicies) annotations(namespace, containerName, imageURL string) map[string]string {
+	annotations := make(map[string]string)
+	if v == nil {
+		return annotations
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var clusterPolicies, namespacePolicies []*api.VersionCheckPolicy
+	for _, policy := range v.policies {
+		if !policySelectsImage(policy, imageURL) {
+			continue
+		}
+
+		if len(policy.Spec.Namespaces) == 0 {
+			clusterPolicies = append(clusterPolicies, policy)
+			continue
+		}
+
+		for _, ns := range policy.Spec.Namespaces {
+			if ns == namespace {
+				namespacePolicies = append(namespacePolicies, policy)
+				break
+			}
+		}
+	}
+
+	for _, policies := range [][]*api.VersionCheckPolicy{clusterPolicies, namespacePolicies} {
+		set := make(map[string]string)
+		for _, policy := range policies {
+			for key, value := range policy.Spec.Annotations(containerName) {
+				if _, ok := set[key]; !ok {
+					set[key] = value
+				}
+			}
+		}
+
+		for key, value := range set {
+			annotations[key] = value
+		}
+	}
+
+	return annotations
+}
+
+// policySelectsImage returns true if the policy applies to the image URL. Image
+// patterns match either the image URL as given, or its full repository, so
+// that docker.io/library/* matches nginx.
+func policySelectsImage(policy *api.VersionCheckPolicy, imageURL string) bool {
+	if len(policy.Spec.Images) == 0 {
+		return true
+	}
+
+	for _, pattern := range policy.Spec.Images {
+		for _, name := range []string{imageURL, repositoryKey(imageURL)} {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// watchPolicies will watch VersionCheckPolicy resources, keeping the policies
+// applied to containers up to date.
+func (c *Controller) watchPolicies(ctx context.Context) error {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(c.policyClient, time.Second*30)
+	informer := factory.ForResource(api.VersionCheckPolicyResource)
+
+	c.policies = &versionCheckPolicies{
+		log:    c.log.WithField("module", "version_check_policies"),
+		lister: informer.Lister(),
+	}
+
+	rebuild := func(interface{}) { c.policies.rebuild() }
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    rebuild,
+		UpdateFunc: func(_, obj interface{}) { rebuild(obj) },
+		DeleteFunc: rebuild,
+	})
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		return fmt.Errorf("error waiting for version check policy informer cache to sync")
+	}
+	c.policies.rebuild()
+
+	return nil
+}
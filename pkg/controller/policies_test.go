@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestContainerAnnotations(t *testing.T) {
+	newPolicy := func(name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "version-checker.io/v1alpha1",
+			"kind":       "VersionCheckPolicy",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       spec,
+		}}
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, obj := range []*unstructured.Unstructured{
+		newPolicy("a-cluster", map[string]interface{}{
+			"cacheTimeout": "2h",
+			"useMetaData":  true,
+		}),
+		// Sorts after a-cluster, so only sets options a-cluster does not.
+		newPolicy("b-cluster", map[string]interface{}{
+			"cacheTimeout": "1h",
+			"matchOS":      "linux",
+		}),
+		newPolicy("c-legacy-nginx", map[string]interface{}{
+			"namespaces":  []interface{}{"legacy"},
+			"images":      []interface{}{"docker.io/library/*"},
+			"pinMajor":    int64(1),
+			"useMetaData": false,
+		}),
+		newPolicy("d-quay", map[string]interface{}{
+			"images":     []interface{}{"quay.io/jetstack/*"},
+			"matchRegex": "^v",
+		}),
+	} {
+		if err := indexer.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	policies := &versionCheckPolicies{
+		log:    logrus.NewEntry(logrus.New()),
+		lister: cache.NewGenericLister(indexer, api.VersionCheckPolicyResource.GroupResource()),
+	}
+	policies.rebuild()
+	c := &Controller{policies: policies}
+
+	tests := map[string]struct {
+		namespace      string
+		image          string
+		podAnnotations map[string]string
+		exp            map[string]string
+	}{
+		"cluster policies should apply, with the first by name taking precedence": {
+			namespace: "default",
+			image:     "nginx:1.19",
+			exp: map[string]string{
+				"cache-timeout.version-checker.io/app": "2h",
+				"use-metadata.version-checker.io/app":  "true",
+				"match-os.version-checker.io/app":      "linux",
+			},
+		},
+		"namespace policy should take precedence over cluster policies": {
+			namespace: "legacy",
+			image:     "nginx:1.19",
+			exp: map[string]string{
+				"cache-timeout.version-checker.io/app": "2h",
+				"use-metadata.version-checker.io/app":  "false",
+				"match-os.version-checker.io/app":      "linux",
+				"pin-major.version-checker.io/app":     "1",
+			},
+		},
+		"pod annotations should take precedence over policies": {
+			namespace: "legacy",
+			image:     "nginx:1.19",
+			podAnnotations: map[string]string{
+				"pin-major.version-checker.io/app": "2",
+				"enable.version-checker.io/app":    "true",
+			},
+			exp: map[string]string{
+				"cache-timeout.version-checker.io/app": "2h",
+				"use-metadata.version-checker.io/app":  "false",
+				"match-os.version-checker.io/app":      "linux",
+				"pin-major.version-checker.io/app":     "2",
+				"enable.version-checker.io/app":        "true",
+			},
+		},
+		"policies should only apply to selected images": {
+			namespace: "legacy",
+			image:     "quay.io/jetstack/version-checker:v0.2.0",
+			exp: map[string]string{
+				"cache-timeout.version-checker.io/app": "2h",
+				"use-metadata.version-checker.io/app":  "true",
+				"match-os.version-checker.io/app":      "linux",
+				"match-regex.version-checker.io/app":   "^v",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   test.namespace,
+				Annotations: test.podAnnotations,
+			}}
+
+			annotations := c.containerAnnotations(policies.log, pod, "app", test.image)
+			if !reflect.DeepEqual(annotations, test.exp) {
+				t.Errorf("unexpected annotations, exp=%v got=%v", test.exp, annotations)
+			}
+		})
+	}
+}
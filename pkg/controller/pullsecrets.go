@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+// pullSecretCacheItem is the registry credentials parsed from a single image
+// pull secret.
+type pullSecretCacheItem struct {
+	timestamp time.Time
+	creds     map[string]util.Credentials
+}
+
+// pullSecretCache resolves the registry credentials of pods from their image
+// pull secrets. Parsed secrets are cached, and fetched again after the
+// timeout.
+type pullSecretCache struct {
+	kubeClient kubernetes.Interface
+	timeout    time.Duration
+
+	mu    sync.Mutex
+	items map[string]pullSecretCacheItem
+}
+
+func newPullSecretCache(kubeClient kubernetes.Interface, timeout time.Duration) *pullSecretCache {
+	return &pullSecretCache{
+		kubeClient: kubeClient,
+		timeout:    timeout,
+		items:      make(map[string]pullSecretCacheItem),
+	}
+}
+
+// credentials will return the registry credentials of each host in the
+// image pull secrets of the pod. The first secret with credentials for a host
+// is used. Secrets which don't exist are ignored, as they are by the kubelet.
+func (p *pullSecretCache) credentials(ctx context.Context, pod *corev1.Pod) (map[string]util.Credentials, error) {
+	creds := make(map[string]util.Credentials)
+	for _, ref := range pod.Spec.ImagePullSecrets {
+		secretCreds, err := p.secretCredentials(ctx, pod.Namespace, ref.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get image pull secret %s/%s: %s",
+				pod.Namespace, ref.Name, err)
+		}
+
+		for host, cred := range secretCreds {
+			if _, ok := creds[host]; !ok {
+				creds[host] = cred
+			}
+		}
+	}
+
+	return creds, nil
+}
+
+// secretCredentials will return the parsed credentials of the secret, from
+// the cache if fresh.
+func (p *pullSecretCache) secretCredentials(ctx context.Context, namespace, name string) (map[string]util.Credentials, error) {
+	key := namespace + "/" + name
+
+	p.mu.Lock()
+	item, ok := p.items[key]
+	p.mu.Unlock()
+
+	if ok && item.timestamp.Add(p.timeout).After(time.Now()) {
+		return item.creds, nil
+	}
+
+	secret, err := p.kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var creds map[string]util.Credentials
+	if secret != nil {
+		creds, err = parsePullSecret(secret)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p.mu.Lock()
+	p.items[key] = pullSecretCacheItem{time.Now(), creds}
+	p.mu.Unlock()
+
+	return creds, nil
+}
+
+// parsePullSecret will parse the registry credentials of a
+// kubernetes.io/dockerconfigjson or kubernetes.io/dockercfg secret, by
+// registry host.
+func parsePullSecret(secret *corev1.Secret) (map[string]util.Credentials, error) {
+	var entries map[string]util.DockerConfigEntry
+
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		var config util.DockerConfig
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %s", corev1.DockerConfigJsonKey, err)
+		}
+		entries = config.Auths
+
+	case corev1.SecretTypeDockercfg:
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %s", corev1.DockerConfigKey, err)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported secret type %q", secret.Type)
+	}
+
+	creds := make(map[string]util.Credentials)
+	for server, entry := range entries {
+		cred, err := entry.Credentials()
+		if err != nil {
+			return nil, fmt.Errorf("invalid credentials of %q: %s", server, err)
+		}
+
+		creds[util.RegistryHost(server)] = cred
+	}
+
+	return creds, nil
+}
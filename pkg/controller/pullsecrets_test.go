@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+	"github.com/jetstack/version-checker/pkg/metrics"
+	"github.com/jetstack/version-checker/pkg/version"
+)
+
+func TestParsePullSecret(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pa:ss"))
+
+	tests := map[string]struct {
+		secret   *corev1.Secret
+		expCreds map[string]util.Credentials
+		expErr   bool
+	}{
+		"dockerconfigjson with auth and username/password": {
+			secret: &corev1.Secret{
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					corev1.DockerConfigJsonKey: []byte(`{"auths":{
+						"https://index.docker.io/v1/":{"auth":"` + auth + `"},
+						"registry.corp:5000":{"username":"robot","password":"secret"}
+					}}`),
+				},
+			},
+			expCreds: map[string]util.Credentials{
+				"docker.io":          {Username: "user", Password: "pa:ss"},
+				"registry.corp:5000": {Username: "robot", Password: "secret"},
+			},
+		},
+		"dockercfg": {
+			secret: &corev1.Secret{
+				Type: corev1.SecretTypeDockercfg,
+				Data: map[string][]byte{
+					corev1.DockerConfigKey: []byte(`{"https://registry.corp/v1/":{"auth":"` + auth + `"}}`),
+				},
+			},
+			expCreds: map[string]util.Credentials{
+				"registry.corp": {Username: "user", Password: "pa:ss"},
+			},
+		},
+		"malformed auth should error": {
+			secret: &corev1.Secret{
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					corev1.DockerConfigJsonKey: []byte(`{"auths":{"registry.corp":{"auth":"` +
+						base64.StdEncoding.EncodeToString([]byte("user")) + `"}}}`),
+				},
+			},
+			expErr: true,
+		},
+		"opaque secret should error": {
+			secret: &corev1.Secret{Type: corev1.SecretTypeOpaque},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			creds, err := parsePullSecret(test.secret)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			if !reflect.DeepEqual(creds, test.expCreds) {
+				t.Errorf("unexpected credentials, exp=%v got=%v", test.expCreds, creds)
+			}
+		})
+	}
+}
+
+func TestPullSecretCacheCredentials(t *testing.T) {
+	newSecret := func(name, config string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(config)},
+		}
+	}
+
+	kubeClient := fake.NewSimpleClientset(
+		newSecret("first", `{"auths":{"registry.corp":{"username":"first","password":"pass"}}}`),
+		newSecret("second", `{"auths":{
+			"registry.corp":{"username":"second","password":"pass"},
+			"quay.io":{"username":"second","password":"pass"}
+		}}`),
+	)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "pod"},
+		Spec: corev1.PodSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{
+				{Name: "missing"}, {Name: "first"}, {Name: "second"},
+			},
+		},
+	}
+
+	cache := newPullSecretCache(kubeClient, time.Minute)
+
+	exp := map[string]util.Credentials{
+		"registry.corp": {Username: "first", Password: "pass"},
+		"quay.io":       {Username: "second", Password: "pass"},
+	}
+
+	creds, err := cache.credentials(context.TODO(), pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(creds, exp) {
+		t.Errorf("unexpected credentials, exp=%v got=%v", exp, creds)
+	}
+
+	// Parsed secrets should be served from the cache.
+	if err := kubeClient.CoreV1().Secrets("test").Delete(context.TODO(), "first", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err = cache.credentials(context.TODO(), pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(creds, exp) {
+		t.Errorf("unexpected cached credentials, exp=%v got=%v", exp, creds)
+	}
+}
+
+// credentialsTagLister records the credentials of registry.corp of every
+// lookup.
+type credentialsTagLister struct {
+	mu    sync.Mutex
+	users []string
+}
+
+func (c *credentialsTagLister) Tags(ctx context.Context, _ string) ([]api.ImageTag, error) {
+	cred, _ := util.CredentialsFor(ctx, "registry.corp")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users = append(c.users, cred.Username)
+
+	return []api.ImageTag{{Tag: "v1.0.0"}}, nil
+}
+
+func TestSyncPullSecretCredentials(t *testing.T) {
+	newSecret := func(namespace, username string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "registry"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(
+				`{"auths":{"registry.corp":{"username":"` + username + `","password":"pass"}}}`)},
+		}
+	}
+	newPod := func(namespace string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "app"},
+			Spec: corev1.PodSpec{
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				Containers:       []corev1.Container{{Name: "app", Image: "registry.corp/team/app:v1.0.0"}},
+			},
+		}
+	}
+
+	kubeClient := fake.NewSimpleClientset(newSecret("team-a", "a"), newSecret("team-b", "b"))
+	lister := new(credentialsTagLister)
+	log := logrus.NewEntry(logrus.New())
+
+	c := &Controller{
+		log:            log,
+		workqueue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		versionGetter:  version.New(log, lister, time.Minute, nil),
+		metrics:        metrics.New(log),
+		cacheTimeout:   time.Minute,
+		imageCache:     make(map[string]imageCacheItem),
+		lookups:        newLookupGroup(),
+		defaultTestAll: true,
+		pullSecrets:    newPullSecretCache(kubeClient, time.Minute),
+	}
+	defer c.workqueue.ShutDown()
+
+	// The pod of team-a is synced again, which should be served from the
+	// cache of its own credentials.
+	for _, pod := range []*corev1.Pod{newPod("team-a"), newPod("team-b"), newPod("team-a")} {
+		if err := c.sync(context.TODO(), pod.Namespace+"/"+pod.Name, pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if exp := []string{"a", "b"}; !reflect.DeepEqual(lister.users, exp) {
+		t.Errorf("expected a lookup with the credentials of each pod, exp=%v got=%v", exp, lister.users)
+	}
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+// registryCredentials holds the registry credentials declared by
+// RegistryCredential resources. Credentials are rebuilt whenever a resource
+// changes, and on every informer resync to pick up rotated secrets.
+type registryCredentials struct {
+	log        *logrus.Entry
+	kubeClient kubernetes.Interface
+	lister     cache.GenericLister
+
+	// namespace is the only namespace whose secrets may be referenced, so
+	// that resources cannot expose the secrets of other namespaces.
+	namespace string
+
+	mu    sync.RWMutex
+	creds map[string]util.Credentials
+}
+
+// get will return the current credentials of each registry host.
+func (r *registryCredentials) get() map[string]util.Credentials {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.creds
+}
+
+// rebuild will resolve the secrets of all RegistryCredential resources. A
+// resource failing to resolve is logged and skipped, keeping the credentials
+// of all others. When more than one resource declares a host, the first by
+// name is used.
+func (r *registryCredentials) rebuild(ctx context.Context) {
+	objs, err := r.lister.List(labels.Everything())
+	if err != nil {
+		r.log.Errorf("failed to list registry credentials: %s", err)
+		return
+	}
+
+	var resources []*api.RegistryCredential
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		resource := new(api.RegistryCredential)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, resource); err != nil {
+			r.log.Errorf("failed to decode registry credential %q: %s", u.GetName(), err)
+			continue
+		}
+		resources = append(resources, resource)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].Name < resources[j].Name
+	})
+
+	creds := make(map[string]util.Credentials)
+	for _, resource := range resources {
+		resourceCreds, err := r.resolve(ctx, resource)
+		if err != nil {
+			r.log.Errorf("failed to resolve registry credential %q: %s", resource.Name, err)
+			continue
+		}
+
+		for host, cred := range resourceCreds {
+			if _, ok := creds[host]; !ok {
+				creds[host] = cred
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.creds = creds
+	r.mu.Unlock()
+}
+
+// resolve will return the credentials of each host declared by the resource.
+func (r *registryCredentials) resolve(ctx context.Context, resource *api.RegistryCredential) (map[string]util.Credentials, error) {
+	ref := resource.Spec.SecretRef
+	if ref.Namespace != r.namespace {
+		return nil, fmt.Errorf("secret %s/%s is not in the registry credentials namespace %q",
+			ref.Namespace, ref.Name, r.namespace)
+	}
+
+	secret, err := r.kubeClient.CoreV1().Secrets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s/%s: %s", ref.Namespace, ref.Name, err)
+	}
+
+	if secret.Type == corev1.SecretTypeBasicAuth {
+		if len(resource.Spec.Host) == 0 {
+			return nil, fmt.Errorf("host is required for %q secrets", secret.Type)
+		}
+
+		return map[string]util.Credentials{
+			resource.Spec.Host: {
+				Username: string(secret.Data[corev1.BasicAuthUsernameKey]),
+				Password: string(secret.Data[corev1.BasicAuthPasswordKey]),
+			},
+		}, nil
+	}
+
+	creds, err := parsePullSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse secret %s/%s: %s", ref.Namespace, ref.Name, err)
+	}
+
+	if len(resource.Spec.Host) == 0 {
+		return creds, nil
+	}
+
+	cred, ok := creds[resource.Spec.Host]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no credentials for %q",
+			ref.Namespace, ref.Name, resource.Spec.Host)
+	}
+
+	return map[string]util.Credentials{resource.Spec.Host: cred}, nil
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+func TestRegistryCredentialsRebuild(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "creds", Name: "basic"},
+			Type:       corev1.SecretTypeBasicAuth,
+			Data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte("user"),
+				corev1.BasicAuthPasswordKey: []byte("pass"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "creds", Name: "config"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{
+				"registry.corp:5000":{"username":"config","password":"pass"},
+				"quay.io":{"username":"config","password":"pass"}
+			}}`)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "basic"},
+			Type:       corev1.SecretTypeBasicAuth,
+			Data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte("other"),
+				corev1.BasicAuthPasswordKey: []byte("pass"),
+			},
+		},
+	)
+
+	newResource := func(name, host, namespace, secret string) *unstructured.Unstructured {
+		spec := map[string]interface{}{
+			"secretRef": map[string]interface{}{"namespace": namespace, "name": secret},
+		}
+		if len(host) > 0 {
+			spec["host"] = host
+		}
+
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "version-checker.io/v1alpha1",
+			"kind":       "RegistryCredential",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       spec,
+		}}
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, obj := range []*unstructured.Unstructured{
+		// Sorts first, so takes precedence for registry.corp:5000.
+		newResource("a-basic", "registry.corp:5000", "creds", "basic"),
+		newResource("b-config", "", "creds", "config"),
+		newResource("c-missing-secret", "missing.corp", "creds", "missing"),
+		newResource("d-basic-without-host", "", "creds", "basic"),
+		newResource("e-other-namespace", "other.corp", "other", "basic"),
+	} {
+		if err := indexer.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	creds := &registryCredentials{
+		log:        logrus.NewEntry(logrus.New()),
+		kubeClient: kubeClient,
+		lister:     cache.NewGenericLister(indexer, api.RegistryCredentialResource.GroupResource()),
+		namespace:  "creds",
+	}
+	creds.rebuild(context.TODO())
+
+	exp := map[string]util.Credentials{
+		"registry.corp:5000": {Username: "user", Password: "pass"},
+		"quay.io":            {Username: "config", Password: "pass"},
+	}
+	if got := creds.get(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected credentials, exp=%v got=%v", exp, got)
+	}
+}
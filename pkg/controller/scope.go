@@ -0,0 +1,55 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// podScope restricts the pods checked to those of the included namespaces, if
+// any, which are not excluded, and whose labels match the selector.
+type podScope struct {
+	includeNamespaces map[string]bool
+	excludeNamespaces map[string]bool
+	podSelector       labels.Selector
+}
+
+func newPodScope(includeNamespaces, excludeNamespaces []string, podSelector labels.Selector) *podScope {
+	scope := &podScope{
+		includeNamespaces: make(map[string]bool),
+		excludeNamespaces: make(map[string]bool),
+		podSelector:       podSelector,
+	}
+
+	for _, ns := range includeNamespaces {
+		scope.includeNamespaces[ns] = true
+	}
+	for _, ns := range excludeNamespaces {
+		scope.excludeNamespaces[ns] = true
+	}
+
+	if scope.podSelector == nil {
+		scope.podSelector = labels.Everything()
+	}
+
+	return scope
+}
+
+// contains returns true if the pod is in scope, and so should be checked.
+// Objects which are not pods, such as tombstones of deleted pods, are always
+// in scope.
+func (s *podScope) contains(obj interface{}) bool {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || s == nil {
+		return true
+	}
+
+	if len(s.includeNamespaces) > 0 && !s.includeNamespaces[pod.Namespace] {
+		return false
+	}
+
+	if s.excludeNamespaces[pod.Namespace] {
+		return false
+	}
+
+	return s.podSelector.Matches(labels.Set(pod.Labels))
+}
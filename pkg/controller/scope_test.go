@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestPodScopeContains(t *testing.T) {
+	selector, err := labels.Parse("tier=production,!canary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPod := func(namespace string, podLabels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "pod",
+			Labels:    podLabels,
+		}}
+	}
+
+	tests := map[string]struct {
+		scope *podScope
+		obj   interface{}
+		exp   bool
+	}{
+		"empty scope should contain every pod": {
+			scope: newPodScope(nil, nil, nil),
+			obj:   newPod("default", nil),
+			exp:   true,
+		},
+		"pod of an included namespace should be contained": {
+			scope: newPodScope([]string{"prod", "payments"}, nil, nil),
+			obj:   newPod("payments", nil),
+			exp:   true,
+		},
+		"pod of another namespace should not be contained": {
+			scope: newPodScope([]string{"prod", "payments"}, nil, nil),
+			obj:   newPod("default", nil),
+			exp:   false,
+		},
+		"pod of an excluded namespace should not be contained": {
+			scope: newPodScope(nil, []string{"kube-system"}, nil),
+			obj:   newPod("kube-system", nil),
+			exp:   false,
+		},
+		"exclusion should take precedence over inclusion": {
+			scope: newPodScope([]string{"prod"}, []string{"prod"}, nil),
+			obj:   newPod("prod", nil),
+			exp:   false,
+		},
+		"pod matching the selector should be contained": {
+			scope: newPodScope(nil, nil, selector),
+			obj:   newPod("prod", map[string]string{"tier": "production"}),
+			exp:   true,
+		},
+		"pod not matching the selector should not be contained": {
+			scope: newPodScope(nil, nil, selector),
+			obj:   newPod("prod", map[string]string{"tier": "production", "canary": "true"}),
+			exp:   false,
+		},
+		"tombstones should always be contained": {
+			scope: newPodScope([]string{"prod"}, nil, selector),
+			obj:   cache.DeletedFinalStateUnknown{Key: "default/pod"},
+			exp:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := test.scope.contains(test.obj); got != test.exp {
+				t.Errorf("unexpected contains, exp=%t got=%t", test.exp, got)
+			}
+		})
+	}
+}
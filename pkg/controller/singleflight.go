@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// lookupGroup shares the result of a latest image lookup with all identical
+// lookups made while it is in flight, so that pods running the same image
+// make one upstream call before the cache is populated.
+type lookupGroup struct {
+	mu    sync.Mutex
+	calls map[string]*lookupCall
+}
+
+type lookupCall struct {
+	done        chan struct{}
+	latestImage *api.ImageTag
+	err         error
+}
+
+func newLookupGroup() *lookupGroup {
+	return &lookupGroup{calls: make(map[string]*lookupCall)}
+}
+
+// do will call fn, unless a call with the same key is in flight, in which case
+// its result is waited for and returned instead. Returns true if the result
+// was shared from another call. Waiting returns early if the context is done.
+func (g *lookupGroup) do(ctx context.Context, key string, fn func() (*api.ImageTag, error)) (*api.ImageTag, bool, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+
+		select {
+		case <-call.done:
+			return call.latestImage, true, call.err
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+
+	call := &lookupCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.latestImage, call.err = fn()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+
+	return call.latestImage, false, call.err
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestLookupGroup(t *testing.T) {
+	g := newLookupGroup()
+
+	var calls int32
+	started, release := make(chan struct{}), make(chan struct{})
+	fn := func() (*api.ImageTag, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return &api.ImageTag{Tag: "v1.0.0"}, nil
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		shared int
+	)
+	lookup := func() {
+		defer wg.Done()
+		latest, isShared, err := g.do(context.TODO(), "search", fn)
+		if err != nil || latest.Tag != "v1.0.0" {
+			t.Errorf("unexpected result, got=%+v %v", latest, err)
+		}
+		mu.Lock()
+		if isShared {
+			shared++
+		}
+		mu.Unlock()
+	}
+
+	wg.Add(1)
+	go lookup()
+	<-started
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go lookup()
+	}
+	// Give the lookups time to join the call in flight.
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected concurrent lookups to share one call, exp=1 got=%d", calls)
+	}
+	if shared != 10 {
+		t.Errorf("unexpected shared lookups, exp=10 got=%d", shared)
+	}
+
+	// Calls after completion are not shared.
+	if _, isShared, err := g.do(context.TODO(), "search", fn); err != nil || isShared {
+		t.Errorf("expected completed call to not be shared, got=%t %v", isShared, err)
+	}
+	if calls != 2 {
+		t.Errorf("expected new call after completion, exp=2 got=%d", calls)
+	}
+}
+
+func TestLookupGroupContext(t *testing.T) {
+	g := newLookupGroup()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	go g.do(context.TODO(), "search", func() (*api.ImageTag, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if _, _, err := g.do(ctx, "search", nil); err != context.Canceled {
+		t.Errorf("expected waiting lookup to return when the context is done, exp=%v got=%v", context.Canceled, err)
+	}
+}
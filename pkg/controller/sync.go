@@ -6,21 +6,67 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+	"github.com/jetstack/version-checker/pkg/version"
 	"github.com/jetstack/version-checker/pkg/version/semver"
 )
 
-// sync will enqueue a given pod to run against the version checker.
-func (c *Controller) sync(ctx context.Context, pod *corev1.Pod) error {
+const (
+	// Container types of the containers of pods, exported as the
+	// container_type label.
+	containerTypeContainer = "container"
+	containerTypeInit      = "init"
+	containerTypeEphemeral = "ephemeral"
+)
+
+var (
+	// channelRegex matches valid release channels, being pre-release
+	// identifiers.
+	channelRegex = regexp.MustCompile(`^[a-z]+$`)
+)
+
+// sync will enqueue a given pod to run against the version checker. The pod
+// is requeued by its workqueue key, being the pod or the key of the workload
+// it is the pod template of.
+func (c *Controller) sync(ctx context.Context, key interface{}, pod *corev1.Pod) error {
 	log := c.log.WithField("name", pod.Name).WithField("namespace", pod.Namespace)
 
+	creds := make(map[string]util.Credentials)
+	if c.registryCredentials != nil {
+		for host, cred := range c.registryCredentials.get() {
+			creds[host] = cred
+		}
+	}
+
+	if c.pullSecrets != nil {
+		podCreds, err := c.pullSecrets.credentials(ctx, pod)
+		if err != nil {
+			// Requeue rather than looking up images without credentials.
+			return fmt.Errorf("failed to resolve image pull secrets of pod %s/%s: %s",
+				pod.Name, pod.Namespace, err)
+		}
+
+		// Pull secrets of the pod take precedence.
+		for host, cred := range podCreds {
+			creds[host] = cred
+		}
+	}
+	ctx = util.WithCredentials(ctx, creds)
+
+	// Check the pod again after the shortest cache timeout of its containers.
+	requeueAfter := c.cacheTimeout
+
 	var errs []string
-	for _, container := range pod.Spec.Containers {
-		enable, ok := pod.Annotations[api.EnableAnnotationKey+"/"+container.Name]
+	for _, container := range podContainers(pod) {
+		annotations := c.containerAnnotations(log, pod, container.Name, container.Image)
+
+		enable, ok := annotations[api.EnableAnnotationKey+"/"+container.Name]
 		if c.defaultTestAll {
 			// If default all and we explicitly disable, ignore
 			if ok && enable == "false" {
@@ -36,22 +82,31 @@ func (c *Controller) sync(ctx context.Context, pod *corev1.Pod) error {
 		log = log.WithField("container", container.Name)
 		log.Debug("processing conainer image")
 
-		opts, err := c.buildOptions(container.Name, pod.Annotations)
+		opts, err := c.buildOptions(container.Name, annotations)
 		if err != nil {
 			errs = append(errs, fmt.Sprintf("failed to build options from annotations for %q: %s",
 				container.Name, err))
 			continue
 		}
 
-		if err := c.testContainerImage(ctx, log, pod, &container, opts); err != nil {
+		cacheTimeout, err := c.containerCacheTimeout(container.Name, container.Image, annotations)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to parse cache timeout for %q: %s",
+				container.Name, err))
+			continue
+		}
+		if cacheTimeout < requeueAfter {
+			requeueAfter = cacheTimeout
+		}
+
+		if err := c.testContainerImage(ctx, log, pod, &container, opts, cacheTimeout); err != nil {
 			errs = append(errs, fmt.Sprintf("failed to test container image %q: %s",
 				container.Name, err))
 			continue
 		}
 	}
 
-	// Check the image tag again after the cache timeout.
-	c.workqueue.AddAfter(pod, c.cacheTimeout)
+	c.workqueue.AddAfter(key, requeueAfter)
 
 	if len(errs) > 0 {
 		return fmt.Errorf("failed to sync pod %s/%s: %s",
@@ -64,28 +119,72 @@ func (c *Controller) sync(ctx context.Context, pod *corev1.Pod) error {
 // testContainerImage will test a given image version to the latest image
 // available in the remote registry given the options.
 func (c *Controller) testContainerImage(ctx context.Context, log *logrus.Entry,
-	pod *corev1.Pod, container *corev1.Container, opts *api.Options) error {
+	pod *corev1.Pod, container *podContainer, opts *api.Options, cacheTimeout time.Duration) error {
 	imageURL, currentTag := urlAndTagFromImage(container.Image)
 
-	latestImage, err := c.getLatestImage(ctx, log, imageURL, opts)
+	// Only tags allowed by the tag policy of the repository are searched.
+	if ok, allowed := c.applyTagPolicy(imageURL, currentTag, opts); ok {
+		if !allowed {
+			log.Warnf("image tag %q is not allowed by the tag policy of %s", currentTag, imageURL)
+		}
+		c.metrics.ObserveTagAllowed(pod.Namespace, pod.Name, container.Name,
+			imageURL, currentTag, allowed)
+	}
+
+	// if container is using latest or '' image tag, the digest of the tag is
+	// compared, so only a HEAD request of its manifest is needed, unless the
+	// options require a search of every tag.
+	if statusTag := currentTag; c.digests != nil && !searchRequired(opts) &&
+		(statusTag == "latest" || statusTag == "") {
+		digest := containerImageDigest(pod, container.Name)
+		if digest == "" {
+			log.Errorf("image using %q tag, and image ID not yet set",
+				statusTag)
+			return nil
+		}
+
+		if statusTag == "" {
+			statusTag = "latest"
+		}
+
+		latestDigest, err := c.getTagDigest(ctx, imageURL, statusTag, cacheTimeout)
+		if err == nil {
+			log.Debugf("image using %q tag, comparing image SHA %q to tag digest %q",
+				statusTag, digest, latestDigest)
+
+			if digest == latestDigest {
+				log.Debugf("image is latest %s:%s", imageURL, digest)
+			} else {
+				log.Debugf("image is not latest %s: %s -> %s", imageURL, digest, latestDigest)
+			}
+
+			c.metrics.AddImage(pod.Namespace, pod.Name,
+				container.Name, container.containerType, imageURL, digest, latestDigest)
+
+			return nil
+		}
+
+		log.Debugf("failed to get digest of %q tag, falling back to searching tags: %s",
+			statusTag, err)
+	}
+
+	// Digests of multi-arch images differ per platform, so are compared
+	// against the image of the platform of the node running the container.
+	// Platforms set by annotation take precedence.
+	usingLatest := currentTag == "latest" || currentTag == ""
+	if (opts.UseSHA || usingLatest) && opts.Platform == nil {
+		opts.Platform = c.nodePlatform(log, pod)
+	}
+
+	latestImage, err := c.getLatestImage(ctx, log, imageURL, opts, cacheTimeout)
 	if err != nil {
 		return err
 	}
 
-	var (
-		latestTag string
-		isLatest  bool
-	)
-
 	// if container is using latest or '' image tag, compare SHA tag
 	if statusTag := currentTag; statusTag == "latest" ||
 		statusTag == "" {
-		for _, status := range pod.Status.ContainerStatuses {
-			if status.Name == container.Name {
-				_, currentTag = urlAndTagFromImage(status.ImageID)
-				break
-			}
-		}
+		currentTag = containerImageDigest(pod, container.Name)
 
 		if currentTag == "" {
 			log.Errorf("image using %q tag, and image ID not yet set",
@@ -98,25 +197,21 @@ func (c *Controller) testContainerImage(ctx context.Context, log *logrus.Entry,
 			statusTag, currentTag)
 	}
 
-	if opts.UseSHA {
-		// If we are using SHA then we can do a string comparison of the latest
-		if currentTag == latestImage.SHA {
-			isLatest = true
-		}
-
-		latestTag = latestImage.SHA
-	} else {
-		// Test against normal semvar
-		currentImage := semver.Parse(currentTag)
-		latestImageV := semver.Parse(latestImage.Tag)
-
-		if !currentImage.LessThan(latestImageV) {
-			isLatest = true
+	// The running image may be referenced by the digest of its manifest list,
+	// so is resolved to the image of the node's platform to compare with the
+	// latest image of the platform.
+	if opts.UseSHA && opts.Platform != nil && c.digests != nil &&
+		len(latestImage.Architecture) > 0 && currentTag != latestImage.SHA &&
+		strings.Contains(currentTag, ":") {
+		digest, err := c.getPlatformDigest(ctx, imageURL, currentTag, opts.Platform, cacheTimeout)
+		if err != nil {
+			log.Debugf("failed to resolve platform digest of %q: %s", currentTag, err)
+		} else {
+			currentTag = digest
 		}
-
-		latestTag = latestImage.Tag
 	}
 
+	isLatest, latestTag := version.IsLatest(opts, currentTag, latestImage)
 	if isLatest {
 		log.Debugf("image is latest %s:%s",
 			imageURL, currentTag)
@@ -126,13 +221,20 @@ func (c *Controller) testContainerImage(ctx context.Context, log *logrus.Entry,
 	}
 
 	c.metrics.AddImage(pod.Namespace, pod.Name,
-		container.Name, imageURL, currentTag, latestTag)
+		container.Name, container.containerType, imageURL, currentTag, latestTag)
 
 	return nil
 }
 
 // buildOptions will build the tag options based on pod annotations.
 func (c *Controller) buildOptions(containerName string, annotations map[string]string) (*api.Options, error) {
+	return BuildOptions(containerName, annotations, c.excludeTagRegexes)
+}
+
+// BuildOptions will build the tag options of a container from its version
+// checker annotations. The exclude tag regexes are applied alongside the
+// exclude regex annotation.
+func BuildOptions(containerName string, annotations map[string]string, excludeTagRegexes []string) (*api.Options, error) {
 	var (
 		opts      api.Options
 		errs      []string
@@ -148,6 +250,18 @@ func (c *Controller) buildOptions(containerName string, annotations map[string]s
 		opts.UseMetaData = true
 	}
 
+	if channel, ok := annotations[api.ChannelAnnotationKey+"/"+containerName]; ok {
+		setNonSha = true
+
+		channel = strings.ToLower(channel)
+		if !channelRegex.MatchString(channel) {
+			errs = append(errs, fmt.Sprintf("invalid channel %q at annotation %q, must be %q or a pre-release identifier such as rc",
+				channel, api.ChannelAnnotationKey+"/"+containerName, api.ChannelStable))
+		} else {
+			opts.Channel = &channel
+		}
+	}
+
 	if matchRegex, ok := annotations[api.MatchRegexAnnotationKey+"/"+containerName]; ok {
 		setNonSha = true
 
@@ -156,10 +270,34 @@ func (c *Controller) buildOptions(containerName string, annotations map[string]s
 			errs = append(errs, fmt.Sprintf("failed to compile regex at annotation %q: %s",
 				api.MatchRegexAnnotationKey, err))
 		} else {
+			opts.MatchRegex = &matchRegex
 			opts.RegexMatcher = regexMatcher
 		}
 	}
 
+	// Exclusions apply regardless of the other options, so may be used with
+	// use-sha.
+	excludes := append([]string{}, excludeTagRegexes...)
+	if excludeRegex, ok := annotations[api.ExcludeRegexAnnotationKey+"/"+containerName]; ok {
+		if _, err := regexp.Compile(excludeRegex); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to compile regex at annotation %q: %s",
+				api.ExcludeRegexAnnotationKey, err))
+		} else {
+			excludes = append(excludes, excludeRegex)
+		}
+	}
+	if len(excludes) > 0 {
+		excludeRegex := "(?:" + strings.Join(excludes, ")|(?:") + ")"
+
+		excludeMatcher, err := regexp.Compile(excludeRegex)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to compile exclude regexes: %s", err))
+		} else {
+			opts.ExcludeRegex = &excludeRegex
+			opts.ExcludeMatcher = excludeMatcher
+		}
+	}
+
 	if pinMajor, ok := annotations[api.PinMajorAnnotationKey+"/"+containerName]; ok {
 		setNonSha = true
 
@@ -210,6 +348,69 @@ func (c *Controller) buildOptions(containerName string, annotations map[string]s
 		}
 	}
 
+	if constraint, ok := annotations[api.SemverConstraintAnnotationKey+"/"+containerName]; ok {
+		setNonSha = true
+
+		matcher, err := semver.ParseConstraint(constraint)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to parse %s: %s",
+				api.SemverConstraintAnnotationKey+"/"+containerName, err))
+		} else {
+			opts.SemverConstraint = &constraint
+			opts.ConstraintMatcher = matcher
+		}
+	}
+
+	if tagOrdering, ok := annotations[api.TagOrderingAnnotationKey+"/"+containerName]; ok {
+		setNonSha = true
+
+		switch ordering := api.TagOrdering(tagOrdering); ordering {
+		case api.TagOrderingSemver, api.TagOrderingDebian, api.TagOrderingCalVer,
+			api.TagOrderingNumeric, api.TagOrderingLexical:
+			opts.TagOrdering = ordering
+		default:
+			errs = append(errs, fmt.Sprintf("unknown tag ordering %q at annotation %q, must be %q, %q, %q, %q or %q",
+				tagOrdering, api.TagOrderingAnnotationKey+"/"+containerName,
+				api.TagOrderingSemver, api.TagOrderingDebian, api.TagOrderingCalVer,
+				api.TagOrderingNumeric, api.TagOrderingLexical))
+		}
+	}
+
+	if buildIDRegex, ok := annotations[api.BuildIDRegexAnnotationKey+"/"+containerName]; ok {
+		setNonSha = true
+
+		buildIDMatcher, err := regexp.Compile(buildIDRegex)
+		switch {
+		case opts.TagOrdering != api.TagOrderingNumeric && opts.TagOrdering != api.TagOrderingLexical:
+			errs = append(errs, fmt.Sprintf("unable to set %q without setting %q to %q or %q",
+				api.BuildIDRegexAnnotationKey+"/"+containerName,
+				api.TagOrderingAnnotationKey+"/"+containerName,
+				api.TagOrderingNumeric, api.TagOrderingLexical))
+		case err != nil:
+			errs = append(errs, fmt.Sprintf("failed to compile regex at annotation %q: %s",
+				api.BuildIDRegexAnnotationKey, err))
+		case buildIDMatcher.NumSubexp() == 0:
+			errs = append(errs, fmt.Sprintf("regex at annotation %q must have a capture group",
+				api.BuildIDRegexAnnotationKey))
+		default:
+			opts.BuildIDRegex = &buildIDRegex
+			opts.BuildIDMatcher = buildIDMatcher
+		}
+	}
+
+	// Platform restrictions apply to both semver and SHA searches.
+	matchOS, okOS := annotations[api.MatchOSAnnotationKey+"/"+containerName]
+	matchArch, okArch := annotations[api.MatchArchitectureAnnotationKey+"/"+containerName]
+	if okOS || okArch {
+		if (okOS && len(matchOS) == 0) || (okArch && len(matchArch) == 0) {
+			errs = append(errs, fmt.Sprintf("%q and %q must not be empty",
+				api.MatchOSAnnotationKey+"/"+containerName,
+				api.MatchArchitectureAnnotationKey+"/"+containerName))
+		} else {
+			opts.Platform = &api.Platform{OS: matchOS, Architecture: matchArch}
+		}
+	}
+
 	if opts.UseSHA && setNonSha {
 		errs = append(errs, fmt.Sprintf("cannot define %q with any semver otions",
 			api.UseSHAAnnotationKey+"/"+containerName))
@@ -223,6 +424,168 @@ func (c *Controller) buildOptions(containerName string, annotations map[string]s
 	return &opts, nil
 }
 
+// containerCacheTimeout returns the cache timeout of the container's image,
+// set by its annotation, otherwise the cache timeout of its registry host, or
+// the default cache timeout.
+func (c *Controller) containerCacheTimeout(containerName, image string, annotations map[string]string) (time.Duration, error) {
+	key := api.CacheTimeoutAnnotationKey + "/" + containerName
+	if value, ok := annotations[key]; ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse %s: %s", key, err)
+		}
+		if timeout <= 0 {
+			return 0, fmt.Errorf("%s must be positive, got %q", key, value)
+		}
+
+		return timeout, nil
+	}
+
+	if timeout, ok := c.registryCacheTimeouts[api.ParseImageRef(image).Registry]; ok {
+		return timeout, nil
+	}
+
+	return c.cacheTimeout, nil
+}
+
+// nodePlatform returns the platform of the node running the pod, or nil if
+// it is not known.
+func (c *Controller) nodePlatform(log *logrus.Entry, pod *corev1.Pod) *api.Platform {
+	if c.nodeLister == nil || len(pod.Spec.NodeName) == 0 {
+		return nil
+	}
+
+	node, err := c.nodeLister.Get(pod.Spec.NodeName)
+	if err != nil {
+		log.Debugf("failed to get node %q: %s", pod.Spec.NodeName, err)
+		return nil
+	}
+
+	info := node.Status.NodeInfo
+	if len(info.OperatingSystem) == 0 && len(info.Architecture) == 0 {
+		return nil
+	}
+
+	return &api.Platform{
+		OS:           info.OperatingSystem,
+		Architecture: info.Architecture,
+	}
+}
+
+// searchRequired returns true if the options require a search of every tag
+// of an image to find the latest, rather than only the digest of its tag.
+func searchRequired(opts *api.Options) bool {
+	return opts.UseSHA || opts.UseMetaData || opts.RegexMatcher != nil ||
+		opts.PinMajor != nil || opts.PinMinor != nil || opts.PinPatch != nil ||
+		opts.ConstraintMatcher != nil || opts.Channel != nil || len(opts.TagOrdering) > 0 ||
+		len(opts.AllowTags) > 0 || len(opts.DenyTags) > 0
+}
+
+// containerAnnotations returns the annotations of the container, being those
+// of the pod, merged over the default annotations of its namespace, merged over
+// those set by policies.
+func (c *Controller) containerAnnotations(log *logrus.Entry, pod *corev1.Pod, containerName, image string) map[string]string {
+	annotations := make(map[string]string)
+	if c.policies != nil {
+		imageURL, _ := urlAndTagFromImage(image)
+		annotations = c.policies.annotations(pod.Namespace, containerName, imageURL)
+	}
+
+	for key, value := range c.namespaceAnnotations(log, pod.Namespace, containerName) {
+		annotations[key] = value
+	}
+
+	for key, value := range pod.Annotations {
+		annotations[key] = value
+	}
+
+	return annotations
+}
+
+// namespaceAnnotations returns the default annotations of the container set
+// on its namespace. Annotations without a container name, such as
+// pin-major.version-checker.io, apply to every container of the namespace,
+// and those of the container's name take precedence.
+func (c *Controller) namespaceAnnotations(log *logrus.Entry, namespace, containerName string) map[string]string {
+	annotations := make(map[string]string)
+	if c.namespaceLister == nil {
+		return annotations
+	}
+
+	ns, err := c.namespaceLister.Get(namespace)
+	if err != nil {
+		log.Debugf("failed to get namespace %q for default annotations: %s", namespace, err)
+		return annotations
+	}
+
+	for key, value := range ns.Annotations {
+		if strings.HasSuffix(key, api.AnnotationDomain) {
+			if _, ok := annotations[key+"/"+containerName]; !ok {
+				annotations[key+"/"+containerName] = value
+			}
+		}
+
+		if strings.HasSuffix(key, api.AnnotationDomain+"/"+containerName) {
+			annotations[key] = value
+		}
+	}
+
+	return annotations
+}
+
+// podContainer is a container of a pod, of any container type.
+type podContainer struct {
+	corev1.Container
+
+	// containerType is one of container, init or ephemeral.
+	containerType string
+}
+
+// podContainers returns the containers, init containers and ephemeral
+// containers of the pod. Containers whose image was resolved to a digest at
+// admission have their original image.
+func podContainers(pod *corev1.Pod) []podContainer {
+	var containers []podContainer
+	for _, container := range pod.Spec.Containers {
+		containers = append(containers, podContainer{container, containerTypeContainer})
+	}
+	for _, container := range pod.Spec.InitContainers {
+		containers = append(containers, podContainer{container, containerTypeInit})
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		containers = append(containers, podContainer{
+			corev1.Container{Name: container.Name, Image: container.Image},
+			containerTypeEphemeral,
+		})
+	}
+
+	for i := range containers {
+		if image, ok := pod.Annotations[api.OriginalImageAnnotationKey+"/"+containers[i].Name]; ok && len(image) > 0 {
+			containers[i].Image = image
+		}
+	}
+
+	return containers
+}
+
+// containerImageDigest returns the digest of the image running in the
+// container, from its status. Returns empty if the image ID is not yet set.
+func containerImageDigest(pod *corev1.Pod, containerName string) string {
+	var statuses []corev1.ContainerStatus
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.EphemeralContainerStatuses...)
+
+	for _, status := range statuses {
+		if status.Name == containerName {
+			_, digest := urlAndTagFromImage(status.ImageID)
+			return digest
+		}
+	}
+
+	return ""
+}
+
 func urlAndTagFromImage(image string) (string, string) {
 	imageSplit := strings.Split(image, "@")
 	if len(imageSplit) == 2 {
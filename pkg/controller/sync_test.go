@@ -0,0 +1,590 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+func TestContainerCacheTimeout(t *testing.T) {
+	c := &Controller{
+		cacheTimeout: time.Minute * 30,
+		registryCacheTimeouts: map[string]time.Duration{
+			"registry.corp": time.Minute * 5,
+			"docker.io":     time.Hour * 2,
+		},
+	}
+
+	tests := map[string]struct {
+		image       string
+		annotations map[string]string
+		exp         time.Duration
+		expErr      bool
+	}{
+		"image without overrides should use the default": {
+			image: "quay.io/jetstack/version-checker:v0.2.0",
+			exp:   time.Minute * 30,
+		},
+		"image should use the timeout of its registry": {
+			image: "registry.corp/team/app:v1.0.0",
+			exp:   time.Minute * 5,
+		},
+		"docker hub image should use the docker.io timeout": {
+			image: "nginx:1.19",
+			exp:   time.Hour * 2,
+		},
+		"annotation should take precedence": {
+			image:       "registry.corp/team/app:v1.0.0",
+			annotations: map[string]string{"cache-timeout.version-checker.io/app": "1m"},
+			exp:         time.Minute,
+		},
+		"annotation of another container should be ignored": {
+			image:       "registry.corp/team/app:v1.0.0",
+			annotations: map[string]string{"cache-timeout.version-checker.io/other": "1m"},
+			exp:         time.Minute * 5,
+		},
+		"invalid annotation should error": {
+			image:       "nginx:1.19",
+			annotations: map[string]string{"cache-timeout.version-checker.io/app": "soon"},
+			expErr:      true,
+		},
+		"non positive annotation should error": {
+			image:       "nginx:1.19",
+			annotations: map[string]string{"cache-timeout.version-checker.io/app": "0s"},
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			timeout, err := c.containerCacheTimeout("app", test.image, test.annotations)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if timeout != test.exp {
+				t.Errorf("unexpected cache timeout, exp=%s got=%s", test.exp, timeout)
+			}
+		})
+	}
+}
+
+type fakeDigests struct {
+	digests map[string]string
+	calls   []string
+}
+
+func (f *fakeDigests) TagDigest(_ context.Context, imageURL, tag string) (string, error) {
+	f.calls = append(f.calls, imageURL+":"+tag)
+	return f.digests[imageURL+":"+tag], nil
+}
+
+func (f *fakeDigests) PlatformDigest(_ context.Context, imageURL, digest string, platform *api.Platform) (string, error) {
+	f.calls = append(f.calls, imageURL+"@"+digest+"/"+platform.Architecture)
+	return f.digests[imageURL+"@"+digest+"/"+platform.Architecture], nil
+}
+
+func TestTestContainerImageTagDigest(t *testing.T) {
+	tests := map[string]struct {
+		image    string
+		imageID  string
+		expCalls []string
+	}{
+		"latest tag should only get the tag digest": {
+			image:    "nginx:latest",
+			imageID:  "docker-pullable://nginx@sha256:a",
+			expCalls: []string{"nginx:latest"},
+		},
+		"empty tag should get the latest tag digest": {
+			image:    "nginx",
+			imageID:  "docker-pullable://nginx@sha256:b",
+			expCalls: []string{"nginx:latest"},
+		},
+		"missing image ID should not get the digest": {
+			image: "nginx:latest",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			log := logrus.NewEntry(logrus.New())
+			digests := &fakeDigests{digests: map[string]string{"nginx:latest": "sha256:a"}}
+			c := &Controller{
+				log:        log,
+				metrics:    metrics.New(log),
+				digests:    digests,
+				imageCache: make(map[string]imageCacheItem),
+				lookups:    newLookupGroup(),
+			}
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{
+						{Name: "app", ImageID: test.imageID},
+					},
+				},
+			}
+			container := &podContainer{
+				Container:     corev1.Container{Name: "app", Image: test.image},
+				containerType: containerTypeContainer,
+			}
+
+			// The second test should be served from the cache.
+			for i := 0; i < 2; i++ {
+				if err := c.testContainerImage(context.TODO(), log, pod, container,
+					new(api.Options), time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if len(digests.calls) != len(test.expCalls) ||
+				(len(test.expCalls) > 0 && digests.calls[0] != test.expCalls[0]) {
+				t.Errorf("unexpected digest lookups, exp=%v got=%v", test.expCalls, digests.calls)
+			}
+		})
+	}
+}
+
+func TestSearchRequired(t *testing.T) {
+	major := int64(1)
+	tests := map[string]struct {
+		opts *api.Options
+		exp  bool
+	}{
+		"no options should not require a search": {
+			opts: new(api.Options),
+			exp:  false,
+		},
+		"use sha should require a search": {
+			opts: &api.Options{UseSHA: true},
+			exp:  true,
+		},
+		"metadata should require a search": {
+			opts: &api.Options{UseMetaData: true},
+			exp:  true,
+		},
+		"pinned major should require a search": {
+			opts: &api.Options{PinMajor: &major},
+			exp:  true,
+		},
+		"tag ordering should require a search": {
+			opts: &api.Options{TagOrdering: api.TagOrderingDebian},
+			exp:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := searchRequired(test.opts); got != test.exp {
+				t.Errorf("unexpected search required, exp=%t got=%t", test.exp, got)
+			}
+		})
+	}
+}
+
+func TestNodePlatform(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "arm"},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{OperatingSystem: "linux", Architecture: "arm64"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Controller{nodeLister: corev1listers.NewNodeLister(indexer)}
+	log := logrus.NewEntry(logrus.New())
+
+	tests := map[string]struct {
+		nodeName string
+		exp      *api.Platform
+	}{
+		"pod on a node should use the node's platform": {
+			nodeName: "arm",
+			exp:      &api.Platform{OS: "linux", Architecture: "arm64"},
+		},
+		"unscheduled pod should have no platform": {
+			nodeName: "",
+		},
+		"pod on an unknown node should have no platform": {
+			nodeName: "missing",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{Spec: corev1.PodSpec{NodeName: test.nodeName}}
+			if platform := c.nodePlatform(log, pod); !reflect.DeepEqual(platform, test.exp) {
+				t.Errorf("unexpected platform, exp=%+v got=%+v", test.exp, platform)
+			}
+		})
+	}
+}
+
+func TestBuildOptionsPlatform(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		exp         *api.Platform
+		expErr      bool
+	}{
+		"no annotations should not restrict the platform": {
+			annotations: map[string]string{},
+		},
+		"os and architecture should restrict the platform": {
+			annotations: map[string]string{
+				"match-os.version-checker.io/app":           "linux",
+				"match-architecture.version-checker.io/app": "amd64",
+			},
+			exp: &api.Platform{OS: "linux", Architecture: "amd64"},
+		},
+		"architecture alone should restrict the architecture": {
+			annotations: map[string]string{
+				"match-architecture.version-checker.io/app": "arm64",
+			},
+			exp: &api.Platform{Architecture: "arm64"},
+		},
+		"architecture may be used with use sha": {
+			annotations: map[string]string{
+				"use-sha.version-checker.io/app":            "true",
+				"match-architecture.version-checker.io/app": "arm64",
+			},
+			exp: &api.Platform{Architecture: "arm64"},
+		},
+		"annotations of another container should be ignored": {
+			annotations: map[string]string{
+				"match-architecture.version-checker.io/other": "arm64",
+			},
+		},
+		"empty architecture should error": {
+			annotations: map[string]string{
+				"match-architecture.version-checker.io/app": "",
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts, err := new(Controller).buildOptions("app", test.annotations)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(opts.Platform, test.exp) {
+				t.Errorf("unexpected platform, exp=%+v got=%+v", test.exp, opts.Platform)
+			}
+		})
+	}
+}
+
+func TestBuildOptionsSemverConstraint(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		expErr      bool
+	}{
+		"valid constraint should be parsed": {
+			annotations: map[string]string{"semver-constraint.version-checker.io/app": ">=1.4 <2.0"},
+		},
+		"invalid constraint should error": {
+			annotations: map[string]string{"semver-constraint.version-checker.io/app": ">=one"},
+			expErr:      true,
+		},
+		"constraint should not be used with use sha": {
+			annotations: map[string]string{
+				"semver-constraint.version-checker.io/app": "^1.4",
+				"use-sha.version-checker.io/app":           "true",
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts, err := new(Controller).buildOptions("app", test.annotations)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if opts.ConstraintMatcher == nil || *opts.SemverConstraint != test.annotations["semver-constraint.version-checker.io/app"] {
+				t.Errorf("unexpected constraint, got=%v", opts.SemverConstraint)
+			}
+		})
+	}
+}
+
+func TestBuildOptionsExcludeRegex(t *testing.T) {
+	tests := map[string]struct {
+		global      []string
+		annotations map[string]string
+		expExcluded []string
+		expIncluded []string
+		expErr      bool
+	}{
+		"no exclusions should not set a matcher": {
+			annotations: map[string]string{},
+			expIncluded: []string{"nightly-1", "v1.0.0"},
+		},
+		"annotation should exclude matching tags": {
+			annotations: map[string]string{"exclude-regex.version-checker.io/app": "^nightly-"},
+			expExcluded: []string{"nightly-1"},
+			expIncluded: []string{"v1.0.0", "v1.0.0-debug"},
+		},
+		"global exclusions should apply alongside the annotation": {
+			global:      []string{"-debug$"},
+			annotations: map[string]string{"exclude-regex.version-checker.io/app": "^nightly-"},
+			expExcluded: []string{"nightly-1", "v1.0.0-debug"},
+			expIncluded: []string{"v1.0.0"},
+		},
+		"exclusions may be used with use sha": {
+			global:      []string{"-debug$"},
+			annotations: map[string]string{"use-sha.version-checker.io/app": "true"},
+			expExcluded: []string{"v1.0.0-debug"},
+			expIncluded: []string{"v1.0.0"},
+		},
+		"invalid annotation should error": {
+			annotations: map[string]string{"exclude-regex.version-checker.io/app": "("},
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{excludeTagRegexes: test.global}
+			opts, err := c.buildOptions("app", test.annotations)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			for _, tag := range test.expExcluded {
+				if !opts.Excludes(tag) {
+					t.Errorf("expected %q to be excluded", tag)
+				}
+			}
+			for _, tag := range test.expIncluded {
+				if opts.Excludes(tag) {
+					t.Errorf("expected %q to not be excluded", tag)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildOptionsBuildIDRegex(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		expErr      bool
+	}{
+		"regex with numeric ordering should be set": {
+			annotations: map[string]string{
+				"tag-ordering.version-checker.io/app":   "numeric",
+				"build-id-regex.version-checker.io/app": "^build-([0-9]+)$",
+			},
+		},
+		"regex without a build id ordering should error": {
+			annotations: map[string]string{
+				"build-id-regex.version-checker.io/app": "^build-([0-9]+)$",
+			},
+			expErr: true,
+		},
+		"regex without a capture group should error": {
+			annotations: map[string]string{
+				"tag-ordering.version-checker.io/app":   "lexical",
+				"build-id-regex.version-checker.io/app": "^build-[0-9]+$",
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts, err := new(Controller).buildOptions("app", test.annotations)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err == nil && opts.BuildIDMatcher == nil {
+				t.Error("expected build id regex to be set")
+			}
+		})
+	}
+}
+
+func TestBuildOptionsChannel(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		exp         string
+		expErr      bool
+	}{
+		"pre-release channel should be set": {
+			annotations: map[string]string{"channel.version-checker.io/app": "rc"},
+			exp:         "rc",
+		},
+		"channel should be lower cased": {
+			annotations: map[string]string{"channel.version-checker.io/app": "Stable"},
+			exp:         "stable",
+		},
+		"invalid channel should error": {
+			annotations: map[string]string{"channel.version-checker.io/app": "rc.1"},
+			expErr:      true,
+		},
+		"channel should not be used with use sha": {
+			annotations: map[string]string{
+				"channel.version-checker.io/app": "rc",
+				"use-sha.version-checker.io/app": "true",
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts, err := new(Controller).buildOptions("app", test.annotations)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if opts.Channel == nil || *opts.Channel != test.exp {
+				t.Errorf("unexpected channel, exp=%s got=%v", test.exp, opts.Channel)
+			}
+		})
+	}
+}
+
+func TestContainerAnnotationsNamespace(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "legacy",
+			Annotations: map[string]string{
+				"pin-major.version-checker.io":          "4",
+				"cache-timeout.version-checker.io":      "2h",
+				"cache-timeout.version-checker.io/app":  "1h",
+				"use-metadata.version-checker.io/other": "true",
+				"unrelated.io/annotation":               "true",
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Controller{namespaceLister: corev1listers.NewNamespaceLister(indexer)}
+	log := logrus.NewEntry(logrus.New())
+
+	tests := map[string]struct {
+		namespace      string
+		podAnnotations map[string]string
+		exp            map[string]string
+	}{
+		"namespace annotations should apply to the container, with container annotations taking precedence": {
+			namespace: "legacy",
+			exp: map[string]string{
+				"pin-major.version-checker.io/app":     "4",
+				"cache-timeout.version-checker.io/app": "1h",
+			},
+		},
+		"pod annotations should take precedence": {
+			namespace: "legacy",
+			podAnnotations: map[string]string{
+				"pin-major.version-checker.io/app": "5",
+			},
+			exp: map[string]string{
+				"pin-major.version-checker.io/app":     "5",
+				"cache-timeout.version-checker.io/app": "1h",
+			},
+		},
+		"unknown namespace should only use pod annotations": {
+			namespace: "default",
+			podAnnotations: map[string]string{
+				"pin-major.version-checker.io/app": "5",
+			},
+			exp: map[string]string{
+				"pin-major.version-checker.io/app": "5",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   test.namespace,
+				Annotations: test.podAnnotations,
+			}}
+
+			annotations := c.containerAnnotations(log, pod, "app", "nginx:1.19")
+			if !reflect.DeepEqual(annotations, test.exp) {
+				t.Errorf("unexpected annotations, exp=%v got=%v", test.exp, annotations)
+			}
+		})
+	}
+}
+
+func TestPodContainers(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				api.OriginalImageAnnotationKey + "/sidecar": "envoyproxy/envoy:v1.16.0",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "app", Image: "nginx:1.19"},
+				{Name: "sidecar", Image: "envoyproxy/envoy:v1.16.0@sha256:c"},
+			},
+			InitContainers: []corev1.Container{{Name: "migrate", Image: "flyway/flyway:7"}},
+			EphemeralContainers: []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug", Image: "busybox:1.32"},
+			}},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "migrate", ImageID: "docker-pullable://flyway/flyway@sha256:a"},
+			},
+			EphemeralContainerStatuses: []corev1.ContainerStatus{
+				{Name: "debug", ImageID: "docker-pullable://busybox@sha256:b"},
+			},
+		},
+	}
+
+	var got []string
+	for _, container := range podContainers(pod) {
+		got = append(got, container.containerType+"/"+container.Name+"/"+container.Image)
+	}
+
+	exp := []string{
+		"container/app/nginx:1.19",
+		"container/sidecar/envoyproxy/envoy:v1.16.0",
+		"init/migrate/flyway/flyway:7",
+		"ephemeral/debug/busybox:1.32",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected containers, exp=%v got=%v", exp, got)
+	}
+
+	for name, expDigest := range map[string]string{"migrate": "sha256:a", "debug": "sha256:b", "app": ""} {
+		if digest := containerImageDigest(pod, name); digest != expDigest {
+			t.Errorf("unexpected digest of %q, exp=%s got=%s", name, expDigest, digest)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// tagList is the curated tags of a repository. If Allow is set, only those
+// tags are considered. Tags in Deny are never considered.
+type tagList struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// tagPolicy holds the tag lists of each repository, declared by a ConfigMap.
+// Every key of the ConfigMap holds YAML, or JSON, mapping repositories to
+// their tag lists, e.g.:
+//
+//	docker.io/library/nginx:
+//	  allow: ["1.25.3", "1.25.4"]
+//	  deny: ["1.25.0"]
+type tagPolicy struct {
+	log *logrus.Entry
+
+	mu    sync.RWMutex
+	repos map[string]tagList
+}
+
+// get will return the tag list of the image URL's repository, if any.
+func (t *tagPolicy) get(imageURL string) (tagList, bool) {
+	if t == nil {
+		return tagList{}, false
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	list, ok := t.repos[repositoryKey(imageURL)]
+	return list, ok
+}
+
+// update will replace the tag lists with those of the ConfigMap. If the
+// ConfigMap is invalid, the error is logged and the current lists are kept. A
+// nil ConfigMap clears the lists.
+func (t *tagPolicy) update(cm *corev1.ConfigMap) {
+	repos := make(map[string]tagList)
+	if cm != nil {
+		var err error
+		repos, err = parseTagPolicy(cm.Data)
+		if err != nil {
+			t.log.Errorf("failed to parse tag policy %s/%s: %s", cm.Namespace, cm.Name, err)
+			return
+		}
+	}
+
+	t.mu.Lock()
+	t.repos = repos
+	t.mu.Unlock()
+}
+
+// parseTagPolicy will parse the tag lists of each repository from the data of
+// a ConfigMap. Lists of the same repository in more than one key are merged.
+func parseTagPolicy(data map[string]string) (map[string]tagList, error) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	repos := make(map[string]tagList)
+	for _, key := range keys {
+		var lists map[string]tagList
+		if err := yaml.Unmarshal([]byte(data[key]), &lists); err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %s", key, err)
+		}
+
+		for repo, list := range lists {
+			repo = repositoryKey(repo)
+			merged := repos[repo]
+			merged.Allow = append(merged.Allow, list.Allow...)
+			merged.Deny = append(merged.Deny, list.Deny...)
+			repos[repo] = merged
+		}
+	}
+
+	return repos, nil
+}
+
+// repositoryKey returns the registry and repository of the image URL, so
+// that references to the same repository, such as nginx and
+// docker.io/library/nginx, share tag lists.
+func repositoryKey(imageURL string) string {
+	ref := api.ParseImageRef(imageURL)
+	return ref.Registry + "/" + ref.Repository
+}
+
+// applyTagPolicy will restrict the options to the tag list of the image
+// URL's repository, returning whether the current tag is allowed. Images
+// without a tag list are always allowed.
+func (c *Controller) applyTagPolicy(imageURL, currentTag string, opts *api.Options) (bool, bool) {
+	list, ok := c.tagPolicy.get(imageURL)
+	if !ok {
+		return false, true
+	}
+
+	opts.AllowTags = list.Allow
+	opts.DenyTags = list.Deny
+
+	return true, opts.TagAllowed(currentTag)
+}
+
+// watchTagPolicy will watch the tag policy ConfigMap, of the form
+// namespace/name, keeping the tag lists up to date.
+func (c *Controller) watchTagPolicy(ctx context.Context) error {
+	split := strings.Split(c.tagPolicyConfigMap, "/")
+	if len(split) != 2 || len(split[0]) == 0 || len(split[1]) == 0 {
+		return fmt.Errorf("invalid tag policy ConfigMap %q, must be namespace/name", c.tagPolicyConfigMap)
+	}
+	namespace, name := split[0], split[1]
+
+	factory := informers.NewSharedInformerFactoryWithOptions(c.kubeClient, time.Second*30,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}),
+	)
+	informer := factory.Core().V1().ConfigMaps().Informer()
+
+	c.tagPolicy = &tagPolicy{
+		log:   c.log.WithField("module", "tag_policy"),
+		repos: make(map[string]tagList),
+	}
+
+	update := func(obj interface{}) {
+		if cm, ok := obj.(*corev1.ConfigMap); ok {
+			c.tagPolicy.update(cm)
+		}
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    update,
+		UpdateFunc: func(_, obj interface{}) { update(obj) },
+		DeleteFunc: func(interface{}) { c.tagPolicy.update(nil) },
+	})
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("error waiting for tag policy informer cache to sync")
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestParseTagPolicy(t *testing.T) {
+	tests := map[string]struct {
+		data   map[string]string
+		exp    map[string]tagList
+		expErr bool
+	}{
+		"yaml should be parsed, with repositories normalised": {
+			data: map[string]string{
+				"policy.yaml": "nginx:\n  allow: [\"1.25.3\", \"1.25.4\"]\n  deny: [\"1.25.0\"]\n",
+			},
+			exp: map[string]tagList{
+				repositoryKey("docker.io/library/nginx"): {
+					Allow: []string{"1.25.3", "1.25.4"},
+					Deny:  []string{"1.25.0"},
+				},
+			},
+		},
+		"json should be parsed": {
+			data: map[string]string{
+				"policy.json": `{"quay.io/jetstack/version-checker": {"deny": ["v0.2.0"]}}`,
+			},
+			exp: map[string]tagList{
+				"quay.io/jetstack/version-checker": {Deny: []string{"v0.2.0"}},
+			},
+		},
+		"lists of the same repository should be merged": {
+			data: map[string]string{
+				"a": "nginx:\n  allow: [\"1.25.3\"]\n",
+				"b": "docker.io/library/nginx:\n  allow: [\"1.25.4\"]\n",
+			},
+			exp: map[string]tagList{
+				repositoryKey("nginx"): {Allow: []string{"1.25.3", "1.25.4"}},
+			},
+		},
+		"invalid data should error": {
+			data:   map[string]string{"policy": "nginx: [1.25.3"},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repos, err := parseTagPolicy(test.data)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			if !test.expErr && !reflect.DeepEqual(repos, test.exp) {
+				t.Errorf("unexpected tag lists, exp=%+v got=%+v", test.exp, repos)
+			}
+		})
+	}
+}
+
+func TestApplyTagPolicy(t *testing.T) {
+	policy := &tagPolicy{
+		log: logrus.NewEntry(logrus.New()),
+	}
+	policy.update(&corev1.ConfigMap{
+		Data: map[string]string{
+			"policy": "nginx:\n  allow: [\"1.25.3\", \"1.25.4\"]\n  deny: [\"1.25.4\"]\n",
+		},
+	})
+	c := &Controller{tagPolicy: policy}
+
+	tests := map[string]struct {
+		image      string
+		expOK      bool
+		expAllowed bool
+		expOpts    *api.Options
+	}{
+		"image without a tag list should be allowed": {
+			image:      "quay.io/jetstack/version-checker:v0.2.0",
+			expOK:      false,
+			expAllowed: true,
+			expOpts:    &api.Options{},
+		},
+		"allowed tag should be allowed": {
+			image:      "nginx:1.25.3",
+			expOK:      true,
+			expAllowed: true,
+			expOpts: &api.Options{
+				AllowTags: []string{"1.25.3", "1.25.4"},
+				DenyTags:  []string{"1.25.4"},
+			},
+		},
+		"denied tag should not be allowed": {
+			image:      "docker.io/library/nginx:1.25.4",
+			expOK:      true,
+			expAllowed: false,
+			expOpts: &api.Options{
+				AllowTags: []string{"1.25.3", "1.25.4"},
+				DenyTags:  []string{"1.25.4"},
+			},
+		},
+		"tag missing from the allowlist should not be allowed": {
+			image:      "nginx:1.19",
+			expOK:      true,
+			expAllowed: false,
+			expOpts: &api.Options{
+				AllowTags: []string{"1.25.3", "1.25.4"},
+				DenyTags:  []string{"1.25.4"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			imageURL, currentTag := urlAndTagFromImage(test.image)
+			opts := new(api.Options)
+
+			ok, allowed := c.applyTagPolicy(imageURL, currentTag, opts)
+			if ok != test.expOK || allowed != test.expAllowed {
+				t.Errorf("unexpected result, exp=%t,%t got=%t,%t",
+					test.expOK, test.expAllowed, ok, allowed)
+			}
+
+			if !reflect.DeepEqual(opts, test.expOpts) {
+				t.Errorf("unexpected options, exp=%+v got=%+v", test.expOpts, opts)
+			}
+		})
+	}
+
+	policy.update(nil)
+	if _, ok := policy.get("nginx"); ok {
+		t.Error("expected tag lists to be cleared when the ConfigMap is deleted")
+	}
+}
@@ -0,0 +1,390 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// workload is a kind of resource, other than pods, whose pod template is
+// checked, such that images are reported whether or not pods of the resource
+// are running.
+type workload struct {
+	resource schema.GroupVersionResource
+
+	// kind prefixes the names of the resources in metrics, such as rollout.
+	kind string
+
+	// pod returns the pod of the pod template of the resource, or nil if the
+	// resource has none.
+	pod func(obj *unstructured.Unstructured) (*corev1.Pod, error)
+}
+
+// workloadKey is the workqueue key of a workload resource.
+type workloadKey struct {
+	resource  schema.GroupVersionResource
+	namespace string
+	name      string
+}
+
+func (w workloadKey) String() string {
+	return fmt.Sprintf("%s %s/%s", w.resource.Resource, w.namespace, w.name)
+}
+
+// rolloutWorkload is Argo Rollouts, whose pod template is the desired
+// revision, including paused and aborted revisions. Rollouts referencing the
+// template of a Deployment have no pod template.
+var rolloutWorkload = workload{
+	resource: schema.GroupVersionResource{
+		Group:    "argoproj.io",
+		Version:  "v1alpha1",
+		Resource: "rollouts",
+	},
+	kind: "rollout",
+	pod: func(obj *unstructured.Unstructured) (*corev1.Pod, error) {
+		return podFromTemplate("rollout", obj, "spec", "template")
+	},
+}
+
+// knativeServiceWorkload is Knative Services, whose pod template is that of
+// the latest revision, so that images of services scaled to zero are still
+// checked.
+var knativeServiceWorkload = workload{
+	resource: schema.GroupVersionResource{
+		Group:    "serving.knative.dev",
+		Version:  "v1",
+		Resource: "services",
+	},
+	kind: "ksvc",
+	pod: func(obj *unstructured.Unstructured) (*corev1.Pod, error) {
+		return podFromTemplate("ksvc", obj, "spec", "template")
+	},
+}
+
+// knativeRevisionWorkload is Knative Revisions, whose spec is a pod spec. Only
+// revisions receiving traffic are checked, rather than every revision kept in
+// the history of a service.
+var knativeRevisionWorkload = workload{
+	resource: schema.GroupVersionResource{
+		Group:    "serving.knative.dev",
+		Version:  "v1",
+		Resource: "revisions",
+	},
+	kind: "revision",
+	pod: func(obj *unstructured.Unstructured) (*corev1.Pod, error) {
+		if obj.GetLabels()[knativeRoutingStateLabel] != "active" {
+			return nil, nil
+		}
+
+		specObj, ok, err := unstructured.NestedMap(obj.Object, "spec")
+		if err != nil || !ok {
+			return nil, err
+		}
+
+		spec := new(corev1.PodSpec)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(specObj, spec); err != nil {
+			return nil, fmt.Errorf("failed to decode spec: %s", err)
+		}
+
+		meta := metav1.ObjectMeta{Labels: obj.GetLabels()}
+		return newWorkloadPod("revision", obj, meta, *spec), nil
+	},
+}
+
+// knativeRoutingStateLabel is set on Knative Revisions to active while they
+// are routed traffic.
+const knativeRoutingStateLabel = "serving.knative.dev/routingState"
+
+// parseWorkload will parse a workload of the form group/version/Kind=jsonpath,
+// or version/Kind=jsonpath for the core group, returning its kind and the
+// JSONPath to the images of its resources.
+func parseWorkload(spec string) (schema.GroupVersionKind, string, error) {
+	split := strings.SplitN(spec, "=", 2)
+	if len(split) != 2 || len(split[1]) == 0 {
+		return schema.GroupVersionKind{}, "", fmt.Errorf("invalid workload %q, must be group/version/Kind=jsonpath", spec)
+	}
+
+	var gvk schema.GroupVersionKind
+	switch parts := strings.Split(split[0], "/"); len(parts) {
+	case 2:
+		gvk = schema.GroupVersionKind{Version: parts[0], Kind: parts[1]}
+	case 3:
+		gvk = schema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]}
+	}
+	// Kinds are capitalised, e.g. Prometheus, and versions are not.
+	if len(gvk.Version) == 0 || len(gvk.Kind) == 0 ||
+		!unicode.IsUpper(rune(gvk.Kind[0])) || unicode.IsUpper(rune(gvk.Version[0])) {
+		return schema.GroupVersionKind{}, "", fmt.Errorf("invalid workload %q, must be group/version/Kind=jsonpath", spec)
+	}
+
+	expr := split[1]
+	if !strings.HasPrefix(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+
+	if _, err := parseImagePath(expr); err != nil {
+		return schema.GroupVersionKind{}, "", fmt.Errorf("invalid workload %q JSONPath: %s", spec, err)
+	}
+
+	return gvk, expr, nil
+}
+
+// parseImagePath returns the JSONPath of the expression, where missing fields
+// find no images. JSONPaths hold state while finding results, so are parsed
+// for each resource.
+func parseImagePath(expr string) (*jsonpath.JSONPath, error) {
+	path := jsonpath.New("images").AllowMissingKeys(true)
+	if err := path.Parse(expr); err != nil {
+		return nil, err
+	}
+
+	return path, nil
+}
+
+// newGenericWorkload returns the workload of the resource, whose images are
+// found by the JSONPath. Each image is a container named image, or image-0,
+// image-1 and so on if more than one image is found.
+func newGenericWorkload(resource schema.GroupVersionResource, gvk schema.GroupVersionKind, expr string) workload {
+	kind := strings.ToLower(gvk.Kind)
+
+	return workload{
+		resource: resource,
+		kind:     kind,
+		pod: func(obj *unstructured.Unstructured) (*corev1.Pod, error) {
+			path, err := parseImagePath(expr)
+			if err != nil {
+				return nil, err
+			}
+
+			results, err := path.FindResults(obj.Object)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find images: %s", err)
+			}
+
+			var images []string
+			for _, result := range results {
+				for _, value := range result {
+					if image, ok := value.Interface().(string); ok && len(image) > 0 {
+						images = append(images, image)
+					}
+				}
+			}
+
+			if len(images) == 0 {
+				return nil, nil
+			}
+
+			// Wildcards over maps find images in random order, so images
+			// are sorted to keep the names of their containers stable.
+			sort.Strings(images)
+
+			var spec corev1.PodSpec
+			for i, image := range images {
+				name := "image"
+				if len(images) > 1 {
+					name = fmt.Sprintf("image-%d", i)
+				}
+				spec.Containers = append(spec.Containers, corev1.Container{Name: name, Image: image})
+			}
+
+			meta := metav1.ObjectMeta{Labels: obj.GetLabels()}
+			return newWorkloadPod(kind, obj, meta, spec), nil
+		},
+	}
+}
+
+// resolveWorkloads will resolve the resource of each workload given by kind
+// and JSONPath, using discovery.
+func (c *Controller) resolveWorkloads() error {
+	if len(c.workloadSpecs) == 0 {
+		return nil
+	}
+
+	groupResources, err := restmapper.GetAPIGroupResources(c.kubeClient.Discovery())
+	if err != nil {
+		return fmt.Errorf("failed to discover API resources: %s", err)
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groupResources)
+
+	for _, spec := range c.workloadSpecs {
+		gvk, expr, err := parseWorkload(spec)
+		if err != nil {
+			return err
+		}
+
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("failed to resolve resource of workload %q: %s", spec, err)
+		}
+
+		c.workloads[mapping.Resource] = newGenericWorkload(mapping.Resource, gvk, expr)
+	}
+
+	return nil
+}
+
+// podFromTemplate returns the pod of the pod template of the resource at the
+// given fields. The pod is named by the kind and name of the resource, and
+// annotations of the resource take precedence over those of the template.
+func podFromTemplate(kind string, obj *unstructured.Unstructured, fields ...string) (*corev1.Pod, error) {
+	templateObj, ok, err := unstructured.NestedMap(obj.Object, fields...)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	template := new(corev1.PodTemplateSpec)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(templateObj, template); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %s", strings.Join(fields, "."), err)
+	}
+
+	return newWorkloadPod(kind, obj, template.ObjectMeta, template.Spec), nil
+}
+
+// newWorkloadPod returns the pod of the workload resource, with the given
+// template metadata and spec.
+func newWorkloadPod(kind string, obj *unstructured.Unstructured, meta metav1.ObjectMeta, spec corev1.PodSpec) *corev1.Pod {
+	annotations := make(map[string]string)
+	for key, value := range meta.Annotations {
+		annotations[key] = value
+	}
+	for key, value := range obj.GetAnnotations() {
+		annotations[key] = value
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   obj.GetNamespace(),
+			Name:        kind + "/" + obj.GetName(),
+			Labels:      meta.Labels,
+			Annotations: annotations,
+		},
+		Spec: spec,
+	}
+}
+
+// workloadPod returns the pod of the workload resource of the key, or nil if
+// the resource has been deleted, has no pod template, or is out of scope.
+func (c *Controller) workloadPod(key workloadKey) (*corev1.Pod, error) {
+	w, ok := c.workloads[key.resource]
+	if !ok {
+		return nil, fmt.Errorf("unknown workload resource %q", key.resource)
+	}
+
+	obj, err := c.workloadListers[key.resource].ByNamespace(key.namespace).Get(key.name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object type %T", obj)
+	}
+
+	pod, err := w.pod(u)
+	if err != nil || pod == nil || !c.scope.contains(pod) {
+		return nil, err
+	}
+
+	return pod, nil
+}
+
+// watchWorkloads will watch the resources of each workload, queueing them to
+// be checked, and removing the metrics of their pod templates when they are
+// deleted or their pod template changes.
+func (c *Controller) watchWorkloads(ctx context.Context) error {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(c.workloadClient, time.Second*30)
+
+	c.workloadListers = make(map[schema.GroupVersionResource]cache.GenericLister)
+
+	var synced []cache.InformerSynced
+	for resource, w := range c.workloads {
+		w := w
+		informer := factory.ForResource(resource)
+		c.workloadListers[resource] = informer.Lister()
+
+		// pod returns the pod of the resource, if in scope.
+		pod := func(obj interface{}) *corev1.Pod {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return nil
+			}
+
+			pod, err := w.pod(u)
+			if err != nil {
+				c.log.Errorf("failed to get pod template of %s %s/%s: %s",
+					w.kind, u.GetNamespace(), u.GetName(), err)
+				return nil
+			}
+
+			if pod == nil || !c.scope.contains(pod) {
+				return nil
+			}
+
+			return pod
+		}
+
+		enqueue := func(obj interface{}) {
+			if u, ok := obj.(*unstructured.Unstructured); ok && pod(obj) != nil {
+				c.workqueue.Add(workloadKey{resource, u.GetNamespace(), u.GetName()})
+			}
+		}
+
+		informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: enqueue,
+			UpdateFunc: func(oldObj, obj interface{}) {
+				if oldPod, newPod := pod(oldObj), pod(obj); oldPod != nil &&
+					(newPod == nil || !reflect.DeepEqual(podImages(oldPod), podImages(newPod))) {
+					c.removePodMetrics(oldPod)
+				}
+				enqueue(obj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if pod := pod(obj); pod != nil {
+					c.removePodMetrics(pod)
+				}
+			},
+		})
+		synced = append(synced, informer.Informer().HasSynced)
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return fmt.Errorf("error waiting for workload informer caches to sync")
+	}
+
+	return nil
+}
+
+// podImages returns the image of each container of the pod, by name.
+func podImages(pod *corev1.Pod) map[string]string {
+	images := make(map[string]string)
+	for _, container := range podContainers(pod) {
+		images[container.Name] = container.Image
+	}
+	return images
+}
@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWorkloadPod(t *testing.T) {
+	tests := map[string]struct {
+		workload       workload
+		obj            map[string]interface{}
+		expNil         bool
+		expName        string
+		expImages      map[string]string
+		expAnnotations map[string]string
+	}{
+		"rollout should use its pod template, with its annotations taking precedence": {
+			workload: rolloutWorkload,
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"namespace": "shop",
+					"name":      "checkout",
+					"annotations": map[string]interface{}{
+						"pin-major.version-checker.io/app": "2",
+					},
+				},
+				"spec": map[string]interface{}{
+					"paused": true,
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"annotations": map[string]interface{}{
+								"pin-major.version-checker.io/app":   "1",
+								"use-sha.version-checker.io/sidecar": "true",
+							},
+						},
+						"spec": map[string]interface{}{
+							"containers": []interface{}{
+								map[string]interface{}{"name": "app", "image": "registry.corp/checkout:v1.4.0"},
+								map[string]interface{}{"name": "sidecar", "image": "envoyproxy/envoy:v1.16.0"},
+							},
+						},
+					},
+				},
+			},
+			expName: "rollout/checkout",
+			expImages: map[string]string{
+				"app":     "registry.corp/checkout:v1.4.0",
+				"sidecar": "envoyproxy/envoy:v1.16.0",
+			},
+			expAnnotations: map[string]string{
+				"pin-major.version-checker.io/app":   "2",
+				"use-sha.version-checker.io/sidecar": "true",
+			},
+		},
+		"rollout referencing a deployment should have no pod": {
+			workload: rolloutWorkload,
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"namespace": "shop", "name": "checkout"},
+				"spec": map[string]interface{}{
+					"workloadRef": map[string]interface{}{"kind": "Deployment", "name": "checkout"},
+				},
+			},
+			expNil: true,
+		},
+		"knative service should use its revision template": {
+			workload: knativeServiceWorkload,
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"namespace": "shop", "name": "search"},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"containerConcurrency": int64(10),
+							"containers": []interface{}{
+								map[string]interface{}{"name": "user-container", "image": "registry.corp/search:v2.0.1"},
+							},
+						},
+					},
+				},
+			},
+			expName:        "ksvc/search",
+			expImages:      map[string]string{"user-container": "registry.corp/search:v2.0.1"},
+			expAnnotations: map[string]string{},
+		},
+		"active knative revision should use its spec": {
+			workload: knativeRevisionWorkload,
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"namespace": "shop",
+					"name":      "search-00002",
+					"labels":    map[string]interface{}{"serving.knative.dev/routingState": "active"},
+					"annotations": map[string]interface{}{
+						"use-sha.version-checker.io/user-container": "true",
+					},
+				},
+				"spec": map[string]interface{}{
+					"timeoutSeconds": int64(300),
+					"containers": []interface{}{
+						map[string]interface{}{"name": "user-container", "image": "registry.corp/search:v2.0.1"},
+					},
+				},
+			},
+			expName:   "revision/search-00002",
+			expImages: map[string]string{"user-container": "registry.corp/search:v2.0.1"},
+			expAnnotations: map[string]string{
+				"use-sha.version-checker.io/user-container": "true",
+			},
+		},
+		"knative revision not receiving traffic should have no pod": {
+			workload: knativeRevisionWorkload,
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"namespace": "shop",
+					"name":      "search-00001",
+					"labels":    map[string]interface{}{"serving.knative.dev/routingState": "reserve"},
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "user-container", "image": "registry.corp/search:v2.0.0"},
+					},
+				},
+			},
+			expNil: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pod, err := test.workload.pod(&unstructured.Unstructured{Object: test.obj})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.expNil {
+				if pod != nil {
+					t.Errorf("unexpected pod, exp=nil got=%+v", pod)
+				}
+				return
+			}
+
+			if pod.Name != test.expName || pod.Namespace != "shop" {
+				t.Errorf("unexpected pod name, exp=shop/%s got=%s/%s", test.expName, pod.Namespace, pod.Name)
+			}
+
+			if images := podImages(pod); !reflect.DeepEqual(images, test.expImages) {
+				t.Errorf("unexpected images, exp=%v got=%v", test.expImages, images)
+			}
+
+			if !reflect.DeepEqual(pod.Annotations, test.expAnnotations) {
+				t.Errorf("unexpected annotations, exp=%v got=%v", test.expAnnotations, pod.Annotations)
+			}
+		})
+	}
+}
+
+func TestParseWorkload(t *testing.T) {
+	tests := map[string]struct {
+		spec    string
+		expGVK  schema.GroupVersionKind
+		expExpr string
+		expErr  bool
+	}{
+		"workload of a group should be parsed": {
+			spec:    "monitoring.coreos.com/v1/Prometheus={.spec.image}",
+			expGVK:  schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "Prometheus"},
+			expExpr: "{.spec.image}",
+		},
+		"workload of the core group should be parsed, with braces added": {
+			spec:    "v1/ReplicationController=.spec.template.spec.containers[*].image",
+			expGVK:  schema.GroupVersionKind{Version: "v1", Kind: "ReplicationController"},
+			expExpr: "{.spec.template.spec.containers[*].image}",
+		},
+		"missing JSONPath should error": {
+			spec:   "monitoring.coreos.com/v1/Prometheus",
+			expErr: true,
+		},
+		"missing kind should error": {
+			spec:   "monitoring.coreos.com/v1={.spec.image}",
+			expErr: true,
+		},
+		"invalid JSONPath should error": {
+			spec:   "monitoring.coreos.com/v1/Prometheus={.spec.image",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			gvk, expr, err := parseWorkload(test.spec)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			if gvk != test.expGVK || expr != test.expExpr {
+				t.Errorf("unexpected workload, exp=%v %s got=%v %s", test.expGVK, test.expExpr, gvk, expr)
+			}
+		})
+	}
+}
+
+func TestGenericWorkloadPod(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "kafka.strimzi.io", Version: "v1beta1", Kind: "Kafka"}
+
+	tests := map[string]struct {
+		expr      string
+		expNil    bool
+		expImages map[string]string
+	}{
+		"single image should be named image": {
+			expr:      "{.spec.kafka.image}",
+			expImages: map[string]string{"image": "strimzi/kafka:0.20.0-kafka-2.6.0"},
+		},
+		"many images should be named by index": {
+			expr: "{..image}",
+			expImages: map[string]string{
+				"image-0": "strimzi/kafka:0.20.0-kafka-2.6.0",
+				"image-1": "strimzi/zookeeper:0.20.0",
+			},
+		},
+		"missing field should have no pod": {
+			expr:   "{.spec.entityOperator.image}",
+			expNil: true,
+		},
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"namespace": "streaming",
+			"name":      "events",
+			"labels":    map[string]interface{}{"tier": "production"},
+		},
+		"spec": map[string]interface{}{
+			"kafka":     map[string]interface{}{"image": "strimzi/kafka:0.20.0-kafka-2.6.0"},
+			"zookeeper": map[string]interface{}{"image": "strimzi/zookeeper:0.20.0"},
+		},
+	}}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := newGenericWorkload(schema.GroupVersionResource{}, gvk, test.expr)
+
+			pod, err := w.pod(obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.expNil {
+				if pod != nil {
+					t.Errorf("unexpected pod, exp=nil got=%+v", pod)
+				}
+				return
+			}
+
+			if pod.Name != "kafka/events" || pod.Labels["tier"] != "production" {
+				t.Errorf("unexpected pod, exp=kafka/events got=%s %v", pod.Name, pod.Labels)
+			}
+
+			if images := podImages(pod); !reflect.DeepEqual(images, test.expImages) {
+				t.Errorf("unexpected images, exp=%v got=%v", test.expImages, images)
+			}
+		})
+	}
+}
+
+func TestResolveWorkloads(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "monitoring.coreos.com/v1",
+			APIResources: []metav1.APIResource{{Name: "prometheuses", Kind: "Prometheus", Namespaced: true}},
+		},
+	}
+
+	c := &Controller{
+		kubeClient:    kubeClient,
+		workloads:     make(map[schema.GroupVersionResource]workload),
+		workloadSpecs: []string{"monitoring.coreos.com/v1/Prometheus={.spec.image}"},
+	}
+	if err := c.resolveWorkloads(); err != nil {
+		t.Fatal(err)
+	}
+
+	resource := schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheuses"}
+	if w, ok := c.workloads[resource]; !ok || w.kind != "prometheus" {
+		t.Errorf("unexpected workloads, exp=%v got=%v", resource, c.workloads)
+	}
+
+	c.workloadSpecs = []string{"monitoring.coreos.com/v1/Alertmanager={.spec.image}"}
+	if err := c.resolveWorkloads(); err == nil {
+		t.Error("expected error resolving unknown workload kind")
+	}
+}
@@ -0,0 +1,146 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrBranchExists is returned when opening a pull request whose branch
+// already exists, such as one opened previously for the same version.
+var ErrBranchExists = errors.New("branch already exists")
+
+// PullRequest is a pull request, or merge request, committing the updated
+// content of a file to a new branch.
+type PullRequest struct {
+	// Repo is the repository of the provider, such as org/repo.
+	Repo string
+
+	// Path is the path of the file in the repository.
+	Path string
+
+	// Content is the updated content of the file.
+	Content []byte
+
+	// FileVersion is the version of the file the content was updated from,
+	// as returned by GetFile.
+	FileVersion string
+
+	BaseBranch string
+	Branch     string
+	Title      string
+	Body       string
+}
+
+// Provider opens pull requests on a Git hosting provider.
+type Provider interface {
+	// Host is the host of the repositories of the provider, such as
+	// github.com.
+	Host() string
+
+	// GetFile returns the content of the file of the repository at the
+	// branch, and the version of the file to update.
+	GetFile(ctx context.Context, repo, path, branch string) ([]byte, string, error)
+
+	// OpenPullRequest opens the pull request, returning its URL.
+	OpenPullRequest(ctx context.Context, pr PullRequest) (string, error)
+}
+
+// apiClient is a client of the JSON API of a provider.
+type apiClient struct {
+	*http.Client
+
+	baseURL   string
+	authorize func(req *http.Request)
+}
+
+// statusError is returned for unexpected status codes of API responses.
+type statusError struct {
+	code int
+	body []byte
+}
+
+func (s *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", s.code, s.body)
+}
+
+func newAPIClient(baseURL string, transport http.RoundTripper, authorize func(req *http.Request)) apiClient {
+	return apiClient{
+		Client: &http.Client{
+			Timeout:   time.Second * 10,
+			Transport: transport,
+		},
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		authorize: authorize,
+	}
+}
+
+// host returns the host of the base URL of the API.
+func (a *apiClient) host() string {
+	u, err := url.Parse(a.baseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// do will send the request with the JSON body to the path of the API,
+// decoding the JSON response into resp if not nil.
+func (a *apiClient) do(ctx context.Context, method, path string, body, resp interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %s", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, a.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	a.authorize(req)
+	req = req.WithContext(ctx)
+
+	res, err := a.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		resBody, _ := ioutil.ReadAll(res.Body)
+		return &statusError{code: res.StatusCode, body: resBody}
+	}
+
+	if resp == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return fmt.Errorf("failed to decode response: %s", err)
+	}
+
+	return nil
+}
+
+// statusCode returns the status code of the error of an unexpected response,
+// or zero.
+func statusCode(err error) int {
+	var serr *statusError
+	if errors.As(err, &serr) {
+		return serr.code
+	}
+	return 0
+}
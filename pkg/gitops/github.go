@@ -0,0 +1,119 @@
+package gitops
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DefaultGitHubURL is the API URL of github.com.
+	DefaultGitHubURL = "https://api.github.com"
+)
+
+// GitHub opens pull requests on GitHub, or GitHub Enterprise.
+type GitHub struct {
+	apiClient
+}
+
+type githubContent struct {
+	Content string `json:"content"`
+	SHA     string `json:"sha"`
+}
+
+type githubRef struct {
+	Object struct {
+		SHA string `json:"sha"`
+	} `json:"object"`
+}
+
+type githubPullRequest struct {
+	HTMLURL string `json:"html_url"`
+}
+
+// NewGitHub returns a GitHub provider of the API URL, authenticating with the
+// token. The API URL defaults to DefaultGitHubURL.
+func NewGitHub(apiURL, token string, transport http.RoundTripper) *GitHub {
+	if len(apiURL) == 0 {
+		apiURL = DefaultGitHubURL
+	}
+
+	return &GitHub{
+		apiClient: newAPIClient(apiURL, transport, func(req *http.Request) {
+			req.Header.Set("Authorization", "token "+token)
+			req.Header.Set("Accept", "application/vnd.github.v3+json")
+		}),
+	}
+}
+
+// Host returns the host of the repositories of the provider, being github.com
+// for the github.com API.
+func (g *GitHub) Host() string {
+	if host := g.host(); host != "api.github.com" {
+		return host
+	}
+	return "github.com"
+}
+
+// GetFile returns the content of the file of the repository at the branch,
+// and its blob SHA.
+func (g *GitHub) GetFile(ctx context.Context, repo, path, branch string) ([]byte, string, error) {
+	var content githubContent
+	if err := g.do(ctx, http.MethodGet,
+		fmt.Sprintf("/repos/%s/contents/%s?ref=%s", repo, path, url.QueryEscape(branch)), nil, &content); err != nil {
+		return nil, "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(content.Content, "\n", ""))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode content of %q: %s", path, err)
+	}
+
+	return data, content.SHA, nil
+}
+
+// OpenPullRequest will create the branch of the pull request from the base
+// branch, commit the file to it, and open the pull request, returning its
+// URL.
+func (g *GitHub) OpenPullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	var base githubRef
+	if err := g.do(ctx, http.MethodGet,
+		fmt.Sprintf("/repos/%s/git/ref/heads/%s", pr.Repo, pr.BaseBranch), nil, &base); err != nil {
+		return "", err
+	}
+
+	err := g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/git/refs", pr.Repo), map[string]string{
+		"ref": "refs/heads/" + pr.Branch,
+		"sha": base.Object.SHA,
+	}, nil)
+	if statusCode(err) == http.StatusUnprocessableEntity {
+		return "", ErrBranchExists
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := g.do(ctx, http.MethodPut, fmt.Sprintf("/repos/%s/contents/%s", pr.Repo, pr.Path), map[string]string{
+		"message": pr.Title,
+		"content": base64.StdEncoding.EncodeToString(pr.Content),
+		"sha":     pr.FileVersion,
+		"branch":  pr.Branch,
+	}, nil); err != nil {
+		return "", err
+	}
+
+	var pull githubPullRequest
+	if err := g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls", pr.Repo), map[string]string{
+		"title": pr.Title,
+		"body":  pr.Body,
+		"head":  pr.Branch,
+		"base":  pr.BaseBranch,
+	}, &pull); err != nil {
+		return "", err
+	}
+
+	return pull.HTMLURL, nil
+}
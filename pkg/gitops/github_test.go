@@ -0,0 +1,96 @@
+package gitops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGitHubOpenPullRequest(t *testing.T) {
+	tests := map[string]struct {
+		refStatus int
+		expCalls  []string
+		expURL    string
+		expErr    error
+	}{
+		"new branch should be committed to and opened": {
+			refStatus: http.StatusCreated,
+			expCalls: []string{
+				"GET /repos/org/deploy/git/ref/heads/main",
+				"POST /repos/org/deploy/git/refs",
+				"PUT /repos/org/deploy/contents/web/values.yaml",
+				"POST /repos/org/deploy/pulls",
+			},
+			expURL: "https://github.com/org/deploy/pull/1",
+		},
+		"existing branch should return ErrBranchExists": {
+			refStatus: http.StatusUnprocessableEntity,
+			expCalls: []string{
+				"GET /repos/org/deploy/git/ref/heads/main",
+				"POST /repos/org/deploy/git/refs",
+			},
+			expErr: ErrBranchExists,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				calls []string
+				ref   map[string]string
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				calls = append(calls, req.Method+" "+req.URL.Path)
+				if auth := req.Header.Get("Authorization"); auth != "token gh-token" {
+					t.Errorf("unexpected authorization: %q", auth)
+				}
+
+				switch req.URL.Path {
+				case "/repos/org/deploy/git/ref/heads/main":
+					w.Write([]byte(`{"object":{"sha":"base-sha"}}`))
+				case "/repos/org/deploy/git/refs":
+					if err := json.NewDecoder(req.Body).Decode(&ref); err != nil {
+						t.Error(err)
+					}
+					w.WriteHeader(test.refStatus)
+				case "/repos/org/deploy/pulls":
+					w.Write([]byte(`{"html_url":"https://github.com/org/deploy/pull/1"}`))
+				}
+			}))
+			defer server.Close()
+
+			url, err := NewGitHub(server.URL, "gh-token", nil).OpenPullRequest(context.TODO(), PullRequest{
+				Repo:       "org/deploy",
+				Path:       "web/values.yaml",
+				Content:    []byte("image:\n  tag: 1.21.0\n"),
+				BaseBranch: "main",
+				Branch:     "version-checker/nginx-1.21.0",
+				Title:      "Update nginx to 1.21.0",
+			})
+			if err != test.expErr {
+				t.Fatalf("unexpected error, exp=%v got=%v", test.expErr, err)
+			}
+			if url != test.expURL {
+				t.Errorf("unexpected url, exp=%q got=%q", test.expURL, url)
+			}
+			if !reflect.DeepEqual(calls, test.expCalls) {
+				t.Errorf("unexpected calls, exp=%v got=%v", test.expCalls, calls)
+			}
+			if exp := map[string]string{"ref": "refs/heads/version-checker/nginx-1.21.0", "sha": "base-sha"}; !reflect.DeepEqual(ref, exp) {
+				t.Errorf("unexpected ref, exp=%v got=%v", exp, ref)
+			}
+		})
+	}
+}
+
+func TestGitHubHost(t *testing.T) {
+	if host := NewGitHub("", "", nil).Host(); host != "github.com" {
+		t.Errorf("unexpected host of github.com, got=%q", host)
+	}
+	if host := NewGitHub("https://github.example.com/api/v3", "", nil).Host(); host != "github.example.com" {
+		t.Errorf("unexpected host of enterprise, got=%q", host)
+	}
+}
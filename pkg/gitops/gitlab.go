@@ -0,0 +1,104 @@
+package gitops
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// DefaultGitLabURL is the URL of gitlab.com.
+	DefaultGitLabURL = "https://gitlab.com"
+)
+
+// GitLab opens merge requests on GitLab.com, or a self-managed instance.
+type GitLab struct {
+	apiClient
+}
+
+type gitlabFile struct {
+	Content      string `json:"content"`
+	LastCommitID string `json:"last_commit_id"`
+}
+
+type gitlabMergeRequest struct {
+	WebURL string `json:"web_url"`
+}
+
+// NewGitLab returns a GitLab provider of the instance URL, authenticating
+// with the token. The URL defaults to DefaultGitLabURL.
+func NewGitLab(instanceURL, token string, transport http.RoundTripper) *GitLab {
+	if len(instanceURL) == 0 {
+		instanceURL = DefaultGitLabURL
+	}
+
+	return &GitLab{
+		apiClient: newAPIClient(instanceURL+"/api/v4", transport, func(req *http.Request) {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}),
+	}
+}
+
+// Host returns the host of the repositories of the instance.
+func (g *GitLab) Host() string {
+	return g.host()
+}
+
+// GetFile returns the content of the file of the project at the branch, and
+// the last commit of the file.
+func (g *GitLab) GetFile(ctx context.Context, repo, path, branch string) ([]byte, string, error) {
+	var file gitlabFile
+	if err := g.do(ctx, http.MethodGet, fmt.Sprintf("/projects/%s/repository/files/%s?ref=%s",
+		url.PathEscape(repo), url.PathEscape(path), url.QueryEscape(branch)), nil, &file); err != nil {
+		return nil, "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode content of %q: %s", path, err)
+	}
+
+	return data, file.LastCommitID, nil
+}
+
+// OpenPullRequest will commit the file to the branch of the merge request,
+// created from the base branch, and open the merge request, returning its
+// URL.
+func (g *GitLab) OpenPullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	project := url.PathEscape(pr.Repo)
+
+	err := g.do(ctx, http.MethodPost, fmt.Sprintf("/projects/%s/repository/commits", project), map[string]interface{}{
+		"branch":         pr.Branch,
+		"start_branch":   pr.BaseBranch,
+		"commit_message": pr.Title,
+		"actions": []map[string]string{{
+			"action":         "update",
+			"file_path":      pr.Path,
+			"content":        base64.StdEncoding.EncodeToString(pr.Content),
+			"encoding":       "base64",
+			"last_commit_id": pr.FileVersion,
+		}},
+	}, nil)
+	if statusCode(err) == http.StatusBadRequest {
+		// GitLab rejects commits creating a branch which already exists.
+		return "", ErrBranchExists
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var mr gitlabMergeRequest
+	if err := g.do(ctx, http.MethodPost, fmt.Sprintf("/projects/%s/merge_requests", project), map[string]interface{}{
+		"source_branch":        pr.Branch,
+		"target_branch":        pr.BaseBranch,
+		"title":                pr.Title,
+		"description":          pr.Body,
+		"remove_source_branch": true,
+	}, &mr); err != nil {
+		return "", err
+	}
+
+	return mr.WebURL, nil
+}
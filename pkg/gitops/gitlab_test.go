@@ -0,0 +1,36 @@
+package gitops
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitLabGetFile(t *testing.T) {
+	var path, token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path, token = req.URL.RawPath+"?"+req.URL.RawQuery, req.Header.Get("PRIVATE-TOKEN")
+		w.Write([]byte(`{"content":"aW1hZ2U6CiAgdGFnOiAxLjE5LjYK","last_commit_id":"abc123"}`))
+	}))
+	defer server.Close()
+
+	content, fileVersion, err := NewGitLab(server.URL, "gl-token", nil).
+		GetFile(context.TODO(), "group/deploy", "web/values.yaml", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := "/api/v4/projects/group%2Fdeploy/repository/files/web%2Fvalues.yaml?ref=main"; path != exp {
+		t.Errorf("unexpected path, exp=%q got=%q", exp, path)
+	}
+	if token != "gl-token" {
+		t.Errorf("unexpected token, got=%q", token)
+	}
+	if exp := "image:\n  tag: 1.19.6\n"; string(content) != exp {
+		t.Errorf("unexpected content, exp=%q got=%q", exp, content)
+	}
+	if fileVersion != "abc123" {
+		t.Errorf("unexpected file version, got=%q", fileVersion)
+	}
+}
@@ -0,0 +1,254 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/controller"
+	"github.com/jetstack/version-checker/pkg/metrics"
+	"github.com/jetstack/version-checker/pkg/version"
+)
+
+const (
+	// defaultBaseBranch is the branch pull requests are opened against if
+	// not set by annotation.
+	defaultBaseBranch = "main"
+)
+
+var branchInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// AllowedRepo is a repository pattern pull requests may be opened against,
+// for pods of the namespace, or of any namespace if empty.
+type AllowedRepo struct {
+	Namespace string
+	// Pattern matches repositories of the form host/repo, such as
+	// github.com/my-org/*.
+	Pattern string
+}
+
+// ParseAllowedRepos parses allowed repositories of the form
+// [<namespace>=]<host>/<repo pattern>, where the pattern is matched as a
+// path, so * matches any single path element.
+func ParseAllowedRepos(repos []string) ([]AllowedRepo, error) {
+	allowed := make([]AllowedRepo, 0, len(repos))
+	for _, repo := range repos {
+		var allow AllowedRepo
+		if i := strings.Index(repo, "="); i >= 0 {
+			allow.Namespace, allow.Pattern = repo[:i], repo[i+1:]
+		} else {
+			allow.Pattern = repo
+		}
+
+		if !strings.Contains(allow.Pattern, "/") {
+			return nil, fmt.Errorf("allowed repository %q is not of the form host/repo", repo)
+		}
+		if _, err := path.Match(allow.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid allowed repository %q: %s", repo, err)
+		}
+
+		allowed = append(allowed, allow)
+	}
+
+	return allowed, nil
+}
+
+// Updater opens pull requests bumping the version of outdated images in the
+// Git repositories they are tracked from, set by the gitops annotations of
+// their pods.
+type Updater struct {
+	log        *logrus.Entry
+	kubeClient kubernetes.Interface
+
+	// providers are the providers of repositories, by their host.
+	providers map[string]Provider
+
+	// allowedRepos are the only repositories pull requests are opened
+	// against, since repositories are set by pod annotation yet opened with
+	// the tokens of the operator.
+	allowedRepos []AllowedRepo
+
+	// opened is the latest version a pull request was last opened for, by
+	// the repository, path and key of the tracked value. Only accessed by
+	// the update worker.
+	opened map[string]string
+}
+
+func New(log *logrus.Entry, kubeClient kubernetes.Interface, providers []Provider, allowedRepos []AllowedRepo) *Updater {
+	u := &Updater{
+		log:          log.WithField("module", "gitops"),
+		kubeClient:   kubeClient,
+		providers:    make(map[string]Provider),
+		allowedRepos: allowedRepos,
+		opened:       make(map[string]string),
+	}
+
+	for _, provider := range providers {
+		u.providers[provider.Host()] = provider
+	}
+
+	return u
+}
+
+// Run will watch the results of image checks, opening pull requests for
+// outdated images until the context is done.
+func (u *Updater) Run(ctx context.Context, watcher metrics.Watcher) {
+	metrics.WatchOutdated(ctx, u.log, watcher, u.update)
+}
+
+// update will open a pull request bumping the version of the image in the
+// repository it is tracked from, if its pod has the gitops annotations of the
+// container. A pull request is opened at most once per latest version of a
+// tracked value.
+func (u *Updater) update(ctx context.Context, image metrics.Image) error {
+	pod, err := u.kubeClient.CoreV1().Pods(image.Namespace).Get(ctx, image.Pod, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %s", err)
+	}
+
+	annotation := func(key string) string {
+		return pod.Annotations[key+"/"+image.Container]
+	}
+
+	repo := annotation(api.GitOpsRepoAnnotationKey)
+	if len(repo) == 0 {
+		return nil
+	}
+
+	path, key := annotation(api.GitOpsPathAnnotationKey), annotation(api.GitOpsKeyAnnotationKey)
+	if len(path) == 0 || len(key) == 0 {
+		return fmt.Errorf("both %s and %s annotations must be set with %s",
+			api.GitOpsPathAnnotationKey, api.GitOpsKeyAnnotationKey, api.GitOpsRepoAnnotationKey)
+	}
+
+	if !u.allowed(image.Namespace, repo) {
+		return fmt.Errorf("repository %q is not allowed for namespace %q", repo, image.Namespace)
+	}
+
+	// Only bump to a strictly newer version, by the tag ordering the latest
+	// version was selected with, so that images are never downgraded.
+	opts, err := controller.BuildOptions(image.Container, pod.Annotations, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build version options: %s", err)
+	}
+	if c, ok := version.CompareTags(opts, image.CurrentVersion, image.LatestVersion); !ok || c >= 0 {
+		u.log.Debugf("latest version %q of %s/%s %s is not newer than %q, not updating",
+			image.LatestVersion, image.Namespace, image.Pod, image.Container, image.CurrentVersion)
+		return nil
+	}
+
+	baseBranch := annotation(api.GitOpsBranchAnnotationKey)
+	if len(baseBranch) == 0 {
+		baseBranch = defaultBaseBranch
+	}
+
+	id := strings.Join([]string{repo, path, key}, "/")
+	if u.opened[id] == image.LatestVersion {
+		return nil
+	}
+
+	host := strings.SplitN(repo, "/", 2)
+	if len(host) != 2 {
+		return fmt.Errorf("repository %q is not of the form host/repo", repo)
+	}
+
+	provider, ok := u.providers[host[0]]
+	if !ok {
+		return fmt.Errorf("no provider configured for %q", host[0])
+	}
+
+	content, fileVersion, err := provider.GetFile(ctx, host[1], path, baseBranch)
+	if err != nil {
+		return fmt.Errorf("failed to get %s of %s: %s", path, repo, err)
+	}
+
+	updated, err := SetYAMLValue(content, key, func(value string) (string, error) {
+		return bumpValue(value, image.CurrentVersion, image.LatestVersion)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update %s of %s: %s", key, path, err)
+	}
+
+	// The value may already be updated, waiting to be deployed.
+	if bytes.Equal(updated, content) {
+		u.opened[id] = image.LatestVersion
+		return nil
+	}
+
+	url, err := provider.OpenPullRequest(ctx, PullRequest{
+		Repo:        host[1],
+		Path:        path,
+		Content:     updated,
+		FileVersion: fileVersion,
+		BaseBranch:  baseBranch,
+		Branch:      branchName(image),
+		Title:       fmt.Sprintf("Update %s to %s", image.Image, image.LatestVersion),
+		Body: fmt.Sprintf("Updates `%s` in `%s` from `%s` to `%s`.\n\n"+
+			"The image is outdated in container %s of pod %s/%s, as checked by version-checker.",
+			key, path, image.CurrentVersion, image.LatestVersion,
+			image.Container, image.Namespace, image.Pod),
+	})
+	if err == ErrBranchExists {
+		u.log.Debugf("pull request updating %s to %s already opened", image.Image, image.LatestVersion)
+		u.opened[id] = image.LatestVersion
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open pull request of %s: %s", repo, err)
+	}
+
+	u.log.Infof("opened pull request updating %s to %s: %s", image.Image, image.LatestVersion, url)
+	u.opened[id] = image.LatestVersion
+
+	return nil
+}
+
+// allowed returns true if pull requests may be opened against the repository
+// for pods of the namespace.
+func (u *Updater) allowed(namespace, repo string) bool {
+	for _, allow := range u.allowedRepos {
+		if len(allow.Namespace) > 0 && allow.Namespace != namespace {
+			continue
+		}
+		if ok, _ := path.Match(allow.Pattern, repo); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bumpValue returns the value with the current version replaced by the
+// latest. The value is either the version, or an image ending with
+// :<version>. Values already of the latest version are returned as is.
+func bumpValue(value, currentVersion, latestVersion string) (string, error) {
+	switch {
+	case value == latestVersion, strings.HasSuffix(value, ":"+latestVersion):
+		return value, nil
+	case value == currentVersion:
+		return latestVersion, nil
+	case strings.HasSuffix(value, ":"+currentVersion):
+		return strings.TrimSuffix(value, currentVersion) + latestVersion, nil
+	default:
+		return "", fmt.Errorf("value %q is not of the current version %q", value, currentVersion)
+	}
+}
+
+// branchName returns the name of the branch of the pull request updating the
+// image to its latest version.
+func branchName(image metrics.Image) string {
+	return "version-checker/" +
+		branchInvalidChars.ReplaceAllString(image.Image+"-"+image.LatestVersion, "-")
+}
@@ -0,0 +1,277 @@
+package gitops
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+type fakeProvider struct {
+	content string
+	openErr error
+	prs     []PullRequest
+}
+
+func (f *fakeProvider) Host() string {
+	return "github.com"
+}
+
+func (f *fakeProvider) GetFile(_ context.Context, _, _, _ string) ([]byte, string, error) {
+	return []byte(f.content), "sha", nil
+}
+
+func (f *fakeProvider) OpenPullRequest(_ context.Context, pr PullRequest) (string, error) {
+	f.prs = append(f.prs, pr)
+	return "https://github.com/org/deploy/pull/1", f.openErr
+}
+
+func TestUpdate(t *testing.T) {
+	image := metrics.Image{
+		Namespace:      "prod",
+		Pod:            "web-1",
+		Container:      "nginx",
+		Image:          "docker.io/library/nginx",
+		CurrentVersion: "1.19.6",
+		LatestVersion:  "1.21.0",
+	}
+
+	tracked := map[string]string{
+		"gitops-repo.version-checker.io/nginx": "github.com/org/deploy",
+		"gitops-path.version-checker.io/nginx": "web/values.yaml",
+		"gitops-key.version-checker.io/nginx":  "image.tag",
+	}
+
+	allowed := []AllowedRepo{{Pattern: "github.com/org/*"}, {Pattern: "bitbucket.org/org/deploy"}}
+
+	tests := map[string]struct {
+		annotations  map[string]string
+		allowedRepos []AllowedRepo
+		image        *metrics.Image
+		content      string
+		openErr      error
+		expPRs       []PullRequest
+		expErr       bool
+	}{
+		"pod without annotations should not open a pull request": {
+			content: "image:\n  tag: 1.19.6\n",
+		},
+		"tracked value of the current version should be bumped": {
+			annotations: tracked,
+			content:     "image:\n  tag: 1.19.6\n",
+			expPRs: []PullRequest{{
+				Repo:        "org/deploy",
+				Path:        "web/values.yaml",
+				Content:     []byte("image:\n  tag: 1.21.0\n"),
+				FileVersion: "sha",
+				BaseBranch:  "main",
+				Branch:      "version-checker/docker.io-library-nginx-1.21.0",
+				Title:       "Update docker.io/library/nginx to 1.21.0",
+			}},
+		},
+		"tracked value already of the latest version should not open a pull request": {
+			annotations: tracked,
+			content:     "image:\n  tag: 1.21.0\n",
+		},
+		"tracked value of another version should error": {
+			annotations: tracked,
+			content:     "image:\n  tag: 1.18.0\n",
+			expErr:      true,
+		},
+		"existing branch should not error": {
+			annotations: tracked,
+			content:     "image:\n  tag: 1.19.6\n",
+			openErr:     ErrBranchExists,
+			expPRs: []PullRequest{{
+				Repo:        "org/deploy",
+				Path:        "web/values.yaml",
+				Content:     []byte("image:\n  tag: 1.21.0\n"),
+				FileVersion: "sha",
+				BaseBranch:  "main",
+				Branch:      "version-checker/docker.io-library-nginx-1.21.0",
+				Title:       "Update docker.io/library/nginx to 1.21.0",
+			}},
+		},
+		"repository of unknown host should error": {
+			annotations: map[string]string{
+				"gitops-repo.version-checker.io/nginx": "bitbucket.org/org/deploy",
+				"gitops-path.version-checker.io/nginx": "web/values.yaml",
+				"gitops-key.version-checker.io/nginx":  "image.tag",
+			},
+			content: "image:\n  tag: 1.19.6\n",
+			expErr:  true,
+		},
+		"repository not allowed should error": {
+			annotations: map[string]string{
+				"gitops-repo.version-checker.io/nginx": "github.com/other-org/deploy",
+				"gitops-path.version-checker.io/nginx": "web/values.yaml",
+				"gitops-key.version-checker.io/nginx":  "image.tag",
+			},
+			content: "image:\n  tag: 1.19.6\n",
+			expErr:  true,
+		},
+		"repository allowed for another namespace should error": {
+			annotations:  tracked,
+			allowedRepos: []AllowedRepo{{Namespace: "staging", Pattern: "github.com/org/deploy"}},
+			content:      "image:\n  tag: 1.19.6\n",
+			expErr:       true,
+		},
+		"repository allowed for the namespace should be bumped": {
+			annotations:  tracked,
+			allowedRepos: []AllowedRepo{{Namespace: "prod", Pattern: "github.com/org/deploy"}},
+			content:      "image:\n  tag: 1.19.6\n",
+			expPRs: []PullRequest{{
+				Repo:        "org/deploy",
+				Path:        "web/values.yaml",
+				Content:     []byte("image:\n  tag: 1.21.0\n"),
+				FileVersion: "sha",
+				BaseBranch:  "main",
+				Branch:      "version-checker/docker.io-library-nginx-1.21.0",
+				Title:       "Update docker.io/library/nginx to 1.21.0",
+			}},
+		},
+		"older latest version should not downgrade": {
+			annotations: tracked,
+			image: &metrics.Image{
+				Namespace:      "prod",
+				Pod:            "web-1",
+				Container:      "nginx",
+				Image:          "docker.io/library/nginx",
+				CurrentVersion: "1.21.0",
+				LatestVersion:  "1.9.0",
+			},
+			content: "image:\n  tag: 1.21.0\n",
+		},
+		"repository without path should error": {
+			annotations: map[string]string{
+				"gitops-repo.version-checker.io/nginx": "github.com/org/deploy",
+			},
+			content: "image:\n  tag: 1.19.6\n",
+			expErr:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "prod",
+					Name:        "web-1",
+					Annotations: test.annotations,
+				},
+			})
+			provider := &fakeProvider{content: test.content, openErr: test.openErr}
+
+			allowedRepos := allowed
+			if test.allowedRepos != nil {
+				allowedRepos = test.allowedRepos
+			}
+			image := image
+			if test.image != nil {
+				image = *test.image
+			}
+
+			u := New(logrus.NewEntry(logrus.New()), kubeClient, []Provider{provider}, allowedRepos)
+
+			// Updating again should not open another pull request.
+			for i := 0; i < 2; i++ {
+				err := u.update(context.TODO(), image)
+				if (err != nil) != test.expErr {
+					t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+				}
+			}
+
+			if len(provider.prs) != len(test.expPRs) {
+				t.Fatalf("unexpected pull requests, exp=%d got=%d", len(test.expPRs), len(provider.prs))
+			}
+			for i, pr := range provider.prs {
+				pr.Body = ""
+				exp := test.expPRs[i]
+				if pr.Repo != exp.Repo || pr.Path != exp.Path || string(pr.Content) != string(exp.Content) ||
+					pr.FileVersion != exp.FileVersion || pr.BaseBranch != exp.BaseBranch ||
+					pr.Branch != exp.Branch || pr.Title != exp.Title {
+					t.Errorf("unexpected pull request, exp=%+v got=%+v", exp, pr)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAllowedRepos(t *testing.T) {
+	tests := map[string]struct {
+		repos  []string
+		exp    []AllowedRepo
+		expErr bool
+	}{
+		"repositories with and without namespace should be parsed": {
+			repos: []string{"github.com/org/*", "prod=gitlab.com/org/deploy"},
+			exp: []AllowedRepo{
+				{Pattern: "github.com/org/*"},
+				{Namespace: "prod", Pattern: "gitlab.com/org/deploy"},
+			},
+		},
+		"repository without host should error": {
+			repos:  []string{"prod=deploy"},
+			expErr: true,
+		},
+		"invalid pattern should error": {
+			repos:  []string{"github.com/org/[deploy"},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseAllowedRepos(test.repos)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if !reflect.DeepEqual(got, test.exp) && !test.expErr {
+				t.Errorf("unexpected allowed repos, exp=%+v got=%+v", test.exp, got)
+			}
+		})
+	}
+}
+
+func TestBumpValue(t *testing.T) {
+	tests := map[string]struct {
+		value  string
+		exp    string
+		expErr bool
+	}{
+		"version should be bumped": {
+			value: "1.19.6",
+			exp:   "1.21.0",
+		},
+		"image should have its tag bumped": {
+			value: "localhost:5000/nginx:1.19.6",
+			exp:   "localhost:5000/nginx:1.21.0",
+		},
+		"latest version should be unchanged": {
+			value: "nginx:1.21.0",
+			exp:   "nginx:1.21.0",
+		},
+		"other version should error": {
+			value:  "1.19.60",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := bumpValue(test.value, "1.19.6", "1.21.0")
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if got != test.exp {
+				t.Errorf("unexpected value, exp=%q got=%q", test.exp, got)
+			}
+		})
+	}
+}
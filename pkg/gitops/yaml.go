@@ -0,0 +1,114 @@
+package gitops
+
+import (
+	"fmt"
+	"strings"
+)
+
+// yamlKey is a mapping key of the YAML file, by its indentation.
+type yamlKey struct {
+	indent int
+	key    string
+}
+
+// SetYAMLValue returns the YAML content with the scalar value of the dot
+// separated key path, such as image.tag, replaced by update of the current
+// value. Only the value is changed, keeping the comments, quoting and
+// formatting of the file. Keys are of nested mappings; keys within sequences
+// are not addressable.
+func SetYAMLValue(content []byte, keyPath string, update func(value string) (string, error)) ([]byte, error) {
+	lines := strings.Split(string(content), "\n")
+
+	var stack []yamlKey
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+
+		if len(strings.TrimSpace(trimmed)) == 0 || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "---") {
+			stack = nil
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		// Sequence items are pushed so the keys within them never match.
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			stack = append(stack, yamlKey{indent: indent, key: "-"})
+			trimmed = strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " ")
+			indent = len(line) - len(trimmed)
+		}
+
+		key, value, valueStart, ok := splitYAMLLine(trimmed)
+		if !ok {
+			continue
+		}
+
+		if len(value) == 0 {
+			stack = append(stack, yamlKey{indent: indent, key: key})
+			continue
+		}
+
+		path := make([]string, 0, len(stack)+1)
+		for _, k := range stack {
+			path = append(path, k.key)
+		}
+		if strings.Join(append(path, key), ".") != keyPath {
+			continue
+		}
+
+		quote := ""
+		if value[0] == '"' || value[0] == '\'' {
+			quote = value[:1]
+			value = strings.Trim(value, quote)
+		}
+
+		updated, err := update(value)
+		if err != nil {
+			return nil, err
+		}
+
+		start := len(line) - len(trimmed) + valueStart
+		end := start + len(quote) + len(value) + len(quote)
+		lines[i] = line[:start] + quote + updated + quote + line[end:]
+
+		return []byte(strings.Join(lines, "\n")), nil
+	}
+
+	return nil, fmt.Errorf("key %q not found", keyPath)
+}
+
+// splitYAMLLine returns the key and the scalar value of a mapping line, with
+// the offset of the value in the line. The value is empty if the line starts a
+// nested mapping or sequence.
+func splitYAMLLine(line string) (string, string, int, bool) {
+	i := strings.Index(line, ":")
+	if i < 1 || (i+1 < len(line) && line[i+1] != ' ') {
+		return "", "", 0, false
+	}
+	key := strings.Trim(line[:i], `"'`)
+
+	rest := line[i+1:]
+	value := strings.TrimLeft(rest, " ")
+	valueStart := i + 1 + len(rest) - len(value)
+
+	switch {
+	case len(value) == 0 || value[0] == '#':
+		return key, "", 0, true
+	case value[0] == '"' || value[0] == '\'':
+		if end := strings.IndexByte(value[1:], value[0]); end > -1 {
+			value = value[:end+2]
+		}
+	default:
+		if end := strings.Index(value, " #"); end > -1 {
+			value = value[:end]
+		}
+		value = strings.TrimRight(value, " ")
+	}
+
+	return key, value, valueStart, true
+}
@@ -0,0 +1,90 @@
+package gitops
+
+import (
+	"testing"
+)
+
+func TestSetYAMLValue(t *testing.T) {
+	const values = `# Values of the web chart.
+image:
+  repository: nginx
+  tag: 1.19.6 # managed by version-checker
+sidecar:
+  image: "envoyproxy/envoy:v1.16.0"
+containers:
+  - name: app
+    tag: 1.0.0
+tag: top
+`
+
+	tests := map[string]struct {
+		key    string
+		exp    string
+		expErr bool
+	}{
+		"nested value should keep its comment": {
+			key: "image.tag",
+			exp: `# Values of the web chart.
+image:
+  repository: nginx
+  tag: 2.0.0 # managed by version-checker
+sidecar:
+  image: "envoyproxy/envoy:v1.16.0"
+containers:
+  - name: app
+    tag: 1.0.0
+tag: top
+`,
+		},
+		"quoted value should keep its quotes": {
+			key: "sidecar.image",
+			exp: `# Values of the web chart.
+image:
+  repository: nginx
+  tag: 1.19.6 # managed by version-checker
+sidecar:
+  image: "2.0.0"
+containers:
+  - name: app
+    tag: 1.0.0
+tag: top
+`,
+		},
+		"top level value should be found after nested keys": {
+			key: "tag",
+			exp: `# Values of the web chart.
+image:
+  repository: nginx
+  tag: 1.19.6 # managed by version-checker
+sidecar:
+  image: "envoyproxy/envoy:v1.16.0"
+containers:
+  - name: app
+    tag: 1.0.0
+tag: 2.0.0
+`,
+		},
+		"keys within sequences should not be found": {
+			key:    "containers.tag",
+			expErr: true,
+		},
+		"missing key should error": {
+			key:    "image.digest",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := SetYAMLValue([]byte(values), test.key, func(string) (string, error) {
+				return "2.0.0", nil
+			})
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err == nil && string(got) != test.exp {
+				t.Errorf("unexpected content, exp=%q got=%q", test.exp, got)
+			}
+		})
+	}
+}
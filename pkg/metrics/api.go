@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// Image is the result of the last check of the image of a container.
+type Image struct {
+	Namespace      string `json:"namespace"`
+	Pod            string `json:"pod"`
+	Container      string `json:"container"`
+	ContainerType  string `json:"containerType"`
+	Image          string `json:"image"`
+	CurrentVersion string `json:"currentVersion"`
+	LatestVersion  string `json:"latestVersion"`
+	IsLatest       bool   `json:"isLatest"`
+}
+
+// ImageFilter selects the images returned by Images. Empty fields match every
+// image.
+type ImageFilter struct {
+	Namespace    string
+	Pod          string
+	OutdatedOnly bool
+}
+
+// Matches returns true if the image matches the filter.
+func (f ImageFilter) Matches(image Image) bool {
+	if len(f.Namespace) > 0 && image.Namespace != f.Namespace {
+		return false
+	}
+	if len(f.Pod) > 0 && image.Pod != f.Pod {
+		return false
+	}
+
+	return !f.OutdatedOnly || !image.IsLatest
+}
+
+// Images returns the results of the images currently checked which match the
+// filter, ordered by namespace, pod and container.
+func (m *Metrics) Images(filter ImageFilter) []Image {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.filterImages(filter)
+}
+
+// ImageStatus returns the result of the image of the container, if checked.
+func (m *Metrics) ImageStatus(namespace, pod, container string) (Image, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	image, ok := m.images[m.latestImageIndex(namespace, pod, container)]
+	return image, ok
+}
+
+// filterImages returns the images matching the filter. The lock must be held.
+func (m *Metrics) filterImages(filter ImageFilter) []Image {
+	images := []Image{}
+	for _, image := range m.images {
+		if filter.Matches(image) {
+			images = append(images, image)
+		}
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].Namespace != images[j].Namespace {
+			return images[i].Namespace < images[j].Namespace
+		}
+		if images[i].Pod != images[j].Pod {
+			return images[i].Pod < images[j].Pod
+		}
+		return images[i].Container < images[j].Container
+	})
+
+	return images
+}
+
+// serveImages serves the images currently checked as JSON, filtered by the
+// namespace, pod and outdated query parameters.
+func (m *Metrics) serveImages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := ImageFilter{
+		Namespace:    query.Get("namespace"),
+		Pod:          query.Get("pod"),
+		OutdatedOnly: query.Get("outdated") == "true",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Images(filter)); err != nil {
+		m.log.Errorf("failed to write images response: %s", err)
+	}
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestServeImages(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()))
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.17.0", "1.19.6")
+	m.AddImage("prod", "web-1", "init", "init", "busybox", "1.32", "1.32")
+	m.AddImage("dev", "app", "app", "container", "quay.io/jetstack/app", "v0.1.0", "v0.2.0")
+	m.AddImage("dev", "gone", "app", "container", "quay.io/jetstack/app", "v0.1.0", "v0.2.0")
+	m.RemoveImage("dev", "gone", "app", "container", "quay.io/jetstack/app", "v0.1.0")
+
+	var (
+		nginx = Image{Namespace: "prod", Pod: "web-1", Container: "nginx", ContainerType: "container",
+			Image: "nginx", CurrentVersion: "1.17.0", LatestVersion: "1.19.6"}
+		busybox = Image{Namespace: "prod", Pod: "web-1", Container: "init", ContainerType: "init",
+			Image: "busybox", CurrentVersion: "1.32", LatestVersion: "1.32", IsLatest: true}
+		app = Image{Namespace: "dev", Pod: "app", Container: "app", ContainerType: "container",
+			Image: "quay.io/jetstack/app", CurrentVersion: "v0.1.0", LatestVersion: "v0.2.0"}
+	)
+
+	tests := map[string]struct {
+		query string
+		exp   []Image
+	}{
+		"no filters should return every image": {
+			query: "",
+			exp:   []Image{app, busybox, nginx},
+		},
+		"namespace filter should only return images of the namespace": {
+			query: "?namespace=prod",
+			exp:   []Image{busybox, nginx},
+		},
+		"pod filter should only return images of the pod": {
+			query: "?namespace=dev&pod=app",
+			exp:   []Image{app},
+		},
+		"outdated filter should only return outdated images": {
+			query: "?namespace=prod&outdated=true",
+			exp:   []Image{nginx},
+		},
+		"no matches should return an empty list": {
+			query: "?namespace=missing",
+			exp:   []Image{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.serveImages(rec, httptest.NewRequest(http.MethodGet, "/api/v1/images"+test.query, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("unexpected status code, exp=%d got=%d", http.StatusOK, rec.Code)
+			}
+
+			var images []Image
+			if err := json.Unmarshal(rec.Body.Bytes(), &images); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(images, test.exp) {
+				t.Errorf("unexpected images, exp=%+v got=%+v", test.exp, images)
+			}
+		})
+	}
+}
@@ -20,12 +20,17 @@ import (
 type Metrics struct {
 	*http.Server
 
-	registry              *prometheus.Registry
-	containerImageVersion *prometheus.GaugeVec
-	log                   *logrus.Entry
+	registry                *prometheus.Registry
+	containerImageVersion   *prometheus.GaugeVec
+	registryRequestDuration *prometheus.SummaryVec
+	dockerHubRateLimit      *prometheus.GaugeVec
+	negativeCacheEntries    *prometheus.GaugeVec
+	tagNotAllowed           *prometheus.GaugeVec
+	log                     *logrus.Entry
 
-	mu               sync.Mutex
-	latestImageLabel map[string]string
+	mu       sync.RWMutex
+	images   map[string]Image
+	watchers map[chan ImageEvent]ImageFilter
 }
 
 func New(log *logrus.Entry) *Metrics {
@@ -36,18 +41,64 @@ func New(log *logrus.Entry) *Metrics {
 			Help:      "Where the container in use is using the latest upstream registry version",
 		},
 		[]string{
-			"namespace", "pod", "container", "image", "current_version", "latest_version",
+			"namespace", "pod", "container", "container_type", "image", "current_version", "latest_version",
+		},
+	)
+
+	registryRequestDuration := prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace:  "version_checker",
+			Name:       "registry_request_duration_seconds",
+			Help:       "Duration of requests made to image registries",
+			Objectives: map[float64]float64{0.5: 0.05, 0.95: 0.01, 0.99: 0.001},
+			MaxAge:     time.Minute * 10,
+		},
+		[]string{"host"},
+	)
+
+	dockerHubRateLimit := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "version_checker",
+			Name:      "docker_hub_rate_limit",
+			Help:      "Docker Hub rate limit and remaining budget, as last reported by Docker Hub",
+		},
+		[]string{"type"},
+	)
+
+	negativeCacheEntries := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "version_checker",
+			Name:      "negative_cache_entries",
+			Help:      "Images whose repository was not found, which are not looked up until the entry expires",
+		},
+		[]string{"image"},
+	)
+
+	tagNotAllowed := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "version_checker",
+			Name:      "tag_not_allowed",
+			Help:      "Containers whose current tag is not allowed by the tag policy of the image's repository",
+		},
+		[]string{
+			"namespace", "pod", "container", "image", "current_version",
 		},
 	)
 
 	registry := prometheus.NewRegistry()
-	registry.MustRegister(containerImageVersion)
+	registry.MustRegister(containerImageVersion, registryRequestDuration, dockerHubRateLimit,
+		negativeCacheEntries, tagNotAllowed)
 
 	return &Metrics{
-		log:                   log.WithField("module", "metrics"),
-		registry:              registry,
-		containerImageVersion: containerImageVersion,
-		latestImageLabel:      make(map[string]string),
+		log:                     log.WithField("module", "metrics"),
+		registry:                registry,
+		containerImageVersion:   containerImageVersion,
+		registryRequestDuration: registryRequestDuration,
+		dockerHubRateLimit:      dockerHubRateLimit,
+		negativeCacheEntries:    negativeCacheEntries,
+		tagNotAllowed:           tagNotAllowed,
+		images:                  make(map[string]Image),
+		watchers:                make(map[chan ImageEvent]ImageFilter),
 	}
 }
 
@@ -55,6 +106,7 @@ func New(log *logrus.Entry) *Metrics {
 func (m *Metrics) Run(servingAddress string) error {
 	router := http.NewServeMux()
 	router.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	router.HandleFunc("/api/v1/images", m.serveImages)
 
 	ln, err := net.Listen("tcp", servingAddress)
 	if err != nil {
@@ -81,7 +133,7 @@ func (m *Metrics) Run(servingAddress string) error {
 	return nil
 }
 
-func (m *Metrics) AddImage(namespace, pod, container, imageURL string, currentImage, latestImage string) {
+func (m *Metrics) AddImage(namespace, pod, container, containerType, imageURL string, currentImage, latestImage string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -91,41 +143,109 @@ func (m *Metrics) AddImage(namespace, pod, container, imageURL string, currentIm
 	}
 
 	m.containerImageVersion.With(
-		m.buildLabels(namespace, pod, container, imageURL, currentImage, latestImage),
+		m.buildLabels(namespace, pod, container, containerType, imageURL, currentImage, latestImage),
 	).Set(isLatest)
 
+	image := Image{
+		Namespace:      namespace,
+		Pod:            pod,
+		Container:      container,
+		ContainerType:  containerType,
+		Image:          imageURL,
+		CurrentVersion: currentImage,
+		LatestVersion:  latestImage,
+		IsLatest:       isLatest == 1.0,
+	}
+
 	index := m.latestImageIndex(namespace, pod, container)
-	m.latestImageLabel[index] = latestImage
+	last, ok := m.images[index]
+	m.images[index] = image
+
+	switch {
+	case !ok:
+		m.notifyWatchers(ImageEvent{Type: ImageEventAdded, Image: image}, nil)
+	case last != image:
+		m.notifyWatchers(ImageEvent{Type: ImageEventUpdated, Image: image}, &last)
+	}
 }
 
-func (m *Metrics) RemoveImage(namespace, pod, container, imageURL, currentImage string) {
+func (m *Metrics) RemoveImage(namespace, pod, container, containerType, imageURL, currentImage string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	index := m.latestImageIndex(namespace, pod, container)
 	m.containerImageVersion.Delete(
-		m.buildLabels(namespace, pod, container, imageURL, currentImage,
-			m.latestImageLabel[index],
+		m.buildLabels(namespace, pod, container, containerType, imageURL, currentImage,
+			m.images[index].LatestVersion,
 		),
 	)
-	delete(m.latestImageLabel, index)
+	if image, ok := m.images[index]; ok {
+		delete(m.images, index)
+		m.notifyWatchers(ImageEvent{Type: ImageEventRemoved, Image: image}, nil)
+	}
+
+	m.tagNotAllowed.Delete(m.buildTagLabels(namespace, pod, container, imageURL, currentImage))
+}
+
+// ObserveTagAllowed records whether the current tag of the container is
+// allowed by the tag policy of the image's repository.
+func (m *Metrics) ObserveTagAllowed(namespace, pod, container, imageURL, currentImage string, allowed bool) {
+	labels := m.buildTagLabels(namespace, pod, container, imageURL, currentImage)
+	if allowed {
+		m.tagNotAllowed.Delete(labels)
+	} else {
+		m.tagNotAllowed.With(labels).Set(1)
+	}
+}
+
+// ObserveRegistryRequestDuration records the duration of a request made to the
+// given registry host.
+func (m *Metrics) ObserveRegistryRequestDuration(host string, duration time.Duration) {
+	m.registryRequestDuration.With(prometheus.Labels{"host": host}).Observe(duration.Seconds())
+}
+
+// ObserveDockerHubRateLimit records the rate limit and remaining budget last
+// reported by Docker Hub.
+func (m *Metrics) ObserveDockerHubRateLimit(limit, remaining int) {
+	m.dockerHubRateLimit.With(prometheus.Labels{"type": "limit"}).Set(float64(limit))
+	m.dockerHubRateLimit.With(prometheus.Labels{"type": "remaining"}).Set(float64(remaining))
+}
+
+// ObserveNegativeCache records whether the image is in the negative cache.
+func (m *Metrics) ObserveNegativeCache(imageURL string, cached bool) {
+	if cached {
+		m.negativeCacheEntries.With(prometheus.Labels{"image": imageURL}).Set(1)
+	} else {
+		m.negativeCacheEntries.Delete(prometheus.Labels{"image": imageURL})
+	}
 }
 
 func (m *Metrics) latestImageIndex(namespace, pod, container string) string {
-	return strings.Join([]string{namespace, pod, container}, "")
+	return strings.Join([]string{namespace, pod, container}, "/")
 }
 
-func (m *Metrics) buildLabels(namespace, pod, container, imageURL, currentImage, latestImage string) prometheus.Labels {
+func (m *Metrics) buildLabels(namespace, pod, container, containerType, imageURL, currentImage, latestImage string) prometheus.Labels {
 	return prometheus.Labels{
 		"namespace":       namespace,
 		"pod":             pod,
 		"container":       container,
+		"container_type":  containerType,
 		"image":           imageURL,
 		"current_version": currentImage,
 		"latest_version":  latestImage,
 	}
 }
 
+func (m *Metrics) buildTagLabels(namespace, pod, container, imageURL, currentImage string) prometheus.Labels {
+	return prometheus.Labels{
+		"namespace":       namespace,
+		"pod":             pod,
+		"container":       container,
+		"image":           imageURL,
+		"current_version": currentImage,
+	}
+}
+
 func (m *Metrics) Shutdown() error {
 	// If metrics server is not started than exit early
 	if m.Server == nil {
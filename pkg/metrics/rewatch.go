@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// RewatchBackoff is the time waited before watching images again after
+	// a watch ends.
+	RewatchBackoff = time.Second
+
+	// outdatedQueue is the number of outdated images waiting to be handled
+	// before further images are dropped.
+	outdatedQueue = 100
+)
+
+// Watcher watches the results of image checks.
+type Watcher interface {
+	Watch(ctx context.Context, filter ImageFilter) ([]Image, <-chan ImageEvent)
+}
+
+// Rewatch will watch the images matching the filter until the context is
+// done, watching again once a watch ends, such as by falling behind. reset is
+// called with the images currently checked at the start of every watch, and
+// handle with every event following them.
+func Rewatch(ctx context.Context, log *logrus.Entry, watcher Watcher, filter ImageFilter,
+	reset func([]Image), handle func(ImageEvent)) {
+	for {
+		images, events := watcher.Watch(ctx, filter)
+		reset(images)
+
+		for event := range events {
+			handle(event)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(RewatchBackoff):
+			log.Debug("image watch ended, watching again")
+		}
+	}
+}
+
+// WatchOutdated will watch the results of image checks until the context is
+// done, calling update with outdated images one at a time. Images compared by
+// digest are not updated, having no version to update to. Outdated images are
+// dropped while the queue of images waiting to be updated is full.
+func WatchOutdated(ctx context.Context, log *logrus.Entry, watcher Watcher,
+	update func(context.Context, Image) error) {
+	queue := make(chan Image, outdatedQueue)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case image := <-queue:
+				if err := update(ctx, image); err != nil {
+					log.Errorf("failed to update %s/%s %s: %s",
+						image.Namespace, image.Pod, image.Container, err)
+				}
+			}
+		}
+	}()
+
+	enqueue := func(image Image) {
+		if image.IsLatest ||
+			strings.Contains(image.CurrentVersion, ":") || strings.Contains(image.LatestVersion, ":") {
+			return
+		}
+
+		select {
+		case queue <- image:
+		default:
+			log.Warnf("update queue full, dropping update of %s %s -> %s",
+				image.Image, image.CurrentVersion, image.LatestVersion)
+		}
+	}
+
+	Rewatch(ctx, log, watcher, ImageFilter{},
+		func(images []Image) {
+			for _, image := range images {
+				enqueue(image)
+			}
+		},
+		func(event ImageEvent) {
+			if event.Type != ImageEventRemoved {
+				enqueue(event.Image)
+			}
+		},
+	)
+}
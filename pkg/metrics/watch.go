@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+)
+
+const (
+	// watcherBuffer is the number of events buffered per watcher. Watchers
+	// falling further behind are closed, so must watch again.
+	watcherBuffer = 100
+)
+
+// ImageEventType is the type of change of an image result.
+type ImageEventType string
+
+const (
+	ImageEventAdded   ImageEventType = "ADDED"
+	ImageEventUpdated ImageEventType = "UPDATED"
+	ImageEventRemoved ImageEventType = "REMOVED"
+)
+
+// ImageEvent is a change of the result of the image of a container, such as
+// its latest version changing.
+type ImageEvent struct {
+	Type  ImageEventType `json:"type"`
+	Image Image          `json:"image"`
+}
+
+// Watch returns the images currently checked which match the filter, and a
+// channel of the events of matching images following them. The channel is
+// closed once the context is done, or if the watcher falls behind.
+func (m *Metrics) Watch(ctx context.Context, filter ImageFilter) ([]Image, <-chan ImageEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan ImageEvent, watcherBuffer)
+	m.watchers[ch] = filter
+
+	go func() {
+		<-ctx.Done()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.removeWatcher(ch)
+	}()
+
+	return m.filterImages(filter), ch
+}
+
+// notifyWatchers sends the event to every watcher whose filter matches the
+// image, or its previous result, so that watchers see images leaving the
+// filter. The lock must be held.
+func (m *Metrics) notifyWatchers(event ImageEvent, previous *Image) {
+	for ch, filter := range m.watchers {
+		if !filter.Matches(event.Image) && (previous == nil || !filter.Matches(*previous)) {
+			continue
+		}
+
+		select {
+		case ch <- event:
+		default:
+			m.log.Warn("image watcher fell behind, closing")
+			m.removeWatcher(ch)
+		}
+	}
+}
+
+// removeWatcher closes the watcher, if not already. The lock must be held.
+func (m *Metrics) removeWatcher(ch chan ImageEvent) {
+	if _, ok := m.watchers[ch]; ok {
+		delete(m.watchers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWatch(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()))
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.19.6", "1.19.6")
+	m.AddImage("dev", "app", "app", "container", "app", "v0.1.0", "v0.2.0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	images, events := m.Watch(ctx, ImageFilter{Namespace: "prod", OutdatedOnly: true})
+	if len(images) != 0 {
+		t.Errorf("unexpected images, exp=[] got=%+v", images)
+	}
+
+	// Rechecks without changes have no events.
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.19.6", "1.19.6")
+	// Images of other namespaces have no events.
+	m.AddImage("dev", "app", "app", "container", "app", "v0.1.0", "v0.3.0")
+	// A new latest version makes the image outdated.
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.19.6", "1.21.0")
+	// Updating to the latest version leaves the filter.
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.21.0", "1.21.0")
+	// Images which have left the filter have no events.
+	m.RemoveImage("prod", "web-1", "nginx", "container", "nginx", "1.21.0")
+	m.AddImage("prod", "web-2", "nginx", "container", "nginx", "1.19.6", "1.21.0")
+	m.RemoveImage("prod", "web-2", "nginx", "container", "nginx", "1.19.6")
+
+	cancel()
+
+	var got []ImageEvent
+	for event := range events {
+		got = append(got, event)
+	}
+
+	var (
+		outdated = Image{Namespace: "prod", Pod: "web-1", Container: "nginx", ContainerType: "container",
+			Image: "nginx", CurrentVersion: "1.19.6", LatestVersion: "1.21.0"}
+		updated = Image{Namespace: "prod", Pod: "web-1", Container: "nginx", ContainerType: "container",
+			Image: "nginx", CurrentVersion: "1.21.0", LatestVersion: "1.21.0", IsLatest: true}
+		added = Image{Namespace: "prod", Pod: "web-2", Container: "nginx", ContainerType: "container",
+			Image: "nginx", CurrentVersion: "1.19.6", LatestVersion: "1.21.0"}
+	)
+
+	exp := []ImageEvent{
+		{Type: ImageEventUpdated, Image: outdated},
+		{Type: ImageEventUpdated, Image: updated},
+		{Type: ImageEventAdded, Image: added},
+		{Type: ImageEventRemoved, Image: added},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected events, exp=%+v got=%+v", exp, got)
+	}
+}
+
+func TestWatchFallingBehind(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()))
+
+	_, events := m.Watch(context.Background(), ImageFilter{})
+	for i := 0; i <= watcherBuffer; i++ {
+		m.AddImage("prod", "web", "nginx", "container", "nginx", "1.19.6", fmt.Sprintf("1.%d.0", i))
+	}
+
+	var received int
+	for range events {
+		received++
+	}
+
+	if received != watcherBuffer {
+		t.Errorf("unexpected received events, exp=%d got=%d", watcherBuffer, received)
+	}
+}
+
+func TestWatchOutdated(t *testing.T) {
+	m := New(logrus.NewEntry(logrus.New()))
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.19.6", "1.21.0")
+	m.AddImage("prod", "web-2", "nginx", "container", "nginx", "1.21.0", "1.21.0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updated := make(chan Image)
+	go WatchOutdated(ctx, logrus.NewEntry(logrus.New()), m, func(_ context.Context, image Image) error {
+		updated <- image
+		return nil
+	})
+
+	if got := <-updated; got.Pod != "web-1" {
+		t.Errorf("unexpected updated image of the watch, exp=web-1 got=%+v", got)
+	}
+
+	// Images compared by digest have no version to update to.
+	m.AddImage("prod", "web-3", "app", "container", "app", "sha256:a", "sha256:b")
+	m.AddImage("prod", "web-2", "nginx", "container", "nginx", "1.21.0", "1.22.0")
+
+	if got := <-updated; got.Pod != "web-2" || got.LatestVersion != "1.22.0" {
+		t.Errorf("unexpected updated image of the events, exp=web-2 got=%+v", got)
+	}
+}
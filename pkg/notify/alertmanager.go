@@ -0,0 +1,279 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+const (
+	// AlertName is the alertname label of alerts of outdated images.
+	AlertName = "VersionCheckerImageOutdated"
+
+	alertmanagerAlertsPath = "/api/v2/alerts"
+
+	defaultAlertmanagerResendInterval = time.Minute
+)
+
+// AlertmanagerOptions used to configure pushing alerts of outdated images to
+// Alertmanager.
+type AlertmanagerOptions struct {
+	// URL is the base URL of Alertmanager, such as
+	// http://alertmanager.monitoring:9093.
+	URL string
+
+	// VersionsBehind fires the alert of an image once its current version is
+	// at least this many minor versions behind the latest, or patch versions
+	// if the same minor. A major version behind always exceeds it. Zero
+	// disables the threshold.
+	VersionsBehind int
+
+	// OutdatedFor fires the alert of an image once it has been outdated for
+	// this long. Zero disables the threshold. If both thresholds are
+	// disabled, every outdated image fires.
+	OutdatedFor time.Duration
+
+	// ResendInterval is how often firing alerts are pushed again, so
+	// Alertmanager does not resolve them. Defaults to 1m.
+	ResendInterval time.Duration
+
+	// Labels are added to every alert, such as the cluster name.
+	Labels map[string]string
+
+	HTTPOptions
+}
+
+// Alertmanager pushes alerts to Alertmanager for images exceeding the
+// versions behind or outdated thresholds, labelled the same as the
+// is_latest_version metric. Alerts are resolved once the image is no longer
+// outdated, or its container is removed.
+type Alertmanager struct {
+	*http.Client
+	AlertmanagerOptions
+
+	log *logrus.Entry
+
+	images map[string]metrics.Image
+
+	// outdatedSince is when each outdated image was first seen outdated.
+	outdatedSince map[string]time.Time
+
+	// firing are the alerts last pushed as firing, by their alertID.
+	firing map[string]alert
+}
+
+// alert is an alert of the Alertmanager v2 API.
+type alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+func NewAlertmanager(log *logrus.Entry, opts AlertmanagerOptions) (*Alertmanager, error) {
+	if len(opts.URL) == 0 {
+		return nil, errors.New("alertmanager notifier requires a URL")
+	}
+
+	if opts.ResendInterval <= 0 {
+		opts.ResendInterval = defaultAlertmanagerResendInterval
+	}
+
+	return &Alertmanager{
+		AlertmanagerOptions: opts,
+		Client:              opts.newClient(),
+		log:                 log.WithField("module", "alertmanager"),
+		images:              make(map[string]metrics.Image),
+		outdatedSince:       make(map[string]time.Time),
+		firing:              make(map[string]alert),
+	}, nil
+}
+
+// Run will watch the results of image checks, pushing alerts every resend
+// interval until the context is done.
+func (a *Alertmanager) Run(ctx context.Context, watcher Watcher) {
+	ticker := time.NewTicker(a.ResendInterval)
+	defer ticker.Stop()
+
+	for {
+		images, events := watcher.Watch(ctx, metrics.ImageFilter{})
+		a.reset(images, time.Now())
+
+	watch:
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					break watch
+				}
+				a.handle(event, time.Now())
+
+			case <-ticker.C:
+				if err := a.push(ctx, a.alerts(time.Now())); err != nil {
+					a.log.Errorf("failed to push alerts: %s", err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(metrics.RewatchBackoff):
+			a.log.Debug("image watch ended, watching again")
+		}
+	}
+}
+
+// reset will set the images currently checked, keeping when images still
+// outdated were first seen outdated.
+func (a *Alertmanager) reset(images []metrics.Image, now time.Time) {
+	outdatedSince := a.outdatedSince
+
+	a.images = make(map[string]metrics.Image)
+	a.outdatedSince = make(map[string]time.Time)
+	for _, image := range images {
+		key := imageKey(image)
+		a.images[key] = image
+
+		if !image.IsLatest {
+			since, ok := outdatedSince[key]
+			if !ok {
+				since = now
+			}
+			a.outdatedSince[key] = since
+		}
+	}
+}
+
+// handle will update the image of the event, recording when it was first
+// seen outdated.
+func (a *Alertmanager) handle(event metrics.ImageEvent, now time.Time) {
+	key := imageKey(event.Image)
+
+	if event.Type == metrics.ImageEventRemoved || event.Image.IsLatest {
+		delete(a.outdatedSince, key)
+		if event.Type == metrics.ImageEventRemoved {
+			delete(a.images, key)
+			return
+		}
+	} else if _, ok := a.outdatedSince[key]; !ok {
+		a.outdatedSince[key] = now
+	}
+
+	a.images[key] = event.Image
+}
+
+// alerts returns the alerts of the images exceeding a threshold, and the
+// alerts last firing which no longer do as resolved.
+func (a *Alertmanager) alerts(now time.Time) []alert {
+	var alerts []alert
+
+	firing := make(map[string]alert)
+	for key, image := range a.images {
+		since, ok := a.outdatedSince[key]
+		if !ok || !a.exceeds(image, since, now) {
+			continue
+		}
+
+		labels := a.labels(image)
+		id := alertID(labels)
+
+		startsAt := now
+		if last, ok := a.firing[id]; ok {
+			startsAt = last.StartsAt
+		}
+
+		firing[id] = alert{
+			Labels: labels,
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("%s of %s/%s container %s is outdated: %s -> %s",
+					image.Image, image.Namespace, image.Pod, image.Container,
+					image.CurrentVersion, image.LatestVersion),
+				"outdated_since": since.UTC().Format(time.RFC3339),
+			},
+			StartsAt: startsAt,
+			// Alertmanager resolves alerts which are not pushed again in
+			// time, such as if version-checker is stopped.
+			EndsAt: now.Add(a.ResendInterval * 3),
+		}
+		alerts = append(alerts, firing[id])
+	}
+
+	for id, last := range a.firing {
+		if _, ok := firing[id]; !ok {
+			last.EndsAt = now
+			alerts = append(alerts, last)
+		}
+	}
+
+	a.firing = firing
+
+	return alerts
+}
+
+// exceeds returns true if the outdated image exceeds the versions behind or
+// outdated threshold.
+func (a *Alertmanager) exceeds(image metrics.Image, outdatedSince, now time.Time) bool {
+	if image.IsLatest {
+		return false
+	}
+
+	if a.VersionsBehind <= 0 && a.OutdatedFor <= 0 {
+		return true
+	}
+
+	if a.VersionsBehind > 0 &&
+		api.VersionsBehind(image.CurrentVersion, image.LatestVersion) >= float64(a.VersionsBehind) {
+		return true
+	}
+
+	return a.OutdatedFor > 0 && now.Sub(outdatedSince) >= a.OutdatedFor
+}
+
+// labels returns the labels of the alert of the image, matching the labels of
+// the is_latest_version metric.
+func (a *Alertmanager) labels(image metrics.Image) map[string]string {
+	labels := make(map[string]string)
+	for k, v := range a.Labels {
+		labels[k] = v
+	}
+
+	labels["alertname"] = AlertName
+	labels["namespace"] = image.Namespace
+	labels["pod"] = image.Pod
+	labels["container"] = image.Container
+	labels["container_type"] = image.ContainerType
+	labels["image"] = image.Image
+	labels["current_version"] = image.CurrentVersion
+	labels["latest_version"] = image.LatestVersion
+
+	return labels
+}
+
+// push will POST the alerts to Alertmanager, if any.
+func (a *Alertmanager) push(ctx context.Context, alerts []alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	url := strings.TrimSuffix(a.URL, "/") + alertmanagerAlertsPath
+	_, err := postJSON(ctx, a.Client, url, alerts, nil)
+	return err
+}
+
+// alertID returns the identity of the alert of the labels, which changes
+// when the image or versions of the container change.
+func alertID(labels map[string]string) string {
+	return strings.Join([]string{
+		labels["namespace"], labels["pod"], labels["container"],
+		labels["image"], labels["current_version"], labels["latest_version"],
+	}, "/")
+}
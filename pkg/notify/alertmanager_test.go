@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+func TestAlertmanagerAlerts(t *testing.T) {
+	a, err := NewAlertmanager(logrus.NewEntry(logrus.New()), AlertmanagerOptions{
+		URL:            "http://alertmanager",
+		VersionsBehind: 3,
+		OutdatedFor:    time.Hour * 24 * 7,
+		Labels:         map[string]string{"cluster": "prod-eu"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.reset([]metrics.Image{
+		{Namespace: "prod", Pod: "web", Container: "nginx", ContainerType: "container",
+			Image: "nginx", CurrentVersion: "1.17.0", LatestVersion: "1.21.0"},
+		{Namespace: "prod", Pod: "db", Container: "postgres", ContainerType: "container",
+			Image: "postgres", CurrentVersion: "13.1", LatestVersion: "13.2"},
+		{Namespace: "prod", Pod: "cache", Container: "redis", ContainerType: "container",
+			Image: "redis", CurrentVersion: "6.0.9", LatestVersion: "6.0.9", IsLatest: true},
+	}, now)
+
+	// Only nginx is enough versions behind.
+	alerts := a.alerts(now)
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got=%+v", alerts)
+	}
+	expLabels := map[string]string{
+		"alertname":       AlertName,
+		"cluster":         "prod-eu",
+		"namespace":       "prod",
+		"pod":             "web",
+		"container":       "nginx",
+		"container_type":  "container",
+		"image":           "nginx",
+		"current_version": "1.17.0",
+		"latest_version":  "1.21.0",
+	}
+	for k, v := range expLabels {
+		if alerts[0].Labels[k] != v {
+			t.Errorf("unexpected label %q, exp=%q got=%q", k, v, alerts[0].Labels[k])
+		}
+	}
+	if !alerts[0].EndsAt.After(now) {
+		t.Errorf("expected firing alert, got ends at %s", alerts[0].EndsAt)
+	}
+
+	// postgres exceeds the outdated threshold, and nginx is updated to the
+	// latest version so is resolved.
+	later := now.Add(time.Hour * 24 * 8)
+	a.handle(metrics.ImageEvent{Type: metrics.ImageEventUpdated, Image: metrics.Image{
+		Namespace: "prod", Pod: "web", Container: "nginx", ContainerType: "container",
+		Image: "nginx", CurrentVersion: "1.21.0", LatestVersion: "1.21.0", IsLatest: true,
+	}}, later)
+	// redis becoming outdated has not been outdated long enough.
+	a.handle(metrics.ImageEvent{Type: metrics.ImageEventUpdated, Image: metrics.Image{
+		Namespace: "prod", Pod: "cache", Container: "redis", ContainerType: "container",
+		Image: "redis", CurrentVersion: "6.0.9", LatestVersion: "6.0.10",
+	}}, later)
+
+	alerts = a.alerts(later)
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Labels["pod"] < alerts[j].Labels["pod"]
+	})
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got=%+v", alerts)
+	}
+	if alerts[0].Labels["pod"] != "db" || !alerts[0].EndsAt.After(later) {
+		t.Errorf("expected firing postgres alert, got=%+v", alerts[0])
+	}
+	if alerts[1].Labels["pod"] != "web" || !alerts[1].EndsAt.Equal(later) ||
+		!alerts[1].StartsAt.Equal(now) {
+		t.Errorf("expected resolved nginx alert, got=%+v", alerts[1])
+	}
+
+	// Resolved alerts are only pushed once.
+	alerts = a.alerts(later)
+	if len(alerts) != 1 || alerts[0].Labels["pod"] != "db" {
+		t.Errorf("expected only postgres alert, got=%+v", alerts)
+	}
+}
+
+func TestAlertmanagerPush(t *testing.T) {
+	var (
+		path   string
+		alerts []alert
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path = req.URL.Path
+		if err := json.NewDecoder(req.Body).Decode(&alerts); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer server.Close()
+
+	a, err := NewAlertmanager(logrus.NewEntry(logrus.New()), AlertmanagerOptions{
+		URL: server.URL + "/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.reset([]metrics.Image{
+		{Namespace: "prod", Pod: "web", Container: "nginx",
+			Image: "nginx", CurrentVersion: "1.21.0", LatestVersion: "1.21.1"},
+	}, time.Now())
+
+	if err := a.push(context.TODO(), a.alerts(time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	if path != alertmanagerAlertsPath {
+		t.Errorf("unexpected path, exp=%q got=%q", alertmanagerAlertsPath, path)
+	}
+	if len(alerts) != 1 || alerts[0].Labels["image"] != "nginx" {
+		t.Errorf("unexpected alerts pushed: %+v", alerts)
+	}
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	defaultChatTextField = "text"
+)
+
+// ChatOptions used to configure the generic chat notifier, for chat platforms
+// whose incoming webhooks accept a JSON object with the message text, such as
+// Mattermost, Rocket.Chat, Google Chat or Discord.
+type ChatOptions struct {
+	// WebhookURL is the incoming webhook messages are posted to.
+	WebhookURL string
+
+	// TextField is the field of the JSON object holding the message text.
+	// Defaults to "text", Discord uses "content".
+	TextField string
+
+	// Template renders the messages. If nil, DefaultTemplate is used.
+	Template *Template
+
+	HTTPOptions
+}
+
+// Chat posts notifications as messages to a generic chat incoming webhook.
+type Chat struct {
+	*http.Client
+	ChatOptions
+}
+
+func NewChat(opts ChatOptions) (*Chat, error) {
+	if len(opts.WebhookURL) == 0 {
+		return nil, errors.New("chat notifier requires a webhook URL")
+	}
+
+	if len(opts.TextField) == 0 {
+		opts.TextField = defaultChatTextField
+	}
+
+	if opts.Template == nil {
+		tmpl, err := NewTemplate("")
+		if err != nil {
+			return nil, err
+		}
+		opts.Template = tmpl
+	}
+
+	return &Chat{
+		ChatOptions: opts,
+		Client:      opts.newClient(),
+	}, nil
+}
+
+// Send will post the notification to the webhook.
+func (c *Chat) Send(ctx context.Context, notification Notification) error {
+	text, err := c.Template.Render(notification)
+	if err != nil {
+		return err
+	}
+
+	if _, err := postJSON(ctx, c.Client, c.WebhookURL, map[string]string{c.TextField: text}, nil); err != nil {
+		return fmt.Errorf("failed to send chat webhook: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChatSend(t *testing.T) {
+	tests := map[string]struct {
+		textField string
+		expField  string
+	}{
+		"should default to the text field": {
+			expField: "text",
+		},
+		"should use the text field": {
+			textField: "content",
+			expField:  "content",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var message map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if err := json.NewDecoder(req.Body).Decode(&message); err != nil {
+					t.Fatal(err)
+				}
+			}))
+			defer server.Close()
+
+			chat, err := NewChat(ChatOptions{WebhookURL: server.URL, TextField: test.textField})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := chat.Send(context.TODO(), Notification{
+				Namespace:      "prod",
+				Pod:            "web-1",
+				Container:      "nginx",
+				Image:          "nginx",
+				CurrentVersion: "1.19.6",
+				LatestVersion:  "1.19.7",
+				Severity:       SeverityPatch,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			exp := "`nginx` of `prod/web-1` container `nginx` is outdated: 1.19.6 -> 1.19.7 (patch)"
+			if len(message) != 1 || message[test.expField] != exp {
+				t.Errorf("unexpected message, exp=%s=%q got=%v", test.expField, exp, message)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+const (
+	defaultEmailInterval = time.Hour * 24
+
+	// digestTemplate is the plain text body of digest emails.
+	digestTemplate = `{{ .Total }} outdated container images, as of {{ .Date }}.
+{{ range .Groups }}
+{{ .Name }}
+{{ range .Images }}  {{ .Namespace }}: {{ .Image }} {{ .CurrentVersion }} -> {{ .LatestVersion }} ({{ .Severity }}), container {{ .Container }} of {{ join .Pods ", " }}
+{{ end }}{{ end }}`
+)
+
+var digestTmpl = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(digestTemplate))
+
+// Lister lists the results of image checks.
+type Lister interface {
+	Images(filter metrics.ImageFilter) []metrics.Image
+}
+
+// EmailOptions used to configure the email digest notifier.
+type EmailOptions struct {
+	// SMTPAddr is the host:port of the SMTP server digests are sent through.
+	SMTPAddr string
+
+	// Username and Password authenticate with the SMTP server using PLAIN
+	// auth, if Username is set.
+	Username string
+	Password string
+
+	// From and To are the sender and recipients of digests.
+	From string
+	To   []string
+
+	// Interval is how often a digest is sent, such as daily or weekly.
+	// Defaults to 24h.
+	Interval time.Duration
+
+	// GroupBy returns the group of the images of a namespace, such as the
+	// team owning it. If nil, images are grouped by namespace.
+	GroupBy func(namespace string) string
+}
+
+// Email periodically sends a digest email of the outdated images, grouped by
+// namespace or team, for organisations where chat integrations are not
+// allowed.
+type Email struct {
+	EmailOptions
+
+	log *logrus.Entry
+
+	// sendMail sends the message, being smtp.SendMail.
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// digest is the data of digestTemplate.
+type digest struct {
+	Total  int
+	Date   string
+	Groups []digestGroup
+}
+
+type digestGroup struct {
+	Name   string
+	Images []digestImage
+}
+
+// digestImage is an outdated image of the container of one or more pods of a
+// namespace, such as the pods of a Deployment.
+type digestImage struct {
+	Namespace      string
+	Container      string
+	Image          string
+	CurrentVersion string
+	LatestVersion  string
+	Severity       Severity
+	Pods           []string
+}
+
+func NewEmail(log *logrus.Entry, opts EmailOptions) (*Email, error) {
+	if len(opts.SMTPAddr) == 0 {
+		return nil, errors.New("email notifier requires an SMTP server address")
+	}
+	if _, _, err := net.SplitHostPort(opts.SMTPAddr); err != nil {
+		return nil, fmt.Errorf("invalid SMTP server address %q: %s", opts.SMTPAddr, err)
+	}
+	if len(opts.From) == 0 || len(opts.To) == 0 {
+		return nil, errors.New("email notifier requires a sender and at least one recipient")
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = defaultEmailInterval
+	}
+
+	return &Email{
+		EmailOptions: opts,
+		log:          log.WithField("module", "email"),
+		sendMail:     smtp.SendMail,
+	}, nil
+}
+
+// Run will send a digest of the outdated images every interval, until the
+// context is done.
+func (e *Email) Run(ctx context.Context, lister Lister) {
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			images := lister.Images(metrics.ImageFilter{OutdatedOnly: true})
+			if err := e.Send(images, time.Now()); err != nil {
+				e.log.Errorf("failed to send digest email: %s", err)
+			}
+		}
+	}
+}
+
+// Send will send a digest email of the outdated images, if any.
+func (e *Email) Send(images []metrics.Image, now time.Time) error {
+	if len(images) == 0 {
+		return nil
+	}
+
+	msg, err := e.message(images, now)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if len(e.Username) > 0 {
+		host, _, _ := net.SplitHostPort(e.SMTPAddr)
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+
+	if err := e.sendMail(e.SMTPAddr, auth, e.From, e.To, msg); err != nil {
+		return fmt.Errorf("failed to send email: %s", err)
+	}
+
+	return nil
+}
+
+// message returns the digest email of the outdated images, with headers.
+func (e *Email) message(images []metrics.Image, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&buf, "Subject: version-checker: %d outdated container images\r\n", len(images))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+
+	if err := digestTmpl.Execute(&buf, e.digest(images, now)); err != nil {
+		return nil, fmt.Errorf("failed to render digest email: %s", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// digest returns the outdated images grouped, ordered by group, namespace and
+// image. The pods of the same container, image and versions of a namespace
+// are listed together.
+func (e *Email) digest(images []metrics.Image, now time.Time) digest {
+	groupBy := e.GroupBy
+	if groupBy == nil {
+		groupBy = func(namespace string) string { return namespace }
+	}
+
+	// The group of each namespace, so that it is looked up once.
+	namespaceGroups := make(map[string]string)
+
+	groups := make(map[string]map[string]*digestImage)
+	for _, image := range images {
+		name, ok := namespaceGroups[image.Namespace]
+		if !ok {
+			name = groupBy(image.Namespace)
+			namespaceGroups[image.Namespace] = name
+		}
+
+		if groups[name] == nil {
+			groups[name] = make(map[string]*digestImage)
+		}
+
+		key := strings.Join([]string{image.Namespace, image.Container, image.Image,
+			image.CurrentVersion, image.LatestVersion}, "/")
+		if d, ok := groups[name][key]; ok {
+			d.Pods = append(d.Pods, image.Pod)
+			continue
+		}
+
+		groups[name][key] = &digestImage{
+			Namespace:      image.Namespace,
+			Container:      image.Container,
+			Image:          image.Image,
+			CurrentVersion: image.CurrentVersion,
+			LatestVersion:  image.LatestVersion,
+			Severity:       SeverityOf(image.CurrentVersion, image.LatestVersion),
+			Pods:           []string{image.Pod},
+		}
+	}
+
+	d := digest{
+		Total: len(images),
+		Date:  now.UTC().Format("2006-01-02"),
+	}
+	for name, byKey := range groups {
+		group := digestGroup{Name: name}
+		for _, image := range byKey {
+			sort.Strings(image.Pods)
+			group.Images = append(group.Images, *image)
+		}
+
+		sort.Slice(group.Images, func(i, j int) bool {
+			a, b := group.Images[i], group.Images[j]
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			if a.Image != b.Image {
+				return a.Image < b.Image
+			}
+			if a.Container != b.Container {
+				return a.Container < b.Container
+			}
+			return a.CurrentVersion < b.CurrentVersion
+		})
+
+		d.Groups = append(d.Groups, group)
+	}
+
+	sort.Slice(d.Groups, func(i, j int) bool {
+		return d.Groups[i].Name < d.Groups[j].Name
+	})
+
+	return d
+}
@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"net/smtp"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+func TestEmailSend(t *testing.T) {
+	teams := map[string]string{"payments": "team-a", "checkout": "team-a"}
+
+	e, err := NewEmail(logrus.NewEntry(logrus.New()), EmailOptions{
+		SMTPAddr: "smtp.example.com:587",
+		Username: "version-checker",
+		Password: "secret",
+		From:     "version-checker@example.com",
+		To:       []string{"platform@example.com", "security@example.com"},
+		GroupBy: func(namespace string) string {
+			if team, ok := teams[namespace]; ok {
+				return team
+			}
+			return namespace
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		sent      bool
+		addr      string
+		from      string
+		to        []string
+		msg       string
+		withCreds bool
+	)
+	e.sendMail = func(a string, auth smtp.Auth, f string, t []string, m []byte) error {
+		sent, addr, from, to, msg, withCreds = true, a, f, t, string(m), auth != nil
+		return nil
+	}
+
+	now := time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := e.Send(nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if sent {
+		t.Fatal("expected no email without outdated images")
+	}
+
+	images := []metrics.Image{
+		{Namespace: "payments", Pod: "api-2", Container: "api", Image: "payments-api",
+			CurrentVersion: "1.2.0", LatestVersion: "1.4.0"},
+		{Namespace: "payments", Pod: "api-1", Container: "api", Image: "payments-api",
+			CurrentVersion: "1.2.0", LatestVersion: "1.4.0"},
+		{Namespace: "checkout", Pod: "web", Container: "nginx", Image: "nginx",
+			CurrentVersion: "1.19.6", LatestVersion: "2.0.0"},
+		{Namespace: "dev", Pod: "app", Container: "app", Image: "app",
+			CurrentVersion: "sha256:abc", LatestVersion: "sha256:def"},
+	}
+	if err := e.Send(images, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if addr != "smtp.example.com:587" || from != "version-checker@example.com" || !withCreds {
+		t.Errorf("unexpected smtp args, addr=%q from=%q auth=%t", addr, from, withCreds)
+	}
+	if !reflect.DeepEqual(to, e.To) {
+		t.Errorf("unexpected recipients, exp=%v got=%v", e.To, to)
+	}
+
+	parts := strings.SplitN(msg, "\r\n\r\n", 2)
+	if len(parts) != 2 {
+		t.Fatalf("expected headers and body, got=%q", msg)
+	}
+	for _, header := range []string{
+		"To: platform@example.com, security@example.com",
+		"Subject: version-checker: 4 outdated container images",
+	} {
+		if !strings.Contains(parts[0], header) {
+			t.Errorf("expected header %q, got=%q", header, parts[0])
+		}
+	}
+
+	expBody := `4 outdated container images, as of 2021-03-01.
+
+dev
+  dev: app sha256:abc -> sha256:def (unknown), container app of app
+
+team-a
+  checkout: nginx 1.19.6 -> 2.0.0 (major), container nginx of web
+  payments: payments-api 1.2.0 -> 1.4.0 (minor), container api of api-1, api-2
+`
+	if parts[1] != expBody {
+		t.Errorf("unexpected body, exp=%q got=%q", expBody, parts[1])
+	}
+}
+
+func TestNewEmail(t *testing.T) {
+	tests := map[string]struct {
+		opts   EmailOptions
+		expErr bool
+	}{
+		"valid options should not error": {
+			opts: EmailOptions{SMTPAddr: "smtp:25", From: "a@example.com", To: []string{"b@example.com"}},
+		},
+		"address without port should error": {
+			opts:   EmailOptions{SMTPAddr: "smtp", From: "a@example.com", To: []string{"b@example.com"}},
+			expErr: true,
+		},
+		"no recipients should error": {
+			opts:   EmailOptions{SMTPAddr: "smtp:25", From: "a@example.com"},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewEmail(logrus.NewEntry(logrus.New()), test.opts)
+			if (err != nil) != test.expErr {
+				t.Errorf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// HTTPOptions configure the requests of notifiers posting to HTTP endpoints.
+type HTTPOptions struct {
+	// Transport is used for all requests. If nil, the default transport is
+	// used.
+	Transport http.RoundTripper
+}
+
+// newClient returns the client notifications are posted with.
+func (o HTTPOptions) newClient() *http.Client {
+	return &http.Client{
+		Timeout:   time.Second * 10,
+		Transport: o.Transport,
+	}
+}
+
+// statusError is returned when a request fails with a non 2xx status code.
+type statusError struct {
+	code int
+	body []byte
+}
+
+func (s *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", s.code, s.body)
+}
+
+// postJSON will POST the body as JSON to the URL, returning the response
+// body. The header, if set, is added to the request, overriding the
+// Content-Type. Returns a *statusError if the response is not 2xx.
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}, header http.Header) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %s", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &statusError{code: resp.StatusCode, body: respBody}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return respBody, nil
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostJSON(t *testing.T) {
+	tests := map[string]struct {
+		status         int
+		header         http.Header
+		expContentType string
+		expStatusErr   bool
+	}{
+		"successful post should return the response body": {
+			status:         http.StatusOK,
+			expContentType: "application/json",
+		},
+		"header should override the content type": {
+			status:         http.StatusOK,
+			header:         http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+			expContentType: "application/json; charset=utf-8",
+		},
+		"non 2xx status should return a status error": {
+			status:         http.StatusBadGateway,
+			expContentType: "application/json",
+			expStatusErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if ct := r.Header.Get("Content-Type"); ct != test.expContentType {
+					t.Errorf("unexpected content type, exp=%q got=%q", test.expContentType, ct)
+				}
+
+				body, _ := ioutil.ReadAll(r.Body)
+				if string(body) != `{"text":"hello"}` {
+					t.Errorf("unexpected body: %s", body)
+				}
+
+				w.WriteHeader(test.status)
+				w.Write([]byte("response"))
+			}))
+			defer server.Close()
+
+			body, err := postJSON(context.TODO(), server.Client(), server.URL,
+				map[string]string{"text": "hello"}, test.header)
+
+			var statusErr *statusError
+			if errors.As(err, &statusErr) != test.expStatusErr {
+				t.Fatalf("unexpected error, exp status error=%t got=%v", test.expStatusErr, err)
+			}
+			if test.expStatusErr {
+				if statusErr.code != test.status || string(statusErr.body) != "response" {
+					t.Errorf("unexpected status error: %s", statusErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != "response" {
+				t.Errorf("unexpected response body, exp=%q got=%q", "response", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/jetstack/version-checker/pkg/metrics"
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+const (
+	// notificationQueue is the number of notifications waiting on the rate
+	// limit before further notifications are dropped.
+	notificationQueue = 100
+)
+
+// Severity is how far behind the latest version the current version of an
+// image is, being the most significant semver component changed.
+type Severity string
+
+const (
+	SeverityMajor Severity = "major"
+	SeverityMinor Severity = "minor"
+	SeverityPatch Severity = "patch"
+
+	// SeverityUnknown is the severity of versions which are not semver, such
+	// as digests.
+	SeverityUnknown Severity = "unknown"
+)
+
+// Notification is sent when the image of a container becomes outdated.
+type Notification struct {
+	Namespace      string   `json:"namespace"`
+	Pod            string   `json:"pod"`
+	Container      string   `json:"container"`
+	Image          string   `json:"image"`
+	CurrentVersion string   `json:"currentVersion"`
+	LatestVersion  string   `json:"latestVersion"`
+	Severity       Severity `json:"severity"`
+}
+
+// Sender sends notifications, such as to a webhook.
+type Sender interface {
+	Send(ctx context.Context, notification Notification) error
+}
+
+// Watcher watches the results of image checks.
+type Watcher = metrics.Watcher
+
+// DispatcherOptions used to configure the dispatcher of notifications.
+type DispatcherOptions struct {
+	// DedupWindow is the time the same image, current and latest version of
+	// a namespace is notified at most once in, such as for the pods of a
+	// Deployment becoming outdated together.
+	DedupWindow time.Duration
+
+	// RateLimit is the number of notifications sent per second, with bursts
+	// of RateBurst. Zero is unlimited.
+	RateLimit float64
+	RateBurst int
+}
+
+// Dispatcher sends a notification to every sender when the image of a
+// container transitions from the latest version to outdated.
+type Dispatcher struct {
+	log     *logrus.Entry
+	senders []Sender
+	opts    DispatcherOptions
+	limiter *rate.Limiter
+	queue   chan Notification
+
+	// isLatest is whether the image of each container was last the latest
+	// version.
+	isLatest map[string]bool
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+func NewDispatcher(log *logrus.Entry, senders []Sender, opts DispatcherOptions) *Dispatcher {
+	limit := rate.Inf
+	if opts.RateLimit > 0 {
+		limit = rate.Limit(opts.RateLimit)
+	}
+	if opts.RateBurst <= 0 {
+		opts.RateBurst = 1
+	}
+
+	return &Dispatcher{
+		log:      log.WithField("module", "notify"),
+		senders:  senders,
+		opts:     opts,
+		limiter:  rate.NewLimiter(limit, opts.RateBurst),
+		queue:    make(chan Notification, notificationQueue),
+		isLatest: make(map[string]bool),
+		sent:     make(map[string]time.Time),
+	}
+}
+
+// Run will watch the results of image checks, sending notifications until the
+// context is done.
+func (d *Dispatcher) Run(ctx context.Context, watcher Watcher) {
+	go d.sendQueued(ctx)
+
+	metrics.Rewatch(ctx, d.log, watcher, metrics.ImageFilter{}, d.reset, d.handle)
+}
+
+// reset will set the images currently checked. Images already outdated are
+// not notified, so that restarts and rewatches do not notify them again.
+func (d *Dispatcher) reset(images []metrics.Image) {
+	d.isLatest = make(map[string]bool)
+	for _, image := range images {
+		d.isLatest[imageKey(image)] = image.IsLatest
+	}
+}
+
+// handle will queue a notification if the event's image transitioned from the
+// latest version to outdated.
+func (d *Dispatcher) handle(event metrics.ImageEvent) {
+	key := imageKey(event.Image)
+
+	if event.Type == metrics.ImageEventRemoved {
+		delete(d.isLatest, key)
+		return
+	}
+
+	wasLatest := d.isLatest[key]
+	d.isLatest[key] = event.Image.IsLatest
+
+	if !wasLatest || event.Image.IsLatest || !d.dedup(event.Image) {
+		return
+	}
+
+	notification := Notification{
+		Namespace:      event.Image.Namespace,
+		Pod:            event.Image.Pod,
+		Container:      event.Image.Container,
+		Image:          event.Image.Image,
+		CurrentVersion: event.Image.CurrentVersion,
+		LatestVersion:  event.Image.LatestVersion,
+		Severity:       SeverityOf(event.Image.CurrentVersion, event.Image.LatestVersion),
+	}
+
+	select {
+	case d.queue <- notification:
+	default:
+		d.log.Warnf("notification queue full, dropping notification of %s %s -> %s",
+			notification.Image, notification.CurrentVersion, notification.LatestVersion)
+	}
+}
+
+// dedup returns true if the image has not been notified in the namespace
+// during the dedup window, recording it as notified.
+func (d *Dispatcher) dedup(image metrics.Image) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for key, sentAt := range d.sent {
+		if now.Sub(sentAt) >= d.opts.DedupWindow {
+			delete(d.sent, key)
+		}
+	}
+
+	key := strings.Join([]string{image.Namespace, image.Image, image.CurrentVersion, image.LatestVersion}, "/")
+	if _, ok := d.sent[key]; ok {
+		return false
+	}
+
+	if d.opts.DedupWindow > 0 {
+		d.sent[key] = now
+	}
+
+	return true
+}
+
+// sendQueued will send queued notifications to every sender, waiting on the
+// rate limit.
+func (d *Dispatcher) sendQueued(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-d.queue:
+			if err := d.limiter.Wait(ctx); err != nil {
+				return
+			}
+
+			for _, sender := range d.senders {
+				if err := sender.Send(ctx, notification); err != nil {
+					d.log.Errorf("failed to send notification of %s: %s", notification.Image, err)
+				}
+			}
+		}
+	}
+}
+
+// SeverityOf returns the severity of the current version being behind the
+// latest version.
+func SeverityOf(currentVersion, latestVersion string) Severity {
+	current, latest := semver.Parse(currentVersion), semver.Parse(latestVersion)
+	if !current.IsVersion() || !latest.IsVersion() {
+		return SeverityUnknown
+	}
+
+	switch {
+	case current.Major() != latest.Major():
+		return SeverityMajor
+	case current.Minor() != latest.Minor():
+		return SeverityMinor
+	default:
+		return SeverityPatch
+	}
+}
+
+func imageKey(image metrics.Image) string {
+	return strings.Join([]string{image.Namespace, image.Pod, image.Container}, "/")
+}
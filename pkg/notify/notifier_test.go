@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/metrics"
+)
+
+// fakeSender records the notifications sent.
+type fakeSender struct {
+	mu            sync.Mutex
+	notifications []Notification
+}
+
+func (f *fakeSender) Send(_ context.Context, notification Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notifications = append(f.notifications, notification)
+	return nil
+}
+
+func (f *fakeSender) sent() []Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Notification{}, f.notifications...)
+}
+
+func TestDispatcher(t *testing.T) {
+	m := metrics.New(logrus.NewEntry(logrus.New()))
+	// Outdated before watching, so not notified.
+	m.AddImage("dev", "app", "app", "container", "app", "v0.1.0", "v0.2.0")
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.19.6", "1.19.6")
+	m.AddImage("prod", "web-2", "nginx", "container", "nginx", "1.19.6", "1.19.6")
+	m.AddImage("prod", "db", "postgres", "container", "postgres", "13.1", "13.1")
+
+	d := NewDispatcher(logrus.NewEntry(logrus.New()), nil, DispatcherOptions{
+		DedupWindow: time.Hour,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	images, events := m.Watch(ctx, metrics.ImageFilter{})
+	d.reset(images)
+
+	m.AddImage("dev", "app", "app", "container", "app", "v0.1.0", "v0.3.0")
+	// Pods of the same image are only notified once.
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.19.6", "1.21.0")
+	m.AddImage("prod", "web-2", "nginx", "container", "nginx", "1.19.6", "1.21.0")
+	m.AddImage("prod", "db", "postgres", "container", "postgres", "13.1", "14.0")
+	// New pods which are outdated are not notified.
+	m.AddImage("prod", "web-3", "nginx", "container", "nginx", "1.17.0", "1.21.0")
+	// Pods becoming outdated again after being the latest are notified.
+	m.AddImage("prod", "db", "postgres", "container", "postgres", "14.0", "14.0")
+	m.AddImage("prod", "db", "postgres", "container", "postgres", "14.0", "14.1")
+
+	cancel()
+	for event := range events {
+		d.handle(event)
+	}
+	close(d.queue)
+
+	var sent []Notification
+	for notification := range d.queue {
+		sent = append(sent, notification)
+	}
+
+	exp := []Notification{
+		{Namespace: "prod", Pod: "web-1", Container: "nginx", Image: "nginx",
+			CurrentVersion: "1.19.6", LatestVersion: "1.21.0", Severity: SeverityMinor},
+		{Namespace: "prod", Pod: "db", Container: "postgres", Image: "postgres",
+			CurrentVersion: "13.1", LatestVersion: "14.0", Severity: SeverityMajor},
+		{Namespace: "prod", Pod: "db", Container: "postgres", Image: "postgres",
+			CurrentVersion: "14.0", LatestVersion: "14.1", Severity: SeverityMinor},
+	}
+	if !reflect.DeepEqual(sent, exp) {
+		t.Errorf("unexpected notifications, exp=%+v got=%+v", exp, sent)
+	}
+}
+
+func TestDispatcherSend(t *testing.T) {
+	sender := new(fakeSender)
+	d := NewDispatcher(logrus.NewEntry(logrus.New()), []Sender{sender, sender}, DispatcherOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.sendQueued(ctx)
+
+	notification := Notification{Namespace: "prod", Image: "nginx", CurrentVersion: "1.19.6", LatestVersion: "1.21.0"}
+	d.queue <- notification
+
+	var sent []Notification
+	for i := 0; i < 100; i++ {
+		if sent = sender.sent(); len(sent) == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if exp := []Notification{notification, notification}; !reflect.DeepEqual(sent, exp) {
+		t.Errorf("unexpected notifications, exp=%+v got=%+v", exp, sent)
+	}
+}
+
+func TestSeverityOf(t *testing.T) {
+	tests := map[string]struct {
+		current, latest string
+		exp             Severity
+	}{
+		"major version change should be major": {
+			current: "v1.9.0", latest: "v2.0.0", exp: SeverityMajor,
+		},
+		"minor version change should be minor": {
+			current: "1.19.6", latest: "1.21.0", exp: SeverityMinor,
+		},
+		"patch version change should be patch": {
+			current: "1.19.6", latest: "1.19.7", exp: SeverityPatch,
+		},
+		"digests should be unknown": {
+			current: "sha256:a", latest: "sha256:b", exp: SeverityUnknown,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := SeverityOf(test.current, test.latest); got != test.exp {
+				t.Errorf("unexpected severity, exp=%s got=%s", test.exp, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	defaultSlackAPIURL = "https://slack.com/api"
+)
+
+// SlackOptions used to configure the Slack notifier. One of Token or
+// WebhookURL must be set.
+type SlackOptions struct {
+	// Token is the bot token messages are posted with, using
+	// chat.postMessage.
+	Token string
+
+	// WebhookURL is the incoming webhook messages are posted to, if no token
+	// is set.
+	WebhookURL string
+
+	// Channel is the channel messages are posted to, unless the namespace
+	// has a channel in NamespaceChannels. Incoming webhooks post to their own
+	// channel if empty.
+	Channel string
+
+	// NamespaceChannels is the channel of each namespace, so that teams are
+	// notified of their own images.
+	NamespaceChannels map[string]string
+
+	// Template renders the messages. If nil, DefaultTemplate is used.
+	Template *Template
+
+	// APIURL is the URL of the Slack Web API. Defaults to
+	// https://slack.com/api.
+	APIURL string
+
+	HTTPOptions
+}
+
+// Slack posts notifications as messages to Slack channels.
+type Slack struct {
+	*http.Client
+	SlackOptions
+}
+
+type slackMessage struct {
+	Channel string `json:"channel,omitempty"`
+	Text    string `json:"text"`
+}
+
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func NewSlack(opts SlackOptions) (*Slack, error) {
+	if len(opts.Token) == 0 && len(opts.WebhookURL) == 0 {
+		return nil, errors.New("slack notifier requires a token or webhook URL")
+	}
+
+	if opts.Template == nil {
+		tmpl, err := NewTemplate("")
+		if err != nil {
+			return nil, err
+		}
+		opts.Template = tmpl
+	}
+
+	if len(opts.APIURL) == 0 {
+		opts.APIURL = defaultSlackAPIURL
+	}
+
+	return &Slack{
+		SlackOptions: opts,
+		Client:       opts.newClient(),
+	}, nil
+}
+
+// Send will post the notification to the channel of its namespace.
+func (s *Slack) Send(ctx context.Context, notification Notification) error {
+	text, err := s.Template.Render(notification)
+	if err != nil {
+		return err
+	}
+
+	msg := slackMessage{
+		Channel: s.channel(notification.Namespace),
+		Text:    text,
+	}
+
+	if len(s.Token) == 0 {
+		if _, err := s.post(ctx, s.WebhookURL, msg); err != nil {
+			return fmt.Errorf("failed to send slack webhook: %s", err)
+		}
+		return nil
+	}
+
+	if len(msg.Channel) == 0 {
+		return fmt.Errorf("no slack channel for namespace %q", notification.Namespace)
+	}
+
+	body, err := s.post(ctx, s.APIURL+"/chat.postMessage", msg)
+	if err != nil {
+		return fmt.Errorf("failed to post slack message: %s", err)
+	}
+
+	var resp slackResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("failed to unmarshal slack response: %s", err)
+	}
+	if !resp.OK {
+		return fmt.Errorf("failed to post slack message: %s", resp.Error)
+	}
+
+	return nil
+}
+
+// channel returns the channel notifications of the namespace are posted to.
+func (s *Slack) channel(namespace string) string {
+	if channel, ok := s.NamespaceChannels[namespace]; ok {
+		return channel
+	}
+	return s.Channel
+}
+
+// post will POST the message, authenticated with the token if set, returning
+// the response body.
+func (s *Slack) post(ctx context.Context, url string, msg slackMessage) ([]byte, error) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	if len(s.Token) > 0 {
+		header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	return postJSON(ctx, s.Client, url, msg, header)
+}
@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSlackSend(t *testing.T) {
+	notification := Notification{
+		Namespace:      "prod",
+		Pod:            "web-1",
+		Container:      "nginx",
+		Image:          "nginx",
+		CurrentVersion: "1.19.6",
+		LatestVersion:  "1.21.0",
+		Severity:       SeverityMinor,
+	}
+
+	tests := map[string]struct {
+		opts       SlackOptions
+		namespace  string
+		response   string
+		expPath    string
+		expAuth    string
+		expMessage slackMessage
+		expErr     bool
+	}{
+		"incoming webhook should post to its own channel": {
+			opts:       SlackOptions{WebhookURL: "/webhook"},
+			namespace:  "prod",
+			expPath:    "/webhook",
+			expMessage: slackMessage{Text: "`nginx` of `prod/web-1` container `nginx` is outdated: 1.19.6 -> 1.21.0 (minor)"},
+		},
+		"token should post to the default channel": {
+			opts:       SlackOptions{Token: "xoxb-token", Channel: "#images"},
+			namespace:  "prod",
+			response:   `{"ok":true}`,
+			expPath:    "/api/chat.postMessage",
+			expAuth:    "Bearer xoxb-token",
+			expMessage: slackMessage{Channel: "#images", Text: "`nginx` of `prod/web-1` container `nginx` is outdated: 1.19.6 -> 1.21.0 (minor)"},
+		},
+		"token should post to the channel of the namespace": {
+			opts: SlackOptions{
+				Token:             "xoxb-token",
+				Channel:           "#images",
+				NamespaceChannels: map[string]string{"payments": "#payments"},
+			},
+			namespace:  "payments",
+			response:   `{"ok":true}`,
+			expPath:    "/api/chat.postMessage",
+			expAuth:    "Bearer xoxb-token",
+			expMessage: slackMessage{Channel: "#payments", Text: "`nginx` of `payments/web-1` container `nginx` is outdated: 1.19.6 -> 1.21.0 (minor)"},
+		},
+		"token without a channel for the namespace should error": {
+			opts:      SlackOptions{Token: "xoxb-token", NamespaceChannels: map[string]string{"payments": "#payments"}},
+			namespace: "prod",
+			expErr:    true,
+		},
+		"response not ok should error": {
+			opts:       SlackOptions{Token: "xoxb-token", Channel: "#missing"},
+			namespace:  "prod",
+			response:   `{"ok":false,"error":"channel_not_found"}`,
+			expPath:    "/api/chat.postMessage",
+			expAuth:    "Bearer xoxb-token",
+			expMessage: slackMessage{Channel: "#missing", Text: "`nginx` of `prod/web-1` container `nginx` is outdated: 1.19.6 -> 1.21.0 (minor)"},
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				path, auth string
+				message    slackMessage
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				path, auth = req.URL.Path, req.Header.Get("Authorization")
+				if err := json.NewDecoder(req.Body).Decode(&message); err != nil {
+					t.Fatal(err)
+				}
+				w.Write([]byte(test.response))
+			}))
+			defer server.Close()
+
+			opts := test.opts
+			opts.APIURL = server.URL + "/api"
+			if len(opts.WebhookURL) > 0 {
+				opts.WebhookURL = server.URL + opts.WebhookURL
+			}
+
+			slack, err := NewSlack(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n := notification
+			n.Namespace = test.namespace
+			err = slack.Send(context.TODO(), n)
+			if (err != nil) != test.expErr {
+				t.Errorf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			if path != test.expPath {
+				t.Errorf("unexpected path, exp=%q got=%q", test.expPath, path)
+			}
+			if auth != test.expAuth {
+				t.Errorf("unexpected authorization, exp=%q got=%q", test.expAuth, auth)
+			}
+			if message != test.expMessage {
+				t.Errorf("unexpected message, exp=%+v got=%+v", test.expMessage, message)
+			}
+		})
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	notification := Notification{
+		Namespace:      "prod",
+		Image:          "nginx",
+		CurrentVersion: "1.19.6",
+		LatestVersion:  "2.0.0",
+		Severity:       SeverityMajor,
+	}
+
+	tests := map[string]struct {
+		text     string
+		expMsg   string
+		expParse bool
+	}{
+		"custom template should render": {
+			text:   "{{ .Namespace }}: {{ .Image }} {{ .CurrentVersion }} -> {{ .LatestVersion }}",
+			expMsg: "prod: nginx 1.19.6 -> 2.0.0",
+		},
+		"template should support conditionals": {
+			text:   `{{ if eq .Severity "major" }}MAJOR {{ end }}{{ .Image }}`,
+			expMsg: "MAJOR nginx",
+		},
+		"invalid template should error": {
+			text:     "{{ .Image ",
+			expParse: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := NewTemplate(test.text)
+			if (err != nil) != test.expParse {
+				t.Fatalf("unexpected parse error, exp=%t got=%v", test.expParse, err)
+			}
+			if err != nil {
+				return
+			}
+
+			msg, err := tmpl.Render(notification)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg != test.expMsg {
+				t.Errorf("unexpected message, exp=%q got=%q", test.expMsg, msg)
+			}
+		})
+	}
+}
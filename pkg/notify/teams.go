@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	teamsCardType    = "MessageCard"
+	teamsCardContext = "https://schema.org/extensions"
+)
+
+// teamsSeverityColors are the theme colors of connector cards by severity.
+var teamsSeverityColors = map[Severity]string{
+	SeverityMajor:   "D13438",
+	SeverityMinor:   "FF8C00",
+	SeverityPatch:   "FFB900",
+	SeverityUnknown: "8A8886",
+}
+
+// TeamsOptions used to configure the Microsoft Teams notifier.
+type TeamsOptions struct {
+	// WebhookURL is the incoming webhook connector cards are posted to,
+	// unless the namespace has a webhook in NamespaceWebhookURLs.
+	WebhookURL string
+
+	// NamespaceWebhookURLs is the incoming webhook of each namespace, so that
+	// teams are notified of their own images.
+	NamespaceWebhookURLs map[string]string
+
+	// Template renders the card text. If nil, DefaultTemplate is used.
+	Template *Template
+
+	HTTPOptions
+}
+
+// Teams posts notifications as connector cards to Microsoft Teams channels.
+type Teams struct {
+	*http.Client
+	TeamsOptions
+}
+
+type teamsCard struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	Summary    string `json:"summary"`
+	ThemeColor string `json:"themeColor"`
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+}
+
+func NewTeams(opts TeamsOptions) (*Teams, error) {
+	if len(opts.WebhookURL) == 0 && len(opts.NamespaceWebhookURLs) == 0 {
+		return nil, errors.New("teams notifier requires a webhook URL")
+	}
+
+	if opts.Template == nil {
+		tmpl, err := NewTemplate("")
+		if err != nil {
+			return nil, err
+		}
+		opts.Template = tmpl
+	}
+
+	return &Teams{
+		TeamsOptions: opts,
+		Client:       opts.newClient(),
+	}, nil
+}
+
+// Send will post the notification as a connector card to the webhook of its
+// namespace.
+func (t *Teams) Send(ctx context.Context, notification Notification) error {
+	url, ok := t.NamespaceWebhookURLs[notification.Namespace]
+	if !ok {
+		url = t.WebhookURL
+	}
+	if len(url) == 0 {
+		return fmt.Errorf("no teams webhook for namespace %q", notification.Namespace)
+	}
+
+	text, err := t.Template.Render(notification)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("%s is outdated", notification.Image)
+	card := teamsCard{
+		Type:       teamsCardType,
+		Context:    teamsCardContext,
+		Summary:    title,
+		ThemeColor: teamsSeverityColors[notification.Severity],
+		Title:      title,
+		Text:       text,
+	}
+
+	if _, err := postJSON(ctx, t.Client, url, card, nil); err != nil {
+		return fmt.Errorf("failed to send teams webhook: %s", err)
+	}
+
+	return nil
+}
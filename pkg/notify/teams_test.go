@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTeamsSend(t *testing.T) {
+	tmpl, err := NewTemplate("{{ .CurrentVersion }} -> {{ .LatestVersion }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		namespaceURLs map[string]string
+		defaultURL    bool
+		namespace     string
+		expPath       string
+		expErr        bool
+	}{
+		"should post to the default webhook": {
+			defaultURL: true,
+			namespace:  "prod",
+			expPath:    "/default",
+		},
+		"should post to the webhook of the namespace": {
+			namespaceURLs: map[string]string{"payments": "/payments"},
+			defaultURL:    true,
+			namespace:     "payments",
+			expPath:       "/payments",
+		},
+		"no webhook for the namespace should error": {
+			namespaceURLs: map[string]string{"payments": "/payments"},
+			namespace:     "prod",
+			expErr:        true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				path string
+				card teamsCard
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				path = req.URL.Path
+				if err := json.NewDecoder(req.Body).Decode(&card); err != nil {
+					t.Fatal(err)
+				}
+			}))
+			defer server.Close()
+
+			opts := TeamsOptions{
+				NamespaceWebhookURLs: make(map[string]string),
+				Template:             tmpl,
+			}
+			if test.defaultURL {
+				opts.WebhookURL = server.URL + "/default"
+			}
+			for namespace, path := range test.namespaceURLs {
+				opts.NamespaceWebhookURLs[namespace] = server.URL + path
+			}
+
+			teams, err := NewTeams(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = teams.Send(context.TODO(), Notification{
+				Namespace:      test.namespace,
+				Image:          "nginx",
+				CurrentVersion: "1.19.6",
+				LatestVersion:  "2.0.0",
+				Severity:       SeverityMajor,
+			})
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if path != test.expPath {
+				t.Errorf("unexpected path, exp=%q got=%q", test.expPath, path)
+			}
+
+			exp := teamsCard{
+				Type:       teamsCardType,
+				Context:    teamsCardContext,
+				Summary:    "nginx is outdated",
+				ThemeColor: teamsSeverityColors[SeverityMajor],
+				Title:      "nginx is outdated",
+				Text:       "1.19.6 -> 2.0.0",
+			}
+			if card != exp {
+				t.Errorf("unexpected card, exp=%+v got=%+v", exp, card)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+const (
+	// DefaultTemplate is the template of chat messages if none is given.
+	DefaultTemplate = "`{{ .Image }}` of `{{ .Namespace }}/{{ .Pod }}` " +
+		"container `{{ .Container }}` is outdated: " +
+		"{{ .CurrentVersion }} -> {{ .LatestVersion }} ({{ .Severity }})"
+)
+
+// Template renders chat messages of notifications from a Go template, whose
+// data is the Notification.
+type Template struct {
+	tmpl *template.Template
+}
+
+// NewTemplate parses the template text, using DefaultTemplate if empty.
+func NewTemplate(text string) (*Template, error) {
+	if len(text) == 0 {
+		text = DefaultTemplate
+	}
+
+	tmpl, err := template.New("notification").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notification template: %s", err)
+	}
+
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Render returns the message of the notification.
+func (t *Template) Render(notification Notification) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, notification); err != nil {
+		return "", fmt.Errorf("failed to render notification template: %s", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,224 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version"
+)
+
+const (
+	// SignatureHeader is the header holding the HMAC SHA256 signature of the
+	// payload, hex encoded and prefixed with "sha256=".
+	SignatureHeader = "X-Version-Checker-Signature"
+
+	defaultRetries = 3
+	defaultBackoff = time.Second
+)
+
+// EventType is the type of change detected for an image.
+type EventType string
+
+const (
+	EventNewVersion EventType = "new_version"
+	EventRebuild    EventType = "rebuild"
+)
+
+// Options used to configure the webhook notifier.
+type Options struct {
+	// URL is the webhook URL payloads are POSTed to.
+	URL string
+
+	// Secret, if set, is used to sign payloads with HMAC SHA256.
+	Secret string
+
+	// Retries is the number of times a failed request is retried. Defaults to
+	// 3.
+	Retries int
+
+	// Backoff is the time waited before the first retry, doubling for each
+	// retry after. Defaults to 1s.
+	Backoff time.Duration
+
+	HTTPOptions
+}
+
+// Webhook notifies a webhook of new versions and silent rebuilds found
+// between scans.
+type Webhook struct {
+	*http.Client
+	Options
+}
+
+// Snapshot is the latest tag of each image URL, as of a scan.
+type Snapshot map[string]api.ImageTag
+
+// Payload is the JSON body POSTed to the webhook.
+type Payload struct {
+	Events []Event `json:"events"`
+}
+
+// Event is a change detected for an image since the previous snapshot.
+type Event struct {
+	Type     EventType         `json:"type"`
+	ImageURL string            `json:"imageURL"`
+	Previous api.ImageTag      `json:"previous"`
+	Latest   api.ImageTag      `json:"latest"`
+	Rebuild  *api.RebuildEvent `json:"rebuild,omitempty"`
+}
+
+func New(opts Options) *Webhook {
+	if opts.Retries <= 0 {
+		opts.Retries = defaultRetries
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaultBackoff
+	}
+
+	return &Webhook{
+		Options: opts,
+		Client:  opts.newClient(),
+	}
+}
+
+// Notify will compare the scan results to the previous snapshot, POSTing the
+// events found to the webhook, if any. Returns the snapshot of the results to
+// compare the next scan against. Stale and failed results are not compared,
+// and keep their previous entry in the snapshot.
+func (w *Webhook) Notify(ctx context.Context, results []version.ScanResult, previous Snapshot) (Snapshot, error) {
+	snapshot, events := Compare(results, previous)
+	if len(events) == 0 {
+		return snapshot, nil
+	}
+
+	body, err := json.Marshal(Payload{Events: events})
+	if err != nil {
+		return previous, fmt.Errorf("failed to marshal webhook payload: %s", err)
+	}
+
+	if err := w.send(ctx, body); err != nil {
+		return previous, err
+	}
+
+	return snapshot, nil
+}
+
+// Send will POST the notification to the webhook.
+func (w *Webhook) Send(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %s", err)
+	}
+
+	return w.send(ctx, body)
+}
+
+// Compare returns the snapshot of the scan results, and the events detected
+// since the previous snapshot. Images not in the previous snapshot have no
+// events.
+func Compare(results []version.ScanResult, previous Snapshot) (Snapshot, []Event) {
+	snapshot := make(Snapshot)
+	for imageURL, tag := range previous {
+		snapshot[imageURL] = tag
+	}
+
+	var events []Event
+	for _, result := range results {
+		if result.Err != nil || result.Stale || result.LatestTag == nil {
+			continue
+		}
+
+		latest := *result.LatestTag
+		snapshot[result.ImageURL] = latest
+
+		prev, ok := previous[result.ImageURL]
+		if !ok {
+			continue
+		}
+
+		if prev.Tag != latest.Tag {
+			events = append(events, Event{
+				Type:     EventNewVersion,
+				ImageURL: result.ImageURL,
+				Previous: prev,
+				Latest:   latest,
+			})
+			continue
+		}
+
+		for _, rebuild := range api.DetectSilentRebuild([]api.ImageTag{prev}, []api.ImageTag{latest}) {
+			rebuild := rebuild
+			events = append(events, Event{
+				Type:     EventRebuild,
+				ImageURL: result.ImageURL,
+				Previous: prev,
+				Latest:   latest,
+				Rebuild:  &rebuild,
+			})
+		}
+	}
+
+	return snapshot, events
+}
+
+// send will POST the body to the webhook, retrying with backoff on
+// connection errors and server errors.
+func (w *Webhook) send(ctx context.Context, body []byte) error {
+	backoff := w.Backoff
+
+	var err error
+	for attempt := 0; attempt <= w.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return fmt.Errorf("failed to send webhook: %s", ctx.Err())
+			}
+			backoff *= 2
+		}
+
+		var retry bool
+		retry, err = w.post(ctx, body)
+		if err == nil || !retry {
+			break
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %s", err)
+	}
+
+	return nil
+}
+
+// post will POST the body once, returning whether a failure may be retried.
+func (w *Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	header := http.Header{}
+	if len(w.Secret) > 0 {
+		header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+
+	_, err := postJSON(ctx, w.Client, w.URL, json.RawMessage(body), header)
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500 || statusErr.code == http.StatusTooManyRequests, err
+	}
+
+	return err != nil, err
+}
+
+// Sign returns the signature header value of the payload for the secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version"
+)
+
+func result(imageURL string, tag api.ImageTag) version.ScanResult {
+	return version.ScanResult{
+		ScanImage: version.ScanImage{ImageURL: imageURL, Options: new(api.Options)},
+		LatestTag: &tag,
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		payloads []Payload
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+		// Fail the first attempt to be retried.
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sig := req.Header.Get(SignatureHeader); !hmac.Equal([]byte(sig), []byte(Sign("secret", body))) {
+			t.Errorf("unexpected signature, exp=%q got=%q", Sign("secret", body), sig)
+		}
+
+		var payload Payload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatal(err)
+		}
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	webhook := New(Options{
+		URL:     server.URL,
+		Secret:  "secret",
+		Backoff: time.Millisecond,
+	})
+
+	previous := Snapshot{
+		"quay.io/jetstack/upgraded": {Tag: "v0.1.0", SHA: "sha256:a"},
+		"quay.io/jetstack/rebuilt":  {Tag: "v0.1.0", SHA: "sha256:b"},
+		"quay.io/jetstack/same":     {Tag: "v0.1.0", SHA: "sha256:c"},
+		"quay.io/jetstack/stale":    {Tag: "v0.1.0", SHA: "sha256:d"},
+	}
+
+	stale := result("quay.io/jetstack/stale", api.ImageTag{Tag: "v0.0.1"})
+	stale.Stale = true
+
+	snapshot, err := webhook.Notify(context.TODO(), []version.ScanResult{
+		result("quay.io/jetstack/upgraded", api.ImageTag{Tag: "v0.2.0", SHA: "sha256:e"}),
+		result("quay.io/jetstack/rebuilt", api.ImageTag{Tag: "v0.1.0", SHA: "sha256:f"}),
+		result("quay.io/jetstack/same", api.ImageTag{Tag: "v0.1.0", SHA: "sha256:c"}),
+		result("quay.io/jetstack/new", api.ImageTag{Tag: "v1.0.0", SHA: "sha256:g"}),
+		stale,
+	}, previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("expected failed request to be retried, exp=2 got=%d", attempts)
+	}
+	if len(payloads) != 1 {
+		t.Fatalf("expected a single payload, got=%d", len(payloads))
+	}
+
+	events := payloads[0].Events
+	if len(events) != 2 {
+		t.Fatalf("unexpected number of events, exp=2 got=%+v", events)
+	}
+	if e := events[0]; e.Type != EventNewVersion || e.ImageURL != "quay.io/jetstack/upgraded" ||
+		e.Previous.Tag != "v0.1.0" || e.Latest.Tag != "v0.2.0" {
+		t.Errorf("unexpected new version event: %+v", e)
+	}
+	if e := events[1]; e.Type != EventRebuild || e.ImageURL != "quay.io/jetstack/rebuilt" ||
+		e.Rebuild == nil || e.Rebuild.OldSHA != "sha256:b" || e.Rebuild.NewSHA != "sha256:f" {
+		t.Errorf("unexpected rebuild event: %+v", e)
+	}
+
+	if tag := snapshot["quay.io/jetstack/new"]; tag.Tag != "v1.0.0" {
+		t.Errorf("expected new image to be added to the snapshot, got=%+v", tag)
+	}
+	if tag := snapshot["quay.io/jetstack/stale"]; tag.Tag != "v0.1.0" {
+		t.Errorf("expected stale image to keep its previous snapshot, got=%+v", tag)
+	}
+}
+
+func TestNotifyNoEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Error("unexpected webhook request")
+	}))
+	defer server.Close()
+
+	previous := Snapshot{"quay.io/jetstack/same": {Tag: "v0.1.0", SHA: "sha256:c"}}
+	_, err := New(Options{URL: server.URL}).Notify(context.TODO(), []version.ScanResult{
+		result("quay.io/jetstack/same", api.ImageTag{Tag: "v0.1.0", SHA: "sha256:c"}),
+	}, previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNotifyClientError(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	previous := Snapshot{"quay.io/jetstack/upgraded": {Tag: "v0.1.0"}}
+	snapshot, err := New(Options{URL: server.URL, Backoff: time.Millisecond}).Notify(context.TODO(), []version.ScanResult{
+		result("quay.io/jetstack/upgraded", api.ImageTag{Tag: "v0.2.0"}),
+	}, previous)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if attempts != 1 {
+		t.Errorf("expected client errors not to be retried, exp=1 got=%d", attempts)
+	}
+	if snapshot["quay.io/jetstack/upgraded"].Tag != "v0.1.0" {
+		t.Errorf("expected previous snapshot to be returned on failure, got=%+v", snapshot)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer server.Close()
+
+	notification := Notification{
+		Namespace:      "prod",
+		Pod:            "web-1",
+		Container:      "nginx",
+		Image:          "nginx",
+		CurrentVersion: "1.19.6",
+		LatestVersion:  "1.21.0",
+		Severity:       SeverityMinor,
+	}
+
+	if err := New(Options{URL: server.URL}).Send(context.TODO(), notification); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != notification {
+		t.Errorf("unexpected payload, exp=%+v got=%+v", notification, got)
+	}
+}
@@ -0,0 +1,135 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+// TagLister lists the available tags of an image URL. Satisfied by
+// client.Client.
+type TagLister interface {
+	Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error)
+}
+
+// Policy describes the restrictions an image tag must satisfy to be
+// compliant. Unset fields are not enforced.
+type Policy struct {
+	// AllowedTags is a regex which tags must match.
+	AllowedTags *regexp.Regexp
+
+	// MaxAge is the maximum age of a tag, since it was pushed.
+	MaxAge time.Duration
+
+	// RequireSigned requires tags to have an attached signature.
+	RequireSigned bool
+}
+
+// Result is the result of evaluating the running tag of an image against a
+// Policy.
+type Result struct {
+	// Compliant is true if the running tag satisfies the policy.
+	Compliant bool
+
+	// Violations describe why the running tag is not compliant.
+	Violations []string
+
+	// UpgradeTargets are the compliant tags which are a newer version than
+	// the running tag, newest first.
+	UpgradeTargets []api.ImageTag
+}
+
+// Evaluator evaluates images against policies, using the tags available at
+// the remote registry.
+type Evaluator struct {
+	tags TagLister
+}
+
+func New(tags TagLister) *Evaluator {
+	return &Evaluator{
+		tags: tags,
+	}
+}
+
+// Evaluate will evaluate whether the running tag of the image URL complies
+// with the policy, as well as return the compliant upgrade targets.
+func (e *Evaluator) Evaluate(ctx context.Context, imageURL, currentTag string, policy *Policy) (*Result, error) {
+	tags, err := e.tags.Tags(ctx, imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags for %q: %s", imageURL, err)
+	}
+
+	signed := client.SignedDigests(tags)
+	now := time.Now()
+
+	var current *api.ImageTag
+	for i := range tags {
+		if tags[i].Tag == currentTag {
+			current = &tags[i]
+			break
+		}
+	}
+
+	result := new(Result)
+	if current == nil {
+		result.Violations = []string{fmt.Sprintf("tag %q not found at remote registry", currentTag)}
+	} else {
+		result.Violations = policy.violations(current, signed, now)
+	}
+	result.Compliant = len(result.Violations) == 0
+
+	currentV := semver.Parse(currentTag)
+	seen := make(map[string]bool)
+	for i := range tags {
+		tag := &tags[i]
+		if seen[tag.Tag] || client.IsCosignArtifact(tag.Tag) {
+			continue
+		}
+
+		if !currentV.LessThan(semver.Parse(tag.Tag)) {
+			continue
+		}
+
+		if len(policy.violations(tag, signed, now)) > 0 {
+			continue
+		}
+
+		seen[tag.Tag] = true
+		result.UpgradeTargets = append(result.UpgradeTargets, *tag)
+	}
+
+	sort.SliceStable(result.UpgradeTargets, func(i, j int) bool {
+		return semver.Parse(result.UpgradeTargets[j].Tag).LessThan(
+			semver.Parse(result.UpgradeTargets[i].Tag))
+	})
+
+	return result, nil
+}
+
+// violations returns the ways in which the given tag doesn't comply with the
+// policy.
+func (p *Policy) violations(tag *api.ImageTag, signed map[string]bool, now time.Time) []string {
+	var violations []string
+
+	if p.AllowedTags != nil && !p.AllowedTags.MatchString(tag.Tag) {
+		violations = append(violations, fmt.Sprintf("tag %q does not match allowed tags %q",
+			tag.Tag, p.AllowedTags))
+	}
+
+	if p.MaxAge > 0 && now.Sub(tag.Timestamp) > p.MaxAge {
+		violations = append(violations, fmt.Sprintf("tag %q is older than max age %s",
+			tag.Tag, p.MaxAge))
+	}
+
+	if p.RequireSigned && !signed[client.SignatureDigest(*tag)] {
+		violations = append(violations, fmt.Sprintf("tag %q is not signed", tag.Tag))
+	}
+
+	return violations
+}
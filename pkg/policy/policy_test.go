@@ -0,0 +1,143 @@
+package policy
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+type fakeTagLister []api.ImageTag
+
+func (f fakeTagLister) Tags(context.Context, string) ([]api.ImageTag, error) {
+	return f, nil
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Now()
+	tags := fakeTagLister{
+		{Tag: "v0.9.0-beta.1", SHA: "sha256:090", Timestamp: now.Add(-time.Hour * 24 * 120)},
+		{Tag: "v1.0.0", SHA: "sha256:100", Timestamp: now.Add(-time.Hour * 24 * 90)},
+		{Tag: "v1.1.0", SHA: "sha256:110", Timestamp: now.Add(-time.Hour * 24 * 30)},
+		{Tag: "v1.2.0", SHA: "sha256:120", Timestamp: now.Add(-time.Hour * 24)},
+		{Tag: "v1.2.1", SHA: "sha256:121-amd64", IndexSHA: "sha256:121", Architecture: "amd64",
+			Timestamp: now.Add(-time.Hour * 12)},
+		{Tag: "v1.2.1", SHA: "sha256:121-arm64", IndexSHA: "sha256:121", Architecture: "arm64",
+			Timestamp: now.Add(-time.Hour * 12)},
+		{Tag: "v1.3.0-rc.0", SHA: "sha256:130", Timestamp: now},
+		{Tag: "sha256-100.sig", SHA: "sha256:s100", Timestamp: now.Add(-time.Hour * 24 * 90)},
+		{Tag: "sha256-110.sig", SHA: "sha256:s110", Timestamp: now.Add(-time.Hour * 24 * 30)},
+		{Tag: "sha256-121.sig", SHA: "sha256:s121", Timestamp: now.Add(-time.Hour * 12)},
+	}
+
+	tests := map[string]struct {
+		currentTag    string
+		policy        *Policy
+		expCompliant  bool
+		expViolations int
+		expTargets    []string
+	}{
+		"empty policy should be compliant with all newer targets": {
+			currentTag:   "v1.0.0",
+			policy:       new(Policy),
+			expCompliant: true,
+			expTargets:   []string{"v1.2.1", "v1.2.0", "v1.1.0"},
+		},
+		"tag matching regex should be compliant": {
+			currentTag:   "v1.0.0",
+			policy:       &Policy{AllowedTags: regexp.MustCompile(`^v\d+\.\d+\.\d+$`)},
+			expCompliant: true,
+			expTargets:   []string{"v1.2.1", "v1.2.0", "v1.1.0"},
+		},
+		"tag not matching regex should not be compliant": {
+			currentTag:    "v1.3.0-rc.0",
+			policy:        &Policy{AllowedTags: regexp.MustCompile(`^v\d+\.\d+\.\d+$`)},
+			expCompliant:  false,
+			expViolations: 1,
+		},
+		"tag within max age should be compliant": {
+			currentTag:   "v1.1.0",
+			policy:       &Policy{MaxAge: time.Hour * 24 * 60},
+			expCompliant: true,
+			expTargets:   []string{"v1.2.1", "v1.2.0"},
+		},
+		"tag older than max age should not be compliant": {
+			currentTag:    "v1.0.0",
+			policy:        &Policy{MaxAge: time.Hour * 24 * 7},
+			expCompliant:  false,
+			expViolations: 1,
+			expTargets:    []string{"v1.2.1", "v1.2.0"},
+		},
+		"signed tag should be compliant": {
+			currentTag:   "v1.0.0",
+			policy:       &Policy{RequireSigned: true},
+			expCompliant: true,
+			expTargets:   []string{"v1.2.1", "v1.1.0"},
+		},
+		"multi-arch tag with signed index should be compliant": {
+			currentTag:   "v1.2.1",
+			policy:       &Policy{RequireSigned: true},
+			expCompliant: true,
+		},
+		"unsigned tag should not be compliant": {
+			currentTag:    "v1.2.0",
+			policy:        &Policy{RequireSigned: true},
+			expCompliant:  false,
+			expViolations: 1,
+			expTargets:    []string{"v1.2.1"},
+		},
+		"tag violating all dimensions should report all violations": {
+			currentTag: "v0.9.0-beta.1",
+			policy: &Policy{
+				AllowedTags:   regexp.MustCompile(`^v\d+\.\d+\.\d+$`),
+				MaxAge:        time.Hour * 24 * 7,
+				RequireSigned: true,
+			},
+			expCompliant:  false,
+			expViolations: 3,
+			expTargets:    []string{"v1.2.1"},
+		},
+		"unknown tag should not be compliant": {
+			currentTag:    "v0.9.0",
+			policy:        new(Policy),
+			expCompliant:  false,
+			expViolations: 1,
+			expTargets:    []string{"v1.2.1", "v1.2.0", "v1.1.0", "v1.0.0"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			result, err := New(tags).Evaluate(context.TODO(), "image", test.currentTag, test.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Compliant != test.expCompliant {
+				t.Errorf("unexpected compliant, exp=%t got=%t (%v)",
+					test.expCompliant, result.Compliant, result.Violations)
+			}
+
+			if len(result.Violations) != test.expViolations {
+				t.Errorf("unexpected violations, exp=%d got=%v",
+					test.expViolations, result.Violations)
+			}
+
+			var targets []string
+			for _, tag := range result.UpgradeTargets {
+				targets = append(targets, tag.Tag)
+			}
+			if len(targets) != len(test.expTargets) {
+				t.Fatalf("unexpected upgrade targets, exp=%v got=%v", test.expTargets, targets)
+			}
+			for i := range targets {
+				if targets[i] != test.expTargets[i] {
+					t.Errorf("unexpected upgrade targets, exp=%v got=%v", test.expTargets, targets)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jetstack/version-checker/pkg/metrics"
+	queryv1 "github.com/jetstack/version-checker/pkg/query/v1"
+)
+
+// Source holds the results of image checks.
+type Source interface {
+	ImageStatus(namespace, pod, container string) (metrics.Image, bool)
+	Watch(ctx context.Context, filter metrics.ImageFilter) ([]metrics.Image, <-chan metrics.ImageEvent)
+}
+
+// Server serves the results of image checks over gRPC, and streams changes to
+// them, so integrations can react to new versions without polling metrics.
+type Server struct {
+	queryv1.UnimplementedImageStatusServiceServer
+
+	server *grpc.Server
+
+	log    *logrus.Entry
+	source Source
+
+	// ctx is cancelled on shutdown to end open watches, which would otherwise
+	// keep the server from stopping gracefully.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func New(log *logrus.Entry, source Source) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Server{
+		log:    log.WithField("module", "query"),
+		source: source,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Run will run the query server.
+func (s *Server) Run(servingAddress string) error {
+	ln, err := net.Listen("tcp", servingAddress)
+	if err != nil {
+		return err
+	}
+
+	s.serve(ln)
+
+	return nil
+}
+
+func (s *Server) serve(ln net.Listener) {
+	s.server = grpc.NewServer()
+	queryv1.RegisterImageStatusServiceServer(s.server, s)
+
+	go func() {
+		s.log.Infof("serving image status queries on %s", ln.Addr())
+
+		if err := s.server.Serve(ln); err != nil {
+			s.log.Errorf("failed to serve image status queries: %s", err)
+		}
+	}()
+}
+
+// GetImageStatus returns the result of the image of the container given by
+// the request.
+func (s *Server) GetImageStatus(_ context.Context, req *queryv1.GetImageStatusRequest) (*queryv1.Image, error) {
+	if len(req.Namespace) == 0 || len(req.Pod) == 0 || len(req.Container) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "namespace, pod and container are required")
+	}
+
+	image, ok := s.source.ImageStatus(req.Namespace, req.Pod, req.Container)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "image of container %s/%s/%s not found",
+			req.Namespace, req.Pod, req.Container)
+	}
+
+	return imageToProto(image), nil
+}
+
+// WatchImageStatus streams the events of images matching the request,
+// starting with an ADDED event of every image currently checked. The stream
+// ends with ABORTED if the client falls behind, so must watch again.
+func (s *Server) WatchImageStatus(req *queryv1.WatchImageStatusRequest,
+	stream queryv1.ImageStatusService_WatchImageStatusServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	images, events := s.source.Watch(ctx, metrics.ImageFilter{
+		Namespace:    req.Namespace,
+		Pod:          req.Pod,
+		OutdatedOnly: req.OutdatedOnly,
+	})
+
+	for _, image := range images {
+		if err := stream.Send(&queryv1.ImageEvent{
+			Type:  queryv1.ImageEvent_ADDED,
+			Image: imageToProto(image),
+		}); err != nil {
+			return err
+		}
+	}
+
+	for event := range events {
+		if err := stream.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+
+	// The channel is closed once the context is done, or else the watch fell
+	// behind.
+	if s.ctx.Err() != nil {
+		return status.Error(codes.Unavailable, "query server shutting down")
+	}
+	if err := stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	return status.Error(codes.Aborted, "watch fell behind, must watch again")
+}
+
+func (s *Server) Shutdown() error {
+	// If the query server is not started then exit early
+	if s.server == nil {
+		return nil
+	}
+
+	s.log.Info("shutting down query server...")
+	s.cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second * 5):
+		s.server.Stop()
+		return fmt.Errorf("query server shutdown failed: %s", context.DeadlineExceeded)
+	}
+
+	s.log.Info("query server gracefully stopped")
+
+	return nil
+}
+
+func imageToProto(image metrics.Image) *queryv1.Image {
+	return &queryv1.Image{
+		Namespace:      image.Namespace,
+		Pod:            image.Pod,
+		Container:      image.Container,
+		ContainerType:  image.ContainerType,
+		Image:          image.Image,
+		CurrentVersion: image.CurrentVersion,
+		LatestVersion:  image.LatestVersion,
+		IsLatest:       image.IsLatest,
+	}
+}
+
+func eventToProto(event metrics.ImageEvent) *queryv1.ImageEvent {
+	var typ queryv1.ImageEvent_Type
+	switch event.Type {
+	case metrics.ImageEventAdded:
+		typ = queryv1.ImageEvent_ADDED
+	case metrics.ImageEventUpdated:
+		typ = queryv1.ImageEvent_UPDATED
+	case metrics.ImageEventRemoved:
+		typ = queryv1.ImageEvent_REMOVED
+	}
+
+	return &queryv1.ImageEvent{
+		Type:  typ,
+		Image: imageToProto(event.Image),
+	}
+}
@@ -0,0 +1,112 @@
+package query
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/jetstack/version-checker/pkg/metrics"
+	queryv1 "github.com/jetstack/version-checker/pkg/query/v1"
+)
+
+func TestGetImageStatus(t *testing.T) {
+	m := metrics.New(logrus.NewEntry(logrus.New()))
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.19.6", "1.21.0")
+	s := New(logrus.NewEntry(logrus.New()), m)
+
+	tests := map[string]struct {
+		req     *queryv1.GetImageStatusRequest
+		expCode codes.Code
+		exp     *queryv1.Image
+	}{
+		"missing fields should be an invalid argument": {
+			req:     &queryv1.GetImageStatusRequest{Namespace: "prod", Pod: "web-1"},
+			expCode: codes.InvalidArgument,
+		},
+		"unknown container should not be found": {
+			req:     &queryv1.GetImageStatusRequest{Namespace: "prod", Pod: "web-1", Container: "sidecar"},
+			expCode: codes.NotFound,
+		},
+		"known container should return its image status": {
+			req:     &queryv1.GetImageStatusRequest{Namespace: "prod", Pod: "web-1", Container: "nginx"},
+			expCode: codes.OK,
+			exp: &queryv1.Image{Namespace: "prod", Pod: "web-1", Container: "nginx", ContainerType: "container",
+				Image: "nginx", CurrentVersion: "1.19.6", LatestVersion: "1.21.0"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			image, err := s.GetImageStatus(context.Background(), test.req)
+			if code := status.Code(err); code != test.expCode {
+				t.Fatalf("unexpected status code, exp=%s got=%s (%v)", test.expCode, code, err)
+			}
+
+			if !proto.Equal(image, test.exp) {
+				t.Errorf("unexpected image, exp=%+v got=%+v", test.exp, image)
+			}
+		})
+	}
+}
+
+func TestWatchImageStatus(t *testing.T) {
+	m := metrics.New(logrus.NewEntry(logrus.New()))
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.19.6", "1.19.6")
+	m.AddImage("dev", "app", "app", "container", "app", "v0.1.0", "v0.2.0")
+
+	s := New(logrus.NewEntry(logrus.New()), m)
+	ln := bufconn.Listen(1 << 20)
+	s.serve(ln)
+	defer func() {
+		if err := s.Shutdown(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "bufconn", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return ln.Dial()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := queryv1.NewImageStatusServiceClient(conn).WatchImageStatus(ctx,
+		&queryv1.WatchImageStatusRequest{Namespace: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next := func() *queryv1.ImageEvent {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected end of stream: %s", err)
+		}
+		return event
+	}
+
+	image := &queryv1.Image{Namespace: "prod", Pod: "web-1", Container: "nginx", ContainerType: "container",
+		Image: "nginx", CurrentVersion: "1.19.6", LatestVersion: "1.19.6", IsLatest: true}
+	if event, exp := next(), (&queryv1.ImageEvent{Type: queryv1.ImageEvent_ADDED, Image: image}); !proto.Equal(event, exp) {
+		t.Errorf("unexpected event, exp=%+v got=%+v", exp, event)
+	}
+
+	m.AddImage("dev", "app", "app", "container", "app", "v0.1.0", "v0.3.0")
+	m.AddImage("prod", "web-1", "nginx", "container", "nginx", "1.19.6", "1.21.0")
+
+	image.LatestVersion, image.IsLatest = "1.21.0", false
+	if event, exp := next(), (&queryv1.ImageEvent{Type: queryv1.ImageEvent_UPDATED, Image: image}); !proto.Equal(event, exp) {
+		t.Errorf("unexpected event, exp=%+v got=%+v", exp, event)
+	}
+}
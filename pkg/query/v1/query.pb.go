@@ -0,0 +1,690 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.24.0
+// 	protoc        (unknown)
+// source: pkg/query/v1/query.proto
+
+package v1
+
+import (
+	context "context"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type ImageEvent_Type int32
+
+const (
+	ImageEvent_TYPE_UNSPECIFIED ImageEvent_Type = 0
+	ImageEvent_ADDED            ImageEvent_Type = 1
+	ImageEvent_UPDATED          ImageEvent_Type = 2
+	ImageEvent_REMOVED          ImageEvent_Type = 3
+)
+
+// Enum value maps for ImageEvent_Type.
+var (
+	ImageEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "ADDED",
+		2: "UPDATED",
+		3: "REMOVED",
+	}
+	ImageEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"ADDED":            1,
+		"UPDATED":          2,
+		"REMOVED":          3,
+	}
+)
+
+func (x ImageEvent_Type) Enum() *ImageEvent_Type {
+	p := new(ImageEvent_Type)
+	*p = x
+	return p
+}
+
+func (x ImageEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ImageEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_query_v1_query_proto_enumTypes[0].Descriptor()
+}
+
+func (ImageEvent_Type) Type() protoreflect.EnumType {
+	return &file_pkg_query_v1_query_proto_enumTypes[0]
+}
+
+func (x ImageEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ImageEvent_Type.Descriptor instead.
+func (ImageEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_query_v1_query_proto_rawDescGZIP(), []int{3, 0}
+}
+
+type GetImageStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pod       string `protobuf:"bytes,2,opt,name=pod,proto3" json:"pod,omitempty"`
+	Container string `protobuf:"bytes,3,opt,name=container,proto3" json:"container,omitempty"`
+}
+
+func (x *GetImageStatusRequest) Reset() {
+	*x = GetImageStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_query_v1_query_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetImageStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetImageStatusRequest) ProtoMessage() {}
+
+func (x *GetImageStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_query_v1_query_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetImageStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetImageStatusRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_query_v1_query_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetImageStatusRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *GetImageStatusRequest) GetPod() string {
+	if x != nil {
+		return x.Pod
+	}
+	return ""
+}
+
+func (x *GetImageStatusRequest) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+type WatchImageStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Namespace    string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pod          string `protobuf:"bytes,2,opt,name=pod,proto3" json:"pod,omitempty"`
+	OutdatedOnly bool   `protobuf:"varint,3,opt,name=outdated_only,json=outdatedOnly,proto3" json:"outdated_only,omitempty"`
+}
+
+func (x *WatchImageStatusRequest) Reset() {
+	*x = WatchImageStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_query_v1_query_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchImageStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchImageStatusRequest) ProtoMessage() {}
+
+func (x *WatchImageStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_query_v1_query_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchImageStatusRequest.ProtoReflect.Descriptor instead.
+func (*WatchImageStatusRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_query_v1_query_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *WatchImageStatusRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *WatchImageStatusRequest) GetPod() string {
+	if x != nil {
+		return x.Pod
+	}
+	return ""
+}
+
+func (x *WatchImageStatusRequest) GetOutdatedOnly() bool {
+	if x != nil {
+		return x.OutdatedOnly
+	}
+	return false
+}
+
+type Image struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Namespace      string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pod            string `protobuf:"bytes,2,opt,name=pod,proto3" json:"pod,omitempty"`
+	Container      string `protobuf:"bytes,3,opt,name=container,proto3" json:"container,omitempty"`
+	ContainerType  string `protobuf:"bytes,4,opt,name=container_type,json=containerType,proto3" json:"container_type,omitempty"`
+	Image          string `protobuf:"bytes,5,opt,name=image,proto3" json:"image,omitempty"`
+	CurrentVersion string `protobuf:"bytes,6,opt,name=current_version,json=currentVersion,proto3" json:"current_version,omitempty"`
+	LatestVersion  string `protobuf:"bytes,7,opt,name=latest_version,json=latestVersion,proto3" json:"latest_version,omitempty"`
+	IsLatest       bool   `protobuf:"varint,8,opt,name=is_latest,json=isLatest,proto3" json:"is_latest,omitempty"`
+}
+
+func (x *Image) Reset() {
+	*x = Image{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_query_v1_query_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Image) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Image) ProtoMessage() {}
+
+func (x *Image) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_query_v1_query_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Image.ProtoReflect.Descriptor instead.
+func (*Image) Descriptor() ([]byte, []int) {
+	return file_pkg_query_v1_query_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Image) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Image) GetPod() string {
+	if x != nil {
+		return x.Pod
+	}
+	return ""
+}
+
+func (x *Image) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *Image) GetContainerType() string {
+	if x != nil {
+		return x.ContainerType
+	}
+	return ""
+}
+
+func (x *Image) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *Image) GetCurrentVersion() string {
+	if x != nil {
+		return x.CurrentVersion
+	}
+	return ""
+}
+
+func (x *Image) GetLatestVersion() string {
+	if x != nil {
+		return x.LatestVersion
+	}
+	return ""
+}
+
+func (x *Image) GetIsLatest() bool {
+	if x != nil {
+		return x.IsLatest
+	}
+	return false
+}
+
+type ImageEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type  ImageEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=versionchecker.query.v1.ImageEvent_Type" json:"type,omitempty"`
+	Image *Image          `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+}
+
+func (x *ImageEvent) Reset() {
+	*x = ImageEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_query_v1_query_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ImageEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageEvent) ProtoMessage() {}
+
+func (x *ImageEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_query_v1_query_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageEvent.ProtoReflect.Descriptor instead.
+func (*ImageEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_query_v1_query_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ImageEvent) GetType() ImageEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return ImageEvent_TYPE_UNSPECIFIED
+}
+
+func (x *ImageEvent) GetImage() *Image {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+var File_pkg_query_v1_query_proto protoreflect.FileDescriptor
+
+var file_pkg_query_v1_query_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x70, 0x6b, 0x67, 0x2f, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2f, 0x76, 0x31, 0x2f, 0x71,
+	0x75, 0x65, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x17, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x71, 0x75, 0x65, 0x72, 0x79,
+	0x2e, 0x76, 0x31, 0x22, 0x65, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09,
+	0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6f,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x70, 0x6f, 0x64, 0x12, 0x1c, 0x0a, 0x09,
+	0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x22, 0x6e, 0x0a, 0x17, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6f, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x70, 0x6f, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x64, 0x61, 0x74, 0x65,
+	0x64, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x6f, 0x75,
+	0x74, 0x64, 0x61, 0x74, 0x65, 0x64, 0x4f, 0x6e, 0x6c, 0x79, 0x22, 0xff, 0x01, 0x0a, 0x05, 0x49,
+	0x6d, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x6f, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x70, 0x6f, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65,
+	0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e,
+	0x65, 0x72, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x5f,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x6f, 0x6e, 0x74,
+	0x61, 0x69, 0x6e, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61,
+	0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12,
+	0x27, 0x0a, 0x0f, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e,
+	0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x6c, 0x61, 0x74, 0x65,
+	0x73, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0d, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x1b, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x08, 0x69, 0x73, 0x4c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x22, 0xc3, 0x01, 0x0a,
+	0x0a, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x3c, 0x0a, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x28, 0x2e, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x71, 0x75, 0x65, 0x72, 0x79,
+	0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x54,
+	0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x34, 0x0a, 0x05, 0x69, 0x6d, 0x61,
+	0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2e,
+	0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x22,
+	0x41, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x09, 0x0a,
+	0x05, 0x41, 0x44, 0x44, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x50, 0x44, 0x41,
+	0x54, 0x45, 0x44, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x45, 0x4d, 0x4f, 0x56, 0x45, 0x44,
+	0x10, 0x03, 0x32, 0xe3, 0x01, 0x0a, 0x12, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x60, 0x0a, 0x0e, 0x47, 0x65, 0x74,
+	0x49, 0x6d, 0x61, 0x67, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2e, 0x2e, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x71, 0x75, 0x65,
+	0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x72, 0x2e, 0x71, 0x75, 0x65,
+	0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x6b, 0x0a, 0x10, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x30, 0x2e, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x72,
+	0x2e, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x49,
+	0x6d, 0x61, 0x67, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x23, 0x2e, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x63, 0x68, 0x65, 0x63, 0x6b,
+	0x65, 0x72, 0x2e, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67,
+	0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x30, 0x01, 0x42, 0x35, 0x5a, 0x33, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x65, 0x74, 0x73, 0x74, 0x61, 0x63, 0x6b, 0x2f,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x2d, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x65, 0x72, 0x2f,
+	0x70, 0x6b, 0x67, 0x2f, 0x71, 0x75, 0x65, 0x72, 0x79, 0x2f, 0x76, 0x31, 0x3b, 0x76, 0x31, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pkg_query_v1_query_proto_rawDescOnce sync.Once
+	file_pkg_query_v1_query_proto_rawDescData = file_pkg_query_v1_query_proto_rawDesc
+)
+
+func file_pkg_query_v1_query_proto_rawDescGZIP() []byte {
+	file_pkg_query_v1_query_proto_rawDescOnce.Do(func() {
+		file_pkg_query_v1_query_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_query_v1_query_proto_rawDescData)
+	})
+	return file_pkg_query_v1_query_proto_rawDescData
+}
+
+var file_pkg_query_v1_query_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pkg_query_v1_query_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pkg_query_v1_query_proto_goTypes = []interface{}{
+	(ImageEvent_Type)(0),            // 0: versionchecker.query.v1.ImageEvent.Type
+	(*GetImageStatusRequest)(nil),   // 1: versionchecker.query.v1.GetImageStatusRequest
+	(*WatchImageStatusRequest)(nil), // 2: versionchecker.query.v1.WatchImageStatusRequest
+	(*Image)(nil),                   // 3: versionchecker.query.v1.Image
+	(*ImageEvent)(nil),              // 4: versionchecker.query.v1.ImageEvent
+}
+var file_pkg_query_v1_query_proto_depIdxs = []int32{
+	0, // 0: versionchecker.query.v1.ImageEvent.type:type_name -> versionchecker.query.v1.ImageEvent.Type
+	3, // 1: versionchecker.query.v1.ImageEvent.image:type_name -> versionchecker.query.v1.Image
+	1, // 2: versionchecker.query.v1.ImageStatusService.GetImageStatus:input_type -> versionchecker.query.v1.GetImageStatusRequest
+	2, // 3: versionchecker.query.v1.ImageStatusService.WatchImageStatus:input_type -> versionchecker.query.v1.WatchImageStatusRequest
+	3, // 4: versionchecker.query.v1.ImageStatusService.GetImageStatus:output_type -> versionchecker.query.v1.Image
+	4, // 5: versionchecker.query.v1.ImageStatusService.WatchImageStatus:output_type -> versionchecker.query.v1.ImageEvent
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_pkg_query_v1_query_proto_init() }
+func file_pkg_query_v1_query_proto_init() {
+	if File_pkg_query_v1_query_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pkg_query_v1_query_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetImageStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_query_v1_query_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchImageStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_query_v1_query_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Image); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_query_v1_query_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ImageEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pkg_query_v1_query_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_query_v1_query_proto_goTypes,
+		DependencyIndexes: file_pkg_query_v1_query_proto_depIdxs,
+		EnumInfos:         file_pkg_query_v1_query_proto_enumTypes,
+		MessageInfos:      file_pkg_query_v1_query_proto_msgTypes,
+	}.Build()
+	File_pkg_query_v1_query_proto = out.File
+	file_pkg_query_v1_query_proto_rawDesc = nil
+	file_pkg_query_v1_query_proto_goTypes = nil
+	file_pkg_query_v1_query_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// ImageStatusServiceClient is the client API for ImageStatusService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ImageStatusServiceClient interface {
+	GetImageStatus(ctx context.Context, in *GetImageStatusRequest, opts ...grpc.CallOption) (*Image, error)
+	WatchImageStatus(ctx context.Context, in *WatchImageStatusRequest, opts ...grpc.CallOption) (ImageStatusService_WatchImageStatusClient, error)
+}
+
+type imageStatusServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewImageStatusServiceClient(cc grpc.ClientConnInterface) ImageStatusServiceClient {
+	return &imageStatusServiceClient{cc}
+}
+
+func (c *imageStatusServiceClient) GetImageStatus(ctx context.Context, in *GetImageStatusRequest, opts ...grpc.CallOption) (*Image, error) {
+	out := new(Image)
+	err := c.cc.Invoke(ctx, "/versionchecker.query.v1.ImageStatusService/GetImageStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageStatusServiceClient) WatchImageStatus(ctx context.Context, in *WatchImageStatusRequest, opts ...grpc.CallOption) (ImageStatusService_WatchImageStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ImageStatusService_serviceDesc.Streams[0], "/versionchecker.query.v1.ImageStatusService/WatchImageStatus", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &imageStatusServiceWatchImageStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ImageStatusService_WatchImageStatusClient interface {
+	Recv() (*ImageEvent, error)
+	grpc.ClientStream
+}
+
+type imageStatusServiceWatchImageStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *imageStatusServiceWatchImageStatusClient) Recv() (*ImageEvent, error) {
+	m := new(ImageEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ImageStatusServiceServer is the server API for ImageStatusService service.
+type ImageStatusServiceServer interface {
+	GetImageStatus(context.Context, *GetImageStatusRequest) (*Image, error)
+	WatchImageStatus(*WatchImageStatusRequest, ImageStatusService_WatchImageStatusServer) error
+}
+
+// UnimplementedImageStatusServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedImageStatusServiceServer struct {
+}
+
+func (*UnimplementedImageStatusServiceServer) GetImageStatus(context.Context, *GetImageStatusRequest) (*Image, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetImageStatus not implemented")
+}
+func (*UnimplementedImageStatusServiceServer) WatchImageStatus(*WatchImageStatusRequest, ImageStatusService_WatchImageStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchImageStatus not implemented")
+}
+
+func RegisterImageStatusServiceServer(s *grpc.Server, srv ImageStatusServiceServer) {
+	s.RegisterService(&_ImageStatusService_serviceDesc, srv)
+}
+
+func _ImageStatusService_GetImageStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetImageStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageStatusServiceServer).GetImageStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/versionchecker.query.v1.ImageStatusService/GetImageStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageStatusServiceServer).GetImageStatus(ctx, req.(*GetImageStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageStatusService_WatchImageStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchImageStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ImageStatusServiceServer).WatchImageStatus(m, &imageStatusServiceWatchImageStatusServer{stream})
+}
+
+type ImageStatusService_WatchImageStatusServer interface {
+	Send(*ImageEvent) error
+	grpc.ServerStream
+}
+
+type imageStatusServiceWatchImageStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *imageStatusServiceWatchImageStatusServer) Send(m *ImageEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _ImageStatusService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "versionchecker.query.v1.ImageStatusService",
+	HandlerType: (*ImageStatusServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetImageStatus",
+			Handler:    _ImageStatusService_GetImageStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchImageStatus",
+			Handler:       _ImageStatusService_WatchImageStatus_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/query/v1/query.proto",
+}
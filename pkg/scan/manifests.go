@@ -0,0 +1,219 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// podTemplatePaths are the fields of a resource holding a pod template,
+	// such as those of Deployments, Jobs and CronJobs, Argo Rollouts and
+	// Knative Services.
+	podTemplatePaths = [][]string{
+		{"spec", "template"},
+		{"spec", "jobTemplate", "spec", "template"},
+	}
+
+	// manifestExtensions are the file extensions of manifests read from a
+	// directory.
+	manifestExtensions = map[string]bool{
+		".yaml": true,
+		".yml":  true,
+		".json": true,
+	}
+)
+
+// Container is a container found in a manifest.
+type Container struct {
+	// Source is the file the manifest was read from.
+	Source string `json:"source,omitempty"`
+
+	Kind          string `json:"kind,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	Name          string `json:"name,omitempty"`
+	ContainerName string `json:"container,omitempty"`
+	Image         string `json:"image"`
+
+	// Annotations are the annotations of the resource, merged over those of
+	// its pod template.
+	Annotations map[string]string `json:"-"`
+}
+
+// ReadManifests will return the containers of the manifests at the path,
+// being a file, or a directory which is walked for .yaml, .yml and .json
+// files. A path of "-" reads manifests from stdin, such as the output of helm
+// template.
+func ReadManifests(path string) ([]Container, error) {
+	if path == "-" {
+		return decodeManifests("stdin", os.Stdin)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return readManifestFile(path)
+	}
+
+	var files []string
+	err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() && manifestExtensions[strings.ToLower(filepath.Ext(file))] {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %q: %s", path, err)
+	}
+	sort.Strings(files)
+
+	var containers []Container
+	for _, file := range files {
+		fileContainers, err := readManifestFile(file)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, fileContainers...)
+	}
+
+	return containers, nil
+}
+
+func readManifestFile(file string) ([]Container, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeManifests(file, bytes.NewReader(data))
+}
+
+// decodeManifests will return the containers of every manifest of the YAML or
+// JSON documents read. Documents which are not Kubernetes resources, or have
+// no pod template, are skipped.
+func decodeManifests(source string, r io.Reader) ([]Container, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+
+	var containers []Container
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %s", source, err)
+		}
+
+		obj := make(map[string]interface{})
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return nil, fmt.Errorf("failed to decode manifest of %q: %s", source, err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+
+		objContainers, err := manifestContainers(source, &unstructured.Unstructured{Object: obj})
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, objContainers...)
+	}
+
+	return containers, nil
+}
+
+// manifestContainers returns the containers of the pod spec of the resource,
+// or of every item of a List.
+func manifestContainers(source string, obj *unstructured.Unstructured) ([]Container, error) {
+	if obj.IsList() {
+		var containers []Container
+		err := obj.EachListItem(func(item runtime.Object) error {
+			itemContainers, err := manifestContainers(source, item.(*unstructured.Unstructured))
+			containers = append(containers, itemContainers...)
+			return err
+		})
+
+		return containers, err
+	}
+
+	template, err := podTemplate(obj)
+	if err != nil || template == nil {
+		return nil, err
+	}
+
+	annotations := make(map[string]string)
+	for key, value := range template.Annotations {
+		annotations[key] = value
+	}
+	if obj.GetKind() != "Pod" {
+		for key, value := range obj.GetAnnotations() {
+			annotations[key] = value
+		}
+	}
+
+	var containers []Container
+	for _, list := range [][]corev1.Container{template.Spec.InitContainers, template.Spec.Containers} {
+		for _, container := range list {
+			containers = append(containers, Container{
+				Source:        source,
+				Kind:          obj.GetKind(),
+				Namespace:     obj.GetNamespace(),
+				Name:          obj.GetName(),
+				ContainerName: container.Name,
+				Image:         container.Image,
+				Annotations:   annotations,
+			})
+		}
+	}
+
+	return containers, nil
+}
+
+// podTemplate returns the pod template of the resource, or the pod itself, or
+// nil if it has neither.
+func podTemplate(obj *unstructured.Unstructured) (*corev1.PodTemplateSpec, error) {
+	template := new(corev1.PodTemplateSpec)
+
+	if obj.GetKind() == "Pod" {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, template); err != nil {
+			return nil, fmt.Errorf("failed to decode pod %q: %s", obj.GetName(), err)
+		}
+		return template, nil
+	}
+
+	for _, fields := range podTemplatePaths {
+		raw, ok, err := unstructured.NestedMap(obj.Object, fields...)
+		if err != nil || !ok {
+			continue
+		}
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, template); err != nil {
+			return nil, fmt.Errorf("failed to decode pod template of %s %q: %s",
+				obj.GetKind(), obj.GetName(), err)
+		}
+
+		return template, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,149 @@
+package scan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeManifests(t *testing.T) {
+	tests := map[string]struct {
+		manifests string
+		exp       []Container
+		expErr    bool
+	}{
+		"resources without pod templates should be skipped": {
+			manifests: `
+# Source: chart/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+`,
+			exp: nil,
+		},
+		"containers of pods should be found": {
+			manifests: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: prod
+  annotations:
+    pin-major.version-checker.io/nginx: "1"
+spec:
+  initContainers:
+  - name: init
+    image: busybox:1.32
+  containers:
+  - name: nginx
+    image: nginx:1.19.0
+`,
+			exp: []Container{
+				{
+					Source: "test.yaml", Kind: "Pod", Namespace: "prod", Name: "web",
+					ContainerName: "init", Image: "busybox:1.32",
+					Annotations: map[string]string{"pin-major.version-checker.io/nginx": "1"},
+				},
+				{
+					Source: "test.yaml", Kind: "Pod", Namespace: "prod", Name: "web",
+					ContainerName: "nginx", Image: "nginx:1.19.0",
+					Annotations: map[string]string{"pin-major.version-checker.io/nginx": "1"},
+				},
+			},
+		},
+		"annotations of resources should take precedence over their pod template": {
+			manifests: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  annotations:
+    pin-major.version-checker.io/nginx: "1"
+spec:
+  template:
+    metadata:
+      annotations:
+        pin-major.version-checker.io/nginx: "2"
+        use-metadata.version-checker.io/nginx: "true"
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.19.0
+`,
+			exp: []Container{
+				{
+					Source: "test.yaml", Kind: "Deployment", Name: "web",
+					ContainerName: "nginx", Image: "nginx:1.19.0",
+					Annotations: map[string]string{
+						"pin-major.version-checker.io/nginx":    "1",
+						"use-metadata.version-checker.io/nginx": "true",
+					},
+				},
+			},
+		},
+		"pod templates of cron jobs and lists should be found": {
+			manifests: `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: batch/v1beta1
+  kind: CronJob
+  metadata:
+    name: backup
+  spec:
+    jobTemplate:
+      spec:
+        template:
+          spec:
+            containers:
+            - name: backup
+              image: quay.io/jetstack/backup:v0.1.0
+`,
+			exp: []Container{
+				{
+					Source: "test.yaml", Kind: "CronJob", Name: "backup",
+					ContainerName: "backup", Image: "quay.io/jetstack/backup:v0.1.0",
+					Annotations: map[string]string{},
+				},
+			},
+		},
+		"JSON manifests should be decoded": {
+			manifests: `{"apiVersion": "apps/v1", "kind": "StatefulSet", "metadata": {"name": "db"},
+"spec": {"template": {"spec": {"containers": [{"name": "postgres", "image": "postgres:13.1"}]}}}}`,
+			exp: []Container{
+				{
+					Source: "test.yaml", Kind: "StatefulSet", Name: "db",
+					ContainerName: "postgres", Image: "postgres:13.1",
+					Annotations: map[string]string{},
+				},
+			},
+		},
+		"invalid manifests should error": {
+			manifests: "kind: [Deployment",
+			expErr:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			containers, err := decodeManifests("test.yaml", strings.NewReader(test.manifests))
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+
+			if !reflect.DeepEqual(containers, test.exp) {
+				t.Errorf("unexpected containers, exp=%+v got=%+v", test.exp, containers)
+			}
+		})
+	}
+}
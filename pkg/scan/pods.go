@@ -0,0 +1,46 @@
+package scan
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodContainers returns the containers of the pod. Containers using the latest
+// tag, or no tag, are given the digest of their running image, so it is
+// compared against the digest of the latest image.
+func PodContainers(pod *corev1.Pod) []Container {
+	imageIDs := make(map[string]string)
+	for _, statuses := range [][]corev1.ContainerStatus{
+		pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses,
+	} {
+		for _, status := range statuses {
+			imageIDs[status.Name] = status.ImageID
+		}
+	}
+
+	var containers []Container
+	for _, list := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range list {
+			image := container.Image
+
+			imageURL, currentVersion, _ := splitImage(image)
+			if currentVersion == "" || currentVersion == "latest" {
+				if i := strings.Index(imageIDs[container.Name], "@"); i > -1 {
+					image = imageURL + imageIDs[container.Name][i:]
+				}
+			}
+
+			containers = append(containers, Container{
+				Kind:          "Pod",
+				Namespace:     pod.Namespace,
+				Name:          pod.Name,
+				ContainerName: container.Name,
+				Image:         image,
+				Annotations:   pod.Annotations,
+			})
+		}
+	}
+
+	return containers
+}
@@ -0,0 +1,54 @@
+package scan
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodContainers(t *testing.T) {
+	annotations := map[string]string{"pin-major.version-checker.io/nginx": "1"}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "prod",
+			Name:        "web-1",
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "init", Image: "busybox"},
+			},
+			Containers: []corev1.Container{
+				{Name: "nginx", Image: "nginx:1.19.0"},
+				{Name: "sidecar", Image: "localhost:5000/sidecar:latest"},
+				{Name: "pending", Image: "redis"},
+			},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "init", ImageID: "docker-pullable://busybox@sha256:a"},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "nginx", ImageID: "docker-pullable://nginx@sha256:b"},
+				{Name: "sidecar", ImageID: "docker-pullable://localhost:5000/sidecar@sha256:c"},
+			},
+		},
+	}
+
+	exp := []Container{
+		{Kind: "Pod", Namespace: "prod", Name: "web-1", ContainerName: "init",
+			Image: "busybox@sha256:a", Annotations: annotations},
+		{Kind: "Pod", Namespace: "prod", Name: "web-1", ContainerName: "nginx",
+			Image: "nginx:1.19.0", Annotations: annotations},
+		{Kind: "Pod", Namespace: "prod", Name: "web-1", ContainerName: "sidecar",
+			Image: "localhost:5000/sidecar@sha256:c", Annotations: annotations},
+		{Kind: "Pod", Namespace: "prod", Name: "web-1", ContainerName: "pending",
+			Image: "redis", Annotations: annotations},
+	}
+
+	if containers := PodContainers(pod); !reflect.DeepEqual(containers, exp) {
+		t.Errorf("unexpected containers, exp=%+v got=%+v", exp, containers)
+	}
+}
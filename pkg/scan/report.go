@@ -0,0 +1,141 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// Report formats supported by WriteReport.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+// WriteReport will write the results in the output format, being table or
+// json.
+func WriteReport(w io.Writer, output string, results []Result) error {
+	switch output {
+	case OutputTable:
+		return writeTable(w, results)
+	case OutputJSON:
+		// Always write a list, so an empty report is still valid JSON.
+		if results == nil {
+			results = []Result{}
+		}
+
+		return writeJSON(w, results)
+	default:
+		return fmt.Errorf("unsupported output %q, must be %s or %s",
+			output, OutputTable, OutputJSON)
+	}
+}
+
+// WriteCheckReport will write the result of checking a single image in the
+// output format, being table or json.
+func WriteCheckReport(w io.Writer, output string, result Result) error {
+	switch output {
+	case OutputTable:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "IMAGE\tCURRENT\tLATEST\tSTATUS")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			result.Image, result.CurrentVersion, result.LatestVersion, result.Status())
+		return tw.Flush()
+	case OutputJSON:
+		return writeJSON(w, result)
+	default:
+		return fmt.Errorf("unsupported output %q, must be %s or %s",
+			output, OutputTable, OutputJSON)
+	}
+}
+
+// WriteNamespaceReport will write the results of checking the containers of
+// pods in the output format, being table or json. Tables are written per
+// namespace, ordered by pod.
+func WriteNamespaceReport(w io.Writer, output string, results []Result) error {
+	switch output {
+	case OutputTable:
+		results = append([]Result{}, results...)
+		sort.SliceStable(results, func(i, j int) bool {
+			if results[i].Namespace != results[j].Namespace {
+				return results[i].Namespace < results[j].Namespace
+			}
+			return results[i].Name < results[j].Name
+		})
+
+		var tw *tabwriter.Writer
+		for i, result := range results {
+			if i == 0 || result.Namespace != results[i-1].Namespace {
+				if tw != nil {
+					if err := tw.Flush(); err != nil {
+						return err
+					}
+					fmt.Fprintln(w)
+				}
+
+				fmt.Fprintf(w, "NAMESPACE: %s\n", result.Namespace)
+				tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+				fmt.Fprintln(tw, "POD\tCONTAINER\tIMAGE\tCURRENT\tLATEST\tSTATUS")
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				result.Name, result.ContainerName, result.Image,
+				result.CurrentVersion, result.LatestVersion, result.Status())
+		}
+
+		if tw == nil {
+			return nil
+		}
+		return tw.Flush()
+	case OutputJSON:
+		if results == nil {
+			results = []Result{}
+		}
+
+		return writeJSON(w, results)
+	default:
+		return fmt.Errorf("unsupported output %q, must be %s or %s",
+			output, OutputTable, OutputJSON)
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tRESOURCE\tCONTAINER\tIMAGE\tCURRENT\tLATEST\tSTATUS")
+
+	for _, result := range results {
+		resource := result.Kind + "/" + result.Name
+		if len(result.Namespace) > 0 {
+			resource = result.Namespace + "/" + resource
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			result.Source, resource, result.ContainerName, result.Image,
+			result.CurrentVersion, result.LatestVersion, result.Status())
+	}
+
+	return tw.Flush()
+}
+
+// Status returns the status of the result, being latest, outdated, unpinned,
+// or the error checking the image.
+func (r *Result) Status() string {
+	switch {
+	case len(r.Error) > 0:
+		return "error: " + r.Error
+	case r.Unpinned():
+		return "unpinned"
+	case r.IsLatest:
+		return "latest"
+	default:
+		return "outdated"
+	}
+}
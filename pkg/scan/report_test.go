@@ -0,0 +1,45 @@
+package scan
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteNamespaceReport(t *testing.T) {
+	results := []Result{
+		{
+			Container:      Container{Namespace: "prod", Name: "web-2", ContainerName: "nginx", Image: "nginx:1.17.0"},
+			CurrentVersion: "1.17.0",
+			LatestVersion:  "1.19.6",
+		},
+		{
+			Container: Container{Namespace: "dev", Name: "app", ContainerName: "app", Image: "app"},
+			Error:     "not found",
+		},
+		{
+			Container:      Container{Namespace: "prod", Name: "web-1", ContainerName: "nginx", Image: "nginx:1.19.6"},
+			CurrentVersion: "1.19.6",
+			LatestVersion:  "1.19.6",
+			IsLatest:       true,
+		},
+	}
+
+	exp := `NAMESPACE: dev
+POD  CONTAINER  IMAGE  CURRENT  LATEST  STATUS
+app  app        app                     error: not found
+
+NAMESPACE: prod
+POD    CONTAINER  IMAGE         CURRENT  LATEST  STATUS
+web-1  nginx      nginx:1.19.6  1.19.6   1.19.6  latest
+web-2  nginx      nginx:1.17.0  1.17.0   1.19.6  outdated
+`
+
+	var buf bytes.Buffer
+	if err := WriteNamespaceReport(&buf, OutputTable, results); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != exp {
+		t.Errorf("unexpected report, exp=%q got=%q", exp, got)
+	}
+}
@@ -0,0 +1,164 @@
+package scan
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/controller"
+	"github.com/jetstack/version-checker/pkg/version"
+)
+
+// imageScanner finds the latest tags of a batch of images.
+type imageScanner interface {
+	ScanImages(ctx context.Context, images []version.ScanImage, opts version.ScanOptions) []version.ScanResult
+}
+
+// Result is the result of checking the image of a container.
+type Result struct {
+	Container
+
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	LatestVersion  string `json:"latestVersion,omitempty"`
+	IsLatest       bool   `json:"isLatest"`
+
+	// Error is set if the image could not be checked.
+	Error string `json:"error,omitempty"`
+}
+
+// Scanner checks the images of containers against their registries, using
+// the same options as containers of pods.
+type Scanner struct {
+	images            imageScanner
+	excludeTagRegexes []string
+	scanOpts          version.ScanOptions
+}
+
+// New returns a scanner looking up images with the client, caching tags for
+// the cache timeout. The exclude tag regexes are applied to every image, and
+// enrichment requests of all images are limited to the enrichment
+// concurrency, if not zero.
+func New(log *logrus.Entry, client *client.Client, cacheTimeout time.Duration, excludeTagRegexes []string,
+	enrichmentConcurrency int) *Scanner {
+	return &Scanner{
+		images:            version.New(log, client, cacheTimeout, nil),
+		excludeTagRegexes: excludeTagRegexes,
+		scanOpts:          version.ScanOptions{EnrichmentConcurrency: enrichmentConcurrency},
+	}
+}
+
+// Scan will check the image of every container, returning results in the same
+// order. Containers disabled by their enable annotation are skipped. Images
+// using the latest tag, or no tag, can't be compared without the digest of a
+// running image, so are reported as errors.
+func (s *Scanner) Scan(ctx context.Context, containers []Container) []Result {
+	var (
+		results []Result
+		images  []version.ScanImage
+		indexes []int
+	)
+
+	for _, container := range containers {
+		if container.Annotations[api.EnableAnnotationKey+"/"+container.ContainerName] == "false" {
+			continue
+		}
+
+		result := Result{Container: container}
+
+		imageURL, currentVersion, isDigest := splitImage(container.Image)
+		result.CurrentVersion = currentVersion
+
+		opts, err := controller.BuildOptions(container.ContainerName, container.Annotations, s.excludeTagRegexes)
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+		case result.Unpinned():
+			result.Error = `image using "latest" tag, pin a tag or digest to check it`
+		default:
+			if isDigest {
+				opts.UseSHA = true
+			}
+
+			images = append(images, version.ScanImage{ImageURL: imageURL, Options: opts})
+			indexes = append(indexes, len(results))
+		}
+
+		results = append(results, result)
+	}
+
+	for i, scanned := range s.images.ScanImages(ctx, images, s.scanOpts) {
+		result := &results[indexes[i]]
+
+		if scanned.Err != nil {
+			result.Error = scanned.Err.Error()
+			continue
+		}
+
+		result.IsLatest, result.LatestVersion = version.IsLatest(scanned.Options,
+			result.CurrentVersion, scanned.LatestTag)
+	}
+
+	return results
+}
+
+// Check will check the image of the container. Images using the latest tag,
+// or no tag, have no version to compare, so only the latest version is found.
+func (s *Scanner) Check(ctx context.Context, container Container) Result {
+	result := Result{Container: container}
+
+	imageURL, currentVersion, isDigest := splitImage(container.Image)
+	result.CurrentVersion = currentVersion
+
+	opts, err := controller.BuildOptions(container.ContainerName, container.Annotations, s.excludeTagRegexes)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if isDigest {
+		opts.UseSHA = true
+	}
+
+	images := []version.ScanImage{{ImageURL: imageURL, Options: opts}}
+	scanned := s.images.ScanImages(ctx, images, s.scanOpts)[0]
+	if scanned.Err != nil {
+		result.Error = scanned.Err.Error()
+		return result
+	}
+
+	isLatest, latestVersion := version.IsLatest(opts, currentVersion, scanned.LatestTag)
+	result.LatestVersion = latestVersion
+	if !result.Unpinned() {
+		result.IsLatest = isLatest
+	}
+
+	return result
+}
+
+// Unpinned returns true if the image uses the latest tag, or no tag, so has
+// no version to compare with the latest version.
+func (r *Result) Unpinned() bool {
+	return r.CurrentVersion == "" || r.CurrentVersion == "latest"
+}
+
+// splitImage returns the image URL of the image, and its digest if set,
+// otherwise its tag.
+func splitImage(image string) (string, string, bool) {
+	if i := strings.Index(image, "@"); i > -1 {
+		imageURL := image[:i]
+		if j := strings.LastIndex(imageURL, ":"); j > strings.LastIndex(imageURL, "/") {
+			imageURL = imageURL[:j]
+		}
+
+		return imageURL, image[i+1:], true
+	}
+
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:], false
+	}
+
+	return image, "", false
+}
@@ -0,0 +1,200 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version"
+)
+
+// fakeImageScanner returns the latest tags of images by image URL.
+type fakeImageScanner map[string]*api.ImageTag
+
+func (f fakeImageScanner) ScanImages(_ context.Context, images []version.ScanImage, _ version.ScanOptions) []version.ScanResult {
+	results := make([]version.ScanResult, len(images))
+	for i, image := range images {
+		results[i] = version.ScanResult{ScanImage: image, LatestTag: f[image.ImageURL]}
+		if results[i].LatestTag == nil {
+			results[i].Err = errors.New("not found")
+		}
+	}
+
+	return results
+}
+
+// optionsImageScanner records the options of every batch scan.
+type optionsImageScanner struct {
+	fakeImageScanner
+	opts []version.ScanOptions
+}
+
+func (o *optionsImageScanner) ScanImages(ctx context.Context, images []version.ScanImage, opts version.ScanOptions) []version.ScanResult {
+	o.opts = append(o.opts, opts)
+	return o.fakeImageScanner.ScanImages(ctx, images, opts)
+}
+
+func TestScanOptions(t *testing.T) {
+	images := &optionsImageScanner{fakeImageScanner: fakeImageScanner{"nginx": {Tag: "1.19.6"}}}
+	scanner := &Scanner{images: images, scanOpts: version.ScanOptions{EnrichmentConcurrency: 4}}
+
+	container := Container{ContainerName: "nginx", Image: "nginx:1.19.6"}
+	scanner.Scan(context.TODO(), []Container{container})
+	scanner.Check(context.TODO(), container)
+
+	exp := []version.ScanOptions{{EnrichmentConcurrency: 4}, {EnrichmentConcurrency: 4}}
+	if !reflect.DeepEqual(images.opts, exp) {
+		t.Errorf("unexpected scan options, exp=%+v got=%+v", exp, images.opts)
+	}
+}
+
+func TestScan(t *testing.T) {
+	scanner := &Scanner{images: fakeImageScanner{
+		"nginx":                   {Tag: "1.19.6", SHA: "sha256:b"},
+		"quay.io/jetstack/backup": {Tag: "v0.2.0", SHA: "sha256:d"},
+		"localhost:5000/app":      {Tag: "v2.0.0", SHA: "sha256:f"},
+	}}
+
+	tests := map[string]struct {
+		container Container
+		exp       *Result
+	}{
+		"outdated tag should not be latest": {
+			container: Container{ContainerName: "backup", Image: "quay.io/jetstack/backup:v0.1.0"},
+			exp: &Result{
+				CurrentVersion: "v0.1.0",
+				LatestVersion:  "v0.2.0",
+			},
+		},
+		"latest tag should be latest": {
+			container: Container{ContainerName: "nginx", Image: "nginx:1.19.6"},
+			exp: &Result{
+				CurrentVersion: "1.19.6",
+				LatestVersion:  "1.19.6",
+				IsLatest:       true,
+			},
+		},
+		"registry ports should not be taken as tags": {
+			container: Container{ContainerName: "app", Image: "localhost:5000/app:v2.0.0"},
+			exp: &Result{
+				CurrentVersion: "v2.0.0",
+				LatestVersion:  "v2.0.0",
+				IsLatest:       true,
+			},
+		},
+		"digests should be compared against the latest digest": {
+			container: Container{ContainerName: "nginx", Image: "nginx:1.19.6@sha256:a"},
+			exp: &Result{
+				CurrentVersion: "sha256:a",
+				LatestVersion:  "sha256:b",
+			},
+		},
+		"images using the latest tag should error": {
+			container: Container{ContainerName: "nginx", Image: "nginx"},
+			exp: &Result{
+				Error: `image using "latest" tag, pin a tag or digest to check it`,
+			},
+		},
+		"invalid annotations should error": {
+			container: Container{ContainerName: "nginx", Image: "nginx:1.19.6",
+				Annotations: map[string]string{"pin-minor.version-checker.io/nginx": "19"}},
+			exp: &Result{
+				CurrentVersion: "1.19.6",
+				Error: `failed to build version options: unable to set "pin-minor.version-checker.io/nginx" ` +
+					`without setting "pin-major.version-checker.io/nginx"`,
+			},
+		},
+		"lookup errors should be reported": {
+			container: Container{ContainerName: "app", Image: "quay.io/jetstack/app:v1.0.0"},
+			exp: &Result{
+				CurrentVersion: "v1.0.0",
+				Error:          "not found",
+			},
+		},
+		"disabled containers should be skipped": {
+			container: Container{ContainerName: "nginx", Image: "nginx:1.19.6",
+				Annotations: map[string]string{"enable.version-checker.io/nginx": "false"}},
+			exp: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			results := scanner.Scan(context.TODO(), []Container{test.container})
+
+			var exp []Result
+			if test.exp != nil {
+				test.exp.Container = test.container
+				exp = []Result{*test.exp}
+			}
+
+			if !reflect.DeepEqual(results, exp) {
+				t.Errorf("unexpected results, exp=%+v got=%+v", exp, results)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	scanner := &Scanner{images: fakeImageScanner{
+		"nginx": {Tag: "1.19.6", SHA: "sha256:b"},
+	}}
+
+	tests := map[string]struct {
+		image       string
+		annotations map[string]string
+		exp         Result
+	}{
+		"outdated tag should not be latest": {
+			image: "nginx:1.17.0",
+			exp: Result{
+				CurrentVersion: "1.17.0",
+				LatestVersion:  "1.19.6",
+			},
+		},
+		"latest tag should be latest": {
+			image: "nginx:1.19.6",
+			exp: Result{
+				CurrentVersion: "1.19.6",
+				LatestVersion:  "1.19.6",
+				IsLatest:       true,
+			},
+		},
+		"untagged images should only find the latest version": {
+			image: "nginx",
+			exp: Result{
+				LatestVersion: "1.19.6",
+			},
+		},
+		"untagged images using SHA should find the latest digest": {
+			image:       "nginx:latest",
+			annotations: map[string]string{"use-sha.version-checker.io/image": "true"},
+			exp: Result{
+				CurrentVersion: "latest",
+				LatestVersion:  "sha256:b",
+			},
+		},
+		"invalid options should error": {
+			image:       "nginx:1.19.6",
+			annotations: map[string]string{"tag-ordering.version-checker.io/image": "random"},
+			exp: Result{
+				CurrentVersion: "1.19.6",
+				Error: `failed to build version options: unknown tag ordering "random" at annotation ` +
+					`"tag-ordering.version-checker.io/image", must be "semver", "debian", "calver", "numeric" or "lexical"`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			container := Container{ContainerName: "image", Image: test.image, Annotations: test.annotations}
+			test.exp.Container = container
+
+			if result := scanner.Check(context.TODO(), container); !reflect.DeepEqual(result, test.exp) {
+				t.Errorf("unexpected result, exp=%+v got=%+v", test.exp, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+var (
+	// defaultNumericBuildID captures the last number of a tag, such as 1041 of
+	// build-1041.
+	defaultNumericBuildID = regexp.MustCompile(`([0-9]+)[^0-9]*$`)
+
+	// defaultLexicalBuildID captures the whole tag.
+	defaultLexicalBuildID = regexp.MustCompile(`^(.*)$`)
+)
+
+// buildID returns the build ID of the tag, captured by the first capture
+// group of the build ID regex of the options, or the default of the tag
+// ordering. Returns false if the tag does not match, or a numeric build ID is
+// not a number.
+func buildID(opts *api.Options, tag string) (string, bool) {
+	matcher := opts.BuildIDMatcher
+	if matcher == nil {
+		matcher = defaultLexicalBuildID
+		if opts.TagOrdering == api.TagOrderingNumeric {
+			matcher = defaultNumericBuildID
+		}
+	}
+
+	match := matcher.FindStringSubmatch(tag)
+	if len(match) < 2 || len(match[1]) == 0 {
+		return "", false
+	}
+
+	id := match[1]
+	if opts.TagOrdering == api.TagOrderingNumeric {
+		for i := 0; i < len(id); i++ {
+			if !isDigit(id[i]) {
+				return "", false
+			}
+		}
+	}
+
+	return id, true
+}
+
+// compareBuildID returns -1, 0 or 1 if build ID a is lower than, equal to or
+// higher than b. Numeric build IDs are compared numerically, of any length,
+// otherwise lexically.
+func compareBuildID(ordering api.TagOrdering, a, b string) int {
+	if ordering == api.TagOrderingNumeric {
+		return compareDebianNumeric(a, b)
+	}
+
+	return strings.Compare(a, b)
+}
+
+// latestBuildID will return the latest ImageTag based on the given options
+// restriction, ordering tags by the build ID captured from each tag. Tags
+// without a build ID are ignored, as are those not matching the regex, if
+// set.
+func latestBuildID(opts *api.Options, tags []api.ImageTag) (*api.ImageTag, error) {
+	var (
+		latestImageTag *api.ImageTag
+		latestID       string
+	)
+
+	for i := range tags {
+		if opts.RegexMatcher != nil && !opts.RegexMatcher.MatchString(tags[i].Tag) {
+			continue
+		}
+
+		id, ok := buildID(opts, tags[i].Tag)
+		if !ok {
+			continue
+		}
+
+		if latestImageTag == nil {
+			latestID, latestImageTag = id, &tags[i]
+			continue
+		}
+
+		c := compareBuildID(opts.TagOrdering, latestID, id)
+		if c < 0 || (c == 0 && isNewerTimestamp(latestImageTag, &tags[i])) {
+			latestID, latestImageTag = id, &tags[i]
+		}
+	}
+
+	if latestImageTag == nil {
+		return nil, fmt.Errorf("no tag found with those option constraints: %+v", opts)
+	}
+
+	return latestImageTag, nil
+}
@@ -0,0 +1,84 @@
+package version
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestLatestBuildID(t *testing.T) {
+	tests := map[string]struct {
+		ordering api.TagOrdering
+		regex    string
+		tags     []string
+		expTag   string
+		expErr   bool
+	}{
+		"numeric should compare the last number numerically": {
+			ordering: api.TagOrderingNumeric,
+			tags:     []string{"build-999", "build-1041", "build-1040", "latest"},
+			expTag:   "build-1041",
+		},
+		"numeric should compare long build numbers": {
+			ordering: api.TagOrderingNumeric,
+			tags:     []string{"99999999999999999999", "100000000000000000000"},
+			expTag:   "100000000000000000000",
+		},
+		"capture group should select the build number": {
+			ordering: api.TagOrderingNumeric,
+			regex:    `^([0-9]+)-app-[0-9]+$`,
+			tags:     []string{"12-app-9", "9-app-99", "13-app-1", "14-other-1"},
+			expTag:   "13-app-1",
+		},
+		"non numeric capture should be ignored by numeric": {
+			ordering: api.TagOrderingNumeric,
+			regex:    `^build-(.*)$`,
+			tags:     []string{"build-12", "build-abc"},
+			expTag:   "build-12",
+		},
+		"lexical should compare the whole tag": {
+			ordering: api.TagOrderingLexical,
+			tags:     []string{"r-a", "r-c", "r-b"},
+			expTag:   "r-c",
+		},
+		"lexical should compare the capture": {
+			ordering: api.TagOrderingLexical,
+			regex:    `^[a-z]+-([0-9a-f]+)$`,
+			tags:     []string{"z-0a", "a-0f", "m-01"},
+			expTag:   "a-0f",
+		},
+		"no build ids should error": {
+			ordering: api.TagOrderingNumeric,
+			tags:     []string{"latest", "stable"},
+			expErr:   true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := &api.Options{TagOrdering: test.ordering}
+			if len(test.regex) > 0 {
+				opts.BuildIDRegex = &test.regex
+				opts.BuildIDMatcher = regexp.MustCompile(test.regex)
+			}
+
+			var tags []api.ImageTag
+			for _, tag := range test.tags {
+				tags = append(tags, api.ImageTag{Tag: tag})
+			}
+
+			latest, err := latestBuildID(opts, tags)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if latest.Tag != test.expTag {
+				t.Errorf("unexpected latest tag, exp=%s got=%s", test.expTag, latest.Tag)
+			}
+		})
+	}
+}
@@ -1,76 +1,116 @@
 package version
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"hash/fnv"
 	"time"
 
 	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
 )
 
-// imageCache is used to store a cache of all remote images for a given
-// imageURL. This cache is periodically garbage collected.
+// imageCacheKeyPrefix is the prefix of the cache keys of image tags, so that
+// the cache may be shared with other data.
+const imageCacheKeyPrefix = "tags:"
+
+type cacheTimeoutKey struct{}
+
+// imageCacheItem is the cached tags of an image, with when they were fetched
+// so that lookups with a shorter cache timeout can treat them as stale.
 type imageCacheItem struct {
-	timestamp time.Time
-	tags      []api.ImageTag
+	Timestamp time.Time      `json:"timestamp"`
+	Tags      []api.ImageTag `json:"tags"`
+}
+
+// WithCacheTimeout returns a context with the cache timeout of the image tags
+// of lookups, in place of the VersionGetter's cache timeout.
+func WithCacheTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, cacheTimeoutKey{}, timeout)
 }
 
-// CalculateHashIndex returns a hash index given an imageURL and options.
+// contextCacheTimeout returns the cache timeout of the context, or the
+// VersionGetter's cache timeout if it has none.
+func (v *VersionGetter) contextCacheTimeout(ctx context.Context) time.Duration {
+	if timeout, ok := ctx.Value(cacheTimeoutKey{}).(time.Duration); ok {
+		return timeout
+	}
+	return v.cacheTimeout
+}
+
+// CalculateHashIndex returns a hash index given an imageURL and options. All
+// options which affect the search result are included, so that different
+// searches against the same image never share an index.
 func CalculateHashIndex(imageURL string, opts *api.Options) (string, error) {
 	opsJson, err := json.Marshal(opts)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal options: %s", err)
 	}
 
-	hash := fnv.New32()
-	if _, err := hash.Write(append(opsJson, []byte(imageURL)...)); err != nil {
+	hash := sha256.New()
+	// Separate the options from the image URL so the two can't run into
+	// each other.
+	if _, err := hash.Write(append(append(opsJson, 0), []byte(imageURL)...)); err != nil {
 		return "", fmt.Errorf("failed to calculate search hash: %s", err)
 	}
 
-	return fmt.Sprintf("%d", hash.Sum32()), nil
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// tryImageCache return an imageCacheItem item and true if their is a cache hit
-// on the given image URL. Deletes the item, and returns nil and false if there
-// is a miss.
-func (v *VersionGetter) tryImageCache(imageURL string) ([]api.ImageTag, bool) {
-	v.cacheMu.RLock()
-	defer v.cacheMu.RUnlock()
+// imageCacheKey returns the cache key of the tags of the image URL looked up
+// with the credentials of the context, so that tags listed with the
+// credentials of one pod are never served to pods without them.
+func imageCacheKey(ctx context.Context, imageURL string) string {
+	return imageCacheKeyPrefix + imageURL + util.CredentialsKey(ctx)
+}
 
-	if imageCacheItem, ok := v.imageCache[imageURL]; ok &&
-		!imageCacheItem.timestamp.Add(v.cacheTimeout).Before(time.Now()) {
+// tryImageCache returns the cached tags of the given image URL and true if
+// there is a cache hit fresh within the cache timeout of the context. Cache
+// errors are logged and treated as a miss, so that
+// lookups continue against the registry.
+func (v *VersionGetter) tryImageCache(ctx context.Context, imageURL string) ([]api.ImageTag, bool) {
+	log := v.log.WithField("cache", "getter")
 
-		v.log.WithField("cache", "getter").Debugf(
-			"found image tags: %q", imageURL)
+	data, ok, err := v.cache.Get(ctx, imageCacheKey(ctx, imageURL))
+	if err != nil {
+		log.Errorf("failed to get image tags %q from cache: %s", imageURL, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
 
-		return imageCacheItem.tags, true
+	var item imageCacheItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		log.Errorf("failed to decode cached image tags %q: %s", imageURL, err)
+		return nil, false
 	}
 
-	return nil, false
-}
+	if item.Timestamp.Add(v.contextCacheTimeout(ctx)).Before(time.Now()) {
+		return nil, false
+	}
 
-// garbageCollect is a blocking func that will run the garbage collector
-// against the images tag cache.
-func (v *VersionGetter) garbageCollect(refreshRate time.Duration) {
-	log := v.log.WithField("cache", "garbage_collector")
-	log.Infof("starting image tags cache garbage collector")
-	ticker := time.NewTicker(refreshRate)
+	log.Debugf("found image tags: %q", imageURL)
 
-	for {
-		<-ticker.C
+	return item.Tags, true
+}
 
-		v.cacheMu.Lock()
+// commitImageCache will store the tags of the image URL in the cache for the
+// cache timeout of the context. Cache errors are logged.
+func (v *VersionGetter) commitImageCache(ctx context.Context, imageURL string, tags []api.ImageTag) {
+	log := v.log.WithField("cache", "getter")
 
-		now := time.Now()
-		for imageURL, cacheItem := range v.imageCache {
-			if cacheItem.timestamp.Add(v.cacheTimeout).Before(now) {
+	data, err := json.Marshal(imageCacheItem{Timestamp: time.Now(), Tags: tags})
+	if err != nil {
+		log.Errorf("failed to encode image tags %q: %s", imageURL, err)
+		return
+	}
 
-				log.Debugf("removing stale image tags: %q", imageURL)
-				delete(v.imageCache, imageURL)
-			}
-		}
+	log.Debugf("committing image tags: %q", imageURL)
 
-		v.cacheMu.Unlock()
+	if err := v.cache.Set(ctx, imageCacheKey(ctx, imageURL), data, v.contextCacheTimeout(ctx)); err != nil {
+		log.Errorf("failed to commit image tags %q to cache: %s", imageURL, err)
 	}
 }
@@ -0,0 +1,99 @@
+package version
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/cache"
+)
+
+func TestCalculateHashIndex(t *testing.T) {
+	regexOpts := func(expr string) *api.Options {
+		return &api.Options{
+			MatchRegex:   &expr,
+			RegexMatcher: regexp.MustCompile(expr),
+		}
+	}
+	int64p := func(i int64) *int64 { return &i }
+
+	tests := map[string]struct {
+		imageURLA, imageURLB string
+		optsA, optsB         *api.Options
+		expEqual             bool
+	}{
+		"same image and options should share an index": {
+			"quay.io/jetstack/version-checker", "quay.io/jetstack/version-checker",
+			regexOpts(`^v\d+$`), regexOpts(`^v\d+$`),
+			true,
+		},
+		"different images should not share an index": {
+			"quay.io/jetstack/version-checker", "quay.io/jetstack/cert-manager",
+			new(api.Options), new(api.Options),
+			false,
+		},
+		"different regex should not share an index": {
+			"quay.io/jetstack/version-checker", "quay.io/jetstack/version-checker",
+			regexOpts(`^v\d+$`), regexOpts(`^v\d+-debian$`),
+			false,
+		},
+		"regex and no regex should not share an index": {
+			"quay.io/jetstack/version-checker", "quay.io/jetstack/version-checker",
+			regexOpts(`.*`), new(api.Options),
+			false,
+		},
+		"different pins should not share an index": {
+			"quay.io/jetstack/version-checker", "quay.io/jetstack/version-checker",
+			&api.Options{PinMajor: int64p(1)}, &api.Options{PinMajor: int64p(2)},
+			false,
+		},
+		"metadata and no metadata should not share an index": {
+			"quay.io/jetstack/version-checker", "quay.io/jetstack/version-checker",
+			&api.Options{UseMetaData: true}, new(api.Options),
+			false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, err := CalculateHashIndex(test.imageURLA, test.optsA)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := CalculateHashIndex(test.imageURLB, test.optsB)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (a == b) != test.expEqual {
+				t.Errorf("unexpected index equality, exp=%t a=%s b=%s",
+					test.expEqual, a, b)
+			}
+		})
+	}
+}
+
+func TestImageCacheTimeout(t *testing.T) {
+	v := &VersionGetter{
+		log:          logrus.NewEntry(logrus.New()),
+		cache:        cache.NewMemory(),
+		cacheTimeout: time.Hour,
+	}
+
+	ctx := context.TODO()
+	v.commitImageCache(ctx, "quay.io/jetstack/version-checker", []api.ImageTag{{Tag: "v0.1.0"}})
+	time.Sleep(time.Millisecond * 20)
+
+	if tags, ok := v.tryImageCache(ctx, "quay.io/jetstack/version-checker"); !ok || len(tags) != 1 {
+		t.Errorf("expected cache hit within default timeout, got=%+v %t", tags, ok)
+	}
+
+	ctx = WithCacheTimeout(ctx, time.Millisecond*10)
+	if _, ok := v.tryImageCache(ctx, "quay.io/jetstack/version-checker"); ok {
+		t.Error("expected cache miss of tags older than the context cache timeout")
+	}
+}
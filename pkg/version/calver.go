@@ -0,0 +1,172 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+var (
+	calVerRegex = regexp.MustCompile(`^v?([0-9]+)((?:\.[0-9]+)*)(.*)$`)
+)
+
+// CalVer is a calendar version, such as 2024.06.2, 24.04 or 20240611. The
+// year is always the first part, with two digit years in the 2000s. Packed
+// dates, such as 20240611 or 202406111230, are split into their year, month,
+// day and time parts.
+type CalVer struct {
+	// Parts are the numeric parts of the version, year first.
+	Parts []int64
+
+	// Modifier is anything following the numeric parts, such as -alpine.
+	Modifier string
+}
+
+// ParseCalVer will parse a calendar version. Returns false if the tag does
+// not start with a year, or a packed date, of a plausible value.
+func ParseCalVer(tag string) (*CalVer, bool) {
+	match := calVerRegex.FindStringSubmatch(tag)
+	if len(match) == 0 {
+		return nil, false
+	}
+
+	// The modifier must be separated from the numeric parts.
+	if len(match[3]) > 0 && !strings.ContainsRune("-+_", rune(match[3][0])) {
+		return nil, false
+	}
+
+	v := &CalVer{Modifier: match[3]}
+
+	first := match[1]
+	switch len(first) {
+	case 2, 4:
+		year, _ := strconv.ParseInt(first, 10, 64)
+		if len(first) == 2 {
+			year += 2000
+		}
+		v.Parts = []int64{year}
+
+	case 6, 8, 10, 12, 14:
+		// Packed dates are the year, followed by two digit parts.
+		year, _ := strconv.ParseInt(first[:4], 10, 64)
+		v.Parts = []int64{year}
+		for i := 4; i < len(first); i += 2 {
+			part, _ := strconv.ParseInt(first[i:i+2], 10, 64)
+			v.Parts = append(v.Parts, part)
+		}
+
+		if v.Parts[1] < 1 || v.Parts[1] > 12 {
+			return nil, false
+		}
+		if len(v.Parts) > 2 && (v.Parts[2] < 1 || v.Parts[2] > 31) {
+			return nil, false
+		}
+
+	default:
+		return nil, false
+	}
+
+	if v.Parts[0] < 1970 || v.Parts[0] > 2999 {
+		return nil, false
+	}
+
+	for _, part := range strings.Split(match[2], ".")[1:] {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		v.Parts = append(v.Parts, n)
+	}
+
+	return v, true
+}
+
+// Compare returns -1, 0 or 1 if this version is lower than, equal to or
+// higher than the other. Parts are compared numerically in order, where a
+// version with extra parts is higher. Modifiers are not compared.
+func (v *CalVer) Compare(other *CalVer) int {
+	for i := 0; i < len(v.Parts) || i < len(other.Parts); i++ {
+		if i >= len(v.Parts) {
+			return -1
+		}
+		if i >= len(other.Parts) {
+			return 1
+		}
+
+		if v.Parts[i] != other.Parts[i] {
+			if v.Parts[i] < other.Parts[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// part returns the numeric part at the index, or zero if the version has no
+// such part.
+func (v *CalVer) part(i int) int64 {
+	if i < len(v.Parts) {
+		return v.Parts[i]
+	}
+	return 0
+}
+
+// latestCalVer will return the latest ImageTag based on the given options
+// restriction, using calendar version ordering. Tags which are not calendar
+// versions are ignored. Pins apply to the year, then the following parts, and
+// tags with a modifier are only considered if UseMetaData is set. A regex
+// applies to the whole tag in place of pins and metadata restrictions.
+func latestCalVer(opts *api.Options, tags []api.ImageTag) (*api.ImageTag, error) {
+	var (
+		latestImageTag *api.ImageTag
+		latestV        *CalVer
+	)
+
+	for i := range tags {
+		v, ok := ParseCalVer(tags[i].Tag)
+		if !ok || !calVerMatches(opts, tags[i].Tag, v) {
+			continue
+		}
+
+		if latestV == nil {
+			latestV, latestImageTag = v, &tags[i]
+			continue
+		}
+
+		c := latestV.Compare(v)
+		if c < 0 || (c == 0 && isNewerTimestamp(latestImageTag, &tags[i])) {
+			latestV, latestImageTag = v, &tags[i]
+		}
+	}
+
+	if latestImageTag == nil {
+		return nil, fmt.Errorf("no tag found with those option constraints: %+v", opts)
+	}
+
+	return latestImageTag, nil
+}
+
+// calVerMatches returns true if the calendar version of the tag satisfies
+// the restrictions of the options.
+func calVerMatches(opts *api.Options, tag string, v *CalVer) bool {
+	if opts.RegexMatcher != nil {
+		return opts.RegexMatcher.MatchString(tag)
+	}
+
+	if !opts.UseMetaData && len(v.Modifier) > 0 {
+		return false
+	}
+
+	for i, pin := range []*int64{opts.PinMajor, opts.PinMinor, opts.PinPatch} {
+		if pin != nil && *pin != v.part(i) {
+			return false
+		}
+	}
+
+	return true
+}
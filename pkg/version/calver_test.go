@@ -0,0 +1,143 @@
+package version
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestParseCalVer(t *testing.T) {
+	tests := map[string]struct {
+		exp   *CalVer
+		expOK bool
+	}{
+		"2024.06.2":        {&CalVer{Parts: []int64{2024, 6, 2}}, true},
+		"v2024.06.2":       {&CalVer{Parts: []int64{2024, 6, 2}}, true},
+		"24.04":            {&CalVer{Parts: []int64{2024, 4}}, true},
+		"2024":             {&CalVer{Parts: []int64{2024}}, true},
+		"20240611":         {&CalVer{Parts: []int64{2024, 6, 11}}, true},
+		"202406":           {&CalVer{Parts: []int64{2024, 6}}, true},
+		"202406111230":     {&CalVer{Parts: []int64{2024, 6, 11, 12, 30}}, true},
+		"20240611.3":       {&CalVer{Parts: []int64{2024, 6, 11, 3}}, true},
+		"2024.06.2-alpine": {&CalVer{Parts: []int64{2024, 6, 2}, Modifier: "-alpine"}, true},
+		"latest":           {nil, false},
+		"1.2.3":            {nil, false},
+		"20241311":         {nil, false},
+		"20240632":         {nil, false},
+		"1024.01":          {nil, false},
+		"2024.06.2rc1":     {nil, false},
+		"2024.06.x":        {nil, false},
+	}
+
+	for tag, test := range tests {
+		t.Run(tag, func(t *testing.T) {
+			v, ok := ParseCalVer(tag)
+			if ok != test.expOK {
+				t.Fatalf("unexpected ok, exp=%t got=%t", test.expOK, ok)
+			}
+			if !ok {
+				return
+			}
+
+			if !reflect.DeepEqual(v, test.exp) {
+				t.Errorf("unexpected version, exp=%+v got=%+v", test.exp, v)
+			}
+		})
+	}
+}
+
+func TestCalVerCompare(t *testing.T) {
+	tests := map[string]struct {
+		a, b string
+		exp  int
+	}{
+		"equal versions":                      {"2024.06.2", "2024.06.2", 0},
+		"leading zeros are ignored":           {"2024.06.2", "2024.6.2", 0},
+		"months are compared numerically":     {"2024.10.1", "2024.9.1", 1},
+		"year dominates":                      {"2025.01.0", "2024.12.9", 1},
+		"extra parts are higher":              {"2024.06.1", "2024.06", 1},
+		"packed dates compare with dotted":    {"20240611", "2024.06.2", 1},
+		"short years compare with long years": {"24.04", "2023.10", 1},
+		"modifiers are not compared":          {"2024.06.2-alpine", "2024.06.2", 0},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, ok := ParseCalVer(test.a)
+			if !ok {
+				t.Fatalf("failed to parse %q", test.a)
+			}
+			b, ok := ParseCalVer(test.b)
+			if !ok {
+				t.Fatalf("failed to parse %q", test.b)
+			}
+
+			if got := a.Compare(b); got != test.exp {
+				t.Errorf("unexpected compare of %s to %s, exp=%d got=%d", test.a, test.b, test.exp, got)
+			}
+			if got := b.Compare(a); got != -test.exp {
+				t.Errorf("unexpected compare of %s to %s, exp=%d got=%d", test.b, test.a, -test.exp, got)
+			}
+		})
+	}
+}
+
+func TestLatestCalVer(t *testing.T) {
+	pinYear := int64(2023)
+
+	tests := map[string]struct {
+		opts   api.Options
+		tags   []string
+		expTag string
+	}{
+		"months should be ordered numerically": {
+			opts:   api.Options{TagOrdering: api.TagOrderingCalVer},
+			tags:   []string{"2024.9.1", "2024.10.0", "2024.2.5"},
+			expTag: "2024.10.0",
+		},
+		"packed dates should be ordered": {
+			opts:   api.Options{TagOrdering: api.TagOrderingCalVer},
+			tags:   []string{"20240611", "20231231", "20240102"},
+			expTag: "20240611",
+		},
+		"non calver tags should be ignored": {
+			opts:   api.Options{TagOrdering: api.TagOrderingCalVer},
+			tags:   []string{"latest", "2024.06.2", "stable", "1.2.3"},
+			expTag: "2024.06.2",
+		},
+		"modifiers should be ignored without use metadata": {
+			opts:   api.Options{TagOrdering: api.TagOrderingCalVer},
+			tags:   []string{"2024.06.2", "2024.07.0-rc1"},
+			expTag: "2024.06.2",
+		},
+		"modifiers should be considered with use metadata": {
+			opts:   api.Options{TagOrdering: api.TagOrderingCalVer, UseMetaData: true},
+			tags:   []string{"2024.06.2", "2024.07.0-rc1"},
+			expTag: "2024.07.0-rc1",
+		},
+		"pins should apply to the year": {
+			opts:   api.Options{TagOrdering: api.TagOrderingCalVer, PinMajor: &pinYear},
+			tags:   []string{"2023.11.0", "2023.12.4", "2024.01.0"},
+			expTag: "2023.12.4",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var tags []api.ImageTag
+			for _, tag := range test.tags {
+				tags = append(tags, api.ImageTag{Tag: tag})
+			}
+
+			latest, err := latestCalVer(&test.opts, tags)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if latest.Tag != test.expTag {
+				t.Errorf("unexpected latest tag, exp=%s got=%s", test.expTag, latest.Tag)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+// DebianVersion is a Debian style version of the form
+// [epoch:]upstream_version[-debian_revision].
+type DebianVersion struct {
+	Epoch    int64
+	Upstream string
+	Revision string
+}
+
+// ParseDebian will parse a Debian style version. Returns false if the tag is
+// not a valid Debian version, such as if the upstream version does not start
+// with a digit.
+func ParseDebian(tag string) (*DebianVersion, bool) {
+	var v DebianVersion
+
+	if i := strings.Index(tag, ":"); i > -1 {
+		epoch, err := strconv.ParseInt(tag[:i], 10, 64)
+		if err != nil || epoch < 0 {
+			return nil, false
+		}
+		v.Epoch = epoch
+		tag = tag[i+1:]
+	}
+
+	v.Upstream = tag
+	if i := strings.LastIndex(tag, "-"); i > -1 {
+		v.Upstream, v.Revision = tag[:i], tag[i+1:]
+		if len(v.Revision) == 0 {
+			return nil, false
+		}
+	}
+
+	if len(v.Upstream) == 0 || !isDigit(v.Upstream[0]) {
+		return nil, false
+	}
+
+	for _, part := range []string{v.Upstream, v.Revision} {
+		for i := 0; i < len(part); i++ {
+			c := part[i]
+			if !isDigit(c) && !isLetter(c) && !strings.ContainsRune(".+~-_", rune(c)) {
+				return nil, false
+			}
+		}
+	}
+
+	return &v, true
+}
+
+// Compare returns -1, 0 or 1 if this version is lower than, equal to or
+// higher than the other. The epoch dominates, then the upstream version, then
+// the revision, each compared using the dpkg algorithm.
+func (v *DebianVersion) Compare(other *DebianVersion) int {
+	if v.Epoch != other.Epoch {
+		if v.Epoch < other.Epoch {
+			return -1
+		}
+		return 1
+	}
+
+	if c := compareDebianPart(v.Upstream, other.Upstream); c != 0 {
+		return c
+	}
+
+	return compareDebianPart(v.Revision, other.Revision)
+}
+
+func (v *DebianVersion) String() string {
+	s := v.Upstream
+	if v.Epoch > 0 {
+		s = fmt.Sprintf("%d:%s", v.Epoch, s)
+	}
+	if len(v.Revision) > 0 {
+		s += "-" + v.Revision
+	}
+	return s
+}
+
+// compareDebianPart compares an upstream version or revision by alternating
+// between comparing leading non-digits lexically, and leading digits
+// numerically.
+func compareDebianPart(a, b string) int {
+	for len(a) > 0 || len(b) > 0 {
+		var aStr, bStr string
+		aStr, a = splitDebianPrefix(a, false)
+		bStr, b = splitDebianPrefix(b, false)
+		if c := compareDebianLexical(aStr, bStr); c != 0 {
+			return c
+		}
+
+		aStr, a = splitDebianPrefix(a, true)
+		bStr, b = splitDebianPrefix(b, true)
+		if c := compareDebianNumeric(aStr, bStr); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// splitDebianPrefix splits the leading digits, or non-digits, from s.
+func splitDebianPrefix(s string, digits bool) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareDebianLexical compares non-digit strings, where letters sort before
+// non-letters and '~' sorts before everything, even the end of the string.
+func compareDebianLexical(a, b string) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var ac, bc int
+		if i < len(a) {
+			ac = debianOrder(a[i])
+		}
+		if i < len(b) {
+			bc = debianOrder(b[i])
+		}
+
+		if ac != bc {
+			if ac < bc {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func debianOrder(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case isLetter(c):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+// compareDebianNumeric compares digit strings numerically, where an empty
+// string is zero.
+func compareDebianNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// latestDebian will return the latest ImageTag based on the given options
+// restriction, using Debian version ordering. Tags which are not Debian
+// versions are ignored. Pins and metadata restrictions apply to the upstream
+// version, and a regex to the whole tag.
+func latestDebian(opts *api.Options, tags []api.ImageTag) (*api.ImageTag, error) {
+	var (
+		latestImageTag *api.ImageTag
+		latestV        *DebianVersion
+	)
+
+	for i := range tags {
+		v, ok := ParseDebian(tags[i].Tag)
+		if !ok {
+			continue
+		}
+
+		match := v.Upstream
+		if opts.RegexMatcher != nil {
+			match = tags[i].Tag
+		}
+		if !opts.Matches(match) {
+			continue
+		}
+
+		if latestV == nil {
+			latestV, latestImageTag = v, &tags[i]
+			continue
+		}
+
+		c := latestV.Compare(v)
+		if c < 0 || (c == 0 && isNewerTimestamp(latestImageTag, &tags[i])) {
+			latestV, latestImageTag = v, &tags[i]
+		}
+	}
+
+	if latestImageTag == nil {
+		return nil, fmt.Errorf("no tag found with those option constraints: %+v", opts)
+	}
+
+	return latestImageTag, nil
+}
@@ -0,0 +1,145 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/jetstack/version-checker/pkg/api"
+)
+
+func TestParseDebian(t *testing.T) {
+	tests := map[string]struct {
+		exp   *DebianVersion
+		expOK bool
+	}{
+		"1.2.3":         {&DebianVersion{Upstream: "1.2.3"}, true},
+		"1.2.3-1":       {&DebianVersion{Upstream: "1.2.3", Revision: "1"}, true},
+		"1:1.2.3":       {&DebianVersion{Epoch: 1, Upstream: "1.2.3"}, true},
+		"2:1.2.3-4-5":   {&DebianVersion{Epoch: 2, Upstream: "1.2.3-4", Revision: "5"}, true},
+		"1.2.3~rc1-0.1": {&DebianVersion{Upstream: "1.2.3~rc1", Revision: "0.1"}, true},
+		"latest":        {nil, false},
+		"v1.2.3":        {nil, false},
+		"a:1.2.3":       {nil, false},
+		"1.2.3-":        {nil, false},
+		"1.2.3!":        {nil, false},
+	}
+
+	for tag, test := range tests {
+		t.Run(tag, func(t *testing.T) {
+			v, ok := ParseDebian(tag)
+			if ok != test.expOK {
+				t.Fatalf("unexpected ok, exp=%t got=%t", test.expOK, ok)
+			}
+			if !ok {
+				return
+			}
+
+			if *v != *test.exp {
+				t.Errorf("unexpected version, exp=%+v got=%+v", test.exp, v)
+			}
+			if v.String() != tag {
+				t.Errorf("unexpected string, exp=%s got=%s", tag, v.String())
+			}
+		})
+	}
+}
+
+func TestDebianCompare(t *testing.T) {
+	tests := map[string]struct {
+		a, b string
+		exp  int
+	}{
+		"equal versions":                        {"1.2.3-1", "1.2.3-1", 0},
+		"missing epoch is zero":                 {"0:1.2.3", "1.2.3", 0},
+		"leading zeros are ignored":             {"1.02.3", "1.2.3", 0},
+		"missing revision is zero":              {"1.2.3", "1.2.3-0", 0},
+		"epoch dominates upstream":              {"1:1.0.0", "2.0.0", 1},
+		"epoch dominates lower":                 {"1:9.9.9", "2:0.1", -1},
+		"upstream is compared numerically":      {"1.10.0", "1.9.0", 1},
+		"upstream dominates revision":           {"1.2.3-9", "1.2.4-1", -1},
+		"revision is compared numerically":      {"1.2.3-10", "1.2.3-9", 1},
+		"revision is present":                   {"1.2.3-1", "1.2.3", 1},
+		"tilde sorts before release":            {"1.2.3~rc1", "1.2.3", -1},
+		"tilde sorts before tilde":              {"1.2.3~~", "1.2.3~", -1},
+		"tilde ordered by suffix":               {"1.2.3~rc1", "1.2.3~rc2", -1},
+		"letters sort before non-letters":       {"1.2a", "1.2+", -1},
+		"suffix sorts after release":            {"1.2.3+deb10u1", "1.2.3", 1},
+		"security update after point release":   {"1.2.3-1+deb10u2", "1.2.3-1+deb10u1", 1},
+		"ubuntu revision ordered numerically":   {"1.2.3-0ubuntu10", "1.2.3-0ubuntu9", 1},
+		"revision with dots":                    {"1.2.3-1.1", "1.2.3-1", 1},
+		"hyphenated upstream ordered correctly": {"1.2-3-1", "1.2-10-1", -1},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, ok := ParseDebian(test.a)
+			if !ok {
+				t.Fatalf("failed to parse %q", test.a)
+			}
+			b, ok := ParseDebian(test.b)
+			if !ok {
+				t.Fatalf("failed to parse %q", test.b)
+			}
+
+			if got := a.Compare(b); got != test.exp {
+				t.Errorf("unexpected compare of %s to %s, exp=%d got=%d", test.a, test.b, test.exp, got)
+			}
+			if got := b.Compare(a); got != -test.exp {
+				t.Errorf("unexpected compare of %s to %s, exp=%d got=%d", test.b, test.a, -test.exp, got)
+			}
+		})
+	}
+}
+
+func TestLatestDebian(t *testing.T) {
+	pinMajor := int64(1)
+
+	tests := map[string]struct {
+		opts   api.Options
+		tags   []string
+		expTag string
+	}{
+		"epoch should win over higher upstream version": {
+			opts:   api.Options{TagOrdering: api.TagOrderingDebian},
+			tags:   []string{"2.0.0-1", "1:1.0.0-1", "1.5.0-3"},
+			expTag: "1:1.0.0-1",
+		},
+		"highest revision should win": {
+			opts:   api.Options{TagOrdering: api.TagOrderingDebian},
+			tags:   []string{"1.2.3-2", "1.2.3-10", "1.2.3-9", "1.2.3"},
+			expTag: "1.2.3-10",
+		},
+		"pre-releases should sort before release": {
+			opts:   api.Options{TagOrdering: api.TagOrderingDebian},
+			tags:   []string{"1.2.3~rc2-1", "1.2.3-1", "1.2.3~rc1-1"},
+			expTag: "1.2.3-1",
+		},
+		"non-debian tags should be ignored": {
+			opts:   api.Options{TagOrdering: api.TagOrderingDebian},
+			tags:   []string{"latest", "1.2.3-1", "stable"},
+			expTag: "1.2.3-1",
+		},
+		"pins should apply to upstream version": {
+			opts:   api.Options{TagOrdering: api.TagOrderingDebian, PinMajor: &pinMajor},
+			tags:   []string{"1.2.3-1", "1.3.0-2", "2.0.0-1"},
+			expTag: "1.3.0-2",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var tags []api.ImageTag
+			for _, tag := range test.tags {
+				tags = append(tags, api.ImageTag{Tag: tag})
+			}
+
+			latest, err := latestDebian(&test.opts, tags)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if latest.Tag != test.expTag {
+				t.Errorf("unexpected latest tag, exp=%s got=%s", test.expTag, latest.Tag)
+			}
+		})
+	}
+}
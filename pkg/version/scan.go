@@ -0,0 +1,139 @@
+package version
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+const (
+	// scanWorkers is the number of images scanned concurrently by
+	// ScanImages.
+	scanWorkers = 5
+)
+
+// ScanOptions configures a batch scan of images.
+type ScanOptions struct {
+	// EnrichmentConcurrency limits the number of concurrent enrichment
+	// requests, such as per tag manifest fetches, across all images of the
+	// batch. Zero is unlimited.
+	EnrichmentConcurrency int
+}
+
+// lastResult is the last latest tag found for a search, and when it expires.
+type lastResult struct {
+	tag     *api.ImageTag
+	expires time.Time
+}
+
+// ScanImage is an image URL, and the options to find its latest tag with.
+type ScanImage struct {
+	ImageURL string
+	Options  *api.Options
+}
+
+// ScanResult is the latest tag found for a scanned image.
+type ScanResult struct {
+	ScanImage
+
+	LatestTag *api.ImageTag
+
+	// Stale is true if the scan could not complete before the deadline, and
+	// LatestTag is the last result found for the image instead.
+	Stale bool
+
+	// Err is set if the scan failed and there is no previous result to fall
+	// back to.
+	Err error
+}
+
+// ScanImages will find the latest tag of each image, returning results in
+// the same order. Once the context is done, images which have not completed
+// scanning fall back to their last result, marked as Stale, rather than
+// failing.
+func (v *VersionGetter) ScanImages(ctx context.Context, images []ScanImage, opts ScanOptions) []ScanResult {
+	ctx = util.WithEnrichmentLimit(ctx, opts.EnrichmentConcurrency)
+	v.pruneLastResults()
+
+	results := make([]ScanResult, len(images))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < scanWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = v.scanImage(ctx, images[i])
+			}
+		}()
+	}
+
+	for i := range images {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func (v *VersionGetter) scanImage(ctx context.Context, image ScanImage) ScanResult {
+	result := ScanResult{ScanImage: image}
+
+	hashIndex, err := CalculateHashIndex(image.ImageURL, image.Options)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// Don't start a scan which can't complete.
+	if ctx.Err() == nil {
+		result.LatestTag, result.Err = v.LatestTagFromImage(ctx, image.Options, image.ImageURL)
+		if result.Err == nil {
+			v.lastResultsMu.Lock()
+			v.lastResults[hashIndex] = lastResult{
+				tag:     result.LatestTag,
+				expires: time.Now().Add(v.contextCacheTimeout(ctx)),
+			}
+			v.lastResultsMu.Unlock()
+			return result
+		}
+
+		if ctx.Err() == nil {
+			return result
+		}
+	} else {
+		result.Err = ctx.Err()
+	}
+
+	v.lastResultsMu.RLock()
+	last, ok := v.lastResults[hashIndex]
+	v.lastResultsMu.RUnlock()
+
+	if ok && time.Now().Before(last.expires) {
+		v.log.Debugf("scan of %q did not complete in time, using last result: %s",
+			image.ImageURL, result.Err)
+		result.LatestTag, result.Stale, result.Err = last.tag, true, nil
+	}
+
+	return result
+}
+
+// pruneLastResults will delete the last results which have expired, such as
+// of images no longer scanned.
+func (v *VersionGetter) pruneLastResults() {
+	now := time.Now()
+
+	v.lastResultsMu.Lock()
+	defer v.lastResultsMu.Unlock()
+
+	for hashIndex, last := range v.lastResults {
+		if !now.Before(last.expires) {
+			delete(v.lastResults, hashIndex)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package version
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/cache"
+	"github.com/jetstack/version-checker/pkg/client/util"
+)
+
+// slowTagLister returns tags for an image, blocking until the context is done
+// for images marked as slow.
+type slowTagLister struct {
+	mu   sync.Mutex
+	slow map[string]bool
+	tags map[string][]api.ImageTag
+}
+
+func (s *slowTagLister) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	s.mu.Lock()
+	slow, tags := s.slow[imageURL], s.tags[imageURL]
+	s.mu.Unlock()
+
+	if slow {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return tags, nil
+}
+
+func TestScanImagesDeadlineFallback(t *testing.T) {
+	lister := &slowTagLister{
+		slow: make(map[string]bool),
+		tags: map[string][]api.ImageTag{
+			"quay.io/jetstack/cached": {{Tag: "v0.1.0"}},
+			"quay.io/jetstack/fast":   {{Tag: "v0.2.0"}},
+			"quay.io/jetstack/cold":   {{Tag: "v0.3.0"}},
+		},
+	}
+
+	v := &VersionGetter{
+		log:          logrus.NewEntry(logrus.New()),
+		client:       lister,
+		cache:        cache.NewMemory(),
+		cacheTimeout: time.Minute,
+		lastResults:  make(map[string]lastResult),
+	}
+
+	cached := ScanImage{ImageURL: "quay.io/jetstack/cached", Options: new(api.Options)}
+	fast := ScanImage{ImageURL: "quay.io/jetstack/fast", Options: new(api.Options)}
+	cold := ScanImage{ImageURL: "quay.io/jetstack/cold", Options: new(api.Options)}
+
+	// Warm the cache of the first image.
+	for _, result := range v.ScanImages(context.TODO(), []ScanImage{cached}, ScanOptions{}) {
+		if result.Err != nil || result.Stale {
+			t.Fatalf("unexpected warm up result: %+v", result)
+		}
+	}
+
+	// Drop the cached tags, so that the image is listed again.
+	v.cache = cache.NewMemory()
+
+	lister.mu.Lock()
+	lister.slow[cached.ImageURL] = true
+	lister.slow[cold.ImageURL] = true
+	lister.tags[cached.ImageURL] = []api.ImageTag{{Tag: "v0.1.1"}}
+	lister.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*50)
+	defer cancel()
+
+	results := v.ScanImages(ctx, []ScanImage{cached, fast, cold}, ScanOptions{})
+	if len(results) != 3 {
+		t.Fatalf("expected a result per image, exp=3 got=%d", len(results))
+	}
+
+	if r := results[0]; r.Err != nil || !r.Stale || r.LatestTag == nil || r.LatestTag.Tag != "v0.1.0" {
+		t.Errorf("expected cached image to fall back to the last result, got=%+v", r)
+	}
+	if r := results[1]; r.Err != nil || r.Stale || r.LatestTag == nil || r.LatestTag.Tag != "v0.2.0" {
+		t.Errorf("expected fast image to be scanned, got=%+v", r)
+	}
+	if r := results[2]; r.Err == nil || r.Stale || r.LatestTag != nil {
+		t.Errorf("expected image without a last result to fail, got=%+v", r)
+	}
+
+	// Expire the last results, as once the cache timeout has passed.
+	v.lastResultsMu.Lock()
+	for hashIndex, last := range v.lastResults {
+		last.expires = time.Now().Add(-time.Second)
+		v.lastResults[hashIndex] = last
+	}
+	v.lastResultsMu.Unlock()
+
+	ctx, cancel = context.WithTimeout(context.TODO(), time.Millisecond*50)
+	defer cancel()
+
+	if r := v.ScanImages(ctx, []ScanImage{cached}, ScanOptions{})[0]; r.Err == nil || r.Stale {
+		t.Errorf("expected image with an expired last result to fail, got=%+v", r)
+	}
+	v.lastResultsMu.RLock()
+	if len(v.lastResults) != 0 {
+		t.Errorf("expected expired last results to be pruned, got=%+v", v.lastResults)
+	}
+	v.lastResultsMu.RUnlock()
+}
+
+// enrichingTagLister enriches each of its tags concurrently, recording the
+// peak number of concurrent enrichment requests across all images.
+type enrichingTagLister struct {
+	mu             sync.Mutex
+	inFlight, peak int
+}
+
+func (e *enrichingTagLister) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	tags := make([]api.ImageTag, 4)
+
+	var wg sync.WaitGroup
+	for i := range tags {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			release, err := util.AcquireEnrichment(ctx)
+			if err != nil {
+				return
+			}
+			defer release()
+
+			e.mu.Lock()
+			e.inFlight++
+			if e.inFlight > e.peak {
+				e.peak = e.inFlight
+			}
+			e.mu.Unlock()
+
+			time.Sleep(time.Millisecond * 5)
+			tags[i] = api.ImageTag{Tag: fmt.Sprintf("v0.%d.0", i)}
+
+			e.mu.Lock()
+			e.inFlight--
+			e.mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	return tags, nil
+}
+
+func TestScanImagesEnrichmentConcurrency(t *testing.T) {
+	lister := new(enrichingTagLister)
+	v := &VersionGetter{
+		log:         logrus.NewEntry(logrus.New()),
+		client:      lister,
+		cache:       cache.NewMemory(),
+		lastResults: make(map[string]lastResult),
+	}
+
+	var images []ScanImage
+	for i := 0; i < 10; i++ {
+		images = append(images, ScanImage{
+			ImageURL: fmt.Sprintf("quay.io/jetstack/app-%d", i),
+			Options:  new(api.Options),
+		})
+	}
+
+	for _, result := range v.ScanImages(context.TODO(), images, ScanOptions{EnrichmentConcurrency: 3}) {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	if lister.peak > 3 {
+		t.Errorf("expected concurrent enrichment to never exceed the limit, exp<=3 got=%d", lister.peak)
+	}
+	if lister.peak == 0 {
+		t.Error("expected enrichment requests to be made")
+	}
+}
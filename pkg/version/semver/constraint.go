@@ -0,0 +1,206 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	comparatorRegex = regexp.MustCompile(`^(\^|~|>=|<=|!=|=|>|<)?v?(\d+|[xX*])(?:\.(\d+|[xX*]))?(?:\.(\d+|[xX*]))?$`)
+	operatorRegex   = regexp.MustCompile(`(\^|~|>=|<=|!=|=|>|<)\s+`)
+)
+
+// Constraint is a range expression which versions are checked against, such
+// as ">=1.4 <2.0", "^1.4" or "1.2.x || 1.4.x". Comparators separated by
+// whitespace or commas must all be satisfied, and ranges separated by "||"
+// are alternatives. Missing or wildcard parts of a version match any value.
+type Constraint struct {
+	original string
+
+	// ranges are alternative ranges, each holding comparators which must all
+	// be satisfied.
+	ranges [][]comparator
+}
+
+// comparator compares a version against a bound using an operator.
+type comparator struct {
+	op    string
+	bound *SemVer
+}
+
+// ParseConstraint will parse the range expression into a Constraint.
+func ParseConstraint(expr string) (*Constraint, error) {
+	c := &Constraint{original: expr}
+
+	for _, rng := range strings.Split(expr, "||") {
+		var comparators []comparator
+		for _, field := range strings.FieldsFunc(normaliseOperators(rng), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		}) {
+			cmps, err := parseComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, cmps...)
+		}
+
+		if len(comparators) == 0 {
+			return nil, fmt.Errorf("empty range in constraint %q", expr)
+		}
+
+		c.ranges = append(c.ranges, comparators)
+	}
+
+	return c, nil
+}
+
+// Check returns true if the version satisfies the constraint. Tags which are
+// not versions never satisfy a constraint.
+func (c *Constraint) Check(v *SemVer) bool {
+	if !v.IsVersion() {
+		return false
+	}
+
+	for _, comparators := range c.ranges {
+		satisfied := true
+		for _, cmp := range comparators {
+			if !cmp.check(v) {
+				satisfied = false
+				break
+			}
+		}
+
+		if satisfied {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Constraint) String() string {
+	return c.original
+}
+
+func (c comparator) check(v *SemVer) bool {
+	switch c.op {
+	case ">":
+		return c.bound.LessThan(v)
+	case ">=":
+		return !v.LessThan(c.bound)
+	case "<":
+		return v.LessThan(c.bound)
+	case "<=":
+		return !c.bound.LessThan(v)
+	case "!=":
+		return v.LessThan(c.bound) || c.bound.LessThan(v)
+	default:
+		return !v.LessThan(c.bound) && !c.bound.LessThan(v)
+	}
+}
+
+// normaliseOperators removes whitespace between operators and their version,
+// so that ">= 1.4" is read as a single comparator.
+func normaliseOperators(rng string) string {
+	return operatorRegex.ReplaceAllString(rng, "$1")
+}
+
+// parseComparator will parse a single comparator into the comparators it
+// expands to. Partial versions, wildcards, carets and tildes expand to a
+// lower and upper bound.
+func parseComparator(field string) ([]comparator, error) {
+	match := comparatorRegex.FindStringSubmatch(field)
+	if len(match) == 0 {
+		return nil, fmt.Errorf("invalid comparator %q", field)
+	}
+
+	op := match[1]
+
+	// parts holds the version numbers given, up to the first wildcard.
+	var parts []int64
+	for _, part := range match[2:] {
+		if len(part) == 0 || part == "x" || part == "X" || part == "*" {
+			break
+		}
+
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid comparator %q: %s", field, err)
+		}
+		parts = append(parts, n)
+	}
+
+	lower := version(parts)
+
+	// Wildcards match any version.
+	if len(parts) == 0 {
+		return []comparator{{">=", lower}}, nil
+	}
+
+	switch op {
+	case "^":
+		// The left most non-zero part may not change.
+		upper := make([]int64, len(parts))
+		copy(upper, parts)
+		i := 0
+		for i < len(upper)-1 && upper[i] == 0 {
+			i++
+		}
+		return []comparator{{">=", lower}, {"<", version(increment(upper[:i+1]))}}, nil
+
+	case "~":
+		// The minor version may not change if given, else the major.
+		keep := len(parts)
+		if keep > 2 {
+			keep = 2
+		}
+		return []comparator{{">=", lower}, {"<", version(increment(parts[:keep]))}}, nil
+	}
+
+	// Full versions are compared as is.
+	if len(parts) == 3 {
+		return []comparator{{op, lower}}, nil
+	}
+
+	// Partial versions match any value of the missing parts.
+	upper := version(increment(parts))
+	switch op {
+	case ">":
+		return []comparator{{">=", upper}}, nil
+	case ">=":
+		return []comparator{{">=", lower}}, nil
+	case "<":
+		return []comparator{{"<", lower}}, nil
+	case "<=":
+		return []comparator{{"<", upper}}, nil
+	case "!=":
+		return nil, fmt.Errorf("invalid comparator %q: %q requires a full version", field, op)
+	default:
+		return []comparator{{">=", lower}, {"<", upper}}, nil
+	}
+}
+
+// increment returns the version parts with the last part incremented.
+func increment(parts []int64) []int64 {
+	incremented := make([]int64, len(parts))
+	copy(incremented, parts)
+	incremented[len(incremented)-1]++
+	return incremented
+}
+
+// version returns the SemVer of the version parts, with missing parts as
+// zero.
+func version(parts []int64) *SemVer {
+	s := &SemVer{isVersion: true}
+	copy(s.version[:], parts)
+
+	strs := make([]string, 3)
+	for i := range strs {
+		strs[i] = strconv.FormatInt(s.version[i], 10)
+	}
+	s.original = strings.Join(strs, ".")
+
+	return s
+}
@@ -0,0 +1,109 @@
+package semver
+
+import (
+	"testing"
+)
+
+func TestConstraint(t *testing.T) {
+	tests := map[string]struct {
+		expr     string
+		expMatch []string
+		expNot   []string
+		expErr   bool
+	}{
+		"range should match between the bounds": {
+			expr:     ">=1.4 <2.0",
+			expMatch: []string{"1.4.0", "v1.4.1", "1.9.9", "1.5"},
+			expNot:   []string{"1.3.9", "2.0.0", "2.1.0"},
+		},
+		"comma separated range should match between the bounds": {
+			expr:     ">= 1.4, < 2.0",
+			expMatch: []string{"1.4.0", "1.9.9"},
+			expNot:   []string{"1.3.9", "2.0.0"},
+		},
+		"caret should not change the major version": {
+			expr:     "^1.4",
+			expMatch: []string{"1.4.0", "1.99.0"},
+			expNot:   []string{"1.3.0", "2.0.0"},
+		},
+		"caret of zero major should not change the minor version": {
+			expr:     "^0.4.2",
+			expMatch: []string{"0.4.2", "0.4.9"},
+			expNot:   []string{"0.4.1", "0.5.0", "1.0.0"},
+		},
+		"tilde should not change the minor version": {
+			expr:     "~1.4.2",
+			expMatch: []string{"1.4.2", "1.4.9"},
+			expNot:   []string{"1.4.1", "1.5.0"},
+		},
+		"wildcard should match any patch": {
+			expr:     "1.4.x",
+			expMatch: []string{"1.4.0", "1.4.7"},
+			expNot:   []string{"1.3.9", "1.5.0"},
+		},
+		"partial greater than should exclude the whole minor": {
+			expr:     ">1.4",
+			expMatch: []string{"1.5.0", "2.0.0"},
+			expNot:   []string{"1.4.0", "1.4.9"},
+		},
+		"partial less than or equal should include the whole minor": {
+			expr:     "<=1.4",
+			expMatch: []string{"1.4.9", "1.0.0"},
+			expNot:   []string{"1.5.0"},
+		},
+		"not equal should exclude the version": {
+			expr:     "^1.4 !=1.4.3",
+			expMatch: []string{"1.4.2", "1.4.4"},
+			expNot:   []string{"1.4.3"},
+		},
+		"alternatives should match either range": {
+			expr:     "1.2.x || >=1.6 <1.8",
+			expMatch: []string{"1.2.5", "1.6.0", "1.7.9"},
+			expNot:   []string{"1.3.0", "1.8.0"},
+		},
+		"pre-releases should sort before their version": {
+			expr:     ">=1.4.0",
+			expMatch: []string{"1.4.1-rc.0"},
+			expNot:   []string{"1.4.0-rc.0"},
+		},
+		"non versions should not match": {
+			expr:   "*",
+			expNot: []string{"latest", "main"},
+		},
+		"invalid comparator should error": {
+			expr:   ">=one",
+			expErr: true,
+		},
+		"empty range should error": {
+			expr:   "^1.4 ||",
+			expErr: true,
+		},
+		"partial not equal should error": {
+			expr:   "!=1.4",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := ParseConstraint(test.expr)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			for _, tag := range test.expMatch {
+				if !c.Check(Parse(tag)) {
+					t.Errorf("expected %q to satisfy %q", tag, test.expr)
+				}
+			}
+			for _, tag := range test.expNot {
+				if c.Check(Parse(tag)) {
+					t.Errorf("expected %q to not satisfy %q", tag, test.expr)
+				}
+			}
+		})
+	}
+}
@@ -21,6 +21,9 @@ type SemVer struct {
 
 	// original holds the origin string of the tag
 	original string
+
+	// isVersion is true if the tag contains a version number
+	isVersion bool
 }
 
 func Parse(tag string) *SemVer {
@@ -35,6 +38,7 @@ func Parse(tag string) *SemVer {
 		return s
 	}
 
+	s.isVersion = true
 	for i := 0; i < 3; i++ {
 		if len(match[i+1]) > 0 {
 			s.version[i], _ = strconv.ParseInt(strings.TrimPrefix(match[i+1], "."), 10, 64)
@@ -97,6 +101,33 @@ func (s *SemVer) HasMetaData() bool {
 	return len(s.metadata) > 0
 }
 
+// MetaData returns the metadata of this SemVer, which is anything after the
+// patch digit.
+func (s *SemVer) MetaData() string {
+	return s.metadata
+}
+
+// PreRelease returns the pre-release identifier of this SemVer, which is the
+// leading letters of its metadata, lower cased. e.g. "rc" of v1.0.1-rc.2, or
+// "alpha" of v1.0.1-alpha0. Returns empty if there is no such identifier.
+func (s *SemVer) PreRelease() string {
+	metadata := strings.TrimLeft(s.metadata, "-.+_~")
+
+	i := 0
+	for i < len(metadata) && ((metadata[i] >= 'a' && metadata[i] <= 'z') ||
+		(metadata[i] >= 'A' && metadata[i] <= 'Z')) {
+		i++
+	}
+
+	return strings.ToLower(metadata[:i])
+}
+
+// IsVersion returns whether this SemVer contains a version number. Tags such
+// as "latest" are not versions.
+func (s *SemVer) IsVersion() bool {
+	return s.isVersion
+}
+
 // Major returns the major version of this SemVer.
 func (s *SemVer) Major() int64 {
 	return s.version[0]
@@ -220,3 +220,26 @@ func TestLessThan(t *testing.T) {
 		})
 	}
 }
+
+func TestPreRelease(t *testing.T) {
+	tests := map[string]string{
+		"v1.0.1":            "",
+		"v1.0.1-rc.2":       "rc",
+		"1.0.1-RC2":         "rc",
+		"v1.0.1-alpha0":     "alpha",
+		"1.0.1~beta1":       "beta",
+		"1.0.1-debian-r3":   "debian",
+		"1.0.1-0.3.7":       "",
+		"v1.2.3.4":          "",
+		"latest":            "latest",
+		"v1.0.0-beta.1-gke": "beta",
+	}
+
+	for tag, exp := range tests {
+		t.Run(tag, func(t *testing.T) {
+			if got := Parse(tag).PreRelease(); got != exp {
+				t.Errorf("unexpected pre-release, exp=%q got=%q", exp, got)
+			}
+		})
+	}
+}
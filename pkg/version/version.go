@@ -10,34 +10,47 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/jetstack/version-checker/pkg/api"
-	"github.com/jetstack/version-checker/pkg/client"
+	"github.com/jetstack/version-checker/pkg/cache"
 	"github.com/jetstack/version-checker/pkg/version/semver"
 )
 
+// TagLister lists the available tags of an image URL. Satisfied by
+// client.Client.
+type TagLister interface {
+	Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error)
+}
+
 type VersionGetter struct {
 	log *logrus.Entry
 
-	client *client.Client
+	client TagLister
 
-	// cacheTimeout is the amount of time a imageCache item is considered fresh
-	// for.
+	// cacheTimeout is the amount of time the tags of an image are considered
+	// fresh for.
 	cacheTimeout time.Duration
-	cacheMu      sync.RWMutex
-	imageCache   map[string]imageCacheItem
+	cache        cache.Cache
+
+	// lastResults holds the last latest tag found for each search, by hash
+	// index, to fall back to when a scan can't complete in time. Results
+	// expire with the cache timeout of their image's tags.
+	lastResultsMu sync.RWMutex
+	lastResults   map[string]lastResult
 }
 
-func New(log *logrus.Entry, client *client.Client, cacheTimeout time.Duration) *VersionGetter {
-	vg := &VersionGetter{
+// New returns a VersionGetter caching the tags of images in tagCache. If
+// tagCache is nil, tags are cached in memory.
+func New(log *logrus.Entry, client TagLister, cacheTimeout time.Duration, tagCache cache.Cache) *VersionGetter {
+	if tagCache == nil {
+		tagCache = cache.NewMemory()
+	}
+
+	return &VersionGetter{
 		log:          log.WithField("module", "version_getter"),
 		client:       client,
-		imageCache:   make(map[string]imageCacheItem),
+		cache:        tagCache,
 		cacheTimeout: cacheTimeout,
+		lastResults:  make(map[string]lastResult),
 	}
-
-	// Start garbage collector
-	go vg.garbageCollect(cacheTimeout / 2)
-
-	return vg
 }
 
 // LatestTagFromOImage will return the latest tag given an imageURL, according
@@ -48,20 +61,99 @@ func (v *VersionGetter) LatestTagFromImage(ctx context.Context, opts *api.Option
 		return nil, err
 	}
 
+	if opts.Platform != nil || opts.ExcludeMatcher != nil ||
+		len(opts.AllowTags) > 0 || len(opts.DenyTags) > 0 {
+		tags = filterTags(opts, tags)
+	}
+
 	// If UseSHA then return early
 	if opts.UseSHA {
 		return latestSHA(tags)
 	}
 
+	switch opts.TagOrdering {
+	case api.TagOrderingDebian:
+		return latestDebian(opts, tags)
+	case api.TagOrderingCalVer:
+		return latestCalVer(opts, tags)
+	case api.TagOrderingNumeric, api.TagOrderingLexical:
+		return latestBuildID(opts, tags)
+	}
+
 	return latestSemver(opts, tags)
 }
 
+// IsLatest returns true if the current tag is not older than the latest image,
+// along with the latest tag compared against. Digests are compared if UseSHA
+// is set, otherwise tags are compared by the tag ordering of the options.
+// Tags which can't be ordered are only the latest if equal.
+func IsLatest(opts *api.Options, currentTag string, latestImage *api.ImageTag) (bool, string) {
+	if opts.UseSHA {
+		return currentTag == latestImage.SHA, latestImage.SHA
+	}
+
+	c, ok := CompareTags(opts, currentTag, latestImage.Tag)
+	if !ok {
+		return currentTag == latestImage.Tag, latestImage.Tag
+	}
+
+	return c >= 0, latestImage.Tag
+}
+
+// CompareTags returns -1, 0 or 1 if tag a is older than, the same version as,
+// or newer than tag b, by the tag ordering of the options that the latest tag
+// is selected with. Returns false if either tag has no version in the
+// ordering, such as a build ID ordered tag not matching the build ID regex.
+func CompareTags(opts *api.Options, a, b string) (int, bool) {
+	switch opts.TagOrdering {
+	case api.TagOrderingDebian:
+		av, aok := ParseDebian(a)
+		bv, bok := ParseDebian(b)
+		if !aok || !bok {
+			return 0, false
+		}
+		return av.Compare(bv), true
+
+	case api.TagOrderingCalVer:
+		av, aok := ParseCalVer(a)
+		bv, bok := ParseCalVer(b)
+		if !aok || !bok {
+			return 0, false
+		}
+		return av.Compare(bv), true
+
+	case api.TagOrderingNumeric, api.TagOrderingLexical:
+		aid, aok := buildID(opts, a)
+		bid, bok := buildID(opts, b)
+		if !aok || !bok {
+			return 0, false
+		}
+		return compareBuildID(opts.TagOrdering, aid, bid), true
+	}
+
+	av, bv := semver.Parse(a), semver.Parse(b)
+	switch {
+	case av.LessThan(bv):
+		return -1, true
+	case bv.LessThan(av):
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// Tags returns the available tags of the image URL, from the cache if fresh,
+// so that other lookups of tags share the cache of image checks.
+func (v *VersionGetter) Tags(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
+	return v.allTagsFromImage(ctx, imageURL)
+}
+
 // allTagsFromImage will return all available tags from the remote repository
 // given an imageURL. It also holds a cache for each imageURL that is
 // periodically garbage collected.
 func (v *VersionGetter) allTagsFromImage(ctx context.Context, imageURL string) ([]api.ImageTag, error) {
 	// Check for cache hit
-	if tags, ok := v.tryImageCache(imageURL); ok {
+	if tags, ok := v.tryImageCache(ctx, imageURL); ok {
 		return tags, nil
 	}
 
@@ -76,23 +168,31 @@ func (v *VersionGetter) allTagsFromImage(ctx context.Context, imageURL string) (
 		return nil, fmt.Errorf("no tags found for given image URL: %q", imageURL)
 	}
 
-	v.log.Debugf("committing image tags: %q", imageURL)
+	v.commitImageCache(ctx, imageURL, tags)
 
-	// Add tags to cache
-	v.cacheMu.Lock()
-	defer v.cacheMu.Unlock()
-	v.imageCache[imageURL] = imageCacheItem{
-		timestamp: time.Now(),
-		tags:      tags,
+	return tags, nil
+}
+
+// filterTags returns the tags with images built for the platform of the
+// options, so that the latest digest of a multi-arch image is that of the
+// platform, and which are allowed and not excluded.
+func filterTags(opts *api.Options, tags []api.ImageTag) []api.ImageTag {
+	var matched []api.ImageTag
+	for i := range tags {
+		if opts.MatchesPlatform(&tags[i]) && !opts.Excludes(tags[i].Tag) &&
+			opts.TagAllowed(tags[i].Tag) {
+			matched = append(matched, tags[i])
+		}
 	}
 
-	return tags, nil
+	return matched
 }
 
 // latestSemver will return the latest ImageTag based on the given options
 // restriction, using semver. This should not be used is UseSHA has been
 // enabled.
-// TODO: add tests..
+// Tags are ordered by semver, then timestamp, then digest so that the same tag
+// is always selected between tags of equal version, such as aliases.
 func latestSemver(opts *api.Options, tags []api.ImageTag) (*api.ImageTag, error) {
 	var (
 		latestImageTag *api.ImageTag
@@ -100,36 +200,12 @@ func latestSemver(opts *api.Options, tags []api.ImageTag) (*api.ImageTag, error)
 	)
 
 	for i := range tags {
-		v := semver.Parse(tags[i].Tag)
-
-		// If regex enabled continue here.
-		// If we match, and is less than, update latest.
-		if opts.RegexMatcher != nil {
-			if opts.RegexMatcher.MatchString(tags[i].Tag) &&
-				(latestV == nil || latestV.LessThan(v)) {
-				latestV = v
-				latestImageTag = &tags[i]
-			}
-
-			continue
-		}
-
-		// If we have declared we wont use metadata but version has it, continue.
-		if !opts.UseMetaData && v.HasMetaData() {
-			continue
-		}
-
-		if opts.PinMajor != nil && *opts.PinMajor != v.Major() {
-			continue
-		}
-		if opts.PinMinor != nil && *opts.PinMinor != v.Minor() {
-			continue
-		}
-		if opts.PinPatch != nil && *opts.PinPatch != v.Patch() {
+		if !opts.Matches(tags[i].Tag) {
 			continue
 		}
 
-		if latestV == nil || latestV.LessThan(v) {
+		v := semver.Parse(tags[i].Tag)
+		if latestV == nil || isNewer(latestImageTag, latestV, &tags[i], v) {
 			latestV = v
 			latestImageTag = &tags[i]
 		}
@@ -142,12 +218,13 @@ func latestSemver(opts *api.Options, tags []api.ImageTag) (*api.ImageTag, error)
 	return latestImageTag, nil
 }
 
-// latestSHA will return the latest ImageTag based on image timestamps.
+// latestSHA will return the latest ImageTag based on image timestamps. Tags
+// with equal timestamps are ordered by digest.
 func latestSHA(tags []api.ImageTag) (*api.ImageTag, error) {
 	var latestTag *api.ImageTag
 
 	for i := range tags {
-		if latestTag == nil || tags[i].Timestamp.After(latestTag.Timestamp) {
+		if latestTag == nil || isNewerTimestamp(latestTag, &tags[i]) {
 			latestTag = &tags[i]
 		}
 	}
@@ -158,3 +235,32 @@ func latestSHA(tags []api.ImageTag) (*api.ImageTag, error) {
 
 	return latestTag, nil
 }
+
+// isNewer returns true if the candidate tag should be selected over the
+// current latest tag. Tags are compared by semver, then timestamp, then
+// digest.
+func isNewer(latest *api.ImageTag, latestV *semver.SemVer, candidate *api.ImageTag, candidateV *semver.SemVer) bool {
+	if latestV.LessThan(candidateV) {
+		return true
+	}
+	if candidateV.LessThan(latestV) {
+		return false
+	}
+
+	return isNewerTimestamp(latest, candidate)
+}
+
+// isNewerTimestamp returns true if the candidate tag has a later timestamp
+// than the latest tag. Equal timestamps are tie-broken by choosing the
+// lexically greatest digest, then tag.
+func isNewerTimestamp(latest, candidate *api.ImageTag) bool {
+	if !candidate.Timestamp.Equal(latest.Timestamp) {
+		return candidate.Timestamp.After(latest.Timestamp)
+	}
+
+	if candidate.SHA != latest.SHA {
+		return candidate.SHA > latest.SHA
+	}
+
+	return candidate.Tag > latest.Tag
+}
@@ -0,0 +1,381 @@
+package version
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jetstack/version-checker/pkg/api"
+	"github.com/jetstack/version-checker/pkg/version/semver"
+)
+
+func TestLatestSemverTieBreak(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		tags   []api.ImageTag
+		expTag string
+		expSHA string
+	}{
+		"higher semver should win over newer timestamp": {
+			tags: []api.ImageTag{
+				{Tag: "v1.2.0", SHA: "sha256:b", Timestamp: now},
+				{Tag: "v1.3.0", SHA: "sha256:a", Timestamp: now.Add(-time.Hour)},
+			},
+			expTag: "v1.3.0",
+			expSHA: "sha256:a",
+		},
+		"aliased tags should be tie-broken by newest timestamp": {
+			tags: []api.ImageTag{
+				{Tag: "v1.3.0", SHA: "sha256:a", Timestamp: now.Add(-time.Hour)},
+				{Tag: "1.3.0", SHA: "sha256:b", Timestamp: now},
+			},
+			expTag: "1.3.0",
+			expSHA: "sha256:b",
+		},
+		"aliased tags with same timestamp should be tie-broken by digest": {
+			tags: []api.ImageTag{
+				{Tag: "v1.3.0", SHA: "sha256:aaa", Timestamp: now},
+				{Tag: "1.3.0", SHA: "sha256:ccc", Timestamp: now},
+				{Tag: "1.3", SHA: "sha256:bbb", Timestamp: now},
+			},
+			expTag: "1.3.0",
+			expSHA: "sha256:ccc",
+		},
+		"aliased tags with same timestamp and digest should be tie-broken by tag": {
+			tags: []api.ImageTag{
+				{Tag: "1.3.0", SHA: "sha256:aaa", Timestamp: now},
+				{Tag: "v1.3.0", SHA: "sha256:aaa", Timestamp: now},
+			},
+			expTag: "v1.3.0",
+			expSHA: "sha256:aaa",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Selection should be stable regardless of tag order.
+			for i := 0; i < 20; i++ {
+				tags := make([]api.ImageTag, len(test.tags))
+				copy(tags, test.tags)
+				rand.Shuffle(len(tags), func(i, j int) { tags[i], tags[j] = tags[j], tags[i] })
+
+				latest, err := latestSemver(new(api.Options), tags)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if latest.Tag != test.expTag || latest.SHA != test.expSHA {
+					t.Fatalf("unexpected latest tag, exp=%s@%s got=%s@%s",
+						test.expTag, test.expSHA, latest.Tag, latest.SHA)
+				}
+			}
+		})
+	}
+}
+
+func TestLatestSHATieBreak(t *testing.T) {
+	now := time.Now()
+	tags := []api.ImageTag{
+		{SHA: "sha256:aaa", Timestamp: now},
+		{SHA: "sha256:ccc", Timestamp: now},
+		{SHA: "sha256:bbb", Timestamp: now},
+		{SHA: "sha256:ddd", Timestamp: now.Add(-time.Hour)},
+	}
+
+	for i := 0; i < 20; i++ {
+		rand.Shuffle(len(tags), func(i, j int) { tags[i], tags[j] = tags[j], tags[i] })
+
+		latest, err := latestSHA(tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if latest.SHA != "sha256:ccc" {
+			t.Fatalf("unexpected latest SHA, exp=sha256:ccc got=%s", latest.SHA)
+		}
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	tags := []api.ImageTag{
+		{Tag: "v1.0.0", SHA: "sha256:amd64", OS: "linux", Architecture: "amd64"},
+		{Tag: "v1.0.0", SHA: "sha256:armv7", OS: "linux", Architecture: "arm", Variant: "v7"},
+		{Tag: "v1.0.0", SHA: "sha256:armv6", OS: "linux", Architecture: "arm", Variant: "v6"},
+		{Tag: "v1.1.0", SHA: "sha256:list"},
+		{Tag: "nightly-20200101", SHA: "sha256:nightly"},
+	}
+
+	tests := map[string]struct {
+		platform *api.Platform
+		exclude  string
+		allow    []string
+		deny     []string
+		expSHAs  []string
+	}{
+		"architecture should match tags of the architecture, and without a platform": {
+			platform: &api.Platform{OS: "linux", Architecture: "amd64"},
+			expSHAs:  []string{"sha256:amd64", "sha256:list", "sha256:nightly"},
+		},
+		"unset variant should match every variant": {
+			platform: &api.Platform{OS: "linux", Architecture: "arm"},
+			expSHAs:  []string{"sha256:armv7", "sha256:armv6", "sha256:list", "sha256:nightly"},
+		},
+		"variant should match tags of the variant": {
+			platform: &api.Platform{OS: "linux", Architecture: "arm", Variant: "v6"},
+			expSHAs:  []string{"sha256:armv6", "sha256:list", "sha256:nightly"},
+		},
+		"other os should only match tags without a platform": {
+			platform: &api.Platform{OS: "windows", Architecture: "amd64"},
+			expSHAs:  []string{"sha256:list", "sha256:nightly"},
+		},
+		"excluded tags should be removed": {
+			exclude: "^nightly-|^v1\\.0",
+			expSHAs: []string{"sha256:list"},
+		},
+		"exclusion should apply alongside the platform": {
+			platform: &api.Platform{OS: "linux", Architecture: "amd64"},
+			exclude:  "^nightly-",
+			expSHAs:  []string{"sha256:amd64", "sha256:list"},
+		},
+		"only allowed tags should be kept": {
+			allow:   []string{"v1.1.0", "nightly-20200101"},
+			expSHAs: []string{"sha256:list", "sha256:nightly"},
+		},
+		"denied tags should be removed, even if allowed": {
+			allow:   []string{"v1.1.0", "nightly-20200101"},
+			deny:    []string{"nightly-20200101"},
+			expSHAs: []string{"sha256:list"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := &api.Options{Platform: test.platform, AllowTags: test.allow, DenyTags: test.deny}
+			if len(test.exclude) > 0 {
+				opts.ExcludeRegex = &test.exclude
+				opts.ExcludeMatcher = regexp.MustCompile(test.exclude)
+			}
+
+			var shas []string
+			for _, tag := range filterTags(opts, tags) {
+				shas = append(shas, tag.SHA)
+			}
+
+			if strings.Join(shas, ",") != strings.Join(test.expSHAs, ",") {
+				t.Errorf("unexpected tags, exp=%v got=%v", test.expSHAs, shas)
+			}
+		})
+	}
+}
+
+func TestLatestSemverConstraint(t *testing.T) {
+	tags := []api.ImageTag{
+		{Tag: "1.3.9"}, {Tag: "1.4.0"}, {Tag: "1.4.7"}, {Tag: "1.9.2"},
+		{Tag: "1.10.0-rc.1"}, {Tag: "2.0.0"}, {Tag: "latest"},
+	}
+
+	tests := map[string]struct {
+		constraint string
+		expTag     string
+		expErr     bool
+	}{
+		"range should select the latest within the range": {
+			constraint: ">=1.4 <2.0",
+			expTag:     "1.9.2",
+		},
+		"tilde should select the latest patch": {
+			constraint: "~1.4",
+			expTag:     "1.4.7",
+		},
+		"range without tags should error": {
+			constraint: "^3",
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			matcher, err := semver.ParseConstraint(test.constraint)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			latest, err := latestSemver(&api.Options{
+				SemverConstraint:  &test.constraint,
+				ConstraintMatcher: matcher,
+			}, tags)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if latest.Tag != test.expTag {
+				t.Errorf("unexpected latest tag, exp=%s got=%s", test.expTag, latest.Tag)
+			}
+		})
+	}
+}
+
+func TestLatestSemverChannel(t *testing.T) {
+	tags := []api.ImageTag{
+		{Tag: "1.4.0"}, {Tag: "1.5.0-rc.1"}, {Tag: "1.5.0-rc.2"}, {Tag: "1.4.1"},
+		{Tag: "1.6.0-beta.1"}, {Tag: "1.5.0-rc.10"},
+	}
+
+	tests := map[string]struct {
+		channel string
+		expTag  string
+		expErr  bool
+	}{
+		"rc channel should report the latest rc": {
+			channel: "rc",
+			expTag:  "1.5.0-rc.10",
+		},
+		"beta channel should report the latest beta": {
+			channel: "beta",
+			expTag:  "1.6.0-beta.1",
+		},
+		"stable channel should report the latest release": {
+			channel: api.ChannelStable,
+			expTag:  "1.4.1",
+		},
+		"channel without tags should error": {
+			channel: "alpha",
+			expErr:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			latest, err := latestSemver(&api.Options{Channel: &test.channel}, tags)
+			if (err != nil) != test.expErr {
+				t.Fatalf("unexpected error, exp=%t got=%v", test.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if latest.Tag != test.expTag {
+				t.Errorf("unexpected latest tag, exp=%s got=%s", test.expTag, latest.Tag)
+			}
+		})
+	}
+}
+
+func TestIsLatest(t *testing.T) {
+	latest := &api.ImageTag{Tag: "v1.2.0", SHA: "sha256:b"}
+
+	tests := map[string]struct {
+		opts       *api.Options
+		currentTag string
+		expLatest  bool
+		expTag     string
+	}{
+		"older semver tag should not be latest": {
+			opts:       new(api.Options),
+			currentTag: "v1.1.9",
+			expLatest:  false,
+			expTag:     "v1.2.0",
+		},
+		"same semver tag should be latest": {
+			opts:       new(api.Options),
+			currentTag: "v1.2.0",
+			expLatest:  true,
+			expTag:     "v1.2.0",
+		},
+		"newer semver tag should be latest": {
+			opts:       new(api.Options),
+			currentTag: "v1.3.0",
+			expLatest:  true,
+			expTag:     "v1.2.0",
+		},
+		"different digest should not be latest": {
+			opts:       &api.Options{UseSHA: true},
+			currentTag: "sha256:a",
+			expLatest:  false,
+			expTag:     "sha256:b",
+		},
+		"same digest should be latest": {
+			opts:       &api.Options{UseSHA: true},
+			currentTag: "sha256:b",
+			expLatest:  true,
+			expTag:     "sha256:b",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			isLatest, latestTag := IsLatest(test.opts, test.currentTag, latest)
+			if isLatest != test.expLatest || latestTag != test.expTag {
+				t.Errorf("unexpected result, exp=%t,%s got=%t,%s",
+					test.expLatest, test.expTag, isLatest, latestTag)
+			}
+		})
+	}
+}
+
+func TestIsLatestTagOrdering(t *testing.T) {
+	tests := map[string]struct {
+		opts      *api.Options
+		current   string
+		latest    string
+		expLatest bool
+	}{
+		"semver should compare versions": {
+			opts:    new(api.Options),
+			current: "v1.2.0",
+			latest:  "v1.10.0",
+		},
+		"semver newer than latest should be latest": {
+			opts:      new(api.Options),
+			current:   "v2.1.0",
+			latest:    "v1.9.0",
+			expLatest: true,
+		},
+		"debian should compare epochs": {
+			opts:    &api.Options{TagOrdering: api.TagOrderingDebian},
+			current: "1:2.0-1",
+			latest:  "2:1.0-1",
+		},
+		"debian of higher epoch should be latest": {
+			opts:      &api.Options{TagOrdering: api.TagOrderingDebian},
+			current:   "2:1.0-1",
+			latest:    "1:2.0-1",
+			expLatest: true,
+		},
+		"numeric should compare build numbers": {
+			opts:    &api.Options{TagOrdering: api.TagOrderingNumeric},
+			current: "build-999",
+			latest:  "build-1041",
+		},
+		"lexical should compare tags lexically": {
+			opts:    &api.Options{TagOrdering: api.TagOrderingLexical},
+			current: "2021-03-01",
+			latest:  "2021-11-01",
+		},
+		"calver should compare calendar versions": {
+			opts:    &api.Options{TagOrdering: api.TagOrderingCalVer},
+			current: "2021.9.1",
+			latest:  "2021.10.0",
+		},
+		"tags without a build ID should only be latest if equal": {
+			opts:    &api.Options{TagOrdering: api.TagOrderingNumeric},
+			current: "stable",
+			latest:  "build-1041",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			isLatest, latest := IsLatest(test.opts, test.current, &api.ImageTag{Tag: test.latest})
+			if isLatest != test.expLatest || latest != test.latest {
+				t.Errorf("unexpected result, exp=%t,%q got=%t,%q", test.expLatest, test.latest, isLatest, latest)
+			}
+		})
+	}
+}